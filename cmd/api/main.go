@@ -4,25 +4,26 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"net"
 	"os"
 	"os/signal"
 	"syscall"
-	"time"
-
-	"github.com/gofiber/fiber/v2"
-	"github.com/gofiber/fiber/v2/middleware/cors"
-	"github.com/gofiber/fiber/v2/middleware/logger"
-	"github.com/gofiber/fiber/v2/middleware/recover"
 
 	"alfredoptarigan/cv-evaluator/internal/config"
-	"alfredoptarigan/cv-evaluator/internal/handlers"
-	"alfredoptarigan/cv-evaluator/internal/repositories"
-	"alfredoptarigan/cv-evaluator/internal/services"
+	"alfredoptarigan/cv-evaluator/internal/secrets"
+	"alfredoptarigan/cv-evaluator/internal/server"
 )
 
 func main() {
+	// Mask secrets in everything logged from here on
+	logOutput := secrets.NewWriter(os.Stderr)
+	log.SetOutput(logOutput)
+
 	// Load configuration
 	cfg := config.Load()
+	if err := cfg.Validate(); err != nil {
+		log.Fatalf("❌ %v", err)
+	}
 	log.Println("✅ Config loaded successfully")
 
 	// Initialize database
@@ -31,131 +32,26 @@ func main() {
 		log.Fatalf("❌ Failed to initialize database: %v", err)
 	}
 
-	// Initializes repositories
-	docRepo := repositories.NewDocumentRepository(db)
-	evalRepo := repositories.NewEvaluationRepository(db)
-	log.Println("✅ Repositories initialized successfully")
-
-	// Initialize services
-	storageService := services.NewStorageService(cfg.Storage.UploadPath)
-	if err := storageService.EnsureUploadDir(); err != nil {
-		log.Fatalf("❌ Failed to create upload directory: %v", err)
-	}
-
-	pdfParser := services.NewPDFParserService()
-	log.Println("✅ Services initialized successfully")
-
-	// Initialize Gemini AI
-	geminiService, err := services.NewGeminiService(cfg.Gemini.APIKey)
+	srv, err := server.New(cfg, db)
 	if err != nil {
-		log.Fatalf("❌ Failed to initialize Gemini AI: %v", err)
+		log.Fatalf("❌ %v", err)
 	}
-	log.Println("✅ Gemini AI initialized successfully")
+	srv.Start(context.Background())
 
-	// Initialize Qdrant
-	qdrantService, err := services.NewQdrantService(
-		cfg.Qdrant.URL,
-		cfg.Qdrant.APIKey,
-		cfg.Qdrant.Collection,
-	)
-	if err != nil {
-		log.Fatalf("❌ Failed to initialize Qdrant: %v", err)
-	}
+	// Start gRPC server
+	if srv.GRPC != nil {
+		lis, err := net.Listen("tcp", fmt.Sprintf(":%s", cfg.GRPC.Port))
+		if err != nil {
+			log.Fatalf("❌ Failed to listen for gRPC: %v", err)
+		}
 
-	if err := qdrantService.InitCollection(); err != nil {
-		log.Fatalf("❌ Failed to initialize Qdrant collection: %v", err)
+		go func() {
+			log.Printf("🚀 gRPC server starting on :%s\n", cfg.GRPC.Port)
+			if err := srv.GRPC.Serve(lis); err != nil {
+				log.Printf("❌ gRPC server stopped: %v", err)
+			}
+		}()
 	}
-	log.Println("✅ Qdrant initialized successfully")
-
-	// Initialize evaluator
-	evaluatorService := services.NewEvaluatorService(
-		evalRepo,
-		docRepo,
-		geminiService,
-		qdrantService,
-		pdfParser,
-		cfg.Worker.RetryMaxAttempts,
-	)
-	log.Println("✅ Evaluator service initialized")
-
-	// Initialize worker
-	worker := services.NewWorker(
-		evalRepo,
-		evaluatorService,
-		cfg.Worker.Concurrency,
-	)
-	log.Println("✅ Worker initialized successfully")
-
-	// Start worker
-	ctx := context.Background()
-	worker.Start(ctx)
-	log.Println("✅ Worker started successfully")
-
-	// Initialize Handlers
-	uploadHandler := handlers.NewUploadHandler(
-		docRepo,
-		storageService,
-		cfg.Storage.MaxFileSize,
-	)
-	evaluateHandler := handlers.NewEvaluationHandler(
-		evalRepo,
-		docRepo,
-		worker,
-	)
-
-	resultHandler := handlers.NewResultHandler(evalRepo)
-	log.Println("✅ Handlers initialized")
-
-	// Create Fiber app
-	app := fiber.New(fiber.Config{
-		AppName:      "AI CV Evaluator API",
-		ReadTimeout:  30 * time.Second,
-		WriteTimeout: 30 * time.Second,
-		BodyLimit:    int(cfg.Storage.MaxFileSize),
-		ErrorHandler: customErrorHandler,
-	})
-
-	// Middleware
-	app.Use(recover.New())
-	app.Use(logger.New(logger.Config{
-		Format:     "[${time}] ${status} - ${latency} ${method} ${path}\n",
-		TimeFormat: "2006-01-02 15:04:05",
-	}))
-
-	app.Use(cors.New(cors.Config{
-		AllowOrigins: "*",
-		AllowMethods: "GET,POST,PUT,DELETE,OPTIONS",
-		AllowHeaders: "Origin, Content-Type, Accept, Authorization",
-	}))
-
-	// Routes
-	api := app.Group("/api/v1")
-
-	// Health check
-	api.Get("/health", func(c *fiber.Ctx) error {
-		return c.JSON(fiber.Map{
-			"status": "healthy",
-			"time":   time.Now(),
-		})
-	})
-
-	// API endpoints
-	api.Post("/upload", uploadHandler.HandleUpload)
-	api.Post("/evaluate", evaluateHandler.HandleEvaluate)
-	api.Get("/result/:id", resultHandler.HandleGetResult)
-
-	// Root route
-	app.Get("/", func(c *fiber.Ctx) error {
-		return c.JSON(fiber.Map{
-			"message": "AI CV Evaluator API",
-			"version": "1.0.0",
-			"endpoints": []string{
-				"POST /api/v1/upload",
-				"POST /api/v1/evaluate",
-				"GET /api/v1/result/:id",
-			},
-		})
-	})
 
 	// Graceful shutdown
 	quit := make(chan os.Signal, 1)
@@ -164,10 +60,7 @@ func main() {
 	go func() {
 		<-quit
 		log.Println("\n🛑 Shutting down server...")
-		worker.Stop()
-		if err := app.Shutdown(); err != nil {
-			log.Printf("❌ Server forced to shutdown: %v", err)
-		}
+		srv.Stop()
 	}()
 
 	// Start server
@@ -175,21 +68,8 @@ func main() {
 	log.Printf("🚀 Server starting on %s\n", addr)
 	log.Printf("📖 API Documentation: http://localhost%s\n", addr)
 
-	if err := app.Listen(addr); err != nil {
+	if err := srv.Listen(addr); err != nil {
 		log.Fatalf("❌ Failed to start server: %v", err)
 	}
 
 }
-
-func customErrorHandler(c *fiber.Ctx, err error) error {
-	code := fiber.StatusInternalServerError
-
-	if e, ok := err.(*fiber.Error); ok {
-		code = e.Code
-	}
-
-	return c.Status(code).JSON(fiber.Map{
-		"error": err.Error(),
-		"code":  code,
-	})
-}
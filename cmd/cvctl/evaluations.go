@@ -0,0 +1,161 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/spf13/cobra"
+
+	"alfredoptarigan/cv-evaluator/internal/config"
+	"alfredoptarigan/cv-evaluator/internal/models"
+	"alfredoptarigan/cv-evaluator/internal/repositories"
+)
+
+// maxErrorWidth truncates error messages in the list output.
+const maxErrorWidth = 60
+
+func newEvaluationsCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "evaluations",
+		Aliases: []string{"evals"},
+		Short:   "List, retry and cancel evaluations",
+	}
+	cmd.AddCommand(
+		newListEvaluationsCommand(),
+		newRetryEvaluationsCommand(),
+		newCancelEvaluationsCommand(),
+	)
+	return cmd
+}
+
+func newListEvaluationsCommand() *cobra.Command {
+	var (
+		statuses []string
+		tenantID string
+		limit    int
+	)
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List evaluations, newest first",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			evalRepo, err := openEvaluations()
+			if err != nil {
+				return err
+			}
+
+			filter := repositories.EvaluationFilter{TenantID: tenantID, Limit: limit}
+			for _, status := range statuses {
+				filter.Statuses = append(filter.Statuses, models.EvaluationStatus(status))
+			}
+			evals, err := evalRepo.List(filter)
+			if err != nil {
+				return err
+			}
+
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "ID\tSTATUS\tTENANT\tATTEMPTS\tCREATED\tERROR")
+			for _, eval := range evals {
+				fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%s\t%s\n",
+					eval.ID, eval.Status, eval.TenantID, eval.Attempts,
+					eval.CreatedAt.Local().Format(time.DateTime), truncate(eval.ErrorMessage, maxErrorWidth))
+			}
+			return w.Flush()
+		},
+	}
+	cmd.Flags().StringSliceVar(&statuses, "status", nil, "only these statuses, e.g. failed,expired")
+	cmd.Flags().StringVar(&tenantID, "tenant", "", "only this tenant's evaluations")
+	cmd.Flags().IntVar(&limit, "limit", 50, "maximum number of evaluations (0 = all)")
+	return cmd
+}
+
+func newRetryEvaluationsCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "retry ID...",
+		Short: "Queue failed, expired or cancelled evaluations again",
+		Long: `Puts the evaluations back in the queue; a running API dispatches them.
+Stages that finished before are restored from their checkpoints.`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return forEachEvaluation(args, "🔁 Retried", func(evalRepo repositories.EvaluationRepository, id uuid.UUID) error {
+				return evalRepo.Retry(id)
+			})
+		},
+	}
+}
+
+func newCancelEvaluationsCommand() *cobra.Command {
+	var reason string
+	cmd := &cobra.Command{
+		Use:   "cancel ID...",
+		Short: "Cancel queued or running evaluations",
+		Long: `Cancels the evaluations. A running attempt isn't interrupted, but it can't
+save anything after the cancellation and stops at its next checkpoint.`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return forEachEvaluation(args, "🛑 Cancelled", func(evalRepo repositories.EvaluationRepository, id uuid.UUID) error {
+				return evalRepo.Cancel(id, reason)
+			})
+		},
+	}
+	cmd.Flags().StringVar(&reason, "reason", "cancelled by an operator", "error message shown for the evaluations")
+	return cmd
+}
+
+// forEachEvaluation applies fn to each evaluation ID, going on after
+// failures and returning an error if any failed.
+func forEachEvaluation(args []string, done string, fn func(repositories.EvaluationRepository, uuid.UUID) error) error {
+	ids := make([]uuid.UUID, len(args))
+	for i, arg := range args {
+		id, err := uuid.Parse(arg)
+		if err != nil {
+			return fmt.Errorf("invalid evaluation ID %q", arg)
+		}
+		ids[i] = id
+	}
+
+	evalRepo, err := openEvaluations()
+	if err != nil {
+		return err
+	}
+
+	failed := 0
+	for _, id := range ids {
+		if err := fn(evalRepo, id); err != nil {
+			log.Printf("❌ %s: %v", id, err)
+			failed++
+			continue
+		}
+		log.Printf("%s %s", done, id)
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d evaluations failed", failed, len(ids))
+	}
+	return nil
+}
+
+func openEvaluations() (repositories.EvaluationRepository, error) {
+	cfg, err := loadConfig(false)
+	if err != nil {
+		return nil, err
+	}
+	db, err := config.InitDatabase(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return repositories.NewEvaluationRepository(db), nil
+}
+
+func truncate(s string, width int) string {
+	s = strings.Join(strings.Fields(s), " ")
+	if len([]rune(s)) <= width {
+		return s
+	}
+	return string([]rune(s)[:width-1]) + "…"
+}
@@ -0,0 +1,430 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+
+	"alfredoptarigan/cv-evaluator/internal/config"
+	"alfredoptarigan/cv-evaluator/internal/repositories"
+	"alfredoptarigan/cv-evaluator/internal/services"
+	"alfredoptarigan/cv-evaluator/internal/tenant"
+)
+
+const (
+	defaultReferenceDir = "./reference_docs"
+	// defaultManifest is used instead of scanning defaultReferenceDir when
+	// it exists.
+	defaultManifest = defaultReferenceDir + "/manifest.yaml"
+)
+
+// ingestOptions are the flags shared by ingest and vectors rebuild.
+type ingestOptions struct {
+	manifest string
+	dirs     []string
+	docType  string
+	name     string
+	job      string
+	tenantID string
+	force    bool
+}
+
+func (o *ingestOptions) register(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&o.manifest, "manifest", "", "YAML or JSON file listing the documents with their type, name, job and tenant")
+	cmd.Flags().StringSliceVar(&o.dirs, "dir", nil, "directory with a subdirectory per document type, e.g. <dir>/cv_rubric/*.pdf")
+	cmd.Flags().StringVar(&o.docType, "type", "", "document type of the file arguments: "+strings.Join(services.ReferenceDocTypes, ", ")+" (default: guessed from the file name)")
+	cmd.Flags().StringVar(&o.name, "name", "", "source name shown for the chunks; only with a single file (default: the file name)")
+	cmd.Flags().StringVar(&o.job, "job", "", "job title the documents are for, so evaluations for other jobs don't retrieve them (default: every job)")
+	cmd.Flags().StringVar(&o.tenantID, "tenant", "", "make the documents visible to this tenant only instead of sharing them with every tenant")
+	cmd.Flags().BoolVar(&o.force, "force", false, "ingest files whose content didn't change since the last run too")
+}
+
+func newIngestCommand() *cobra.Command {
+	var opts ingestOptions
+	cmd := &cobra.Command{
+		Use:   "ingest [file|dir|glob]...",
+		Short: "Embed reference PDFs into the vector store",
+		Long: `Parses, chunks and embeds reference PDFs. Arguments are files, directories
+(every PDF in them) or glob patterns; --manifest and --dir add more. Without
+any, ` + defaultManifest + ` is read if it exists, else every PDF in
+` + defaultReferenceDir + `.
+
+Files whose content, type, name and job are unchanged since they were last
+ingested are skipped unless --force is given. A changed file replaces its
+old chunks.
+
+Documents ingested with a job (--job or the manifest's job) are only
+retrieved when evaluating for that job title; the others are retrieved for
+every job.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runIngest(cmd.Context(), args, opts, false)
+		},
+	}
+	opts.register(cmd)
+	return cmd
+}
+
+// ingestItem is a document to ingest and the tenant it is for ("" =
+// shared).
+type ingestItem struct {
+	doc      services.ReferenceDocument
+	tenantID string
+}
+
+// runIngest ingests the documents named by args and opts, first dropping the
+// whole collection when reset is set.
+func runIngest(ctx context.Context, args []string, opts ingestOptions, reset bool) error {
+	items, err := ingestItems(args, opts)
+	if err != nil {
+		return err
+	}
+
+	cfg, err := loadConfig(true)
+	if err != nil {
+		return err
+	}
+	if cfg.VectorStore == services.VectorStoreMemory {
+		return errors.New("VECTOR_STORE=memory lives inside the API process and can't be ingested into")
+	}
+
+	// The database backs the ingestion records, the embedding cache and
+	// pgvector
+	db, err := config.InitDatabase(cfg)
+	if err != nil {
+		return err
+	}
+	geminiService, err := newEmbedder(cfg, db)
+	if err != nil {
+		return fmt.Errorf("failed to initialize Gemini: %w", err)
+	}
+	vectorStore, err := newVectorStore(cfg, db)
+	if err != nil {
+		return fmt.Errorf("failed to initialize vector store: %w", err)
+	}
+
+	pdfEngines, err := services.NewPDFEngines(cfg.Storage.PDFEngine, cfg.Storage.PDFFallbackEngine, cfg.Storage.PDFToTextPath)
+	if err != nil {
+		return fmt.Errorf("failed to initialize PDF parser: %w", err)
+	}
+
+	ingester := services.NewReferenceIngester(
+		services.NewPDFParserService(services.ParseLimits{}, pdfEngines...),
+		services.NewTextChunker(),
+		geminiService,
+		vectorStore,
+		repositories.NewIngestedReferenceRepository(db),
+	)
+
+	if reset {
+		log.Printf("♻️  Rebuilding collection for %s...", geminiService.EmbeddingModel())
+		if err := ingester.Reset(); err != nil {
+			return err
+		}
+	} else if err := config.WaitFor(cfg.VectorStore, cfg.Startup, func() error {
+		err := vectorStore.InitCollection()
+		if errors.Is(err, services.ErrDimensionMismatch) {
+			return config.Permanent(err)
+		}
+		return err
+	}); err != nil {
+		return fmt.Errorf("failed to initialize collection: %w", err)
+	}
+
+	ingested, unchanged, failed := 0, 0, 0
+	for _, item := range items {
+		doc := item.doc
+		docCtx := ctx
+		scope := "shared"
+		if item.tenantID != "" {
+			docCtx = tenant.WithID(ctx, item.tenantID)
+			scope = "tenant " + item.tenantID
+		}
+
+		if doc.Job != "" {
+			scope += ", job " + doc.Job
+		}
+
+		log.Printf("📄 %s (%s, %s) from %s", doc.Name, doc.DocType, scope, doc.Path)
+		result, err := ingester.Ingest(docCtx, doc, opts.force)
+		switch {
+		case err != nil:
+			log.Printf("   ❌ %v", err)
+			failed++
+		case result.Unchanged:
+			log.Printf("   ⏭️  Unchanged, skipped")
+			unchanged++
+		default:
+			log.Printf("   ✅ Stored %d chunks", result.Chunks)
+			ingested++
+		}
+	}
+
+	log.Printf("📊 %d ingested, %d unchanged, %d failed", ingested, unchanged, failed)
+	if failed > 0 {
+		return fmt.Errorf("%d documents failed to ingest", failed)
+	}
+	return nil
+}
+
+// ingestItems collects the documents of the manifest, the --dir directories
+// and the arguments. A file listed more than once for a tenant is ingested
+// as first listed.
+func ingestItems(args []string, opts ingestOptions) ([]ingestItem, error) {
+	if opts.docType != "" && !slices.Contains(services.ReferenceDocTypes, opts.docType) {
+		return nil, fmt.Errorf("unknown document type %q; use one of %s", opts.docType, strings.Join(services.ReferenceDocTypes, ", "))
+	}
+
+	manifest := opts.manifest
+	if manifest == "" && len(args) == 0 && len(opts.dirs) == 0 {
+		if _, err := os.Stat(defaultManifest); err == nil {
+			manifest = defaultManifest
+		} else {
+			args = []string{defaultReferenceDir}
+		}
+	}
+
+	var items []ingestItem
+	add := func(item ingestItem) {
+		for _, existing := range items {
+			if existing.doc.Path == item.doc.Path && existing.tenantID == item.tenantID {
+				return
+			}
+		}
+		items = append(items, item)
+	}
+
+	if manifest != "" {
+		manifestItems, err := readManifest(manifest, opts.tenantID, opts.job)
+		if err != nil {
+			return nil, err
+		}
+		for _, item := range manifestItems {
+			add(item)
+		}
+	}
+
+	for _, dir := range opts.dirs {
+		dirItems, err := typedDirItems(dir, opts.tenantID, opts.job)
+		if err != nil {
+			return nil, err
+		}
+		for _, item := range dirItems {
+			add(item)
+		}
+	}
+
+	var paths []string
+	for _, arg := range args {
+		matches, err := expandPath(arg)
+		if err != nil {
+			return nil, err
+		}
+		paths = append(paths, matches...)
+	}
+	if opts.name != "" && len(paths) != 1 {
+		return nil, errors.New("--name needs a single file argument")
+	}
+	for _, path := range paths {
+		doc, err := referenceDocument(path, opts.docType, opts.name, opts.job)
+		if err != nil {
+			return nil, err
+		}
+		add(ingestItem{doc: doc, tenantID: opts.tenantID})
+	}
+
+	if len(items) == 0 {
+		return nil, errors.New("no PDF files found")
+	}
+	return items, nil
+}
+
+// manifestFile lists reference documents. Paths are relative to the
+// manifest and may be globs or directories.
+type manifestFile struct {
+	Documents []manifestDocument `yaml:"documents"`
+}
+
+type manifestDocument struct {
+	Path   string `yaml:"path"`
+	Type   string `yaml:"type"`
+	Name   string `yaml:"name"`
+	Job    string `yaml:"job"`
+	Tenant string `yaml:"tenant"`
+}
+
+// readManifest reads a YAML manifest (JSON being valid YAML, JSON works
+// too). Entries without a tenant or job get defaultTenant and defaultJob.
+func readManifest(path string, defaultTenant string, defaultJob string) ([]ingestItem, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var manifest manifestFile
+	decoder := yaml.NewDecoder(f)
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&manifest); err != nil {
+		return nil, fmt.Errorf("invalid manifest %s: %w", path, err)
+	}
+
+	base := filepath.Dir(path)
+	var items []ingestItem
+	for i, entry := range manifest.Documents {
+		if entry.Path == "" {
+			return nil, fmt.Errorf("manifest %s: document %d has no path", path, i+1)
+		}
+		if entry.Type != "" && !slices.Contains(services.ReferenceDocTypes, entry.Type) {
+			return nil, fmt.Errorf("manifest %s: unknown document type %q for %s", path, entry.Type, entry.Path)
+		}
+
+		docPath := entry.Path
+		if !filepath.IsAbs(docPath) {
+			docPath = filepath.Join(base, docPath)
+		}
+		matches, err := expandPath(docPath)
+		if err != nil {
+			return nil, fmt.Errorf("manifest %s: %w", path, err)
+		}
+		if entry.Name != "" && len(matches) > 1 {
+			return nil, fmt.Errorf("manifest %s: %s matches several files but has a name", path, entry.Path)
+		}
+
+		tenantID := entry.Tenant
+		if tenantID == "" {
+			tenantID = defaultTenant
+		}
+		job := entry.Job
+		if job == "" {
+			job = defaultJob
+		}
+		for _, match := range matches {
+			doc, err := referenceDocument(match, entry.Type, entry.Name, job)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, ingestItem{doc: doc, tenantID: tenantID})
+		}
+	}
+	return items, nil
+}
+
+// typedDirItems reads dir/<document type>/*.pdf; PDFs directly in dir get a
+// type guessed from their name.
+func typedDirItems(dir string, tenantID string, job string) ([]ingestItem, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var items []ingestItem
+	for _, entry := range entries {
+		path := filepath.Join(dir, entry.Name())
+
+		docType := ""
+		var paths []string
+		switch {
+		case entry.IsDir() && slices.Contains(services.ReferenceDocTypes, entry.Name()):
+			docType = entry.Name()
+			if paths, err = expandPath(path); err != nil {
+				return nil, err
+			}
+		case entry.IsDir():
+			log.Printf("⚠️  Skipping %s: not a document type (%s)", path, strings.Join(services.ReferenceDocTypes, ", "))
+			continue
+		case isPDF(entry.Name()):
+			paths = []string{path}
+		}
+
+		for _, p := range paths {
+			doc, err := referenceDocument(p, docType, "", job)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, ingestItem{doc: doc, tenantID: tenantID})
+		}
+	}
+	return items, nil
+}
+
+// referenceDocument describes the PDF at path, guessing the type when
+// docType is empty and deriving the name from the file name when name is.
+func referenceDocument(path string, docType string, name string, job string) (services.ReferenceDocument, error) {
+	if docType == "" {
+		docType = guessDocType(path)
+		if docType == "" {
+			return services.ReferenceDocument{}, fmt.Errorf("can't tell the document type of %s; pass --type", path)
+		}
+	}
+
+	if name == "" {
+		base := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+		name = strings.ReplaceAll(base, "_", " ")
+	}
+
+	return services.ReferenceDocument{Path: path, DocType: docType, Name: name, Job: job}, nil
+}
+
+// expandPath resolves a glob pattern, a directory (its PDFs) or a file.
+func expandPath(arg string) ([]string, error) {
+	if strings.ContainsAny(arg, "*?[") {
+		matches, err := filepath.Glob(arg)
+		if err != nil {
+			return nil, fmt.Errorf("bad pattern %q: %w", arg, err)
+		}
+		if len(matches) == 0 {
+			return nil, fmt.Errorf("%s matches no files", arg)
+		}
+		return matches, nil
+	}
+
+	info, err := os.Stat(arg)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return []string{arg}, nil
+	}
+
+	var pdfs []string
+	entries, err := os.ReadDir(arg)
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range entries {
+		if !entry.IsDir() && isPDF(entry.Name()) {
+			pdfs = append(pdfs, filepath.Join(arg, entry.Name()))
+		}
+	}
+	return pdfs, nil
+}
+
+func isPDF(name string) bool {
+	return strings.EqualFold(filepath.Ext(name), ".pdf")
+}
+
+// guessDocType derives the document type from words in the file name, e.g.
+// scoring_rubric.pdf is a CV rubric. It returns "" when no word fits.
+func guessDocType(path string) string {
+	name := strings.ToLower(filepath.Base(path))
+	switch {
+	case strings.Contains(name, "rubric") && (strings.Contains(name, "project") || strings.Contains(name, "case")):
+		return "project_rubric"
+	case strings.Contains(name, "rubric"):
+		return "cv_rubric"
+	case strings.Contains(name, "job"):
+		return "job_description"
+	case strings.Contains(name, "case") || strings.Contains(name, "study"):
+		return "case_study"
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,95 @@
+// Command cvctl runs maintenance tasks against the same database and vector
+// store as the API: ingesting reference documents, managing evaluations,
+// rebuilding vectors and purging old data.
+package main
+
+import (
+	"log"
+	"os"
+
+	"github.com/spf13/cobra"
+	"gorm.io/gorm"
+
+	"alfredoptarigan/cv-evaluator/internal/config"
+	"alfredoptarigan/cv-evaluator/internal/repositories"
+	"alfredoptarigan/cv-evaluator/internal/secrets"
+	"alfredoptarigan/cv-evaluator/internal/services"
+)
+
+func main() {
+	log.SetOutput(secrets.NewWriter(os.Stderr))
+
+	if err := newRootCommand().Execute(); err != nil {
+		os.Exit(1)
+	}
+}
+
+func newRootCommand() *cobra.Command {
+	root := &cobra.Command{
+		Use:          "cvctl",
+		Short:        "Administer the CV evaluator",
+		SilenceUsage: true,
+	}
+	root.AddCommand(
+		newIngestCommand(),
+		newEvaluationsCommand(),
+		newVectorsCommand(),
+		newPurgeCommand(),
+	)
+	return root
+}
+
+// loadConfig loads the configuration. Only commands that call Gemini
+// validate it in full, as that wants an API key.
+func loadConfig(full bool) (*config.Config, error) {
+	cfg := config.Load()
+	if full {
+		if err := cfg.Validate(); err != nil {
+			return nil, err
+		}
+	}
+	return cfg, nil
+}
+
+// newEmbedder returns the LLM service used for embeddings, behind the
+// embedding cache when it is enabled.
+func newEmbedder(cfg *config.Config, db *gorm.DB) (services.GeminiService, error) {
+	geminiService, err := services.NewLLMService(
+		cfg.LLMProvider,
+		cfg.Gemini.APIKey,
+		cfg.Gemini.EmbeddingModel,
+		cfg.Gemini.EmbeddingDimensions,
+		services.GenerationParams{},
+	)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Gemini.EmbeddingCacheEnabled {
+		geminiService = services.NewCachedEmbeddingService(
+			geminiService,
+			repositories.NewEmbeddingCacheRepository(db),
+		)
+	}
+	return geminiService, nil
+}
+
+func newVectorStore(cfg *config.Config, db *gorm.DB) (services.VectorStore, error) {
+	return services.NewVectorStore(services.VectorStoreOptions{
+		Backend:    cfg.VectorStore,
+		Dimensions: cfg.Gemini.EmbeddingDimensions,
+		DB:         db,
+
+		QdrantURL:    cfg.Qdrant.URL,
+		QdrantAPIKey: cfg.Qdrant.APIKey,
+		Collection:   cfg.Qdrant.Collection,
+		QdrantSnapshots: services.QdrantSnapshotOptions{
+			HTTPURL: cfg.Qdrant.HTTPURL,
+			Path:    cfg.Qdrant.SnapshotPath,
+		},
+		QdrantTenantCollections: cfg.Qdrant.TenantCollections,
+
+		WeaviateURL:    cfg.Weaviate.URL,
+		WeaviateAPIKey: cfg.Weaviate.APIKey,
+		WeaviateClass:  cfg.Weaviate.Class,
+	})
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/spf13/cobra"
+
+	"alfredoptarigan/cv-evaluator/internal/config"
+	"alfredoptarigan/cv-evaluator/internal/models"
+	"alfredoptarigan/cv-evaluator/internal/repositories"
+	"alfredoptarigan/cv-evaluator/internal/services"
+)
+
+const purgeBatchSize = 100
+
+// finishedStatuses are the evaluations purge may delete; queued and running
+// ones are kept however old they are.
+var finishedStatuses = []models.EvaluationStatus{
+	models.StatusCompleted,
+	models.StatusFailed,
+	models.StatusPartiallyCompleted,
+	models.StatusExpired,
+	models.StatusCancelled,
+}
+
+func newPurgeCommand() *cobra.Command {
+	var (
+		olderThan time.Duration
+		tenantID  string
+	)
+	cmd := &cobra.Command{
+		Use:   "purge",
+		Short: "Delete finished evaluations and uploaded documents older than a cutoff",
+		Long: `Deletes finished evaluations (with their timeline, errors and checkpoints)
+and then uploaded documents (file, row and vectors) created more than
+--older-than ago, like the retention job does. Documents used by queued or
+running evaluations are kept.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if olderThan <= 0 {
+				return errors.New("--older-than must be positive")
+			}
+
+			cfg, err := loadConfig(false)
+			if err != nil {
+				return err
+			}
+			db, err := config.InitDatabase(cfg)
+			if err != nil {
+				return err
+			}
+			vectorStore, err := newVectorStore(cfg, db)
+			if err != nil {
+				return fmt.Errorf("failed to initialize vector store: %w", err)
+			}
+
+			evaluations, err := purgeEvaluations(repositories.NewEvaluationRepository(db), repositories.EvaluationFilter{
+				Statuses:      finishedStatuses,
+				TenantID:      tenantID,
+				CreatedBefore: time.Now().Add(-olderThan),
+				Limit:         purgeBatchSize,
+			})
+			log.Printf("🧹 Deleted %d evaluations", evaluations)
+			if err != nil {
+				return err
+			}
+
+			// The retention job does the document cleanup, once, with the
+			// cutoff as its period
+			period, overrides := olderThan, map[string]time.Duration(nil)
+			if tenantID != "" {
+				period, overrides = 0, map[string]time.Duration{tenantID: olderThan}
+			}
+			retention := services.NewRetentionService(
+				repositories.NewDocumentRepository(db),
+				services.NewStorageService(cfg.Storage.UploadPath, cfg.Storage.AllowedFileTypes),
+				vectorStore,
+				period,
+				overrides,
+				0,
+			)
+			documents, err := retention.RunOnce(cmd.Context())
+			log.Printf("🧹 Deleted %d documents", documents)
+			return err
+		},
+	}
+	cmd.Flags().DurationVar(&olderThan, "older-than", 0, "delete data created longer ago than this, e.g. 2160h (required)")
+	cmd.Flags().StringVar(&tenantID, "tenant", "", "only purge this tenant's data")
+	_ = cmd.MarkFlagRequired("older-than")
+	return cmd
+}
+
+// purgeEvaluations deletes the evaluations matching filter batch by batch.
+func purgeEvaluations(evalRepo repositories.EvaluationRepository, filter repositories.EvaluationFilter) (int, error) {
+	deleted := 0
+	for {
+		evals, err := evalRepo.List(filter)
+		if err != nil {
+			return deleted, err
+		}
+
+		ids := make([]uuid.UUID, len(evals))
+		for i, eval := range evals {
+			ids[i] = eval.ID
+		}
+		removed, err := evalRepo.Delete(ids)
+		if err != nil {
+			return deleted, err
+		}
+		deleted += len(removed)
+
+		if len(evals) < filter.Limit {
+			return deleted, nil
+		}
+	}
+}
@@ -0,0 +1,29 @@
+package main
+
+import (
+	"github.com/spf13/cobra"
+)
+
+func newVectorsCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "vectors",
+		Short: "Manage the vector store",
+	}
+
+	var opts ingestOptions
+	rebuild := &cobra.Command{
+		Use:   "rebuild [file|dir|glob]...",
+		Short: "Drop the collection and ingest the reference PDFs again",
+		Long: `Drops every chunk, recreates the collection and ingests the given reference
+PDFs (like ingest), e.g. after changing the embedding model or
+EMBEDDING_DIMENSIONS. Tenant-specific documents have to be ingested again
+with --tenant afterwards.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runIngest(cmd.Context(), args, opts, true)
+		},
+	}
+	opts.register(rebuild)
+
+	cmd.AddCommand(rebuild)
+	return cmd
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/pressly/goose/v3"
+
+	"alfredoptarigan/cv-evaluator/internal/config"
+	"alfredoptarigan/cv-evaluator/internal/databases/migrations"
+	"alfredoptarigan/cv-evaluator/internal/secrets"
+)
+
+const usage = `Usage: migrate <command> [args]
+
+Commands:
+  up               apply all pending migrations
+  up-by-one        apply the next pending migration
+  up-to VERSION    apply migrations up to VERSION
+  down             roll back the latest migration
+  down-to VERSION  roll back migrations down to VERSION
+  redo             roll back and re-apply the latest migration
+  status           list every migration and whether it is applied
+  version          print the current schema version
+`
+
+func main() {
+	flag.Usage = func() { fmt.Fprint(os.Stderr, usage) }
+	flag.Parse()
+	if flag.NArg() < 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	command, args := flag.Arg(0), flag.Args()[1:]
+
+	log.SetOutput(secrets.NewWriter(os.Stderr))
+
+	// Only the database settings matter here, so the full Validate (which
+	// wants a Gemini key) is skipped.
+	cfg := config.Load()
+	dir := "."
+	switch cfg.Database.Driver {
+	case config.DriverPostgres:
+	case config.DriverMySQL:
+		dir = "mysql"
+	default:
+		log.Fatalf("❌ There are no migrations for %s; its schema is created when the API starts", cfg.Database.Driver)
+	}
+
+	db, err := config.InitDatabase(cfg)
+	if err != nil {
+		log.Fatalf("❌ Failed to initialize database: %v", err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		log.Fatalf("❌ Failed to get database handle: %v", err)
+	}
+	defer sqlDB.Close()
+
+	goose.SetBaseFS(migrations.FS)
+	if err := goose.SetDialect(cfg.Database.Driver); err != nil {
+		log.Fatalf("❌ %v", err)
+	}
+
+	if err := goose.RunContext(context.Background(), command, sqlDB, dir, args...); err != nil {
+		log.Fatalf("❌ migrate %s: %v", command, err)
+	}
+}
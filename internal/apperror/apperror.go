@@ -0,0 +1,131 @@
+package apperror
+
+import (
+	"errors"
+	"net/http"
+)
+
+// Code is a stable, machine-readable error identifier returned to clients.
+type Code string
+
+const (
+	CodeInvalidRequest      Code = "INVALID_REQUEST"
+	CodeValidationFailed    Code = "VALIDATION_FAILED"
+	CodeInvalidID           Code = "INVALID_ID"
+	CodeUnauthorized        Code = "UNAUTHORIZED"
+	CodeForbidden           Code = "FORBIDDEN"
+	CodeDocumentNotFound    Code = "DOCUMENT_NOT_FOUND"
+	CodeWrongDocumentType   Code = "WRONG_DOCUMENT_TYPE"
+	CodeEvaluationNotFound  Code = "EVALUATION_NOT_FOUND"
+	CodeFileTooLarge        Code = "FILE_TOO_LARGE"
+	CodeUnsupportedFileType Code = "UNSUPPORTED_FILE_TYPE"
+	CodeDocumentEncrypted   Code = "DOCUMENT_ENCRYPTED"
+	CodeDocumentTooLong     Code = "DOCUMENT_TOO_LONG"
+	CodeNoFilesUploaded     Code = "NO_FILES_UPLOADED"
+	CodeUploadNotFound      Code = "UPLOAD_NOT_FOUND"
+	CodeUploadExpired       Code = "UPLOAD_EXPIRED"
+	CodeUploadOffset        Code = "UPLOAD_OFFSET_MISMATCH"
+	CodeFetchFailed         Code = "FETCH_FAILED"
+	CodeLLMUnavailable      Code = "LLM_UNAVAILABLE"
+	CodeQuotaExceeded       Code = "QUOTA_EXCEEDED"
+	CodeQueueFull           Code = "QUEUE_FULL"
+	CodeNotFound            Code = "NOT_FOUND"
+	CodeMethodNotAllowed    Code = "METHOD_NOT_ALLOWED"
+	CodePayloadTooLarge     Code = "PAYLOAD_TOO_LARGE"
+	CodeRequestTimeout      Code = "REQUEST_TIMEOUT"
+	CodeRateLimited         Code = "RATE_LIMITED"
+	CodeServiceUnavailable  Code = "SERVICE_UNAVAILABLE"
+	CodeInternal            Code = "INTERNAL_ERROR"
+)
+
+// FieldError describes a single invalid request field.
+type FieldError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+// Error is an error that knows how it should be presented to API clients.
+// Message is safe to return to callers; Err holds the underlying cause and is
+// only used for logging.
+type Error struct {
+	Status  int
+	Code    Code
+	Message string
+	Fields  []FieldError
+	Details map[string]interface{}
+	Err     error
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return e.Message + ": " + e.Err.Error()
+	}
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+func New(status int, code Code, message string) *Error {
+	return &Error{
+		Status:  status,
+		Code:    code,
+		Message: message,
+	}
+}
+
+// Wrap attaches status, code and message to err. If err already carries an
+// *Error it is returned unchanged so the most specific classification wins.
+func Wrap(err error, status int, code Code, message string) error {
+	if err == nil {
+		return nil
+	}
+
+	if _, ok := As(err); ok {
+		return err
+	}
+
+	return &Error{
+		Status:  status,
+		Code:    code,
+		Message: message,
+		Err:     err,
+	}
+}
+
+func As(err error) (*Error, bool) {
+	var appErr *Error
+	if errors.As(err, &appErr) {
+		return appErr, true
+	}
+	return nil, false
+}
+
+// CodeForStatus returns the generic code used for errors that were not
+// classified explicitly (e.g. fiber's routing errors).
+func CodeForStatus(status int) Code {
+	switch status {
+	case http.StatusBadRequest:
+		return CodeInvalidRequest
+	case http.StatusUnauthorized:
+		return CodeUnauthorized
+	case http.StatusForbidden:
+		return CodeForbidden
+	case http.StatusNotFound:
+		return CodeNotFound
+	case http.StatusMethodNotAllowed:
+		return CodeMethodNotAllowed
+	case http.StatusRequestEntityTooLarge:
+		return CodePayloadTooLarge
+	case http.StatusRequestTimeout:
+		return CodeRequestTimeout
+	case http.StatusTooManyRequests:
+		return CodeRateLimited
+	case http.StatusServiceUnavailable:
+		return CodeServiceUnavailable
+	default:
+		return CodeInternal
+	}
+}
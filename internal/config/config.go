@@ -5,26 +5,92 @@ import (
 	"log"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/joho/godotenv"
+
+	"alfredoptarigan/cv-evaluator/internal/secrets"
 )
 
 type Config struct {
-	Server   ServerConfig
-	Database DatabaseConfig
-	Qdrant   QdrantConfig
-	Gemini   GeminiConfig
-	Storage  StorageConfig
-	Worker   WorkerConfig
+	Server    ServerConfig
+	GRPC      GRPCConfig
+	Database  DatabaseConfig
+	Qdrant    QdrantConfig
+	Gemini    GeminiConfig
+	Storage   StorageConfig
+	Worker    WorkerConfig
+	Quota     QuotaConfig
+	Retention RetentionConfig
+	Admin     AdminConfig
+	// EmailIntake turns emailed CVs into documents and evaluations.
+	EmailIntake EmailIntakeConfig
+	Breaker     BreakerConfig
+	Retrieval   RetrievalConfig
+	// LLMProvider is "gemini" or "mock" (canned answers, offline, for
+	// tests and demos).
+	LLMProvider string
+	// VectorStore selects where reference chunks are kept: "qdrant",
+	// "pgvector" (the main database), "weaviate" or "memory" (development
+	// only, lost on restart).
+	VectorStore string
+	Weaviate    WeaviateConfig
+	Startup     StartupConfig
+
+	// parseProblems lists the settings Load couldn't parse, for Validate
+	// to report.
+	parseProblems []string
 }
 
 type ServerConfig struct {
 	Port string
 	Env  string
+	// BodyLimit caps non-upload request bodies; uploads are streamed and
+	// limited per file by StorageConfig.MaxFileSize.
+	BodyLimit int
+	// RequestTimeout bounds reading a request and writing its response.
+	// Uploads get UploadTimeout instead, as large files on slow
+	// connections take longer.
+	RequestTimeout time.Duration
+	UploadTimeout  time.Duration
+	// TLSCertFile and TLSKeyFile serve HTTPS with that certificate.
+	// TLSAutocertDomains gets certificates for those domains from Let's
+	// Encrypt instead, cached in TLSAutocertCacheDir. Without either the
+	// server speaks plain HTTP, e.g. behind a proxy.
+	TLSCertFile         string
+	TLSKeyFile          string
+	TLSAutocertDomains  []string
+	TLSAutocertCacheDir string
+	// TLSRedirectPort, if set, redirects plain HTTP on that port to HTTPS
+	// and answers Let's Encrypt's HTTP challenges.
+	TLSRedirectPort string
+}
+
+// TLSEnabled reports whether the server serves HTTPS.
+func (c ServerConfig) TLSEnabled() bool {
+	return c.TLSCertFile != "" || len(c.TLSAutocertDomains) > 0
+}
+
+type GRPCConfig struct {
+	Enabled bool
+	Port    string
+}
+
+// StartupConfig bounds how long the startup waits for external
+// dependencies: up to ConnectRetries retries, doubling the delay from
+// ConnectInitialDelay, within ConnectMaxWait overall.
+type StartupConfig struct {
+	ConnectRetries      int
+	ConnectInitialDelay time.Duration
+	ConnectMaxWait      time.Duration
 }
 
 type DatabaseConfig struct {
+	// Driver is "postgres", "mysql" (MySQL 8 or MariaDB 10.6+) or "sqlite"
+	// (local development only); Path is the SQLite database file.
+	Driver   string
+	Path     string
 	Host     string
 	Port     string
 	User     string
@@ -36,61 +102,398 @@ type QdrantConfig struct {
 	URL        string
 	APIKey     string
 	Collection string
+	// HTTPURL is the REST API, needed to restore snapshots; SnapshotPath is
+	// where the Qdrant node keeps them.
+	HTTPURL      string
+	SnapshotPath string
+	// TenantCollections lists tenants whose reference chunks are kept in
+	// a collection of their own.
+	TenantCollections []string
+}
+
+type WeaviateConfig struct {
+	URL    string
+	APIKey string
+	Class  string
 }
 
 type GeminiConfig struct {
 	APIKey string
+	// EmbeddingModel and EmbeddingDimensions must match what the vector
+	// store was built with; changing either needs a re-index.
+	EmbeddingModel      string
+	EmbeddingDimensions int
+	// MaxInFlight caps concurrent Gemini calls across all workers; MaxQueue
+	// caps how many more may wait for a slot (0 = unbounded).
+	MaxInFlight int
+	MaxQueue    int
+	// Prices in USD per million tokens, used for cost estimates
+	InputPricePerMTok  float64
+	OutputPricePerMTok float64
+	// CacheTTL keeps generated responses for reuse; 0 disables the cache.
+	CacheTTL              time.Duration
+	EmbeddingCacheEnabled bool
+	// PromptTokenBudget caps evaluation prompts; PromptContextShare is the
+	// part of it reserved for retrieved context when the CV is long.
+	PromptTokenBudget  int
+	PromptContextShare float64
+	// AllowedModels are the generation models an evaluation may select.
+	AllowedModels []string
+	// CanaryModel receives CanaryPercent of the evaluations that don't pick
+	// a model, to validate an upgrade on live traffic.
+	CanaryModel   string
+	CanaryPercent float64
+	// ShadowModel (and ShadowTemperature, if set) re-evaluate ShadowPercent
+	// of the evaluations for offline comparison.
+	ShadowModel       string
+	ShadowTemperature *float32
+	ShadowPercent     float64
+	// Batch-mode requests are submitted every BatchFlushInterval, at most
+	// BatchMaxRequests at a time, and their jobs checked every
+	// BatchPollInterval.
+	BatchFlushInterval time.Duration
+	BatchPollInterval  time.Duration
+	BatchMaxRequests   int
+	// VisionMaxImages is how many diagrams from PDF project reports are sent
+	// to the model with their text; 0 sends none.
+	VisionMaxImages int
+	// Generation applies to every text generation call; ScoringGeneration
+	// and SummaryGeneration override it for those pipeline stages.
+	Generation        GenerationConfig
+	ScoringGeneration GenerationConfig
+	SummaryGeneration GenerationConfig
+	// SafetySettings maps harm categories to block thresholds for every
+	// call, e.g. HARM_CATEGORY_HARASSMENT:BLOCK_ONLY_HIGH.
+	SafetySettings map[string]string
+}
+
+// GenerationConfig tunes text generation. Zero values keep the defaults.
+type GenerationConfig struct {
+	MaxOutputTokens int
+	TopP            *float32
+	TopK            *float32
 }
 
 type StorageConfig struct {
-	UploadPath  string
-	MaxFileSize int64
+	UploadPath        string
+	MaxFileSize       int64
+	AllowedFileTypes  []string
+	ReconcileInterval time.Duration
+	ReconcileCleanup  bool
+	UploadSessionTTL  time.Duration
+	// RequireUploadPair makes /upload accept only a cv and project_report
+	// together.
+	RequireUploadPair bool
+	// DownloadSigningKey enables signed download links when set.
+	DownloadSigningKey string
+	DownloadURLTTL     time.Duration
+	// URLUpload lets /upload download documents from a URL.
+	URLUploadEnabled bool
+	URLUploadTimeout time.Duration
+	// URLUploadAllowPrivate lets URLs resolve to loopback and private
+	// addresses, e.g. for a document server on the internal network.
+	URLUploadAllowPrivate bool
+	// PDFEngine extracts text from PDFs: "builtin" or "pdftotext" (poppler,
+	// at PDFToTextPath). PDFFallbackEngine, if set, is tried when it fails
+	// or finds no text.
+	PDFEngine         string
+	PDFFallbackEngine string
+	PDFToTextPath     string
+	// MaxPages and MaxTextLength (in characters) reject documents too long
+	// to evaluate; 0 disables a limit.
+	MaxPages      int
+	MaxTextLength int
+	// ContactExtraction is what is stored of the contact details found in
+	// CVs: "off", "hashed" (e-mail and phone hashes) or "plain".
+	ContactExtraction string
+}
+
+// RetrievalConfig tunes how reference context is fetched for prompts.
+type RetrievalConfig struct {
+	// Hybrid adds a keyword pass to the vector search.
+	Hybrid bool
+	// Candidates is how many chunks per document type are fetched before
+	// reranking and diversity selection narrow them down.
+	Candidates int
+	MinScore   float64
+	// Rerank has the LLM score candidates and drop those below
+	// RerankMinScore (0-10).
+	Rerank         bool
+	RerankMinScore int
+	MMRLambda      float64
+}
+
+// BreakerConfig applies to the Gemini and Qdrant circuit breakers.
+type BreakerConfig struct {
+	MaxFailures uint32
+	OpenTimeout time.Duration
+}
+
+// AdminConfig protects the /admin endpoints; they are disabled when Token is
+// empty.
+type AdminConfig struct {
+	Token string
+	// UIEnabled serves the web UI at /admin. It works without Token, minus
+	// reference document management.
+	UIEnabled bool
+}
+
+// EmailIntakeConfig configures the inbound email webhook.
+type EmailIntakeConfig struct {
+	Enabled bool
+	// Token authenticates the webhook, which passes it as ?token=.
+	Token string
+	// Address is the intake address; messages not sent to it are ignored.
+	// Empty accepts every message the webhook receives.
+	Address string
+	// APIKey picks the tenant that owns the documents and evaluations.
+	APIKey string
+	// JobCodes maps the job codes a subject line may carry to job titles.
+	JobCodes map[string]string
+	// AutoEvaluate starts an evaluation when a message carries a CV, a
+	// project report and a known job code.
+	AutoEvaluate bool
 }
 
 type WorkerConfig struct {
-	Concurrency       int
-	RetryMaxAttempts  int
-	RetryInitialDelay time.Duration
+	Concurrency int
+	// MaxConcurrency above Concurrency enables autoscaling between the two
+	// based on queue depth.
+	MaxConcurrency    int
+	AutoscaleInterval time.Duration
+	// QueueSize is how many dispatched jobs wait in memory for a worker;
+	// beyond that they stay queued in the database.
+	QueueSize int
+	// MaxBacklog rejects new evaluations once this many are queued (0 =
+	// unlimited).
+	MaxBacklog int64
+	// ShutdownGrace is how long Stop waits for running evaluations before
+	// cancelling and requeueing them.
+	ShutdownGrace time.Duration
+	// QueueTTL expires evaluations still queued this long after submission,
+	// or after their run_at if scheduled (0 = never).
+	QueueTTL time.Duration
+	// Attempts per pipeline step: RetryMaxAttempts for each LLM call, the
+	// others for parsing a document and for each vector store search. The
+	// delay between attempts starts at RetryInitialDelay and doubles.
+	RetryMaxAttempts       int
+	ParseMaxAttempts       int
+	VectorQueryMaxAttempts int
+	RetryInitialDelay      time.Duration
+	// Per-stage deadlines inside an evaluation (0 = none)
+	ParseTimeout       time.Duration
+	LLMCallTimeout     time.Duration
+	VectorQueryTimeout time.Duration
 }
 
+// QuotaConfig holds monthly per-tenant limits; zero means unlimited.
+type QuotaConfig struct {
+	MonthlyUploads     int64
+	MonthlyEvaluations int64
+}
+
+// RetentionConfig controls the document cleanup job; a zero Period disables
+// it for tenants without an override.
+type RetentionConfig struct {
+	Period          time.Duration
+	Interval        time.Duration
+	TenantOverrides map[string]time.Duration
+}
+
+// Load reads the configuration from environment variables, falling back to
+// the config file (CONFIG_FILE, config.yaml by default) and then to
+// defaults.
 func Load() *Config {
+	parseProblems = nil
+
 	if err := godotenv.Load(); err != nil {
 		log.Println("No .env file found. Using default values.")
 	}
 
-	return &Config{
+	configFile := os.Getenv("CONFIG_FILE")
+	required := configFile != ""
+	if !required {
+		configFile = defaultConfigFile
+	}
+	if err := loadConfigFile(configFile, required); err != nil {
+		log.Fatalf("❌ Failed to load config file %s: %v", configFile, err)
+	}
+
+	dbDriver := getEnv("DB_DRIVER", DriverPostgres)
+	dbPort := "5432"
+	if dbDriver == DriverMySQL {
+		dbPort = "3306"
+	}
+
+	// The mock provider is for running offline, so it keeps the vectors
+	// in memory unless told otherwise
+	llmProvider := getEnv("LLM_PROVIDER", "gemini")
+	defaultVectorStore := "qdrant"
+	if llmProvider == "mock" {
+		defaultVectorStore = "memory"
+	}
+
+	cfg := &Config{
 		Server: ServerConfig{
-			Port: getEnv("PORT", "3000"),
-			Env:  getEnv("ENV", "development"),
+			Port:      getEnv("PORT", "3000"),
+			Env:       getEnv("ENV", "development"),
+			BodyLimit: getEnvAsInt("BODY_LIMIT", 1048576),
+
+			RequestTimeout: getEnvAsDuration("REQUEST_TIMEOUT", "30s"),
+			UploadTimeout:  getEnvAsDuration("UPLOAD_TIMEOUT", "5m"),
+
+			TLSCertFile:         getEnv("TLS_CERT_FILE", ""),
+			TLSKeyFile:          getEnv("TLS_KEY_FILE", ""),
+			TLSAutocertDomains:  getEnvAsSlice("TLS_AUTOCERT_DOMAINS", nil),
+			TLSAutocertCacheDir: getEnv("TLS_AUTOCERT_CACHE_DIR", "./certs"),
+			TLSRedirectPort:     getEnv("TLS_REDIRECT_PORT", ""),
+		},
+		GRPC: GRPCConfig{
+			Enabled: getEnvAsBool("GRPC_ENABLED", true),
+			Port:    getEnv("GRPC_PORT", "9090"),
 		},
 		Database: DatabaseConfig{
+			Driver:   dbDriver,
+			Path:     getEnv("DB_PATH", "cv_evaluator.db"),
 			Host:     getEnv("DB_HOST", "localhost"),
-			Port:     getEnv("DB_PORT", "5432"),
+			Port:     getEnv("DB_PORT", dbPort),
 			User:     getEnv("DB_USER", "postgres"),
-			Password: getEnv("DB_PASSWORD", "postgres"),
+			Password: getSecret("DB_PASSWORD", "postgres"),
 			DBName:   getEnv("DB_NAME", "ai_cv_evaluator"),
 		},
 		Qdrant: QdrantConfig{
-			URL:        getEnv("QDRANT_URL", "http://localhost:6333"),
-			APIKey:     getEnv("QDRANT_API_KEY", ""),
-			Collection: getEnv("QDRANT_COLLECTION", "cv_evaluator_docs"),
+			URL:          getEnv("QDRANT_URL", "http://localhost:6333"),
+			APIKey:       getSecret("QDRANT_API_KEY", ""),
+			Collection:   getEnv("QDRANT_COLLECTION", "cv_evaluator_docs"),
+			HTTPURL:      getEnv("QDRANT_HTTP_URL", "http://qdrant:6333"),
+			SnapshotPath: getEnv("QDRANT_SNAPSHOT_PATH", "/qdrant/snapshots"),
+
+			TenantCollections: getEnvAsSlice("QDRANT_TENANT_COLLECTIONS", nil),
 		},
 		Gemini: GeminiConfig{
-			APIKey: getEnv("GEMINI_API_KEY", ""),
+			APIKey:                getSecret("GEMINI_API_KEY", ""),
+			EmbeddingModel:        getEnv("GEMINI_EMBEDDING_MODEL", "text-embedding-004"),
+			EmbeddingDimensions:   getEnvAsInt("EMBEDDING_DIMENSIONS", 768),
+			MaxInFlight:           getEnvAsInt("GEMINI_MAX_IN_FLIGHT", 4),
+			MaxQueue:              getEnvAsInt("GEMINI_MAX_QUEUE", 0),
+			InputPricePerMTok:     getEnvAsFloat("GEMINI_INPUT_PRICE_PER_MTOK", 0.30),
+			OutputPricePerMTok:    getEnvAsFloat("GEMINI_OUTPUT_PRICE_PER_MTOK", 2.50),
+			CacheTTL:              getEnvAsDuration("LLM_CACHE_TTL", "168h"),
+			EmbeddingCacheEnabled: getEnvAsBool("EMBEDDING_CACHE_ENABLED", true),
+			PromptTokenBudget:     getEnvAsInt("PROMPT_TOKEN_BUDGET", 32000),
+			PromptContextShare:    getEnvAsFloat("PROMPT_CONTEXT_SHARE", 0.3),
+			AllowedModels:         getEnvAsSlice("GEMINI_ALLOWED_MODELS", []string{"gemini-2.5-flash", "gemini-2.5-pro"}),
+			CanaryModel:           getEnv("CANARY_MODEL", ""),
+			CanaryPercent:         getEnvAsFloat("CANARY_PERCENT", 0),
+			ShadowModel:           getEnv("SHADOW_MODEL", ""),
+			ShadowTemperature:     getEnvAsOptionalFloat32("SHADOW_TEMPERATURE"),
+			ShadowPercent:         getEnvAsFloat("SHADOW_PERCENT", 100),
+			BatchFlushInterval:    getEnvAsDuration("GEMINI_BATCH_FLUSH_INTERVAL", "1m"),
+			BatchPollInterval:     getEnvAsDuration("GEMINI_BATCH_POLL_INTERVAL", "5m"),
+			BatchMaxRequests:      getEnvAsInt("GEMINI_BATCH_MAX_REQUESTS", 100),
+			VisionMaxImages:       getEnvAsInt("GEMINI_VISION_MAX_IMAGES", 0),
+			Generation:            getGenerationConfig("GEMINI_", 4096),
+			ScoringGeneration:     getGenerationConfig("GEMINI_SCORING_", 0),
+			SummaryGeneration:     getGenerationConfig("GEMINI_SUMMARY_", 0),
+			SafetySettings:        getEnvAsStringMap("GEMINI_SAFETY_SETTINGS"),
 		},
 		Storage: StorageConfig{
-			UploadPath:  getEnv("UPLOAD_PATH", "./uploads"),
-			MaxFileSize: getEnvAsInt64("MAX_FILE_SIZE", 10485760),
+			UploadPath:            getEnv("UPLOAD_PATH", "./uploads"),
+			MaxFileSize:           getEnvAsInt64("MAX_FILE_SIZE", 10485760),
+			AllowedFileTypes:      getEnvAsSlice("ALLOWED_FILE_TYPES", []string{"pdf"}),
+			ReconcileInterval:     getEnvAsDuration("STORAGE_RECONCILE_INTERVAL", "0s"),
+			ReconcileCleanup:      getEnvAsBool("STORAGE_RECONCILE_CLEANUP", false),
+			UploadSessionTTL:      getEnvAsDuration("UPLOAD_SESSION_TTL", "24h"),
+			RequireUploadPair:     getEnvAsBool("UPLOAD_REQUIRE_PAIR", false),
+			DownloadSigningKey:    getSecret("DOWNLOAD_SIGNING_KEY", ""),
+			DownloadURLTTL:        getEnvAsDuration("DOWNLOAD_URL_TTL", "15m"),
+			URLUploadEnabled:      getEnvAsBool("URL_UPLOAD_ENABLED", true),
+			URLUploadTimeout:      getEnvAsDuration("URL_UPLOAD_TIMEOUT", "30s"),
+			URLUploadAllowPrivate: getEnvAsBool("URL_UPLOAD_ALLOW_PRIVATE", false),
+			PDFEngine:             getEnv("PDF_ENGINE", "builtin"),
+			PDFFallbackEngine:     getEnv("PDF_FALLBACK_ENGINE", ""),
+			PDFToTextPath:         getEnv("PDFTOTEXT_PATH", "pdftotext"),
+			MaxPages:              getEnvAsInt("PARSE_MAX_PAGES", 50),
+			MaxTextLength:         getEnvAsInt("PARSE_MAX_TEXT_LENGTH", 200000),
+			ContactExtraction:     getEnv("CONTACT_EXTRACTION", "off"),
 		},
 		Worker: WorkerConfig{
-			Concurrency:       getEnvAsInt("WORKER_CONCURRENCY", 3),
-			RetryMaxAttempts:  getEnvAsInt("RETRY_MAX_ATTEMPTS", 3),
-			RetryInitialDelay: getEnvAsDuration("RETRY_INITIAL_DELAY", "2s"),
+			Concurrency:            getEnvAsInt("WORKER_CONCURRENCY", 3),
+			MaxConcurrency:         getEnvAsInt("WORKER_MAX_CONCURRENCY", 0),
+			AutoscaleInterval:      getEnvAsDuration("WORKER_AUTOSCALE_INTERVAL", "10s"),
+			QueueSize:              getEnvAsInt("WORKER_QUEUE_SIZE", 100),
+			MaxBacklog:             getEnvAsInt64("WORKER_MAX_BACKLOG", 0),
+			ShutdownGrace:          getEnvAsDuration("WORKER_SHUTDOWN_GRACE", "30s"),
+			QueueTTL:               getEnvAsDuration("EVALUATION_QUEUE_TTL", "168h"),
+			RetryMaxAttempts:       getEnvAsInt("RETRY_MAX_ATTEMPTS", 3),
+			ParseMaxAttempts:       getEnvAsInt("PARSE_MAX_ATTEMPTS", 1),
+			VectorQueryMaxAttempts: getEnvAsInt("VECTOR_QUERY_MAX_ATTEMPTS", 2),
+			RetryInitialDelay:      getEnvAsDuration("RETRY_INITIAL_DELAY", "2s"),
+			ParseTimeout:           getEnvAsDuration("PARSE_TIMEOUT", "30s"),
+			LLMCallTimeout:         getEnvAsDuration("LLM_CALL_TIMEOUT", "90s"),
+			VectorQueryTimeout:     getEnvAsDuration("VECTOR_QUERY_TIMEOUT", "10s"),
+		},
+		Quota: QuotaConfig{
+			MonthlyUploads:     getEnvAsInt64("QUOTA_MONTHLY_UPLOADS", 0),
+			MonthlyEvaluations: getEnvAsInt64("QUOTA_MONTHLY_EVALUATIONS", 0),
+		},
+		Retention: RetentionConfig{
+			Period:          getEnvAsDuration("RETENTION_PERIOD", "0s"),
+			Interval:        getEnvAsDuration("RETENTION_INTERVAL", "1h"),
+			TenantOverrides: getEnvAsDurationMap("RETENTION_TENANT_OVERRIDES"),
+		},
+		Admin: AdminConfig{
+			Token:     getSecret("ADMIN_TOKEN", ""),
+			UIEnabled: getEnvAsBool("ADMIN_UI_ENABLED", true),
+		},
+		EmailIntake: EmailIntakeConfig{
+			Enabled:      getEnvAsBool("EMAIL_INTAKE_ENABLED", false),
+			Token:        getSecret("EMAIL_INTAKE_TOKEN", ""),
+			Address:      getEnv("EMAIL_INTAKE_ADDRESS", ""),
+			APIKey:       getSecret("EMAIL_INTAKE_API_KEY", ""),
+			JobCodes:     getEnvAsStringMap("EMAIL_INTAKE_JOB_CODES"),
+			AutoEvaluate: getEnvAsBool("EMAIL_INTAKE_AUTO_EVALUATE", false),
+		},
+		Breaker: BreakerConfig{
+			MaxFailures: uint32(getEnvAsInt("BREAKER_MAX_FAILURES", 5)),
+			OpenTimeout: getEnvAsDuration("BREAKER_OPEN_TIMEOUT", "30s"),
+		},
+		LLMProvider: llmProvider,
+		VectorStore: getEnv("VECTOR_STORE", defaultVectorStore),
+		Weaviate: WeaviateConfig{
+			URL:    getEnv("WEAVIATE_URL", "http://weaviate:8080"),
+			APIKey: getSecret("WEAVIATE_API_KEY", ""),
+			Class:  getEnv("WEAVIATE_CLASS", "CvEvaluatorChunk"),
+		},
+		Startup: StartupConfig{
+			ConnectRetries:      getEnvAsInt("STARTUP_CONNECT_RETRIES", 10),
+			ConnectInitialDelay: getEnvAsDuration("STARTUP_CONNECT_INITIAL_DELAY", "1s"),
+			ConnectMaxWait:      getEnvAsDuration("STARTUP_CONNECT_MAX_WAIT", "60s"),
+		},
+		Retrieval: RetrievalConfig{
+			Hybrid:         getEnvAsBool("RETRIEVAL_HYBRID", false),
+			Candidates:     getEnvAsInt("RETRIEVAL_CANDIDATES", 10),
+			MinScore:       getEnvAsFloat("RETRIEVAL_MIN_SCORE", 0),
+			Rerank:         getEnvAsBool("RETRIEVAL_RERANK", false),
+			RerankMinScore: getEnvAsInt("RETRIEVAL_RERANK_MIN_SCORE", 3),
+			MMRLambda:      getEnvAsFloat("RETRIEVAL_MMR_LAMBDA", 0.7),
 		},
 	}
+	cfg.parseProblems = parseProblems
+	return cfg
 }
 
 func (c *Config) GetDatabaseDSN() string {
+	if c.Database.Driver == DriverMySQL {
+		return fmt.Sprintf(
+			"%s:%s@tcp(%s:%s)/%s?charset=utf8mb4&parseTime=true&loc=UTC",
+			c.Database.User,
+			c.Database.Password,
+			c.Database.Host,
+			c.Database.Port,
+			c.Database.DBName,
+		)
+	}
 	return fmt.Sprintf(
 		"host=%s port=%s user=%s password=%s dbname=%s sslmode=disable",
 		c.Database.Host,
@@ -101,27 +504,112 @@ func (c *Config) GetDatabaseDSN() string {
 	)
 }
 
+// getSecret is getEnv for values that must never be logged: they are
+// registered with the secrets package, which masks them in log output.
+func getSecret(key, defaultValue string) string {
+	value := getEnv(key, defaultValue)
+	secrets.Register(value)
+	return value
+}
+
 func getEnv(key, defaultValue string) string {
 	if value := os.Getenv(key); value != "" {
 		return value
 	}
+	if value := fileValues[key]; value != "" {
+		return value
+	}
 	return defaultValue
 }
 
+// parseProblems collects the settings the getEnvAs functions couldn't
+// parse while loading; they fall back to their defaults meanwhile.
+var parseProblems []string
+
+func invalidSetting(key, value, kind string) {
+	parseProblems = append(parseProblems, fmt.Sprintf("%s %q is not a valid %s", key, value, kind))
+}
+
 func getEnvAsInt(key string, defaultValue int) int {
 	valueStr := getEnv(key, "")
-	if value, err := strconv.Atoi(valueStr); err == nil {
-		return value
+	if valueStr == "" {
+		return defaultValue
 	}
-	return defaultValue
+	value, err := strconv.Atoi(valueStr)
+	if err != nil {
+		invalidSetting(key, valueStr, "integer")
+		return defaultValue
+	}
+	return value
+}
+
+func getEnvAsBool(key string, defaultValue bool) bool {
+	valueStr := getEnv(key, "")
+	if valueStr == "" {
+		return defaultValue
+	}
+	value, err := strconv.ParseBool(valueStr)
+	if err != nil {
+		invalidSetting(key, valueStr, "boolean")
+		return defaultValue
+	}
+	return value
 }
 
 func getEnvAsInt64(key string, defaultValue int64) int64 {
 	valueStr := getEnv(key, "")
-	if value, err := strconv.ParseInt(valueStr, 10, 64); err == nil {
-		return value
+	if valueStr == "" {
+		return defaultValue
 	}
-	return defaultValue
+	value, err := strconv.ParseInt(valueStr, 10, 64)
+	if err != nil {
+		invalidSetting(key, valueStr, "integer")
+		return defaultValue
+	}
+	return value
+}
+
+func getEnvAsFloat(key string, defaultValue float64) float64 {
+	valueStr := getEnv(key, "")
+	if valueStr == "" {
+		return defaultValue
+	}
+	value, err := strconv.ParseFloat(valueStr, 64)
+	if err != nil {
+		invalidSetting(key, valueStr, "number")
+		return defaultValue
+	}
+	return value
+}
+
+// getEnvAsOptionalFloat32 returns nil when key is unset or invalid.
+func getEnvAsOptionalFloat32(key string) *float32 {
+	valueStr := getEnv(key, "")
+	if valueStr == "" {
+		return nil
+	}
+	value, err := strconv.ParseFloat(valueStr, 32)
+	if err != nil {
+		invalidSetting(key, valueStr, "number")
+		return nil
+	}
+	f := float32(value)
+	return &f
+}
+
+func getEnvAsSlice(key string, defaultValue []string) []string {
+	valueStr := getEnv(key, "")
+	if valueStr == "" {
+		return defaultValue
+	}
+
+	var values []string
+	for _, v := range strings.Split(valueStr, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
 }
 
 func getEnvAsDuration(key string, defaultValue string) time.Duration {
@@ -129,6 +617,53 @@ func getEnvAsDuration(key string, defaultValue string) time.Duration {
 	if duration, err := time.ParseDuration(valueStr); err == nil {
 		return duration
 	}
+	invalidSetting(key, valueStr, "duration")
 	duration, _ := time.ParseDuration(defaultValue)
 	return duration
 }
+
+// getGenerationConfig reads <prefix>MAX_OUTPUT_TOKENS, <prefix>TOP_P and
+// <prefix>TOP_K.
+func getGenerationConfig(prefix string, maxOutputTokens int) GenerationConfig {
+	return GenerationConfig{
+		MaxOutputTokens: getEnvAsInt(prefix+"MAX_OUTPUT_TOKENS", maxOutputTokens),
+		TopP:            getEnvAsOptionalFloat32(prefix + "TOP_P"),
+		TopK:            getEnvAsOptionalFloat32(prefix + "TOP_K"),
+	}
+}
+
+// getEnvAsStringMap parses "key:value" pairs separated by commas. Invalid
+// entries are skipped.
+func getEnvAsStringMap(key string) map[string]string {
+	values := make(map[string]string)
+	for _, pair := range getEnvAsSlice(key, nil) {
+		name, value, ok := strings.Cut(pair, ":")
+		name, value = strings.TrimSpace(name), strings.TrimSpace(value)
+		if !ok || name == "" || value == "" {
+			log.Printf("⚠️  Ignoring invalid %s entry %q\n", key, pair)
+			continue
+		}
+		values[name] = value
+	}
+	return values
+}
+
+// getEnvAsDurationMap parses "key:duration" pairs separated by commas,
+// e.g. "tenant-a:720h,tenant-b:48h". Invalid entries are skipped.
+func getEnvAsDurationMap(key string) map[string]time.Duration {
+	values := make(map[string]time.Duration)
+	for _, pair := range getEnvAsSlice(key, nil) {
+		name, durationStr, ok := strings.Cut(pair, ":")
+		if !ok {
+			log.Printf("⚠️  Ignoring invalid %s entry %q\n", key, pair)
+			continue
+		}
+		duration, err := time.ParseDuration(strings.TrimSpace(durationStr))
+		if err != nil || duration <= 0 {
+			log.Printf("⚠️  Ignoring invalid %s entry %q\n", key, pair)
+			continue
+		}
+		values[strings.TrimSpace(name)] = duration
+	}
+	return values
+}
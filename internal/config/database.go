@@ -3,28 +3,121 @@ package config
 import (
 	"fmt"
 	"log"
+	"reflect"
+	"strings"
+	"time"
 
+	"github.com/glebarez/sqlite"
+	"gorm.io/driver/mysql"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
+	"gorm.io/gorm/schema"
+
+	"alfredoptarigan/cv-evaluator/internal/models"
 )
 
-func InitDatabase(cfg *Config) (*gorm.DB, error) {
-	dsn := cfg.GetDatabaseDSN()
+// Database drivers for DB_DRIVER
+const (
+	DriverPostgres = "postgres"
+	DriverMySQL    = "mysql"
+	DriverSQLite   = "sqlite"
+)
 
+func InitDatabase(cfg *Config) (*gorm.DB, error) {
 	logLevel := logger.Silent
 	if cfg.Server.Env == "development" {
 		logLevel = logger.Info
 	}
 
-	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{
-		Logger: logger.Default.LogMode(logLevel),
+	dialector, err := dialectorFor(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	var db *gorm.DB
+	err = WaitFor("database", cfg.Startup, func() error {
+		var err error
+		db, err = gorm.Open(dialector, &gorm.Config{
+			Logger: logger.Default.LogMode(logLevel),
+		})
+		return err
 	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to database: %w", err)
 	}
 
-	log.Println("✅ Database connected successfully")
+	if cfg.Database.Driver == DriverSQLite {
+		if err := setupSQLite(db); err != nil {
+			return nil, err
+		}
+	}
+
+	log.Printf("✅ Database (%s) connected successfully", cfg.Database.Driver)
 
 	return db, nil
 }
+
+func dialectorFor(cfg *Config) (gorm.Dialector, error) {
+	switch cfg.Database.Driver {
+	case DriverPostgres:
+		return postgres.Open(cfg.GetDatabaseDSN()), nil
+	case DriverMySQL:
+		return mysql.Open(cfg.GetDatabaseDSN()), nil
+	case DriverSQLite:
+		// WAL and a busy timeout let the workers write concurrently
+		// without "database is locked" errors.
+		return sqlite.Open(cfg.Database.Path + "?_pragma=journal_mode(WAL)&_pragma=busy_timeout(5000)"), nil
+	default:
+		return nil, fmt.Errorf("unsupported database driver %q", cfg.Database.Driver)
+	}
+}
+
+// setupSQLite creates the schema straight from the models, as the SQL
+// migrations are written for Postgres.
+func setupSQLite(db *gorm.DB) error {
+	// SQLite compares times as text, so CURRENT_TIMESTAMP defaults (second
+	// precision, no zone) wouldn't order against times written by the app.
+	err := db.Callback().Create().Before("gorm:create").Register("sqlite:default_timestamps", fillDefaultTimestamps)
+	if err != nil {
+		return fmt.Errorf("failed to register sqlite callback: %w", err)
+	}
+	if err := db.AutoMigrate(models.Schema()...); err != nil {
+		return fmt.Errorf("failed to create sqlite schema: %w", err)
+	}
+	return nil
+}
+
+// fillDefaultTimestamps sets unset CURRENT_TIMESTAMP columns before insert.
+func fillDefaultTimestamps(db *gorm.DB) {
+	if db.Statement.Schema == nil {
+		return
+	}
+	var fields []*schema.Field
+	for _, field := range db.Statement.Schema.Fields {
+		if strings.EqualFold(field.DefaultValue, "CURRENT_TIMESTAMP") {
+			fields = append(fields, field)
+		}
+	}
+	if len(fields) == 0 {
+		return
+	}
+
+	now := time.Now()
+	fill := func(rv reflect.Value) {
+		for _, field := range fields {
+			if _, zero := field.ValueOf(db.Statement.Context, rv); zero {
+				_ = field.Set(db.Statement.Context, rv, now)
+			}
+		}
+	}
+	rv := reflect.Indirect(db.Statement.ReflectValue)
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < rv.Len(); i++ {
+			fill(reflect.Indirect(rv.Index(i)))
+		}
+	case reflect.Struct:
+		fill(rv)
+	}
+}
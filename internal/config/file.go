@@ -0,0 +1,228 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// defaultConfigFile is read when CONFIG_FILE isn't set; it may be missing.
+const defaultConfigFile = "config.yaml"
+
+// fileKeys maps the dotted paths of the config file to the environment
+// variables they stand in for. Environment variables win over the file.
+var fileKeys = map[string]string{
+	"server.port":       "PORT",
+	"server.env":        "ENV",
+	"server.body_limit": "BODY_LIMIT",
+	"grpc.enabled":      "GRPC_ENABLED",
+	"grpc.port":         "GRPC_PORT",
+	"admin.token":       "ADMIN_TOKEN",
+	"admin.ui_enabled":  "ADMIN_UI_ENABLED",
+
+	"email_intake.enabled":       "EMAIL_INTAKE_ENABLED",
+	"email_intake.token":         "EMAIL_INTAKE_TOKEN",
+	"email_intake.address":       "EMAIL_INTAKE_ADDRESS",
+	"email_intake.api_key":       "EMAIL_INTAKE_API_KEY",
+	"email_intake.job_codes":     "EMAIL_INTAKE_JOB_CODES",
+	"email_intake.auto_evaluate": "EMAIL_INTAKE_AUTO_EVALUATE",
+
+	"server.request_timeout": "REQUEST_TIMEOUT",
+	"server.upload_timeout":  "UPLOAD_TIMEOUT",
+
+	"server.tls.cert_file":          "TLS_CERT_FILE",
+	"server.tls.key_file":           "TLS_KEY_FILE",
+	"server.tls.autocert_domains":   "TLS_AUTOCERT_DOMAINS",
+	"server.tls.autocert_cache_dir": "TLS_AUTOCERT_CACHE_DIR",
+	"server.tls.redirect_port":      "TLS_REDIRECT_PORT",
+
+	"database.driver":   "DB_DRIVER",
+	"database.path":     "DB_PATH",
+	"database.host":     "DB_HOST",
+	"database.port":     "DB_PORT",
+	"database.user":     "DB_USER",
+	"database.password": "DB_PASSWORD",
+	"database.name":     "DB_NAME",
+
+	"providers.llm":                          "LLM_PROVIDER",
+	"providers.gemini.api_key":               "GEMINI_API_KEY",
+	"providers.gemini.embedding_model":       "GEMINI_EMBEDDING_MODEL",
+	"providers.gemini.embedding_dimensions":  "EMBEDDING_DIMENSIONS",
+	"providers.gemini.embedding_cache":       "EMBEDDING_CACHE_ENABLED",
+	"providers.gemini.max_in_flight":         "GEMINI_MAX_IN_FLIGHT",
+	"providers.gemini.max_queue":             "GEMINI_MAX_QUEUE",
+	"providers.gemini.input_price_per_mtok":  "GEMINI_INPUT_PRICE_PER_MTOK",
+	"providers.gemini.output_price_per_mtok": "GEMINI_OUTPUT_PRICE_PER_MTOK",
+	"providers.gemini.cache_ttl":             "LLM_CACHE_TTL",
+	"providers.gemini.allowed_models":        "GEMINI_ALLOWED_MODELS",
+	"providers.gemini.max_output_tokens":     "GEMINI_MAX_OUTPUT_TOKENS",
+	"providers.gemini.top_p":                 "GEMINI_TOP_P",
+	"providers.gemini.top_k":                 "GEMINI_TOP_K",
+	"providers.gemini.safety_settings":       "GEMINI_SAFETY_SETTINGS",
+	"providers.gemini.canary.model":          "CANARY_MODEL",
+	"providers.gemini.canary.percent":        "CANARY_PERCENT",
+	"providers.gemini.shadow.model":          "SHADOW_MODEL",
+	"providers.gemini.shadow.temperature":    "SHADOW_TEMPERATURE",
+	"providers.gemini.shadow.percent":        "SHADOW_PERCENT",
+	"providers.gemini.batch.flush_interval":  "GEMINI_BATCH_FLUSH_INTERVAL",
+	"providers.gemini.batch.poll_interval":   "GEMINI_BATCH_POLL_INTERVAL",
+	"providers.gemini.batch.max_requests":    "GEMINI_BATCH_MAX_REQUESTS",
+	"providers.gemini.vision_max_images":     "GEMINI_VISION_MAX_IMAGES",
+	"providers.vector_store":                 "VECTOR_STORE",
+	"providers.qdrant.url":                   "QDRANT_URL",
+	"providers.qdrant.api_key":               "QDRANT_API_KEY",
+	"providers.qdrant.collection":            "QDRANT_COLLECTION",
+	"providers.qdrant.http_url":              "QDRANT_HTTP_URL",
+	"providers.qdrant.snapshot_path":         "QDRANT_SNAPSHOT_PATH",
+	"providers.qdrant.tenant_collections":    "QDRANT_TENANT_COLLECTIONS",
+	"providers.weaviate.url":                 "WEAVIATE_URL",
+	"providers.weaviate.api_key":             "WEAVIATE_API_KEY",
+	"providers.weaviate.class":               "WEAVIATE_CLASS",
+
+	"storage.upload_path":              "UPLOAD_PATH",
+	"storage.max_file_size":            "MAX_FILE_SIZE",
+	"storage.allowed_file_types":       "ALLOWED_FILE_TYPES",
+	"storage.reconcile_interval":       "STORAGE_RECONCILE_INTERVAL",
+	"storage.reconcile_cleanup":        "STORAGE_RECONCILE_CLEANUP",
+	"storage.upload_session_ttl":       "UPLOAD_SESSION_TTL",
+	"storage.require_upload_pair":      "UPLOAD_REQUIRE_PAIR",
+	"storage.download_signing_key":     "DOWNLOAD_SIGNING_KEY",
+	"storage.download_url_ttl":         "DOWNLOAD_URL_TTL",
+	"storage.url_upload_enabled":       "URL_UPLOAD_ENABLED",
+	"storage.url_upload_timeout":       "URL_UPLOAD_TIMEOUT",
+	"storage.url_upload_allow_private": "URL_UPLOAD_ALLOW_PRIVATE",
+	"storage.pdf_engine":               "PDF_ENGINE",
+	"storage.pdf_fallback_engine":      "PDF_FALLBACK_ENGINE",
+	"storage.pdftotext_path":           "PDFTOTEXT_PATH",
+	"storage.max_pages":                "PARSE_MAX_PAGES",
+	"storage.max_text_length":          "PARSE_MAX_TEXT_LENGTH",
+	"storage.contact_extraction":       "CONTACT_EXTRACTION",
+
+	"queue.concurrency":                     "WORKER_CONCURRENCY",
+	"queue.max_concurrency":                 "WORKER_MAX_CONCURRENCY",
+	"queue.autoscale_interval":              "WORKER_AUTOSCALE_INTERVAL",
+	"queue.size":                            "WORKER_QUEUE_SIZE",
+	"queue.max_backlog":                     "WORKER_MAX_BACKLOG",
+	"queue.shutdown_grace":                  "WORKER_SHUTDOWN_GRACE",
+	"queue.ttl":                             "EVALUATION_QUEUE_TTL",
+	"queue.retry.max_attempts":              "RETRY_MAX_ATTEMPTS",
+	"queue.retry.parse_max_attempts":        "PARSE_MAX_ATTEMPTS",
+	"queue.retry.vector_query_max_attempts": "VECTOR_QUERY_MAX_ATTEMPTS",
+	"queue.retry.initial_delay":             "RETRY_INITIAL_DELAY",
+	"queue.timeouts.parse":                  "PARSE_TIMEOUT",
+	"queue.timeouts.llm_call":               "LLM_CALL_TIMEOUT",
+	"queue.timeouts.vector_query":           "VECTOR_QUERY_TIMEOUT",
+	"queue.breaker.max_failures":            "BREAKER_MAX_FAILURES",
+	"queue.breaker.open_timeout":            "BREAKER_OPEN_TIMEOUT",
+
+	"prompts.token_budget":              "PROMPT_TOKEN_BUDGET",
+	"prompts.context_share":             "PROMPT_CONTEXT_SHARE",
+	"prompts.scoring.max_output_tokens": "GEMINI_SCORING_MAX_OUTPUT_TOKENS",
+	"prompts.scoring.top_p":             "GEMINI_SCORING_TOP_P",
+	"prompts.scoring.top_k":             "GEMINI_SCORING_TOP_K",
+	"prompts.summary.max_output_tokens": "GEMINI_SUMMARY_MAX_OUTPUT_TOKENS",
+	"prompts.summary.top_p":             "GEMINI_SUMMARY_TOP_P",
+	"prompts.summary.top_k":             "GEMINI_SUMMARY_TOP_K",
+
+	"retrieval.hybrid":           "RETRIEVAL_HYBRID",
+	"retrieval.candidates":       "RETRIEVAL_CANDIDATES",
+	"retrieval.min_score":        "RETRIEVAL_MIN_SCORE",
+	"retrieval.rerank":           "RETRIEVAL_RERANK",
+	"retrieval.rerank_min_score": "RETRIEVAL_RERANK_MIN_SCORE",
+	"retrieval.mmr_lambda":       "RETRIEVAL_MMR_LAMBDA",
+
+	"quota.monthly_uploads":     "QUOTA_MONTHLY_UPLOADS",
+	"quota.monthly_evaluations": "QUOTA_MONTHLY_EVALUATIONS",
+
+	"retention.period":           "RETENTION_PERIOD",
+	"retention.interval":         "RETENTION_INTERVAL",
+	"retention.tenant_overrides": "RETENTION_TENANT_OVERRIDES",
+
+	"startup.connect_retries":       "STARTUP_CONNECT_RETRIES",
+	"startup.connect_initial_delay": "STARTUP_CONNECT_INITIAL_DELAY",
+	"startup.connect_max_wait":      "STARTUP_CONNECT_MAX_WAIT",
+}
+
+// fileValues holds the config file's settings by environment variable.
+var fileValues = map[string]string{}
+
+// loadConfigFile reads the YAML config file at path into fileValues. A
+// missing file is only an error when required.
+func loadConfigFile(path string, required bool) error {
+	raw, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) && !required {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var doc map[string]interface{}
+	if err := yaml.Unmarshal(raw, &doc); err != nil {
+		return fmt.Errorf("invalid YAML: %w", err)
+	}
+
+	values := map[string]string{}
+	if err := flattenConfig("", doc, values); err != nil {
+		return err
+	}
+	fileValues = values
+	return nil
+}
+
+// flattenConfig walks the sections of node into values. Lists become
+// comma-separated and maps of a single setting "key:value" pairs, the way
+// the environment variables spell them.
+func flattenConfig(prefix string, node map[string]interface{}, values map[string]string) error {
+	for key, value := range node {
+		path := key
+		if prefix != "" {
+			path = prefix + "." + key
+		}
+
+		if env, ok := fileKeys[path]; ok {
+			s, err := configValue(value)
+			if err != nil {
+				return fmt.Errorf("%s: %w", path, err)
+			}
+			values[env] = s
+			continue
+		}
+
+		section, ok := value.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("unknown setting %s", path)
+		}
+		if err := flattenConfig(path, section, values); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func configValue(value interface{}) (string, error) {
+	switch v := value.(type) {
+	case nil:
+		return "", nil
+	case []interface{}:
+		items := make([]string, len(v))
+		for i, item := range v {
+			items[i] = fmt.Sprint(item)
+		}
+		return strings.Join(items, ","), nil
+	case map[string]interface{}:
+		pairs := make([]string, 0, len(v))
+		for k, item := range v {
+			pairs = append(pairs, fmt.Sprintf("%s:%v", k, item))
+		}
+		sort.Strings(pairs)
+		return strings.Join(pairs, ","), nil
+	default:
+		return fmt.Sprint(v), nil
+	}
+}
@@ -0,0 +1,58 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+)
+
+type permanentError struct {
+	err error
+}
+
+func (e permanentError) Error() string { return e.err.Error() }
+func (e permanentError) Unwrap() error { return e.err }
+
+// Permanent marks a connect error that retrying won't fix, such as a
+// misconfiguration, so WaitFor returns it straight away.
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return permanentError{err: err}
+}
+
+// WaitFor calls connect until it succeeds, backing off exponentially from
+// StartupConfig.ConnectInitialDelay, for up to ConnectRetries retries and
+// ConnectMaxWait in total. It smooths over dependencies that start after
+// the API, e.g. with docker-compose.
+func WaitFor(name string, opts StartupConfig, connect func() error) error {
+	deadline := time.Now().Add(opts.ConnectMaxWait)
+	delay := opts.ConnectInitialDelay
+	if delay <= 0 {
+		delay = time.Second
+	}
+
+	for attempt := 0; ; attempt++ {
+		err := connect()
+		if err == nil {
+			return nil
+		}
+		var permanent permanentError
+		if errors.As(err, &permanent) {
+			return permanent.err
+		}
+
+		remaining := time.Until(deadline)
+		if attempt >= opts.ConnectRetries || remaining <= 0 {
+			return fmt.Errorf("%s unavailable after %d attempts: %w", name, attempt+1, err)
+		}
+		if delay > remaining {
+			delay = remaining
+		}
+		log.Printf("⏳ %s not ready (%v), retrying in %s", name, err, delay)
+		time.Sleep(delay)
+		delay *= 2
+	}
+}
@@ -0,0 +1,269 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"alfredoptarigan/cv-evaluator/internal/secrets"
+)
+
+// maxUploadSize keeps MAX_FILE_SIZE within what a single upload can
+// reasonably be buffered and parsed as.
+const maxUploadSize = 1 << 30
+
+// Validate checks the configuration before anything is started, so a bad
+// setting fails the startup with every problem listed instead of failing
+// at first use. Errors name the variables, never secret values.
+func (c *Config) Validate() error {
+	var problems []string
+	addf := func(format string, args ...any) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+
+	problems = append(problems, c.parseProblems...)
+	problems = append(problems, c.secretProblems()...)
+
+	if !validPort(c.Server.Port) {
+		addf("PORT %q is not a valid port", c.Server.Port)
+	}
+	if c.GRPC.Enabled && !validPort(c.GRPC.Port) {
+		addf("GRPC_PORT %q is not a valid port", c.GRPC.Port)
+	}
+	if c.Server.BodyLimit <= 0 {
+		addf("BODY_LIMIT must be positive")
+	}
+	if c.Server.RequestTimeout <= 0 {
+		addf("REQUEST_TIMEOUT must be positive")
+	}
+	if c.Server.UploadTimeout < c.Server.RequestTimeout {
+		addf("UPLOAD_TIMEOUT must be at least REQUEST_TIMEOUT")
+	}
+	if (c.Server.TLSCertFile == "") != (c.Server.TLSKeyFile == "") {
+		addf("TLS_CERT_FILE and TLS_KEY_FILE must be set together")
+	}
+	if c.Server.TLSCertFile != "" && len(c.Server.TLSAutocertDomains) > 0 {
+		addf("TLS_CERT_FILE and TLS_AUTOCERT_DOMAINS can't both be set")
+	}
+	if len(c.Server.TLSAutocertDomains) > 0 && c.Server.TLSAutocertCacheDir == "" {
+		addf("TLS_AUTOCERT_CACHE_DIR is not set")
+	}
+	if c.Server.TLSRedirectPort != "" {
+		switch {
+		case !c.Server.TLSEnabled():
+			addf("TLS_REDIRECT_PORT needs TLS_CERT_FILE or TLS_AUTOCERT_DOMAINS")
+		case !validPort(c.Server.TLSRedirectPort):
+			addf("TLS_REDIRECT_PORT %q is not a valid port", c.Server.TLSRedirectPort)
+		case c.Server.TLSRedirectPort == c.Server.Port:
+			addf("TLS_REDIRECT_PORT must differ from PORT")
+		}
+	}
+
+	switch c.Database.Driver {
+	case DriverPostgres, DriverMySQL:
+		if c.Database.Host == "" {
+			addf("DB_HOST is not set")
+		}
+		if !validPort(c.Database.Port) {
+			addf("DB_PORT %q is not a valid port", c.Database.Port)
+		}
+	case DriverSQLite:
+		if c.Database.Path == "" {
+			addf("DB_PATH is not set")
+		}
+	default:
+		addf("DB_DRIVER %q is not one of %s, %s, %s", c.Database.Driver, DriverPostgres, DriverMySQL, DriverSQLite)
+	}
+	switch c.LLMProvider {
+	case "gemini", "mock":
+	default:
+		addf("LLM_PROVIDER %q is not one of gemini, mock", c.LLMProvider)
+	}
+
+	if c.VectorStore == "pgvector" && c.Database.Driver != DriverPostgres {
+		addf("VECTOR_STORE=pgvector needs DB_DRIVER=%s", DriverPostgres)
+	}
+
+	switch c.VectorStore {
+	case "qdrant":
+		if err := validURL(c.Qdrant.URL); err != nil {
+			addf("QDRANT_URL %v", err)
+		}
+		if c.Qdrant.HTTPURL != "" {
+			if err := validURL(c.Qdrant.HTTPURL); err != nil {
+				addf("QDRANT_HTTP_URL %v", err)
+			}
+		}
+		if c.Qdrant.Collection == "" {
+			addf("QDRANT_COLLECTION is not set")
+		}
+	case "weaviate":
+		if err := validURL(c.Weaviate.URL); err != nil {
+			addf("WEAVIATE_URL %v", err)
+		}
+		if c.Weaviate.Class == "" {
+			addf("WEAVIATE_CLASS is not set")
+		}
+	case "pgvector", "memory":
+	default:
+		addf("VECTOR_STORE %q is not one of qdrant, pgvector, weaviate, memory", c.VectorStore)
+	}
+
+	if c.Gemini.EmbeddingDimensions <= 0 {
+		addf("EMBEDDING_DIMENSIONS must be positive")
+	}
+	if c.Gemini.MaxInFlight <= 0 {
+		addf("GEMINI_MAX_IN_FLIGHT must be positive")
+	}
+	if len(c.Gemini.AllowedModels) == 0 {
+		addf("GEMINI_ALLOWED_MODELS is empty")
+	}
+	if c.Gemini.PromptTokenBudget <= 0 {
+		addf("PROMPT_TOKEN_BUDGET must be positive")
+	}
+	if c.Gemini.PromptContextShare < 0 || c.Gemini.PromptContextShare > 1 {
+		addf("PROMPT_CONTEXT_SHARE must be between 0 and 1")
+	}
+	if c.Gemini.CanaryPercent < 0 || c.Gemini.CanaryPercent > 100 {
+		addf("CANARY_PERCENT must be between 0 and 100")
+	}
+	if c.Gemini.ShadowPercent < 0 || c.Gemini.ShadowPercent > 100 {
+		addf("SHADOW_PERCENT must be between 0 and 100")
+	}
+	if c.Gemini.BatchMaxRequests <= 0 {
+		addf("GEMINI_BATCH_MAX_REQUESTS must be positive")
+	}
+	if c.Gemini.VisionMaxImages < 0 {
+		addf("GEMINI_VISION_MAX_IMAGES must not be negative")
+	}
+
+	if c.Storage.UploadPath == "" {
+		addf("UPLOAD_PATH is not set")
+	}
+	if c.Storage.MaxFileSize <= 0 || c.Storage.MaxFileSize > maxUploadSize {
+		addf("MAX_FILE_SIZE must be between 1 and %d bytes", maxUploadSize)
+	}
+	if len(c.Storage.AllowedFileTypes) == 0 {
+		addf("ALLOWED_FILE_TYPES is empty")
+	}
+	if c.Storage.URLUploadEnabled && c.Storage.URLUploadTimeout <= 0 {
+		addf("URL_UPLOAD_TIMEOUT must be positive")
+	}
+	if c.Storage.MaxPages < 0 {
+		addf("PARSE_MAX_PAGES must not be negative")
+	}
+	if c.Storage.MaxTextLength < 0 {
+		addf("PARSE_MAX_TEXT_LENGTH must not be negative")
+	}
+	if !validPDFEngine(c.Storage.PDFEngine) {
+		addf("PDF_ENGINE %q is not one of builtin, pdftotext", c.Storage.PDFEngine)
+	}
+	if c.Storage.PDFFallbackEngine != "" {
+		if !validPDFEngine(c.Storage.PDFFallbackEngine) {
+			addf("PDF_FALLBACK_ENGINE %q is not one of builtin, pdftotext", c.Storage.PDFFallbackEngine)
+		} else if c.Storage.PDFFallbackEngine == c.Storage.PDFEngine {
+			addf("PDF_FALLBACK_ENGINE must differ from PDF_ENGINE")
+		}
+	}
+	switch c.Storage.ContactExtraction {
+	case "off", "hashed", "plain":
+	default:
+		addf("CONTACT_EXTRACTION %q is not one of off, hashed, plain", c.Storage.ContactExtraction)
+	}
+
+	if c.Worker.Concurrency <= 0 {
+		addf("WORKER_CONCURRENCY must be positive")
+	}
+	if c.Worker.MaxConcurrency < 0 {
+		addf("WORKER_MAX_CONCURRENCY must not be negative")
+	}
+	if c.Worker.QueueSize <= 0 {
+		addf("WORKER_QUEUE_SIZE must be positive")
+	}
+	if c.Worker.QueueTTL < 0 {
+		addf("EVALUATION_QUEUE_TTL must not be negative")
+	}
+	if c.Worker.RetryMaxAttempts <= 0 {
+		addf("RETRY_MAX_ATTEMPTS must be positive")
+	}
+	if c.Worker.ParseMaxAttempts <= 0 {
+		addf("PARSE_MAX_ATTEMPTS must be positive")
+	}
+	if c.Worker.VectorQueryMaxAttempts <= 0 {
+		addf("VECTOR_QUERY_MAX_ATTEMPTS must be positive")
+	}
+	if c.Worker.RetryInitialDelay < 0 {
+		addf("RETRY_INITIAL_DELAY must not be negative")
+	}
+
+	if c.Retrieval.Candidates <= 0 {
+		addf("RETRIEVAL_CANDIDATES must be positive")
+	}
+	if c.Retrieval.MMRLambda < 0 || c.Retrieval.MMRLambda > 1 {
+		addf("RETRIEVAL_MMR_LAMBDA must be between 0 and 1")
+	}
+
+	if c.EmailIntake.AutoEvaluate && len(c.EmailIntake.JobCodes) == 0 {
+		addf("EMAIL_INTAKE_AUTO_EVALUATE needs EMAIL_INTAKE_JOB_CODES")
+	}
+
+	if c.Startup.ConnectRetries < 0 {
+		addf("STARTUP_CONNECT_RETRIES must not be negative")
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("invalid configuration:\n  - %s", strings.Join(problems, "\n  - "))
+	}
+	return nil
+}
+
+// secretProblems reports a missing required secret and any configured
+// secret that looks like a placeholder copied from an example.
+func (c *Config) secretProblems() []string {
+	checks := []struct {
+		env      string
+		value    string
+		required bool
+	}{
+		{"GEMINI_API_KEY", c.Gemini.APIKey, c.LLMProvider != "mock"},
+		{"DB_PASSWORD", c.Database.Password, false},
+		{"QDRANT_API_KEY", c.Qdrant.APIKey, false},
+		{"WEAVIATE_API_KEY", c.Weaviate.APIKey, false},
+		{"ADMIN_TOKEN", c.Admin.Token, false},
+		{"DOWNLOAD_SIGNING_KEY", c.Storage.DownloadSigningKey, false},
+		{"EMAIL_INTAKE_TOKEN", c.EmailIntake.Token, c.EmailIntake.Enabled},
+		{"EMAIL_INTAKE_API_KEY", c.EmailIntake.APIKey, false},
+	}
+
+	var problems []string
+	for _, check := range checks {
+		switch {
+		case check.value == "" && check.required:
+			problems = append(problems, check.env+" is not set")
+		case secrets.LooksLikePlaceholder(check.value):
+			problems = append(problems, check.env+" looks like a placeholder")
+		}
+	}
+	return problems
+}
+
+func validPDFEngine(name string) bool {
+	return name == "builtin" || name == "pdftotext"
+}
+
+func validPort(port string) bool {
+	n, err := strconv.Atoi(port)
+	return err == nil && n > 0 && n <= 65535
+}
+
+func validURL(raw string) error {
+	if raw == "" {
+		return fmt.Errorf("is not set")
+	}
+	parsed, err := url.Parse(raw)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return fmt.Errorf("%q is not an http(s) URL", raw)
+	}
+	return nil
+}
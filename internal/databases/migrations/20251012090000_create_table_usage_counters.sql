@@ -0,0 +1,28 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS usage_counters (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    tenant_id TEXT NOT NULL,
+    period VARCHAR(7) NOT NULL, -- 'YYYY-MM' (UTC)
+    uploads BIGINT NOT NULL DEFAULT 0,
+    evaluations BIGINT NOT NULL DEFAULT 0,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (tenant_id, period)
+);
+
+ALTER TABLE documents ADD COLUMN IF NOT EXISTS tenant_id TEXT NOT NULL DEFAULT 'anonymous';
+ALTER TABLE evaluations ADD COLUMN IF NOT EXISTS tenant_id TEXT NOT NULL DEFAULT 'anonymous';
+
+CREATE INDEX IF NOT EXISTS idx_documents_tenant_id ON documents(tenant_id);
+CREATE INDEX IF NOT EXISTS idx_evaluations_tenant_id ON evaluations(tenant_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_evaluations_tenant_id;
+DROP INDEX IF EXISTS idx_documents_tenant_id;
+ALTER TABLE evaluations DROP COLUMN IF EXISTS tenant_id;
+ALTER TABLE documents DROP COLUMN IF EXISTS tenant_id;
+DROP TABLE IF EXISTS usage_counters;
+-- +goose StatementEnd
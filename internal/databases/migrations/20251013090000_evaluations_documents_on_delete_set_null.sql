@@ -0,0 +1,30 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Let the retention job delete documents while keeping the evaluation results
+ALTER TABLE evaluations DROP CONSTRAINT IF EXISTS evaluations_cv_document_id_fkey;
+ALTER TABLE evaluations DROP CONSTRAINT IF EXISTS evaluations_project_document_id_fkey;
+
+ALTER TABLE evaluations
+    ADD CONSTRAINT evaluations_cv_document_id_fkey
+    FOREIGN KEY (cv_document_id) REFERENCES documents(id) ON DELETE SET NULL;
+ALTER TABLE evaluations
+    ADD CONSTRAINT evaluations_project_document_id_fkey
+    FOREIGN KEY (project_document_id) REFERENCES documents(id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_documents_created_at ON documents(created_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_documents_created_at;
+
+ALTER TABLE evaluations DROP CONSTRAINT IF EXISTS evaluations_cv_document_id_fkey;
+ALTER TABLE evaluations DROP CONSTRAINT IF EXISTS evaluations_project_document_id_fkey;
+
+ALTER TABLE evaluations
+    ADD CONSTRAINT evaluations_cv_document_id_fkey
+    FOREIGN KEY (cv_document_id) REFERENCES documents(id);
+ALTER TABLE evaluations
+    ADD CONSTRAINT evaluations_project_document_id_fkey
+    FOREIGN KEY (project_document_id) REFERENCES documents(id);
+-- +goose StatementEnd
@@ -0,0 +1,12 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE documents ADD COLUMN IF NOT EXISTS content_hash VARCHAR(64);
+
+CREATE INDEX IF NOT EXISTS idx_documents_tenant_content_hash ON documents(tenant_id, content_hash);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_documents_tenant_content_hash;
+ALTER TABLE documents DROP COLUMN IF EXISTS content_hash;
+-- +goose StatementEnd
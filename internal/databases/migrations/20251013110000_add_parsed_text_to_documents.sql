@@ -0,0 +1,11 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE documents ADD COLUMN IF NOT EXISTS parsed_text TEXT;
+ALTER TABLE documents ADD COLUMN IF NOT EXISTS page_count INTEGER NOT NULL DEFAULT 0;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE documents DROP COLUMN IF EXISTS page_count;
+ALTER TABLE documents DROP COLUMN IF EXISTS parsed_text;
+-- +goose StatementEnd
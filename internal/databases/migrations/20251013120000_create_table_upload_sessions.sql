@@ -0,0 +1,23 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS upload_sessions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    tenant_id TEXT NOT NULL DEFAULT 'anonymous',
+    original_name TEXT NOT NULL,
+    file_type TEXT NOT NULL,
+    size BIGINT NOT NULL,
+    received_bytes BIGINT NOT NULL DEFAULT 0,
+    document_id UUID REFERENCES documents(id) ON DELETE SET NULL,
+    expires_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_upload_sessions_expires_at ON upload_sessions(expires_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_upload_sessions_expires_at;
+DROP TABLE IF EXISTS upload_sessions;
+-- +goose StatementEnd
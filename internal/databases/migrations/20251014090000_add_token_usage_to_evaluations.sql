@@ -0,0 +1,13 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE evaluations ADD COLUMN IF NOT EXISTS prompt_tokens BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE evaluations ADD COLUMN IF NOT EXISTS completion_tokens BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE evaluations ADD COLUMN IF NOT EXISTS estimated_cost_usd NUMERIC(12, 6) NOT NULL DEFAULT 0;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE evaluations DROP COLUMN IF EXISTS estimated_cost_usd;
+ALTER TABLE evaluations DROP COLUMN IF EXISTS completion_tokens;
+ALTER TABLE evaluations DROP COLUMN IF EXISTS prompt_tokens;
+-- +goose StatementEnd
@@ -0,0 +1,21 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS llm_cache (
+    key VARCHAR(64) PRIMARY KEY, -- sha256(model, temperature, prompt)
+    model TEXT NOT NULL,
+    response TEXT NOT NULL,
+    expires_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_llm_cache_expires_at ON llm_cache(expires_at);
+
+ALTER TABLE evaluations ADD COLUMN IF NOT EXISTS bypass_cache BOOLEAN NOT NULL DEFAULT FALSE;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE evaluations DROP COLUMN IF EXISTS bypass_cache;
+DROP INDEX IF EXISTS idx_llm_cache_expires_at;
+DROP TABLE IF EXISTS llm_cache;
+-- +goose StatementEnd
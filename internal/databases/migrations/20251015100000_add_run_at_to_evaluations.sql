@@ -0,0 +1,13 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE evaluations ADD COLUMN IF NOT EXISTS run_at TIMESTAMP;
+ALTER TABLE evaluation_outbox ADD COLUMN IF NOT EXISTS available_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP;
+CREATE INDEX IF NOT EXISTS idx_evaluation_outbox_available_at ON evaluation_outbox(available_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_evaluation_outbox_available_at;
+ALTER TABLE evaluation_outbox DROP COLUMN IF EXISTS available_at;
+ALTER TABLE evaluations DROP COLUMN IF EXISTS run_at;
+-- +goose StatementEnd
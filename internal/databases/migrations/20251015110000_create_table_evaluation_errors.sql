@@ -0,0 +1,23 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE evaluations ADD COLUMN IF NOT EXISTS attempts INT NOT NULL DEFAULT 0;
+ALTER TABLE evaluations ADD COLUMN IF NOT EXISTS last_error TEXT;
+
+CREATE TABLE IF NOT EXISTS evaluation_errors (
+    id BIGSERIAL PRIMARY KEY,
+    evaluation_id UUID NOT NULL REFERENCES evaluations(id) ON DELETE CASCADE,
+    attempt INT NOT NULL,
+    stage TEXT NOT NULL,
+    message TEXT NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_evaluation_errors_evaluation_id ON evaluation_errors(evaluation_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS evaluation_errors;
+ALTER TABLE evaluations DROP COLUMN IF EXISTS last_error;
+ALTER TABLE evaluations DROP COLUMN IF EXISTS attempts;
+-- +goose StatementEnd
@@ -0,0 +1,11 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE evaluations ADD COLUMN IF NOT EXISTS cv_result TEXT;
+ALTER TABLE evaluations ADD COLUMN IF NOT EXISTS project_result TEXT;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE evaluations DROP COLUMN IF EXISTS project_result;
+ALTER TABLE evaluations DROP COLUMN IF EXISTS cv_result;
+-- +goose StatementEnd
@@ -0,0 +1,33 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS evaluation_checkpoints (
+    evaluation_id UUID NOT NULL REFERENCES evaluations(id) ON DELETE CASCADE,
+    stage TEXT NOT NULL,
+    output TEXT NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (evaluation_id, stage)
+);
+
+INSERT INTO evaluation_checkpoints (evaluation_id, stage, output)
+SELECT id, 'cv_result', cv_result FROM evaluations WHERE cv_result <> ''
+ON CONFLICT DO NOTHING;
+INSERT INTO evaluation_checkpoints (evaluation_id, stage, output)
+SELECT id, 'project_result', project_result FROM evaluations WHERE project_result <> ''
+ON CONFLICT DO NOTHING;
+
+ALTER TABLE evaluations DROP COLUMN IF EXISTS project_result;
+ALTER TABLE evaluations DROP COLUMN IF EXISTS cv_result;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE evaluations ADD COLUMN IF NOT EXISTS cv_result TEXT;
+ALTER TABLE evaluations ADD COLUMN IF NOT EXISTS project_result TEXT;
+
+UPDATE evaluations e SET cv_result = c.output
+FROM evaluation_checkpoints c WHERE c.evaluation_id = e.id AND c.stage = 'cv_result';
+UPDATE evaluations e SET project_result = c.output
+FROM evaluation_checkpoints c WHERE c.evaluation_id = e.id AND c.stage = 'project_result';
+
+DROP TABLE IF EXISTS evaluation_checkpoints;
+-- +goose StatementEnd
@@ -0,0 +1,14 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE evaluations ADD COLUMN IF NOT EXISTS started_at TIMESTAMP;
+ALTER TABLE evaluations ADD COLUMN IF NOT EXISTS completed_at TIMESTAMP;
+
+CREATE INDEX IF NOT EXISTS idx_evaluations_completed_at ON evaluations(completed_at) WHERE completed_at IS NOT NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_evaluations_completed_at;
+ALTER TABLE evaluations DROP COLUMN IF EXISTS completed_at;
+ALTER TABLE evaluations DROP COLUMN IF EXISTS started_at;
+-- +goose StatementEnd
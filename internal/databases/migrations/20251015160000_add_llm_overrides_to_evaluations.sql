@@ -0,0 +1,11 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE evaluations ADD COLUMN IF NOT EXISTS model VARCHAR(100);
+ALTER TABLE evaluations ADD COLUMN IF NOT EXISTS temperature REAL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE evaluations DROP COLUMN IF EXISTS temperature;
+ALTER TABLE evaluations DROP COLUMN IF EXISTS model;
+-- +goose StatementEnd
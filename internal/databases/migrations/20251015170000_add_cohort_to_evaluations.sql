@@ -0,0 +1,10 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE evaluations ADD COLUMN IF NOT EXISTS cohort VARCHAR(20) NOT NULL DEFAULT 'stable';
+UPDATE evaluations SET cohort = 'override' WHERE model <> '';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE evaluations DROP COLUMN IF EXISTS cohort;
+-- +goose StatementEnd
@@ -0,0 +1,26 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS evaluation_shadows (
+    id BIGSERIAL PRIMARY KEY,
+    evaluation_id UUID NOT NULL REFERENCES evaluations(id) ON DELETE CASCADE,
+    model VARCHAR(100) NOT NULL,
+    temperature REAL,
+    cv_match_rate DECIMAL(3,2),
+    cv_feedback TEXT,
+    project_score DECIMAL(3,2),
+    project_feedback TEXT,
+    overall_summary TEXT,
+    error_message TEXT,
+    prompt_tokens BIGINT NOT NULL DEFAULT 0,
+    completion_tokens BIGINT NOT NULL DEFAULT 0,
+    estimated_cost_usd NUMERIC(12, 6) NOT NULL DEFAULT 0,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_evaluation_shadows_evaluation_id ON evaluation_shadows(evaluation_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS evaluation_shadows;
+-- +goose StatementEnd
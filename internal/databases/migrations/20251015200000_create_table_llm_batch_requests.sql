@@ -0,0 +1,28 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE evaluations ADD COLUMN IF NOT EXISTS execution_mode VARCHAR(20) NOT NULL DEFAULT 'interactive';
+
+CREATE TABLE IF NOT EXISTS llm_batch_requests (
+    key VARCHAR(64) PRIMARY KEY,
+    model TEXT NOT NULL,
+    temperature REAL NOT NULL,
+    prompt TEXT NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    job_name TEXT,
+    batch_index INT NOT NULL DEFAULT 0,
+    response TEXT,
+    error_message TEXT,
+    prompt_tokens BIGINT NOT NULL DEFAULT 0,
+    completion_tokens BIGINT NOT NULL DEFAULT 0,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_llm_batch_requests_status ON llm_batch_requests(status, created_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS llm_batch_requests;
+ALTER TABLE evaluations DROP COLUMN IF EXISTS execution_mode;
+-- +goose StatementEnd
@@ -0,0 +1,9 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE llm_batch_requests ADD COLUMN IF NOT EXISTS generation_params TEXT;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE llm_batch_requests DROP COLUMN IF EXISTS generation_params;
+-- +goose StatementEnd
@@ -0,0 +1,22 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS erasure_reports (
+    id UUID PRIMARY KEY,
+    tenant_id TEXT NOT NULL,
+    subject_hash VARCHAR(64), -- sha256 of the lower-cased email
+    document_ids TEXT,        -- JSON arrays
+    evaluation_ids TEXT,
+    files_deleted INT NOT NULL DEFAULT 0,
+    vectors_deleted INT NOT NULL DEFAULT 0,
+    upload_sessions_deleted BIGINT NOT NULL DEFAULT 0,
+    failures TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_erasure_reports_tenant_id ON erasure_reports(tenant_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS erasure_reports;
+-- +goose StatementEnd
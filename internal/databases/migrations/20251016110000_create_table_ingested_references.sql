@@ -0,0 +1,19 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS ingested_references (
+    tenant_id VARCHAR(255) NOT NULL, -- empty for shared documents
+    document_id VARCHAR(255) NOT NULL,
+    doc_type VARCHAR(50) NOT NULL,
+    name TEXT NOT NULL,
+    content_hash VARCHAR(64) NOT NULL, -- sha256 of the file
+    embedding_model VARCHAR(100) NOT NULL,
+    chunks INT NOT NULL DEFAULT 0,
+    ingested_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (tenant_id, document_id)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS ingested_references;
+-- +goose StatementEnd
@@ -0,0 +1,17 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS document_pairs (
+    id UUID PRIMARY KEY,
+    tenant_id TEXT NOT NULL DEFAULT 'anonymous',
+    cv_document_id UUID NOT NULL REFERENCES documents(id) ON DELETE CASCADE,
+    project_document_id UUID NOT NULL REFERENCES documents(id) ON DELETE CASCADE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_document_pairs_tenant_id ON document_pairs(tenant_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS document_pairs;
+-- +goose StatementEnd
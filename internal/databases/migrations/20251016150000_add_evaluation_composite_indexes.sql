@@ -0,0 +1,31 @@
+-- +goose Up
+-- Composite indexes matching how evaluations are queried: by status in
+-- creation order (dispatch recovery, expiry, listings), by tenant for the
+-- list endpoint and by documents and job title for duplicate detection.
+-- The single-column status and tenant indexes are prefixes of the new ones.
+-- Partitioning by created_at was left out: it would need created_at in the
+-- primary key, which the tables referencing evaluations(id) can't follow.
+-- +goose StatementBegin
+CREATE INDEX IF NOT EXISTS idx_evaluations_status_created_at ON evaluations(status, created_at, id);
+CREATE INDEX IF NOT EXISTS idx_evaluations_tenant_created_at ON evaluations(tenant_id, created_at, id);
+CREATE INDEX IF NOT EXISTS idx_evaluations_tenant_status_created_at ON evaluations(tenant_id, status, created_at, id);
+CREATE INDEX IF NOT EXISTS idx_evaluations_documents_job_title ON evaluations(cv_document_id, project_document_id, job_title);
+CREATE INDEX IF NOT EXISTS idx_evaluations_project_document_id ON evaluations(project_document_id);
+CREATE INDEX IF NOT EXISTS idx_evaluations_queue_order ON evaluations((COALESCE(run_at, created_at)), id) WHERE status = 'queued';
+
+DROP INDEX IF EXISTS idx_evaluations_status;
+DROP INDEX IF EXISTS idx_evaluations_tenant_id;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+CREATE INDEX IF NOT EXISTS idx_evaluations_status ON evaluations(status);
+CREATE INDEX IF NOT EXISTS idx_evaluations_tenant_id ON evaluations(tenant_id);
+
+DROP INDEX IF EXISTS idx_evaluations_queue_order;
+DROP INDEX IF EXISTS idx_evaluations_project_document_id;
+DROP INDEX IF EXISTS idx_evaluations_documents_job_title;
+DROP INDEX IF EXISTS idx_evaluations_tenant_status_created_at;
+DROP INDEX IF EXISTS idx_evaluations_tenant_created_at;
+DROP INDEX IF EXISTS idx_evaluations_status_created_at;
+-- +goose StatementEnd
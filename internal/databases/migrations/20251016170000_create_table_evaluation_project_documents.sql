@@ -0,0 +1,16 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS evaluation_project_documents (
+    evaluation_id UUID NOT NULL REFERENCES evaluations(id) ON DELETE CASCADE,
+    document_id UUID NOT NULL REFERENCES documents(id) ON DELETE CASCADE,
+    position INTEGER NOT NULL,
+    PRIMARY KEY (evaluation_id, document_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_evaluation_project_documents_document_id ON evaluation_project_documents(document_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS evaluation_project_documents;
+-- +goose StatementEnd
@@ -0,0 +1,13 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE documents ADD COLUMN IF NOT EXISTS candidate_name TEXT;
+ALTER TABLE documents ADD COLUMN IF NOT EXISTS candidate_email VARCHAR(255);
+ALTER TABLE documents ADD COLUMN IF NOT EXISTS candidate_phone VARCHAR(255);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE documents DROP COLUMN IF EXISTS candidate_phone;
+ALTER TABLE documents DROP COLUMN IF EXISTS candidate_email;
+ALTER TABLE documents DROP COLUMN IF EXISTS candidate_name;
+-- +goose StatementEnd
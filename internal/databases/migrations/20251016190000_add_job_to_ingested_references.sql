@@ -0,0 +1,9 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE ingested_references ADD COLUMN IF NOT EXISTS job VARCHAR(255) NOT NULL DEFAULT '';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE ingested_references DROP COLUMN IF EXISTS job;
+-- +goose StatementEnd
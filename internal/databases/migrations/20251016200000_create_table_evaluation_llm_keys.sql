@@ -0,0 +1,20 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS evaluation_llm_keys (
+    evaluation_id UUID NOT NULL REFERENCES evaluations(id) ON DELETE CASCADE,
+    key VARCHAR(64) NOT NULL, -- llm_cache / llm_batch_requests key
+    PRIMARY KEY (evaluation_id, key)
+);
+
+CREATE INDEX IF NOT EXISTS idx_evaluation_llm_keys_key ON evaluation_llm_keys(key);
+
+ALTER TABLE erasure_reports ADD COLUMN IF NOT EXISTS llm_cache_entries_deleted BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE erasure_reports ADD COLUMN IF NOT EXISTS llm_batch_requests_deleted BIGINT NOT NULL DEFAULT 0;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE erasure_reports DROP COLUMN IF EXISTS llm_batch_requests_deleted;
+ALTER TABLE erasure_reports DROP COLUMN IF EXISTS llm_cache_entries_deleted;
+DROP TABLE IF EXISTS evaluation_llm_keys;
+-- +goose StatementEnd
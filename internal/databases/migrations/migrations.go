@@ -0,0 +1,9 @@
+// Package migrations holds the goose SQL migrations, embedded so the
+// migrate command carries them in its binary. The top level is Postgres;
+// mysql/ has the MySQL/MariaDB set.
+package migrations
+
+import "embed"
+
+//go:embed *.sql mysql/*.sql
+var FS embed.FS
@@ -0,0 +1,203 @@
+-- +goose Up
+-- MySQL 8 / MariaDB 10.6+ baseline, equivalent to the Postgres migrations up
+-- to 20251015210000. Later schema changes need a migration in both sets.
+CREATE TABLE IF NOT EXISTS documents (
+    id CHAR(36) PRIMARY KEY,
+    filename TEXT NOT NULL,
+    original_name TEXT NOT NULL,
+    file_type VARCHAR(255) NOT NULL,
+    file_path TEXT NOT NULL,
+    tenant_id VARCHAR(255) NOT NULL DEFAULT 'anonymous',
+    content_hash VARCHAR(64),
+    parsed_text LONGTEXT,
+    page_count INT NOT NULL DEFAULT 0,
+    created_at DATETIME(6) DEFAULT CURRENT_TIMESTAMP(6),
+    updated_at DATETIME(6) DEFAULT CURRENT_TIMESTAMP(6),
+    INDEX idx_documents_tenant_id (tenant_id),
+    INDEX idx_documents_created_at (created_at),
+    INDEX idx_documents_tenant_content_hash (tenant_id, content_hash)
+);
+
+CREATE TABLE IF NOT EXISTS evaluations (
+    id CHAR(36) PRIMARY KEY,
+    job_title VARCHAR(255) NOT NULL,
+    cv_document_id CHAR(36),
+    project_document_id CHAR(36),
+    status VARCHAR(50) NOT NULL,
+    cv_match_rate DECIMAL(3,2),
+    cv_feedback TEXT,
+    project_score DECIMAL(3,2),
+    project_feedback TEXT,
+    overall_summary TEXT,
+    error_message TEXT,
+    tenant_id VARCHAR(255) NOT NULL DEFAULT 'anonymous',
+    bypass_cache BOOLEAN NOT NULL DEFAULT FALSE,
+    prompt_tokens BIGINT NOT NULL DEFAULT 0,
+    completion_tokens BIGINT NOT NULL DEFAULT 0,
+    estimated_cost_usd DECIMAL(12, 6) NOT NULL DEFAULT 0,
+    attempts INT NOT NULL DEFAULT 0,
+    last_error TEXT,
+    run_at DATETIME(6),
+    model VARCHAR(100),
+    temperature FLOAT,
+    cohort VARCHAR(20) NOT NULL DEFAULT 'stable',
+    execution_mode VARCHAR(20) NOT NULL DEFAULT 'interactive',
+    started_at DATETIME(6),
+    completed_at DATETIME(6),
+    created_at DATETIME(6) DEFAULT CURRENT_TIMESTAMP(6),
+    updated_at DATETIME(6) DEFAULT CURRENT_TIMESTAMP(6),
+    INDEX idx_evaluations_status (status),
+    INDEX idx_evaluations_created_at (created_at),
+    INDEX idx_evaluations_tenant_id (tenant_id),
+    INDEX idx_evaluations_completed_at (completed_at),
+    CONSTRAINT evaluations_cv_document_id_fkey
+        FOREIGN KEY (cv_document_id) REFERENCES documents(id) ON DELETE SET NULL,
+    CONSTRAINT evaluations_project_document_id_fkey
+        FOREIGN KEY (project_document_id) REFERENCES documents(id) ON DELETE SET NULL
+);
+
+CREATE TABLE IF NOT EXISTS usage_counters (
+    id CHAR(36) PRIMARY KEY,
+    tenant_id VARCHAR(255) NOT NULL,
+    period VARCHAR(7) NOT NULL,
+    uploads BIGINT NOT NULL DEFAULT 0,
+    evaluations BIGINT NOT NULL DEFAULT 0,
+    created_at DATETIME(6) DEFAULT CURRENT_TIMESTAMP(6),
+    updated_at DATETIME(6) DEFAULT CURRENT_TIMESTAMP(6),
+    UNIQUE (tenant_id, period)
+);
+
+CREATE TABLE IF NOT EXISTS upload_sessions (
+    id CHAR(36) PRIMARY KEY,
+    tenant_id VARCHAR(255) NOT NULL DEFAULT 'anonymous',
+    original_name TEXT NOT NULL,
+    file_type TEXT NOT NULL,
+    size BIGINT NOT NULL,
+    received_bytes BIGINT NOT NULL DEFAULT 0,
+    document_id CHAR(36),
+    expires_at DATETIME(6) NOT NULL,
+    created_at DATETIME(6) DEFAULT CURRENT_TIMESTAMP(6),
+    updated_at DATETIME(6) DEFAULT CURRENT_TIMESTAMP(6),
+    INDEX idx_upload_sessions_expires_at (expires_at),
+    FOREIGN KEY (document_id) REFERENCES documents(id) ON DELETE SET NULL
+);
+
+CREATE TABLE IF NOT EXISTS llm_cache (
+    `key` VARCHAR(64) PRIMARY KEY,
+    model TEXT NOT NULL,
+    response LONGTEXT NOT NULL,
+    expires_at DATETIME(6) NOT NULL,
+    created_at DATETIME(6) DEFAULT CURRENT_TIMESTAMP(6),
+    INDEX idx_llm_cache_expires_at (expires_at)
+);
+
+CREATE TABLE IF NOT EXISTS embedding_cache (
+    `key` VARCHAR(64) PRIMARY KEY,
+    model TEXT NOT NULL,
+    embedding LONGBLOB NOT NULL,
+    created_at DATETIME(6) DEFAULT CURRENT_TIMESTAMP(6)
+);
+
+CREATE TABLE IF NOT EXISTS evaluation_outbox (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    evaluation_id CHAR(36) NOT NULL,
+    available_at DATETIME(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+    created_at DATETIME(6) DEFAULT CURRENT_TIMESTAMP(6),
+    INDEX idx_evaluation_outbox_available_at (available_at),
+    FOREIGN KEY (evaluation_id) REFERENCES evaluations(id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS evaluation_errors (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    evaluation_id CHAR(36) NOT NULL,
+    attempt INT NOT NULL,
+    stage VARCHAR(50) NOT NULL,
+    message TEXT NOT NULL,
+    created_at DATETIME(6) DEFAULT CURRENT_TIMESTAMP(6),
+    INDEX idx_evaluation_errors_evaluation_id (evaluation_id),
+    FOREIGN KEY (evaluation_id) REFERENCES evaluations(id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS evaluation_checkpoints (
+    evaluation_id CHAR(36) NOT NULL,
+    stage VARCHAR(50) NOT NULL,
+    output LONGTEXT NOT NULL,
+    created_at DATETIME(6) DEFAULT CURRENT_TIMESTAMP(6),
+    PRIMARY KEY (evaluation_id, stage),
+    FOREIGN KEY (evaluation_id) REFERENCES evaluations(id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS evaluation_events (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    evaluation_id CHAR(36) NOT NULL,
+    type VARCHAR(50) NOT NULL,
+    detail TEXT,
+    created_at DATETIME(6) DEFAULT CURRENT_TIMESTAMP(6),
+    INDEX idx_evaluation_events_evaluation_id (evaluation_id),
+    FOREIGN KEY (evaluation_id) REFERENCES evaluations(id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS evaluation_shadows (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    evaluation_id CHAR(36) NOT NULL,
+    model VARCHAR(100) NOT NULL,
+    temperature FLOAT,
+    cv_match_rate DECIMAL(3,2),
+    cv_feedback TEXT,
+    project_score DECIMAL(3,2),
+    project_feedback TEXT,
+    overall_summary TEXT,
+    error_message TEXT,
+    prompt_tokens BIGINT NOT NULL DEFAULT 0,
+    completion_tokens BIGINT NOT NULL DEFAULT 0,
+    estimated_cost_usd DECIMAL(12, 6) NOT NULL DEFAULT 0,
+    created_at DATETIME(6) DEFAULT CURRENT_TIMESTAMP(6),
+    INDEX idx_evaluation_shadows_evaluation_id (evaluation_id),
+    FOREIGN KEY (evaluation_id) REFERENCES evaluations(id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS evaluation_corrections (
+    evaluation_id CHAR(36) PRIMARY KEY,
+    cv_match_rate DECIMAL(3,2),
+    cv_feedback TEXT,
+    project_score DECIMAL(3,2),
+    project_feedback TEXT,
+    overall_summary TEXT,
+    note TEXT,
+    created_at DATETIME(6) DEFAULT CURRENT_TIMESTAMP(6),
+    updated_at DATETIME(6) DEFAULT CURRENT_TIMESTAMP(6),
+    FOREIGN KEY (evaluation_id) REFERENCES evaluations(id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS llm_batch_requests (
+    `key` VARCHAR(64) PRIMARY KEY,
+    model TEXT NOT NULL,
+    temperature FLOAT NOT NULL,
+    generation_params TEXT,
+    prompt LONGTEXT NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    job_name TEXT,
+    batch_index INT NOT NULL DEFAULT 0,
+    response LONGTEXT,
+    error_message TEXT,
+    prompt_tokens BIGINT NOT NULL DEFAULT 0,
+    completion_tokens BIGINT NOT NULL DEFAULT 0,
+    created_at DATETIME(6) DEFAULT CURRENT_TIMESTAMP(6),
+    updated_at DATETIME(6) DEFAULT CURRENT_TIMESTAMP(6),
+    INDEX idx_llm_batch_requests_status (status, created_at)
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS llm_batch_requests;
+DROP TABLE IF EXISTS evaluation_corrections;
+DROP TABLE IF EXISTS evaluation_shadows;
+DROP TABLE IF EXISTS evaluation_events;
+DROP TABLE IF EXISTS evaluation_checkpoints;
+DROP TABLE IF EXISTS evaluation_errors;
+DROP TABLE IF EXISTS evaluation_outbox;
+DROP TABLE IF EXISTS embedding_cache;
+DROP TABLE IF EXISTS llm_cache;
+DROP TABLE IF EXISTS upload_sessions;
+DROP TABLE IF EXISTS usage_counters;
+DROP TABLE IF EXISTS evaluations;
+DROP TABLE IF EXISTS documents;
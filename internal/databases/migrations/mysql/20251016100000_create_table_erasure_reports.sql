@@ -0,0 +1,17 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS erasure_reports (
+    id CHAR(36) PRIMARY KEY,
+    tenant_id VARCHAR(255) NOT NULL,
+    subject_hash VARCHAR(64),
+    document_ids TEXT,
+    evaluation_ids TEXT,
+    files_deleted INT NOT NULL DEFAULT 0,
+    vectors_deleted INT NOT NULL DEFAULT 0,
+    upload_sessions_deleted BIGINT NOT NULL DEFAULT 0,
+    failures TEXT,
+    created_at DATETIME(6) DEFAULT CURRENT_TIMESTAMP(6),
+    INDEX idx_erasure_reports_tenant_id (tenant_id)
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS erasure_reports;
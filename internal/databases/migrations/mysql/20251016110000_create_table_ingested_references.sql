@@ -0,0 +1,15 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS ingested_references (
+    tenant_id VARCHAR(255) NOT NULL,
+    document_id VARCHAR(255) NOT NULL,
+    doc_type VARCHAR(50) NOT NULL,
+    name TEXT NOT NULL,
+    content_hash VARCHAR(64) NOT NULL,
+    embedding_model VARCHAR(100) NOT NULL,
+    chunks INT NOT NULL DEFAULT 0,
+    ingested_at DATETIME(6) NOT NULL,
+    PRIMARY KEY (tenant_id, document_id)
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS ingested_references;
@@ -0,0 +1,14 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS evaluation_responses (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    evaluation_id CHAR(36) NOT NULL,
+    attempt INT NOT NULL,
+    stage VARCHAR(32) NOT NULL,
+    response LONGTEXT NOT NULL,
+    created_at DATETIME(6) DEFAULT CURRENT_TIMESTAMP(6),
+    INDEX idx_evaluation_responses_evaluation_id (evaluation_id),
+    FOREIGN KEY (evaluation_id) REFERENCES evaluations(id) ON DELETE CASCADE
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS evaluation_responses;
@@ -0,0 +1,5 @@
+-- +goose Up
+ALTER TABLE evaluations ADD COLUMN rag_context_missing BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- +goose Down
+ALTER TABLE evaluations DROP COLUMN rag_context_missing;
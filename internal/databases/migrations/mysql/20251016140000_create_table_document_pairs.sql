@@ -0,0 +1,14 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS document_pairs (
+    id CHAR(36) PRIMARY KEY,
+    tenant_id VARCHAR(255) NOT NULL DEFAULT 'anonymous',
+    cv_document_id CHAR(36) NOT NULL,
+    project_document_id CHAR(36) NOT NULL,
+    created_at DATETIME(6) DEFAULT CURRENT_TIMESTAMP(6),
+    INDEX idx_document_pairs_tenant_id (tenant_id),
+    FOREIGN KEY (cv_document_id) REFERENCES documents(id) ON DELETE CASCADE,
+    FOREIGN KEY (project_document_id) REFERENCES documents(id) ON DELETE CASCADE
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS document_pairs;
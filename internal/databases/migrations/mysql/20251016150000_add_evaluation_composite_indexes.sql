@@ -0,0 +1,20 @@
+-- +goose Up
+-- See the Postgres migration. The document foreign keys already have
+-- indexes here, and the queue order index is left out since MariaDB has no
+-- functional indexes.
+CREATE INDEX idx_evaluations_status_created_at ON evaluations (status, created_at, id);
+CREATE INDEX idx_evaluations_tenant_created_at ON evaluations (tenant_id, created_at, id);
+CREATE INDEX idx_evaluations_tenant_status_created_at ON evaluations (tenant_id, status, created_at, id);
+CREATE INDEX idx_evaluations_documents_job_title ON evaluations (cv_document_id, project_document_id, job_title);
+
+DROP INDEX idx_evaluations_status ON evaluations;
+DROP INDEX idx_evaluations_tenant_id ON evaluations;
+
+-- +goose Down
+CREATE INDEX idx_evaluations_status ON evaluations (status);
+CREATE INDEX idx_evaluations_tenant_id ON evaluations (tenant_id);
+
+DROP INDEX idx_evaluations_documents_job_title ON evaluations;
+DROP INDEX idx_evaluations_tenant_status_created_at ON evaluations;
+DROP INDEX idx_evaluations_tenant_created_at ON evaluations;
+DROP INDEX idx_evaluations_status_created_at ON evaluations;
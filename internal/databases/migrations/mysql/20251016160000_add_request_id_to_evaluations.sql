@@ -0,0 +1,5 @@
+-- +goose Up
+ALTER TABLE evaluations ADD COLUMN request_id VARCHAR(128);
+
+-- +goose Down
+ALTER TABLE evaluations DROP COLUMN request_id;
@@ -0,0 +1,13 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS evaluation_project_documents (
+    evaluation_id CHAR(36) NOT NULL,
+    document_id CHAR(36) NOT NULL,
+    position INT NOT NULL,
+    PRIMARY KEY (evaluation_id, document_id),
+    INDEX idx_evaluation_project_documents_document_id (document_id),
+    FOREIGN KEY (evaluation_id) REFERENCES evaluations(id) ON DELETE CASCADE,
+    FOREIGN KEY (document_id) REFERENCES documents(id) ON DELETE CASCADE
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS evaluation_project_documents;
@@ -0,0 +1,9 @@
+-- +goose Up
+ALTER TABLE documents ADD COLUMN candidate_name TEXT;
+ALTER TABLE documents ADD COLUMN candidate_email VARCHAR(255);
+ALTER TABLE documents ADD COLUMN candidate_phone VARCHAR(255);
+
+-- +goose Down
+ALTER TABLE documents DROP COLUMN candidate_phone;
+ALTER TABLE documents DROP COLUMN candidate_email;
+ALTER TABLE documents DROP COLUMN candidate_name;
@@ -0,0 +1,5 @@
+-- +goose Up
+ALTER TABLE ingested_references ADD COLUMN job VARCHAR(255) NOT NULL DEFAULT '';
+
+-- +goose Down
+ALTER TABLE ingested_references DROP COLUMN job;
@@ -0,0 +1,16 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS evaluation_llm_keys (
+    evaluation_id CHAR(36) NOT NULL,
+    `key` VARCHAR(64) NOT NULL,
+    PRIMARY KEY (evaluation_id, `key`),
+    INDEX idx_evaluation_llm_keys_key (`key`),
+    FOREIGN KEY (evaluation_id) REFERENCES evaluations(id) ON DELETE CASCADE
+);
+
+ALTER TABLE erasure_reports ADD COLUMN llm_cache_entries_deleted BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE erasure_reports ADD COLUMN llm_batch_requests_deleted BIGINT NOT NULL DEFAULT 0;
+
+-- +goose Down
+ALTER TABLE erasure_reports DROP COLUMN llm_batch_requests_deleted;
+ALTER TABLE erasure_reports DROP COLUMN llm_cache_entries_deleted;
+DROP TABLE IF EXISTS evaluation_llm_keys;
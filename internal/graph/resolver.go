@@ -0,0 +1,139 @@
+// Package graph serves the GraphQL API. The schema is in schema.graphqls;
+// generated.go and model/ are generated from it with go generate.
+package graph
+
+//go:generate go tool gqlgen generate --config gqlgen.yml
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/google/uuid"
+
+	"alfredoptarigan/cv-evaluator/internal/graph/model"
+	"alfredoptarigan/cv-evaluator/internal/models"
+	"alfredoptarigan/cv-evaluator/internal/repositories"
+	"alfredoptarigan/cv-evaluator/internal/tenant"
+)
+
+// maxPageSize caps first on every list, as nested lists multiply.
+const maxPageSize = 100
+
+// Resolver resolves the queries of schema.graphqls.
+type Resolver struct {
+	evalRepo repositories.EvaluationRepository
+	docRepo  repositories.DocumentRepository
+}
+
+// NewSchema returns the executable GraphQL schema.
+func NewSchema(evalRepo repositories.EvaluationRepository, docRepo repositories.DocumentRepository) graphql.ExecutableSchema {
+	return NewExecutableSchema(Config{Resolvers: &Resolver{evalRepo: evalRepo, docRepo: docRepo}})
+}
+
+// findEvaluation returns the caller's evaluation, or nil.
+func (r *Resolver) findEvaluation(ctx context.Context, id uuid.UUID) (*models.Evaluation, error) {
+	evaluation, err := r.evalRepo.FindByID(id)
+	if err != nil || evaluation.TenantID != tenant.FromContext(ctx) {
+		return nil, nil
+	}
+	return &evaluation, nil
+}
+
+// findDocument returns the caller's document, or nil.
+func (r *Resolver) findDocument(ctx context.Context, id uuid.UUID) (*models.Document, error) {
+	doc, err := r.docRepo.FindByID(id)
+	if err != nil || doc.TenantID != tenant.FromContext(ctx) {
+		return nil, nil
+	}
+	return doc, nil
+}
+
+// listEvaluations pages through the caller's evaluations matching base
+// and filter.
+func (r *Resolver) listEvaluations(ctx context.Context, base repositories.EvaluationFilter, filter *model.EvaluationFilter, first *int, after *string) (*model.EvaluationConnection, error) {
+	base.TenantID = tenant.FromContext(ctx)
+
+	var err error
+	if base.Limit, err = pageSize(first); err != nil {
+		return nil, err
+	}
+	if base.After, err = parseCursor(after); err != nil {
+		return nil, err
+	}
+	if filter != nil {
+		for _, status := range filter.Statuses {
+			status := models.EvaluationStatus(status)
+			if !status.IsValid() {
+				return nil, fmt.Errorf("unknown status %q", status)
+			}
+			base.Statuses = append(base.Statuses, status)
+		}
+		if filter.JobTitle != nil {
+			base.JobTitle = *filter.JobTitle
+		}
+	}
+
+	evaluations, err := r.evalRepo.List(base)
+	if err != nil {
+		return nil, err
+	}
+
+	connection := &model.EvaluationConnection{Nodes: make([]*models.Evaluation, len(evaluations))}
+	for i := range evaluations {
+		connection.Nodes[i] = &evaluations[i]
+	}
+	connection.NextCursor = cursorString(repositories.NextCursor(evaluations, base.Limit, repositories.EvaluationCursor))
+
+	return connection, nil
+}
+
+// listDocuments pages through the caller's documents of fileType (all if
+// empty).
+func (r *Resolver) listDocuments(ctx context.Context, fileType string, first *int, after *string) ([]models.Document, *string, error) {
+	filter := repositories.DocumentFilter{TenantID: tenant.FromContext(ctx), FileType: fileType}
+
+	var err error
+	if filter.Limit, err = pageSize(first); err != nil {
+		return nil, nil, err
+	}
+	if filter.After, err = parseCursor(after); err != nil {
+		return nil, nil, err
+	}
+
+	docs, err := r.docRepo.List(filter)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return docs, cursorString(repositories.NextCursor(docs, filter.Limit, repositories.DocumentCursor)), nil
+}
+
+func pageSize(first *int) (int, error) {
+	if first == nil {
+		return 20, nil
+	}
+	if *first < 1 || *first > maxPageSize {
+		return 0, fmt.Errorf("first must be between 1 and %d", maxPageSize)
+	}
+	return *first, nil
+}
+
+func parseCursor(after *string) (*repositories.Cursor, error) {
+	if after == nil || *after == "" {
+		return nil, nil
+	}
+	cursor, err := repositories.ParseCursor(*after)
+	if err != nil {
+		return nil, fmt.Errorf("after is not a valid cursor")
+	}
+	return &cursor, nil
+}
+
+func cursorString(cursor *repositories.Cursor) *string {
+	if cursor == nil {
+		return nil
+	}
+	s := cursor.String()
+	return &s
+}
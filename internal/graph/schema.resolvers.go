@@ -0,0 +1,219 @@
+package graph
+
+// This file will be automatically regenerated based on the schema, any resolver implementations
+// will be copied through when generating and any unknown code will be moved to the end.
+// Code generated by github.com/99designs/gqlgen
+
+import (
+	"alfredoptarigan/cv-evaluator/internal/graph/model"
+	"alfredoptarigan/cv-evaluator/internal/models"
+	"alfredoptarigan/cv-evaluator/internal/repositories"
+	"alfredoptarigan/cv-evaluator/internal/tenant"
+	"context"
+
+	"github.com/google/uuid"
+)
+
+// Cv is the resolver for the cv field.
+func (r *candidateResolver) Cv(ctx context.Context, obj *model.Candidate) (*models.Document, error) {
+	return r.findDocument(ctx, obj.ID)
+}
+
+// Evaluations is the resolver for the evaluations field.
+func (r *candidateResolver) Evaluations(ctx context.Context, obj *model.Candidate, filter *model.EvaluationFilter, first *int, after *string) (*model.EvaluationConnection, error) {
+	return r.listEvaluations(ctx, repositories.EvaluationFilter{DocumentID: obj.ID}, filter, first, after)
+}
+
+// Evaluations is the resolver for the evaluations field.
+func (r *documentResolver) Evaluations(ctx context.Context, obj *models.Document, filter *model.EvaluationFilter, first *int, after *string) (*model.EvaluationConnection, error) {
+	return r.listEvaluations(ctx, repositories.EvaluationFilter{DocumentID: obj.ID}, filter, first, after)
+}
+
+// Status is the resolver for the status field.
+func (r *evaluationResolver) Status(ctx context.Context, obj *models.Evaluation) (string, error) {
+	return string(obj.Status), nil
+}
+
+// Job is the resolver for the job field.
+func (r *evaluationResolver) Job(ctx context.Context, obj *models.Evaluation) (*models.JobStats, error) {
+	jobs, err := r.evalRepo.ListJobs(obj.TenantID, obj.JobTitle)
+	if err != nil {
+		return nil, err
+	}
+	if len(jobs) == 0 {
+		return &models.JobStats{JobTitle: obj.JobTitle}, nil
+	}
+	return &jobs[0], nil
+}
+
+// Candidate is the resolver for the candidate field.
+func (r *evaluationResolver) Candidate(ctx context.Context, obj *models.Evaluation) (*model.Candidate, error) {
+	return &model.Candidate{ID: obj.CVDocumentID}, nil
+}
+
+// CvDocument is the resolver for the cvDocument field.
+func (r *evaluationResolver) CvDocument(ctx context.Context, obj *models.Evaluation) (*models.Document, error) {
+	return r.findDocument(ctx, obj.CVDocumentID)
+}
+
+// ProjectDocument is the resolver for the projectDocument field.
+func (r *evaluationResolver) ProjectDocument(ctx context.Context, obj *models.Evaluation) (*models.Document, error) {
+	return r.findDocument(ctx, obj.ProjectDocumentID)
+}
+
+// AdditionalProjectDocuments is the resolver for the additionalProjectDocuments field.
+func (r *evaluationResolver) AdditionalProjectDocuments(ctx context.Context, obj *models.Evaluation) ([]*models.Document, error) {
+	ids, err := r.evalRepo.FindAdditionalProjectDocuments(obj.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	docs := make([]*models.Document, 0, len(ids))
+	for _, id := range ids {
+		doc, err := r.findDocument(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		if doc != nil {
+			docs = append(docs, doc)
+		}
+	}
+	return docs, nil
+}
+
+// Result is the resolver for the result field.
+func (r *evaluationResolver) Result(ctx context.Context, obj *models.Evaluation) (*models.EvaluationData, error) {
+	if !obj.Status.HasResult() {
+		return nil, nil
+	}
+	return &models.EvaluationData{
+		CVMatchRate:       obj.CVMatchRate,
+		CVFeedback:        obj.CVFeedback,
+		ProjectScore:      obj.ProjectScore,
+		ProjectFeedback:   obj.ProjectFeedback,
+		OverallSummary:    obj.OverallSummary,
+		RAGContextMissing: obj.RAGContextMissing,
+	}, nil
+}
+
+// ErrorMessage is the resolver for the errorMessage field.
+func (r *evaluationResolver) ErrorMessage(ctx context.Context, obj *models.Evaluation) (*string, error) {
+	if !obj.Status.IsFailure() || obj.ErrorMessage == "" {
+		return nil, nil
+	}
+	return &obj.ErrorMessage, nil
+}
+
+// Model is the resolver for the model field.
+func (r *evaluationResolver) Model(ctx context.Context, obj *models.Evaluation) (*string, error) {
+	if obj.Model == "" {
+		return nil, nil
+	}
+	return &obj.Model, nil
+}
+
+// Evaluations is the resolver for the evaluations field.
+func (r *jobResolver) Evaluations(ctx context.Context, obj *models.JobStats, filter *model.EvaluationFilter, first *int, after *string) (*model.EvaluationConnection, error) {
+	return r.listEvaluations(ctx, repositories.EvaluationFilter{JobTitle: obj.JobTitle}, filter, first, after)
+}
+
+// Evaluation is the resolver for the evaluation field.
+func (r *queryResolver) Evaluation(ctx context.Context, id uuid.UUID) (*models.Evaluation, error) {
+	return r.findEvaluation(ctx, id)
+}
+
+// Evaluations is the resolver for the evaluations field.
+func (r *queryResolver) Evaluations(ctx context.Context, filter *model.EvaluationFilter, first *int, after *string) (*model.EvaluationConnection, error) {
+	return r.listEvaluations(ctx, repositories.EvaluationFilter{}, filter, first, after)
+}
+
+// Document is the resolver for the document field.
+func (r *queryResolver) Document(ctx context.Context, id uuid.UUID) (*models.Document, error) {
+	return r.findDocument(ctx, id)
+}
+
+// Documents is the resolver for the documents field.
+func (r *queryResolver) Documents(ctx context.Context, fileType *string, first *int, after *string) (*model.DocumentConnection, error) {
+	var only string
+	if fileType != nil {
+		only = *fileType
+	}
+
+	docs, next, err := r.listDocuments(ctx, only, first, after)
+	if err != nil {
+		return nil, err
+	}
+
+	connection := &model.DocumentConnection{Nodes: make([]*models.Document, len(docs)), NextCursor: next}
+	for i := range docs {
+		connection.Nodes[i] = &docs[i]
+	}
+	return connection, nil
+}
+
+// Candidate is the resolver for the candidate field.
+func (r *queryResolver) Candidate(ctx context.Context, id uuid.UUID) (*model.Candidate, error) {
+	doc, err := r.findDocument(ctx, id)
+	if err != nil || doc == nil || doc.FileType != "cv" {
+		return nil, err
+	}
+	return &model.Candidate{ID: doc.ID}, nil
+}
+
+// Candidates is the resolver for the candidates field.
+func (r *queryResolver) Candidates(ctx context.Context, first *int, after *string) (*model.CandidateConnection, error) {
+	docs, next, err := r.listDocuments(ctx, "cv", first, after)
+	if err != nil {
+		return nil, err
+	}
+
+	connection := &model.CandidateConnection{Nodes: make([]*model.Candidate, len(docs)), NextCursor: next}
+	for i, doc := range docs {
+		connection.Nodes[i] = &model.Candidate{ID: doc.ID}
+	}
+	return connection, nil
+}
+
+// Job is the resolver for the job field.
+func (r *queryResolver) Job(ctx context.Context, title string) (*models.JobStats, error) {
+	jobs, err := r.evalRepo.ListJobs(tenant.FromContext(ctx), title)
+	if err != nil || len(jobs) == 0 {
+		return nil, err
+	}
+	return &jobs[0], nil
+}
+
+// Jobs is the resolver for the jobs field.
+func (r *queryResolver) Jobs(ctx context.Context) ([]*models.JobStats, error) {
+	jobs, err := r.evalRepo.ListJobs(tenant.FromContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*models.JobStats, len(jobs))
+	for i := range jobs {
+		result[i] = &jobs[i]
+	}
+	return result, nil
+}
+
+// Candidate returns CandidateResolver implementation.
+func (r *Resolver) Candidate() CandidateResolver { return &candidateResolver{r} }
+
+// Document returns DocumentResolver implementation.
+func (r *Resolver) Document() DocumentResolver { return &documentResolver{r} }
+
+// Evaluation returns EvaluationResolver implementation.
+func (r *Resolver) Evaluation() EvaluationResolver { return &evaluationResolver{r} }
+
+// Job returns JobResolver implementation.
+func (r *Resolver) Job() JobResolver { return &jobResolver{r} }
+
+// Query returns QueryResolver implementation.
+func (r *Resolver) Query() QueryResolver { return &queryResolver{r} }
+
+type candidateResolver struct{ *Resolver }
+type documentResolver struct{ *Resolver }
+type evaluationResolver struct{ *Resolver }
+type jobResolver struct{ *Resolver }
+type queryResolver struct{ *Resolver }
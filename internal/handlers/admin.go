@@ -0,0 +1,30 @@
+package handlers
+
+import (
+	"crypto/subtle"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+
+	"alfredoptarigan/cv-evaluator/internal/apperror"
+)
+
+// AdminTokenHeader is an alternative to "Authorization: Bearer <token>".
+const AdminTokenHeader = "X-Admin-Token"
+
+// RequireAdminToken rejects requests that don't carry the configured admin
+// token.
+func RequireAdminToken(token string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		provided := c.Get(AdminTokenHeader)
+		if provided == "" {
+			provided = strings.TrimPrefix(c.Get(fiber.HeaderAuthorization), "Bearer ")
+		}
+
+		if token == "" || subtle.ConstantTimeCompare([]byte(provided), []byte(token)) != 1 {
+			return apperror.New(fiber.StatusUnauthorized, apperror.CodeUnauthorized, "Invalid admin token")
+		}
+
+		return c.Next()
+	}
+}
@@ -0,0 +1,481 @@
+package handlers
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"mime/multipart"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+
+	"alfredoptarigan/cv-evaluator/internal/apperror"
+	"alfredoptarigan/cv-evaluator/internal/models"
+	"alfredoptarigan/cv-evaluator/internal/repositories"
+	"alfredoptarigan/cv-evaluator/internal/services"
+	"alfredoptarigan/cv-evaluator/internal/tenant"
+)
+
+type AdminHandler struct {
+	reconciler  services.StorageReconciler
+	evalRepo    repositories.EvaluationRepository
+	snapshotter services.VectorSnapshotter
+	evaluator   services.EvaluatorService
+	worker      services.Worker
+	exporter    services.TrainingExporter
+	ingester    services.ReferenceIngester
+	maxFileSize int64
+}
+
+// NewAdminHandler creates the admin handler. snapshotter is nil when the
+// vector store doesn't support snapshots.
+func NewAdminHandler(reconciler services.StorageReconciler, evalRepo repositories.EvaluationRepository, snapshotter services.VectorSnapshotter, evaluator services.EvaluatorService, worker services.Worker, exporter services.TrainingExporter, ingester services.ReferenceIngester, maxFileSize int64) *AdminHandler {
+	return &AdminHandler{
+		reconciler:  reconciler,
+		evalRepo:    evalRepo,
+		snapshotter: snapshotter,
+		evaluator:   evaluator,
+		worker:      worker,
+		exporter:    exporter,
+		ingester:    ingester,
+		maxFileSize: maxFileSize,
+	}
+}
+
+const (
+	defaultDebugMatches = 10
+	maxDebugMatches     = 100
+
+	defaultExcerptChars = 4000
+)
+
+// HandleReconcileStorage handles POST /admin/storage/reconcile
+// Pass ?cleanup=true to delete what was found instead of only reporting it.
+func (h *AdminHandler) HandleReconcileStorage(c *fiber.Ctx) error {
+	report, err := h.reconciler.Reconcile(c.UserContext(), c.QueryBool("cleanup", false))
+	if err != nil {
+		return apperror.Wrap(err, fiber.StatusInternalServerError, apperror.CodeInternal, "Failed to reconcile storage")
+	}
+
+	return c.JSON(report)
+}
+
+// HandleStats handles GET /admin/stats
+func (h *AdminHandler) HandleStats(c *fiber.Ctx) error {
+	stats, err := h.evalRepo.Stats()
+	if err != nil {
+		return apperror.Wrap(err, fiber.StatusInternalServerError, apperror.CodeInternal, "Failed to load stats")
+	}
+
+	return c.JSON(stats)
+}
+
+// HandleWorkers handles GET /admin/workers
+func (h *AdminHandler) HandleWorkers(c *fiber.Ctx) error {
+	return c.JSON(h.worker.Status())
+}
+
+// HandlePauseWorkers handles POST /admin/workers/pause
+// Running jobs finish; queued ones wait until resumed.
+func (h *AdminHandler) HandlePauseWorkers(c *fiber.Ctx) error {
+	h.worker.Pause()
+	return c.JSON(h.worker.Status())
+}
+
+// HandleResumeWorkers handles POST /admin/workers/resume
+func (h *AdminHandler) HandleResumeWorkers(c *fiber.Ctx) error {
+	h.worker.Resume()
+	return c.JSON(h.worker.Status())
+}
+
+// HandleSetConcurrency handles PUT /admin/workers/concurrency
+func (h *AdminHandler) HandleSetConcurrency(c *fiber.Ctx) error {
+	var req models.WorkerConcurrencyRequest
+	if err := parseAndValidate(c, &req); err != nil {
+		return err
+	}
+
+	switch {
+	case req.Autoscale:
+		if err := h.worker.EnableAutoscale(); err != nil {
+			return apperror.Wrap(err, fiber.StatusBadRequest, apperror.CodeInvalidRequest, err.Error())
+		}
+	case req.Concurrency != nil:
+		h.worker.SetConcurrency(*req.Concurrency)
+	default:
+		return apperror.New(fiber.StatusBadRequest, apperror.CodeValidationFailed, "concurrency or autoscale is required")
+	}
+
+	return c.JSON(h.worker.Status())
+}
+
+// HandleShadowResults handles GET /admin/evaluations/:id/shadow
+// Returns the canonical result next to the shadow pipeline's results.
+func (h *AdminHandler) HandleShadowResults(c *fiber.Ctx) error {
+	evalID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return apperror.New(fiber.StatusBadRequest, apperror.CodeInvalidID, "Invalid evaluation ID format")
+	}
+
+	evaluation, err := h.evalRepo.FindByID(evalID)
+	if err != nil {
+		return apperror.New(fiber.StatusNotFound, apperror.CodeEvaluationNotFound, "Evaluation not found")
+	}
+
+	shadows, err := h.evalRepo.FindShadows(evalID)
+	if err != nil {
+		return apperror.Wrap(err, fiber.StatusInternalServerError, apperror.CodeInternal, "Failed to load shadow results")
+	}
+
+	response := models.ShadowComparison{
+		ID:      evaluation.ID.String(),
+		Status:  string(evaluation.Status),
+		Model:   evaluation.Model,
+		Cohort:  evaluation.Cohort,
+		Shadows: shadows,
+	}
+	if evaluation.Status.HasResult() {
+		response.Result = &models.EvaluationData{
+			CVMatchRate:       evaluation.CVMatchRate,
+			CVFeedback:        evaluation.CVFeedback,
+			ProjectScore:      evaluation.ProjectScore,
+			ProjectFeedback:   evaluation.ProjectFeedback,
+			OverallSummary:    evaluation.OverallSummary,
+			RAGContextMissing: evaluation.RAGContextMissing,
+		}
+	}
+
+	return c.JSON(response)
+}
+
+// HandleReplay handles POST /admin/evaluations/:id/replay
+// Parses a failed evaluation's stored LLM responses again, completing it when
+// they cover every stage. Nothing is sent to the LLM.
+func (h *AdminHandler) HandleReplay(c *fiber.Ctx) error {
+	evalID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return apperror.New(fiber.StatusBadRequest, apperror.CodeInvalidID, "Invalid evaluation ID format")
+	}
+
+	if _, err := h.evalRepo.FindByID(evalID); err != nil {
+		return apperror.New(fiber.StatusNotFound, apperror.CodeEvaluationNotFound, "Evaluation not found")
+	}
+
+	replay, err := h.evaluator.Replay(c.UserContext(), evalID)
+	if errors.Is(err, repositories.ErrInvalidTransition) {
+		return apperror.Wrap(err, fiber.StatusConflict, apperror.CodeInvalidRequest, "Only failed or partially completed evaluations can be replayed")
+	}
+	if err != nil {
+		return apperror.Wrap(err, fiber.StatusInternalServerError, apperror.CodeInternal, "Failed to replay evaluation")
+	}
+
+	return c.JSON(replay)
+}
+
+// HandleSaveCorrection handles PUT /admin/evaluations/:id/correction
+// Records a reviewer's fix of a completed evaluation for training exports;
+// the evaluation's own result is left as the model produced it.
+func (h *AdminHandler) HandleSaveCorrection(c *fiber.Ctx) error {
+	evalID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return apperror.New(fiber.StatusBadRequest, apperror.CodeInvalidID, "Invalid evaluation ID format")
+	}
+
+	var req models.EvaluationCorrectionRequest
+	if err := parseAndValidate(c, &req); err != nil {
+		return err
+	}
+
+	evaluation, err := h.evalRepo.FindByID(evalID)
+	if err != nil {
+		return apperror.New(fiber.StatusNotFound, apperror.CodeEvaluationNotFound, "Evaluation not found")
+	}
+	if evaluation.Status != models.StatusCompleted {
+		return apperror.New(fiber.StatusConflict, apperror.CodeInvalidRequest, "Only completed evaluations can be corrected")
+	}
+
+	correction := &models.EvaluationCorrection{
+		EvaluationID:    evalID,
+		CVMatchRate:     req.CVMatchRate,
+		CVFeedback:      req.CVFeedback,
+		ProjectScore:    req.ProjectScore,
+		ProjectFeedback: req.ProjectFeedback,
+		OverallSummary:  req.OverallSummary,
+		Note:            req.Note,
+	}
+	if err := h.evalRepo.SaveCorrection(correction); err != nil {
+		return apperror.Wrap(err, fiber.StatusInternalServerError, apperror.CodeInternal, "Failed to save correction")
+	}
+
+	return c.JSON(correction)
+}
+
+// HandleExportTraining handles GET /admin/exports/training
+// Streams completed evaluations as prompt/completion JSON lines. Takes
+// optional ?since= (RFC 3339), ?limit=, ?excerpt_chars= (default 4000) and
+// ?corrected_only=true.
+func (h *AdminHandler) HandleExportTraining(c *fiber.Ctx) error {
+	opts := services.TrainingExportOptions{
+		Limit:         c.QueryInt("limit", 0),
+		ExcerptChars:  c.QueryInt("excerpt_chars", defaultExcerptChars),
+		CorrectedOnly: c.QueryBool("corrected_only", false),
+	}
+	if since := c.Query("since"); since != "" {
+		t, err := time.Parse(time.RFC3339, since)
+		if err != nil {
+			return apperror.New(fiber.StatusBadRequest, apperror.CodeValidationFailed, "since must be an RFC 3339 time")
+		}
+		opts.Since = t
+	}
+	if opts.Limit < 0 || opts.ExcerptChars < 0 {
+		return apperror.New(fiber.StatusBadRequest, apperror.CodeValidationFailed, "limit and excerpt_chars must not be negative")
+	}
+
+	ctx := context.WithoutCancel(c.UserContext())
+
+	c.Set(fiber.HeaderContentType, "application/x-ndjson")
+	c.Set(fiber.HeaderContentDisposition, `attachment; filename="training.jsonl"`)
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		n, err := h.exporter.Export(ctx, w, opts)
+		if err != nil {
+			log.Printf("❌ Training export failed after %d records: %v\n", n, err)
+			return
+		}
+		log.Printf("📦 Exported %d training records\n", n)
+	})
+
+	return nil
+}
+
+// HandleRetrievalDebug handles GET /admin/retrieval/debug
+// Takes ?query=, optional ?doc_type=, ?k= (default 10), ?tenant_id= to
+// search as that tenant and ?job_title= to search as an evaluation for it.
+func (h *AdminHandler) HandleRetrievalDebug(c *fiber.Ctx) error {
+	query := strings.TrimSpace(c.Query("query"))
+	if query == "" {
+		return apperror.New(fiber.StatusBadRequest, apperror.CodeValidationFailed, "query is required")
+	}
+
+	k := c.QueryInt("k", defaultDebugMatches)
+	if k < 1 || k > maxDebugMatches {
+		return apperror.New(fiber.StatusBadRequest, apperror.CodeValidationFailed, fmt.Sprintf("k must be between 1 and %d", maxDebugMatches))
+	}
+
+	ctx := c.UserContext()
+	if tenantID := c.Query("tenant_id"); tenantID != "" {
+		ctx = tenant.WithID(ctx, tenantID)
+	}
+	if jobTitle := c.Query("job_title"); jobTitle != "" {
+		ctx = services.WithJob(ctx, services.JobKey(jobTitle))
+	}
+
+	debug, err := h.evaluator.DebugRetrieval(ctx, query, c.Query("doc_type"), k)
+	if err != nil {
+		return apperror.Wrap(err, fiber.StatusServiceUnavailable, apperror.CodeServiceUnavailable, "Failed to run retrieval")
+	}
+
+	return c.JSON(debug)
+}
+
+// HandleListSnapshots handles GET /admin/vectors/snapshots
+func (h *AdminHandler) HandleListSnapshots(c *fiber.Ctx) error {
+	snapshots, err := h.snapshotter.ListSnapshots(c.UserContext())
+	if err != nil {
+		return apperror.Wrap(err, fiber.StatusServiceUnavailable, apperror.CodeServiceUnavailable, "Failed to list snapshots")
+	}
+
+	return c.JSON(fiber.Map{"snapshots": snapshots})
+}
+
+// HandleCreateSnapshot handles POST /admin/vectors/snapshots
+func (h *AdminHandler) HandleCreateSnapshot(c *fiber.Ctx) error {
+	snapshot, err := h.snapshotter.CreateSnapshot(c.UserContext())
+	if err != nil {
+		return apperror.Wrap(err, fiber.StatusServiceUnavailable, apperror.CodeServiceUnavailable, "Failed to create snapshot")
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(snapshot)
+}
+
+// HandleRestoreSnapshot handles POST /admin/vectors/snapshots/:name/restore
+func (h *AdminHandler) HandleRestoreSnapshot(c *fiber.Ctx) error {
+	name := c.Params("name")
+	if err := h.snapshotter.RestoreSnapshot(c.UserContext(), name); err != nil {
+		return apperror.Wrap(err, fiber.StatusServiceUnavailable, apperror.CodeServiceUnavailable, "Failed to restore snapshot")
+	}
+
+	return c.JSON(fiber.Map{"restored": name})
+}
+
+// HandleExportVectors handles GET /admin/vectors/export
+// Streams every point with its vector and payload as JSON lines.
+func (h *AdminHandler) HandleExportVectors(c *fiber.Ctx) error {
+	ctx := context.WithoutCancel(c.UserContext())
+
+	c.Set(fiber.HeaderContentType, "application/x-ndjson")
+	c.Set(fiber.HeaderContentDisposition, `attachment; filename="vectors.jsonl"`)
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		n, err := h.snapshotter.ExportPoints(ctx, w)
+		if err != nil {
+			log.Printf("❌ Vector export failed after %d points: %v\n", n, err)
+			return
+		}
+		log.Printf("📦 Exported %d vector points\n", n)
+	})
+
+	return nil
+}
+
+// HandleListReferences handles GET /admin/references
+// Lists the ingested reference documents of every tenant; shared ones have
+// an empty tenant_id.
+func (h *AdminHandler) HandleListReferences(c *fiber.Ctx) error {
+	refs, err := h.ingester.List()
+	if err != nil {
+		return apperror.Wrap(err, fiber.StatusInternalServerError, apperror.CodeInternal, "Failed to list reference documents")
+	}
+
+	return c.JSON(fiber.Map{"references": refs})
+}
+
+// HandleIngestReference handles POST /admin/references
+// Takes a multipart form with the PDF as file, its doc_type, and optional
+// name, job (retrieved for every job if empty), tenant_id (shared with
+// every tenant if empty) and force, like cvctl ingest. The document is embedded before responding.
+func (h *AdminHandler) HandleIngestReference(c *fiber.Ctx) error {
+	mediaType, params, err := mime.ParseMediaType(c.Get(fiber.HeaderContentType))
+	if err != nil || mediaType != fiber.MIMEMultipartForm || params["boundary"] == "" {
+		return apperror.New(fiber.StatusBadRequest, apperror.CodeInvalidRequest, "failed to parse multipart form")
+	}
+
+	body := c.Context().RequestBodyStream()
+	if body == nil {
+		body = bytes.NewReader(c.Body())
+	}
+	reader := multipart.NewReader(io.LimitReader(body, h.maxFileSize+multipartOverhead), params["boundary"])
+
+	// The file is kept under its own name, which the document ID is
+	// derived from
+	dir, err := os.MkdirTemp("", "reference-")
+	if err != nil {
+		return apperror.Wrap(err, fiber.StatusInternalServerError, apperror.CodeInternal, "Failed to store reference document")
+	}
+	defer os.RemoveAll(dir)
+
+	var doc services.ReferenceDocument
+	var tenantID string
+	var force bool
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return apperror.New(fiber.StatusBadRequest, apperror.CodeInvalidRequest, "failed to parse multipart form")
+		}
+
+		if part.FormName() == "file" && part.FileName() != "" {
+			if doc.Path, err = h.saveReference(dir, part); err != nil {
+				return err
+			}
+			continue
+		}
+
+		value, err := io.ReadAll(io.LimitReader(part, 1024))
+		if err != nil {
+			return apperror.New(fiber.StatusBadRequest, apperror.CodeInvalidRequest, "failed to parse multipart form")
+		}
+		switch part.FormName() {
+		case "doc_type":
+			doc.DocType = strings.TrimSpace(string(value))
+		case "name":
+			doc.Name = strings.TrimSpace(string(value))
+		case "job":
+			doc.Job = services.JobKey(string(value))
+		case "tenant_id":
+			tenantID = strings.TrimSpace(string(value))
+		case "force":
+			force = string(value) == "true"
+		}
+	}
+
+	if doc.Path == "" {
+		return apperror.New(fiber.StatusBadRequest, apperror.CodeNoFilesUploaded, "Upload the reference PDF as 'file'.")
+	}
+	if !slices.Contains(services.ReferenceDocTypes, doc.DocType) {
+		return apperror.New(fiber.StatusBadRequest, apperror.CodeValidationFailed, "doc_type must be one of "+strings.Join(services.ReferenceDocTypes, ", "))
+	}
+
+	ctx := c.UserContext()
+	if tenantID != "" {
+		ctx = tenant.WithID(ctx, tenantID)
+	}
+	result, err := h.ingester.Ingest(ctx, doc, force)
+	if err != nil {
+		return apperror.Wrap(err, fiber.StatusServiceUnavailable, apperror.CodeServiceUnavailable, "Failed to ingest reference document")
+	}
+	log.Printf("📄 Ingested reference %s (%s) for tenant %q: %d chunks, unchanged: %t\n", doc.ID(), doc.DocType, tenantID, result.Chunks, result.Unchanged)
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"document_id": doc.ID(),
+		"tenant_id":   tenantID,
+		"job":         doc.Job,
+		"chunks":      result.Chunks,
+		"unchanged":   result.Unchanged,
+	})
+}
+
+// saveReference copies an uploaded reference PDF into dir.
+func (h *AdminHandler) saveReference(dir string, part *multipart.Part) (string, error) {
+	name := filepath.Base(part.FileName())
+	if !strings.EqualFold(filepath.Ext(name), ".pdf") {
+		return "", apperror.New(fiber.StatusBadRequest, apperror.CodeUnsupportedFileType, "reference documents must be PDF files")
+	}
+
+	path := filepath.Join(dir, name)
+	f, err := os.Create(path)
+	if err != nil {
+		return "", apperror.Wrap(err, fiber.StatusInternalServerError, apperror.CodeInternal, "Failed to store reference document")
+	}
+	defer f.Close()
+
+	n, err := io.Copy(f, io.LimitReader(part, h.maxFileSize+1))
+	if err != nil {
+		return "", apperror.New(fiber.StatusBadRequest, apperror.CodeInvalidRequest, "upload was truncated")
+	}
+	if n > h.maxFileSize {
+		return "", apperror.New(fiber.StatusBadRequest, apperror.CodeFileTooLarge, fmt.Sprintf("file too large. Max size: %d bytes", h.maxFileSize))
+	}
+
+	return path, nil
+}
+
+// HandleDeleteReference handles DELETE /admin/references/:id
+// Removes a shared reference document, or the one of ?tenant_id=.
+func (h *AdminHandler) HandleDeleteReference(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	tenantID := c.Query("tenant_id")
+	if tenantID != "" {
+		ctx = tenant.WithID(ctx, tenantID)
+	}
+
+	if err := h.ingester.Delete(ctx, c.Params("id")); err != nil {
+		if errors.Is(err, services.ErrReferenceNotFound) {
+			return apperror.New(fiber.StatusNotFound, apperror.CodeNotFound, "Reference document not found")
+		}
+		return apperror.Wrap(err, fiber.StatusServiceUnavailable, apperror.CodeServiceUnavailable, "Failed to delete reference document")
+	}
+	log.Printf("🗑️  Deleted reference %s for tenant %q\n", c.Params("id"), tenantID)
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
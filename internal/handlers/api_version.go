@@ -0,0 +1,125 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"sort"
+
+	"github.com/gofiber/fiber/v2"
+
+	"alfredoptarigan/cv-evaluator/internal/apperror"
+	"alfredoptarigan/cv-evaluator/internal/models"
+	"alfredoptarigan/cv-evaluator/internal/services"
+)
+
+// APIVersion is a major version of the HTTP API. All versions are served
+// by the same handlers; only the shape of their responses differs.
+type APIVersion int
+
+const (
+	APIv1 APIVersion = 1
+	// APIv2 breaks results down into sub-scores, stages and citations.
+	APIv2 APIVersion = 2
+)
+
+const apiVersionKey = "apiVersion"
+
+// UseAPIVersion tags the requests of a route group with its API version.
+func UseAPIVersion(version APIVersion) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		c.Locals(apiVersionKey, version)
+		return c.Next()
+	}
+}
+
+// apiVersion returns the API version of the request, v1 if untagged.
+func apiVersion(c *fiber.Ctx) APIVersion {
+	if version, ok := c.Locals(apiVersionKey).(APIVersion); ok {
+		return version
+	}
+	return APIv1
+}
+
+// presentResult returns the evaluation in the result shape of version.
+// Results are built in the v1 shape, which later versions map from.
+func (h *ResultHandler) presentResult(version APIVersion, evaluation models.Evaluation) (interface{}, error) {
+	response, err := h.buildResult(evaluation)
+	if err != nil {
+		return nil, err
+	}
+
+	switch version {
+	case APIv2:
+		return h.resultV2(response, evaluation)
+	default:
+		return response, nil
+	}
+}
+
+// resultV2 maps a v1 result to the v2 shape, adding what the stage
+// checkpoints recorded.
+func (h *ResultHandler) resultV2(response models.ResultResponse, evaluation models.Evaluation) (models.ResultResponseV2, error) {
+	checkpoints, err := h.evalRepo.FindCheckpoints(evaluation.ID)
+	if err != nil {
+		return models.ResultResponseV2{}, apperror.Wrap(err, fiber.StatusInternalServerError, apperror.CodeInternal, "Failed to load evaluation stages")
+	}
+	sort.SliceStable(checkpoints, func(i, j int) bool {
+		return checkpoints[i].CreatedAt.Before(checkpoints[j].CreatedAt)
+	})
+
+	v2 := models.ResultResponseV2{
+		ResultResponse: response,
+		Stages:         make([]models.StageProgress, 0, len(checkpoints)),
+	}
+
+	var cvResult *services.CVEvaluationResult
+	var projectResult *services.ProjectEvaluationResult
+	for _, checkpoint := range checkpoints {
+		v2.Stages = append(v2.Stages, models.StageProgress{Stage: checkpoint.Stage, CompletedAt: checkpoint.CreatedAt})
+
+		var target interface{}
+		switch checkpoint.Stage {
+		case models.CheckpointCVResult:
+			cvResult = &services.CVEvaluationResult{}
+			target = cvResult
+		case models.CheckpointProjectResult:
+			projectResult = &services.ProjectEvaluationResult{}
+			target = projectResult
+		default:
+			continue
+		}
+		if err := json.Unmarshal([]byte(checkpoint.Output), target); err != nil {
+			log.Printf("⚠️  Ignoring %s checkpoint of %s: %v\n", checkpoint.Stage, evaluation.ID, err)
+		}
+	}
+
+	if response.Result == nil {
+		return v2, nil
+	}
+
+	// Scores and feedback come from the evaluation like in v1; the
+	// checkpoints add the breakdown, which evaluations scored before it was
+	// recorded lack.
+	result := &models.EvaluationDataV2{
+		OverallSummary:    response.Result.OverallSummary,
+		RAGContextMissing: response.Result.RAGContextMissing,
+	}
+	if cvResult != nil || evaluation.Status == models.StatusCompleted {
+		result.CV = &models.ScoreBreakdown{Score: response.Result.CVMatchRate, Feedback: response.Result.CVFeedback}
+		if cvResult != nil {
+			result.CV.SubScores = cvResult.SubScores()
+			result.CV.Citations = cvResult.Citations
+			result.CV.ExperienceTimeline = cvResult.ExperienceTimeline
+		}
+	}
+	if projectResult != nil || evaluation.Status == models.StatusCompleted {
+		result.Project = &models.ScoreBreakdown{Score: response.Result.ProjectScore, Feedback: response.Result.ProjectFeedback}
+		if projectResult != nil {
+			result.Project.SubScores = projectResult.SubScores()
+			result.Project.Citations = projectResult.Citations
+		}
+	}
+	v2.Result = result
+
+	return v2, nil
+}
@@ -0,0 +1,117 @@
+package handlers
+
+import (
+	"fmt"
+	"io"
+	"mime"
+	"path/filepath"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+
+	"alfredoptarigan/cv-evaluator/internal/apperror"
+	"alfredoptarigan/cv-evaluator/internal/models"
+	"alfredoptarigan/cv-evaluator/internal/services"
+)
+
+// documentContentTypes are served for downloads. HTML is left out, so it
+// downloads as a file rather than running on this origin.
+var documentContentTypes = map[string]string{
+	services.FileTypePDF:  "application/pdf",
+	services.FileTypeDOCX: "application/vnd.openxmlformats-officedocument.wordprocessingml.document",
+	services.FileTypeText: "text/plain; charset=utf-8",
+}
+
+// Preview lengths in characters
+const (
+	defaultPreviewChars = 1000
+	maxPreviewChars     = 10000
+)
+
+type DocumentHandler struct {
+	downloadService services.DownloadService
+	docService      services.DocumentService
+}
+
+func NewDocumentHandler(downloadService services.DownloadService, docService services.DocumentService) *DocumentHandler {
+	return &DocumentHandler{
+		downloadService: downloadService,
+		docService:      docService,
+	}
+}
+
+// HandleDownload handles GET /documents/:id/download
+// Without query parameters the caller must own the document. A valid
+// expires/signature pair from HandleSignDownload grants access without an
+// API key, so links can be opened directly in a browser.
+func (h *DocumentHandler) HandleDownload(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return apperror.New(fiber.StatusBadRequest, apperror.CodeInvalidID, "Invalid document ID format")
+	}
+
+	var (
+		doc  *models.Document
+		file io.ReadCloser
+		size int64
+	)
+	if signature := c.Query("signature"); signature != "" {
+		doc, file, size, err = h.downloadService.OpenSigned(id, int64(c.QueryInt("expires")), signature)
+	} else {
+		doc, file, size, err = h.downloadService.Open(c.UserContext(), id)
+	}
+	if err != nil {
+		return apperror.Wrap(err, fiber.StatusInternalServerError, apperror.CodeInternal, "Failed to open document")
+	}
+
+	contentType, ok := documentContentTypes[services.FileTypeForExtension(filepath.Ext(doc.Filename))]
+	if !ok {
+		contentType = fiber.MIMEOctetStream
+	}
+
+	c.Set(fiber.HeaderContentType, contentType)
+	c.Set(fiber.HeaderContentDisposition, mime.FormatMediaType("inline", map[string]string{"filename": doc.OriginalName}))
+	c.Set(fiber.HeaderCacheControl, "private, no-store")
+	c.Set(fiber.HeaderXContentTypeOptions, "nosniff")
+
+	// fiber closes the stream once it has been written
+	return c.SendStream(file, int(size))
+}
+
+// HandleSignDownload handles POST /documents/:id/download-url
+func (h *DocumentHandler) HandleSignDownload(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return apperror.New(fiber.StatusBadRequest, apperror.CodeInvalidID, "Invalid document ID format")
+	}
+
+	signed, err := h.downloadService.Sign(c.UserContext(), id)
+	if err != nil {
+		return apperror.Wrap(err, fiber.StatusInternalServerError, apperror.CodeInternal, "Failed to sign download link")
+	}
+
+	return c.JSON(signed)
+}
+
+// HandlePreview handles GET /documents/:id/preview
+// Returns the start of the extracted text, ?chars= long (default 1000), with
+// the page count, detected language and section headers.
+func (h *DocumentHandler) HandlePreview(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return apperror.New(fiber.StatusBadRequest, apperror.CodeInvalidID, "Invalid document ID format")
+	}
+
+	chars := c.QueryInt("chars", defaultPreviewChars)
+	if chars < 1 || chars > maxPreviewChars {
+		return apperror.New(fiber.StatusBadRequest, apperror.CodeValidationFailed, fmt.Sprintf("chars must be between 1 and %d", maxPreviewChars))
+	}
+
+	preview, err := h.docService.Preview(c.UserContext(), id, chars)
+	if err != nil {
+		return apperror.Wrap(err, fiber.StatusInternalServerError, apperror.CodeInternal, "Failed to preview document")
+	}
+
+	c.Set(fiber.HeaderCacheControl, "private, no-store")
+	return c.JSON(preview)
+}
@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"mime/multipart"
+	"net/mail"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+
+	"alfredoptarigan/cv-evaluator/internal/apperror"
+	"alfredoptarigan/cv-evaluator/internal/services"
+)
+
+type EmailIntakeHandler struct {
+	intake services.EmailIntakeService
+	token  string
+}
+
+func NewEmailIntakeHandler(intake services.EmailIntakeService, token string) *EmailIntakeHandler {
+	return &EmailIntakeHandler{
+		intake: intake,
+		token:  token,
+	}
+}
+
+// HandleInbound handles POST /intake/email
+// It takes the form SendGrid Inbound Parse posts (to, cc, envelope,
+// subject and attachment1..N), authenticated by ?token=.
+func (h *EmailIntakeHandler) HandleInbound(c *fiber.Ctx) error {
+	if subtle.ConstantTimeCompare([]byte(c.Query("token")), []byte(h.token)) != 1 {
+		return apperror.New(fiber.StatusUnauthorized, apperror.CodeUnauthorized, "Invalid intake token")
+	}
+
+	form, err := c.MultipartForm()
+	if err != nil {
+		return apperror.New(fiber.StatusBadRequest, apperror.CodeInvalidRequest, "failed to parse multipart form")
+	}
+
+	email := services.InboundEmail{
+		Recipients:  inboundRecipients(form),
+		Subject:     formValue(form, "subject"),
+		Attachments: inboundAttachments(form),
+	}
+
+	result, err := h.intake.Receive(c.UserContext(), email)
+	if err != nil {
+		return apperror.Wrap(err, fiber.StatusInternalServerError, apperror.CodeInternal, "failed to process the email")
+	}
+
+	return c.JSON(result)
+}
+
+// inboundRecipients collects the addresses of the To and Cc headers and the
+// SMTP envelope, which also has Bcc recipients.
+func inboundRecipients(form *multipart.Form) []string {
+	var recipients []string
+	for _, field := range []string{"to", "cc"} {
+		list, err := mail.ParseAddressList(formValue(form, field))
+		if err != nil {
+			continue
+		}
+		for _, addr := range list {
+			recipients = append(recipients, addr.Address)
+		}
+	}
+
+	var envelope struct {
+		To []string `json:"to"`
+	}
+	if err := json.Unmarshal([]byte(formValue(form, "envelope")), &envelope); err == nil {
+		recipients = append(recipients, envelope.To...)
+	}
+
+	return recipients
+}
+
+// inboundAttachments returns the attachment1..N files in order.
+func inboundAttachments(form *multipart.Form) []*multipart.FileHeader {
+	type numbered struct {
+		n    int
+		file *multipart.FileHeader
+	}
+
+	var found []numbered
+	for field, files := range form.File {
+		n, err := strconv.Atoi(strings.TrimPrefix(field, "attachment"))
+		if !strings.HasPrefix(field, "attachment") || err != nil {
+			continue
+		}
+		for _, f := range files {
+			found = append(found, numbered{n: n, file: f})
+		}
+	}
+	sort.SliceStable(found, func(i, j int) bool { return found[i].n < found[j].n })
+
+	attachments := make([]*multipart.FileHeader, len(found))
+	for i, a := range found {
+		attachments[i] = a.file
+	}
+	return attachments
+}
+
+func formValue(form *multipart.Form, field string) string {
+	if values := form.Value[field]; len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
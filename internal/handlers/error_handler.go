@@ -0,0 +1,46 @@
+package handlers
+
+import (
+	"log"
+
+	"github.com/gofiber/fiber/v2"
+
+	"alfredoptarigan/cv-evaluator/internal/apperror"
+	"alfredoptarigan/cv-evaluator/internal/models"
+)
+
+// ErrorHandler renders every error returned by a handler as the shared error
+// envelope so clients can branch on a stable code.
+func ErrorHandler(c *fiber.Ctx, err error) error {
+	response := models.ErrorResponse{
+		Status:    fiber.StatusInternalServerError,
+		Code:      string(apperror.CodeInternal),
+		Error:     "Internal server error",
+		RequestID: requestID(c),
+	}
+
+	if appErr, ok := apperror.As(err); ok {
+		response.Status = appErr.Status
+		response.Code = string(appErr.Code)
+		response.Error = appErr.Message
+		response.Fields = appErr.Fields
+		response.Details = appErr.Details
+	} else if e, ok := err.(*fiber.Error); ok {
+		response.Status = e.Code
+		response.Code = string(apperror.CodeForStatus(e.Code))
+		response.Error = e.Message
+	}
+
+	if response.Status >= fiber.StatusInternalServerError {
+		log.Printf("❌ %s %s failed: %v\n", c.Method(), c.Path(), err)
+	}
+
+	return c.Status(response.Status).JSON(response)
+}
+
+func requestID(c *fiber.Ctx) string {
+	if id := c.GetRespHeader(fiber.HeaderXRequestID); id != "" {
+		return id
+	}
+	return c.Get(fiber.HeaderXRequestID)
+}
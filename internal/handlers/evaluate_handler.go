@@ -1,31 +1,20 @@
 package handlers
 
 import (
-	"time"
-
 	"github.com/gofiber/fiber/v2"
-	"github.com/google/uuid"
 
+	"alfredoptarigan/cv-evaluator/internal/apperror"
 	"alfredoptarigan/cv-evaluator/internal/models"
-	"alfredoptarigan/cv-evaluator/internal/repositories"
 	"alfredoptarigan/cv-evaluator/internal/services"
 )
 
 type EvaluationHandler struct {
-	evalRepo repositories.EvaluationRepository
-	docRepo  repositories.DocumentRepository
-	worker   services.Worker
+	evalService services.EvaluationService
 }
 
-func NewEvaluationHandler(
-	evalRepo repositories.EvaluationRepository,
-	docRepo repositories.DocumentRepository,
-	worker services.Worker,
-) *EvaluationHandler {
+func NewEvaluationHandler(evalService services.EvaluationService) *EvaluationHandler {
 	return &EvaluationHandler{
-		evalRepo: evalRepo,
-		docRepo:  docRepo,
-		worker:   worker,
+		evalService: evalService,
 	}
 }
 
@@ -33,82 +22,53 @@ func NewEvaluationHandler(
 func (h *EvaluationHandler) HandleEvaluate(c *fiber.Ctx) error {
 	var req models.EvaluateRequest
 
-	if err := c.BodyParser(&req); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid request payload",
-		})
+	if err := parseAndValidate(c, &req); err != nil {
+		return err
 	}
 
-	if req.JobTitle == "" {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "job_title is required",
-		})
+	cvID, projectID, pairID := req.IDs()
+	input := services.SubmitEvaluationInput{
+		JobTitle:                     req.JobTitle,
+		CVDocumentID:                 cvID,
+		ProjectDocumentID:            projectID,
+		PairID:                       pairID,
+		AdditionalProjectDocumentIDs: req.AdditionalProjectIDs(),
+		BypassCache:                  req.BypassCache,
+		RunAt:                        req.RunAt,
+		Model:                        req.Model,
+		Temperature:                  req.Temperature,
+		ExecutionMode:                models.ExecutionMode(req.ExecutionMode),
+		Force:                        req.Force,
 	}
 
-	if req.CVDocumentID == "" {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "cv_document_id is required",
-		})
+	if req.DryRun {
+		dryRun, err := h.evalService.DryRun(c.UserContext(), input)
+		if err != nil {
+			return apperror.Wrap(err, fiber.StatusInternalServerError, apperror.CodeInternal, "Failed to prepare the evaluation")
+		}
+		return c.JSON(dryRun)
 	}
 
-	if req.ProjectDocumentID == "" {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "project_document_id is required",
-		})
-	}
-
-	// Parse UUIDs
-	cvDocID, err := uuid.Parse(req.CVDocumentID)
+	evaluation, duplicate, err := h.evalService.Submit(c.UserContext(), input)
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid cv_document_id format",
-		})
+		return apperror.Wrap(err, fiber.StatusInternalServerError, apperror.CodeInternal, "Failed to create evaluation job")
 	}
 
-	projectDocID, err := uuid.Parse(req.ProjectDocumentID)
-	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid project_document_id format",
+	// An existing job was returned; nothing new was accepted
+	if duplicate {
+		return c.JSON(models.EvaluateResponse{
+			ID:        evaluation.ID.String(),
+			Status:    string(evaluation.Status),
+			RunAt:     evaluation.RunAt,
+			Duplicate: true,
 		})
 	}
 
-	// Verify documents exist
-	if _, err := h.docRepo.FindByID(cvDocID); err != nil {
-		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-			"error": "CV document not found",
-		})
-	}
-
-	if _, err := h.docRepo.FindByID(projectDocID); err != nil {
-		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-			"error": "Project document not found",
-		})
-	}
-
-	// Create evaluation record
-	evaluation := &models.Evaluation{
-		ID:                uuid.New(),
-		JobTitle:          req.JobTitle,
-		CVDocumentID:      cvDocID,
-		ProjectDocumentID: projectDocID,
-		Status:            models.StatusQueued,
-		CreatedAt:         time.Now(),
-		UpdatedAt:         time.Now(),
-	}
-
-	if err := h.evalRepo.Create(evaluation); err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Failed to create evaluation job",
-		})
-	}
-
-	// Enqueue job to worker
-	h.worker.EnqueueJob(evaluation.ID)
-
 	// Return job ID immediately
 	return c.Status(fiber.StatusAccepted).JSON(models.EvaluateResponse{
 		ID:     evaluation.ID.String(),
 		Status: string(models.StatusQueued),
+		RunAt:  evaluation.RunAt,
 	})
 
 }
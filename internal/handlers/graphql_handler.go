@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/executor"
+	"github.com/99designs/gqlgen/graphql/handler/extension"
+	"github.com/gofiber/fiber/v2"
+
+	"alfredoptarigan/cv-evaluator/internal/apperror"
+)
+
+// graphQLComplexityLimit bounds the fields a single query may resolve, as
+// nested lists otherwise let one request fan out to many queries.
+const graphQLComplexityLimit = 500
+
+type GraphQLHandler struct {
+	executor *executor.Executor
+}
+
+func NewGraphQLHandler(schema graphql.ExecutableSchema) *GraphQLHandler {
+	exec := executor.New(schema)
+	exec.Use(extension.Introspection{})
+	exec.Use(extension.FixedComplexityLimit(graphQLComplexityLimit))
+
+	return &GraphQLHandler{
+		executor: exec,
+	}
+}
+
+// HandleQuery handles POST /graphql
+// Queries run as the caller's tenant, like the REST endpoints.
+func (h *GraphQLHandler) HandleQuery(c *fiber.Ctx) error {
+	var params graphql.RawParams
+	if err := json.Unmarshal(c.Body(), &params); err != nil {
+		return apperror.New(fiber.StatusBadRequest, apperror.CodeInvalidRequest, "Invalid GraphQL request payload")
+	}
+	params.Headers = http.Header{}
+	c.Request().Header.VisitAll(func(key, value []byte) {
+		params.Headers.Add(string(key), string(value))
+	})
+	params.ReadTime = graphql.TraceTiming{Start: graphql.Now(), End: graphql.Now()}
+
+	ctx := graphql.StartOperationTrace(c.UserContext())
+	opCtx, errs := h.executor.CreateOperationContext(ctx, &params)
+	if errs != nil {
+		// The query didn't parse or validate against the schema
+		response := h.executor.DispatchError(graphql.WithOperationContext(ctx, opCtx), errs)
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(response)
+	}
+
+	responses, ctx := h.executor.DispatchOperation(ctx, opCtx)
+	return c.JSON(responses(ctx))
+}
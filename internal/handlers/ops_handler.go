@@ -0,0 +1,35 @@
+package handlers
+
+import (
+	"bytes"
+
+	"github.com/gofiber/fiber/v2"
+
+	"alfredoptarigan/cv-evaluator/internal/metrics"
+	"alfredoptarigan/cv-evaluator/internal/services"
+)
+
+// HandleMetrics handles GET /metrics
+func HandleMetrics(c *fiber.Ctx) error {
+	var buf bytes.Buffer
+	if err := metrics.WriteText(&buf); err != nil {
+		return err
+	}
+
+	c.Set(fiber.HeaderContentType, "text/plain; version=0.0.4; charset=utf-8")
+	return c.Send(buf.Bytes())
+}
+
+// HandleReady handles GET /readyz
+// The service is not ready while any dependency's circuit breaker is open.
+func HandleReady(c *fiber.Ctx) error {
+	status := fiber.StatusOK
+	if len(services.OpenBreakers()) > 0 {
+		status = fiber.StatusServiceUnavailable
+	}
+
+	return c.Status(status).JSON(fiber.Map{
+		"ready":    status == fiber.StatusOK,
+		"breakers": services.BreakerStates(),
+	})
+}
@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+
+	"alfredoptarigan/cv-evaluator/internal/apperror"
+	"alfredoptarigan/cv-evaluator/internal/models"
+	"alfredoptarigan/cv-evaluator/internal/services"
+)
+
+type PrivacyHandler struct {
+	privacyService services.PrivacyService
+}
+
+func NewPrivacyHandler(privacyService services.PrivacyService) *PrivacyHandler {
+	return &PrivacyHandler{
+		privacyService: privacyService,
+	}
+}
+
+// HandleErase handles POST /privacy/erase
+func (h *PrivacyHandler) HandleErase(c *fiber.Ctx) error {
+	var req models.ErasureRequest
+
+	if err := parseAndValidate(c, &req); err != nil {
+		return err
+	}
+
+	input := services.ErasureInput{Email: req.Email}
+	for _, id := range req.DocumentIDs {
+		input.DocumentIDs = append(input.DocumentIDs, uuid.MustParse(id))
+	}
+
+	report, err := h.privacyService.Erase(c.UserContext(), input)
+	if err != nil {
+		return apperror.Wrap(err, fiber.StatusInternalServerError, apperror.CodeInternal, "Failed to erase personal data")
+	}
+
+	return c.JSON(report)
+}
+
+// HandleGetErasure handles GET /privacy/erasures/:id
+func (h *PrivacyHandler) HandleGetErasure(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return apperror.New(fiber.StatusBadRequest, apperror.CodeInvalidID, "Invalid erasure report ID format")
+	}
+
+	report, err := h.privacyService.Report(c.UserContext(), id)
+	if err != nil {
+		return apperror.Wrap(err, fiber.StatusInternalServerError, apperror.CodeInternal, "Failed to load erasure report")
+	}
+
+	return c.JSON(report)
+}
@@ -0,0 +1,14 @@
+package handlers
+
+import (
+	"github.com/gofiber/fiber/v2"
+
+	"alfredoptarigan/cv-evaluator/internal/requestid"
+)
+
+// AttachRequestID passes the request ID assigned by the requestid
+// middleware on to the services through the request context.
+func AttachRequestID(c *fiber.Ctx) error {
+	c.SetUserContext(requestid.WithID(c.UserContext(), requestID(c)))
+	return c.Next()
+}
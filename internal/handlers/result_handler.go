@@ -1,20 +1,58 @@
 package handlers
 
 import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
 
+	"alfredoptarigan/cv-evaluator/internal/apperror"
 	"alfredoptarigan/cv-evaluator/internal/models"
 	"alfredoptarigan/cv-evaluator/internal/repositories"
+	"alfredoptarigan/cv-evaluator/internal/services"
+	"alfredoptarigan/cv-evaluator/internal/tenant"
+)
+
+// etaSampleSize is how many recent evaluations the ETA averages over.
+const etaSampleSize = 20
+
+const (
+	defaultListLimit = 20
+	maxListLimit     = 100
+)
+
+const (
+	// streamPollInterval is how often a result stream checks for status
+	// changes.
+	streamPollInterval = time.Second
+	// streamMaxDuration ends a result stream before StreamWriteTimeout
+	// cuts it off; EventSource clients reconnect by themselves.
+	streamMaxDuration = 25 * time.Second
+	// StreamWriteTimeout is the write timeout of the result stream route,
+	// whatever REQUEST_TIMEOUT is.
+	StreamWriteTimeout = streamMaxDuration + 5*time.Second
+	// streamRetryMillis is the reconnect delay suggested to clients.
+	streamRetryMillis = 1000
 )
 
 type ResultHandler struct {
-	evalRepo repositories.EvaluationRepository
+	evalRepo  repositories.EvaluationRepository
+	docRepo   repositories.DocumentRepository
+	worker    services.Worker
+	summaries services.SummaryStreams
 }
 
-func NewResultHandler(evalRepo repositories.EvaluationRepository) *ResultHandler {
+func NewResultHandler(evalRepo repositories.EvaluationRepository, docRepo repositories.DocumentRepository, worker services.Worker, summaries services.SummaryStreams) *ResultHandler {
 	return &ResultHandler{
-		evalRepo: evalRepo,
+		evalRepo:  evalRepo,
+		docRepo:   docRepo,
+		worker:    worker,
+		summaries: summaries,
 	}
 }
 
@@ -23,40 +61,404 @@ func (h *ResultHandler) HandleGetResult(c *fiber.Ctx) error {
 	idParam := c.Params("id")
 	evalID, err := uuid.Parse(idParam)
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid evaluation ID format",
-		})
+		return apperror.New(fiber.StatusBadRequest, apperror.CodeInvalidID, "Invalid evaluation ID format")
 	}
 
 	// Get evaluation
-	evaluation, err := h.evalRepo.FindByID(evalID)
+	evaluation, err := h.findOwned(c, evalID)
 	if err != nil {
-		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-			"error": "Evaluation not found",
-		})
+		return err
 	}
 
-	// Build response based on status
-	response := models.ResultResponse{
+	// Pollers that already have this version skip building the result
+	etag := resultETag(evaluation)
+	c.Set(fiber.HeaderETag, etag)
+	c.Set(fiber.HeaderCacheControl, "no-cache")
+	if etagMatches(c.Get(fiber.HeaderIfNoneMatch), etag) {
+		return c.SendStatus(fiber.StatusNotModified)
+	}
+
+	response, err := h.presentResult(apiVersion(c), evaluation)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(response)
+}
+
+// findOwned returns the caller's evaluation. Other tenants' evaluations
+// are hidden behind not-found, as their results name the candidate.
+func (h *ResultHandler) findOwned(c *fiber.Ctx, id uuid.UUID) (models.Evaluation, error) {
+	evaluation, err := h.evalRepo.FindByID(id)
+	if err != nil || evaluation.TenantID != tenant.FromContext(c.UserContext()) {
+		return models.Evaluation{}, apperror.New(fiber.StatusNotFound, apperror.CodeEvaluationNotFound, "Evaluation not found")
+	}
+
+	return evaluation, nil
+}
+
+// resultETag identifies a version of the evaluation. It is weak because the
+// queue position and ETA can change without the evaluation changing.
+func resultETag(evaluation models.Evaluation) string {
+	return fmt.Sprintf(`W/"%s-%d"`, evaluation.Status, evaluation.UpdatedAt.UnixNano())
+}
+
+// etagMatches reports whether an If-None-Match header lists etag, using the
+// weak comparison RFC 9110 prescribes for it.
+func etagMatches(ifNoneMatch, etag string) bool {
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+	return false
+}
+
+// HandleListResults handles GET /results
+// Lists the caller's evaluations newest first. Takes optional ?status=
+// (comma-separated), ?limit= (default 20) and ?cursor= from the previous
+// page's next_cursor.
+func (h *ResultHandler) HandleListResults(c *fiber.Ctx) error {
+	filter := repositories.EvaluationFilter{
+		TenantID: tenant.FromContext(c.UserContext()),
+		Limit:    c.QueryInt("limit", defaultListLimit),
+	}
+	if filter.Limit < 1 || filter.Limit > maxListLimit {
+		return apperror.New(fiber.StatusBadRequest, apperror.CodeValidationFailed, fmt.Sprintf("limit must be between 1 and %d", maxListLimit))
+	}
+	if statuses := c.Query("status"); statuses != "" {
+		for _, status := range strings.Split(statuses, ",") {
+			status := models.EvaluationStatus(strings.TrimSpace(status))
+			if !status.IsValid() {
+				return apperror.New(fiber.StatusBadRequest, apperror.CodeValidationFailed, fmt.Sprintf("unknown status %q", status))
+			}
+			filter.Statuses = append(filter.Statuses, status)
+		}
+	}
+	if cursor := c.Query("cursor"); cursor != "" {
+		after, err := repositories.ParseCursor(cursor)
+		if err != nil {
+			return apperror.New(fiber.StatusBadRequest, apperror.CodeValidationFailed, "cursor is invalid")
+		}
+		filter.After = &after
+	}
+
+	evaluations, err := h.evalRepo.List(filter)
+	if err != nil {
+		return apperror.Wrap(err, fiber.StatusInternalServerError, apperror.CodeInternal, "Failed to list evaluations")
+	}
+
+	response := models.ResultListResponse{Results: make([]interface{}, 0, len(evaluations))}
+	for _, evaluation := range evaluations {
+		result, err := h.presentResult(apiVersion(c), evaluation)
+		if err != nil {
+			return err
+		}
+		response.Results = append(response.Results, result)
+	}
+	if next := repositories.NextCursor(evaluations, filter.Limit, repositories.EvaluationCursor); next != nil {
+		response.NextCursor = next.String()
+	}
+
+	return c.JSON(response)
+}
+
+// HandleBatchResults handles POST /results/batch, returning the same result
+// as GET /result/:id for each of the given evaluations.
+func (h *ResultHandler) HandleBatchResults(c *fiber.Ctx) error {
+	var req models.BatchResultRequest
+	if err := parseAndValidate(c, &req); err != nil {
+		return err
+	}
+
+	ids := make([]uuid.UUID, 0, len(req.IDs))
+	seen := make(map[uuid.UUID]bool, len(req.IDs))
+	for _, id := range req.IDs {
+		evalID := uuid.MustParse(id)
+		if !seen[evalID] {
+			seen[evalID] = true
+			ids = append(ids, evalID)
+		}
+	}
+
+	// Other tenants' evaluations are listed as not found, like missing ones
+	evaluations, err := h.evalRepo.FindByIDs(tenant.FromContext(c.UserContext()), ids)
+	if err != nil {
+		return apperror.Wrap(err, fiber.StatusInternalServerError, apperror.CodeInternal, "Failed to load evaluations")
+	}
+	byID := make(map[uuid.UUID]models.Evaluation, len(evaluations))
+	for _, evaluation := range evaluations {
+		byID[evaluation.ID] = evaluation
+	}
+
+	response := models.BatchResultResponse{Results: make([]interface{}, 0, len(evaluations))}
+	for _, id := range ids {
+		evaluation, ok := byID[id]
+		if !ok {
+			response.NotFound = append(response.NotFound, id.String())
+			continue
+		}
+
+		result, err := h.presentResult(apiVersion(c), evaluation)
+		if err != nil {
+			return err
+		}
+		response.Results = append(response.Results, result)
+	}
+
+	return c.JSON(response)
+}
+
+// HandleGetEvents handles GET /result/:id/events
+func (h *ResultHandler) HandleGetEvents(c *fiber.Ctx) error {
+	evalID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return apperror.New(fiber.StatusBadRequest, apperror.CodeInvalidID, "Invalid evaluation ID format")
+	}
+
+	evaluation, err := h.findOwned(c, evalID)
+	if err != nil {
+		return err
+	}
+
+	events, err := h.evalRepo.FindEvents(evalID)
+	if err != nil {
+		return apperror.Wrap(err, fiber.StatusInternalServerError, apperror.CodeInternal, "Failed to load evaluation events")
+	}
+
+	return c.JSON(models.EvaluationEventsResponse{
 		ID:     evaluation.ID.String(),
 		Status: string(evaluation.Status),
+		Events: events,
+	})
+}
+
+// HandleStream handles GET /result/:id/stream as server-sent events:
+// "status" with the result whenever the evaluation changes, "summary" with
+// pieces of the overall summary as it is generated and "summary_reset" when
+// generation starts over. The stream ends after the final status or
+// streamMaxDuration, whichever comes first.
+func (h *ResultHandler) HandleStream(c *fiber.Ctx) error {
+	evalID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return apperror.New(fiber.StatusBadRequest, apperror.CodeInvalidID, "Invalid evaluation ID format")
+	}
+
+	if _, err := h.findOwned(c, evalID); err != nil {
+		return err
+	}
+
+	c.Set(fiber.HeaderContentType, "text/event-stream")
+	c.Set(fiber.HeaderCacheControl, "no-cache")
+	c.Set(fiber.HeaderConnection, "keep-alive")
+	c.Set("X-Accel-Buffering", "no")
+
+	version := apiVersion(c)
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		if err := h.stream(w, evalID, version); err != nil {
+			log.Printf("⚠️  Result stream for %s ended: %v\n", evalID, err)
+		}
+	})
+
+	return nil
+}
+
+// stream writes the events of HandleStream until the evaluation is final.
+func (h *ResultHandler) stream(w *bufio.Writer, evalID uuid.UUID, version APIVersion) error {
+	text, chunks, cancel := h.summaries.Subscribe(evalID)
+	defer cancel()
+
+	if _, err := fmt.Fprintf(w, "retry: %d\n\n", streamRetryMillis); err != nil {
+		return err
+	}
+	// Catch up on the summary generated before this client (re)connected
+	if text != "" {
+		if err := writeEvent(w, "summary_reset", fiber.Map{}); err != nil {
+			return err
+		}
+		if err := writeEvent(w, "summary", fiber.Map{"text": text}); err != nil {
+			return err
+		}
+	}
+
+	ticker := time.NewTicker(streamPollInterval)
+	defer ticker.Stop()
+	deadline := time.After(streamMaxDuration)
+
+	var lastStatus models.EvaluationStatus
+	var lastUpdate time.Time
+
+	// sendStatus writes the result if it changed and reports whether the
+	// evaluation is final
+	sendStatus := func() (bool, error) {
+		evaluation, err := h.evalRepo.FindByID(evalID)
+		if err != nil {
+			return false, err
+		}
+
+		if evaluation.Status != lastStatus || !evaluation.UpdatedAt.Equal(lastUpdate) {
+			response, err := h.presentResult(version, evaluation)
+			if err != nil {
+				return false, err
+			}
+			if err := writeEvent(w, "status", response); err != nil {
+				return false, err
+			}
+			lastStatus = evaluation.Status
+			lastUpdate = evaluation.UpdatedAt
+		}
+
+		return evaluation.Status.IsFinal(), nil
+	}
+
+	if final, err := sendStatus(); err != nil || final {
+		return err
+	}
+
+	for {
+		select {
+		case chunk, ok := <-chunks:
+			if !ok {
+				// Summary finished or this client fell behind; the final
+				// status carries the full text
+				chunks = nil
+				continue
+			}
+			if chunk.Reset {
+				if err := writeEvent(w, "summary_reset", fiber.Map{}); err != nil {
+					return err
+				}
+			}
+			if chunk.Text != "" {
+				if err := writeEvent(w, "summary", fiber.Map{"text": chunk.Text}); err != nil {
+					return err
+				}
+			}
+		case <-ticker.C:
+			if final, err := sendStatus(); err != nil || final {
+				return err
+			}
+		case <-deadline:
+			return nil
+		}
+	}
+}
+
+// writeEvent writes one server-sent event with data as JSON and flushes it.
+func writeEvent(w *bufio.Writer, event string, data interface{}) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return err
 	}
+	if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, payload); err != nil {
+		return err
+	}
+	return w.Flush()
+}
 
-	// If completed, include results
-	if evaluation.Status == models.StatusCompleted {
+// buildResult describes the evaluation as returned by GET /api/v1/result/:id.
+func (h *ResultHandler) buildResult(evaluation models.Evaluation) (models.ResultResponse, error) {
+	// Build response based on status
+	response := models.ResultResponse{
+		ID:        evaluation.ID.String(),
+		Status:    string(evaluation.Status),
+		Attempts:  evaluation.Attempts,
+		RequestID: evaluation.RequestID,
+
+		Model:         evaluation.Model,
+		Temperature:   evaluation.Temperature,
+		Cohort:        evaluation.Cohort,
+		ExecutionMode: evaluation.ExecutionMode,
+	}
+
+	if evaluation.LastError != "" {
+		errs, err := h.evalRepo.FindErrors(evaluation.ID)
+		if err != nil {
+			return models.ResultResponse{}, apperror.Wrap(err, fiber.StatusInternalServerError, apperror.CodeInternal, "Failed to load evaluation errors")
+		}
+		response.Errors = errs
+	}
+
+	// Include results once any are available
+	if evaluation.Status.HasResult() {
 		response.Result = &models.EvaluationData{
-			CVMatchRate:     evaluation.CVMatchRate,
-			CVFeedback:      evaluation.CVFeedback,
-			ProjectScore:    evaluation.ProjectScore,
-			ProjectFeedback: evaluation.ProjectFeedback,
-			OverallSummary:  evaluation.OverallSummary,
+			CVMatchRate:       evaluation.CVMatchRate,
+			CVFeedback:        evaluation.CVFeedback,
+			ProjectScore:      evaluation.ProjectScore,
+			ProjectFeedback:   evaluation.ProjectFeedback,
+			OverallSummary:    evaluation.OverallSummary,
+			RAGContextMissing: evaluation.RAGContextMissing,
 		}
 	}
 
-	// If failed, include error message
-	if evaluation.Status == models.StatusFailed && evaluation.ErrorMessage != "" {
+	// Include the error of failed and partially completed evaluations
+	if evaluation.Status.IsFailure() && evaluation.ErrorMessage != "" {
 		response.ErrorMessage = &evaluation.ErrorMessage
 	}
 
-	return c.JSON(response)
+	// Tell callers how long they'll likely wait
+	if !evaluation.Status.IsFinal() {
+		h.estimateWait(&response, evaluation)
+	}
+
+	// Say whose result it is. Like the estimate, this is best effort.
+	if cv, err := h.docRepo.FindByID(evaluation.CVDocumentID); err != nil {
+		log.Printf("⚠️  Failed to load CV of %s: %v\n", evaluation.ID, err)
+	} else {
+		response.Candidate = cv.Candidate()
+	}
+
+	return response, nil
+}
+
+// estimateWait fills in the queue position and ETA. Both are best effort, so
+// failures only leave them out.
+func (h *ResultHandler) estimateWait(response *models.ResultResponse, evaluation models.Evaluation) {
+	now := time.Now()
+	due := evaluation.RunAt == nil || !evaluation.RunAt.After(now)
+
+	var position int64
+	if evaluation.Status == models.StatusQueued && due {
+		var err error
+		position, err = h.evalRepo.QueuePosition(evaluation)
+		if err != nil {
+			log.Printf("⚠️  Failed to find queue position of %s: %v\n", evaluation.ID, err)
+			return
+		}
+		response.QueuePosition = &position
+	}
+
+	pool := h.worker.Status()
+	if pool.Paused || pool.Concurrency == 0 {
+		return
+	}
+
+	avg, err := h.evalRepo.AverageProcessingTime(etaSampleSize)
+	if err != nil {
+		log.Printf("⚠️  Failed to estimate processing time: %v\n", err)
+		return
+	}
+	if avg == 0 {
+		return
+	}
+
+	var eta time.Duration
+	switch {
+	case evaluation.Status == models.StatusProcessing && evaluation.StartedAt != nil:
+		eta = avg - now.Sub(*evaluation.StartedAt)
+	case !due:
+		// Scheduled: starts at run_at, assuming a free worker then
+		eta = evaluation.RunAt.Sub(now) + avg
+	default:
+		// Every round of Concurrency jobs ahead takes about avg
+		rounds := (position-1)/int64(pool.Concurrency) + 1
+		eta = time.Duration(rounds) * avg
+	}
+	if eta < 0 {
+		eta = 0
+	}
+
+	seconds := int64(eta.Round(time.Second) / time.Second)
+	response.ETASeconds = &seconds
 }
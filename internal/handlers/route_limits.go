@@ -0,0 +1,125 @@
+package handlers
+
+import (
+	"io"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/valyala/fasthttp"
+
+	"alfredoptarigan/cv-evaluator/internal/apperror"
+)
+
+// RouteLimits caps the body size and the time spent on requests to a
+// route. Zero timeouts keep the server's; a zero BodyLimit leaves the body
+// for the handler to bound, as streamed uploads do.
+type RouteLimits struct {
+	BodyLimit    int
+	ReadTimeout  time.Duration
+	WriteTimeout time.Duration
+}
+
+type routeLimits struct {
+	method   string
+	segments []string
+	limits   RouteLimits
+}
+
+// RouteLimiter applies RouteLimits by route, so that the larger limits
+// uploads need don't open every endpoint to large or slow requests.
+type RouteLimiter struct {
+	defaults RouteLimits
+	routes   []routeLimits
+}
+
+// NewRouteLimiter returns a RouteLimiter applying defaults to routes
+// without limits of their own.
+func NewRouteLimiter(defaults RouteLimits) *RouteLimiter {
+	return &RouteLimiter{defaults: defaults}
+}
+
+// Set gives the route at method and path its own limits. path is written
+// like a fiber route; ":param" segments match any single segment.
+func (l *RouteLimiter) Set(method, path string, limits RouteLimits) {
+	l.routes = append(l.routes, routeLimits{
+		method:   method,
+		segments: pathSegments(path),
+		limits:   limits,
+	})
+}
+
+// HeaderReceived sets the timeouts of a request once its headers are read.
+// It is meant for fasthttp.Server.HeaderReceived, as the body is read and
+// the deadlines set before fiber routes the request.
+func (l *RouteLimiter) HeaderReceived(header *fasthttp.RequestHeader) fasthttp.RequestConfig {
+	path, _, _ := strings.Cut(string(header.RequestURI()), "?")
+	limits := l.lookup(string(header.Method()), path)
+
+	return fasthttp.RequestConfig{
+		ReadTimeout:  limits.ReadTimeout,
+		WriteTimeout: limits.WriteTimeout,
+	}
+}
+
+// Handler rejects requests whose body is larger than their route allows.
+// With streamed request bodies fiber no longer enforces BodyLimit itself.
+func (l *RouteLimiter) Handler(c *fiber.Ctx) error {
+	limit := l.lookup(c.Method(), c.Path()).BodyLimit
+	if limit <= 0 {
+		return c.Next()
+	}
+
+	length := c.Request().Header.ContentLength()
+	if length > limit {
+		return bodyTooLarge(c)
+	}
+
+	// A chunked body declares no length, so read it here, up to the
+	// limit, rather than let the handler read however much is sent
+	if stream := c.Context().RequestBodyStream(); length == -1 && stream != nil {
+		body, err := io.ReadAll(io.LimitReader(stream, int64(limit)+1))
+		if err != nil {
+			return err
+		}
+		if len(body) > limit {
+			return bodyTooLarge(c)
+		}
+		c.Request().SetBody(body)
+	}
+
+	return c.Next()
+}
+
+func (l *RouteLimiter) lookup(method, path string) RouteLimits {
+	segments := pathSegments(path)
+	for _, route := range l.routes {
+		if route.method == method && matchSegments(route.segments, segments) {
+			return route.limits
+		}
+	}
+	return l.defaults
+}
+
+func bodyTooLarge(c *fiber.Ctx) error {
+	// The rest of the body is never read, so the connection can't be
+	// reused for another request
+	c.Context().SetConnectionClose()
+	return apperror.New(fiber.StatusRequestEntityTooLarge, apperror.CodePayloadTooLarge, "Request body too large")
+}
+
+func pathSegments(path string) []string {
+	return strings.Split(strings.Trim(path, "/"), "/")
+}
+
+func matchSegments(pattern, segments []string) bool {
+	if len(pattern) != len(segments) {
+		return false
+	}
+	for i, p := range pattern {
+		if !strings.HasPrefix(p, ":") && p != segments[i] {
+			return false
+		}
+	}
+	return true
+}
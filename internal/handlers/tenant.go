@@ -0,0 +1,15 @@
+package handlers
+
+import (
+	"github.com/gofiber/fiber/v2"
+
+	"alfredoptarigan/cv-evaluator/internal/tenant"
+)
+
+// IdentifyTenant resolves the caller's tenant from the X-API-Key header and
+// attaches it to the request context used by the services.
+func IdentifyTenant(c *fiber.Ctx) error {
+	tenantID := tenant.FromAPIKey(c.Get(tenant.APIKeyHeader))
+	c.SetUserContext(tenant.WithID(c.UserContext(), tenantID))
+	return c.Next()
+}
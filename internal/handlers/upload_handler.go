@@ -1,84 +1,153 @@
 package handlers
 
 import (
-	"fmt"
-	"time"
+	"bytes"
+	"errors"
+	"io"
+	"mime"
+	"mime/multipart"
+	"strings"
 
 	"github.com/gofiber/fiber/v2"
-	"github.com/google/uuid"
 
+	"alfredoptarigan/cv-evaluator/internal/apperror"
 	"alfredoptarigan/cv-evaluator/internal/models"
-	"alfredoptarigan/cv-evaluator/internal/repositories"
 	"alfredoptarigan/cv-evaluator/internal/services"
 )
 
+// multipartOverhead allows for part headers and boundaries on top of the
+// file contents when capping the request size.
+const multipartOverhead = 64 * 1024
+
+// uploadFields maps the accepted form fields to their document type.
+var uploadFields = map[string]string{
+	"cv":             "cv",
+	"project_report": "project_report",
+}
+
+// uploadURLFields maps the form fields naming a document by URL to its
+// document type.
+var uploadURLFields = map[string]string{
+	"cv_url":             "cv",
+	"project_report_url": "project_report",
+}
+
+// uploadPasswordFields maps the form fields carrying a PDF password to its
+// document type. Parts are streamed, so the password has to come before the
+// file.
+var uploadPasswordFields = map[string]string{
+	"cv_password":             "cv",
+	"project_report_password": "project_report",
+}
+
+// maxURLLength caps a URL form field.
+const maxURLLength = 2048
+
+// maxPasswordLength caps a password form field.
+const maxPasswordLength = 1024
+
 type UploadHandler struct {
-	docRepo        repositories.DocumentRepository
-	storageService services.StorageService
-	maxFileSize    int64
+	docService  services.DocumentService
+	maxFileSize int64
+	// requirePair rejects uploads that don't include both files.
+	requirePair bool
 }
 
 func NewUploadHandler(
-	docRepo repositories.DocumentRepository,
-	storageService services.StorageService,
+	docService services.DocumentService,
 	maxFileSize int64,
+	requirePair bool,
 ) *UploadHandler {
 	return &UploadHandler{
-		docRepo:        docRepo,
-		storageService: storageService,
-		maxFileSize:    maxFileSize,
+		docService:  docService,
+		maxFileSize: maxFileSize,
+		requirePair: requirePair,
 	}
 }
 
+// HandleUpload handles POST /upload
+// Parts are streamed straight to storage instead of buffering the body; the
+// size cap is enforced while copying each file. A cv and project_report
+// sent together are recorded as a pair, whose ID /evaluate accepts in
+// place of the two document IDs. Either may be given as a URL instead
+// (cv_url, project_report_url), which is downloaded and stored the same way.
+// Password-protected PDFs need cv_password or project_report_password sent
+// ahead of the file.
 func (h *UploadHandler) HandleUpload(c *fiber.Ctx) error {
-	form, err := c.MultipartForm()
-	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "failed to parse multipart form",
-		})
+	mediaType, params, err := mime.ParseMediaType(c.Get(fiber.HeaderContentType))
+	if err != nil || mediaType != fiber.MIMEMultipartForm || params["boundary"] == "" {
+		return apperror.New(fiber.StatusBadRequest, apperror.CodeInvalidRequest, "failed to parse multipart form")
 	}
 
-	files := form.File
+	body := c.Context().RequestBodyStream()
+	if body == nil {
+		body = bytes.NewReader(c.Body())
+	}
+	maxRequestSize := int64(len(uploadFields))*h.maxFileSize + multipartOverhead
+	reader := multipart.NewReader(io.LimitReader(body, maxRequestSize), params["boundary"])
 
 	var responses []models.UploadResponse
+	uploaded := make(map[string]*models.Document, len(uploadFields))
+	passwords := make(map[string]string, len(uploadPasswordFields))
 
-	// Process the cv file
-	if cvFiles, exists := files["cv"]; exists && len(cvFiles) > 0 {
-		cvFile := cvFiles[0]
-
-		if cvFile.Size > h.maxFileSize {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-				"error": fmt.Sprintf("CV file too large. Max size: %d bytes", h.maxFileSize),
-			})
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
 		}
-
-		// Save file
-		filename, filePath, err := h.storageService.SaveFile(cvFile, "cv")
 		if err != nil {
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-				"error": fmt.Sprintf("failed to save CV file: %v", err),
-			})
+			return apperror.New(fiber.StatusBadRequest, apperror.CodeInvalidRequest, "failed to parse multipart form")
+		}
+
+		if passwordType, ok := uploadPasswordFields[part.FormName()]; ok && part.FileName() == "" {
+			value, err := io.ReadAll(io.LimitReader(part, maxPasswordLength+1))
+			part.Close()
+			if err != nil {
+				return apperror.New(fiber.StatusBadRequest, apperror.CodeInvalidRequest, "failed to parse multipart form")
+			}
+			if len(value) > maxPasswordLength {
+				return apperror.New(fiber.StatusBadRequest, apperror.CodeValidationFailed, part.FormName()+" is too long")
+			}
+			passwords[passwordType] = string(value)
+			continue
 		}
 
-		// Create document record
-		doc := models.Document{
-			ID:           uuid.New(),
-			Filename:     filename,
-			OriginalName: cvFile.Filename,
-			FileType:     "cv",
-			FilePath:     filePath,
-			CreatedAt:    time.Now(),
-			UpdatedAt:    time.Now(),
+		urlType, isURL := uploadURLFields[part.FormName()]
+		fileType, isFile := uploadFields[part.FormName()]
+		if isURL {
+			fileType = urlType
 		}
 
-		// Save document to repository
-		if err := h.docRepo.Create(&doc); err != nil {
-			// Cleanup uploaded file if database insert fails
-			h.storageService.DeleteFile(filename)
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-				"error": fmt.Sprintf("failed to save CV document record: %v", err),
-			})
+		var doc *models.Document
+		if isURL && part.FileName() == "" && uploaded[fileType] == nil {
+			value, err := io.ReadAll(io.LimitReader(part, maxURLLength+1))
+			part.Close()
+			if err != nil {
+				return apperror.New(fiber.StatusBadRequest, apperror.CodeInvalidRequest, "failed to parse multipart form")
+			}
+			if len(value) > maxURLLength {
+				return apperror.New(fiber.StatusBadRequest, apperror.CodeValidationFailed, part.FormName()+" is too long")
+			}
+			if doc, err = h.docService.UploadURL(c.UserContext(), strings.TrimSpace(string(value)), fileType, passwords[fileType]); err != nil {
+				return apperror.Wrap(err, fiber.StatusInternalServerError, apperror.CodeInternal, "failed to save "+fileType+" file")
+			}
+		} else if isFile && part.FileName() != "" && uploaded[fileType] == nil {
+			doc, err = h.docService.Upload(c.UserContext(), part, part.FileName(), fileType, passwords[fileType])
+			part.Close()
+			if err != nil {
+				if errors.Is(err, io.ErrUnexpectedEOF) {
+					return apperror.New(fiber.StatusBadRequest, apperror.CodeInvalidRequest, "upload was truncated")
+				}
+				return apperror.Wrap(err, fiber.StatusInternalServerError, apperror.CodeInternal, "failed to save "+fileType+" file")
+			}
+		} else {
+			// Skip unknown fields and repeated files without buffering them
+			if _, err := io.Copy(io.Discard, part); err != nil {
+				return apperror.New(fiber.StatusBadRequest, apperror.CodeInvalidRequest, "failed to parse multipart form")
+			}
+			continue
 		}
+		uploaded[fileType] = doc
 
 		responses = append(responses, models.UploadResponse{
 			ID:           doc.ID.String(),
@@ -88,59 +157,29 @@ func (h *UploadHandler) HandleUpload(c *fiber.Ctx) error {
 		})
 	}
 
-	// Process the project report
-	if projectFiles, exists := files["project_report"]; exists && len(projectFiles) > 0 {
-		projectFile := projectFiles[0]
-
-		if projectFile.Size > h.maxFileSize {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-				"error": fmt.Sprintf("Project report file too large. Max size: %d bytes", h.maxFileSize),
-			})
-		}
-
-		// Save file
-		filename, filePath, err := h.storageService.SaveFile(projectFile, "project_report")
-		if err != nil {
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-				"error": fmt.Sprintf("failed to save project report file: %v", err),
-			})
-		}
-
-		// Save document record
-		doc := models.Document{
-			ID:           uuid.New(),
-			Filename:     filename,
-			OriginalName: projectFile.Filename,
-			FileType:     "project_report",
-			FilePath:     filePath,
-			CreatedAt:    time.Now(),
-			UpdatedAt:    time.Now(),
-		}
+	if len(responses) == 0 {
+		return apperror.New(fiber.StatusBadRequest, apperror.CodeNoFilesUploaded, "No valid files uploaded. Please upload 'cv' and/or 'project_report' as PDF files, or link them with 'cv_url' and/or 'project_report_url'.")
+	}
 
-		if err := h.docRepo.Create(&doc); err != nil {
-			// Cleanup uploaded file if database insert fails
-			h.storageService.DeleteFile(filename)
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-				"error": "Failed to save project report document record",
-			})
+	cv, projectReport := uploaded["cv"], uploaded["project_report"]
+	if cv == nil || projectReport == nil {
+		if h.requirePair {
+			return apperror.New(fiber.StatusBadRequest, apperror.CodeValidationFailed, "Upload 'cv' and 'project_report' together in one request.")
 		}
-
-		responses = append(responses, models.UploadResponse{
-			ID:           doc.ID.String(),
-			Filename:     doc.Filename,
-			OriginalName: doc.OriginalName,
-			FileType:     doc.FileType,
+		return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+			"message":   "Files uploaded successfully",
+			"documents": responses,
 		})
 	}
 
-	if len(responses) == 0 {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "No valid files uploaded. Please upload 'cv' and/or 'project_report' as PDF files.",
-		})
+	pair, err := h.docService.Pair(c.UserContext(), cv, projectReport)
+	if err != nil {
+		return apperror.Wrap(err, fiber.StatusInternalServerError, apperror.CodeInternal, "failed to pair the uploaded files")
 	}
 
 	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
 		"message":   "Files uploaded successfully",
 		"documents": responses,
+		"pair_id":   pair.ID.String(),
 	})
 }
@@ -0,0 +1,110 @@
+package handlers
+
+import (
+	"bytes"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+
+	"alfredoptarigan/cv-evaluator/internal/apperror"
+	"alfredoptarigan/cv-evaluator/internal/models"
+	"alfredoptarigan/cv-evaluator/internal/services"
+)
+
+// Headers used by the resumable upload protocol.
+const (
+	UploadOffsetHeader = "Upload-Offset"
+	UploadLengthHeader = "Upload-Length"
+)
+
+type UploadSessionHandler struct {
+	sessionService services.UploadSessionService
+}
+
+func NewUploadSessionHandler(sessionService services.UploadSessionService) *UploadSessionHandler {
+	return &UploadSessionHandler{
+		sessionService: sessionService,
+	}
+}
+
+// HandleCreate handles POST /uploads
+func (h *UploadSessionHandler) HandleCreate(c *fiber.Ctx) error {
+	var req models.CreateUploadSessionRequest
+	if err := parseAndValidate(c, &req); err != nil {
+		return err
+	}
+
+	session, err := h.sessionService.Create(c.UserContext(), req.Filename, req.Type, req.Size)
+	if err != nil {
+		return apperror.Wrap(err, fiber.StatusInternalServerError, apperror.CodeInternal, "Failed to create upload session")
+	}
+
+	c.Location("/api/v1/uploads/" + session.ID.String())
+	return h.respond(c.Status(fiber.StatusCreated), session, nil)
+}
+
+// HandleGet handles GET and HEAD /uploads/:id
+func (h *UploadSessionHandler) HandleGet(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return apperror.New(fiber.StatusBadRequest, apperror.CodeInvalidID, "Invalid upload ID format")
+	}
+
+	session, err := h.sessionService.Get(c.UserContext(), id)
+	if err != nil {
+		return apperror.Wrap(err, fiber.StatusInternalServerError, apperror.CodeInternal, "Failed to load upload session")
+	}
+
+	return h.respond(c, session, nil)
+}
+
+// HandleAppend handles PATCH /uploads/:id
+// The body is the next chunk and Upload-Offset must equal the bytes received
+// so far; the chunk that completes the file returns the created document.
+func (h *UploadSessionHandler) HandleAppend(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return apperror.New(fiber.StatusBadRequest, apperror.CodeInvalidID, "Invalid upload ID format")
+	}
+
+	offset, err := strconv.ParseInt(c.Get(UploadOffsetHeader), 10, 64)
+	if err != nil || offset < 0 {
+		return apperror.New(fiber.StatusBadRequest, apperror.CodeInvalidRequest, "Missing or invalid Upload-Offset header")
+	}
+
+	session, doc, err := h.sessionService.Append(c.UserContext(), id, offset, bytes.NewReader(c.Body()))
+	if err != nil {
+		return apperror.Wrap(err, fiber.StatusInternalServerError, apperror.CodeInternal, "Failed to store upload chunk")
+	}
+
+	if doc != nil {
+		c.Status(fiber.StatusCreated)
+	}
+	return h.respond(c, session, doc)
+}
+
+func (h *UploadSessionHandler) respond(c *fiber.Ctx, session *models.UploadSession, doc *models.Document) error {
+	c.Set(UploadOffsetHeader, strconv.FormatInt(session.ReceivedBytes, 10))
+	c.Set(UploadLengthHeader, strconv.FormatInt(session.Size, 10))
+	c.Set(fiber.HeaderCacheControl, "no-store")
+
+	resp := models.UploadSessionResponse{
+		ID:        session.ID.String(),
+		Filename:  session.OriginalName,
+		Type:      session.FileType,
+		Size:      session.Size,
+		Offset:    session.ReceivedBytes,
+		ExpiresAt: session.ExpiresAt,
+	}
+	if doc != nil {
+		resp.Document = &models.UploadResponse{
+			ID:           doc.ID.String(),
+			Filename:     doc.Filename,
+			OriginalName: doc.OriginalName,
+			FileType:     doc.FileType,
+		}
+	}
+
+	return c.JSON(resp)
+}
@@ -0,0 +1,28 @@
+package handlers
+
+import (
+	"github.com/gofiber/fiber/v2"
+
+	"alfredoptarigan/cv-evaluator/internal/apperror"
+	"alfredoptarigan/cv-evaluator/internal/services"
+)
+
+type UsageHandler struct {
+	quotaService services.QuotaService
+}
+
+func NewUsageHandler(quotaService services.QuotaService) *UsageHandler {
+	return &UsageHandler{
+		quotaService: quotaService,
+	}
+}
+
+// HandleGetUsage handles GET /usage
+func (h *UsageHandler) HandleGetUsage(c *fiber.Ctx) error {
+	usage, err := h.quotaService.Usage(c.UserContext())
+	if err != nil {
+		return apperror.Wrap(err, fiber.StatusInternalServerError, apperror.CodeInternal, "Failed to load usage")
+	}
+
+	return c.JSON(usage)
+}
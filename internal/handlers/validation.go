@@ -0,0 +1,18 @@
+package handlers
+
+import (
+	"github.com/gofiber/fiber/v2"
+
+	"alfredoptarigan/cv-evaluator/internal/apperror"
+	"alfredoptarigan/cv-evaluator/internal/validation"
+)
+
+// parseAndValidate parses the request body into out and runs its validate
+// tags. Every JSON request model should go through this helper.
+func parseAndValidate(c *fiber.Ctx, out interface{}) error {
+	if err := c.BodyParser(out); err != nil {
+		return apperror.New(fiber.StatusBadRequest, apperror.CodeInvalidRequest, "Invalid request payload")
+	}
+
+	return validation.Struct(out)
+}
@@ -0,0 +1,120 @@
+// Package metrics is a small registry of counters and gauges rendered in the
+// Prometheus text exposition format.
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+type metricType string
+
+const (
+	typeCounter metricType = "counter"
+	typeGauge   metricType = "gauge"
+)
+
+type sample struct {
+	labels map[string]string
+	value  func() float64
+}
+
+type family struct {
+	help    string
+	kind    metricType
+	samples []sample
+}
+
+var (
+	mu       sync.Mutex
+	families = map[string]*family{}
+)
+
+// Counter is a monotonically increasing value.
+type Counter struct {
+	v atomic.Int64
+}
+
+// Inc adds one to the counter.
+func (c *Counter) Inc() {
+	c.v.Add(1)
+}
+
+// Add adds n to the counter.
+func (c *Counter) Add(n int64) {
+	c.v.Add(n)
+}
+
+// NewCounter registers a counter under name with the given labels.
+func NewCounter(name, help string, labels map[string]string) *Counter {
+	c := &Counter{}
+	register(name, help, typeCounter, labels, func() float64 { return float64(c.v.Load()) })
+	return c
+}
+
+// GaugeFunc registers a gauge whose value is read from fn at scrape time.
+func GaugeFunc(name, help string, labels map[string]string, fn func() float64) {
+	register(name, help, typeGauge, labels, fn)
+}
+
+func register(name, help string, kind metricType, labels map[string]string, fn func() float64) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	f, ok := families[name]
+	if !ok {
+		f = &family{help: help, kind: kind}
+		families[name] = f
+	}
+	f.samples = append(f.samples, sample{labels: labels, value: fn})
+}
+
+// WriteText writes every registered metric to w.
+func WriteText(w io.Writer) error {
+	mu.Lock()
+	defer mu.Unlock()
+
+	names := make([]string, 0, len(families))
+	for name := range families {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		f := families[name]
+		if _, err := fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", name, f.help, name, f.kind); err != nil {
+			return err
+		}
+		for _, s := range f.samples {
+			if _, err := fmt.Fprintf(w, "%s%s %g\n", name, formatLabels(s.labels), s.value()); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func formatLabels(labels map[string]string) string {
+	if len(labels) == 0 {
+		return ""
+	}
+
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	pairs := make([]string, 0, len(keys))
+	for _, k := range keys {
+		v := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(labels[k])
+		pairs = append(pairs, fmt.Sprintf(`%s="%s"`, k, v))
+	}
+
+	return "{" + strings.Join(pairs, ",") + "}"
+}
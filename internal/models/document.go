@@ -7,15 +7,83 @@ import (
 )
 
 type Document struct {
-	ID           uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	ID           uuid.UUID `gorm:"type:uuid;primary_key" json:"id"`
 	Filename     string    `gorm:"type:text" json:"filename"`
 	OriginalName string    `gorm:"type:text" json:"original_name"`
 	FileType     string    `gorm:"type:text" json:"file_type"`
 	FilePath     string    `gorm:"type:text" json:"file_path"`
-	CreatedAt    time.Time `gorm:"type:timestamp;default:now()" json:"created_at"`
-	UpdatedAt    time.Time `gorm:"type:timestamp;default:now()" json:"updated_at"`
+	TenantID     string    `gorm:"type:text;not null;default:'anonymous'" json:"tenant_id"`
+	ContentHash  string    `gorm:"type:varchar(64)" json:"content_hash,omitempty"`
+	ParsedText   string    `gorm:"type:text" json:"-"`
+	PageCount    int       `gorm:"not null;default:0" json:"page_count"`
+	// CandidateName, CandidateEmail and CandidatePhone are the contact
+	// details found in a CV when it was parsed, per CONTACT_EXTRACTION.
+	CandidateName  string    `gorm:"type:text" json:"candidate_name,omitempty"`
+	CandidateEmail string    `gorm:"type:varchar(255)" json:"candidate_email,omitempty"`
+	CandidatePhone string    `gorm:"type:varchar(255)" json:"candidate_phone,omitempty"`
+	CreatedAt      time.Time `gorm:"type:timestamp;default:CURRENT_TIMESTAMP" json:"created_at"`
+	UpdatedAt      time.Time `gorm:"type:timestamp;default:CURRENT_TIMESTAMP" json:"updated_at"`
 }
 
 func (d *Document) TableName() string {
 	return "documents"
 }
+
+// Candidate returns the contact details stored for the document, nil if
+// there are none.
+func (d *Document) Candidate() *CandidateContact {
+	if d.CandidateName == "" && d.CandidateEmail == "" && d.CandidatePhone == "" {
+		return nil
+	}
+	return &CandidateContact{Name: d.CandidateName, Email: d.CandidateEmail, Phone: d.CandidatePhone}
+}
+
+// CandidateContact is who a CV belongs to. With CONTACT_EXTRACTION=hashed,
+// Email and Phone are "sha256:" hashes and there is no Name.
+type CandidateContact struct {
+	Name  string `json:"name,omitempty"`
+	Email string `json:"email,omitempty"`
+	Phone string `json:"phone,omitempty"`
+}
+
+// SignedDownload is a time-limited link to a document's original file.
+type SignedDownload struct {
+	URL       string    `json:"url"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// DocumentPreview is the start of a document's extracted text and what was
+// detected in it, to check the right file was uploaded before evaluating it.
+type DocumentPreview struct {
+	ID              string `json:"id"`
+	OriginalName    string `json:"original_name"`
+	FileType        string `json:"file_type"`
+	PageCount       int    `json:"page_count"`
+	Characters      int    `json:"characters"`
+	EstimatedTokens int    `json:"estimated_tokens"`
+	// Language is an ISO 639-1 code, empty when it couldn't be told.
+	Language string   `json:"language,omitempty"`
+	Sections []string `json:"sections"`
+	Text     string   `json:"text"`
+	// Truncated is set when Text is only the start of the document.
+	Truncated bool `json:"truncated"`
+	// ParseError explains why there is no text.
+	ParseError string `json:"parse_error,omitempty"`
+	// Candidate is the contact details found in a CV.
+	Candidate *CandidateContact `json:"candidate,omitempty"`
+}
+
+// DocumentPair is a CV and project report uploaded together, so an
+// evaluation can name both by one ID instead of two that may belong to
+// different candidates.
+type DocumentPair struct {
+	ID                uuid.UUID `gorm:"type:uuid;primary_key" json:"id"`
+	TenantID          string    `gorm:"type:text;not null;default:'anonymous'" json:"tenant_id"`
+	CVDocumentID      uuid.UUID `gorm:"type:uuid;not null" json:"cv_document_id"`
+	ProjectDocumentID uuid.UUID `gorm:"type:uuid;not null" json:"project_document_id"`
+	CreatedAt         time.Time `gorm:"type:timestamp;default:CURRENT_TIMESTAMP" json:"created_at"`
+}
+
+func (p *DocumentPair) TableName() string {
+	return "document_pairs"
+}
@@ -0,0 +1,16 @@
+package models
+
+// EmailIntakeResult reports what the email intake did with a message.
+type EmailIntakeResult struct {
+	// Accepted is false for messages that weren't sent to the intake
+	// address; Reason says why.
+	Accepted  bool             `json:"accepted"`
+	Reason    string           `json:"reason,omitempty"`
+	Documents []UploadResponse `json:"documents"`
+	// Skipped lists attachments and steps that were left out, with why.
+	Skipped      []string `json:"skipped,omitempty"`
+	PairID       string   `json:"pair_id,omitempty"`
+	JobCode      string   `json:"job_code,omitempty"`
+	JobTitle     string   `json:"job_title,omitempty"`
+	EvaluationID string   `json:"evaluation_id,omitempty"`
+}
@@ -13,10 +13,85 @@ const (
 	StatusProcessing EvaluationStatus = "processing"
 	StatusCompleted  EvaluationStatus = "completed"
 	StatusFailed     EvaluationStatus = "failed"
+	// StatusPartiallyCompleted is a failure after some stages finished;
+	// their results are kept and a retry resumes from the failed stage.
+	StatusPartiallyCompleted EvaluationStatus = "partially_completed"
+	// StatusExpired is a job that stayed queued longer than the queue TTL,
+	// e.g. while the provider credentials were broken.
+	StatusExpired EvaluationStatus = "expired"
+	// StatusCancelled is a job an operator stopped, e.g. with cvctl.
+	StatusCancelled EvaluationStatus = "cancelled"
+)
+
+// evaluationTransitions lists the statuses each status may move to. Running
+// jobs go back to queued when parked or interrupted, failed, expired and
+// cancelled ones on retry; failed ones complete when replayed from their
+// stored LLM responses. Completed is final.
+var evaluationTransitions = map[EvaluationStatus][]EvaluationStatus{
+	StatusQueued:             {StatusProcessing, StatusExpired, StatusCancelled},
+	StatusProcessing:         {StatusCompleted, StatusFailed, StatusPartiallyCompleted, StatusQueued, StatusCancelled},
+	StatusFailed:             {StatusQueued, StatusCompleted},
+	StatusPartiallyCompleted: {StatusQueued, StatusCompleted},
+	StatusExpired:            {StatusQueued},
+	StatusCancelled:          {StatusQueued},
+}
+
+// CanTransitionTo reports whether an evaluation may move from s to next.
+func (s EvaluationStatus) CanTransitionTo(next EvaluationStatus) bool {
+	for _, allowed := range evaluationTransitions[s] {
+		if allowed == next {
+			return true
+		}
+	}
+	return false
+}
+
+// IsValid reports whether s is a known status.
+func (s EvaluationStatus) IsValid() bool {
+	_, ok := evaluationTransitions[s]
+	return ok || s == StatusCompleted
+}
+
+// IsFinal reports whether the evaluation stopped running; only a retry
+// moves it on.
+func (s EvaluationStatus) IsFinal() bool {
+	return s == StatusCompleted || s.IsFailure()
+}
+
+// IsFailure reports whether the evaluation stopped without completing; the
+// reason is in its error message.
+func (s EvaluationStatus) IsFailure() bool {
+	return s == StatusFailed || s == StatusPartiallyCompleted || s == StatusExpired || s == StatusCancelled
+}
+
+// HasResult reports whether (possibly partial) results are available.
+func (s EvaluationStatus) HasResult() bool {
+	return s == StatusCompleted || s == StatusPartiallyCompleted
+}
+
+// StatusesBefore returns the statuses an evaluation may move to next from.
+func StatusesBefore(next EvaluationStatus) []EvaluationStatus {
+	var before []EvaluationStatus
+	for s := range evaluationTransitions {
+		if s.CanTransitionTo(next) {
+			before = append(before, s)
+		}
+	}
+	return before
+}
+
+// EvaluationStage names the pipeline step an error happened in.
+type EvaluationStage string
+
+const (
+	StageLoad      EvaluationStage = "load"
+	StageParse     EvaluationStage = "parse"
+	StageRetrieval EvaluationStage = "retrieval"
+	StageLLM       EvaluationStage = "llm"
 )
 
 type Evaluation struct {
-	ID                uuid.UUID        `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id" column:"id"`
+	ID                uuid.UUID        `gorm:"type:uuid;primary_key" json:"id" column:"id"`
 	JobTitle          string           `gorm:"type:text" json:"job_title" column:"job_title"`
 	CVDocumentID      uuid.UUID        `gorm:"type:uuid;not null" json:"cv_document_id" column:"cv_document_id"`
 	ProjectDocumentID uuid.UUID        `gorm:"type:uuid;not null" json:"project_document_id" column:"project_document_id"`
@@ -27,8 +102,37 @@ type Evaluation struct {
 	ProjectFeedback   string           `gorm:"type:text" json:"project_feedback,omitempty" column:"project_feedback"`
 	OverallSummary    string           `gorm:"type:text" json:"overall_summary,omitempty" column:"overall_summary"`
 	ErrorMessage      string           `gorm:"type:text" json:"error_message,omitempty" column:"error_message"`
-	CreatedAt         time.Time        `gorm:"default:CURRENT_TIMESTAMP" json:"created_at" column:"created_at"`
-	UpdatedAt         time.Time        `gorm:"default:CURRENT_TIMESTAMP" json:"updated_at" column:"updated_at"`
+	TenantID          string           `gorm:"type:text;not null;default:'anonymous'" json:"tenant_id" column:"tenant_id"`
+	BypassCache       bool             `gorm:"not null;default:false" json:"bypass_cache" column:"bypass_cache"`
+	PromptTokens      int64            `gorm:"not null;default:0" json:"prompt_tokens" column:"prompt_tokens"`
+	CompletionTokens  int64            `gorm:"not null;default:0" json:"completion_tokens" column:"completion_tokens"`
+	EstimatedCostUSD  float64          `gorm:"column:estimated_cost_usd;type:numeric(12,6);not null;default:0" json:"estimated_cost_usd"`
+	// Attempts counts how often a worker picked the evaluation up; LastError
+	// is the most recent failure, even if the job was retried since.
+	Attempts  int    `gorm:"not null;default:0" json:"attempts" column:"attempts"`
+	LastError string `gorm:"type:text" json:"last_error,omitempty" column:"last_error"`
+	// RunAt delays processing until the given time (nil = as soon as possible).
+	RunAt *time.Time `gorm:"column:run_at" json:"run_at,omitempty"`
+	// Model and Temperature override the LLM defaults for this evaluation.
+	Model       string   `gorm:"type:varchar(100)" json:"model,omitempty" column:"model"`
+	Temperature *float32 `gorm:"column:temperature" json:"temperature,omitempty"`
+	// Cohort tells which rollout group chose the model.
+	Cohort EvaluationCohort `gorm:"type:varchar(20);not null;default:stable" json:"cohort" column:"cohort"`
+	// ExecutionMode batch sends the LLM calls through the provider's batch
+	// API: cheaper, but it can take hours.
+	ExecutionMode ExecutionMode `gorm:"type:varchar(20);not null;default:interactive" json:"execution_mode" column:"execution_mode"`
+	// RAGContextMissing is set when a score was produced without all of its
+	// reference documents, e.g. because the vector store was unavailable.
+	RAGContextMissing bool `gorm:"not null;default:false" json:"rag_context_missing" column:"rag_context_missing"`
+	// RequestID is the ID of the API request that submitted the evaluation,
+	// for tracing its worker logs back to the call.
+	RequestID string `gorm:"type:varchar(128)" json:"request_id,omitempty" column:"request_id"`
+	// StartedAt is when the latest attempt started, CompletedAt when the
+	// evaluation completed; together they feed the wait estimates.
+	StartedAt   *time.Time `gorm:"column:started_at" json:"started_at,omitempty"`
+	CompletedAt *time.Time `gorm:"column:completed_at" json:"completed_at,omitempty"`
+	CreatedAt   time.Time  `gorm:"default:CURRENT_TIMESTAMP" json:"created_at" column:"created_at"`
+	UpdatedAt   time.Time  `gorm:"default:CURRENT_TIMESTAMP" json:"updated_at" column:"updated_at"`
 
 	// Relations
 	CVDocument      Document `gorm:"foreignKey:CVDocumentID" json:"-"`
@@ -38,3 +142,225 @@ type Evaluation struct {
 func (Evaluation) TableName() string {
 	return "evaluations"
 }
+
+// CheckpointStage names a pipeline stage whose output is checkpointed.
+type CheckpointStage string
+
+const (
+	CheckpointCVText        CheckpointStage = "cv_text"
+	CheckpointCVResult      CheckpointStage = "cv_result"
+	CheckpointProjectText   CheckpointStage = "project_text"
+	CheckpointProjectResult CheckpointStage = "project_result"
+)
+
+// EvaluationProjectDocument is a document submitted with an evaluation's
+// project report, e.g. an architecture document or a README export.
+// Position orders them after the report.
+type EvaluationProjectDocument struct {
+	EvaluationID uuid.UUID `gorm:"type:uuid;primaryKey" json:"-"`
+	DocumentID   uuid.UUID `gorm:"type:uuid;primaryKey;index" json:"document_id"`
+	Position     int       `gorm:"not null" json:"position"`
+}
+
+func (EvaluationProjectDocument) TableName() string {
+	return "evaluation_project_documents"
+}
+
+// EvaluationCheckpoint holds a finished stage's output as JSON, so a retried
+// run restores it instead of running the stage again.
+type EvaluationCheckpoint struct {
+	EvaluationID uuid.UUID       `gorm:"type:uuid;primaryKey" json:"-"`
+	Stage        CheckpointStage `gorm:"type:text;primaryKey" json:"stage"`
+	Output       string          `gorm:"type:text;not null" json:"-"`
+	CreatedAt    time.Time       `gorm:"default:CURRENT_TIMESTAMP" json:"created_at"`
+}
+
+// ResponseStage names the LLM call an EvaluationResponse answered.
+type ResponseStage string
+
+const (
+	ResponseCVResult      ResponseStage = "cv_result"
+	ResponseProjectResult ResponseStage = "project_result"
+	ResponseSummary       ResponseStage = "summary"
+)
+
+// EvaluationResponse is a raw LLM response as received, before parsing, so
+// a run that failed on a parsing bug can be replayed without calling the
+// LLM again.
+type EvaluationResponse struct {
+	ID           int64         `gorm:"primaryKey;autoIncrement" json:"-"`
+	EvaluationID uuid.UUID     `gorm:"type:uuid;not null" json:"-"`
+	Attempt      int           `gorm:"not null" json:"attempt"`
+	Stage        ResponseStage `gorm:"type:text;not null" json:"stage"`
+	Response     string        `gorm:"type:text;not null" json:"response"`
+	CreatedAt    time.Time     `gorm:"default:CURRENT_TIMESTAMP" json:"created_at"`
+}
+
+// EvaluationLLMKey links an evaluation to an LLM cache entry or batch
+// request made by its calls. Both hold the documents' text or the feedback
+// about them, so they are deleted when the evaluation is erased.
+type EvaluationLLMKey struct {
+	EvaluationID uuid.UUID `gorm:"type:uuid;primaryKey" json:"-"`
+	Key          string    `gorm:"type:varchar(64);primaryKey;index" json:"key"`
+}
+
+func (EvaluationLLMKey) TableName() string {
+	return "evaluation_llm_keys"
+}
+
+// ExecutionMode selects how an evaluation's LLM calls are made.
+type ExecutionMode string
+
+const (
+	// ExecutionInteractive calls the model synchronously.
+	ExecutionInteractive ExecutionMode = "interactive"
+	// ExecutionBatch submits the prompts as batch jobs and waits for them
+	// between attempts.
+	ExecutionBatch ExecutionMode = "batch"
+)
+
+// EvaluationCohort is the rollout group an evaluation ran in.
+type EvaluationCohort string
+
+const (
+	// CohortStable runs on the default model.
+	CohortStable EvaluationCohort = "stable"
+	// CohortCanary was routed to the canary model.
+	CohortCanary EvaluationCohort = "canary"
+	// CohortOverride picked its model in the request.
+	CohortOverride EvaluationCohort = "override"
+)
+
+// EvaluationShadow is the result of the shadow pipeline for an evaluation,
+// kept for offline comparison with the canonical result.
+type EvaluationShadow struct {
+	ID               int64     `gorm:"primaryKey;autoIncrement" json:"-"`
+	EvaluationID     uuid.UUID `gorm:"type:uuid;not null" json:"evaluation_id"`
+	Model            string    `gorm:"type:varchar(100);not null" json:"model"`
+	Temperature      *float32  `json:"temperature,omitempty"`
+	CVMatchRate      *float64  `gorm:"column:cv_match_rate" json:"cv_match_rate,omitempty"`
+	CVFeedback       string    `gorm:"type:text" json:"cv_feedback,omitempty"`
+	ProjectScore     *float64  `json:"project_score,omitempty"`
+	ProjectFeedback  string    `gorm:"type:text" json:"project_feedback,omitempty"`
+	OverallSummary   string    `gorm:"type:text" json:"overall_summary,omitempty"`
+	ErrorMessage     string    `gorm:"type:text" json:"error_message,omitempty"`
+	PromptTokens     int64     `gorm:"not null;default:0" json:"prompt_tokens"`
+	CompletionTokens int64     `gorm:"not null;default:0" json:"completion_tokens"`
+	EstimatedCostUSD float64   `gorm:"type:numeric(12,6);not null;default:0" json:"estimated_cost_usd"`
+	CreatedAt        time.Time `gorm:"default:CURRENT_TIMESTAMP" json:"created_at"`
+}
+
+// EvaluationCorrection is a reviewer's fix of an evaluation's result. Nil
+// and empty fields keep the model's output.
+type EvaluationCorrection struct {
+	EvaluationID    uuid.UUID `gorm:"type:uuid;primaryKey" json:"evaluation_id"`
+	CVMatchRate     *float64  `gorm:"column:cv_match_rate" json:"cv_match_rate,omitempty"`
+	CVFeedback      string    `gorm:"type:text" json:"cv_feedback,omitempty"`
+	ProjectScore    *float64  `json:"project_score,omitempty"`
+	ProjectFeedback string    `gorm:"type:text" json:"project_feedback,omitempty"`
+	OverallSummary  string    `gorm:"type:text" json:"overall_summary,omitempty"`
+	Note            string    `gorm:"type:text" json:"note,omitempty"`
+	CreatedAt       time.Time `gorm:"default:CURRENT_TIMESTAMP" json:"created_at"`
+	UpdatedAt       time.Time `gorm:"default:CURRENT_TIMESTAMP" json:"updated_at"`
+}
+
+// EvaluationEventType names a point in an evaluation's timeline.
+type EvaluationEventType string
+
+const (
+	EventQueued           EvaluationEventType = "queued"
+	EventClaimed          EvaluationEventType = "claimed"
+	EventCVEvaluated      EvaluationEventType = "cv_evaluated"
+	EventProjectEvaluated EvaluationEventType = "project_evaluated"
+	EventCompleted        EvaluationEventType = "completed"
+	EventFailed           EvaluationEventType = "failed"
+	// EventRetried is a return to the queue: a retry, a job parked on an
+	// open circuit breaker or one requeued at shutdown.
+	EventRetried EvaluationEventType = "retried"
+	// EventBatchPending is a batch evaluation waiting for a batch job.
+	EventBatchPending EvaluationEventType = "batch_pending"
+	// EventExpired is a job given up on after waiting past the queue TTL.
+	EventExpired EvaluationEventType = "expired"
+	// EventCancelled is a job stopped by an operator.
+	EventCancelled EvaluationEventType = "cancelled"
+)
+
+// EvaluationEvent is one timestamped entry of an evaluation's timeline.
+type EvaluationEvent struct {
+	ID           int64               `gorm:"primaryKey;autoIncrement" json:"-"`
+	EvaluationID uuid.UUID           `gorm:"type:uuid;not null" json:"-"`
+	Type         EvaluationEventType `gorm:"type:text;not null" json:"type"`
+	Detail       string              `gorm:"type:text" json:"detail,omitempty"`
+	CreatedAt    time.Time           `gorm:"default:CURRENT_TIMESTAMP" json:"created_at"`
+}
+
+// EvaluationError is one recorded failure of an evaluation run.
+type EvaluationError struct {
+	ID           int64           `gorm:"primaryKey;autoIncrement" json:"-"`
+	EvaluationID uuid.UUID       `gorm:"type:uuid;not null" json:"-"`
+	Attempt      int             `gorm:"not null" json:"attempt"`
+	Stage        EvaluationStage `gorm:"type:text;not null" json:"stage"`
+	Message      string          `gorm:"type:text;not null" json:"message"`
+	CreatedAt    time.Time       `gorm:"default:CURRENT_TIMESTAMP" json:"created_at"`
+}
+
+func (EvaluationError) TableName() string {
+	return "evaluation_errors"
+}
+
+// EvaluationOutbox records a committed evaluation that still has to be
+// handed to the worker.
+type EvaluationOutbox struct {
+	ID           int64     `gorm:"primaryKey;autoIncrement" json:"id"`
+	EvaluationID uuid.UUID `gorm:"type:uuid;not null" json:"evaluation_id"`
+	// AvailableAt is when the entry may be dispatched, the evaluation's
+	// run_at for scheduled jobs.
+	AvailableAt time.Time `gorm:"not null" json:"available_at"`
+	CreatedAt   time.Time `gorm:"default:CURRENT_TIMESTAMP" json:"created_at"`
+}
+
+func (EvaluationOutbox) TableName() string {
+	return "evaluation_outbox"
+}
+
+// EvaluationStats aggregates evaluation counts and LLM spend for the admin
+// stats endpoint.
+type EvaluationStats struct {
+	ByStatus         map[EvaluationStatus]int64 `json:"by_status"`
+	PromptTokens     int64                      `json:"prompt_tokens"`
+	CompletionTokens int64                      `json:"completion_tokens"`
+	EstimatedCostUSD float64                    `json:"estimated_cost_usd"`
+	Tenants          []TenantCost               `json:"tenants"`
+	Cohorts          []CohortStats              `json:"cohorts"`
+}
+
+// CohortStats compares rollout cohorts; averages cover completed
+// evaluations.
+type CohortStats struct {
+	Cohort           EvaluationCohort `json:"cohort"`
+	Model            string           `json:"model,omitempty"`
+	Evaluations      int64            `json:"evaluations"`
+	Completed        int64            `json:"completed"`
+	Failed           int64            `json:"failed"`
+	AvgCVMatchRate   float64          `json:"avg_cv_match_rate"`
+	AvgProjectScore  float64          `json:"avg_project_score"`
+	EstimatedCostUSD float64          `json:"estimated_cost_usd"`
+}
+
+// JobStats sums up the evaluations submitted for a job title; averages
+// cover completed evaluations.
+type JobStats struct {
+	JobTitle        string  `json:"job_title"`
+	EvaluationCount int64   `json:"evaluation_count"`
+	CompletedCount  int64   `json:"completed_count"`
+	AvgCVMatchRate  float64 `json:"avg_cv_match_rate"`
+	AvgProjectScore float64 `json:"avg_project_score"`
+}
+
+type TenantCost struct {
+	TenantID         string  `json:"tenant_id"`
+	Evaluations      int64   `json:"evaluations"`
+	PromptTokens     int64   `json:"prompt_tokens"`
+	CompletionTokens int64   `json:"completion_tokens"`
+	EstimatedCostUSD float64 `json:"estimated_cost_usd"`
+}
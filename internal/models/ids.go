@@ -0,0 +1,58 @@
+package models
+
+import (
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+func (d *Document) BeforeCreate(*gorm.DB) error {
+	d.ID = newID(d.ID)
+	return nil
+}
+
+func (e *Evaluation) BeforeCreate(*gorm.DB) error {
+	e.ID = newID(e.ID)
+	return nil
+}
+
+func (s *UploadSession) BeforeCreate(*gorm.DB) error {
+	s.ID = newID(s.ID)
+	return nil
+}
+
+func (p *DocumentPair) BeforeCreate(*gorm.DB) error {
+	p.ID = newID(p.ID)
+	return nil
+}
+
+func (u *UsageCounter) BeforeCreate(*gorm.DB) error {
+	u.ID = newID(u.ID)
+	return nil
+}
+
+func (r *ErasureReport) BeforeCreate(*gorm.DB) error {
+	r.ID = newID(r.ID)
+	return nil
+}
+
+// NewID returns a UUIDv7. Its leading timestamp makes IDs sort by creation
+// time, so inserts land at the end of primary key indexes instead of at
+// random pages. Rows created before keep their v4 IDs; lookups accept any
+// version.
+func NewID() uuid.UUID {
+	id, err := uuid.NewV7()
+	if err != nil {
+		// Only fails if the random source does
+		return uuid.New()
+	}
+	return id
+}
+
+// newID fills in a missing ID. IDs are generated here rather than by the
+// database so the models also work on databases without gen_random_uuid().
+func newID(id uuid.UUID) uuid.UUID {
+	if id == uuid.Nil {
+		return NewID()
+	}
+	return id
+}
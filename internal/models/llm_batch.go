@@ -0,0 +1,41 @@
+package models
+
+import "time"
+
+// LLMBatchStatus is where a batched prompt is in its batch job's life.
+type LLMBatchStatus string
+
+const (
+	// BatchPending waits to be submitted with the next batch job.
+	BatchPending LLMBatchStatus = "pending"
+	// BatchSubmitted is part of a running batch job.
+	BatchSubmitted LLMBatchStatus = "submitted"
+	BatchSucceeded LLMBatchStatus = "succeeded"
+	BatchFailed    LLMBatchStatus = "failed"
+)
+
+// LLMBatchRequest is one prompt sent through the batch API, keyed by a hash
+// of the request so a rerun of the same call picks up its response.
+type LLMBatchRequest struct {
+	Key         string  `gorm:"type:varchar(64);primary_key" json:"key"`
+	Model       string  `gorm:"type:text;not null" json:"model"`
+	Temperature float32 `gorm:"not null" json:"temperature"`
+	// GenerationParams holds the request's parameter overrides as JSON.
+	GenerationParams string         `gorm:"type:text" json:"generation_params,omitempty"`
+	Prompt           string         `gorm:"type:text;not null" json:"-"`
+	Status           LLMBatchStatus `gorm:"type:varchar(20);not null;default:pending" json:"status"`
+	JobName          string         `gorm:"type:text" json:"job_name,omitempty"`
+	// BatchIndex is the request's position in its job, which is how the
+	// job's responses are matched up.
+	BatchIndex       int       `gorm:"not null;default:0" json:"batch_index"`
+	Response         string    `gorm:"type:text" json:"-"`
+	ErrorMessage     string    `gorm:"type:text" json:"error_message,omitempty"`
+	PromptTokens     int64     `gorm:"not null;default:0" json:"prompt_tokens"`
+	CompletionTokens int64     `gorm:"not null;default:0" json:"completion_tokens"`
+	CreatedAt        time.Time `gorm:"default:CURRENT_TIMESTAMP" json:"created_at"`
+	UpdatedAt        time.Time `gorm:"default:CURRENT_TIMESTAMP" json:"updated_at"`
+}
+
+func (LLMBatchRequest) TableName() string {
+	return "llm_batch_requests"
+}
@@ -0,0 +1,28 @@
+package models
+
+import "time"
+
+// LLMCacheEntry is a stored model response keyed by a hash of the request.
+type LLMCacheEntry struct {
+	Key       string    `gorm:"type:varchar(64);primary_key" json:"key"`
+	Model     string    `gorm:"type:text;not null" json:"model"`
+	Response  string    `gorm:"type:text;not null" json:"response"`
+	ExpiresAt time.Time `gorm:"type:timestamp;not null" json:"expires_at"`
+	CreatedAt time.Time `gorm:"default:CURRENT_TIMESTAMP" json:"created_at"`
+}
+
+func (LLMCacheEntry) TableName() string {
+	return "llm_cache"
+}
+
+// EmbeddingCacheEntry stores an embedding as little-endian float32 values.
+type EmbeddingCacheEntry struct {
+	Key       string    `gorm:"type:varchar(64);primary_key" json:"key"`
+	Model     string    `gorm:"type:text;not null" json:"model"`
+	Embedding []byte    `gorm:"type:bytea;not null" json:"-"`
+	CreatedAt time.Time `gorm:"default:CURRENT_TIMESTAMP" json:"created_at"`
+}
+
+func (EmbeddingCacheEntry) TableName() string {
+	return "embedding_cache"
+}
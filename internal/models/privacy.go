@@ -0,0 +1,39 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ErasureRequest identifies a data subject by the email address in their
+// CV, their document IDs, or both.
+type ErasureRequest struct {
+	Email       string   `json:"email" validate:"required_without=DocumentIDs,omitempty,email,max=254"`
+	DocumentIDs []string `json:"document_ids" validate:"required_without=Email,omitempty,max=100,dive,uuid"`
+}
+
+// ErasureReport records what an erasure request removed. It keeps no
+// personal data: the email is stored as a hash and only IDs are listed.
+type ErasureReport struct {
+	ID       uuid.UUID `gorm:"type:uuid;primary_key" json:"id"`
+	TenantID string    `gorm:"type:varchar(255);not null" json:"-"`
+	// SubjectHash is the SHA-256 of the lower-cased email, if one was given.
+	SubjectHash   string      `gorm:"type:varchar(64)" json:"subject_hash,omitempty"`
+	DocumentIDs   []uuid.UUID `gorm:"type:text;serializer:json" json:"document_ids"`
+	EvaluationIDs []uuid.UUID `gorm:"type:text;serializer:json" json:"evaluation_ids"`
+	// FilesDeleted, VectorsDeleted, UploadSessionsDeleted and the LLM
+	// counts say what was purged alongside the documents; Failures lists
+	// what could not be.
+	FilesDeleted            int       `gorm:"not null;default:0" json:"files_deleted"`
+	VectorsDeleted          int       `gorm:"not null;default:0" json:"vectors_deleted"`
+	UploadSessionsDeleted   int64     `gorm:"not null;default:0" json:"upload_sessions_deleted"`
+	LLMCacheEntriesDeleted  int64     `gorm:"not null;default:0" json:"llm_cache_entries_deleted"`
+	LLMBatchRequestsDeleted int64     `gorm:"not null;default:0" json:"llm_batch_requests_deleted"`
+	Failures                []string  `gorm:"type:text;serializer:json" json:"failures,omitempty"`
+	CreatedAt               time.Time `gorm:"default:CURRENT_TIMESTAMP" json:"created_at"`
+}
+
+func (ErasureReport) TableName() string {
+	return "erasure_reports"
+}
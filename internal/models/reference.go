@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+// IngestedReference records a reference document embedded into the vector
+// store, so ingesting it again can skip it while its content is unchanged.
+// TenantID is empty for documents shared with every tenant, Job for ones
+// used in evaluations for every job.
+type IngestedReference struct {
+	TenantID       string    `gorm:"type:varchar(255);primaryKey" json:"tenant_id"`
+	DocumentID     string    `gorm:"type:varchar(255);primaryKey" json:"document_id"`
+	DocType        string    `gorm:"type:varchar(50);not null" json:"doc_type"`
+	Name           string    `gorm:"type:text;not null" json:"name"`
+	Job            string    `gorm:"type:varchar(255);not null;default:''" json:"job,omitempty"`
+	ContentHash    string    `gorm:"type:varchar(64);not null" json:"content_hash"`
+	EmbeddingModel string    `gorm:"type:varchar(100);not null" json:"embedding_model"`
+	Chunks         int       `gorm:"not null;default:0" json:"chunks"`
+	IngestedAt     time.Time `gorm:"not null" json:"ingested_at"`
+}
+
+func (IngestedReference) TableName() string {
+	return "ingested_references"
+}
@@ -1,5 +1,13 @@
 package models
 
+import (
+	"time"
+
+	"github.com/google/uuid"
+
+	"alfredoptarigan/cv-evaluator/internal/apperror"
+)
+
 type UploadResponse struct {
 	ID           string `json:"id"`
 	Filename     string `json:"filename"`
@@ -9,13 +17,84 @@ type UploadResponse struct {
 
 type EvaluateRequest struct {
 	JobTitle          string `json:"job_title" validate:"required"`
-	CVDocumentID      string `json:"cv_document_id" validate:"required,uuid"`
-	ProjectDocumentID string `json:"project_document_id" validate:"required,uuid"`
+	CVDocumentID      string `json:"cv_document_id" validate:"required_without=PairID,omitempty,uuid"`
+	ProjectDocumentID string `json:"project_document_id" validate:"required_without=PairID,omitempty,uuid"`
+	// PairID names both documents by the pair_id /upload returned for
+	// them.
+	PairID string `json:"pair_id" validate:"omitempty,uuid"`
+	// AdditionalProjectDocumentIDs are project_report documents evaluated
+	// along with the project report, e.g. an architecture document or a
+	// README export, in the order given.
+	AdditionalProjectDocumentIDs []string `json:"additional_project_document_ids" validate:"omitempty,max=4,unique,dive,uuid"`
+	// BypassCache forces fresh LLM responses instead of cached ones.
+	BypassCache bool `json:"bypass_cache"`
+	// RunAt schedules the evaluation, e.g. for off-peak hours (RFC 3339).
+	RunAt *time.Time `json:"run_at"`
+	// Model and Temperature override the LLM defaults, e.g. to compare
+	// models on the same candidate. Model must be in GEMINI_ALLOWED_MODELS.
+	Model       string   `json:"model" validate:"omitempty,max=100"`
+	Temperature *float32 `json:"temperature" validate:"omitempty,min=0,max=2"`
+	// ExecutionMode batch runs the LLM calls through the batch API at lower
+	// cost; results can take hours.
+	ExecutionMode string `json:"execution_mode" validate:"omitempty,oneof=interactive batch"`
+	// DryRun returns the prompts the evaluation would send instead of
+	// queueing it; no text is generated.
+	DryRun bool `json:"dry_run"`
+	// Force creates a new evaluation even if the same documents are already
+	// queued, processing or completed for the same job title.
+	Force bool `json:"force"`
+}
+
+// IDs returns the document and pair IDs of a validated request, uuid.Nil
+// for those not given.
+func (r *EvaluateRequest) IDs() (cvID, projectID, pairID uuid.UUID) {
+	cvID, _ = uuid.Parse(r.CVDocumentID)
+	projectID, _ = uuid.Parse(r.ProjectDocumentID)
+	pairID, _ = uuid.Parse(r.PairID)
+	return cvID, projectID, pairID
+}
+
+// AdditionalProjectIDs returns the additional project document IDs of a
+// validated request.
+func (r *EvaluateRequest) AdditionalProjectIDs() []uuid.UUID {
+	ids := make([]uuid.UUID, len(r.AdditionalProjectDocumentIDs))
+	for i, id := range r.AdditionalProjectDocumentIDs {
+		ids[i], _ = uuid.Parse(id)
+	}
+	return ids
 }
 
 type EvaluateResponse struct {
-	ID     string `json:"id"`
-	Status string `json:"status"`
+	ID     string     `json:"id"`
+	Status string     `json:"status"`
+	RunAt  *time.Time `json:"run_at,omitempty"`
+	// Duplicate is set when an existing evaluation was returned instead of
+	// creating one.
+	Duplicate bool `json:"duplicate,omitempty"`
+}
+
+// EvaluationDryRun is what an evaluation would send to the LLM. The
+// overall summary prompt needs the scores, so only the scoring prompts are
+// built.
+type EvaluationDryRun struct {
+	DryRun   bool         `json:"dry_run"`
+	JobTitle string       `json:"job_title"`
+	Model    string       `json:"model"`
+	CV       DryRunPrompt `json:"cv"`
+	Project  DryRunPrompt `json:"project"`
+	// EstimatedPromptCostUSD prices the scoring prompts' input tokens; the
+	// output and the summary come on top.
+	EstimatedPromptCostUSD float64 `json:"estimated_prompt_cost_usd"`
+	// Notes lists where a real run would differ, e.g. LLM-based steps
+	// that were skipped.
+	Notes []string `json:"notes,omitempty"`
+}
+
+// DryRunPrompt is one scoring prompt with the reference chunks it embeds.
+type DryRunPrompt struct {
+	Prompt          string           `json:"prompt"`
+	EstimatedTokens int              `json:"estimated_tokens"`
+	Context         []RetrievalMatch `json:"context"`
 }
 
 type ResultResponse struct {
@@ -23,6 +102,75 @@ type ResultResponse struct {
 	Status       string          `json:"status"`
 	Result       *EvaluationData `json:"result,omitempty"`
 	ErrorMessage *string         `json:"error_message,omitempty"`
+	// Attempts is how many times a worker ran the evaluation; Errors lists
+	// every recorded failure, oldest first.
+	Attempts int               `json:"attempts"`
+	Errors   []EvaluationError `json:"errors,omitempty"`
+	// RequestID is the ID of the request that submitted the evaluation.
+	RequestID string `json:"request_id,omitempty"`
+	// Model and Temperature are the overrides the evaluation was run with;
+	// Cohort is its rollout group.
+	Model         string           `json:"model,omitempty"`
+	Temperature   *float32         `json:"temperature,omitempty"`
+	Cohort        EvaluationCohort `json:"cohort"`
+	ExecutionMode ExecutionMode    `json:"execution_mode"`
+	// QueuePosition (1 = next) is set while the evaluation is queued and
+	// due. ETASeconds estimates when it finishes, from recent processing
+	// times; it is omitted without history or while workers are paused.
+	QueuePosition *int64 `json:"queue_position,omitempty"`
+	ETASeconds    *int64 `json:"eta_seconds,omitempty"`
+	// Candidate is the contact details found in the CV, to match the
+	// result to the applicant.
+	Candidate *CandidateContact `json:"candidate,omitempty"`
+}
+
+// BatchResultRequest asks for up to 100 results at once.
+type BatchResultRequest struct {
+	IDs []string `json:"ids" validate:"required,min=1,max=100,dive,uuid"`
+}
+
+// BatchResultResponse holds the results found, in request order, and the
+// IDs that weren't. Results are shaped for the API version, like
+// ResultResponse or ResultResponseV2.
+type BatchResultResponse struct {
+	Results  []interface{} `json:"results"`
+	NotFound []string      `json:"not_found,omitempty"`
+}
+
+// ResultListResponse is a page of results, newest first, shaped like
+// BatchResultResponse's. NextCursor is omitted on the last page.
+type ResultListResponse struct {
+	Results    []interface{} `json:"results"`
+	NextCursor string        `json:"next_cursor,omitempty"`
+}
+
+// EvaluationEventsResponse is an evaluation's timeline, oldest first.
+type EvaluationEventsResponse struct {
+	ID     string            `json:"id"`
+	Status string            `json:"status"`
+	Events []EvaluationEvent `json:"events"`
+}
+
+// ShadowComparison puts an evaluation's canonical result next to its
+// shadow results.
+type ShadowComparison struct {
+	ID      string             `json:"id"`
+	Status  string             `json:"status"`
+	Model   string             `json:"model,omitempty"`
+	Cohort  EvaluationCohort   `json:"cohort"`
+	Result  *EvaluationData    `json:"result,omitempty"`
+	Shadows []EvaluationShadow `json:"shadows"`
+}
+
+// EvaluationCorrectionRequest records a reviewer's correction; omitted
+// fields keep the model's output.
+type EvaluationCorrectionRequest struct {
+	CVMatchRate     *float64 `json:"cv_match_rate" validate:"omitempty,min=0,max=1"`
+	CVFeedback      string   `json:"cv_feedback"`
+	ProjectScore    *float64 `json:"project_score" validate:"omitempty,min=1,max=5"`
+	ProjectFeedback string   `json:"project_feedback"`
+	OverallSummary  string   `json:"overall_summary"`
+	Note            string   `json:"note"`
 }
 
 type EvaluationData struct {
@@ -31,4 +179,44 @@ type EvaluationData struct {
 	ProjectScore    float64 `json:"project_score"`
 	ProjectFeedback string  `json:"project_feedback"`
 	OverallSummary  string  `json:"overall_summary"`
+	// RAGContextMissing means a score was produced without all of its
+	// reference context (job description, case study, rubrics).
+	RAGContextMissing bool `json:"rag_context_missing"`
+}
+
+type ErrorResponse struct {
+	Error     string                 `json:"error"`
+	Code      string                 `json:"code"`
+	Status    int                    `json:"status"`
+	RequestID string                 `json:"request_id,omitempty"`
+	Fields    []apperror.FieldError  `json:"fields,omitempty"`
+	Details   map[string]interface{} `json:"details,omitempty"`
+}
+
+// ReplayOutcome says what a replay made of one stage.
+type ReplayOutcome string
+
+const (
+	// ReplayCheckpointed stages had finished before and were left alone.
+	ReplayCheckpointed ReplayOutcome = "checkpointed"
+	ReplayRecovered    ReplayOutcome = "recovered"
+	// ReplayMissing stages have no stored response; a retry runs them.
+	ReplayMissing     ReplayOutcome = "missing"
+	ReplayUnparseable ReplayOutcome = "unparseable"
+)
+
+// ReplayedStage is the outcome of one stage of a replay.
+type ReplayedStage struct {
+	Stage   ResponseStage `json:"stage"`
+	Outcome ReplayOutcome `json:"outcome"`
+	Error   string        `json:"error,omitempty"`
+}
+
+// EvaluationReplay reports what replaying an evaluation from its stored LLM
+// responses recovered.
+type EvaluationReplay struct {
+	ID      string          `json:"id"`
+	Status  string          `json:"status"`
+	Stages  []ReplayedStage `json:"stages"`
+	Message string          `json:"message"`
 }
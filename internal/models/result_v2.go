@@ -0,0 +1,88 @@
+package models
+
+import "time"
+
+// ResultResponseV2 is the result served under /api/v2. It has the v1
+// fields, with Result broken down into sub-scores and citations, and the
+// pipeline stages finished so far.
+type ResultResponseV2 struct {
+	ResultResponse
+	// Result replaces the v1 result of the same name
+	Result *EvaluationDataV2 `json:"result,omitempty"`
+	Stages []StageProgress   `json:"stages"`
+}
+
+// EvaluationDataV2 holds the result of each scoring stage that finished;
+// a partially completed evaluation has only one of them.
+type EvaluationDataV2 struct {
+	CV             *ScoreBreakdown `json:"cv,omitempty"`
+	Project        *ScoreBreakdown `json:"project,omitempty"`
+	OverallSummary string          `json:"overall_summary,omitempty"`
+	// RAGContextMissing means a score was produced without all of its
+	// reference context (job description, case study, rubrics).
+	RAGContextMissing bool `json:"rag_context_missing"`
+}
+
+// ScoreBreakdown is the result of a scoring stage. Score is the CV match
+// rate (0-1) or the project score (1-5); SubScores are the rubric
+// parameters (1-5) it is weighted from. Both are left out for evaluations
+// scored before they were recorded.
+type ScoreBreakdown struct {
+	Score     float64            `json:"score"`
+	Feedback  string             `json:"feedback"`
+	SubScores map[string]float64 `json:"sub_scores,omitempty"`
+	Citations []Citation         `json:"citations,omitempty"`
+	// ExperienceTimeline is the CV's work history, for the CV only.
+	ExperienceTimeline *ExperienceTimeline `json:"experience_timeline,omitempty"`
+}
+
+// Citation is a reference chunk that went into a scoring prompt.
+type Citation struct {
+	DocType string  `json:"doc_type"`
+	Source  string  `json:"source,omitempty"`
+	Section string  `json:"section,omitempty"`
+	Page    int     `json:"page,omitempty"`
+	Score   float32 `json:"score"`
+}
+
+// ExperienceTimeline is the work history read from the dates in a CV, so
+// the experience level isn't left to the LLM's arithmetic. Months are
+// "YYYY-MM".
+type ExperienceTimeline struct {
+	Periods []ExperiencePeriod `json:"periods"`
+	// TotalYears counts overlapping periods once; GoYears only counts the
+	// periods that mention Go. GoInferred is set when Go is only mentioned
+	// elsewhere in the CV, as "Golang" or more than once, e.g. among the
+	// skills, so every period counts.
+	TotalYears float64 `json:"total_years"`
+	GoYears    float64 `json:"go_years"`
+	GoInferred bool    `json:"go_inferred,omitempty"`
+	// Gaps are breaks of six months or more between periods, and Overlaps
+	// periods running in parallel for two months or more.
+	Gaps     []TimelineInterval `json:"gaps,omitempty"`
+	Overlaps []TimelineInterval `json:"overlaps,omitempty"`
+}
+
+// ExperiencePeriod is a dated entry of a CV's work history. Start and End
+// are inclusive; End is the current month for a Current one.
+type ExperiencePeriod struct {
+	Title   string `json:"title"`
+	Start   string `json:"start"`
+	End     string `json:"end"`
+	Current bool   `json:"current,omitempty"`
+	Months  int    `json:"months"`
+	Go      bool   `json:"go"`
+}
+
+// TimelineInterval is a gap or overlap in a timeline, inclusive.
+type TimelineInterval struct {
+	Start  string `json:"start"`
+	End    string `json:"end"`
+	Months int    `json:"months"`
+}
+
+// StageProgress is a pipeline stage that finished.
+type StageProgress struct {
+	Stage       CheckpointStage `json:"stage"`
+	CompletedAt time.Time       `json:"completed_at"`
+}
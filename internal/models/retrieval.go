@@ -0,0 +1,27 @@
+package models
+
+// RetrievalDebug shows what the vector store returns for a query, to tune
+// chunking and thresholds without running an evaluation.
+type RetrievalDebug struct {
+	Query          string           `json:"query"`
+	DocType        string           `json:"doc_type"`
+	EmbeddingModel string           `json:"embedding_model"`
+	Hybrid         bool             `json:"hybrid"`
+	MinScore       float32          `json:"min_score"`
+	Matches        []RetrievalMatch `json:"matches"`
+}
+
+// RetrievalMatch is one chunk returned by the search. Selected reports
+// whether it survives the score threshold, reranking and diversification and
+// would go into an evaluation prompt.
+type RetrievalMatch struct {
+	ID       string                 `json:"id"`
+	Score    float32                `json:"score"`
+	Text     string                 `json:"text"`
+	DocType  string                 `json:"doc_type"`
+	Source   string                 `json:"source,omitempty"`
+	Section  string                 `json:"section,omitempty"`
+	Page     int                    `json:"page,omitempty"`
+	Metadata map[string]interface{} `json:"metadata"`
+	Selected bool                   `json:"selected"`
+}
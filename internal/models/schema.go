@@ -0,0 +1,27 @@
+package models
+
+// Schema lists every table's model, for creating the schema on databases
+// the SQL migrations don't cover.
+func Schema() []interface{} {
+	return []interface{}{
+		&Document{},
+		&DocumentPair{},
+		&Evaluation{},
+		&EvaluationProjectDocument{},
+		&EvaluationCheckpoint{},
+		&EvaluationResponse{},
+		&EvaluationLLMKey{},
+		&EvaluationShadow{},
+		&EvaluationCorrection{},
+		&EvaluationEvent{},
+		&EvaluationError{},
+		&EvaluationOutbox{},
+		&UploadSession{},
+		&UsageCounter{},
+		&LLMCacheEntry{},
+		&EmbeddingCacheEntry{},
+		&LLMBatchRequest{},
+		&ErasureReport{},
+		&IngestedReference{},
+	}
+}
@@ -0,0 +1,28 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ReconcileReport describes mismatches between the upload directory and the
+// documents table found by a storage reconciliation run.
+type ReconcileReport struct {
+	StartedAt        time.Time         `json:"started_at"`
+	FinishedAt       time.Time         `json:"finished_at"`
+	Cleanup          bool              `json:"cleanup"`
+	FilesChecked     int               `json:"files_checked"`
+	DocumentsChecked int               `json:"documents_checked"`
+	OrphanedFiles    []string          `json:"orphaned_files"`
+	MissingFiles     []MissingFileInfo `json:"missing_files"`
+	DeletedFiles     int               `json:"deleted_files"`
+	DeletedDocuments int               `json:"deleted_documents"`
+}
+
+// MissingFileInfo is a document row whose file no longer exists on disk.
+type MissingFileInfo struct {
+	DocumentID uuid.UUID `json:"document_id"`
+	TenantID   string    `json:"tenant_id"`
+	Filename   string    `json:"filename"`
+}
@@ -0,0 +1,42 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// UploadSession tracks a resumable upload until all bytes have arrived and
+// it is finalized into a Document.
+type UploadSession struct {
+	ID            uuid.UUID  `gorm:"type:uuid;primary_key" json:"id"`
+	TenantID      string     `gorm:"type:text;not null;default:'anonymous'" json:"tenant_id"`
+	OriginalName  string     `gorm:"type:text;not null" json:"original_name"`
+	FileType      string     `gorm:"type:text;not null" json:"file_type"`
+	Size          int64      `gorm:"not null" json:"size"`
+	ReceivedBytes int64      `gorm:"not null;default:0" json:"received_bytes"`
+	DocumentID    *uuid.UUID `gorm:"type:uuid" json:"document_id,omitempty"`
+	ExpiresAt     time.Time  `gorm:"type:timestamp;not null" json:"expires_at"`
+	CreatedAt     time.Time  `gorm:"default:CURRENT_TIMESTAMP" json:"created_at"`
+	UpdatedAt     time.Time  `gorm:"default:CURRENT_TIMESTAMP" json:"updated_at"`
+}
+
+func (UploadSession) TableName() string {
+	return "upload_sessions"
+}
+
+type CreateUploadSessionRequest struct {
+	Filename string `json:"filename" validate:"required,max=255"`
+	Type     string `json:"type" validate:"required,oneof=cv project_report"`
+	Size     int64  `json:"size" validate:"required,min=1"`
+}
+
+type UploadSessionResponse struct {
+	ID        string          `json:"id"`
+	Filename  string          `json:"filename"`
+	Type      string          `json:"type"`
+	Size      int64           `json:"size"`
+	Offset    int64           `json:"offset"`
+	ExpiresAt time.Time       `json:"expires_at"`
+	Document  *UploadResponse `json:"document,omitempty"`
+}
@@ -0,0 +1,43 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type UsageKind string
+
+const (
+	UsageUploads     UsageKind = "uploads"
+	UsageEvaluations UsageKind = "evaluations"
+)
+
+// UsageCounter holds a tenant's consumption for one calendar month (UTC).
+type UsageCounter struct {
+	ID          uuid.UUID `gorm:"type:uuid;primary_key" json:"id"`
+	TenantID    string    `gorm:"type:text;not null;uniqueIndex:idx_usage_counters_tenant_period" json:"tenant_id"`
+	Period      string    `gorm:"type:text;not null;uniqueIndex:idx_usage_counters_tenant_period" json:"period"`
+	Uploads     int64     `gorm:"not null;default:0" json:"uploads"`
+	Evaluations int64     `gorm:"not null;default:0" json:"evaluations"`
+	CreatedAt   time.Time `gorm:"default:CURRENT_TIMESTAMP" json:"created_at"`
+	UpdatedAt   time.Time `gorm:"default:CURRENT_TIMESTAMP" json:"updated_at"`
+}
+
+func (UsageCounter) TableName() string {
+	return "usage_counters"
+}
+
+type UsageResponse struct {
+	TenantID    string     `json:"tenant_id"`
+	Period      string     `json:"period"`
+	ResetsAt    time.Time  `json:"resets_at"`
+	Uploads     UsageQuota `json:"uploads"`
+	Evaluations UsageQuota `json:"evaluations"`
+}
+
+// UsageQuota reports consumption against a limit; a zero limit is unlimited.
+type UsageQuota struct {
+	Used  int64 `json:"used"`
+	Limit int64 `json:"limit"`
+}
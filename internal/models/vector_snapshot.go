@@ -0,0 +1,11 @@
+package models
+
+import "time"
+
+// VectorSnapshot is a point-in-time copy of the vector collection kept by the
+// vector store.
+type VectorSnapshot struct {
+	Name      string     `json:"name"`
+	CreatedAt *time.Time `json:"created_at,omitempty"`
+	SizeBytes int64      `json:"size_bytes"`
+}
@@ -0,0 +1,44 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type WorkerState string
+
+const (
+	WorkerIdle   WorkerState = "idle"
+	WorkerActive WorkerState = "active"
+	// WorkerStopping finishes its current job and exits after a scale-down.
+	WorkerStopping WorkerState = "stopping"
+)
+
+// WorkerStatus describes one worker goroutine for the admin API.
+type WorkerStatus struct {
+	ID           int         `json:"id"`
+	State        WorkerState `json:"state"`
+	EvaluationID *uuid.UUID  `json:"evaluation_id,omitempty"`
+	StartedAt    *time.Time  `json:"started_at,omitempty"`
+}
+
+// WorkerConcurrencyRequest resizes the worker pool. Setting Concurrency
+// turns autoscaling off; Autoscale turns it back on.
+type WorkerConcurrencyRequest struct {
+	Concurrency *int `json:"concurrency" validate:"omitempty,min=1,max=64"`
+	Autoscale   bool `json:"autoscale"`
+}
+
+// WorkerPoolStatus is the state of the worker pool. While paused, workers
+// finish their current job but don't take new ones.
+type WorkerPoolStatus struct {
+	Paused         bool           `json:"paused"`
+	Concurrency    int            `json:"concurrency"`
+	Autoscale      bool           `json:"autoscale"`
+	MinConcurrency int            `json:"min_concurrency"`
+	MaxConcurrency int            `json:"max_concurrency"`
+	QueueBuffered  int            `json:"queue_buffered"`
+	QueueCapacity  int            `json:"queue_capacity"`
+	Workers        []WorkerStatus `json:"workers"`
+}
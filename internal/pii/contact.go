@@ -0,0 +1,155 @@
+package pii
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"regexp"
+	"strings"
+)
+
+// Contact is a candidate's name, e-mail address and phone number as found
+// in their CV; fields that weren't found are empty.
+type Contact struct {
+	Name  string
+	Email string
+	Phone string
+}
+
+const (
+	// maxNameLines is how many non-empty lines from the top of a CV are
+	// searched for the candidate's name.
+	maxNameLines = 5
+	// maxPhoneDigits is the most an E.164 number has.
+	maxPhoneDigits = 15
+	// hashPrefix marks hashed values, so they aren't mistaken for real ones.
+	hashPrefix = "sha256:"
+)
+
+var (
+	// nameWord is a capitalized word, allowing initials and names like
+	// O'Neil and Jean-Luc.
+	nameWord = regexp.MustCompile(`^\p{Lu}[\p{L}'’.\-]*$`)
+	// nameSeparator splits a name from a headline on the same line, as in
+	// "Jane Doe | Backend Engineer".
+	nameSeparator = regexp.MustCompile(`\s+[|–—\-]\s+|,|\t`)
+)
+
+// notNameWords are words of CV titles and section headers, which look like
+// names otherwise.
+var notNameWords = map[string]bool{
+	"curriculum": true, "vitae": true, "resume": true, "résumé": true, "cv": true,
+	"profile": true, "summary": true, "contact": true, "experience": true,
+	"education": true, "skills": true, "projects": true, "objective": true,
+}
+
+// ExtractContact finds the candidate's contact details in the text of a CV:
+// the first e-mail address and phone number, and a name on one of the
+// first lines. The e-mail address is lowercased.
+func ExtractContact(text string) Contact {
+	contact := Contact{
+		Email: strings.ToLower(emailPattern.FindString(text)),
+		Name:  extractName(text),
+	}
+
+	// URLs can hold long digit runs, e.g. in profile IDs. Lines are searched
+	// one at a time so that a number doesn't run into the next line's dates.
+	withoutURLs := urlPattern.ReplaceAllString(text, " ")
+	for _, line := range strings.Split(withoutURLs, "\n") {
+		if contact.Phone = findPhone(line); contact.Phone != "" {
+			break
+		}
+	}
+
+	return contact
+}
+
+func findPhone(line string) string {
+	for _, loc := range phonePattern.FindAllStringIndex(line, -1) {
+		match := line[loc[0]:loc[1]]
+		if digits := countDigits(match); digits < minPhoneDigits || digits > maxPhoneDigits {
+			continue
+		}
+		// The pattern starts at a digit, leaving out an area code's "("
+		if loc[0] > 0 && line[loc[0]-1] == '(' {
+			match = "(" + match
+		}
+		return strings.Join(strings.Fields(match), " ")
+	}
+	return ""
+}
+
+// extractName returns the first of the top lines that reads like a name:
+// two to four capitalized words with nothing else on the line but a
+// headline.
+func extractName(text string) string {
+	lines := 0
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		if lines++; lines > maxNameLines {
+			break
+		}
+
+		candidate := strings.TrimSpace(nameSeparator.Split(line, 2)[0])
+		words := strings.Fields(candidate)
+		if len(words) < 2 || len(words) > 4 {
+			continue
+		}
+		isName := true
+		for _, word := range words {
+			if !nameWord.MatchString(word) || notNameWords[strings.ToLower(word)] {
+				isName = false
+				break
+			}
+		}
+		if isName {
+			return strings.Join(words, " ")
+		}
+	}
+	return ""
+}
+
+// Hashed returns the contact with the e-mail address and phone number
+// replaced by hashes of their normalized forms and without the name, so a
+// candidate can be matched by their details without storing them. Hashes
+// are prefixed with "sha256:".
+func (c Contact) Hashed() Contact {
+	return Contact{
+		Email: HashEmail(c.Email),
+		Phone: HashPhone(c.Phone),
+	}
+}
+
+// HashEmail returns the hash Hashed stores for an e-mail address, "" for
+// an empty one.
+func HashEmail(email string) string {
+	return hash(strings.ToLower(strings.TrimSpace(email)))
+}
+
+// HashPhone returns the hash Hashed stores for a phone number: the hash of
+// its digits, with a leading + kept. It is "" for a number without digits.
+func HashPhone(phone string) string {
+	var b strings.Builder
+	if strings.HasPrefix(strings.TrimSpace(phone), "+") {
+		b.WriteByte('+')
+	}
+	for _, r := range phone {
+		if r >= '0' && r <= '9' {
+			b.WriteRune(r)
+		}
+	}
+	if countDigits(b.String()) == 0 {
+		return ""
+	}
+	return hash(b.String())
+}
+
+func hash(value string) string {
+	if value == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(value))
+	return hashPrefix + hex.EncodeToString(sum[:])
+}
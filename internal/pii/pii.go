@@ -0,0 +1,40 @@
+// Package pii finds personal contact details in text, to remove them from
+// text that leaves the service, e.g. in training data exports, or to record
+// who a CV belongs to.
+package pii
+
+import "regexp"
+
+var (
+	urlPattern   = regexp.MustCompile(`(?i)\b(?:https?://|www\.)\S+|\b(?:linkedin\.com|github\.com|gitlab\.com)/\S+`)
+	emailPattern = regexp.MustCompile(`(?i)\b[a-z0-9._%+\-]+@[a-z0-9.\-]+\.[a-z]{2,}\b`)
+	phonePattern = regexp.MustCompile(`\+?\d[\d\s().\-]{7,}\d`)
+)
+
+// minPhoneDigits keeps year ranges like "2019 - 2023" from passing as phone
+// numbers.
+const minPhoneDigits = 9
+
+// Redact replaces URLs, e-mail addresses and phone numbers in text with
+// placeholders. Names aren't detected.
+func Redact(text string) string {
+	// URLs first so that the other patterns don't match inside them
+	text = urlPattern.ReplaceAllString(text, "[URL]")
+	text = emailPattern.ReplaceAllString(text, "[EMAIL]")
+	return phonePattern.ReplaceAllStringFunc(text, func(match string) string {
+		if countDigits(match) < minPhoneDigits {
+			return match
+		}
+		return "[PHONE]"
+	})
+}
+
+func countDigits(s string) int {
+	digits := 0
+	for _, r := range s {
+		if r >= '0' && r <= '9' {
+			digits++
+		}
+	}
+	return digits
+}
@@ -0,0 +1,75 @@
+package repositories
+
+import (
+	"encoding/base64"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ErrInvalidCursor is returned by ParseCursor for a cursor it didn't make.
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Cursor is a position in a list ordered newest first by (created_at, id).
+// Unlike OFFSET, seeking to it costs the same however deep the page, as
+// the order is served by an index on those columns.
+type Cursor struct {
+	CreatedAt time.Time
+	ID        uuid.UUID
+}
+
+// String encodes the cursor for use in URLs. Clients should treat it as
+// opaque.
+func (c Cursor) String() string {
+	raw := c.CreatedAt.Format(time.RFC3339Nano) + "|" + c.ID.String()
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// ParseCursor decodes a cursor made by Cursor.String.
+func ParseCursor(s string) (Cursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return Cursor{}, ErrInvalidCursor
+	}
+
+	createdAt, id, ok := strings.Cut(string(raw), "|")
+	if !ok {
+		return Cursor{}, ErrInvalidCursor
+	}
+	t, err := time.Parse(time.RFC3339Nano, createdAt)
+	if err != nil {
+		return Cursor{}, ErrInvalidCursor
+	}
+	u, err := uuid.Parse(id)
+	if err != nil {
+		return Cursor{}, ErrInvalidCursor
+	}
+
+	return Cursor{CreatedAt: t, ID: u}, nil
+}
+
+// paginate orders query newest first and limits it to the rows after
+// cursor (nil = from the start). limit 0 means no limit.
+func paginate(query *gorm.DB, after *Cursor, limit int) *gorm.DB {
+	if after != nil {
+		query = query.Where("(created_at, id) < (?, ?)", after.CreatedAt, after.ID)
+	}
+	if limit > 0 {
+		query = query.Limit(limit)
+	}
+	return query.Order("created_at DESC, id DESC")
+}
+
+// NextCursor returns the cursor after the last of rows, or nil if rows
+// didn't fill a page of limit and so was the last one.
+func NextCursor[T any](rows []T, limit int, key func(T) Cursor) *Cursor {
+	if limit <= 0 || len(rows) < limit {
+		return nil
+	}
+
+	next := key(rows[len(rows)-1])
+	return &next
+}
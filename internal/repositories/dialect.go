@@ -0,0 +1,13 @@
+package repositories
+
+import "gorm.io/gorm"
+
+// Dialector names, for the few queries whose SQL differs from Postgres
+const (
+	dialectMySQL  = "mysql"
+	dialectSQLite = "sqlite"
+)
+
+func dialect(db *gorm.DB) string {
+	return db.Dialector.Name()
+}
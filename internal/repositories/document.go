@@ -2,6 +2,8 @@ package repositories
 
 import (
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
@@ -13,6 +15,54 @@ type DocumentRepository interface {
 	Create(document *models.Document) error
 	FindByID(id uuid.UUID) (*models.Document, error)
 	FindByIDs(ids []uuid.UUID) ([]models.Document, error)
+	FindByContentHash(tenantID string, contentHash string) (*models.Document, error)
+	FindExpired(filter DocumentExpiryFilter) ([]models.Document, error)
+	// FindContaining returns the tenant's documents whose parsed text
+	// contains text, ignoring case.
+	FindContaining(tenantID string, text string) ([]models.Document, error)
+	// FindByCandidateEmail returns the tenant's documents whose candidate
+	// email is one of emails, ignoring case.
+	FindByCandidateEmail(tenantID string, emails []string) ([]models.Document, error)
+	// FindUnparsed returns the tenant's documents that have no parsed text.
+	FindUnparsed(tenantID string) ([]models.Document, error)
+	UpdateParsedContent(id uuid.UUID, text string, pageCount int) error
+	// UpdateCandidate stores the contact details set on doc.
+	UpdateCandidate(doc *models.Document) error
+	Delete(id uuid.UUID) error
+	ListFiles() ([]models.Document, error)
+	// List returns the documents matching filter, newest first. Use
+	// NextCursor with DocumentCursor to page through them.
+	List(filter DocumentFilter) ([]models.Document, error)
+	CreatePair(pair *models.DocumentPair) error
+	// FindPair returns the tenant's pair with that ID.
+	FindPair(tenantID string, id uuid.UUID) (*models.DocumentPair, error)
+}
+
+// DocumentExpiryFilter selects documents for the retention job. Documents
+// used by queued or processing evaluations are never returned.
+type DocumentExpiryFilter struct {
+	CreatedBefore time.Time
+	// TenantID restricts the search to one tenant when set.
+	TenantID string
+	// ExcludeTenants skips tenants that have their own retention period.
+	ExcludeTenants []string
+	// SkipIDs leaves out documents the caller already failed to delete.
+	SkipIDs []uuid.UUID
+	Limit   int
+}
+
+// DocumentFilter narrows List; zero fields don't filter.
+type DocumentFilter struct {
+	TenantID string
+	FileType string
+	// After continues a listing from the cursor of its previous page.
+	After *Cursor
+	Limit int
+}
+
+// DocumentCursor is the position of doc in List.
+func DocumentCursor(doc models.Document) Cursor {
+	return Cursor{CreatedAt: doc.CreatedAt, ID: doc.ID}
 }
 
 type documentRepository struct {
@@ -52,6 +102,205 @@ func (d *documentRepository) FindByIDs(ids []uuid.UUID) ([]models.Document, erro
 	return docs, nil
 }
 
+// FindByContentHash implements DocumentRepository. It returns nil when the
+// tenant has no document with that hash.
+func (d *documentRepository) FindByContentHash(tenantID string, contentHash string) (*models.Document, error) {
+	var docs []models.Document
+	err := d.db.
+		Where("tenant_id = ? AND content_hash = ?", tenantID, contentHash).
+		Order("created_at ASC").
+		Limit(1).
+		Find(&docs).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to find document by hash: %w", err)
+	}
+
+	if len(docs) == 0 {
+		return nil, nil
+	}
+
+	return &docs[0], nil
+}
+
+// FindExpired implements DocumentRepository.
+func (d *documentRepository) FindExpired(filter DocumentExpiryFilter) ([]models.Document, error) {
+	query := d.db.
+		Where("created_at < ?", filter.CreatedBefore).
+		Where(`NOT EXISTS (
+			SELECT 1 FROM evaluations e
+			WHERE (e.cv_document_id = documents.id OR e.project_document_id = documents.id OR e.id IN (
+				SELECT p.evaluation_id FROM evaluation_project_documents p WHERE p.document_id = documents.id
+			))
+			AND e.status IN ?
+		)`, []models.EvaluationStatus{models.StatusQueued, models.StatusProcessing})
+
+	if filter.TenantID != "" {
+		query = query.Where("tenant_id = ?", filter.TenantID)
+	}
+	if len(filter.ExcludeTenants) > 0 {
+		query = query.Where("tenant_id NOT IN ?", filter.ExcludeTenants)
+	}
+	if len(filter.SkipIDs) > 0 {
+		query = query.Where("id NOT IN ?", filter.SkipIDs)
+	}
+
+	var docs []models.Document
+	if err := query.Order("created_at ASC").Limit(filter.Limit).Find(&docs).Error; err != nil {
+		return nil, fmt.Errorf("failed to find expired documents: %w", err)
+	}
+
+	return docs, nil
+}
+
+// FindContaining implements DocumentRepository.
+func (d *documentRepository) FindContaining(tenantID string, text string) ([]models.Document, error) {
+	// '!' rather than a backslash escapes, as MySQL treats backslashes in
+	// string literals as escapes themselves
+	pattern := "%" + likeEscaper.Replace(strings.ToLower(text)) + "%"
+
+	var docs []models.Document
+	err := d.db.
+		Where("tenant_id = ? AND LOWER(parsed_text) LIKE ? ESCAPE '!'", tenantID, pattern).
+		Find(&docs).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to search documents: %w", err)
+	}
+
+	return docs, nil
+}
+
+// FindByCandidateEmail implements DocumentRepository.
+func (d *documentRepository) FindByCandidateEmail(tenantID string, emails []string) ([]models.Document, error) {
+	lowered := make([]string, len(emails))
+	for i, email := range emails {
+		lowered[i] = strings.ToLower(email)
+	}
+
+	var docs []models.Document
+	err := d.db.
+		Where("tenant_id = ? AND LOWER(candidate_email) IN ?", tenantID, lowered).
+		Find(&docs).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to find documents by candidate email: %w", err)
+	}
+
+	return docs, nil
+}
+
+// FindUnparsed implements DocumentRepository.
+func (d *documentRepository) FindUnparsed(tenantID string) ([]models.Document, error) {
+	var docs []models.Document
+	err := d.db.
+		Where("tenant_id = ? AND (parsed_text IS NULL OR parsed_text = '')", tenantID).
+		Find(&docs).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to find unparsed documents: %w", err)
+	}
+
+	return docs, nil
+}
+
+var likeEscaper = strings.NewReplacer("!", "!!", "%", "!%", "_", "!_")
+
+// UpdateParsedContent implements DocumentRepository.
+func (d *documentRepository) UpdateParsedContent(id uuid.UUID, text string, pageCount int) error {
+	err := d.db.Model(&models.Document{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"parsed_text": text,
+			"page_count":  pageCount,
+			"updated_at":  time.Now(),
+		}).Error
+	if err != nil {
+		return fmt.Errorf("failed to update parsed content: %w", err)
+	}
+
+	return nil
+}
+
+// UpdateCandidate implements DocumentRepository.
+func (d *documentRepository) UpdateCandidate(doc *models.Document) error {
+	err := d.db.Model(&models.Document{}).
+		Where("id = ?", doc.ID).
+		Updates(map[string]interface{}{
+			"candidate_name":  doc.CandidateName,
+			"candidate_email": doc.CandidateEmail,
+			"candidate_phone": doc.CandidatePhone,
+			"updated_at":      time.Now(),
+		}).Error
+	if err != nil {
+		return fmt.Errorf("failed to update candidate: %w", err)
+	}
+
+	return nil
+}
+
+// Delete implements DocumentRepository.
+func (d *documentRepository) Delete(id uuid.UUID) error {
+	result := d.db.Where("id = ?", id).Delete(&models.Document{})
+	if result.Error != nil {
+		return fmt.Errorf("failed to delete document: %w", result.Error)
+	}
+
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("document not found")
+	}
+
+	return nil
+}
+
+// ListFiles implements DocumentRepository. Only the columns needed to match
+// rows against the upload directory are loaded.
+func (d *documentRepository) ListFiles() ([]models.Document, error) {
+	var docs []models.Document
+	if err := d.db.Select("id", "filename", "tenant_id", "created_at").Find(&docs).Error; err != nil {
+		return nil, fmt.Errorf("failed to list documents: %w", err)
+	}
+
+	return docs, nil
+}
+
+// List implements DocumentRepository.
+func (d *documentRepository) List(filter DocumentFilter) ([]models.Document, error) {
+	query := d.db.Model(&models.Document{})
+	if filter.TenantID != "" {
+		query = query.Where("tenant_id = ?", filter.TenantID)
+	}
+	if filter.FileType != "" {
+		query = query.Where("file_type = ?", filter.FileType)
+	}
+
+	var docs []models.Document
+	if err := paginate(query, filter.After, filter.Limit).Find(&docs).Error; err != nil {
+		return nil, fmt.Errorf("failed to list documents: %w", err)
+	}
+
+	return docs, nil
+}
+
+// CreatePair implements DocumentRepository.
+func (d *documentRepository) CreatePair(pair *models.DocumentPair) error {
+	if err := d.db.Create(pair).Error; err != nil {
+		return fmt.Errorf("failed to create document pair: %w", err)
+	}
+
+	return nil
+}
+
+// FindPair implements DocumentRepository.
+func (d *documentRepository) FindPair(tenantID string, id uuid.UUID) (*models.DocumentPair, error) {
+	var pair models.DocumentPair
+	if err := d.db.Where("id = ? AND tenant_id = ?", id, tenantID).First(&pair).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("document pair not found: %w", err)
+		}
+
+		return nil, fmt.Errorf("failed to find document pair: %w", err)
+	}
+
+	return &pair, nil
+}
+
 func NewDocumentRepository(db *gorm.DB) DocumentRepository {
 	return &documentRepository{db: db}
 }
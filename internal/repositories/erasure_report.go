@@ -0,0 +1,46 @@
+package repositories
+
+import (
+	"fmt"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"alfredoptarigan/cv-evaluator/internal/models"
+)
+
+type ErasureReportRepository interface {
+	Create(report *models.ErasureReport) error
+	FindByID(id uuid.UUID) (*models.ErasureReport, error)
+}
+
+type erasureReportRepository struct {
+	db *gorm.DB
+}
+
+func NewErasureReportRepository(db *gorm.DB) ErasureReportRepository {
+	return &erasureReportRepository{db: db}
+}
+
+// Create implements ErasureReportRepository.
+func (r *erasureReportRepository) Create(report *models.ErasureReport) error {
+	if err := r.db.Create(report).Error; err != nil {
+		return fmt.Errorf("failed to save erasure report: %w", err)
+	}
+
+	return nil
+}
+
+// FindByID implements ErasureReportRepository.
+func (r *erasureReportRepository) FindByID(id uuid.UUID) (*models.ErasureReport, error) {
+	var report models.ErasureReport
+	if err := r.db.Where("id = ?", id).First(&report).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("erasure report not found: %w", err)
+		}
+
+		return nil, fmt.Errorf("failed to find erasure report: %w", err)
+	}
+
+	return &report, nil
+}
@@ -1,22 +1,133 @@
 package repositories
 
 import (
+	"errors"
 	"fmt"
+	"slices"
 	"time"
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 
 	"alfredoptarigan/cv-evaluator/internal/models"
 )
 
+// ErrInvalidTransition is returned when a status change isn't allowed from
+// the evaluation's current status, e.g. because another worker got there
+// first.
+var ErrInvalidTransition = errors.New("invalid evaluation status transition")
+
+// EvaluationRepository stores evaluations. Status changes follow
+// models.EvaluationStatus.CanTransitionTo and are recorded as timeline events
+// in the same transaction.
 type EvaluationRepository interface {
-	Create(eval *models.Evaluation) error
+	// Create stores a new evaluation, with the documents submitted along
+	// with its project report, in order.
+	Create(eval *models.Evaluation, additionalProjectDocIDs []uuid.UUID) error
 	FindByID(id uuid.UUID) (models.Evaluation, error)
+	// FindAdditionalProjectDocuments returns the documents submitted along
+	// with the evaluation's project report, in order.
+	FindAdditionalProjectDocuments(id uuid.UUID) ([]uuid.UUID, error)
+	// FindByIDs returns the tenant's evaluations found, in no particular
+	// order.
+	FindByIDs(tenantID string, ids []uuid.UUID) ([]models.Evaluation, error)
 	UpdateStatus(id uuid.UUID, status models.EvaluationStatus) error
 	UpdateResult(id uuid.UUID, result *EvaluationUpdateData) error
 	UpdateError(id uuid.UUID, errorMsg string) error
-	FindPendingJobs(limit int) ([]models.Evaluation, error)
+	// UpdatePartialError fails an evaluation whose finished stages are kept,
+	// moving it to partially_completed.
+	UpdatePartialError(id uuid.UUID, errorMsg string) error
+	// SaveCheckpoint stores a finished stage's output, replacing an earlier
+	// one, and applies data (may be nil) so partial results show up. It
+	// only succeeds while the evaluation is processing.
+	SaveCheckpoint(id uuid.UUID, stage models.CheckpointStage, output string, data *EvaluationUpdateData) error
+	FindCheckpoints(id uuid.UUID) ([]models.EvaluationCheckpoint, error)
+	// SaveResponse stores a raw LLM response for Replay.
+	SaveResponse(response *models.EvaluationResponse) error
+	// SaveLLMKeys records the LLM cache and batch keys of an evaluation's
+	// calls; keys recorded before are skipped.
+	SaveLLMKeys(id uuid.UUID, keys []string) error
+	// FindLLMKeys returns the keys recorded for any of the evaluations.
+	FindLLMKeys(ids []uuid.UUID) ([]string, error)
+	// FindResponses returns the evaluation's stored LLM responses, oldest
+	// first.
+	FindResponses(id uuid.UUID) ([]models.EvaluationResponse, error)
+	// Replay stores checkpoints recovered from a failed or partially
+	// completed evaluation's stored responses and applies data (may be
+	// nil). With complete it also moves the evaluation to completed.
+	Replay(id uuid.UUID, checkpoints []models.EvaluationCheckpoint, data *EvaluationUpdateData, complete bool) error
+	// FindPendingJobs returns queued evaluations not updated or scheduled
+	// since olderThan, i.e. ones whose dispatch was lost.
+	FindPendingJobs(limit int, olderThan time.Time) ([]models.Evaluation, error)
+	// ExpireQueued moves up to limit evaluations still queued that were
+	// due before dueBefore (scheduled ones at their run_at, others when
+	// created) to expired with message, and returns their IDs.
+	ExpireQueued(dueBefore time.Time, message string, limit int) ([]uuid.UUID, error)
+	// CountQueued counts queued evaluations that are due; scheduled ones
+	// don't count until their run_at.
+	CountQueued() (int64, error)
+	// QueuePosition returns the 1-based position of a queued evaluation that
+	// is due among the due queued ones, in dispatch order.
+	QueuePosition(eval models.Evaluation) (int64, error)
+	// AverageProcessingTime averages how long the last sample completed
+	// evaluations took to process. It returns 0 without history.
+	AverageProcessingTime(sample int) (time.Duration, error)
+	// ClaimOutbox removes up to limit outbox entries that are due and
+	// returns their evaluation IDs. Concurrent callers never get the same
+	// entry.
+	ClaimOutbox(limit int) ([]uuid.UUID, error)
+	// ClaimForProcessing moves a queued evaluation that is due to
+	// processing and reports whether this caller won it.
+	ClaimForProcessing(id uuid.UUID) (bool, error)
+	// RecordError appends to the evaluation's error history and sets
+	// last_error. It doesn't change the status.
+	RecordError(id uuid.UUID, attempt int, stage models.EvaluationStage, message string) error
+	FindErrors(id uuid.UUID) ([]models.EvaluationError, error)
+	// SaveCorrection stores a correction, replacing an earlier one.
+	SaveCorrection(correction *models.EvaluationCorrection) error
+	// FindCorrection returns nil when the evaluation wasn't corrected.
+	FindCorrection(id uuid.UUID) (*models.EvaluationCorrection, error)
+	// FindCompleted pages through completed evaluations with their
+	// documents in (completed_at, id) order, starting after the given ones.
+	FindCompleted(afterTime time.Time, afterID uuid.UUID, limit int) ([]models.Evaluation, error)
+	SaveShadow(shadow *models.EvaluationShadow) error
+	FindShadows(id uuid.UUID) ([]models.EvaluationShadow, error)
+	// FindEvents returns the evaluation's timeline, oldest first.
+	FindEvents(id uuid.UUID) ([]models.EvaluationEvent, error)
+	// Requeue puts an interrupted evaluation back in the queue if it is
+	// still processing; one that finished in the meantime is left alone.
+	Requeue(id uuid.UUID) error
+	// List returns the evaluations matching filter, newest first. Use
+	// NextCursor with EvaluationCursor to page through them.
+	List(filter EvaluationFilter) ([]models.Evaluation, error)
+	// ListJobs sums up the tenant's evaluations per job title, most
+	// evaluated first. With titles it only returns those jobs.
+	ListJobs(tenantID string, titles ...string) ([]models.JobStats, error)
+	// Retry puts a failed, expired or cancelled evaluation back in the queue
+	// for dispatch. Checkpointed stages aren't run again.
+	Retry(id uuid.UUID) error
+	// Cancel stops a queued or processing evaluation. A running attempt
+	// can't be interrupted from here, but its further writes are refused.
+	Cancel(id uuid.UUID, reason string) error
+	// Reschedule returns a processing evaluation to the queue until runAt,
+	// recording eventType with detail.
+	Reschedule(id uuid.UUID, runAt time.Time, eventType models.EvaluationEventType, detail string) error
+	AddTokenUsage(id uuid.UUID, promptTokens, completionTokens int64, cost float64) error
+	Stats() (*models.EvaluationStats, error)
+	// FindDuplicate returns the tenant's newest queued, processing or
+	// completed evaluation matching filter, or nil.
+	FindDuplicate(filter DuplicateFilter) (*models.Evaluation, error)
+	// FindIDsByDocuments returns the evaluations that used any of the
+	// documents as CV, project report or additional project document.
+	FindIDsByDocuments(documentIDs []uuid.UUID) ([]uuid.UUID, error)
+	// Delete removes the evaluations with everything recorded about them:
+	// links to additional project documents (not the documents themselves),
+	// checkpoints, stored LLM responses, events, errors, shadow runs,
+	// corrections and outbox entries. Evaluations being processed are left
+	// alone, as the worker would go on writing about them; the IDs of those
+	// deleted are returned.
+	Delete(ids []uuid.UUID) ([]uuid.UUID, error)
 }
 
 type EvaluationUpdateData struct {
@@ -25,6 +136,43 @@ type EvaluationUpdateData struct {
 	ProjectScore    *float64
 	ProjectFeedback *string
 	OverallSummary  *string
+	// RAGContextMissing flags results scored without all reference context.
+	RAGContextMissing *bool
+}
+
+// EvaluationFilter narrows List; zero fields don't filter.
+// DuplicateFilter describes a submission FindDuplicate looks for an earlier
+// evaluation of: the same documents for the same job title, run the same way.
+type DuplicateFilter struct {
+	TenantID                     string
+	CVDocumentID                 uuid.UUID
+	ProjectDocumentID            uuid.UUID
+	AdditionalProjectDocumentIDs []uuid.UUID
+	JobTitle                     string
+	// Model is the requested model; "" matches evaluations that didn't
+	// override it, whichever cohort they ran in.
+	Model         string
+	Temperature   *float32
+	ExecutionMode models.ExecutionMode
+	RunAt         *time.Time
+}
+
+type EvaluationFilter struct {
+	Statuses      []models.EvaluationStatus
+	TenantID      string
+	CreatedBefore time.Time
+	JobTitle      string
+	// DocumentID matches evaluations of the document as CV, project
+	// report or additional project document.
+	DocumentID uuid.UUID
+	// After continues a listing from the cursor of its previous page.
+	After *Cursor
+	Limit int
+}
+
+// EvaluationCursor is the position of eval in List.
+func EvaluationCursor(eval models.Evaluation) Cursor {
+	return Cursor{CreatedAt: eval.CreatedAt, ID: eval.ID}
 }
 
 type evaluationRepository struct {
@@ -35,8 +183,30 @@ func NewEvaluationRepository(db *gorm.DB) EvaluationRepository {
 	return &evaluationRepository{db: db}
 }
 
-func (r *evaluationRepository) Create(eval *models.Evaluation) error {
-	if err := r.db.Create(eval).Error; err != nil {
+// Create inserts the evaluation together with its outbox entry in one
+// transaction, so every committed job gets dispatched and nothing is
+// dispatched that wasn't committed.
+func (r *evaluationRepository) Create(eval *models.Evaluation, additionalProjectDocIDs []uuid.UUID) error {
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(eval).Error; err != nil {
+			return err
+		}
+		for i, docID := range additionalProjectDocIDs {
+			doc := &models.EvaluationProjectDocument{EvaluationID: eval.ID, DocumentID: docID, Position: i + 1}
+			if err := tx.Create(doc).Error; err != nil {
+				return err
+			}
+		}
+		entry := &models.EvaluationOutbox{EvaluationID: eval.ID, AvailableAt: time.Now()}
+		if eval.RunAt != nil && eval.RunAt.After(entry.AvailableAt) {
+			entry.AvailableAt = *eval.RunAt
+		}
+		if err := tx.Create(entry).Error; err != nil {
+			return err
+		}
+		return recordEvent(tx, eval.ID, models.EventQueued, "")
+	})
+	if err != nil {
 		return fmt.Errorf("failed to create evaluation: %w", err)
 	}
 	return nil
@@ -53,29 +223,68 @@ func (r *evaluationRepository) FindByID(id uuid.UUID) (models.Evaluation, error)
 	return eval, nil
 }
 
-func (r *evaluationRepository) UpdateStatus(id uuid.UUID, status models.EvaluationStatus) error {
-	result := r.db.Model(&models.Evaluation{}).
-		Where("id = ?", id).
-		Updates(map[string]interface{}{
-			"status":     status,
-			"updated_at": time.Now(),
-		})
+func (r *evaluationRepository) FindAdditionalProjectDocuments(id uuid.UUID) ([]uuid.UUID, error) {
+	var ids []uuid.UUID
+	err := r.db.Model(&models.EvaluationProjectDocument{}).
+		Where("evaluation_id = ?", id).
+		Order("position").
+		Pluck("document_id", &ids).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to find additional project documents: %w", err)
+	}
+	return ids, nil
+}
 
-	if result.Error != nil {
-		return fmt.Errorf("failed to update status: %w", result.Error)
+func (r *evaluationRepository) FindByIDs(tenantID string, ids []uuid.UUID) ([]models.Evaluation, error) {
+	var evals []models.Evaluation
+	if err := r.db.Where("tenant_id = ? AND id IN ?", tenantID, ids).Find(&evals).Error; err != nil {
+		return nil, fmt.Errorf("failed to find evaluations: %w", err)
 	}
+	return evals, nil
+}
 
-	if result.RowsAffected == 0 {
-		return fmt.Errorf("evaluation not found")
+func (r *evaluationRepository) UpdateStatus(id uuid.UUID, status models.EvaluationStatus) error {
+	return r.transition(id, status, map[string]interface{}{})
+}
+
+// transition applies updates and moves the evaluation to status, but only
+// from a status that may lead there. The check is part of the UPDATE, so
+// concurrent writers can't both win.
+func (r *evaluationRepository) transition(id uuid.UUID, status models.EvaluationStatus, updates map[string]interface{}) error {
+	updates["status"] = status
+	updates["updated_at"] = time.Now()
+	detail, _ := updates["error_message"].(string)
+
+	var changed bool
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.Evaluation{}).
+			Where("id = ? AND status IN ?", id, models.StatusesBefore(status)).
+			Updates(updates)
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		changed = true
+		return recordEvent(tx, id, statusEvents[status], detail)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to change status to %s: %w", status, err)
+	}
+	if changed {
+		return nil
 	}
 
-	return nil
+	current, err := r.FindByID(id)
+	if err != nil {
+		return err
+	}
+	return fmt.Errorf("%w: %s to %s", ErrInvalidTransition, current.Status, status)
 }
 
-func (r *evaluationRepository) UpdateResult(id uuid.UUID, data *EvaluationUpdateData) error {
-	updates := map[string]interface{}{
-		"status":     models.StatusCompleted,
-		"updated_at": time.Now(),
+// updates returns the columns set in data.
+func (data *EvaluationUpdateData) updates() map[string]interface{} {
+	updates := map[string]interface{}{}
+	if data == nil {
+		return updates
 	}
 
 	if data.CVMatchRate != nil {
@@ -93,47 +302,206 @@ func (r *evaluationRepository) UpdateResult(id uuid.UUID, data *EvaluationUpdate
 	if data.OverallSummary != nil {
 		updates["overall_summary"] = *data.OverallSummary
 	}
+	if data.RAGContextMissing != nil {
+		updates["rag_context_missing"] = *data.RAGContextMissing
+	}
 
-	result := r.db.Model(&models.Evaluation{}).
-		Where("id = ?", id).
-		Updates(updates)
+	return updates
+}
 
-	if result.Error != nil {
-		return fmt.Errorf("failed to update result: %w", result.Error)
-	}
+// statusEvents names the timeline event recorded when an evaluation moves to
+// a status.
+var statusEvents = map[models.EvaluationStatus]models.EvaluationEventType{
+	models.StatusQueued:             models.EventRetried,
+	models.StatusProcessing:         models.EventClaimed,
+	models.StatusCompleted:          models.EventCompleted,
+	models.StatusFailed:             models.EventFailed,
+	models.StatusPartiallyCompleted: models.EventFailed,
+	models.StatusExpired:            models.EventExpired,
+	models.StatusCancelled:          models.EventCancelled,
+}
+
+// checkpointEvents names the timeline event recorded with a checkpoint, if
+// any.
+var checkpointEvents = map[models.CheckpointStage]models.EvaluationEventType{
+	models.CheckpointCVResult:      models.EventCVEvaluated,
+	models.CheckpointProjectResult: models.EventProjectEvaluated,
+}
+
+func recordEvent(tx *gorm.DB, id uuid.UUID, eventType models.EvaluationEventType, detail string) error {
+	return tx.Create(&models.EvaluationEvent{EvaluationID: id, Type: eventType, Detail: detail}).Error
+}
 
-	if result.RowsAffected == 0 {
-		return fmt.Errorf("evaluation not found")
+func (r *evaluationRepository) UpdateResult(id uuid.UUID, data *EvaluationUpdateData) error {
+	updates := data.updates()
+	updates["completed_at"] = time.Now()
+
+	if err := r.transition(id, models.StatusCompleted, updates); err != nil {
+		return fmt.Errorf("failed to update result: %w", err)
 	}
 
 	return nil
 }
 
 func (r *evaluationRepository) UpdateError(id uuid.UUID, errorMsg string) error {
-	result := r.db.Model(&models.Evaluation{}).
-		Where("id = ?", id).
-		Updates(map[string]interface{}{
-			"status":        models.StatusFailed,
-			"error_message": errorMsg,
-			"updated_at":    time.Now(),
-		})
+	if err := r.transition(id, models.StatusFailed, map[string]interface{}{"error_message": errorMsg}); err != nil {
+		return fmt.Errorf("failed to update error: %w", err)
+	}
+
+	return nil
+}
+
+func (r *evaluationRepository) UpdatePartialError(id uuid.UUID, errorMsg string) error {
+	if err := r.transition(id, models.StatusPartiallyCompleted, map[string]interface{}{"error_message": errorMsg}); err != nil {
+		return fmt.Errorf("failed to update error: %w", err)
+	}
+
+	return nil
+}
+
+func (r *evaluationRepository) SaveCheckpoint(id uuid.UUID, stage models.CheckpointStage, output string, data *EvaluationUpdateData) error {
+	updates := data.updates()
+	updates["updated_at"] = time.Now()
+
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.Evaluation{}).
+			Where("id = ? AND status = ?", id, models.StatusProcessing).
+			Updates(updates)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return fmt.Errorf("%w: evaluation is not processing", ErrInvalidTransition)
+		}
+
+		return saveCheckpoint(tx, &models.EvaluationCheckpoint{EvaluationID: id, Stage: stage, Output: output}, "")
+	})
+	if err != nil {
+		return fmt.Errorf("failed to save %s checkpoint: %w", stage, err)
+	}
 
-	if result.Error != nil {
-		return fmt.Errorf("failed to update error: %w", result.Error)
+	return nil
+}
+
+// saveCheckpoint upserts the checkpoint and records its event with detail.
+func saveCheckpoint(tx *gorm.DB, checkpoint *models.EvaluationCheckpoint, detail string) error {
+	err := tx.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "evaluation_id"}, {Name: "stage"}},
+		DoUpdates: clause.AssignmentColumns([]string{"output", "created_at"}),
+	}).Create(checkpoint).Error
+	if err != nil {
+		return err
+	}
+
+	if event, ok := checkpointEvents[checkpoint.Stage]; ok {
+		return recordEvent(tx, checkpoint.EvaluationID, event, detail)
+	}
+	return nil
+}
+
+func (r *evaluationRepository) FindCheckpoints(id uuid.UUID) ([]models.EvaluationCheckpoint, error) {
+	var checkpoints []models.EvaluationCheckpoint
+	if err := r.db.Where("evaluation_id = ?", id).Find(&checkpoints).Error; err != nil {
+		return nil, fmt.Errorf("failed to find evaluation checkpoints: %w", err)
 	}
 
-	if result.RowsAffected == 0 {
-		return fmt.Errorf("evaluation not found")
+	return checkpoints, nil
+}
+
+func (r *evaluationRepository) SaveResponse(response *models.EvaluationResponse) error {
+	if err := r.db.Create(response).Error; err != nil {
+		return fmt.Errorf("failed to save evaluation response: %w", err)
+	}
+
+	return nil
+}
+
+func (r *evaluationRepository) SaveLLMKeys(id uuid.UUID, keys []string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+
+	rows := make([]models.EvaluationLLMKey, len(keys))
+	for i, key := range keys {
+		rows[i] = models.EvaluationLLMKey{EvaluationID: id, Key: key}
+	}
+	if err := r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&rows).Error; err != nil {
+		return fmt.Errorf("failed to save evaluation llm keys: %w", err)
 	}
 
 	return nil
 }
 
-func (r *evaluationRepository) FindPendingJobs(limit int) ([]models.Evaluation, error) {
+func (r *evaluationRepository) FindLLMKeys(ids []uuid.UUID) ([]string, error) {
+	var keys []string
+	err := r.db.Model(&models.EvaluationLLMKey{}).
+		Where("evaluation_id IN ?", ids).
+		Distinct().
+		Pluck("key", &keys).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to find evaluation llm keys: %w", err)
+	}
+
+	return keys, nil
+}
+
+func (r *evaluationRepository) FindResponses(id uuid.UUID) ([]models.EvaluationResponse, error) {
+	var responses []models.EvaluationResponse
+	if err := r.db.Where("evaluation_id = ?", id).Order("id ASC").Find(&responses).Error; err != nil {
+		return nil, fmt.Errorf("failed to find evaluation responses: %w", err)
+	}
+
+	return responses, nil
+}
+
+// replayDetail marks the timeline events of a replay.
+const replayDetail = "replayed from stored LLM responses"
+
+func (r *evaluationRepository) Replay(id uuid.UUID, checkpoints []models.EvaluationCheckpoint, data *EvaluationUpdateData, complete bool) error {
+	now := time.Now()
+	updates := data.updates()
+	updates["updated_at"] = now
+	if complete {
+		updates["status"] = models.StatusCompleted
+		updates["error_message"] = ""
+		updates["completed_at"] = now
+	}
+
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.Evaluation{}).
+			Where("id = ? AND status IN ?", id, []models.EvaluationStatus{models.StatusFailed, models.StatusPartiallyCompleted}).
+			Updates(updates)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return fmt.Errorf("%w: evaluation is not failed", ErrInvalidTransition)
+		}
+
+		for i := range checkpoints {
+			checkpoints[i].EvaluationID = id
+			if err := saveCheckpoint(tx, &checkpoints[i], replayDetail); err != nil {
+				return err
+			}
+		}
+
+		if complete {
+			return recordEvent(tx, id, models.EventCompleted, replayDetail)
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to replay evaluation: %w", err)
+	}
+
+	return nil
+}
+
+func (r *evaluationRepository) FindPendingJobs(limit int, olderThan time.Time) ([]models.Evaluation, error) {
 	var evals []models.Evaluation
 	err := r.db.
-		Where("status = ?", models.StatusQueued).
-		Order("created_at ASC").
+		Where("status = ? AND updated_at < ? AND (run_at IS NULL OR run_at < ?)", models.StatusQueued, olderThan, olderThan).
+		Order("created_at ASC, id ASC").
 		Limit(limit).
 		Find(&evals).Error
 
@@ -143,3 +511,630 @@ func (r *evaluationRepository) FindPendingJobs(limit int) ([]models.Evaluation,
 
 	return evals, nil
 }
+
+func (r *evaluationRepository) ExpireQueued(dueBefore time.Time, message string, limit int) ([]uuid.UUID, error) {
+	var candidates []uuid.UUID
+	err := r.db.Model(&models.Evaluation{}).
+		Where("status = ? AND COALESCE(run_at, created_at) < ?", models.StatusQueued, dueBefore).
+		Order("created_at ASC, id ASC").
+		Limit(limit).
+		Pluck("id", &candidates).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to find expired jobs: %w", err)
+	}
+
+	// Each one goes through transition, so a job claimed in the meantime
+	// is left alone.
+	var expired []uuid.UUID
+	for _, id := range candidates {
+		err := r.transition(id, models.StatusExpired, map[string]interface{}{"error_message": message})
+		if errors.Is(err, ErrInvalidTransition) {
+			continue
+		}
+		if err != nil {
+			return expired, fmt.Errorf("failed to expire job: %w", err)
+		}
+		expired = append(expired, id)
+	}
+
+	return expired, nil
+}
+
+// queuedOnly is spelled out rather than bound so Postgres uses the partial
+// queue order index, whose predicate it can't match against a parameter.
+const queuedOnly = "status = '" + string(models.StatusQueued) + "'"
+
+func (r *evaluationRepository) CountQueued() (int64, error) {
+	var count int64
+	err := r.db.Model(&models.Evaluation{}).
+		Where(queuedOnly+" AND (run_at IS NULL OR run_at <= ?)", time.Now()).
+		Count(&count).Error
+	if err != nil {
+		return 0, fmt.Errorf("failed to count queued jobs: %w", err)
+	}
+
+	return count, nil
+}
+
+func (r *evaluationRepository) QueuePosition(eval models.Evaluation) (int64, error) {
+	var ahead int64
+	err := r.db.Model(&models.Evaluation{}).
+		Where(queuedOnly+" AND (run_at IS NULL OR run_at <= ?)", time.Now()).
+		Where("(COALESCE(run_at, created_at), id) < (?, ?)", dispatchTime(eval), eval.ID).
+		Count(&ahead).Error
+	if err != nil {
+		return 0, fmt.Errorf("failed to find queue position: %w", err)
+	}
+
+	return ahead + 1, nil
+}
+
+// dispatchTime is when an evaluation became due, which orders the queue.
+func dispatchTime(eval models.Evaluation) time.Time {
+	if eval.RunAt != nil {
+		return *eval.RunAt
+	}
+	return eval.CreatedAt
+}
+
+func (r *evaluationRepository) AverageProcessingTime(sample int) (time.Duration, error) {
+	duration := "EXTRACT(EPOCH FROM completed_at - started_at)"
+	switch dialect(r.db) {
+	case dialectMySQL:
+		duration = "TIMESTAMPDIFF(MICROSECOND, started_at, completed_at) / 1000000"
+	case dialectSQLite:
+		duration = "(julianday(completed_at) - julianday(started_at)) * 86400"
+	}
+
+	var seconds *float64
+	err := r.db.Raw(`SELECT AVG(`+duration+`) FROM (
+			SELECT started_at, completed_at FROM evaluations
+			WHERE completed_at IS NOT NULL AND started_at IS NOT NULL
+			ORDER BY completed_at DESC
+			LIMIT ?
+		) recent`, sample).Scan(&seconds).Error
+	if err != nil {
+		return 0, fmt.Errorf("failed to average processing time: %w", err)
+	}
+	if seconds == nil {
+		return 0, nil
+	}
+
+	return time.Duration(*seconds * float64(time.Second)), nil
+}
+
+func (r *evaluationRepository) ClaimOutbox(limit int) ([]uuid.UUID, error) {
+	var ids []uuid.UUID
+	var err error
+	switch dialect(r.db) {
+	case dialectMySQL:
+		ids, err = r.claimOutboxLocked(limit)
+	case dialectSQLite:
+		// SQLite serializes writers, so it needs no row locks
+		err = r.db.Raw(`DELETE FROM evaluation_outbox
+			WHERE id IN (
+				SELECT id FROM evaluation_outbox
+				WHERE available_at <= ?
+				ORDER BY available_at, id
+				LIMIT ?
+			)
+			RETURNING evaluation_id`, time.Now(), limit).Scan(&ids).Error
+	default:
+		err = r.db.Raw(`DELETE FROM evaluation_outbox
+			WHERE id IN (
+				SELECT id FROM evaluation_outbox
+				WHERE available_at <= ?
+				ORDER BY available_at, id
+				LIMIT ?
+				FOR UPDATE SKIP LOCKED
+			)
+			RETURNING evaluation_id`, time.Now(), limit).Scan(&ids).Error
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim outbox entries: %w", err)
+	}
+
+	return ids, nil
+}
+
+// claimOutboxLocked is ClaimOutbox for MySQL, which has neither DELETE ...
+// RETURNING nor LIMIT in IN subqueries: the entries are locked and read
+// first, then deleted in the same transaction.
+func (r *evaluationRepository) claimOutboxLocked(limit int) ([]uuid.UUID, error) {
+	var ids []uuid.UUID
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var entries []models.EvaluationOutbox
+		err := tx.Raw(`SELECT id, evaluation_id FROM evaluation_outbox
+			WHERE available_at <= ?
+			ORDER BY available_at, id
+			LIMIT ?
+			FOR UPDATE SKIP LOCKED`, time.Now(), limit).Scan(&entries).Error
+		if err != nil || len(entries) == 0 {
+			return err
+		}
+
+		entryIDs := make([]int64, len(entries))
+		for i, entry := range entries {
+			entryIDs[i] = entry.ID
+			ids = append(ids, entry.EvaluationID)
+		}
+		return tx.Where("id IN ?", entryIDs).Delete(&models.EvaluationOutbox{}).Error
+	})
+	return ids, err
+}
+
+func (r *evaluationRepository) ClaimForProcessing(id uuid.UUID) (bool, error) {
+	var claimed bool
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.Evaluation{}).
+			Where("id = ? AND status IN ? AND (run_at IS NULL OR run_at <= ?)", id, models.StatusesBefore(models.StatusProcessing), time.Now()).
+			Updates(map[string]interface{}{
+				"status":     models.StatusProcessing,
+				"attempts":   gorm.Expr("attempts + 1"),
+				"started_at": time.Now(),
+				"updated_at": time.Now(),
+			})
+		if result.Error != nil || result.RowsAffected != 1 {
+			return result.Error
+		}
+		claimed = true
+		return recordEvent(tx, id, models.EventClaimed, "")
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to claim evaluation: %w", err)
+	}
+
+	return claimed, nil
+}
+
+func (r *evaluationRepository) RecordError(id uuid.UUID, attempt int, stage models.EvaluationStage, message string) error {
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		entry := &models.EvaluationError{
+			EvaluationID: id,
+			Attempt:      attempt,
+			Stage:        stage,
+			Message:      message,
+		}
+		if err := tx.Create(entry).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.Evaluation{}).Where("id = ?", id).Update("last_error", message).Error
+	})
+	if err != nil {
+		return fmt.Errorf("failed to record evaluation error: %w", err)
+	}
+
+	return nil
+}
+
+func (r *evaluationRepository) FindErrors(id uuid.UUID) ([]models.EvaluationError, error) {
+	var errs []models.EvaluationError
+	err := r.db.Where("evaluation_id = ?", id).Order("id ASC").Find(&errs).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to find evaluation errors: %w", err)
+	}
+
+	return errs, nil
+}
+
+func (r *evaluationRepository) SaveCorrection(correction *models.EvaluationCorrection) error {
+	correction.UpdatedAt = time.Now()
+	err := r.db.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "evaluation_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{
+			"cv_match_rate", "cv_feedback", "project_score", "project_feedback", "overall_summary", "note", "updated_at",
+		}),
+	}).Create(correction).Error
+	if err != nil {
+		return fmt.Errorf("failed to save correction: %w", err)
+	}
+
+	return nil
+}
+
+func (r *evaluationRepository) FindCorrection(id uuid.UUID) (*models.EvaluationCorrection, error) {
+	var correction models.EvaluationCorrection
+	if err := r.db.Where("evaluation_id = ?", id).First(&correction).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to find correction: %w", err)
+	}
+
+	return &correction, nil
+}
+
+func (r *evaluationRepository) FindCompleted(afterTime time.Time, afterID uuid.UUID, limit int) ([]models.Evaluation, error) {
+	var evals []models.Evaluation
+	err := r.db.
+		Preload("CVDocument").
+		Preload("ProjectDocument").
+		Where("status = ? AND (completed_at, id) > (?, ?)", models.StatusCompleted, afterTime, afterID).
+		Order("completed_at ASC, id ASC").
+		Limit(limit).
+		Find(&evals).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to find completed evaluations: %w", err)
+	}
+
+	return evals, nil
+}
+
+func (r *evaluationRepository) SaveShadow(shadow *models.EvaluationShadow) error {
+	if err := r.db.Create(shadow).Error; err != nil {
+		return fmt.Errorf("failed to save shadow result: %w", err)
+	}
+
+	return nil
+}
+
+func (r *evaluationRepository) FindShadows(id uuid.UUID) ([]models.EvaluationShadow, error) {
+	var shadows []models.EvaluationShadow
+	err := r.db.Where("evaluation_id = ?", id).Order("id ASC").Find(&shadows).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to find shadow results: %w", err)
+	}
+
+	return shadows, nil
+}
+
+func (r *evaluationRepository) FindEvents(id uuid.UUID) ([]models.EvaluationEvent, error) {
+	var events []models.EvaluationEvent
+	err := r.db.Where("evaluation_id = ?", id).Order("created_at ASC, id ASC").Find(&events).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to find evaluation events: %w", err)
+	}
+
+	return events, nil
+}
+
+func (r *evaluationRepository) Requeue(id uuid.UUID) error {
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.Evaluation{}).
+			Where("id = ? AND status = ?", id, models.StatusProcessing).
+			Updates(map[string]interface{}{
+				"status":        models.StatusQueued,
+				"error_message": "",
+				"updated_at":    time.Now(),
+			})
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		return recordEvent(tx, id, models.EventRetried, "interrupted by shutdown")
+	})
+	if err != nil {
+		return fmt.Errorf("failed to requeue evaluation: %w", err)
+	}
+
+	return nil
+}
+
+func (r *evaluationRepository) List(filter EvaluationFilter) ([]models.Evaluation, error) {
+	query := r.db.Model(&models.Evaluation{})
+	if len(filter.Statuses) > 0 {
+		query = query.Where("status IN ?", filter.Statuses)
+	}
+	if filter.TenantID != "" {
+		query = query.Where("tenant_id = ?", filter.TenantID)
+	}
+	if !filter.CreatedBefore.IsZero() {
+		query = query.Where("created_at < ?", filter.CreatedBefore)
+	}
+	if filter.JobTitle != "" {
+		query = query.Where("job_title = ?", filter.JobTitle)
+	}
+	if filter.DocumentID != uuid.Nil {
+		query = query.Where("(cv_document_id = ? OR project_document_id = ? OR id IN (?))",
+			filter.DocumentID, filter.DocumentID, projectDocumentEvaluations(r.db, []uuid.UUID{filter.DocumentID}))
+	}
+
+	var evals []models.Evaluation
+	if err := paginate(query, filter.After, filter.Limit).Find(&evals).Error; err != nil {
+		return nil, fmt.Errorf("failed to list evaluations: %w", err)
+	}
+
+	return evals, nil
+}
+
+func (r *evaluationRepository) ListJobs(tenantID string, titles ...string) ([]models.JobStats, error) {
+	query := r.db.Model(&models.Evaluation{}).
+		Select(`job_title, COUNT(*) AS evaluation_count,
+			SUM(CASE WHEN status = ? THEN 1 ELSE 0 END) AS completed_count,
+			COALESCE(AVG(CASE WHEN status = ? THEN cv_match_rate END), 0) AS avg_cv_match_rate,
+			COALESCE(AVG(CASE WHEN status = ? THEN project_score END), 0) AS avg_project_score`,
+			models.StatusCompleted, models.StatusCompleted, models.StatusCompleted).
+		Where("tenant_id = ?", tenantID)
+	if len(titles) > 0 {
+		query = query.Where("job_title IN ?", titles)
+	}
+
+	var jobs []models.JobStats
+	if err := query.Group("job_title").Order("evaluation_count DESC, job_title").Scan(&jobs).Error; err != nil {
+		return nil, fmt.Errorf("failed to list jobs: %w", err)
+	}
+
+	return jobs, nil
+}
+
+func (r *evaluationRepository) Retry(id uuid.UUID) error {
+	now := time.Now()
+	var changed bool
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.Evaluation{}).
+			Where("id = ? AND status IN ? AND status <> ?", id, models.StatusesBefore(models.StatusQueued), models.StatusProcessing).
+			Updates(map[string]interface{}{
+				"status":        models.StatusQueued,
+				"error_message": "",
+				"run_at":        nil,
+				"updated_at":    now,
+			})
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		changed = true
+		if err := tx.Create(&models.EvaluationOutbox{EvaluationID: id, AvailableAt: now}).Error; err != nil {
+			return err
+		}
+		return recordEvent(tx, id, models.EventRetried, "retried by an operator")
+	})
+	if err != nil {
+		return fmt.Errorf("failed to retry evaluation: %w", err)
+	}
+	if changed {
+		return nil
+	}
+
+	current, err := r.FindByID(id)
+	if err != nil {
+		return err
+	}
+	return fmt.Errorf("%w: %s evaluations can't be retried", ErrInvalidTransition, current.Status)
+}
+
+func (r *evaluationRepository) Cancel(id uuid.UUID, reason string) error {
+	if err := r.transition(id, models.StatusCancelled, map[string]interface{}{"error_message": reason}); err != nil {
+		return fmt.Errorf("failed to cancel evaluation: %w", err)
+	}
+
+	return nil
+}
+
+func (r *evaluationRepository) Reschedule(id uuid.UUID, runAt time.Time, eventType models.EvaluationEventType, detail string) error {
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.Evaluation{}).
+			Where("id = ? AND status = ?", id, models.StatusProcessing).
+			Updates(map[string]interface{}{
+				"status":     models.StatusQueued,
+				"run_at":     runAt,
+				"updated_at": time.Now(),
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return fmt.Errorf("%w: evaluation is not processing", ErrInvalidTransition)
+		}
+		if err := tx.Create(&models.EvaluationOutbox{EvaluationID: id, AvailableAt: runAt}).Error; err != nil {
+			return err
+		}
+		return recordEvent(tx, id, eventType, detail)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to reschedule evaluation: %w", err)
+	}
+
+	return nil
+}
+
+// AddTokenUsage adds to the evaluation's counters so retried runs accumulate.
+func (r *evaluationRepository) AddTokenUsage(id uuid.UUID, promptTokens, completionTokens int64, cost float64) error {
+	err := r.db.Model(&models.Evaluation{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"prompt_tokens":      gorm.Expr("prompt_tokens + ?", promptTokens),
+			"completion_tokens":  gorm.Expr("completion_tokens + ?", completionTokens),
+			"estimated_cost_usd": gorm.Expr("estimated_cost_usd + ?", cost),
+		}).Error
+	if err != nil {
+		return fmt.Errorf("failed to update token usage: %w", err)
+	}
+
+	return nil
+}
+
+func (r *evaluationRepository) Stats() (*models.EvaluationStats, error) {
+	stats := &models.EvaluationStats{ByStatus: map[models.EvaluationStatus]int64{}}
+
+	var statusCounts []struct {
+		Status models.EvaluationStatus
+		Count  int64
+	}
+	err := r.db.Model(&models.Evaluation{}).
+		Select("status, COUNT(*) AS count").
+		Group("status").
+		Scan(&statusCounts).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to count evaluations: %w", err)
+	}
+	for _, sc := range statusCounts {
+		stats.ByStatus[sc.Status] = sc.Count
+	}
+
+	err = r.db.Model(&models.Evaluation{}).
+		Select(`tenant_id, COUNT(*) AS evaluations,
+			COALESCE(SUM(prompt_tokens), 0) AS prompt_tokens,
+			COALESCE(SUM(completion_tokens), 0) AS completion_tokens,
+			COALESCE(SUM(estimated_cost_usd), 0) AS estimated_cost_usd`).
+		Group("tenant_id").
+		Order("estimated_cost_usd DESC").
+		Scan(&stats.Tenants).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to aggregate evaluation cost: %w", err)
+	}
+
+	err = r.db.Model(&models.Evaluation{}).
+		Select(`cohort, COALESCE(model, '') AS model, COUNT(*) AS evaluations,
+			SUM(CASE WHEN status = ? THEN 1 ELSE 0 END) AS completed,
+			SUM(CASE WHEN status IN ? THEN 1 ELSE 0 END) AS failed,
+			COALESCE(AVG(CASE WHEN status = ? THEN cv_match_rate END), 0) AS avg_cv_match_rate,
+			COALESCE(AVG(CASE WHEN status = ? THEN project_score END), 0) AS avg_project_score,
+			COALESCE(SUM(estimated_cost_usd), 0) AS estimated_cost_usd`,
+			models.StatusCompleted,
+			[]models.EvaluationStatus{models.StatusFailed, models.StatusPartiallyCompleted, models.StatusExpired},
+			models.StatusCompleted,
+			models.StatusCompleted).
+		Group("cohort, COALESCE(model, '')").
+		Order("cohort, model").
+		Scan(&stats.Cohorts).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to aggregate cohorts: %w", err)
+	}
+
+	for _, t := range stats.Tenants {
+		stats.PromptTokens += t.PromptTokens
+		stats.CompletionTokens += t.CompletionTokens
+		stats.EstimatedCostUSD += t.EstimatedCostUSD
+	}
+
+	return stats, nil
+}
+
+func (r *evaluationRepository) FindDuplicate(filter DuplicateFilter) (*models.Evaluation, error) {
+	query := r.db.
+		Where("tenant_id = ? AND cv_document_id = ? AND project_document_id = ? AND job_title = ?",
+			filter.TenantID, filter.CVDocumentID, filter.ProjectDocumentID, filter.JobTitle).
+		Where("status IN ?", []models.EvaluationStatus{models.StatusQueued, models.StatusProcessing, models.StatusCompleted}).
+		Where("execution_mode = ?", filter.ExecutionMode)
+	if filter.Model != "" {
+		query = query.Where("cohort = ? AND model = ?", models.CohortOverride, filter.Model)
+	} else {
+		query = query.Where("cohort <> ?", models.CohortOverride)
+	}
+
+	var evals []models.Evaluation
+	if err := query.Order("created_at DESC").Find(&evals).Error; err != nil {
+		return nil, fmt.Errorf("failed to find duplicate evaluation: %w", err)
+	}
+
+	// Temperatures and times are compared here, as databases store them
+	// with less precision than they are bound with. Only an evaluation
+	// with the same additional project documents, in the same order, would
+	// produce the same prompts.
+	for i := range evals {
+		if !sameTemperature(evals[i].Temperature, filter.Temperature) || !sameRunAt(evals[i].RunAt, filter.RunAt) {
+			continue
+		}
+		docIDs, err := r.FindAdditionalProjectDocuments(evals[i].ID)
+		if err != nil {
+			return nil, err
+		}
+		if slices.Equal(docIDs, filter.AdditionalProjectDocumentIDs) {
+			return &evals[i], nil
+		}
+	}
+
+	return nil, nil
+}
+
+func sameTemperature(a, b *float32) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+// sameRunAt compares schedules to the second, the precision of MySQL's
+// DATETIME.
+func sameRunAt(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Sub(*b).Abs() < time.Second
+}
+
+func (r *evaluationRepository) FindIDsByDocuments(documentIDs []uuid.UUID) ([]uuid.UUID, error) {
+	var ids []uuid.UUID
+	err := r.db.Model(&models.Evaluation{}).
+		Where("cv_document_id IN ? OR project_document_id IN ? OR id IN (?)",
+			documentIDs, documentIDs, projectDocumentEvaluations(r.db, documentIDs)).
+		Pluck("id", &ids).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to find evaluations by document: %w", err)
+	}
+
+	return ids, nil
+}
+
+// projectDocumentEvaluations selects the IDs of the evaluations that used
+// any of the documents as additional project document.
+func projectDocumentEvaluations(db *gorm.DB, documentIDs []uuid.UUID) *gorm.DB {
+	return db.Model(&models.EvaluationProjectDocument{}).
+		Select("evaluation_id").
+		Where("document_id IN ?", documentIDs)
+}
+
+func (r *evaluationRepository) Delete(ids []uuid.UUID) ([]uuid.UUID, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	// Children are deleted explicitly rather than left to ON DELETE CASCADE,
+	// which SQLite doesn't enforce by default.
+	children := []interface{}{
+		&models.EvaluationProjectDocument{},
+		&models.EvaluationCheckpoint{},
+		&models.EvaluationResponse{},
+		&models.EvaluationLLMKey{},
+		&models.EvaluationEvent{},
+		&models.EvaluationError{},
+		&models.EvaluationShadow{},
+		&models.EvaluationCorrection{},
+		&models.EvaluationOutbox{},
+	}
+	var deleted []uuid.UUID
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		// The evaluations go first, so a worker can't claim a queued one
+		// afterwards; one it claimed first fails the status check
+		result := tx.Where("id IN ? AND status <> ?", ids, models.StatusProcessing).Delete(&models.Evaluation{})
+		if result.Error != nil {
+			return result.Error
+		}
+
+		var kept []uuid.UUID
+		if err := tx.Model(&models.Evaluation{}).Where("id IN ?", ids).Pluck("id", &kept).Error; err != nil {
+			return err
+		}
+		deleted = withoutIDs(ids, kept)
+		if len(deleted) == 0 {
+			return nil
+		}
+
+		for _, child := range children {
+			if err := tx.Where("evaluation_id IN ?", deleted).Delete(child).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to delete evaluations: %w", err)
+	}
+
+	return deleted, nil
+}
+
+// withoutIDs returns ids except those in exclude, in order.
+func withoutIDs(ids, exclude []uuid.UUID) []uuid.UUID {
+	skip := make(map[uuid.UUID]bool, len(exclude))
+	for _, id := range exclude {
+		skip[id] = true
+	}
+
+	var rest []uuid.UUID
+	for _, id := range ids {
+		if !skip[id] {
+			rest = append(rest, id)
+		}
+	}
+	return rest
+}
@@ -0,0 +1,96 @@
+package repositories
+
+import (
+	"fmt"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"alfredoptarigan/cv-evaluator/internal/models"
+)
+
+// IngestedReferenceRepository tracks which reference documents are in the
+// vector store and with what content.
+type IngestedReferenceRepository interface {
+	// Find returns nil when the document wasn't ingested for the tenant.
+	Find(tenantID string, documentID string) (*models.IngestedReference, error)
+	// List returns every ingestion, of all tenants, by tenant, type and
+	// name.
+	List() ([]models.IngestedReference, error)
+	// Save records an ingestion, replacing an earlier one.
+	Save(ref *models.IngestedReference) error
+	// Delete forgets an ingestion.
+	Delete(tenantID string, documentID string) error
+	// DeleteAll forgets every ingestion, e.g. after the collection was
+	// dropped.
+	DeleteAll() error
+}
+
+type ingestedReferenceRepository struct {
+	db *gorm.DB
+}
+
+func NewIngestedReferenceRepository(db *gorm.DB) IngestedReferenceRepository {
+	return &ingestedReferenceRepository{db: db}
+}
+
+// Find implements IngestedReferenceRepository.
+func (r *ingestedReferenceRepository) Find(tenantID string, documentID string) (*models.IngestedReference, error) {
+	var refs []models.IngestedReference
+	err := r.db.
+		Where("tenant_id = ? AND document_id = ?", tenantID, documentID).
+		Limit(1).
+		Find(&refs).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to find ingested reference: %w", err)
+	}
+	if len(refs) == 0 {
+		return nil, nil
+	}
+
+	return &refs[0], nil
+}
+
+// List implements IngestedReferenceRepository.
+func (r *ingestedReferenceRepository) List() ([]models.IngestedReference, error) {
+	var refs []models.IngestedReference
+	if err := r.db.Order("tenant_id, doc_type, name").Find(&refs).Error; err != nil {
+		return nil, fmt.Errorf("failed to list ingested references: %w", err)
+	}
+
+	return refs, nil
+}
+
+// Save implements IngestedReferenceRepository.
+func (r *ingestedReferenceRepository) Save(ref *models.IngestedReference) error {
+	err := r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "tenant_id"}, {Name: "document_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"doc_type", "name", "content_hash", "embedding_model", "chunks", "ingested_at"}),
+	}).Create(ref).Error
+	if err != nil {
+		return fmt.Errorf("failed to save ingested reference: %w", err)
+	}
+
+	return nil
+}
+
+// Delete implements IngestedReferenceRepository.
+func (r *ingestedReferenceRepository) Delete(tenantID string, documentID string) error {
+	err := r.db.
+		Where("tenant_id = ? AND document_id = ?", tenantID, documentID).
+		Delete(&models.IngestedReference{}).Error
+	if err != nil {
+		return fmt.Errorf("failed to delete ingested reference: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteAll implements IngestedReferenceRepository.
+func (r *ingestedReferenceRepository) DeleteAll() error {
+	if err := r.db.Where("1 = 1").Delete(&models.IngestedReference{}).Error; err != nil {
+		return fmt.Errorf("failed to clear ingested references: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,207 @@
+package repositories
+
+import (
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"alfredoptarigan/cv-evaluator/internal/models"
+)
+
+// LLMBatchRepository stores prompts sent through the batch API until their
+// responses are picked up.
+type LLMBatchRepository interface {
+	// Get returns nil when the request isn't stored.
+	Get(key string) (*models.LLMBatchRequest, error)
+	// Enqueue stores a pending request unless one with its key exists.
+	Enqueue(req *models.LLMBatchRequest) error
+	// FindPending returns up to limit requests waiting to be submitted,
+	// oldest first.
+	FindPending(limit int) ([]models.LLMBatchRequest, error)
+	// MarkSubmitted records that keys were submitted as jobName, in order.
+	MarkSubmitted(keys []string, jobName string) error
+	// FindSubmittedJobs returns the names of jobs still being waited for.
+	FindSubmittedJobs() ([]string, error)
+	// FindByJob returns a job's requests in submission order.
+	FindByJob(jobName string) ([]models.LLMBatchRequest, error)
+	Complete(key, response string, promptTokens, completionTokens int64) error
+	Fail(key, errorMsg string) error
+	Delete(key string) error
+	// DeleteKeys removes the requests with any of the keys, whatever their
+	// status.
+	DeleteKeys(keys []string) (int64, error)
+	// DeleteFinished removes answered requests last updated before before.
+	DeleteFinished(before time.Time) (int64, error)
+}
+
+type llmBatchRepository struct {
+	db *gorm.DB
+}
+
+func NewLLMBatchRepository(db *gorm.DB) LLMBatchRepository {
+	return &llmBatchRepository{db: db}
+}
+
+// Get implements LLMBatchRepository.
+func (r *llmBatchRepository) Get(key string) (*models.LLMBatchRequest, error) {
+	var reqs []models.LLMBatchRequest
+	if err := r.db.Where(map[string]interface{}{"key": key}).Limit(1).Find(&reqs).Error; err != nil {
+		return nil, fmt.Errorf("failed to read batch request: %w", err)
+	}
+
+	if len(reqs) == 0 {
+		return nil, nil
+	}
+
+	return &reqs[0], nil
+}
+
+// Enqueue implements LLMBatchRepository.
+func (r *llmBatchRepository) Enqueue(req *models.LLMBatchRequest) error {
+	req.Status = models.BatchPending
+	err := r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(req).Error
+	if err != nil {
+		return fmt.Errorf("failed to enqueue batch request: %w", err)
+	}
+
+	return nil
+}
+
+// FindPending implements LLMBatchRepository.
+func (r *llmBatchRepository) FindPending(limit int) ([]models.LLMBatchRequest, error) {
+	var reqs []models.LLMBatchRequest
+	err := r.db.
+		Where("status = ?", models.BatchPending).
+		Order("created_at ASC").
+		Limit(limit).
+		Find(&reqs).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to find pending batch requests: %w", err)
+	}
+
+	return reqs, nil
+}
+
+// MarkSubmitted implements LLMBatchRepository.
+func (r *llmBatchRepository) MarkSubmitted(keys []string, jobName string) error {
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		for i, key := range keys {
+			err := tx.Model(&models.LLMBatchRequest{}).
+				Where(map[string]interface{}{"key": key}).
+				Updates(map[string]interface{}{
+					"status":      models.BatchSubmitted,
+					"job_name":    jobName,
+					"batch_index": i,
+					"updated_at":  time.Now(),
+				}).Error
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to mark batch requests submitted: %w", err)
+	}
+
+	return nil
+}
+
+// FindSubmittedJobs implements LLMBatchRepository.
+func (r *llmBatchRepository) FindSubmittedJobs() ([]string, error) {
+	var jobs []string
+	err := r.db.Model(&models.LLMBatchRequest{}).
+		Where("status = ?", models.BatchSubmitted).
+		Distinct().
+		Pluck("job_name", &jobs).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to find submitted batch jobs: %w", err)
+	}
+
+	return jobs, nil
+}
+
+// FindByJob implements LLMBatchRepository.
+func (r *llmBatchRepository) FindByJob(jobName string) ([]models.LLMBatchRequest, error) {
+	var reqs []models.LLMBatchRequest
+	err := r.db.
+		Where("job_name = ? AND status = ?", jobName, models.BatchSubmitted).
+		Order("batch_index ASC").
+		Find(&reqs).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to find batch job requests: %w", err)
+	}
+
+	return reqs, nil
+}
+
+// Complete implements LLMBatchRepository.
+func (r *llmBatchRepository) Complete(key, response string, promptTokens, completionTokens int64) error {
+	err := r.db.Model(&models.LLMBatchRequest{}).
+		Where(map[string]interface{}{"key": key}).
+		Updates(map[string]interface{}{
+			"status":            models.BatchSucceeded,
+			"response":          response,
+			"prompt_tokens":     promptTokens,
+			"completion_tokens": completionTokens,
+			"updated_at":        time.Now(),
+		}).Error
+	if err != nil {
+		return fmt.Errorf("failed to complete batch request: %w", err)
+	}
+
+	return nil
+}
+
+// Fail implements LLMBatchRepository.
+func (r *llmBatchRepository) Fail(key, errorMsg string) error {
+	err := r.db.Model(&models.LLMBatchRequest{}).
+		Where(map[string]interface{}{"key": key}).
+		Updates(map[string]interface{}{
+			"status":        models.BatchFailed,
+			"error_message": errorMsg,
+			"updated_at":    time.Now(),
+		}).Error
+	if err != nil {
+		return fmt.Errorf("failed to fail batch request: %w", err)
+	}
+
+	return nil
+}
+
+// Delete implements LLMBatchRepository.
+func (r *llmBatchRepository) Delete(key string) error {
+	if err := r.db.Where(map[string]interface{}{"key": key}).Delete(&models.LLMBatchRequest{}).Error; err != nil {
+		return fmt.Errorf("failed to delete batch request: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteKeys implements LLMBatchRepository.
+func (r *llmBatchRepository) DeleteKeys(keys []string) (int64, error) {
+	if len(keys) == 0 {
+		return 0, nil
+	}
+
+	result := r.db.Where(map[string]interface{}{"key": keys}).Delete(&models.LLMBatchRequest{})
+	if result.Error != nil {
+		return 0, fmt.Errorf("failed to delete batch requests: %w", result.Error)
+	}
+
+	return result.RowsAffected, nil
+}
+
+// DeleteFinished implements LLMBatchRepository.
+func (r *llmBatchRepository) DeleteFinished(before time.Time) (int64, error) {
+	result := r.db.
+		Where("status IN ? AND updated_at < ?", []models.LLMBatchStatus{models.BatchSucceeded, models.BatchFailed}, before).
+		Delete(&models.LLMBatchRequest{})
+	if result.Error != nil {
+		return 0, fmt.Errorf("failed to purge batch requests: %w", result.Error)
+	}
+
+	return result.RowsAffected, nil
+}
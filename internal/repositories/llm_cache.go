@@ -0,0 +1,120 @@
+package repositories
+
+import (
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"alfredoptarigan/cv-evaluator/internal/models"
+)
+
+type LLMCacheRepository interface {
+	Get(key string) (*models.LLMCacheEntry, error)
+	Put(entry *models.LLMCacheEntry) error
+	DeleteExpired(before time.Time) (int64, error)
+	// DeleteKeys removes the entries with any of the keys.
+	DeleteKeys(keys []string) (int64, error)
+}
+
+type llmCacheRepository struct {
+	db *gorm.DB
+}
+
+func NewLLMCacheRepository(db *gorm.DB) LLMCacheRepository {
+	return &llmCacheRepository{db: db}
+}
+
+// Get implements LLMCacheRepository. Misses and expired entries return nil.
+func (r *llmCacheRepository) Get(key string) (*models.LLMCacheEntry, error) {
+	var entries []models.LLMCacheEntry
+	err := r.db.
+		Where(map[string]interface{}{"key": key}).
+		Where("expires_at > ?", time.Now()).
+		Limit(1).
+		Find(&entries).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to read llm cache: %w", err)
+	}
+
+	if len(entries) == 0 {
+		return nil, nil
+	}
+
+	return &entries[0], nil
+}
+
+// Put implements LLMCacheRepository.
+func (r *llmCacheRepository) Put(entry *models.LLMCacheEntry) error {
+	err := r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "key"}},
+		DoUpdates: clause.AssignmentColumns([]string{"model", "response", "expires_at", "created_at"}),
+	}).Create(entry).Error
+	if err != nil {
+		return fmt.Errorf("failed to write llm cache: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteExpired implements LLMCacheRepository.
+func (r *llmCacheRepository) DeleteExpired(before time.Time) (int64, error) {
+	result := r.db.Where("expires_at < ?", before).Delete(&models.LLMCacheEntry{})
+	if result.Error != nil {
+		return 0, fmt.Errorf("failed to purge llm cache: %w", result.Error)
+	}
+
+	return result.RowsAffected, nil
+}
+
+// DeleteKeys implements LLMCacheRepository.
+func (r *llmCacheRepository) DeleteKeys(keys []string) (int64, error) {
+	if len(keys) == 0 {
+		return 0, nil
+	}
+
+	result := r.db.Where(map[string]interface{}{"key": keys}).Delete(&models.LLMCacheEntry{})
+	if result.Error != nil {
+		return 0, fmt.Errorf("failed to delete llm cache entries: %w", result.Error)
+	}
+
+	return result.RowsAffected, nil
+}
+
+type EmbeddingCacheRepository interface {
+	Get(key string) (*models.EmbeddingCacheEntry, error)
+	Put(entry *models.EmbeddingCacheEntry) error
+}
+
+type embeddingCacheRepository struct {
+	db *gorm.DB
+}
+
+func NewEmbeddingCacheRepository(db *gorm.DB) EmbeddingCacheRepository {
+	return &embeddingCacheRepository{db: db}
+}
+
+// Get implements EmbeddingCacheRepository. A miss returns nil.
+func (r *embeddingCacheRepository) Get(key string) (*models.EmbeddingCacheEntry, error) {
+	var entries []models.EmbeddingCacheEntry
+	if err := r.db.Where(map[string]interface{}{"key": key}).Limit(1).Find(&entries).Error; err != nil {
+		return nil, fmt.Errorf("failed to read embedding cache: %w", err)
+	}
+
+	if len(entries) == 0 {
+		return nil, nil
+	}
+
+	return &entries[0], nil
+}
+
+// Put implements EmbeddingCacheRepository.
+func (r *embeddingCacheRepository) Put(entry *models.EmbeddingCacheEntry) error {
+	err := r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(entry).Error
+	if err != nil {
+		return fmt.Errorf("failed to write embedding cache: %w", err)
+	}
+
+	return nil
+}
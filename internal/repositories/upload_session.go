@@ -0,0 +1,110 @@
+package repositories
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"alfredoptarigan/cv-evaluator/internal/models"
+)
+
+type UploadSessionRepository interface {
+	Create(session *models.UploadSession) error
+	FindByID(id uuid.UUID) (*models.UploadSession, error)
+	UpdateReceived(id uuid.UUID, receivedBytes int64) error
+	SetDocument(id uuid.UUID, documentID uuid.UUID) error
+	DeleteExpired(before time.Time) ([]uuid.UUID, error)
+	DeleteByDocuments(documentIDs []uuid.UUID) (int64, error)
+}
+
+type uploadSessionRepository struct {
+	db *gorm.DB
+}
+
+func NewUploadSessionRepository(db *gorm.DB) UploadSessionRepository {
+	return &uploadSessionRepository{db: db}
+}
+
+// Create implements UploadSessionRepository.
+func (r *uploadSessionRepository) Create(session *models.UploadSession) error {
+	if err := r.db.Create(session).Error; err != nil {
+		return fmt.Errorf("failed to create upload session: %w", err)
+	}
+
+	return nil
+}
+
+// FindByID implements UploadSessionRepository.
+func (r *uploadSessionRepository) FindByID(id uuid.UUID) (*models.UploadSession, error) {
+	var session models.UploadSession
+	if err := r.db.Where("id = ?", id).First(&session).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("upload session not found: %w", err)
+		}
+
+		return nil, fmt.Errorf("failed to find upload session: %w", err)
+	}
+
+	return &session, nil
+}
+
+// UpdateReceived implements UploadSessionRepository.
+func (r *uploadSessionRepository) UpdateReceived(id uuid.UUID, receivedBytes int64) error {
+	err := r.db.Model(&models.UploadSession{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"received_bytes": receivedBytes,
+			"updated_at":     time.Now(),
+		}).Error
+	if err != nil {
+		return fmt.Errorf("failed to update upload session: %w", err)
+	}
+
+	return nil
+}
+
+// SetDocument implements UploadSessionRepository.
+func (r *uploadSessionRepository) SetDocument(id uuid.UUID, documentID uuid.UUID) error {
+	err := r.db.Model(&models.UploadSession{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"document_id": documentID,
+			"updated_at":  time.Now(),
+		}).Error
+	if err != nil {
+		return fmt.Errorf("failed to finalize upload session: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteExpired implements UploadSessionRepository. It returns the IDs of
+// the deleted sessions so their partial files can be removed.
+func (r *uploadSessionRepository) DeleteExpired(before time.Time) ([]uuid.UUID, error) {
+	var ids []uuid.UUID
+	if err := r.db.Model(&models.UploadSession{}).Where("expires_at < ?", before).Pluck("id", &ids).Error; err != nil {
+		return nil, fmt.Errorf("failed to find expired upload sessions: %w", err)
+	}
+
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	if err := r.db.Where("id IN ?", ids).Delete(&models.UploadSession{}).Error; err != nil {
+		return nil, fmt.Errorf("failed to delete expired upload sessions: %w", err)
+	}
+
+	return ids, nil
+}
+
+// DeleteByDocuments implements UploadSessionRepository.
+func (r *uploadSessionRepository) DeleteByDocuments(documentIDs []uuid.UUID) (int64, error) {
+	result := r.db.Where("document_id IN ?", documentIDs).Delete(&models.UploadSession{})
+	if result.Error != nil {
+		return 0, fmt.Errorf("failed to delete upload sessions: %w", result.Error)
+	}
+
+	return result.RowsAffected, nil
+}
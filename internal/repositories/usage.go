@@ -0,0 +1,84 @@
+package repositories
+
+import (
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"alfredoptarigan/cv-evaluator/internal/models"
+)
+
+type UsageRepository interface {
+	Get(tenantID, period string) (models.UsageCounter, error)
+	// Increment adds amount to the kind's counter unless that would take it
+	// over limit (zero is unlimited), and reports whether it did. The check
+	// and the update are one statement, so concurrent calls can't overshoot.
+	Increment(tenantID, period string, kind models.UsageKind, amount, limit int64) (bool, error)
+}
+
+type usageRepository struct {
+	db *gorm.DB
+}
+
+func NewUsageRepository(db *gorm.DB) UsageRepository {
+	return &usageRepository{db: db}
+}
+
+// Get implements UsageRepository. A tenant without usage in the period gets a
+// zero counter rather than an error.
+func (r *usageRepository) Get(tenantID, period string) (models.UsageCounter, error) {
+	var counter models.UsageCounter
+	err := r.db.
+		Where("tenant_id = ? AND period = ?", tenantID, period).
+		First(&counter).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return models.UsageCounter{TenantID: tenantID, Period: period}, nil
+		}
+		return models.UsageCounter{}, fmt.Errorf("failed to find usage: %w", err)
+	}
+
+	return counter, nil
+}
+
+// Increment implements UsageRepository.
+func (r *usageRepository) Increment(tenantID, period string, kind models.UsageKind, amount, limit int64) (bool, error) {
+	column := string(kind)
+	if kind != models.UsageUploads && kind != models.UsageEvaluations {
+		return false, fmt.Errorf("unknown usage kind: %s", kind)
+	}
+
+	// Make sure the period's row exists so the update below has one to test
+	counter := models.UsageCounter{
+		ID:        models.NewID(),
+		TenantID:  tenantID,
+		Period:    period,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+	err := r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "tenant_id"}, {Name: "period"}},
+		DoNothing: true,
+	}).Create(&counter).Error
+	if err != nil {
+		return false, fmt.Errorf("failed to increment usage: %w", err)
+	}
+
+	query := r.db.Model(&models.UsageCounter{}).
+		Where("tenant_id = ? AND period = ?", tenantID, period)
+	if limit > 0 {
+		query = query.Where(column+" + ? <= ?", amount, limit)
+	}
+
+	result := query.Updates(map[string]interface{}{
+		column:       gorm.Expr(column+" + ?", amount),
+		"updated_at": time.Now(),
+	})
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to increment usage: %w", result.Error)
+	}
+
+	return result.RowsAffected > 0, nil
+}
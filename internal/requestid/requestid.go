@@ -0,0 +1,27 @@
+// Package requestid carries the ID of the API request that started some
+// work, so logs written later, e.g. by the worker, can be traced back to it.
+package requestid
+
+import "context"
+
+// Header carries the request ID on HTTP requests and responses and as gRPC
+// metadata (lower-cased).
+const Header = "X-Request-ID"
+
+// MaxLength caps IDs taken from clients before they are stored.
+const MaxLength = 128
+
+type contextKey struct{}
+
+func WithID(ctx context.Context, id string) context.Context {
+	if len(id) > MaxLength {
+		id = id[:MaxLength]
+	}
+	return context.WithValue(ctx, contextKey{}, id)
+}
+
+// FromContext returns the request ID attached to ctx, or "".
+func FromContext(ctx context.Context) string {
+	id, _ := ctx.Value(contextKey{}).(string)
+	return id
+}
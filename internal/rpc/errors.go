@@ -0,0 +1,46 @@
+package rpc
+
+import (
+	"net/http"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"alfredoptarigan/cv-evaluator/internal/apperror"
+)
+
+// toStatus converts application errors into gRPC statuses. The message is
+// prefixed with the machine-readable code used by the HTTP error envelope.
+func toStatus(err error) error {
+	appErr, ok := apperror.As(err)
+	if !ok {
+		return status.Error(codes.Internal, err.Error())
+	}
+
+	return status.Errorf(grpcCode(appErr.Status), "%s: %s", appErr.Code, appErr.Message)
+}
+
+func grpcCode(httpStatus int) codes.Code {
+	switch httpStatus {
+	case http.StatusBadRequest:
+		return codes.InvalidArgument
+	case http.StatusUnauthorized:
+		return codes.Unauthenticated
+	case http.StatusForbidden:
+		return codes.PermissionDenied
+	case http.StatusNotFound:
+		return codes.NotFound
+	case http.StatusConflict:
+		return codes.AlreadyExists
+	case http.StatusRequestEntityTooLarge:
+		return codes.InvalidArgument
+	case http.StatusTooManyRequests:
+		return codes.ResourceExhausted
+	case http.StatusServiceUnavailable:
+		return codes.Unavailable
+	case http.StatusGatewayTimeout, http.StatusRequestTimeout:
+		return codes.DeadlineExceeded
+	default:
+		return codes.Internal
+	}
+}
@@ -0,0 +1,45 @@
+package rpc
+
+import (
+	"context"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+
+	"alfredoptarigan/cv-evaluator/internal/requestid"
+	"alfredoptarigan/cv-evaluator/internal/tenant"
+)
+
+// TenantUnaryInterceptor resolves the tenant from the x-api-key metadata,
+// mirroring handlers.IdentifyTenant for HTTP, and takes the request ID from
+// x-request-id if the caller sent one.
+func TenantUnaryInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	return handler(withTenant(ctx), req)
+}
+
+func TenantStreamInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	return handler(srv, &tenantStream{ServerStream: ss, ctx: withTenant(ss.Context())})
+}
+
+func withTenant(ctx context.Context) context.Context {
+	var apiKey string
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get(strings.ToLower(tenant.APIKeyHeader)); len(values) > 0 {
+			apiKey = values[0]
+		}
+		if values := md.Get(strings.ToLower(requestid.Header)); len(values) > 0 {
+			ctx = requestid.WithID(ctx, values[0])
+		}
+	}
+	return tenant.WithID(ctx, tenant.FromAPIKey(apiKey))
+}
+
+type tenantStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *tenantStream) Context() context.Context {
+	return s.ctx
+}
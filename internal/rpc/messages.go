@@ -0,0 +1,114 @@
+package rpc
+
+import "fmt"
+
+// Message types mirror api/proto/cvevaluator/v1/cv_evaluator.proto. They carry
+// protobuf struct tags so the default gRPC proto codec can marshal them
+// without generated code; keep field numbers in sync with the .proto file.
+
+type UploadDocumentRequest struct {
+	FileType     string `protobuf:"bytes,1,opt,name=file_type,json=fileType,proto3" json:"file_type,omitempty"`
+	OriginalName string `protobuf:"bytes,2,opt,name=original_name,json=originalName,proto3" json:"original_name,omitempty"`
+	Chunk        []byte `protobuf:"bytes,3,opt,name=chunk,proto3" json:"chunk,omitempty"`
+	Password     string `protobuf:"bytes,4,opt,name=password,proto3" json:"password,omitempty"`
+}
+
+func (m *UploadDocumentRequest) Reset() { *m = UploadDocumentRequest{} }
+
+// String leaves out the password.
+func (m *UploadDocumentRequest) String() string {
+	c := *m
+	if c.Password != "" {
+		c.Password = "[redacted]"
+	}
+	return fmt.Sprintf("%+v", c)
+}
+func (*UploadDocumentRequest) ProtoMessage() {}
+func (*UploadDocumentRequest) XXX_MessageName() string {
+	return "cvevaluator.v1.UploadDocumentRequest"
+}
+
+type UploadDocumentResponse struct {
+	Id           string `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Filename     string `protobuf:"bytes,2,opt,name=filename,proto3" json:"filename,omitempty"`
+	OriginalName string `protobuf:"bytes,3,opt,name=original_name,json=originalName,proto3" json:"original_name,omitempty"`
+	FileType     string `protobuf:"bytes,4,opt,name=file_type,json=fileType,proto3" json:"file_type,omitempty"`
+}
+
+func (m *UploadDocumentResponse) Reset()         { *m = UploadDocumentResponse{} }
+func (m *UploadDocumentResponse) String() string { return fmt.Sprintf("%+v", *m) }
+func (*UploadDocumentResponse) ProtoMessage()    {}
+func (*UploadDocumentResponse) XXX_MessageName() string {
+	return "cvevaluator.v1.UploadDocumentResponse"
+}
+
+type CreateEvaluationRequest struct {
+	JobTitle                     string   `protobuf:"bytes,1,opt,name=job_title,json=jobTitle,proto3" json:"job_title,omitempty"`
+	CvDocumentId                 string   `protobuf:"bytes,2,opt,name=cv_document_id,json=cvDocumentId,proto3" json:"cv_document_id,omitempty"`
+	ProjectDocumentId            string   `protobuf:"bytes,3,opt,name=project_document_id,json=projectDocumentId,proto3" json:"project_document_id,omitempty"`
+	BypassCache                  bool     `protobuf:"varint,4,opt,name=bypass_cache,json=bypassCache,proto3" json:"bypass_cache,omitempty"`
+	PairId                       string   `protobuf:"bytes,5,opt,name=pair_id,json=pairId,proto3" json:"pair_id,omitempty"`
+	Force                        bool     `protobuf:"varint,6,opt,name=force,proto3" json:"force,omitempty"`
+	AdditionalProjectDocumentIds []string `protobuf:"bytes,7,rep,name=additional_project_document_ids,json=additionalProjectDocumentIds,proto3" json:"additional_project_document_ids,omitempty"`
+}
+
+func (m *CreateEvaluationRequest) Reset()         { *m = CreateEvaluationRequest{} }
+func (m *CreateEvaluationRequest) String() string { return fmt.Sprintf("%+v", *m) }
+func (*CreateEvaluationRequest) ProtoMessage()    {}
+func (*CreateEvaluationRequest) XXX_MessageName() string {
+	return "cvevaluator.v1.CreateEvaluationRequest"
+}
+
+type CreateEvaluationResponse struct {
+	Id        string `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Status    string `protobuf:"bytes,2,opt,name=status,proto3" json:"status,omitempty"`
+	Duplicate bool   `protobuf:"varint,3,opt,name=duplicate,proto3" json:"duplicate,omitempty"`
+}
+
+func (m *CreateEvaluationResponse) Reset()         { *m = CreateEvaluationResponse{} }
+func (m *CreateEvaluationResponse) String() string { return fmt.Sprintf("%+v", *m) }
+func (*CreateEvaluationResponse) ProtoMessage()    {}
+func (*CreateEvaluationResponse) XXX_MessageName() string {
+	return "cvevaluator.v1.CreateEvaluationResponse"
+}
+
+type GetResultRequest struct {
+	Id string `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+}
+
+func (m *GetResultRequest) Reset()         { *m = GetResultRequest{} }
+func (m *GetResultRequest) String() string { return fmt.Sprintf("%+v", *m) }
+func (*GetResultRequest) ProtoMessage()    {}
+func (*GetResultRequest) XXX_MessageName() string {
+	return "cvevaluator.v1.GetResultRequest"
+}
+
+type EvaluationData struct {
+	CvMatchRate       float64 `protobuf:"fixed64,1,opt,name=cv_match_rate,json=cvMatchRate,proto3" json:"cv_match_rate,omitempty"`
+	CvFeedback        string  `protobuf:"bytes,2,opt,name=cv_feedback,json=cvFeedback,proto3" json:"cv_feedback,omitempty"`
+	ProjectScore      float64 `protobuf:"fixed64,3,opt,name=project_score,json=projectScore,proto3" json:"project_score,omitempty"`
+	ProjectFeedback   string  `protobuf:"bytes,4,opt,name=project_feedback,json=projectFeedback,proto3" json:"project_feedback,omitempty"`
+	OverallSummary    string  `protobuf:"bytes,5,opt,name=overall_summary,json=overallSummary,proto3" json:"overall_summary,omitempty"`
+	RagContextMissing bool    `protobuf:"varint,6,opt,name=rag_context_missing,json=ragContextMissing,proto3" json:"rag_context_missing,omitempty"`
+}
+
+func (m *EvaluationData) Reset()         { *m = EvaluationData{} }
+func (m *EvaluationData) String() string { return fmt.Sprintf("%+v", *m) }
+func (*EvaluationData) ProtoMessage()    {}
+func (*EvaluationData) XXX_MessageName() string {
+	return "cvevaluator.v1.EvaluationData"
+}
+
+type ResultResponse struct {
+	Id           string          `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Status       string          `protobuf:"bytes,2,opt,name=status,proto3" json:"status,omitempty"`
+	Result       *EvaluationData `protobuf:"bytes,3,opt,name=result,proto3" json:"result,omitempty"`
+	ErrorMessage string          `protobuf:"bytes,4,opt,name=error_message,json=errorMessage,proto3" json:"error_message,omitempty"`
+}
+
+func (m *ResultResponse) Reset()         { *m = ResultResponse{} }
+func (m *ResultResponse) String() string { return fmt.Sprintf("%+v", *m) }
+func (*ResultResponse) ProtoMessage()    {}
+func (*ResultResponse) XXX_MessageName() string {
+	return "cvevaluator.v1.ResultResponse"
+}
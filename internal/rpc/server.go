@@ -0,0 +1,212 @@
+package rpc
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"alfredoptarigan/cv-evaluator/internal/apperror"
+	"alfredoptarigan/cv-evaluator/internal/models"
+	"alfredoptarigan/cv-evaluator/internal/repositories"
+	"alfredoptarigan/cv-evaluator/internal/services"
+	"alfredoptarigan/cv-evaluator/internal/tenant"
+	"alfredoptarigan/cv-evaluator/internal/validation"
+)
+
+const watchPollInterval = 2 * time.Second
+
+type Server struct {
+	docService  services.DocumentService
+	evalService services.EvaluationService
+	evalRepo    repositories.EvaluationRepository
+}
+
+func NewServer(
+	docService services.DocumentService,
+	evalService services.EvaluationService,
+	evalRepo repositories.EvaluationRepository,
+) *Server {
+	return &Server{
+		docService:  docService,
+		evalService: evalService,
+		evalRepo:    evalRepo,
+	}
+}
+
+// UploadDocument implements CVEvaluatorServer.
+func (s *Server) UploadDocument(stream grpc.ClientStreamingServer[UploadDocumentRequest, UploadDocumentResponse]) error {
+	first, err := stream.Recv()
+	if err == io.EOF {
+		return status.Error(codes.InvalidArgument, "empty upload stream")
+	}
+	if err != nil {
+		return err
+	}
+
+	if first.FileType != "cv" && first.FileType != "project_report" {
+		return status.Error(codes.InvalidArgument, "file_type must be 'cv' or 'project_report'")
+	}
+
+	if first.OriginalName == "" {
+		return status.Error(codes.InvalidArgument, "original_name is required")
+	}
+
+	src := &uploadStreamReader{stream: stream, buf: first.Chunk}
+	doc, err := s.docService.Upload(stream.Context(), src, first.OriginalName, first.FileType, first.Password)
+	if err != nil {
+		return toStatus(apperror.Wrap(err, http.StatusInternalServerError, apperror.CodeInternal, "failed to save document"))
+	}
+
+	return stream.SendAndClose(&UploadDocumentResponse{
+		Id:           doc.ID.String(),
+		Filename:     doc.Filename,
+		OriginalName: doc.OriginalName,
+		FileType:     doc.FileType,
+	})
+}
+
+// CreateEvaluation implements CVEvaluatorServer.
+func (s *Server) CreateEvaluation(ctx context.Context, req *CreateEvaluationRequest) (*CreateEvaluationResponse, error) {
+	input := models.EvaluateRequest{
+		JobTitle:                     req.JobTitle,
+		CVDocumentID:                 req.CvDocumentId,
+		ProjectDocumentID:            req.ProjectDocumentId,
+		PairID:                       req.PairId,
+		AdditionalProjectDocumentIDs: req.AdditionalProjectDocumentIds,
+		BypassCache:                  req.BypassCache,
+		Force:                        req.Force,
+	}
+	if err := validation.Struct(&input); err != nil {
+		return nil, toStatus(err)
+	}
+
+	cvID, projectID, pairID := input.IDs()
+	evaluation, duplicate, err := s.evalService.Submit(ctx, services.SubmitEvaluationInput{
+		JobTitle:                     input.JobTitle,
+		CVDocumentID:                 cvID,
+		ProjectDocumentID:            projectID,
+		PairID:                       pairID,
+		AdditionalProjectDocumentIDs: input.AdditionalProjectIDs(),
+		BypassCache:                  input.BypassCache,
+		Force:                        input.Force,
+	})
+	if err != nil {
+		return nil, toStatus(apperror.Wrap(err, http.StatusInternalServerError, apperror.CodeInternal, "Failed to create evaluation job"))
+	}
+
+	return &CreateEvaluationResponse{
+		Id:        evaluation.ID.String(),
+		Status:    string(evaluation.Status),
+		Duplicate: duplicate,
+	}, nil
+}
+
+// GetResult implements CVEvaluatorServer.
+func (s *Server) GetResult(ctx context.Context, req *GetResultRequest) (*ResultResponse, error) {
+	evaluation, err := s.findEvaluation(ctx, req.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	return toResultResponse(evaluation), nil
+}
+
+// WatchResult implements CVEvaluatorServer.
+func (s *Server) WatchResult(req *GetResultRequest, stream grpc.ServerStreamingServer[ResultResponse]) error {
+	ticker := time.NewTicker(watchPollInterval)
+	defer ticker.Stop()
+
+	var lastStatus models.EvaluationStatus
+	var lastUpdate time.Time
+
+	for {
+		evaluation, err := s.findEvaluation(stream.Context(), req.Id)
+		if err != nil {
+			return err
+		}
+
+		if evaluation.Status != lastStatus || !evaluation.UpdatedAt.Equal(lastUpdate) {
+			if err := stream.Send(toResultResponse(evaluation)); err != nil {
+				return err
+			}
+			lastStatus = evaluation.Status
+			lastUpdate = evaluation.UpdatedAt
+		}
+
+		if evaluation.Status.IsFinal() {
+			return nil
+		}
+
+		select {
+		case <-stream.Context().Done():
+			return status.FromContextError(stream.Context().Err()).Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// findEvaluation returns the caller's evaluation; other tenants' are
+// reported as not found.
+func (s *Server) findEvaluation(ctx context.Context, id string) (models.Evaluation, error) {
+	evalID, err := uuid.Parse(id)
+	if err != nil {
+		return models.Evaluation{}, status.Error(codes.InvalidArgument, "Invalid evaluation ID format")
+	}
+
+	evaluation, err := s.evalRepo.FindByID(evalID)
+	if err != nil || evaluation.TenantID != tenant.FromContext(ctx) {
+		return models.Evaluation{}, status.Error(codes.NotFound, "Evaluation not found")
+	}
+
+	return evaluation, nil
+}
+
+func toResultResponse(evaluation models.Evaluation) *ResultResponse {
+	response := &ResultResponse{
+		Id:     evaluation.ID.String(),
+		Status: string(evaluation.Status),
+	}
+
+	if evaluation.Status.HasResult() {
+		response.Result = &EvaluationData{
+			CvMatchRate:       evaluation.CVMatchRate,
+			CvFeedback:        evaluation.CVFeedback,
+			ProjectScore:      evaluation.ProjectScore,
+			ProjectFeedback:   evaluation.ProjectFeedback,
+			OverallSummary:    evaluation.OverallSummary,
+			RagContextMissing: evaluation.RAGContextMissing,
+		}
+	}
+
+	if evaluation.Status.IsFailure() {
+		response.ErrorMessage = evaluation.ErrorMessage
+	}
+
+	return response
+}
+
+// uploadStreamReader adapts an UploadDocument stream to an io.Reader.
+type uploadStreamReader struct {
+	stream grpc.ClientStreamingServer[UploadDocumentRequest, UploadDocumentResponse]
+	buf    []byte
+}
+
+func (r *uploadStreamReader) Read(p []byte) (int, error) {
+	for len(r.buf) == 0 {
+		msg, err := r.stream.Recv()
+		if err != nil {
+			return 0, err
+		}
+		r.buf = msg.Chunk
+	}
+
+	n := copy(p, r.buf)
+	r.buf = r.buf[n:]
+	return n, nil
+}
@@ -0,0 +1,97 @@
+package rpc
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+)
+
+const serviceName = "cvevaluator.v1.CVEvaluator"
+
+// CVEvaluatorServer is the server API for the CVEvaluator service.
+type CVEvaluatorServer interface {
+	UploadDocument(grpc.ClientStreamingServer[UploadDocumentRequest, UploadDocumentResponse]) error
+	CreateEvaluation(context.Context, *CreateEvaluationRequest) (*CreateEvaluationResponse, error)
+	GetResult(context.Context, *GetResultRequest) (*ResultResponse, error)
+	WatchResult(*GetResultRequest, grpc.ServerStreamingServer[ResultResponse]) error
+}
+
+func RegisterCVEvaluatorServer(s grpc.ServiceRegistrar, srv CVEvaluatorServer) {
+	s.RegisterService(&serviceDesc, srv)
+}
+
+var serviceDesc = grpc.ServiceDesc{
+	ServiceName: serviceName,
+	HandlerType: (*CVEvaluatorServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "CreateEvaluation",
+			Handler:    createEvaluationHandler,
+		},
+		{
+			MethodName: "GetResult",
+			Handler:    getResultHandler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "UploadDocument",
+			Handler:       uploadDocumentHandler,
+			ClientStreams: true,
+		},
+		{
+			StreamName:    "WatchResult",
+			Handler:       watchResultHandler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "api/proto/cvevaluator/v1/cv_evaluator.proto",
+}
+
+func uploadDocumentHandler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(CVEvaluatorServer).UploadDocument(&grpc.GenericServerStream[UploadDocumentRequest, UploadDocumentResponse]{ServerStream: stream})
+}
+
+func createEvaluationHandler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateEvaluationRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CVEvaluatorServer).CreateEvaluation(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/" + serviceName + "/CreateEvaluation",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CVEvaluatorServer).CreateEvaluation(ctx, req.(*CreateEvaluationRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func getResultHandler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetResultRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CVEvaluatorServer).GetResult(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/" + serviceName + "/GetResult",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CVEvaluatorServer).GetResult(ctx, req.(*GetResultRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func watchResultHandler(srv interface{}, stream grpc.ServerStream) error {
+	in := new(GetResultRequest)
+	if err := stream.RecvMsg(in); err != nil {
+		return err
+	}
+	return srv.(CVEvaluatorServer).WatchResult(in, &grpc.GenericServerStream[GetResultRequest, ResultResponse]{ServerStream: stream})
+}
@@ -0,0 +1,101 @@
+// Package secrets keeps secret configuration values out of logs and checks
+// that they were actually configured.
+package secrets
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"sync"
+)
+
+// Mask replaces registered secrets in log output.
+const Mask = "[REDACTED]"
+
+// minSecretLength keeps trivially short values from masking ordinary words.
+const minSecretLength = 4
+
+var (
+	mu     sync.RWMutex
+	values [][]byte
+)
+
+// Register marks value as secret so Redact and Writer mask it.
+func Register(value string) {
+	if len(value) < minSecretLength {
+		return
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	for _, v := range values {
+		if string(v) == value {
+			return
+		}
+	}
+	values = append(values, []byte(value))
+}
+
+// Redact masks every registered secret in text.
+func Redact(text string) string {
+	return string(redact([]byte(text)))
+}
+
+func redact(p []byte) []byte {
+	mu.RLock()
+	defer mu.RUnlock()
+	for _, v := range values {
+		if bytes.Contains(p, v) {
+			p = bytes.ReplaceAll(p, v, []byte(Mask))
+		}
+	}
+	return p
+}
+
+type writer struct {
+	w io.Writer
+}
+
+// NewWriter returns a writer that masks registered secrets before writing to
+// w. Each write is masked on its own, which fits line-based loggers.
+func NewWriter(w io.Writer) io.Writer {
+	return &writer{w: w}
+}
+
+func (w *writer) Write(p []byte) (int, error) {
+	if _, err := w.w.Write(redact(p)); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// placeholders are values copied from examples instead of real secrets.
+var placeholders = []string{
+	"changeme", "change-me", "change_me", "placeholder", "secret", "password",
+	"todo", "example", "dummy", "test", "xxx", "none", "null",
+}
+
+// LooksLikePlaceholder reports whether value is a template or sample value
+// such as "your-api-key", "<token>" or "changeme" rather than a real secret.
+func LooksLikePlaceholder(value string) bool {
+	v := strings.ToLower(strings.TrimSpace(value))
+	if v == "" {
+		return false
+	}
+
+	if strings.HasPrefix(v, "<") && strings.HasSuffix(v, ">") ||
+		strings.HasPrefix(v, "${") && strings.HasSuffix(v, "}") {
+		return true
+	}
+	if strings.HasPrefix(v, "your") || strings.Contains(v, "_here") || strings.Contains(v, "-here") {
+		return true
+	}
+	for _, p := range placeholders {
+		if v == p {
+			return true
+		}
+	}
+
+	// The same character repeated, e.g. "xxxxxxxx" or "********"
+	return strings.Count(v, v[:1]) == len(v)
+}
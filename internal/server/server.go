@@ -0,0 +1,599 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/cors"
+	"github.com/gofiber/fiber/v2/middleware/logger"
+	"github.com/gofiber/fiber/v2/middleware/recover"
+	"github.com/gofiber/fiber/v2/middleware/requestid"
+	"golang.org/x/crypto/acme/autocert"
+	"google.golang.org/grpc"
+	"gorm.io/gorm"
+
+	"alfredoptarigan/cv-evaluator/internal/config"
+	"alfredoptarigan/cv-evaluator/internal/graph"
+	"alfredoptarigan/cv-evaluator/internal/handlers"
+	"alfredoptarigan/cv-evaluator/internal/repositories"
+	"alfredoptarigan/cv-evaluator/internal/rpc"
+	"alfredoptarigan/cv-evaluator/internal/secrets"
+	"alfredoptarigan/cv-evaluator/internal/services"
+	"alfredoptarigan/cv-evaluator/internal/tenant"
+	"alfredoptarigan/cv-evaluator/internal/webui"
+)
+
+// Server is the wired API: the HTTP app, the gRPC server and the background
+// jobs behind them. cmd/api serves it; tests can drive App in-process.
+type Server struct {
+	// App serves the HTTP API.
+	App *fiber.App
+	// GRPC is nil when GRPC_ENABLED is off.
+	GRPC *grpc.Server
+	// LLM and VectorStore are the decorated services the evaluations use,
+	// e.g. to ingest reference documents.
+	LLM         services.GeminiService
+	VectorStore services.VectorStore
+
+	cfg               *config.Config
+	worker            services.Worker
+	batcher           services.GeminiBatcher
+	retentionService  services.RetentionService
+	storageReconciler services.StorageReconciler
+	// autocert and redirect are nil unless configured
+	autocert *autocert.Manager
+	redirect *http.Server
+}
+
+// New wires the services, handlers and routes on db. Nothing runs until
+// Start.
+func New(cfg *config.Config, db *gorm.DB) (*Server, error) {
+	// Initializes repositories
+	docRepo := repositories.NewDocumentRepository(db)
+	evalRepo := repositories.NewEvaluationRepository(db)
+	usageRepo := repositories.NewUsageRepository(db)
+	uploadSessionRepo := repositories.NewUploadSessionRepository(db)
+	llmCacheRepo := repositories.NewLLMCacheRepository(db)
+	llmBatchRepo := repositories.NewLLMBatchRepository(db)
+	embeddingCacheRepo := repositories.NewEmbeddingCacheRepository(db)
+	erasureReportRepo := repositories.NewErasureReportRepository(db)
+	log.Println("✅ Repositories initialized successfully")
+
+	// Initialize services
+	storageService := services.NewStorageService(cfg.Storage.UploadPath, cfg.Storage.AllowedFileTypes)
+	if err := storageService.EnsureUploadDir(); err != nil {
+		return nil, fmt.Errorf("failed to create upload directory: %w", err)
+	}
+
+	pdfEngines, err := services.NewPDFEngines(cfg.Storage.PDFEngine, cfg.Storage.PDFFallbackEngine, cfg.Storage.PDFToTextPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize PDF parser: %w", err)
+	}
+	pdfParser := services.NewPDFParserService(services.ParseLimits{
+		MaxPages:      cfg.Storage.MaxPages,
+		MaxTextLength: cfg.Storage.MaxTextLength,
+	}, pdfEngines...)
+	log.Println("✅ Services initialized successfully")
+
+	// Initialize the LLM (Gemini, or the offline mock)
+	geminiService, err := services.NewLLMService(
+		cfg.LLMProvider,
+		cfg.Gemini.APIKey,
+		cfg.Gemini.EmbeddingModel,
+		cfg.Gemini.EmbeddingDimensions,
+		generationParams(cfg.Gemini.Generation, cfg.Gemini.SafetySettings),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize the LLM: %w", err)
+	}
+	// Batch jobs are managed on the client directly, past the decorators
+	batchAPI, _ := geminiService.(services.GeminiBatchAPI)
+	geminiService = services.NewTimeoutGeminiService(
+		geminiService,
+		cfg.Worker.LLMCallTimeout,
+	)
+	geminiService = services.NewBreakerGeminiService(
+		geminiService,
+		cfg.Breaker.MaxFailures,
+		cfg.Breaker.OpenTimeout,
+	)
+	geminiService = services.NewLimitedGeminiService(
+		geminiService,
+		cfg.Gemini.MaxInFlight,
+		cfg.Gemini.MaxQueue,
+	)
+	geminiService = services.NewBatchGeminiService(
+		geminiService,
+		llmBatchRepo,
+		cfg.Gemini.BatchPollInterval,
+	)
+	geminiService = services.NewCachedGeminiService(
+		geminiService,
+		llmCacheRepo,
+		cfg.Gemini.CacheTTL,
+	)
+	if cfg.Gemini.EmbeddingCacheEnabled {
+		geminiService = services.NewCachedEmbeddingService(geminiService, embeddingCacheRepo)
+	}
+	log.Printf("✅ LLM initialized successfully (provider: %s)", cfg.LLMProvider)
+
+	// Initialize vector store
+	vectorStore, err := services.NewVectorStore(services.VectorStoreOptions{
+		Backend:    cfg.VectorStore,
+		Dimensions: cfg.Gemini.EmbeddingDimensions,
+		DB:         db,
+
+		QdrantURL:    cfg.Qdrant.URL,
+		QdrantAPIKey: cfg.Qdrant.APIKey,
+		Collection:   cfg.Qdrant.Collection,
+		QdrantSnapshots: services.QdrantSnapshotOptions{
+			HTTPURL: cfg.Qdrant.HTTPURL,
+			Path:    cfg.Qdrant.SnapshotPath,
+		},
+		QdrantTenantCollections: cfg.Qdrant.TenantCollections,
+
+		WeaviateURL:    cfg.Weaviate.URL,
+		WeaviateAPIKey: cfg.Weaviate.APIKey,
+		WeaviateClass:  cfg.Weaviate.Class,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize vector store: %w", err)
+	}
+
+	if err := config.WaitFor(cfg.VectorStore, cfg.Startup, func() error {
+		err := vectorStore.InitCollection()
+		if errors.Is(err, services.ErrDimensionMismatch) {
+			return config.Permanent(err)
+		}
+		return err
+	}); err != nil {
+		return nil, fmt.Errorf("failed to initialize vector store collection: %w", err)
+	}
+	// Snapshots are admin operations and bypass the breaker
+	snapshotter, _ := vectorStore.(services.VectorSnapshotter)
+	vectorStore = services.NewBreakerVectorStore(
+		vectorStore,
+		cfg.VectorStore,
+		cfg.Breaker.MaxFailures,
+		cfg.Breaker.OpenTimeout,
+	)
+	log.Printf("✅ Vector store (%s) initialized successfully", cfg.VectorStore)
+
+	// Initialize evaluator
+	retrieval := services.RetrievalOptions{
+		Hybrid:     cfg.Retrieval.Hybrid,
+		Candidates: cfg.Retrieval.Candidates,
+		MinScore:   float32(cfg.Retrieval.MinScore),
+		MMRLambda:  cfg.Retrieval.MMRLambda,
+	}
+	if cfg.Retrieval.Rerank {
+		retrieval.Reranker = services.NewLLMReranker(geminiService, cfg.Retrieval.RerankMinScore)
+	}
+
+	summaryStreams := services.NewSummaryStreams()
+	evaluatorService := services.NewEvaluatorService(
+		evalRepo,
+		docRepo,
+		geminiService,
+		vectorStore,
+		pdfParser,
+		services.StageRetries{
+			Parse:       services.RetryPolicy{MaxAttempts: cfg.Worker.ParseMaxAttempts, InitialDelay: cfg.Worker.RetryInitialDelay},
+			LLM:         services.RetryPolicy{MaxAttempts: cfg.Worker.RetryMaxAttempts, InitialDelay: cfg.Worker.RetryInitialDelay},
+			VectorQuery: services.RetryPolicy{MaxAttempts: cfg.Worker.VectorQueryMaxAttempts, InitialDelay: cfg.Worker.RetryInitialDelay},
+		},
+		services.StageTimeouts{
+			Parse:       cfg.Worker.ParseTimeout,
+			VectorQuery: cfg.Worker.VectorQueryTimeout,
+		},
+		services.TokenPricing{
+			InputPerMTok:  cfg.Gemini.InputPricePerMTok,
+			OutputPerMTok: cfg.Gemini.OutputPricePerMTok,
+		},
+		services.TokenBudget{
+			Total:        cfg.Gemini.PromptTokenBudget,
+			ContextShare: cfg.Gemini.PromptContextShare,
+		},
+		retrieval,
+		services.ShadowOptions{
+			Model:       cfg.Gemini.ShadowModel,
+			Temperature: cfg.Gemini.ShadowTemperature,
+			Percent:     cfg.Gemini.ShadowPercent,
+		},
+		services.StageGeneration{
+			Scoring: generationParams(cfg.Gemini.ScoringGeneration, nil),
+			Summary: generationParams(cfg.Gemini.SummaryGeneration, nil),
+		},
+		services.VisionOptions{MaxImages: cfg.Gemini.VisionMaxImages},
+		cfg.Storage.ContactExtraction,
+		summaryStreams,
+	)
+	log.Println("✅ Evaluator service initialized")
+
+	// Initialize worker
+	worker := services.NewWorker(
+		evalRepo,
+		evaluatorService,
+		services.WorkerOptions{
+			Concurrency:       cfg.Worker.Concurrency,
+			MaxConcurrency:    cfg.Worker.MaxConcurrency,
+			AutoscaleInterval: cfg.Worker.AutoscaleInterval,
+			QueueSize:         cfg.Worker.QueueSize,
+			ShutdownGrace:     cfg.Worker.ShutdownGrace,
+			QueueTTL:          cfg.Worker.QueueTTL,
+		},
+	)
+	log.Println("✅ Worker initialized successfully")
+
+	// The batcher runs batch-mode LLM requests
+	batcher := services.NewGeminiBatcher(batchAPI, llmBatchRepo, services.BatchOptions{
+		FlushInterval: cfg.Gemini.BatchFlushInterval,
+		PollInterval:  cfg.Gemini.BatchPollInterval,
+		MaxRequests:   cfg.Gemini.BatchMaxRequests,
+	})
+
+	// The retention job runs when any retention period is configured
+	var retentionService services.RetentionService
+	if cfg.Retention.Period > 0 || len(cfg.Retention.TenantOverrides) > 0 {
+		retentionService = services.NewRetentionService(
+			docRepo,
+			storageService,
+			vectorStore,
+			cfg.Retention.Period,
+			cfg.Retention.TenantOverrides,
+			cfg.Retention.Interval,
+		)
+	}
+
+	storageReconciler := services.NewStorageReconciler(
+		docRepo,
+		storageService,
+		vectorStore,
+		cfg.Storage.ReconcileInterval,
+		cfg.Storage.ReconcileCleanup,
+	)
+
+	// Initialize application services shared by HTTP and gRPC
+	quotaService := services.NewQuotaService(
+		usageRepo,
+		cfg.Quota.MonthlyUploads,
+		cfg.Quota.MonthlyEvaluations,
+	)
+	var urlFetcher services.URLFetcher
+	if cfg.Storage.URLUploadEnabled {
+		urlFetcher = services.NewURLFetcher(cfg.Storage.URLUploadTimeout, cfg.Storage.URLUploadAllowPrivate)
+	}
+	documentService := services.NewDocumentService(
+		docRepo,
+		storageService,
+		quotaService,
+		pdfParser,
+		urlFetcher,
+		cfg.Storage.MaxFileSize,
+		cfg.Storage.ContactExtraction,
+	)
+	uploadSessionService := services.NewUploadSessionService(
+		uploadSessionRepo,
+		storageService,
+		documentService,
+		cfg.Storage.MaxFileSize,
+		cfg.Storage.UploadSessionTTL,
+	)
+	downloadService := services.NewDownloadService(
+		docRepo,
+		storageService,
+		cfg.Storage.DownloadSigningKey,
+		cfg.Storage.DownloadURLTTL,
+	)
+	evaluationService := services.NewEvaluationService(
+		evalRepo,
+		docRepo,
+		quotaService,
+		worker,
+		evaluatorService,
+		cfg.Worker.MaxBacklog,
+		cfg.Gemini.AllowedModels,
+		services.CanaryOptions{
+			Model:   cfg.Gemini.CanaryModel,
+			Percent: cfg.Gemini.CanaryPercent,
+		},
+	)
+
+	privacyService := services.NewPrivacyService(
+		docRepo,
+		evalRepo,
+		uploadSessionRepo,
+		erasureReportRepo,
+		llmCacheRepo,
+		llmBatchRepo,
+		pdfParser,
+		storageService,
+		vectorStore,
+	)
+
+	emailIntakeService := services.NewEmailIntakeService(
+		documentService,
+		evaluationService,
+		cfg.EmailIntake.Address,
+		tenant.FromAPIKey(cfg.EmailIntake.APIKey),
+		cfg.EmailIntake.JobCodes,
+		cfg.EmailIntake.AutoEvaluate,
+	)
+
+	// Initialize Handlers
+	uploadHandler := handlers.NewUploadHandler(
+		documentService,
+		cfg.Storage.MaxFileSize,
+		cfg.Storage.RequireUploadPair,
+	)
+	uploadSessionHandler := handlers.NewUploadSessionHandler(uploadSessionService)
+	documentHandler := handlers.NewDocumentHandler(downloadService, documentService)
+	evaluateHandler := handlers.NewEvaluationHandler(evaluationService)
+
+	resultHandler := handlers.NewResultHandler(evalRepo, docRepo, worker, summaryStreams)
+	graphqlHandler := handlers.NewGraphQLHandler(graph.NewSchema(evalRepo, docRepo))
+	usageHandler := handlers.NewUsageHandler(quotaService)
+	privacyHandler := handlers.NewPrivacyHandler(privacyService)
+	adminHandler := handlers.NewAdminHandler(
+		storageReconciler,
+		evalRepo,
+		snapshotter,
+		evaluatorService,
+		worker,
+		services.NewTrainingExporter(evalRepo, geminiService.ModelName()),
+		services.NewReferenceIngester(
+			// Reference documents come from operators, not candidates
+			services.NewPDFParserService(services.ParseLimits{}, pdfEngines...),
+			services.NewTextChunker(),
+			geminiService,
+			vectorStore,
+			repositories.NewIngestedReferenceRepository(db),
+		),
+		cfg.Storage.MaxFileSize,
+	)
+	emailIntakeHandler := handlers.NewEmailIntakeHandler(emailIntakeService, cfg.EmailIntake.Token)
+	log.Println("✅ Handlers initialized")
+
+	// Limits per route: small JSON bodies and short timeouts by default,
+	// more time for uploads, whose size the handlers bound per file
+	limiter := handlers.NewRouteLimiter(handlers.RouteLimits{
+		BodyLimit:    cfg.Server.BodyLimit,
+		ReadTimeout:  cfg.Server.RequestTimeout,
+		WriteTimeout: cfg.Server.RequestTimeout,
+	})
+	limiter.Set(fiber.MethodPost, "/api/:version/upload", handlers.RouteLimits{
+		ReadTimeout: cfg.Server.UploadTimeout,
+	})
+	limiter.Set(fiber.MethodPatch, "/api/:version/uploads/:id", handlers.RouteLimits{
+		BodyLimit:   int(cfg.Storage.MaxFileSize),
+		ReadTimeout: cfg.Server.UploadTimeout,
+	})
+	// Reference documents are embedded before the response is written
+	limiter.Set(fiber.MethodPost, "/api/v1/admin/references", handlers.RouteLimits{
+		ReadTimeout:  cfg.Server.UploadTimeout,
+		WriteTimeout: cfg.Server.UploadTimeout,
+	})
+	// Room for a CV, a project report and a few other attachments; they
+	// are parsed before the response is written
+	limiter.Set(fiber.MethodPost, "/api/v1/intake/email", handlers.RouteLimits{
+		BodyLimit:    int(4*cfg.Storage.MaxFileSize) + 64*1024,
+		ReadTimeout:  cfg.Server.UploadTimeout,
+		WriteTimeout: cfg.Server.UploadTimeout,
+	})
+	limiter.Set(fiber.MethodGet, "/api/:version/result/:id/stream", handlers.RouteLimits{
+		BodyLimit:    cfg.Server.BodyLimit,
+		WriteTimeout: handlers.StreamWriteTimeout,
+	})
+
+	// Create Fiber app
+	app := fiber.New(fiber.Config{
+		AppName:      "AI CV Evaluator API",
+		ReadTimeout:  cfg.Server.RequestTimeout,
+		WriteTimeout: cfg.Server.RequestTimeout,
+		BodyLimit:    cfg.Server.BodyLimit,
+		// Uploads are read from the body stream by the handler
+		StreamRequestBody:            true,
+		DisablePreParseMultipartForm: true,
+		ErrorHandler:                 handlers.ErrorHandler,
+	})
+	app.Server().HeaderReceived = limiter.HeaderReceived
+
+	// Middleware
+	app.Use(recover.New())
+	app.Use(requestid.New())
+	app.Use(limiter.Handler)
+	app.Use(logger.New(logger.Config{
+		Output:     secrets.NewWriter(os.Stdout),
+		Format:     "[${time}] ${locals:requestid} ${status} - ${latency} ${method} ${path}\n",
+		TimeFormat: "2006-01-02 15:04:05",
+	}))
+
+	app.Use(cors.New(cors.Config{
+		AllowOrigins:  "*",
+		AllowMethods:  "GET,HEAD,POST,PUT,PATCH,DELETE,OPTIONS",
+		AllowHeaders:  "Origin, Content-Type, Accept, Authorization, X-API-Key, X-Admin-Token, Upload-Offset, If-None-Match, X-Request-ID",
+		ExposeHeaders: "Location, Upload-Offset, Upload-Length, ETag, X-Request-ID",
+	}))
+
+	// Routes. Each API version serves the same endpoints with the same
+	// handlers, which shape their responses for the version.
+	routes := func(api fiber.Router) {
+		// Health check
+		api.Get("/health", func(c *fiber.Ctx) error {
+			return c.JSON(fiber.Map{
+				"status": "healthy",
+				"time":   time.Now(),
+			})
+		})
+
+		// API endpoints
+		api.Post("/upload", uploadHandler.HandleUpload)
+		api.Post("/uploads", uploadSessionHandler.HandleCreate)
+		api.Get("/uploads/:id", uploadSessionHandler.HandleGet)
+		api.Head("/uploads/:id", uploadSessionHandler.HandleGet)
+		api.Patch("/uploads/:id", uploadSessionHandler.HandleAppend)
+		api.Get("/documents/:id/download", documentHandler.HandleDownload)
+		api.Get("/documents/:id/preview", documentHandler.HandlePreview)
+		api.Post("/documents/:id/download-url", documentHandler.HandleSignDownload)
+		api.Post("/evaluate", evaluateHandler.HandleEvaluate)
+		api.Get("/result/:id", resultHandler.HandleGetResult)
+		api.Get("/result/:id/events", resultHandler.HandleGetEvents)
+		api.Get("/result/:id/stream", resultHandler.HandleStream)
+		api.Get("/results", resultHandler.HandleListResults)
+		api.Post("/results/batch", resultHandler.HandleBatchResults)
+		api.Get("/usage", usageHandler.HandleGetUsage)
+		api.Post("/privacy/erase", privacyHandler.HandleErase)
+		api.Get("/privacy/erasures/:id", privacyHandler.HandleGetErasure)
+	}
+
+	api := app.Group("/api/v1", handlers.IdentifyTenant, handlers.AttachRequestID, handlers.UseAPIVersion(handlers.APIv1))
+	routes(api)
+	routes(app.Group("/api/v2", handlers.IdentifyTenant, handlers.AttachRequestID, handlers.UseAPIVersion(handlers.APIv2)))
+
+	// GraphQL, unversioned as clients pick the fields they need
+	app.Post("/graphql", handlers.IdentifyTenant, handlers.AttachRequestID, graphqlHandler.HandleQuery)
+
+	// Admin endpoints, v1 only and only registered when ADMIN_TOKEN is set
+	if cfg.Admin.Token != "" {
+		admin := api.Group("/admin", handlers.RequireAdminToken(cfg.Admin.Token))
+		admin.Post("/storage/reconcile", adminHandler.HandleReconcileStorage)
+		admin.Get("/stats", adminHandler.HandleStats)
+		admin.Get("/retrieval/debug", adminHandler.HandleRetrievalDebug)
+		admin.Get("/evaluations/:id/shadow", adminHandler.HandleShadowResults)
+		admin.Post("/evaluations/:id/replay", adminHandler.HandleReplay)
+		admin.Put("/evaluations/:id/correction", adminHandler.HandleSaveCorrection)
+		admin.Get("/exports/training", adminHandler.HandleExportTraining)
+		admin.Get("/references", adminHandler.HandleListReferences)
+		admin.Post("/references", adminHandler.HandleIngestReference)
+		admin.Delete("/references/:id", adminHandler.HandleDeleteReference)
+		admin.Get("/workers", adminHandler.HandleWorkers)
+		admin.Post("/workers/pause", adminHandler.HandlePauseWorkers)
+		admin.Post("/workers/resume", adminHandler.HandleResumeWorkers)
+		admin.Put("/workers/concurrency", adminHandler.HandleSetConcurrency)
+		if snapshotter != nil {
+			admin.Get("/vectors/snapshots", adminHandler.HandleListSnapshots)
+			admin.Post("/vectors/snapshots", adminHandler.HandleCreateSnapshot)
+			admin.Post("/vectors/snapshots/:name/restore", adminHandler.HandleRestoreSnapshot)
+			admin.Get("/vectors/export", adminHandler.HandleExportVectors)
+		}
+	}
+
+	// Inbound email webhook, v1 only
+	if cfg.EmailIntake.Enabled {
+		api.Post("/intake/email", emailIntakeHandler.HandleInbound)
+	}
+
+	// Web UI
+	if cfg.Admin.UIEnabled {
+		app.Use("/admin", webui.Handler())
+	}
+
+	// Operational endpoints
+	app.Get("/metrics", handlers.HandleMetrics)
+	app.Get("/readyz", handlers.HandleReady)
+
+	// Root route
+	app.Get("/", func(c *fiber.Ctx) error {
+		return c.JSON(fiber.Map{
+			"message": "AI CV Evaluator API",
+			"version": "1.0.0",
+			"endpoints": []string{
+				"POST /api/v1/upload",
+				"POST /api/v1/uploads",
+				"PATCH /api/v1/uploads/:id",
+				"GET /api/v1/documents/:id/download",
+				"GET /api/v1/documents/:id/preview",
+				"POST /api/v1/evaluate",
+				"GET /api/v1/result/:id",
+				"GET /api/v1/result/:id/events",
+				"GET /api/v1/result/:id/stream",
+				"GET /api/v1/results",
+				"POST /api/v1/results/batch",
+				"GET /api/v1/usage",
+				"POST /graphql",
+			},
+			// /api/v2 has the same endpoints, with results broken down
+			// into sub-scores, stages and citations
+			"versions": []string{"v1", "v2"},
+		})
+	})
+
+	// gRPC server, served by the caller
+	var grpcServer *grpc.Server
+	if cfg.GRPC.Enabled {
+		grpcServer = grpc.NewServer(
+			grpc.UnaryInterceptor(rpc.TenantUnaryInterceptor),
+			grpc.StreamInterceptor(rpc.TenantStreamInterceptor),
+		)
+		rpc.RegisterCVEvaluatorServer(grpcServer, rpc.NewServer(
+			documentService,
+			evaluationService,
+			evalRepo,
+		))
+	}
+
+	manager := newAutocert(cfg.Server)
+	return &Server{
+		App:               app,
+		GRPC:              grpcServer,
+		LLM:               geminiService,
+		VectorStore:       vectorStore,
+		cfg:               cfg,
+		worker:            worker,
+		batcher:           batcher,
+		retentionService:  retentionService,
+		storageReconciler: storageReconciler,
+		autocert:          manager,
+		redirect:          newRedirect(cfg.Server, manager),
+	}, nil
+}
+
+// Start starts the worker and the background jobs.
+func (s *Server) Start(ctx context.Context) {
+	s.worker.Start(ctx)
+	log.Println("✅ Worker started successfully")
+
+	s.batcher.Start(ctx)
+	if s.retentionService != nil {
+		s.retentionService.Start(ctx)
+	}
+	if s.cfg.Storage.ReconcileInterval > 0 {
+		s.storageReconciler.Start(ctx)
+	}
+	s.startRedirect()
+}
+
+// Stop stops the worker, the background jobs and both servers.
+func (s *Server) Stop() {
+	s.worker.Stop()
+	s.batcher.Stop()
+	if s.retentionService != nil {
+		s.retentionService.Stop()
+	}
+	if s.cfg.Storage.ReconcileInterval > 0 {
+		s.storageReconciler.Stop()
+	}
+	if s.GRPC != nil {
+		s.GRPC.GracefulStop()
+	}
+	s.stopRedirect()
+	if err := s.App.Shutdown(); err != nil {
+		log.Printf("❌ Server forced to shutdown: %v", err)
+	}
+}
+
+// generationParams converts generation settings from the config.
+func generationParams(gen config.GenerationConfig, safety map[string]string) services.GenerationParams {
+	return services.GenerationParams{
+		MaxOutputTokens: int32(gen.MaxOutputTokens),
+		TopP:            gen.TopP,
+		TopK:            gen.TopK,
+		SafetySettings:  safety,
+	}
+}
@@ -0,0 +1,109 @@
+package server
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"log"
+	"net"
+	"net/http"
+	"time"
+
+	"golang.org/x/crypto/acme"
+	"golang.org/x/crypto/acme/autocert"
+
+	"alfredoptarigan/cv-evaluator/internal/config"
+)
+
+// redirectShutdownTimeout bounds how long Stop waits for redirects in
+// flight.
+const redirectShutdownTimeout = 5 * time.Second
+
+// newAutocert returns the Let's Encrypt manager, or nil when certificates
+// aren't obtained automatically.
+func newAutocert(cfg config.ServerConfig) *autocert.Manager {
+	if len(cfg.TLSAutocertDomains) == 0 {
+		return nil
+	}
+
+	return &autocert.Manager{
+		Prompt:     autocert.AcceptTOS,
+		HostPolicy: autocert.HostWhitelist(cfg.TLSAutocertDomains...),
+		Cache:      autocert.DirCache(cfg.TLSAutocertCacheDir),
+	}
+}
+
+// newRedirect returns the plain HTTP server that sends clients to HTTPS,
+// or nil when TLS_REDIRECT_PORT is not set. With autocert it also answers
+// the ACME HTTP challenges.
+func newRedirect(cfg config.ServerConfig, manager *autocert.Manager) *http.Server {
+	if cfg.TLSRedirectPort == "" {
+		return nil
+	}
+
+	var handler http.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host := r.Host
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		if cfg.Port != "443" {
+			host = net.JoinHostPort(host, cfg.Port)
+		}
+		http.Redirect(w, r, "https://"+host+r.URL.RequestURI(), http.StatusMovedPermanently)
+	})
+	if manager != nil {
+		handler = manager.HTTPHandler(handler)
+	}
+
+	return &http.Server{
+		Addr:              ":" + cfg.TLSRedirectPort,
+		Handler:           handler,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+}
+
+// Listen serves the HTTP API on addr, over TLS when configured, until the
+// server is stopped.
+func (s *Server) Listen(addr string) error {
+	switch {
+	case s.cfg.Server.TLSCertFile != "":
+		return s.App.ListenTLS(addr, s.cfg.Server.TLSCertFile, s.cfg.Server.TLSKeyFile)
+	case s.autocert != nil:
+		tlsConfig := s.autocert.TLSConfig()
+		// fasthttp only speaks HTTP/1.1; the ACME protocol stays for
+		// TLS-ALPN challenges
+		tlsConfig.NextProtos = []string{"http/1.1", acme.ALPNProto}
+		ln, err := tls.Listen("tcp", addr, tlsConfig)
+		if err != nil {
+			return err
+		}
+		return s.App.Listener(ln)
+	default:
+		return s.App.Listen(addr)
+	}
+}
+
+func (s *Server) startRedirect() {
+	if s.redirect == nil {
+		return
+	}
+
+	go func() {
+		log.Printf("↪️  Redirecting HTTP on %s to HTTPS\n", s.redirect.Addr)
+		if err := s.redirect.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Printf("❌ HTTPS redirect stopped: %v", err)
+		}
+	}()
+}
+
+func (s *Server) stopRedirect() {
+	if s.redirect == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), redirectShutdownTimeout)
+	defer cancel()
+	if err := s.redirect.Shutdown(ctx); err != nil {
+		log.Printf("⚠️  HTTPS redirect forced to shut down: %v", err)
+	}
+}
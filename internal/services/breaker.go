@@ -0,0 +1,100 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/sony/gobreaker"
+
+	"alfredoptarigan/cv-evaluator/internal/apperror"
+	"alfredoptarigan/cv-evaluator/internal/metrics"
+)
+
+// ErrCircuitOpen is wrapped by errors returned while a dependency's circuit
+// breaker is open. Evaluations that hit it are parked instead of failed.
+var ErrCircuitOpen = errors.New("circuit breaker open")
+
+var (
+	breakersMu sync.Mutex
+	breakers   = map[string]*gobreaker.CircuitBreaker{}
+)
+
+// newBreaker creates a breaker that opens after maxFailures consecutive
+// failures and probes again after openTimeout. Its state is exported as a
+// metric and reported by BreakerStates.
+func newBreaker(name string, maxFailures uint32, openTimeout time.Duration) *gobreaker.CircuitBreaker {
+	cb := gobreaker.NewCircuitBreaker(gobreaker.Settings{
+		Name:    name,
+		Timeout: openTimeout,
+		ReadyToTrip: func(counts gobreaker.Counts) bool {
+			return counts.ConsecutiveFailures >= maxFailures
+		},
+		IsSuccessful: func(err error) bool {
+			// A caller giving up says nothing about the dependency
+			return err == nil || errors.Is(err, context.Canceled)
+		},
+		OnStateChange: func(name string, from, to gobreaker.State) {
+			log.Printf("🔌 Circuit breaker %s: %s -> %s\n", name, from, to)
+		},
+	})
+
+	metrics.GaugeFunc(
+		"cv_evaluator_circuit_breaker_state",
+		"Circuit breaker state (0 = closed, 1 = half-open, 2 = open).",
+		map[string]string{"name": name},
+		func() float64 { return float64(cb.State()) },
+	)
+
+	breakersMu.Lock()
+	breakers[name] = cb
+	breakersMu.Unlock()
+
+	return cb
+}
+
+// BreakerStates returns the current state of every circuit breaker by name.
+func BreakerStates() map[string]string {
+	breakersMu.Lock()
+	defer breakersMu.Unlock()
+
+	states := make(map[string]string, len(breakers))
+	for name, cb := range breakers {
+		states[name] = cb.State().String()
+	}
+	return states
+}
+
+// OpenBreakers returns the names of breakers that are currently open.
+func OpenBreakers() []string {
+	var open []string
+	for name, state := range BreakerStates() {
+		if state == gobreaker.StateOpen.String() {
+			open = append(open, name)
+		}
+	}
+	sort.Strings(open)
+	return open
+}
+
+// callBreaker runs fn through cb, turning rejections into an apperror with
+// the given code that wraps ErrCircuitOpen.
+func callBreaker[T any](cb *gobreaker.CircuitBreaker, code apperror.Code, fn func() (T, error)) (T, error) {
+	result, err := cb.Execute(func() (interface{}, error) {
+		return fn()
+	})
+	if errors.Is(err, gobreaker.ErrOpenState) || errors.Is(err, gobreaker.ErrTooManyRequests) {
+		var zero T
+		return zero, apperror.Wrap(ErrCircuitOpen, http.StatusServiceUnavailable, code, cb.Name()+" is temporarily unavailable")
+	}
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+
+	return result.(T), nil
+}
@@ -0,0 +1,362 @@
+package services
+
+import (
+	"strings"
+	"unicode"
+)
+
+// embeddingTokenLimit is the input limit of the embedding model.
+const embeddingTokenLimit = 2048
+
+const truncationMarker = "\n[... truncated ...]"
+
+// cvPrioritySections are kept first when a CV has to be cut down.
+var cvPrioritySections = []string{"skill", "experience", "employment", "work history", "project"}
+
+// EstimateTokens approximates how many tokens a model's tokenizer produces
+// for text: words count as one token per four characters (at least one) and
+// every punctuation or symbol character as one token.
+func EstimateTokens(text string) int {
+	tokens := 0
+	wordLen := 0
+
+	flush := func() {
+		if wordLen > 0 {
+			tokens += (wordLen + 3) / 4
+			wordLen = 0
+		}
+	}
+
+	for _, r := range text {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			wordLen++
+		case unicode.IsSpace(r):
+			flush()
+		default:
+			flush()
+			tokens++
+		}
+	}
+	flush()
+
+	return tokens
+}
+
+// TokenBudget splits a prompt's token allowance between the candidate
+// document and the retrieved context once the instructions are accounted for.
+type TokenBudget struct {
+	// Total is the maximum prompt size in tokens.
+	Total int
+	// ContextShare is the fraction of the remaining tokens reserved for
+	// retrieved context when both parts don't fit.
+	ContextShare float64
+}
+
+// DocumentAllowance returns how many tokens the document may use next to
+// instructions and context; 0 means there is no limit.
+func (b TokenBudget) DocumentAllowance(instructions, context string) int {
+	if b.Total <= 0 {
+		return 0
+	}
+
+	available := b.Total - EstimateTokens(instructions)
+	ctxBudget := min(EstimateTokens(context), int(float64(available)*b.ContextShare))
+	return max(available-ctxBudget, 1)
+}
+
+// SplitAllowance divides allowance between documents of the given sizes in
+// tokens. A document smaller than its equal share keeps its size and leaves
+// the rest to the others; 0 means there is no limit.
+func SplitAllowance(sizes []int, allowance int) []int {
+	shares := make([]int, len(sizes))
+	if allowance <= 0 {
+		return shares
+	}
+
+	remaining := allowance
+	open := len(sizes)
+	for open > 0 {
+		share := max(remaining/open, 1)
+		settled := false
+		for i, size := range sizes {
+			if shares[i] == 0 && size <= share {
+				shares[i] = max(size, 1)
+				remaining -= shares[i]
+				open--
+				settled = true
+			}
+		}
+		if !settled {
+			for i := range shares {
+				if shares[i] == 0 {
+					shares[i] = share
+				}
+			}
+			break
+		}
+	}
+
+	return shares
+}
+
+// SplitByTokens groups text into parts of at most maxTokens, breaking
+// between sections where possible and between sentences otherwise.
+func SplitByTokens(text string, maxTokens int) []string {
+	var parts []string
+	var current strings.Builder
+	currentTokens := 0
+
+	flush := func() {
+		if current.Len() > 0 {
+			parts = append(parts, current.String())
+			current.Reset()
+			currentTokens = 0
+		}
+	}
+
+	for _, section := range splitSections(text) {
+		rest := section.text
+		for rest != "" {
+			tokens := EstimateTokens(rest)
+			if currentTokens+tokens <= maxTokens {
+				if current.Len() > 0 {
+					current.WriteString("\n\n")
+				}
+				current.WriteString(rest)
+				currentTokens += tokens
+				break
+			}
+
+			if currentTokens > 0 {
+				flush()
+				continue
+			}
+
+			// A single section larger than a part: split it
+			head := cutAtSentence(rest, maxTokens)
+			if head == "" {
+				head = rest[:min(len(rest), maxTokens*4)]
+			}
+			parts = append(parts, head)
+			rest = strings.TrimSpace(rest[len(head):])
+		}
+	}
+	flush()
+
+	return parts
+}
+
+// Fit returns document and context trimmed so that together with
+// instructions they fit the budget. Space one part doesn't use goes to the
+// other; the document keeps its priority sections first.
+func (b TokenBudget) Fit(instructions, document, context string, prioritySections []string) (string, string) {
+	if b.Total <= 0 {
+		return document, context
+	}
+
+	available := b.Total - EstimateTokens(instructions)
+	if available <= 0 {
+		return "", ""
+	}
+
+	docTokens := EstimateTokens(document)
+	ctxTokens := EstimateTokens(context)
+	if docTokens+ctxTokens <= available {
+		return document, context
+	}
+
+	ctxBudget := min(ctxTokens, int(float64(available)*b.ContextShare))
+	docBudget := available - ctxBudget
+	if docTokens < docBudget {
+		ctxBudget += docBudget - docTokens
+		docBudget = docTokens
+	}
+
+	return TruncateToTokens(document, docBudget, prioritySections), TruncateToTokens(context, ctxBudget, nil)
+}
+
+// TruncateToTokens shortens text to about maxTokens. Sections whose header
+// contains one of prioritySections are kept before the others, the original
+// order is preserved in the output, and cuts fall on sentence boundaries.
+func TruncateToTokens(text string, maxTokens int, prioritySections []string) string {
+	if EstimateTokens(text) <= maxTokens {
+		return text
+	}
+	if maxTokens <= 0 {
+		return ""
+	}
+
+	sections := splitSections(text)
+
+	// Pick sections by priority until the budget is spent
+	order := make([]int, 0, len(sections))
+	for i, s := range sections {
+		if s.matches(prioritySections) {
+			order = append(order, i)
+		}
+	}
+	for i, s := range sections {
+		if !s.matches(prioritySections) {
+			order = append(order, i)
+		}
+	}
+
+	// Keep every section that fits whole, then spend what's left on the
+	// first (highest priority) one that didn't
+	remaining := maxTokens - EstimateTokens(truncationMarker)
+	kept := make([]string, len(sections))
+	partial := -1
+	for _, i := range order {
+		tokens := EstimateTokens(sections[i].text)
+		if tokens <= remaining {
+			kept[i] = sections[i].text
+			remaining -= tokens
+		} else if partial < 0 {
+			partial = i
+		}
+	}
+	if partial >= 0 && remaining > 0 {
+		kept[partial] = cutAtSentence(sections[partial].text, remaining)
+	}
+
+	var b strings.Builder
+	for _, k := range kept {
+		if k == "" {
+			continue
+		}
+		if b.Len() > 0 {
+			b.WriteString("\n\n")
+		}
+		b.WriteString(k)
+	}
+	b.WriteString(truncationMarker)
+
+	return b.String()
+}
+
+type textSection struct {
+	header string
+	text   string
+}
+
+func (s textSection) matches(keywords []string) bool {
+	header := strings.ToLower(s.header)
+	for _, k := range keywords {
+		if header != "" && strings.Contains(header, k) {
+			return true
+		}
+	}
+	return false
+}
+
+// splitSections splits text at lines that look like section headers.
+func splitSections(text string) []textSection {
+	var sections []textSection
+	var current textSection
+	var body strings.Builder
+
+	flush := func() {
+		current.text = strings.TrimSpace(body.String())
+		if current.text != "" {
+			sections = append(sections, current)
+		}
+		body.Reset()
+	}
+
+	for _, line := range strings.Split(text, "\n") {
+		if isSectionHeader(line) {
+			flush()
+			current = textSection{header: strings.TrimSpace(line)}
+		}
+		body.WriteString(line)
+		body.WriteString("\n")
+	}
+	flush()
+
+	return sections
+}
+
+// isSectionHeader reports whether line is a short standalone title such as
+// "EXPERIENCE", "Skills:" or "Work History".
+func isSectionHeader(line string) bool {
+	line = strings.TrimSpace(line)
+	if line == "" || len(line) > 40 || len(strings.Fields(line)) > 4 {
+		return false
+	}
+
+	title := strings.TrimSuffix(line, ":")
+	if strings.ContainsAny(title, ".,;!?") {
+		return false
+	}
+
+	switch {
+	case strings.IndexFunc(title, unicode.IsLetter) < 0:
+		return false
+	case strings.ToUpper(title) == title:
+		return true
+	case strings.HasSuffix(line, ":"):
+		return true
+	default:
+		return unicode.IsUpper([]rune(title)[0]) && knownSectionHeader(title)
+	}
+}
+
+func knownSectionHeader(title string) bool {
+	title = strings.ToLower(title)
+	for _, h := range knownSectionHeaders {
+		if strings.Contains(title, h) {
+			return true
+		}
+	}
+	return false
+}
+
+var knownSectionHeaders = []string{
+	"summary", "profile", "skill", "experience", "employment", "work history",
+	"education", "project", "certification", "award", "achievement",
+	"publication", "language", "interest", "reference",
+}
+
+// cutAtSentence returns the longest prefix of text that ends a sentence and
+// fits maxTokens, falling back to a word boundary.
+func cutAtSentence(text string, maxTokens int) string {
+	lastSentence, lastWord := 0, 0
+	tokens := 0
+	wordStart := -1
+
+	for i, r := range text {
+		isWord := unicode.IsLetter(r) || unicode.IsDigit(r)
+		if isWord {
+			if wordStart < 0 {
+				wordStart = i
+			}
+			continue
+		}
+
+		if wordStart >= 0 {
+			tokens += (i - wordStart + 3) / 4
+			wordStart = -1
+			if tokens > maxTokens {
+				break
+			}
+			lastWord = i
+		}
+
+		if !unicode.IsSpace(r) {
+			tokens++
+			if tokens > maxTokens {
+				break
+			}
+		}
+		if r == '.' || r == '!' || r == '?' || r == '\n' {
+			lastSentence = i + 1
+			lastWord = i + 1
+		}
+	}
+
+	if lastSentence > 0 {
+		return strings.TrimSpace(text[:lastSentence])
+	}
+	return strings.TrimSpace(text[:lastWord])
+}
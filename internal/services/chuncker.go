@@ -7,6 +7,17 @@ import (
 
 type TextChuncker interface {
 	ChunkText(text string, maxChunkSize int, overlap int) []string
+	ChunkCV(text string, maxChunkSize int, overlap int) []TextChunk
+}
+
+// TextChunk is a chunk together with the document section it came from.
+// Section is empty for text before the first recognised header.
+type TextChunk struct {
+	Text    string
+	Section string
+	// Page is the 1-based page the chunk starts on, or 0 when unknown. See
+	// AssignPages.
+	Page int
 }
 
 type textChunker struct{}
@@ -105,6 +116,59 @@ func (tc *textChunker) ChunkText(text string, maxChunkSize int, overlap int) []s
 	return chunks
 }
 
+// ChunkCV implements TextChuncker. It splits text at section headers
+// (Experience, Education, Skills, Projects, ...) and chunks each section on
+// its own, so no chunk mixes two sections. Chunks after the first in a
+// section are prefixed with the section header to keep them self-describing.
+func (tc *textChunker) ChunkCV(text string, maxChunkSize int, overlap int) []TextChunk {
+	var chunks []TextChunk
+	for _, section := range splitSections(text) {
+		name := sectionName(section.header)
+		size := maxChunkSize
+		if section.header != "" && maxChunkSize > 2*len(section.header) {
+			size -= len(section.header) + 1
+		}
+		for i, chunk := range tc.ChunkText(section.text, size, overlap) {
+			if i > 0 && section.header != "" {
+				chunk = section.header + "\n" + chunk
+			}
+			chunks = append(chunks, TextChunk{Text: chunk, Section: name})
+		}
+	}
+
+	return chunks
+}
+
+// sectionName maps a header line to a canonical section name, e.g.
+// "WORK HISTORY:" to "experience". Unknown headers are lowercased as is.
+func sectionName(header string) string {
+	title := strings.ToLower(strings.TrimSuffix(strings.TrimSpace(header), ":"))
+	if title == "" {
+		return ""
+	}
+
+	for _, s := range canonicalSections {
+		for _, k := range s.keywords {
+			if strings.Contains(title, k) {
+				return s.name
+			}
+		}
+	}
+	return title
+}
+
+var canonicalSections = []struct {
+	name     string
+	keywords []string
+}{
+	{"experience", []string{"experience", "employment", "work history"}},
+	{"education", []string{"education"}},
+	{"skills", []string{"skill"}},
+	{"projects", []string{"project"}},
+	{"summary", []string{"summary", "profile"}},
+	{"certifications", []string{"certification"}},
+}
+
 func splitIntoSentences(text string) []string {
 	// Simple sentence splitter
 	sentences := strings.FieldsFunc(text, func(r rune) bool {
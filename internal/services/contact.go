@@ -0,0 +1,41 @@
+package services
+
+import (
+	"alfredoptarigan/cv-evaluator/internal/models"
+	"alfredoptarigan/cv-evaluator/internal/pii"
+)
+
+// CONTACT_EXTRACTION modes: what is stored of the contact details found in
+// a CV.
+const (
+	// ContactExtractionOff stores nothing.
+	ContactExtractionOff = "off"
+	// ContactExtractionHashed stores hashes of the e-mail address and phone
+	// number, to match applicants without keeping their details.
+	ContactExtractionHashed = "hashed"
+	// ContactExtractionPlain stores the name, e-mail address and phone
+	// number as found.
+	ContactExtractionPlain = "plain"
+)
+
+// extractCandidate sets doc's contact details from its parsed text, per
+// mode. Only CVs have them.
+func extractCandidate(mode string, doc *models.Document, text string) {
+	if doc.FileType != "cv" || text == "" {
+		return
+	}
+
+	var contact pii.Contact
+	switch mode {
+	case ContactExtractionPlain:
+		contact = pii.ExtractContact(pageMarker.ReplaceAllString(text, ""))
+	case ContactExtractionHashed:
+		contact = pii.ExtractContact(pageMarker.ReplaceAllString(text, "")).Hashed()
+	default:
+		return
+	}
+
+	doc.CandidateName = contact.Name
+	doc.CandidateEmail = contact.Email
+	doc.CandidatePhone = contact.Phone
+}
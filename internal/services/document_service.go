@@ -0,0 +1,296 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+
+	"alfredoptarigan/cv-evaluator/internal/apperror"
+	"alfredoptarigan/cv-evaluator/internal/models"
+	"alfredoptarigan/cv-evaluator/internal/repositories"
+	"alfredoptarigan/cv-evaluator/internal/tenant"
+)
+
+// DocumentService stores uploaded documents and records them in the
+// database. It is shared by the HTTP and gRPC transports.
+type DocumentService interface {
+	// Upload stores a document. password opens a protected PDF, which is
+	// rejected with ErrDocumentEncrypted without it; it is only used to
+	// extract the text and isn't stored.
+	Upload(ctx context.Context, src io.Reader, originalName string, fileType string, password string) (*models.Document, error)
+	// UploadURL downloads the document at rawURL and stores it like Upload.
+	UploadURL(ctx context.Context, rawURL string, fileType string, password string) (*models.Document, error)
+	// Pair records a CV and project report uploaded together.
+	Pair(ctx context.Context, cv *models.Document, projectReport *models.Document) (*models.DocumentPair, error)
+	// Preview returns the first length characters of the tenant's document
+	// id and what was detected in its text.
+	Preview(ctx context.Context, id uuid.UUID, length int) (*models.DocumentPreview, error)
+}
+
+// ErrSameDocumentPair is returned when both files of a pair have the same
+// content, so deduplication resolved them to one document.
+var ErrSameDocumentPair = apperror.New(http.StatusBadRequest, apperror.CodeValidationFailed, "cv and project_report are the same file")
+
+// ErrURLUploadDisabled is returned by UploadURL when URL_UPLOAD_ENABLED is
+// off.
+var ErrURLUploadDisabled = apperror.New(http.StatusBadRequest, apperror.CodeValidationFailed, "uploads by URL are disabled")
+
+// ErrDocumentEncrypted is returned by Upload for a password-protected PDF
+// uploaded without its password.
+var ErrDocumentEncrypted = apperror.New(http.StatusBadRequest, apperror.CodeDocumentEncrypted, "the PDF is password-protected; send its password to upload it")
+
+// errDocumentWrongPassword is returned by Upload when the password doesn't
+// open the PDF.
+var errDocumentWrongPassword = apperror.New(http.StatusBadRequest, apperror.CodeDocumentEncrypted, "the password does not open the PDF")
+
+type documentService struct {
+	docRepo        repositories.DocumentRepository
+	storageService StorageService
+	quotaService   QuotaService
+	pdfParser      PDFParserService
+	// fetcher downloads documents for UploadURL; nil disables it.
+	fetcher     URLFetcher
+	maxFileSize int64
+	// contactExtraction is the CONTACT_EXTRACTION mode.
+	contactExtraction string
+}
+
+func NewDocumentService(
+	docRepo repositories.DocumentRepository,
+	storageService StorageService,
+	quotaService QuotaService,
+	pdfParser PDFParserService,
+	fetcher URLFetcher,
+	maxFileSize int64,
+	contactExtraction string,
+) DocumentService {
+	return &documentService{
+		docRepo:        docRepo,
+		storageService: storageService,
+		quotaService:   quotaService,
+		pdfParser:      pdfParser,
+		fetcher:        fetcher,
+		maxFileSize:    maxFileSize,
+
+		contactExtraction: contactExtraction,
+	}
+}
+
+// Upload implements DocumentService.
+func (s *documentService) Upload(ctx context.Context, src io.Reader, originalName string, fileType string, password string) (*models.Document, error) {
+	period, err := s.quotaService.Reserve(ctx, models.UsageUploads)
+	if err != nil {
+		return nil, err
+	}
+	// Hand the upload back to the quota unless a document is returned
+	reserved := true
+	defer func() {
+		if reserved {
+			s.releaseUpload(ctx, period)
+		}
+	}()
+
+	// Save file, hashing the content as it is written
+	hasher := sha256.New()
+	filename, filePath, err := s.storageService.SaveReader(io.TeeReader(src, hasher), originalName, fileType, s.maxFileSize)
+	if err != nil {
+		return nil, err
+	}
+	contentHash := hex.EncodeToString(hasher.Sum(nil))
+	tenantID := tenant.FromContext(ctx)
+
+	// Reuse the tenant's existing copy of identical content
+	existing, err := s.docRepo.FindByContentHash(tenantID, contentHash)
+	if err != nil {
+		s.storageService.DeleteFile(filename)
+		return nil, err
+	}
+	if existing != nil {
+		s.storageService.DeleteFile(filename)
+		// A document is evaluated as the type it was stored as, so the
+		// same content can't stand in for the other type
+		if existing.FileType != fileType {
+			return nil, apperror.New(http.StatusBadRequest, apperror.CodeValidationFailed,
+				fmt.Sprintf("%s matches an earlier upload of type %s", fileType, existing.FileType))
+		}
+		log.Printf("♻️  Reusing document %s for duplicate upload %s\n", existing.ID, originalName)
+		reserved = false
+		return existing, nil
+	}
+
+	// Parse once at upload time so evaluations and retries can reuse the text.
+	// A failure here isn't fatal; the evaluator parses the file itself then.
+	// Protected PDFs are the exception, as the password isn't kept for it,
+	// and so are documents too long to evaluate.
+	var parsedText string
+	var pageCount int
+	content, err := s.pdfParser.ExtractProtectedText(filePath, password)
+	if errors.Is(err, ErrPDFEncrypted) {
+		s.storageService.DeleteFile(filename)
+		if password != "" {
+			return nil, errDocumentWrongPassword
+		}
+		return nil, ErrDocumentEncrypted
+	}
+	if errors.Is(err, ErrDocumentTooLong) {
+		s.storageService.DeleteFile(filename)
+		return nil, apperror.Wrap(err, http.StatusBadRequest, apperror.CodeDocumentTooLong, err.Error())
+	}
+	if err != nil {
+		log.Printf("⚠️  Failed to parse %s at upload: %v\n", originalName, err)
+	} else {
+		parsedText = content.Text
+		pageCount = content.PageCount
+	}
+
+	// Create document record
+	doc := &models.Document{
+		ID:           models.NewID(),
+		Filename:     filename,
+		OriginalName: originalName,
+		FileType:     fileType,
+		FilePath:     filePath,
+		TenantID:     tenantID,
+		ContentHash:  contentHash,
+		ParsedText:   parsedText,
+		PageCount:    pageCount,
+		CreatedAt:    time.Now(),
+		UpdatedAt:    time.Now(),
+	}
+	extractCandidate(s.contactExtraction, doc, parsedText)
+
+	if err := s.docRepo.Create(doc); err != nil {
+		// Cleanup uploaded file if database insert fails
+		s.storageService.DeleteFile(filename)
+		return nil, fmt.Errorf("failed to save document record: %w", err)
+	}
+
+	reserved = false
+
+	return doc, nil
+}
+
+// UploadURL implements DocumentService.
+func (s *documentService) UploadURL(ctx context.Context, rawURL string, fileType string, password string) (*models.Document, error) {
+	if s.fetcher == nil {
+		return nil, ErrURLUploadDisabled
+	}
+	// Don't download what the quota would refuse anyway
+	if err := s.quotaService.Check(ctx, models.UsageUploads); err != nil {
+		return nil, err
+	}
+
+	fetched, err := s.fetcher.Fetch(ctx, rawURL, s.maxFileSize)
+	if err != nil {
+		return nil, err
+	}
+	defer fetched.Body.Close()
+
+	log.Printf("🌐 Downloaded %s from %s\n", fetched.Name, fetched.Host)
+	return s.Upload(ctx, fetched.Body, fetched.Name, fileType, password)
+}
+
+// Pair implements DocumentService. Upload already refuses content stored
+// as the other type; the types are checked again so documents from
+// anywhere else can't be paired the wrong way round.
+func (s *documentService) Pair(ctx context.Context, cv *models.Document, projectReport *models.Document) (*models.DocumentPair, error) {
+	if cv.ID == projectReport.ID {
+		return nil, ErrSameDocumentPair
+	}
+	if cv.FileType != "cv" {
+		return nil, apperror.New(http.StatusBadRequest, apperror.CodeValidationFailed,
+			fmt.Sprintf("cv matches an earlier upload of type %s", cv.FileType))
+	}
+	if projectReport.FileType != "project_report" {
+		return nil, apperror.New(http.StatusBadRequest, apperror.CodeValidationFailed,
+			fmt.Sprintf("project_report matches an earlier upload of type %s", projectReport.FileType))
+	}
+
+	pair := &models.DocumentPair{
+		ID:                models.NewID(),
+		TenantID:          tenant.FromContext(ctx),
+		CVDocumentID:      cv.ID,
+		ProjectDocumentID: projectReport.ID,
+		CreatedAt:         time.Now(),
+	}
+	if err := s.docRepo.CreatePair(pair); err != nil {
+		return nil, err
+	}
+
+	return pair, nil
+}
+
+func (s *documentService) releaseUpload(ctx context.Context, period string) {
+	if err := s.quotaService.Release(ctx, models.UsageUploads, period); err != nil {
+		log.Printf("⚠️  Failed to release upload usage: %v\n", err)
+	}
+}
+
+// Preview implements DocumentService. Documents that weren't parsed at
+// upload are parsed now and the text stored, like the evaluator does, with
+// the contact details of CVs.
+func (s *documentService) Preview(ctx context.Context, id uuid.UUID, length int) (*models.DocumentPreview, error) {
+	doc, err := s.docRepo.FindByID(id)
+	if err != nil || doc.TenantID != tenant.FromContext(ctx) {
+		return nil, ErrDocumentNotFound
+	}
+
+	preview := &models.DocumentPreview{
+		ID:           doc.ID.String(),
+		OriginalName: doc.OriginalName,
+		FileType:     doc.FileType,
+		PageCount:    doc.PageCount,
+		Sections:     []string{},
+	}
+
+	text := doc.ParsedText
+	if text == "" {
+		content, err := s.pdfParser.ExtractTextWithMetaData(doc.FilePath)
+		if err != nil {
+			preview.ParseError = err.Error()
+			return preview, nil
+		}
+		text = content.Text
+		preview.PageCount = content.PageCount
+		if err := s.docRepo.UpdateParsedContent(doc.ID, content.Text, content.PageCount); err != nil {
+			log.Printf("⚠️  Failed to store parsed text for document %s: %v\n", doc.ID, err)
+		}
+
+		extractCandidate(s.contactExtraction, doc, text)
+		if doc.Candidate() != nil {
+			if err := s.docRepo.UpdateCandidate(doc); err != nil {
+				log.Printf("⚠️  Failed to store candidate of document %s: %v\n", doc.ID, err)
+			}
+		}
+	}
+	preview.Candidate = doc.Candidate()
+	// Page markers are for the LLM, not for reading
+	text = strings.TrimSpace(pageMarker.ReplaceAllString(text, ""))
+
+	preview.Characters = utf8.RuneCountInString(text)
+	preview.EstimatedTokens = EstimateTokens(text)
+	preview.Language = DetectLanguage(text)
+	for _, section := range splitSections(text) {
+		if section.header != "" {
+			preview.Sections = append(preview.Sections, strings.TrimSuffix(section.header, ":"))
+		}
+	}
+
+	preview.Text = text
+	if preview.Characters > length {
+		preview.Text = string([]rune(text)[:length])
+		preview.Truncated = true
+	}
+
+	return preview, nil
+}
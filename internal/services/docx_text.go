@@ -0,0 +1,112 @@
+package services
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// wordNamespace is the WordprocessingML namespace of document.xml elements.
+const wordNamespace = "http://schemas.openxmlformats.org/wordprocessingml/2006/main"
+
+// maxDOCXBodySize bounds how much of word/document.xml is decompressed, so a
+// zip bomb can't use up memory before the text length limit applies.
+const maxDOCXBodySize = 64 << 20
+
+// DOCXToText extracts the text of a Word document body from its
+// word/document.xml. Each paragraph gets a line of its own and tabs and line
+// breaks are kept; deleted revisions and field codes are dropped.
+func DOCXToText(r io.Reader) (string, error) {
+	dec := xml.NewDecoder(r)
+
+	var b strings.Builder
+	inText := false
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", fmt.Errorf("failed to parse DOCX: %w", err)
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			if t.Name.Space != wordNamespace {
+				continue
+			}
+			switch t.Name.Local {
+			case "t":
+				inText = true
+			case "tab":
+				b.WriteByte('\t')
+			case "br", "cr":
+				b.WriteByte('\n')
+			}
+		case xml.EndElement:
+			if t.Name.Space != wordNamespace {
+				continue
+			}
+			switch t.Name.Local {
+			case "t":
+				inText = false
+			case "p":
+				b.WriteByte('\n')
+			}
+		case xml.CharData:
+			if inText {
+				b.Write(t)
+			}
+		}
+	}
+
+	return b.String(), nil
+}
+
+// readDOCXFile extracts the text of a Word document, which counts as a
+// single page since DOCX files don't record where pages break.
+func readDOCXFile(filePath string) (*PDFContent, error) {
+	r, err := zip.OpenReader(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read DOCX file: %w", err)
+	}
+	defer r.Close()
+
+	var body *zip.File
+	for _, f := range r.File {
+		if f.Name == "word/document.xml" {
+			body = f
+			break
+		}
+	}
+	if body == nil {
+		return nil, errors.New("DOCX file has no word/document.xml")
+	}
+	if body.UncompressedSize64 > maxDOCXBodySize {
+		return nil, fmt.Errorf("DOCX body is %d bytes, at most %d allowed", body.UncompressedSize64, maxDOCXBodySize)
+	}
+
+	rc, err := body.Open()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read DOCX file: %w", err)
+	}
+	defer rc.Close()
+
+	// The declared size may lie, so bound the stream as well
+	text, err := DOCXToText(io.LimitReader(rc, maxDOCXBodySize))
+	if err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(text) == "" {
+		return nil, fmt.Errorf("no text content found in DOCX")
+	}
+
+	return &PDFContent{
+		Text:      text,
+		PageCount: 1,
+		FilePath:  filePath,
+	}, nil
+}
@@ -0,0 +1,128 @@
+package services
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+
+	"alfredoptarigan/cv-evaluator/internal/apperror"
+	"alfredoptarigan/cv-evaluator/internal/models"
+	"alfredoptarigan/cv-evaluator/internal/repositories"
+	"alfredoptarigan/cv-evaluator/internal/tenant"
+)
+
+var (
+	ErrDocumentNotFound   = apperror.New(http.StatusNotFound, apperror.CodeDocumentNotFound, "document not found")
+	ErrInvalidDownloadURL = apperror.New(http.StatusForbidden, apperror.CodeForbidden, "download link is invalid or has expired")
+	ErrSigningDisabled    = apperror.New(http.StatusNotFound, apperror.CodeNotFound, "signed download links are not enabled")
+)
+
+// DownloadService gives access to the original uploaded files, either to the
+// owning tenant directly or to anyone holding a short-lived signed link.
+type DownloadService interface {
+	Open(ctx context.Context, id uuid.UUID) (*models.Document, io.ReadCloser, int64, error)
+	OpenSigned(id uuid.UUID, expires int64, signature string) (*models.Document, io.ReadCloser, int64, error)
+	Sign(ctx context.Context, id uuid.UUID) (*models.SignedDownload, error)
+}
+
+type downloadService struct {
+	docRepo        repositories.DocumentRepository
+	storageService StorageService
+	signingKey     []byte
+	ttl            time.Duration
+}
+
+func NewDownloadService(
+	docRepo repositories.DocumentRepository,
+	storageService StorageService,
+	signingKey string,
+	ttl time.Duration,
+) DownloadService {
+	return &downloadService{
+		docRepo:        docRepo,
+		storageService: storageService,
+		signingKey:     []byte(signingKey),
+		ttl:            ttl,
+	}
+}
+
+// Open implements DownloadService.
+func (s *downloadService) Open(ctx context.Context, id uuid.UUID) (*models.Document, io.ReadCloser, int64, error) {
+	doc, err := s.findOwned(ctx, id)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+
+	return s.open(doc)
+}
+
+// OpenSigned implements DownloadService.
+func (s *downloadService) OpenSigned(id uuid.UUID, expires int64, signature string) (*models.Document, io.ReadCloser, int64, error) {
+	if len(s.signingKey) == 0 {
+		return nil, nil, 0, ErrSigningDisabled
+	}
+
+	expected := s.signature(id, expires)
+	if time.Now().Unix() > expires || !hmac.Equal([]byte(signature), []byte(expected)) {
+		return nil, nil, 0, ErrInvalidDownloadURL
+	}
+
+	doc, err := s.docRepo.FindByID(id)
+	if err != nil {
+		return nil, nil, 0, ErrDocumentNotFound
+	}
+
+	return s.open(doc)
+}
+
+// Sign implements DownloadService.
+func (s *downloadService) Sign(ctx context.Context, id uuid.UUID) (*models.SignedDownload, error) {
+	if len(s.signingKey) == 0 {
+		return nil, ErrSigningDisabled
+	}
+
+	if _, err := s.findOwned(ctx, id); err != nil {
+		return nil, err
+	}
+
+	expiresAt := time.Now().Add(s.ttl)
+	expires := expiresAt.Unix()
+
+	return &models.SignedDownload{
+		URL: "/api/v1/documents/" + id.String() + "/download?expires=" +
+			strconv.FormatInt(expires, 10) + "&signature=" + s.signature(id, expires),
+		ExpiresAt: time.Unix(expires, 0).UTC(),
+	}, nil
+}
+
+// findOwned hides other tenants' documents behind a not-found error.
+func (s *downloadService) findOwned(ctx context.Context, id uuid.UUID) (*models.Document, error) {
+	doc, err := s.docRepo.FindByID(id)
+	if err != nil || doc.TenantID != tenant.FromContext(ctx) {
+		return nil, ErrDocumentNotFound
+	}
+
+	return doc, nil
+}
+
+func (s *downloadService) open(doc *models.Document) (*models.Document, io.ReadCloser, int64, error) {
+	f, size, err := s.storageService.OpenFile(doc.Filename)
+	if err != nil {
+		return nil, nil, 0, apperror.Wrap(err, http.StatusNotFound, apperror.CodeDocumentNotFound, "document file is no longer available")
+	}
+
+	return doc, f, size, nil
+}
+
+func (s *downloadService) signature(id uuid.UUID, expires int64) string {
+	mac := hmac.New(sha256.New, s.signingKey)
+	mac.Write([]byte(id.String() + ":" + strconv.FormatInt(expires, 10)))
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,123 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"alfredoptarigan/cv-evaluator/internal/models"
+)
+
+// DryRunInput names the documents of a dry run. Model is only reported.
+type DryRunInput struct {
+	JobTitle          string
+	CVDocumentID      uuid.UUID
+	ProjectDocumentID uuid.UUID
+	// AdditionalProjectDocumentIDs are evaluated along with the project
+	// report, in order.
+	AdditionalProjectDocumentIDs []uuid.UUID
+	Model                        string
+}
+
+type dryRunKey struct{}
+
+// dryRunLog collects where a dry run departs from a real one.
+type dryRunLog struct {
+	mu    sync.Mutex
+	notes []string
+}
+
+func (l *dryRunLog) note(note string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, n := range l.notes {
+		if n == note {
+			return
+		}
+	}
+	l.notes = append(l.notes, note)
+}
+
+// withDryRun makes the pipeline helpers that would generate text with ctx
+// (condensing, reranking) skip the LLM and note it instead.
+func withDryRun(ctx context.Context) (context.Context, *dryRunLog) {
+	l := &dryRunLog{}
+	return context.WithValue(ctx, dryRunKey{}, l), l
+}
+
+func dryRunFrom(ctx context.Context) *dryRunLog {
+	l, _ := ctx.Value(dryRunKey{}).(*dryRunLog)
+	return l
+}
+
+// DryRun implements EvaluatorService. The query embeddings are still
+// generated, since retrieval needs them.
+func (e *evaluatorService) DryRun(ctx context.Context, input DryRunInput) (*models.EvaluationDryRun, error) {
+	ctx, dryRun := withDryRun(ctx)
+	ctx = WithJob(ctx, JobKey(input.JobTitle))
+	log.Printf("🧪 Dry run for CV %s and project %s\n", input.CVDocumentID, input.ProjectDocumentID)
+
+	cvText, err := e.loadText(ctx, input.CVDocumentID, "CV")
+	if err != nil {
+		return nil, err
+	}
+	projectFiles, err := e.loadProjectFiles(ctx, input.ProjectDocumentID, input.AdditionalProjectDocumentIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	cvResults, err := e.retrieveResults(ctx, cvText, cvContextTypes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve CV context: %w", err)
+	}
+	projectResults, err := e.retrieveResults(ctx, joinProjectFiles(projectFiles), projectContextTypes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve project context: %w", err)
+	}
+
+	timeline := ExtractExperienceTimeline(cvText, time.Now())
+	cv := dryRunPrompt(e.cvPrompt(ctx, cvText, timeline, FormatRAGContext(cvResults), input.JobTitle), cvResults)
+	project := dryRunPrompt(e.projectPrompt(ctx, projectFiles, e.projectImages(projectFiles), FormatRAGContext(projectResults)), projectResults)
+
+	model := input.Model
+	if model == "" {
+		model = e.geminiService.ModelName()
+	}
+
+	return &models.EvaluationDryRun{
+		DryRun:                 true,
+		JobTitle:               input.JobTitle,
+		Model:                  model,
+		CV:                     cv,
+		Project:                project,
+		EstimatedPromptCostUSD: e.pricing.Cost(int64(cv.EstimatedTokens+project.EstimatedTokens), 0),
+		Notes:                  dryRun.notes,
+	}, nil
+}
+
+func dryRunPrompt(prompt string, results []SearchResult) models.DryRunPrompt {
+	context := make([]models.RetrievalMatch, 0, len(results))
+	for _, r := range results {
+		context = append(context, models.RetrievalMatch{
+			ID:       r.ID,
+			Score:    r.Score,
+			Text:     r.Text,
+			DocType:  r.DocType,
+			Source:   r.Source,
+			Section:  r.Section,
+			Page:     r.Page,
+			Metadata: r.Metadata,
+			Selected: true,
+		})
+	}
+
+	return models.DryRunPrompt{
+		Prompt:          prompt,
+		EstimatedTokens: EstimateTokens(prompt),
+		Context:         context,
+	}
+}
@@ -0,0 +1,257 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"mime/multipart"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"unicode"
+
+	"alfredoptarigan/cv-evaluator/internal/apperror"
+	"alfredoptarigan/cv-evaluator/internal/models"
+	"alfredoptarigan/cv-evaluator/internal/tenant"
+)
+
+// Words in an attachment's name that tell the project report and the CV
+// apart. Project hints are checked first, as "report" is the stronger sign.
+var (
+	projectAttachmentHints = []string{"project", "report", "case", "assignment"}
+	cvAttachmentHints      = []string{"cv", "resume", "résumé", "curriculum"}
+)
+
+// InboundEmail is a message received by the email intake.
+type InboundEmail struct {
+	// Recipients are the addresses from To, Cc and the envelope.
+	Recipients  []string
+	Subject     string
+	Attachments []*multipart.FileHeader
+}
+
+// EmailIntakeService turns emailed applications into documents and, with
+// a known job code in the subject, evaluations.
+type EmailIntakeService interface {
+	Receive(ctx context.Context, email InboundEmail) (*models.EmailIntakeResult, error)
+}
+
+type emailIntakeService struct {
+	docService   DocumentService
+	evalService  EvaluationService
+	address      string
+	tenantID     string
+	jobCodes     map[string]string
+	autoEvaluate bool
+}
+
+// NewEmailIntakeService returns an intake creating documents for tenantID
+// from messages sent to address (any, if empty).
+func NewEmailIntakeService(
+	docService DocumentService,
+	evalService EvaluationService,
+	address string,
+	tenantID string,
+	jobCodes map[string]string,
+	autoEvaluate bool,
+) EmailIntakeService {
+	return &emailIntakeService{
+		docService:   docService,
+		evalService:  evalService,
+		address:      strings.ToLower(strings.TrimSpace(address)),
+		tenantID:     tenantID,
+		jobCodes:     jobCodes,
+		autoEvaluate: autoEvaluate,
+	}
+}
+
+// Receive implements EmailIntakeService. Problems with the message itself,
+// such as an attachment that isn't a valid PDF, are reported in the result
+// rather than returned, so the mail provider doesn't retry the message.
+func (s *emailIntakeService) Receive(ctx context.Context, email InboundEmail) (*models.EmailIntakeResult, error) {
+	result := &models.EmailIntakeResult{Documents: []models.UploadResponse{}}
+	if !s.addressedToIntake(email.Recipients) {
+		result.Reason = "not sent to the intake address"
+		return result, nil
+	}
+	result.Accepted = true
+	ctx = tenant.WithID(ctx, s.tenantID)
+
+	assigned := s.assignAttachments(email.Attachments, result)
+	uploaded := make(map[string]*models.Document, len(assigned))
+	for _, fileType := range []string{"cv", "project_report"} {
+		attachment := assigned[fileType]
+		if attachment == nil {
+			continue
+		}
+		doc, err := s.upload(ctx, attachment, fileType)
+		if err != nil {
+			if !skippable(err) {
+				return nil, err
+			}
+			result.Skipped = append(result.Skipped, fmt.Sprintf("%s: %s", attachment.Filename, clientMessage(err)))
+			continue
+		}
+		uploaded[fileType] = doc
+		result.Documents = append(result.Documents, models.UploadResponse{
+			ID:           doc.ID.String(),
+			Filename:     doc.Filename,
+			OriginalName: doc.OriginalName,
+			FileType:     doc.FileType,
+		})
+	}
+	result.JobCode, result.JobTitle = s.jobFromSubject(email.Subject)
+	log.Printf("📧 Email intake stored %d document(s) for tenant %s\n", len(result.Documents), s.tenantID)
+
+	cv, projectReport := uploaded["cv"], uploaded["project_report"]
+	if cv == nil || projectReport == nil {
+		return result, nil
+	}
+	pair, err := s.docService.Pair(ctx, cv, projectReport)
+	if err != nil {
+		if !skippable(err) {
+			return nil, err
+		}
+		result.Skipped = append(result.Skipped, "pair: "+clientMessage(err))
+		return result, nil
+	}
+	result.PairID = pair.ID.String()
+
+	if !s.autoEvaluate {
+		return result, nil
+	}
+	if result.JobTitle == "" {
+		result.Skipped = append(result.Skipped, "evaluation: no known job code in the subject")
+		return result, nil
+	}
+
+	evaluation, _, err := s.evalService.Submit(ctx, SubmitEvaluationInput{
+		JobTitle: result.JobTitle,
+		PairID:   pair.ID,
+	})
+	if err != nil {
+		if !skippable(err) {
+			return nil, err
+		}
+		result.Skipped = append(result.Skipped, "evaluation: "+clientMessage(err))
+		return result, nil
+	}
+	result.EvaluationID = evaluation.ID.String()
+	log.Printf("📧 Email intake queued evaluation %s for %s\n", evaluation.ID, result.JobTitle)
+
+	return result, nil
+}
+
+func (s *emailIntakeService) addressedToIntake(recipients []string) bool {
+	if s.address == "" {
+		return true
+	}
+	for _, r := range recipients {
+		if strings.ToLower(strings.TrimSpace(r)) == s.address {
+			return true
+		}
+	}
+	return false
+}
+
+// assignAttachments picks the CV and the project report among the PDF
+// attachments, by name where it tells and otherwise in order, and notes
+// the others as skipped.
+func (s *emailIntakeService) assignAttachments(attachments []*multipart.FileHeader, result *models.EmailIntakeResult) map[string]*multipart.FileHeader {
+	assigned := make(map[string]*multipart.FileHeader, 2)
+	var unnamed []*multipart.FileHeader
+
+	for _, a := range attachments {
+		if !isPDFAttachment(a) {
+			result.Skipped = append(result.Skipped, a.Filename+": not a PDF")
+			continue
+		}
+		fileType := attachmentType(a.Filename)
+		switch {
+		case fileType == "":
+			unnamed = append(unnamed, a)
+		case assigned[fileType] == nil:
+			assigned[fileType] = a
+		default:
+			result.Skipped = append(result.Skipped, fmt.Sprintf("%s: more than one %s", a.Filename, fileType))
+		}
+	}
+
+	for _, a := range unnamed {
+		switch {
+		case assigned["cv"] == nil:
+			assigned["cv"] = a
+		case assigned["project_report"] == nil:
+			assigned["project_report"] = a
+		default:
+			result.Skipped = append(result.Skipped, a.Filename+": already have a CV and a project report")
+		}
+	}
+
+	return assigned
+}
+
+func (s *emailIntakeService) upload(ctx context.Context, attachment *multipart.FileHeader, fileType string) (*models.Document, error) {
+	f, err := attachment.Open()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open attachment: %w", err)
+	}
+	defer f.Close()
+
+	name := attachment.Filename
+	if FileTypeForExtension(filepath.Ext(name)) != FileTypePDF {
+		name += ".pdf"
+	}
+	return s.docService.Upload(ctx, f, name, fileType, "")
+}
+
+// jobFromSubject finds a configured job code among the words of subject,
+// e.g. "[BE-01] Application: Jane Doe".
+func (s *emailIntakeService) jobFromSubject(subject string) (string, string) {
+	words := strings.FieldsFunc(subject, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '-' && r != '_'
+	})
+	for _, word := range words {
+		for code, title := range s.jobCodes {
+			if strings.EqualFold(word, code) {
+				return code, title
+			}
+		}
+	}
+	return "", ""
+}
+
+func isPDFAttachment(a *multipart.FileHeader) bool {
+	return FileTypeForExtension(filepath.Ext(a.Filename)) == FileTypePDF ||
+		strings.EqualFold(a.Header.Get("Content-Type"), "application/pdf")
+}
+
+// attachmentType guesses the document type from an attachment's name.
+func attachmentType(name string) string {
+	name = strings.ToLower(name)
+	for _, hint := range projectAttachmentHints {
+		if strings.Contains(name, hint) {
+			return "project_report"
+		}
+	}
+	for _, hint := range cvAttachmentHints {
+		if strings.Contains(name, hint) {
+			return "cv"
+		}
+	}
+	return ""
+}
+
+// skippable reports whether err is about the message rather than the
+// service, so retrying wouldn't help.
+func skippable(err error) bool {
+	appErr, ok := apperror.As(err)
+	return ok && appErr.Status < http.StatusInternalServerError
+}
+
+func clientMessage(err error) string {
+	if appErr, ok := apperror.As(err); ok {
+		return appErr.Message
+	}
+	return err.Error()
+}
@@ -0,0 +1,167 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"log"
+	"math"
+
+	"alfredoptarigan/cv-evaluator/internal/metrics"
+	"alfredoptarigan/cv-evaluator/internal/models"
+	"alfredoptarigan/cv-evaluator/internal/repositories"
+)
+
+var (
+	embeddingCacheHits   = metrics.NewCounter("cv_evaluator_embedding_cache_hits_total", "Embeddings served from the cache.", nil)
+	embeddingCacheMisses = metrics.NewCounter("cv_evaluator_embedding_cache_misses_total", "Embeddings that had to be generated.", nil)
+)
+
+// cachedEmbeddingService stores embeddings by hash(model, text), so repeated
+// chunks and duplicate uploads don't call the embedding API again.
+// Embeddings don't change for a given model, so entries never expire.
+type cachedEmbeddingService struct {
+	next      GeminiService
+	cacheRepo repositories.EmbeddingCacheRepository
+}
+
+func NewCachedEmbeddingService(next GeminiService, cacheRepo repositories.EmbeddingCacheRepository) GeminiService {
+	return &cachedEmbeddingService{
+		next:      next,
+		cacheRepo: cacheRepo,
+	}
+}
+
+// ModelName implements GeminiService.
+func (c *cachedEmbeddingService) ModelName() string {
+	return c.next.ModelName()
+}
+
+// EmbeddingModel implements GeminiService.
+func (c *cachedEmbeddingService) EmbeddingModel() string {
+	return c.next.EmbeddingModel()
+}
+
+// GenerateText implements GeminiService.
+func (c *cachedEmbeddingService) GenerateText(ctx context.Context, prompt string, temperature float32) (string, error) {
+	return c.next.GenerateText(ctx, prompt, temperature)
+}
+
+// GenerateTextWithRetry implements GeminiService.
+func (c *cachedEmbeddingService) GenerateTextWithRetry(ctx context.Context, prompt string, temperature float32, maxRetries int) (string, error) {
+	return c.next.GenerateTextWithRetry(ctx, prompt, temperature, maxRetries)
+}
+
+// GenerateEmbedding implements GeminiService.
+func (c *cachedEmbeddingService) GenerateEmbedding(ctx context.Context, text string) ([]float32, error) {
+	key := embeddingCacheKey(c.next.EmbeddingModel(), text)
+
+	entry, err := c.cacheRepo.Get(key)
+	if err != nil {
+		log.Printf("⚠️  Embedding cache read failed: %v\n", err)
+	} else if entry != nil {
+		if embedding, ok := decodeEmbedding(entry.Embedding); ok {
+			embeddingCacheHits.Inc()
+			return embedding, nil
+		}
+	}
+
+	embeddingCacheMisses.Inc()
+	embedding, err := c.next.GenerateEmbedding(ctx, text)
+	if err != nil {
+		return nil, err
+	}
+
+	err = c.cacheRepo.Put(&models.EmbeddingCacheEntry{
+		Key:       key,
+		Model:     c.next.EmbeddingModel(),
+		Embedding: encodeEmbedding(embedding),
+	})
+	if err != nil {
+		log.Printf("⚠️  Embedding cache write failed: %v\n", err)
+	}
+
+	return embedding, nil
+}
+
+// EmbedBatch implements GeminiService. Only the texts missing from the cache
+// are sent to the provider, in a single batch.
+func (c *cachedEmbeddingService) EmbedBatch(ctx context.Context, texts []string) ([][]float32, error) {
+	embeddings := make([][]float32, len(texts))
+	keys := make([]string, len(texts))
+	var missing []int
+
+	for i, text := range texts {
+		keys[i] = embeddingCacheKey(c.next.EmbeddingModel(), text)
+
+		entry, err := c.cacheRepo.Get(keys[i])
+		if err != nil {
+			log.Printf("⚠️  Embedding cache read failed: %v\n", err)
+		} else if entry != nil {
+			if embedding, ok := decodeEmbedding(entry.Embedding); ok {
+				embeddings[i] = embedding
+				embeddingCacheHits.Inc()
+				continue
+			}
+		}
+		missing = append(missing, i)
+	}
+
+	if len(missing) == 0 {
+		return embeddings, nil
+	}
+	embeddingCacheMisses.Add(int64(len(missing)))
+
+	missingTexts := make([]string, len(missing))
+	for j, i := range missing {
+		missingTexts[j] = texts[i]
+	}
+
+	generated, err := c.next.EmbedBatch(ctx, missingTexts)
+	if err != nil {
+		return nil, err
+	}
+
+	for j, i := range missing {
+		embeddings[i] = generated[j]
+		err := c.cacheRepo.Put(&models.EmbeddingCacheEntry{
+			Key:       keys[i],
+			Model:     c.next.EmbeddingModel(),
+			Embedding: encodeEmbedding(generated[j]),
+		})
+		if err != nil {
+			log.Printf("⚠️  Embedding cache write failed: %v\n", err)
+		}
+	}
+
+	return embeddings, nil
+}
+
+func embeddingCacheKey(model, text string) string {
+	h := sha256.New()
+	h.Write([]byte(model))
+	h.Write([]byte{0})
+	h.Write([]byte(text))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func encodeEmbedding(embedding []float32) []byte {
+	buf := make([]byte, 4*len(embedding))
+	for i, v := range embedding {
+		binary.LittleEndian.PutUint32(buf[4*i:], math.Float32bits(v))
+	}
+	return buf
+}
+
+func decodeEmbedding(buf []byte) ([]float32, bool) {
+	if len(buf) == 0 || len(buf)%4 != 0 {
+		return nil, false
+	}
+
+	embedding := make([]float32, len(buf)/4)
+	for i := range embedding {
+		embedding[i] = math.Float32frombits(binary.LittleEndian.Uint32(buf[4*i:]))
+	}
+	return embedding, true
+}
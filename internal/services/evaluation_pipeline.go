@@ -0,0 +1,246 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+
+	"alfredoptarigan/cv-evaluator/internal/models"
+	"alfredoptarigan/cv-evaluator/internal/repositories"
+)
+
+// evaluationRun carries the outputs of the pipeline stages through one run
+// of an evaluation.
+type evaluationRun struct {
+	evaluation    models.Evaluation
+	cvText        string
+	projectFiles  []projectFile
+	cvResult      *CVEvaluationResult
+	projectResult *ProjectEvaluationResult
+}
+
+// partial reports whether a scoring stage finished, so a failure leaves the
+// evaluation partially completed rather than failed.
+func (r *evaluationRun) partial() bool {
+	return r.cvResult != nil || r.projectResult != nil
+}
+
+// ragContextMissing reports whether a finished scoring stage ran without all
+// of its reference context.
+func (r *evaluationRun) ragContextMissing() bool {
+	return (r.cvResult != nil && r.cvResult.RAGContextMissing) ||
+		(r.projectResult != nil && r.projectResult.RAGContextMissing)
+}
+
+// pipelineStage is one checkpointed step of an evaluation. A stage whose
+// checkpoint exists is restored instead of run.
+type pipelineStage struct {
+	checkpoint models.CheckpointStage
+	// feeds is the stage consuming this one's output; once it is
+	// checkpointed this stage isn't needed either.
+	feeds models.CheckpointStage
+	// output points at the run field the stage fills, which is what gets
+	// checkpointed and restored.
+	output func(r *evaluationRun) interface{}
+	// run fills the output and returns the columns to show as partial
+	// results, if any.
+	run func(ctx context.Context, r *evaluationRun) (*repositories.EvaluationUpdateData, error)
+}
+
+// stageError is a stage failure with what to put in the error history.
+type stageError struct {
+	stage   models.EvaluationStage
+	message string
+	err     error
+}
+
+func (e *stageError) Error() string { return e.message }
+func (e *stageError) Unwrap() error { return e.err }
+
+func newStageError(stage models.EvaluationStage, err error, format string, args ...interface{}) *stageError {
+	return &stageError{stage: stage, message: fmt.Sprintf(format, args...), err: err}
+}
+
+// stages lists the checkpointed pipeline in order. The overall summary isn't
+// a stage: it completes the evaluation, so there is nothing to resume.
+func (e *evaluatorService) stages() []pipelineStage {
+	return []pipelineStage{
+		{
+			checkpoint: models.CheckpointCVText,
+			feeds:      models.CheckpointCVResult,
+			output:     func(r *evaluationRun) interface{} { return &r.cvText },
+			run: func(ctx context.Context, r *evaluationRun) (*repositories.EvaluationUpdateData, error) {
+				text, err := e.loadText(ctx, r.evaluation.CVDocumentID, "CV")
+				r.cvText = text
+				return nil, err
+			},
+		},
+		{
+			checkpoint: models.CheckpointCVResult,
+			output:     func(r *evaluationRun) interface{} { return &r.cvResult },
+			run: func(ctx context.Context, r *evaluationRun) (*repositories.EvaluationUpdateData, error) {
+				log.Println("🔍 Retrieving relevant context for CV evaluation...")
+				cvContext, citations, missing := e.retrieveStageContext(ctx, r, r.cvText, "CV", cvContextTypes)
+
+				log.Println("🤖 Evaluating CV with LLM...")
+				result, err := e.evaluateCV(ctx, r.cvText, cvContext, r.evaluation.JobTitle)
+				if err != nil {
+					return nil, newStageError(models.StageLLM, err, "Failed to evaluate CV: %v", err)
+				}
+				result.RAGContextMissing = missing
+				result.Citations = citations
+				r.cvResult = result
+				ragContextMissing := r.ragContextMissing()
+				return &repositories.EvaluationUpdateData{
+					CVMatchRate:       &result.MatchRate,
+					CVFeedback:        &result.Feedback,
+					RAGContextMissing: &ragContextMissing,
+				}, nil
+			},
+		},
+		{
+			checkpoint: models.CheckpointProjectText,
+			feeds:      models.CheckpointProjectResult,
+			// Checkpoints from before multi-file projects hold a string,
+			// which doesn't restore into the files, so the stage reruns
+			output: func(r *evaluationRun) interface{} { return &r.projectFiles },
+			run: func(ctx context.Context, r *evaluationRun) (*repositories.EvaluationUpdateData, error) {
+				additional, err := e.evalRepo.FindAdditionalProjectDocuments(r.evaluation.ID)
+				if err != nil {
+					return nil, newStageError(models.StageLoad, err, "Failed to load project documents: %v", err)
+				}
+				files, err := e.loadProjectFiles(ctx, r.evaluation.ProjectDocumentID, additional)
+				r.projectFiles = files
+				return nil, err
+			},
+		},
+		{
+			checkpoint: models.CheckpointProjectResult,
+			output:     func(r *evaluationRun) interface{} { return &r.projectResult },
+			run: func(ctx context.Context, r *evaluationRun) (*repositories.EvaluationUpdateData, error) {
+				log.Println("🔍 Retrieving relevant context for Project evaluation...")
+				projectContext, citations, missing := e.retrieveStageContext(ctx, r, joinProjectFiles(r.projectFiles), "project", projectContextTypes)
+
+				log.Println("🤖 Evaluating Project Report with LLM...")
+				result, err := e.evaluateProject(ctx, r.projectFiles, projectContext)
+				if err != nil {
+					return nil, newStageError(models.StageLLM, err, "Failed to evaluate project: %v", err)
+				}
+				result.RAGContextMissing = missing
+				result.Citations = citations
+				r.projectResult = result
+				ragContextMissing := r.ragContextMissing()
+				return &repositories.EvaluationUpdateData{
+					ProjectScore:      &result.ProjectScore,
+					ProjectFeedback:   &result.Feedback,
+					RAGContextMissing: &ragContextMissing,
+				}, nil
+			},
+		},
+	}
+}
+
+// runStages runs the stages that have no checkpoint yet, checkpointing each
+// as it finishes.
+func (e *evaluatorService) runStages(ctx context.Context, r *evaluationRun) error {
+	stages := e.stages()
+	done := e.restoreCheckpoints(r, stages)
+
+	for _, stage := range stages {
+		if done[stage.checkpoint] || (stage.feeds != "" && done[stage.feeds]) {
+			log.Printf("⏩ Reusing %s from an earlier attempt for job %s\n", stage.checkpoint, r.evaluation.ID)
+			continue
+		}
+
+		data, err := stage.run(ctx, r)
+		if err != nil {
+			return err
+		}
+		e.saveCheckpoint(r, stage, data)
+	}
+
+	return nil
+}
+
+// restoreCheckpoints loads stored stage outputs into r and reports which
+// stages were restored. Unreadable checkpoints are ignored and their stage
+// runs again.
+func (e *evaluatorService) restoreCheckpoints(r *evaluationRun, stages []pipelineStage) map[models.CheckpointStage]bool {
+	done := make(map[models.CheckpointStage]bool)
+
+	checkpoints, err := e.evalRepo.FindCheckpoints(r.evaluation.ID)
+	if err != nil {
+		log.Printf("⚠️  Failed to load checkpoints of job %s, running all stages: %v\n", r.evaluation.ID, err)
+		return done
+	}
+
+	outputs := make(map[models.CheckpointStage]string, len(checkpoints))
+	for _, c := range checkpoints {
+		outputs[c.Stage] = c.Output
+	}
+
+	for _, stage := range stages {
+		output, ok := outputs[stage.checkpoint]
+		if !ok {
+			continue
+		}
+		if err := json.Unmarshal([]byte(output), stage.output(r)); err != nil {
+			log.Printf("⚠️  Ignoring %s checkpoint of job %s: %v\n", stage.checkpoint, r.evaluation.ID, err)
+			continue
+		}
+		done[stage.checkpoint] = true
+	}
+
+	return done
+}
+
+// saveCheckpoint stores a finished stage. Failing to save only means a retry
+// runs the stage again.
+func (e *evaluatorService) saveCheckpoint(r *evaluationRun, stage pipelineStage, data *repositories.EvaluationUpdateData) {
+	output, err := json.Marshal(stage.output(r))
+	if err == nil {
+		err = e.evalRepo.SaveCheckpoint(r.evaluation.ID, stage.checkpoint, string(output), data)
+	}
+	if err != nil {
+		log.Printf("⚠️  Failed to save %s checkpoint of job %s: %v\n", stage.checkpoint, r.evaluation.ID, err)
+	}
+}
+
+// retrieveStageContext fetches reference context for a scoring stage, with
+// citations of the chunks in it, and reports whether any document type is
+// missing from it. Failures are recorded and the stage continues without
+// context.
+func (e *evaluatorService) retrieveStageContext(ctx context.Context, r *evaluationRun, text, label string, docTypes []string) (string, []models.Citation, bool) {
+	results, err := e.retrieveResults(ctx, text, docTypes)
+	if err != nil {
+		log.Printf("⚠️  Warning: Failed to retrieve %s context: %v\n", label, err)
+		e.recordError(r.evaluation.ID, r.evaluation.Attempts, models.StageRetrieval, fmt.Sprintf("Failed to retrieve %s context: %v", label, err))
+		return "", nil, true
+	}
+
+	found := make(map[string]bool, len(docTypes))
+	citations := make([]models.Citation, 0, len(results))
+	for _, result := range results {
+		found[result.DocType] = true
+		citations = append(citations, models.Citation{
+			DocType: result.DocType,
+			Source:  result.Source,
+			Section: result.Section,
+			Page:    result.Page,
+			Score:   result.Score,
+		})
+	}
+	var missing []string
+	for _, docType := range docTypes {
+		if !found[docType] {
+			missing = append(missing, docType)
+		}
+	}
+	if len(missing) > 0 {
+		log.Printf("⚠️  Evaluating %s of job %s without %s context\n", label, r.evaluation.ID, strings.Join(missing, ", "))
+	}
+
+	return FormatRAGContext(results), citations, len(missing) > 0
+}
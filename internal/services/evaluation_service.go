@@ -0,0 +1,334 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math/rand"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"alfredoptarigan/cv-evaluator/internal/apperror"
+	"alfredoptarigan/cv-evaluator/internal/models"
+	"alfredoptarigan/cv-evaluator/internal/repositories"
+	"alfredoptarigan/cv-evaluator/internal/requestid"
+	"alfredoptarigan/cv-evaluator/internal/tenant"
+)
+
+var (
+	ErrCVDocumentNotFound      = apperror.New(http.StatusNotFound, apperror.CodeDocumentNotFound, "CV document not found")
+	ErrProjectDocumentNotFound = apperror.New(http.StatusNotFound, apperror.CodeDocumentNotFound, "Project document not found")
+	ErrDocumentPairNotFound    = apperror.New(http.StatusNotFound, apperror.CodeDocumentNotFound, "Document pair not found")
+	ErrDocumentPairMismatch    = apperror.New(http.StatusBadRequest, apperror.CodeValidationFailed, "cv_document_id and project_document_id don't match pair_id")
+)
+
+// EvaluationService creates evaluation jobs and hands them to the worker.
+// It is shared by the HTTP and gRPC transports.
+type EvaluationService interface {
+	// Submit returns the existing evaluation instead, with duplicate set,
+	// when the same documents are already queued, processing or completed
+	// for the same job title, unless input.Force is set.
+	Submit(ctx context.Context, input SubmitEvaluationInput) (evaluation *models.Evaluation, duplicate bool, err error)
+	// DryRun returns the prompts the evaluation would send, without
+	// creating it or generating any text.
+	DryRun(ctx context.Context, input SubmitEvaluationInput) (*models.EvaluationDryRun, error)
+}
+
+type SubmitEvaluationInput struct {
+	JobTitle          string
+	CVDocumentID      uuid.UUID
+	ProjectDocumentID uuid.UUID
+	// PairID, when set, supplies the document IDs; any given as well must
+	// match it.
+	PairID uuid.UUID
+	// AdditionalProjectDocumentIDs are evaluated along with the project
+	// report, in order.
+	AdditionalProjectDocumentIDs []uuid.UUID
+	BypassCache                  bool
+	// RunAt delays the job; times in the past mean now.
+	RunAt *time.Time
+	// Model (one of the allowed models) and Temperature override the LLM
+	// defaults for this evaluation.
+	Model       string
+	Temperature *float32
+	// ExecutionMode defaults to interactive.
+	ExecutionMode models.ExecutionMode
+	// Force creates a new evaluation even if a duplicate exists.
+	Force bool
+}
+
+type evaluationService struct {
+	evalRepo     repositories.EvaluationRepository
+	docRepo      repositories.DocumentRepository
+	quotaService QuotaService
+	worker       Worker
+	evaluator    EvaluatorService
+	maxBacklog   int64
+	// allowedModels are the models an evaluation may ask for.
+	allowedModels []string
+	canary        CanaryOptions
+}
+
+// CanaryOptions routes Percent (0-100) of the evaluations that don't pick a
+// model to Model.
+type CanaryOptions struct {
+	Model   string
+	Percent float64
+}
+
+func NewEvaluationService(
+	evalRepo repositories.EvaluationRepository,
+	docRepo repositories.DocumentRepository,
+	quotaService QuotaService,
+	worker Worker,
+	evaluator EvaluatorService,
+	maxBacklog int64,
+	allowedModels []string,
+	canary CanaryOptions,
+) EvaluationService {
+	return &evaluationService{
+		evalRepo:      evalRepo,
+		docRepo:       docRepo,
+		quotaService:  quotaService,
+		worker:        worker,
+		evaluator:     evaluator,
+		maxBacklog:    maxBacklog,
+		allowedModels: allowedModels,
+		canary:        canary,
+	}
+}
+
+// Submit implements EvaluationService.
+func (s *evaluationService) Submit(ctx context.Context, input SubmitEvaluationInput) (*models.Evaluation, bool, error) {
+	if err := s.checkModel(input.Model); err != nil {
+		return nil, false, err
+	}
+
+	if err := s.resolvePair(ctx, &input); err != nil {
+		return nil, false, err
+	}
+
+	if err := s.checkDocuments(ctx, input); err != nil {
+		return nil, false, err
+	}
+
+	mode := input.ExecutionMode
+	if mode == "" {
+		mode = models.ExecutionInteractive
+	}
+
+	// Checked before the quota, so resubmitting doesn't use any
+	if !input.Force {
+		existing, err := s.evalRepo.FindDuplicate(repositories.DuplicateFilter{
+			TenantID:                     tenant.FromContext(ctx),
+			CVDocumentID:                 input.CVDocumentID,
+			ProjectDocumentID:            input.ProjectDocumentID,
+			AdditionalProjectDocumentIDs: input.AdditionalProjectDocumentIDs,
+			JobTitle:                     input.JobTitle,
+			Model:                        input.Model,
+			Temperature:                  input.Temperature,
+			ExecutionMode:                mode,
+			RunAt:                        input.RunAt,
+		})
+		if err != nil {
+			return nil, false, err
+		}
+		if existing != nil {
+			log.Printf("♻️  Returning evaluation %s for duplicate submission\n", existing.ID)
+			return existing, true, nil
+		}
+	}
+
+	if err := s.checkBacklog(); err != nil {
+		return nil, false, err
+	}
+
+	period, err := s.quotaService.Reserve(ctx, models.UsageEvaluations)
+	if err != nil {
+		return nil, false, err
+	}
+
+	model, cohort := s.assignCohort(input.Model)
+
+	// Create evaluation record
+	evaluation := &models.Evaluation{
+		ID:                models.NewID(),
+		JobTitle:          input.JobTitle,
+		CVDocumentID:      input.CVDocumentID,
+		ProjectDocumentID: input.ProjectDocumentID,
+		Status:            models.StatusQueued,
+		BypassCache:       input.BypassCache,
+		TenantID:          tenant.FromContext(ctx),
+		RequestID:         requestid.FromContext(ctx),
+		RunAt:             input.RunAt,
+		Model:             model,
+		Temperature:       input.Temperature,
+		Cohort:            cohort,
+		ExecutionMode:     mode,
+		CreatedAt:         time.Now(),
+		UpdatedAt:         time.Now(),
+	}
+
+	if err := s.evalRepo.Create(evaluation, input.AdditionalProjectDocumentIDs); err != nil {
+		if err := s.quotaService.Release(ctx, models.UsageEvaluations, period); err != nil {
+			log.Printf("⚠️  Failed to release evaluation usage: %v\n", err)
+		}
+		return nil, false, fmt.Errorf("failed to create evaluation job: %w", err)
+	}
+
+	// The job was committed with an outbox entry; wake the worker to
+	// dispatch it now rather than on its next poll
+	s.worker.Notify()
+
+	return evaluation, false, nil
+}
+
+// DryRun implements EvaluationService. It uses no quota.
+func (s *evaluationService) DryRun(ctx context.Context, input SubmitEvaluationInput) (*models.EvaluationDryRun, error) {
+	if err := s.checkModel(input.Model); err != nil {
+		return nil, err
+	}
+
+	if err := s.resolvePair(ctx, &input); err != nil {
+		return nil, err
+	}
+
+	if err := s.checkDocuments(ctx, input); err != nil {
+		return nil, err
+	}
+
+	return s.evaluator.DryRun(ctx, DryRunInput{
+		JobTitle:                     input.JobTitle,
+		CVDocumentID:                 input.CVDocumentID,
+		ProjectDocumentID:            input.ProjectDocumentID,
+		AdditionalProjectDocumentIDs: input.AdditionalProjectDocumentIDs,
+		Model:                        input.Model,
+	})
+}
+
+// checkModel rejects model overrides outside the allowlist.
+func (s *evaluationService) checkModel(model string) error {
+	if model == "" || slices.Contains(s.allowedModels, model) {
+		return nil
+	}
+
+	message := fmt.Sprintf("model must be one of: %s", strings.Join(s.allowedModels, ", "))
+	appErr := apperror.New(http.StatusBadRequest, apperror.CodeValidationFailed, message)
+	appErr.Fields = []apperror.FieldError{{Field: "model", Rule: "allowlist", Message: message}}
+	return appErr
+}
+
+// assignCohort picks the model and rollout cohort of a new evaluation. A
+// model chosen in the request is kept and stays out of the rollout.
+func (s *evaluationService) assignCohort(model string) (string, models.EvaluationCohort) {
+	if model != "" {
+		return model, models.CohortOverride
+	}
+	if s.canary.Model != "" && rand.Float64()*100 < s.canary.Percent {
+		return s.canary.Model, models.CohortCanary
+	}
+	return "", models.CohortStable
+}
+
+// checkBacklog rejects new work once maxBacklog evaluations are waiting, so
+// clients back off instead of piling up jobs that won't run for a long time.
+// checkDocuments verifies that the documents exist, belong to the caller's
+// tenant and were uploaded as the type they are used as. Other tenants'
+// documents are reported as not found.
+func (s *evaluationService) checkDocuments(ctx context.Context, input SubmitEvaluationInput) error {
+	tenantID := tenant.FromContext(ctx)
+
+	cv, err := s.docRepo.FindByID(input.CVDocumentID)
+	if err != nil || cv.TenantID != tenantID {
+		return ErrCVDocumentNotFound
+	}
+	project, err := s.docRepo.FindByID(input.ProjectDocumentID)
+	if err != nil || project.TenantID != tenantID {
+		return ErrProjectDocumentNotFound
+	}
+
+	if cv.FileType != "cv" {
+		return wrongDocumentType("cv_document_id", cv.FileType, "cv")
+	}
+	if project.FileType != "project_report" {
+		return wrongDocumentType("project_document_id", project.FileType, "project_report")
+	}
+
+	for _, docID := range input.AdditionalProjectDocumentIDs {
+		if docID == input.ProjectDocumentID {
+			message := "additional_project_document_ids must not repeat project_document_id"
+			appErr := apperror.New(http.StatusBadRequest, apperror.CodeValidationFailed, message)
+			appErr.Fields = []apperror.FieldError{{Field: "additional_project_document_ids", Rule: "unique", Message: message}}
+			return appErr
+		}
+		doc, err := s.docRepo.FindByID(docID)
+		if err != nil || doc.TenantID != tenantID {
+			return ErrProjectDocumentNotFound
+		}
+		if doc.FileType != "project_report" {
+			return wrongDocumentType("additional_project_document_ids", doc.FileType, "project_report")
+		}
+	}
+
+	return nil
+}
+
+func wrongDocumentType(field, got, want string) error {
+	message := fmt.Sprintf("%s is a %s document, not a %s", field, got, want)
+	appErr := apperror.New(http.StatusBadRequest, apperror.CodeWrongDocumentType, message)
+	appErr.Fields = []apperror.FieldError{{Field: field, Rule: "file_type", Message: message}}
+	return appErr
+}
+
+// resolvePair fills in the documents of input.PairID, if set. Pairs are
+// looked up within the caller's tenant.
+func (s *evaluationService) resolvePair(ctx context.Context, input *SubmitEvaluationInput) error {
+	if input.PairID == uuid.Nil {
+		return nil
+	}
+
+	pair, err := s.docRepo.FindPair(tenant.FromContext(ctx), input.PairID)
+	if err != nil {
+		return ErrDocumentPairNotFound
+	}
+	if (input.CVDocumentID != uuid.Nil && input.CVDocumentID != pair.CVDocumentID) ||
+		(input.ProjectDocumentID != uuid.Nil && input.ProjectDocumentID != pair.ProjectDocumentID) {
+		return ErrDocumentPairMismatch
+	}
+
+	input.CVDocumentID = pair.CVDocumentID
+	input.ProjectDocumentID = pair.ProjectDocumentID
+	return nil
+}
+
+func (s *evaluationService) checkBacklog() error {
+	if s.maxBacklog <= 0 {
+		return nil
+	}
+
+	queued, err := s.evalRepo.CountQueued()
+	if err != nil {
+		return err
+	}
+	if queued < s.maxBacklog {
+		return nil
+	}
+
+	buffered, capacity := s.worker.QueueDepth()
+	appErr := apperror.New(
+		http.StatusServiceUnavailable,
+		apperror.CodeQueueFull,
+		"Evaluation queue is full, try again later",
+	)
+	appErr.Details = map[string]interface{}{
+		"queued":         queued,
+		"max_backlog":    s.maxBacklog,
+		"queue_buffered": buffered,
+		"queue_capacity": capacity,
+	}
+	return appErr
+}
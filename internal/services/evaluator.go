@@ -2,47 +2,133 @@ package services
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
+	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/google/uuid"
 
 	"alfredoptarigan/cv-evaluator/internal/models"
 	"alfredoptarigan/cv-evaluator/internal/repositories"
+	"alfredoptarigan/cv-evaluator/internal/requestid"
+	"alfredoptarigan/cv-evaluator/internal/tenant"
 )
 
 type EvaluatorService interface {
 	EvaluateCandidate(ctx context.Context, evalID uuid.UUID) error
+	// DebugRetrieval returns the top limit matches for queryText the way
+	// evaluations search reference context.
+	DebugRetrieval(ctx context.Context, queryText, docType string, limit int) (*models.RetrievalDebug, error)
+	// DryRun parses the documents, retrieves context and builds the scoring
+	// prompts like an evaluation would, without generating any text.
+	DryRun(ctx context.Context, input DryRunInput) (*models.EvaluationDryRun, error)
+	// Replay parses a failed evaluation's stored LLM responses again and
+	// saves what they yield, completing it if they cover every stage. It
+	// makes no LLM calls.
+	Replay(ctx context.Context, evalID uuid.UUID) (*models.EvaluationReplay, error)
 }
 
 type evaluatorService struct {
 	evalRepo      repositories.EvaluationRepository
 	docRepo       repositories.DocumentRepository
 	geminiService GeminiService
-	qdrantService QdrantService
+	vectorStore   VectorStore
 	pdfParser     PDFParserService
 	promptBuilder *PromptBuilder
-	maxRetries    int
+	retries       StageRetries
+	timeouts      StageTimeouts
+	pricing       TokenPricing
+	budget        TokenBudget
+	retrieval     RetrievalOptions
+	shadow        ShadowOptions
+	generation    StageGeneration
+	vision        VisionOptions
+	// contactExtraction is the CONTACT_EXTRACTION mode for CVs parsed here.
+	contactExtraction string
+	// summaries relays the overall summary while it is generated.
+	summaries SummaryStreams
 }
 
+// VisionOptions sends diagrams from PDF project reports to the model along
+// with their text. MaxImages caps the images per evaluation; 0 disables it.
+type VisionOptions struct {
+	MaxImages int
+}
+
+// maxSummaryPartTokens bounds each map step's input when condensing.
+const maxSummaryPartTokens = 8000
+
+// StageTimeouts bounds the pipeline stages that call out of the process, so
+// a hung dependency can't hold a worker forever. Zero disables a timeout.
+// LLM call timeouts are applied by NewTimeoutGeminiService.
+type StageTimeouts struct {
+	Parse       time.Duration
+	VectorQuery time.Duration
+}
+
+// RetrievalOptions controls how reference context is searched.
+type RetrievalOptions struct {
+	Hybrid bool
+	// Candidates is how many results per document type are fetched when
+	// reranking or diversifying, before contextChunksPerType are kept.
+	Candidates int
+	// MinScore drops results with a lower vector similarity (0 = off).
+	MinScore float32
+	// Reranker, when set, reorders the candidates and drops irrelevant ones.
+	Reranker Reranker
+	// MMRLambda below 1 selects the final chunks by maximal marginal
+	// relevance so near-duplicates don't crowd out other material.
+	MMRLambda float64
+}
+
+// contextChunksPerType is how many chunks of each document type go into a
+// prompt.
+const contextChunksPerType = 3
+
+// Reference document types retrieved for each scoring stage.
+var (
+	cvContextTypes      = []string{"job_description", "cv_rubric"}
+	projectContextTypes = []string{"case_study", "project_rubric"}
+)
+
 func NewEvaluatorService(
 	evalRepo repositories.EvaluationRepository,
 	docRepo repositories.DocumentRepository,
 	geminiService GeminiService,
-	qdrantService QdrantService,
+	vectorStore VectorStore,
 	pdfParser PDFParserService,
-	maxRetries int,
+	retries StageRetries,
+	timeouts StageTimeouts,
+	pricing TokenPricing,
+	budget TokenBudget,
+	retrieval RetrievalOptions,
+	shadow ShadowOptions,
+	generation StageGeneration,
+	vision VisionOptions,
+	contactExtraction string,
+	summaries SummaryStreams,
 ) EvaluatorService {
 	return &evaluatorService{
 		evalRepo:      evalRepo,
 		docRepo:       docRepo,
 		geminiService: geminiService,
-		qdrantService: qdrantService,
+		vectorStore:   vectorStore,
 		pdfParser:     pdfParser,
 		promptBuilder: NewPromptBuilder(),
-		maxRetries:    maxRetries,
+		retries:       retries,
+		timeouts:      timeouts,
+		pricing:       pricing,
+		budget:        budget,
+		retrieval:     retrieval,
+		shadow:        shadow,
+		generation:    generation,
+		vision:        vision,
+		summaries:     summaries,
+
+		contactExtraction: contactExtraction,
 	}
 }
 
@@ -54,6 +140,21 @@ type CVEvaluationResult struct {
 	WeightedAverage      float64 `json:"weighted_average"`
 	MatchRate            float64 `json:"match_rate"`
 	Feedback             string  `json:"feedback"`
+	// RAGContextMissing, Citations and ExperienceTimeline are set by the
+	// pipeline, not the LLM.
+	RAGContextMissing  bool                       `json:"rag_context_missing,omitempty"`
+	Citations          []models.Citation          `json:"citations,omitempty"`
+	ExperienceTimeline *models.ExperienceTimeline `json:"experience_timeline,omitempty"`
+}
+
+// SubScores returns the rubric parameters by name.
+func (r *CVEvaluationResult) SubScores() map[string]float64 {
+	return map[string]float64{
+		"technical_skills": r.TechnicalSkillsScore,
+		"experience_level": r.ExperienceLevelScore,
+		"achievements":     r.AchievementsScore,
+		"cultural_fit":     r.CulturalFitScore,
+	}
 }
 
 type ProjectEvaluationResult struct {
@@ -65,97 +166,112 @@ type ProjectEvaluationResult struct {
 	WeightedAverage    float64 `json:"weighted_average"`
 	ProjectScore       float64 `json:"project_score"`
 	Feedback           string  `json:"feedback"`
+	// RAGContextMissing and Citations are set by the pipeline, not the LLM.
+	RAGContextMissing bool              `json:"rag_context_missing,omitempty"`
+	Citations         []models.Citation `json:"citations,omitempty"`
+}
+
+// SubScores returns the rubric parameters by name.
+func (r *ProjectEvaluationResult) SubScores() map[string]float64 {
+	return map[string]float64{
+		"correctness":   r.CorrectnessScore,
+		"code_quality":  r.CodeQualityScore,
+		"resilience":    r.ResilienceScore,
+		"documentation": r.DocumentationScore,
+		"creativity":    r.CreativityScore,
+	}
 }
 
 func (e *evaluatorService) EvaluateCandidate(ctx context.Context, evalID uuid.UUID) error {
-	// Update status to processing
-	if err := e.evalRepo.UpdateStatus(evalID, models.StatusProcessing); err != nil {
+	// Claim the job so a duplicate dispatch doesn't run it twice
+	claimed, err := e.evalRepo.ClaimForProcessing(evalID)
+	if err != nil {
 		return fmt.Errorf("failed to update status: %w", err)
 	}
+	if !claimed {
+		log.Printf("⏭️  Job %s is no longer queued, skipping\n", evalID)
+		return nil
+	}
 
 	log.Printf("🔄 Starting evaluation for job ID: %s\n", evalID)
 
+	// Count tokens for every LLM call in this run, whatever its outcome
+	ctx, usage := WithTokenUsage(ctx)
+	defer e.saveTokenUsage(evalID, usage)
+
+	// Link the LLM cache entries and batch requests of this run, shadow
+	// included, to the evaluation so erasing it can find them
+	ctx, llmKeys := withLLMKeyLog(ctx)
+	defer e.saveLLMKeys(evalID, llmKeys)
+
 	// Get evaluation details
 	evaluation, err := e.evalRepo.FindByID(evalID)
 	if err != nil {
-		e.evalRepo.UpdateError(evalID, err.Error())
+		e.fail(ctx, evalID, 0, models.StageLoad, err.Error(), err, false)
 		return fmt.Errorf("failed to get evaluation: %w", err)
 	}
 
-	// Get documents
-	cvDoc, err := e.docRepo.FindByID(evaluation.CVDocumentID)
-	if err != nil {
-		e.evalRepo.UpdateError(evalID, fmt.Sprintf("CV document not found: %v", err))
-		return fmt.Errorf("failed to get CV document: %w", err)
+	if evaluation.RequestID != "" {
+		log.Printf("🔗 Job %s was submitted by request %s\n", evalID, evaluation.RequestID)
+		ctx = requestid.WithID(ctx, evaluation.RequestID)
 	}
 
-	projectDoc, err := e.docRepo.FindByID(evaluation.ProjectDocumentID)
-	if err != nil {
-		e.evalRepo.UpdateError(evalID, fmt.Sprintf("Project document not found: %v", err))
-		return fmt.Errorf("failed to get project document: %w", err)
+	if evaluation.BypassCache {
+		ctx = WithLLMCacheBypass(ctx)
 	}
-
-	// Step 1: Parse PDFs
-	log.Println("📄 Parsing CV...")
-	cvContent, err := e.pdfParser.ExtractTextWithMetaData(cvDoc.FilePath)
-	if err != nil {
-		e.evalRepo.UpdateError(evalID, fmt.Sprintf("Failed to parse CV: %v", err))
-		return fmt.Errorf("failed to parse CV: %w", err)
+	if evaluation.Model != "" || evaluation.Temperature != nil {
+		ctx = WithLLMOverrides(ctx, LLMOverrides{Model: evaluation.Model, Temperature: evaluation.Temperature})
 	}
-
-	projectContent, err := e.pdfParser.ExtractTextWithMetaData(projectDoc.FilePath)
-	if err != nil {
-		e.evalRepo.UpdateError(evalID, fmt.Sprintf("Failed to parse project report: %v", err))
-		return fmt.Errorf("failed to parse project report: %w", err)
+	if evaluation.ExecutionMode == models.ExecutionBatch {
+		ctx = WithBatchMode(ctx)
 	}
 
-	// Step 2: Retrieve relevant context from Qdrant (RAG)
-	log.Println("🔍 Retrieving relevant context for CV evaluation...")
-	cvContext, err := e.retrieveContext(ctx, cvContent.Text, []string{"job_description", "cv_rubric"})
-	if err != nil {
-		log.Printf("⚠️  Warning: Failed to retrieve CV context: %v\n", err)
-		cvContext = ""
-	}
+	// Retrieval sees the tenant's own reference chunks next to shared ones,
+	// and those ingested for the job title next to ones for every job
+	ctx = tenant.WithID(ctx, evaluation.TenantID)
+	ctx = WithJob(ctx, JobKey(evaluation.JobTitle))
 
-	log.Println("🔍 Retrieving relevant context for Project evaluation...")
-	projectContext, err := e.retrieveContext(ctx, projectContent.Text, []string{"case_study", "project_rubric"})
-	if err != nil {
-		log.Printf("⚠️  Warning: Failed to retrieve project context: %v\n", err)
-		projectContext = ""
+	// The shadow pipeline, if any, runs alongside; wait for it so the worker
+	// doesn't take on more work than it accounts for
+	if shadowDone := e.startShadow(ctx, evaluation); shadowDone != nil {
+		defer func() { <-shadowDone }()
 	}
 
-	// Step 3: Evaluate CV
-	log.Println("🤖 Evaluating CV with LLM...")
-	cvResult, err := e.evaluateCV(ctx, cvContent.Text, cvContext, evaluation.JobTitle)
-	if err != nil {
-		e.evalRepo.UpdateError(evalID, fmt.Sprintf("Failed to evaluate CV: %v", err))
-		return fmt.Errorf("failed to evaluate CV: %w", err)
-	}
+	// Keep the raw responses so a parsing failure can be replayed
+	ctx = withResponseLog(ctx, evalID, evaluation.Attempts)
 
-	// Step 4: Evaluate Project
-	log.Println("🤖 Evaluating Project Report with LLM...")
-	projectResult, err := e.evaluateProject(ctx, projectContent.Text, projectContext)
-	if err != nil {
-		e.evalRepo.UpdateError(evalID, fmt.Sprintf("Failed to evaluate project: %v", err))
-		return fmt.Errorf("failed to evaluate project: %w", err)
+	// Run the stages without a checkpoint from an earlier attempt
+	run := &evaluationRun{evaluation: evaluation}
+	if err := e.runStages(ctx, run); err != nil {
+		var stageErr *stageError
+		if !errors.As(err, &stageErr) {
+			stageErr = newStageError(models.StageLLM, err, "%v", err)
+		}
+		e.fail(ctx, evalID, evaluation.Attempts, stageErr.stage, stageErr.message, stageErr.err, run.partial())
+		return fmt.Errorf("evaluation stage failed: %w", err)
 	}
+	cvResult, projectResult := run.cvResult, run.projectResult
 
-	// Step 5: Generate Overall Summary
+	// Generate Overall Summary, streaming it to listeners as it forms
 	log.Println("🤖 Generating overall summary...")
-	overallSummary, err := e.generateSummary(ctx, cvResult, projectResult, evaluation.JobTitle)
+	defer e.summaries.Finish(evalID)
+	summaryCtx := WithTextStream(ctx, e.summaries.Stream(evalID))
+	overallSummary, err := e.generateSummary(summaryCtx, cvResult, projectResult, evaluation.JobTitle)
 	if err != nil {
-		e.evalRepo.UpdateError(evalID, fmt.Sprintf("Failed to generate summary: %v", err))
+		e.fail(ctx, evalID, evaluation.Attempts, models.StageLLM, fmt.Sprintf("Failed to generate summary: %v", err), err, true)
 		return fmt.Errorf("failed to generate summary: %w", err)
 	}
 
-	// Step 6: Save results
+	// Save results
 	log.Println("💾 Saving evaluation results...")
+	ragContextMissing := run.ragContextMissing()
 	updateData := &repositories.EvaluationUpdateData{
-		CVMatchRate:     &cvResult.MatchRate,
-		CVFeedback:      &cvResult.Feedback,
-		ProjectScore:    &projectResult.ProjectScore,
-		ProjectFeedback: &projectResult.Feedback,
-		OverallSummary:  &overallSummary,
+		CVMatchRate:       &cvResult.MatchRate,
+		CVFeedback:        &cvResult.Feedback,
+		ProjectScore:      &projectResult.ProjectScore,
+		ProjectFeedback:   &projectResult.Feedback,
+		OverallSummary:    &overallSummary,
+		RAGContextMissing: &ragContextMissing,
 	}
 
 	if err := e.evalRepo.UpdateResult(evalID, updateData); err != nil {
@@ -167,34 +283,188 @@ func (e *evaluatorService) EvaluateCandidate(ctx context.Context, evalID uuid.UU
 }
 
 func (e *evaluatorService) retrieveContext(ctx context.Context, queryText string, docTypes []string) (string, error) {
+	results, err := e.retrieveResults(ctx, queryText, docTypes)
+	if err != nil {
+		return "", err
+	}
+	return FormatRAGContext(results), nil
+}
+
+// retrieveResults returns the chunks of each document type that go into a
+// prompt.
+func (e *evaluatorService) retrieveResults(ctx context.Context, queryText string, docTypes []string) ([]SearchResult, error) {
 	// Generate embedding for query
 	embedding, err := e.geminiService.GenerateEmbedding(ctx, queryText)
 	if err != nil {
-		return "", fmt.Errorf("failed to generate query embedding: %w", err)
+		return nil, fmt.Errorf("failed to generate query embedding: %w", err)
+	}
+
+	limit := contextChunksPerType
+	selective := e.retrieval.Reranker != nil || e.retrieval.MMRLambda < 1
+	if selective && e.retrieval.Candidates > limit {
+		limit = e.retrieval.Candidates
 	}
 
 	// Search for each doc type
 	var allResults []SearchResult
 	for _, docType := range docTypes {
-		results, err := e.qdrantService.SearchSimilar(ctx, embedding, docType, 3)
+		results, err := e.search(ctx, embedding, queryText, docType, limit)
 		if err != nil {
 			log.Printf("⚠️  Failed to search for %s: %v\n", docType, err)
 			continue
 		}
-		allResults = append(allResults, results...)
+		allResults = append(allResults, e.selectContext(ctx, queryText, results)...)
+	}
+
+	return allResults, nil
+}
+
+// DebugRetrieval implements EvaluatorService.
+func (e *evaluatorService) DebugRetrieval(ctx context.Context, queryText, docType string, limit int) (*models.RetrievalDebug, error) {
+	embedding, err := e.geminiService.GenerateEmbedding(ctx, queryText)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate query embedding: %w", err)
+	}
+
+	results, err := e.search(ctx, embedding, queryText, docType, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search: %w", err)
+	}
+
+	selected := make(map[string]bool)
+	for _, r := range e.selectContext(ctx, queryText, results) {
+		selected[r.ID+"\x00"+r.Text] = true
+	}
+
+	debug := &models.RetrievalDebug{
+		Query:          queryText,
+		DocType:        docType,
+		EmbeddingModel: e.geminiService.EmbeddingModel(),
+		Hybrid:         e.retrieval.Hybrid,
+		MinScore:       e.retrieval.MinScore,
+		Matches:        make([]models.RetrievalMatch, 0, len(results)),
+	}
+	for _, r := range results {
+		debug.Matches = append(debug.Matches, models.RetrievalMatch{
+			ID:       r.ID,
+			Score:    r.Score,
+			Text:     r.Text,
+			DocType:  r.DocType,
+			Source:   r.Source,
+			Section:  r.Section,
+			Page:     r.Page,
+			Metadata: r.Metadata,
+			Selected: selected[r.ID+"\x00"+r.Text],
+		})
+	}
+
+	return debug, nil
+}
+
+// search runs the configured vector or hybrid search for one document type
+// under the vector query retry policy. Each attempt gets the full timeout.
+func (e *evaluatorService) search(ctx context.Context, embedding []float32, queryText, docType string, limit int) ([]SearchResult, error) {
+	var results []SearchResult
+	err := e.retries.VectorQuery.do(ctx, "Vector search", func() error {
+		ctx, cancel := withStageTimeout(ctx, e.timeouts.VectorQuery)
+		defer cancel()
+
+		var err error
+		if e.retrieval.Hybrid {
+			results, err = e.vectorStore.HybridSearch(ctx, embedding, queryText, docType, limit)
+		} else {
+			results, err = e.vectorStore.SearchSimilar(ctx, embedding, docType, limit)
+		}
+		return err
+	})
+	return results, err
+}
+
+// selectContext picks the chunks of one document type that go into a prompt.
+func (e *evaluatorService) selectContext(ctx context.Context, queryText string, results []SearchResult) []SearchResult {
+	results = filterByScore(results, e.retrieval.MinScore)
+	results = e.rerank(ctx, queryText, results)
+	return diversify(results, contextChunksPerType, e.retrieval.MMRLambda)
+}
+
+// rerank applies the configured reranker, falling back to the search order
+// when reranking fails. Like condense, it never goes through the batch API.
+func (e *evaluatorService) rerank(ctx context.Context, queryText string, results []SearchResult) []SearchResult {
+	if e.retrieval.Reranker == nil {
+		return results
 	}
+	if dryRun := dryRunFrom(ctx); dryRun != nil {
+		if len(results) > 0 {
+			dryRun.note("Reranking was skipped; a real run reorders the context with an LLM call.")
+		}
+		return results
+	}
+
+	reranked, err := e.retrieval.Reranker.Rerank(withoutBatchMode(ctx), queryText, results, 0)
+	if err != nil {
+		log.Printf("⚠️  Reranking failed, using search order: %v\n", err)
+		return results
+	}
+
+	return reranked
+}
+
+// cvPrompt builds the CV scoring prompt, condensing or cutting the CV and
+// context down to the token budget. The timeline, read from the whole CV,
+// follows it.
+func (e *evaluatorService) cvPrompt(ctx context.Context, cvText string, timeline *models.ExperienceTimeline, context, jobTitle string) string {
+	timelineNote := e.promptBuilder.BuildExperienceTimelineNote(timeline, experienceLevelOf(timeline))
 
-	return FormatRAGContext(allResults), nil
+	instructions := e.promptBuilder.BuildCVEvaluationPrompt("", "", "", jobTitle) + timelineNote
+	cvText = e.condense(ctx, "CV", cvText, e.budget.DocumentAllowance(instructions, context))
+	cvText, context = e.budget.Fit(instructions, cvText, context, cvPrioritySections)
+	return e.promptBuilder.BuildCVEvaluationPrompt(cvText, context, "", jobTitle) + timelineNote
+}
+
+// projectPrompt builds the project scoring prompt like cvPrompt. With more
+// than one file, each is condensed to its share of the allowance. The
+// allowance also makes room for the attached images.
+func (e *evaluatorService) projectPrompt(ctx context.Context, files []projectFile, images []projectImage, context string) string {
+	labels := make([]string, len(images))
+	for i, img := range images {
+		labels[i] = img.Label
+	}
+	diagramsNote := e.promptBuilder.BuildDiagramsNote(labels)
+
+	instructions := e.promptBuilder.BuildProjectEvaluationPrompt("", "", "") + diagramsNote
+	allowance := e.budget.DocumentAllowance(instructions, context)
+	if len(images) > 0 && allowance > 0 {
+		allowance = max(allowance-len(images)*imageTokens, 1)
+	}
+
+	sizes := make([]int, len(files))
+	for i, f := range files {
+		sizes[i] = EstimateTokens(f.Text)
+	}
+	if len(files) > 1 && allowance > 0 {
+		// Leave room for the file headers
+		allowance = max(allowance-EstimateTokens(joinProjectFiles(make([]projectFile, len(files)))), 1)
+	}
+	shares := SplitAllowance(sizes, allowance)
+
+	condensed := make([]projectFile, len(files))
+	for i, f := range files {
+		condensed[i] = projectFile{Name: f.Name, Text: e.condense(ctx, "project report", f.Text, shares[i])}
+	}
+
+	projectText, context := e.budget.Fit(instructions, joinProjectFiles(condensed), context, nil)
+	return e.promptBuilder.BuildProjectEvaluationPrompt(projectText, context, "") + diagramsNote
 }
 
 func (e *evaluatorService) evaluateCV(ctx context.Context, cvText, context, jobTitle string) (*CVEvaluationResult, error) {
-	prompt := e.promptBuilder.BuildCVEvaluationPrompt(cvText, context, "", jobTitle)
+	timeline := ExtractExperienceTimeline(cvText, time.Now())
+	prompt := e.cvPrompt(ctx, cvText, timeline, context, jobTitle)
 
 	// Log prompt length for debugging
-	log.Printf("📝 CV Evaluation prompt length: %d characters", len(prompt))
+	log.Printf("📝 CV Evaluation prompt length: %d characters (~%d tokens)", len(prompt), EstimateTokens(prompt))
 
 	// Generate with retry
-	response, err := e.geminiService.GenerateTextWithRetry(ctx, prompt, 0.3, e.maxRetries)
+	response, err := e.generate(WithGenerationParams(ctx, e.generation.Scoring), prompt, temperatureFor(ctx, 0.3))
 	if err != nil {
 		log.Printf("❌ CV Evaluation failed: %v", err)
 		return nil, fmt.Errorf("failed to generate CV evaluation: %w", err)
@@ -202,20 +472,24 @@ func (e *evaluatorService) evaluateCV(ctx context.Context, cvText, context, jobT
 
 	// Log response for debugging
 	log.Printf("✅ CV Evaluation response received: %d characters", len(response))
+	e.saveResponse(ctx, models.ResponseCVResult, response)
+
+	result, err := e.parseCVResult(response, timeline)
+	if err != nil {
+		if response, err = e.correctResponse(ctx, models.ResponseCVResult, prompt, response, err, CVEvaluationResult{}); err != nil {
+			return nil, err
+		}
+		return e.parseCVResult(response, timeline)
+	}
+	return result, nil
+}
 
-	// Check for empty response
-	if response == "" {
+// parseCVResult turns a CV scoring response into its result, validates it
+// and checks it against the CV's timeline.
+func (e *evaluatorService) parseCVResult(response string, timeline *models.ExperienceTimeline) (*CVEvaluationResult, error) {
+	if strings.TrimSpace(response) == "" {
 		log.Println("⚠️ Empty response received from Gemini API")
-		// Fallback to default evaluation result with error message
-		return &CVEvaluationResult{
-			TechnicalSkillsScore: 0,
-			ExperienceLevelScore: 0,
-			AchievementsScore:    0,
-			CulturalFitScore:     0,
-			WeightedAverage:      0,
-			MatchRate:            0,
-			Feedback:             "Failed to evaluate CV due to API response issues. Please try again later.",
-		}, nil
+		return nil, fmt.Errorf("failed to parse CV evaluation response: %w", errEmptyResponse)
 	}
 
 	// Parse JSON response
@@ -224,18 +498,30 @@ func (e *evaluatorService) evaluateCV(ctx context.Context, cvText, context, jobT
 		log.Printf("❌ Failed to parse CV evaluation response: %v", err)
 		return nil, fmt.Errorf("failed to parse CV evaluation response: %w", err)
 	}
+	if err := result.validate(); err != nil {
+		log.Printf("❌ %v", err)
+		return nil, err
+	}
+
+	result.applyTimeline(timeline)
 
 	return &result, nil
 }
 
-func (e *evaluatorService) evaluateProject(ctx context.Context, projectText, context string) (*ProjectEvaluationResult, error) {
-	prompt := e.promptBuilder.BuildProjectEvaluationPrompt(projectText, context, "")
+func (e *evaluatorService) evaluateProject(ctx context.Context, files []projectFile, context string) (*ProjectEvaluationResult, error) {
+	images := e.projectImages(files)
+	prompt := e.projectPrompt(ctx, files, images, context)
 
 	// Log prompt length for debugging
-	log.Printf("📝 Project Evaluation prompt length: %d characters", len(prompt))
+	log.Printf("📝 Project Evaluation prompt length: %d characters (~%d tokens)", len(prompt), EstimateTokens(prompt))
+
+	promptImages := make([]PromptImage, len(images))
+	for i, img := range images {
+		promptImages[i] = img.PromptImage
+	}
 
 	// Generate with retry
-	response, err := e.geminiService.GenerateTextWithRetry(ctx, prompt, 0.3, e.maxRetries)
+	response, err := e.generate(WithPromptImages(WithGenerationParams(ctx, e.generation.Scoring), promptImages), prompt, temperatureFor(ctx, 0.3))
 	if err != nil {
 		log.Printf("❌ Project Evaluation failed: %v", err)
 		return nil, fmt.Errorf("failed to generate project evaluation: %w", err)
@@ -243,21 +529,24 @@ func (e *evaluatorService) evaluateProject(ctx context.Context, projectText, con
 
 	// Log response for debugging
 	log.Printf("✅ Project Evaluation response received: %d characters", len(response))
+	e.saveResponse(ctx, models.ResponseProjectResult, response)
+
+	result, err := e.parseProjectResult(response)
+	if err != nil {
+		if response, err = e.correctResponse(ctx, models.ResponseProjectResult, prompt, response, err, ProjectEvaluationResult{}); err != nil {
+			return nil, err
+		}
+		return e.parseProjectResult(response)
+	}
+	return result, nil
+}
 
-	// Check for empty response
-	if response == "" {
+// parseProjectResult turns a project scoring response into its result and
+// validates it.
+func (e *evaluatorService) parseProjectResult(response string) (*ProjectEvaluationResult, error) {
+	if strings.TrimSpace(response) == "" {
 		log.Println("⚠️ Empty response received from Gemini API")
-		// Fallback to default evaluation result with error message
-		return &ProjectEvaluationResult{
-			CorrectnessScore:   0,
-			CodeQualityScore:   0,
-			ResilienceScore:    0,
-			DocumentationScore: 0,
-			CreativityScore:    0,
-			WeightedAverage:    0,
-			ProjectScore:       0,
-			Feedback:           "Failed to evaluate project due to API response issues. Please try again later.",
-		}, nil
+		return nil, fmt.Errorf("failed to parse project evaluation response: %w", errEmptyResponse)
 	}
 
 	// Parse JSON response
@@ -266,6 +555,10 @@ func (e *evaluatorService) evaluateProject(ctx context.Context, projectText, con
 		log.Printf("❌ Failed to parse project evaluation response: %v", err)
 		return nil, fmt.Errorf("failed to parse project evaluation response: %w", err)
 	}
+	if err := result.validate(); err != nil {
+		log.Printf("❌ %v", err)
+		return nil, err
+	}
 
 	return &result, nil
 }
@@ -280,45 +573,304 @@ func (e *evaluatorService) generateSummary(ctx context.Context, cvResult *CVEval
 	)
 
 	// Generate with retry
-	summary, err := e.geminiService.GenerateTextWithRetry(ctx, prompt, 0.5, e.maxRetries)
+	ctx = WithGenerationParams(ctx, e.generation.Summary)
+	summary, err := e.generate(ctx, prompt, temperatureFor(ctx, 0.5))
 	if err != nil {
 		return "", fmt.Errorf("failed to generate summary: %w", err)
 	}
+	e.saveResponse(ctx, models.ResponseSummary, summary)
+
+	if strings.TrimSpace(summary) == "" {
+		log.Println("⚠️ Empty summary received from Gemini API, asking again")
+		if summary, err = e.regenerate(ctx, models.ResponseSummary, prompt, temperatureFor(ctx, 0.5)); err != nil {
+			return "", fmt.Errorf("failed to generate summary: %w", err)
+		}
+		if strings.TrimSpace(summary) == "" {
+			return "", fmt.Errorf("failed to generate summary: %w", errEmptyResponse)
+		}
+	}
 
 	return strings.TrimSpace(summary), nil
 }
 
 func (e *evaluatorService) parseJSONResponse(response string, target interface{}) error {
-	// Try to extract JSON from response (LLM might wrap it in markdown)
-	jsonStr := extractJSON(response)
-
-	if err := json.Unmarshal([]byte(jsonStr), target); err != nil {
+	if err := decodeJSON(response, target); err != nil {
 		return fmt.Errorf("failed to unmarshal JSON: %w\nResponse: %s", err, response)
 	}
 
 	return nil
 }
 
-// extractJSON tries to extract JSON from text that might contain markdown or other formatting
-func extractJSON(text string) string {
-	// Remove markdown code blocks
-	text = strings.ReplaceAll(text, "```json", "")
-	text = strings.ReplaceAll(text, "```", "")
+// loadText returns the parsed text of a document for a pipeline stage.
+func (e *evaluatorService) loadText(ctx context.Context, docID uuid.UUID, label string) (string, error) {
+	doc, err := e.docRepo.FindByID(docID)
+	if err != nil {
+		return "", newStageError(models.StageLoad, err, "%s document not found: %v", label, err)
+	}
+
+	content, err := e.documentContent(ctx, doc)
+	if err != nil {
+		return "", newStageError(models.StageParse, err, "Failed to parse %s: %v", label, err)
+	}
+
+	return content.Text, nil
+}
+
+// projectFile is one parsed document of a project submission.
+type projectFile struct {
+	Name string `json:"name"`
+	Text string `json:"text"`
+	// Path is the stored file, for extracting its images.
+	Path string `json:"path,omitempty"`
+}
+
+// projectImage is an image from a project file, labelled for the prompt.
+type projectImage struct {
+	PromptImage
+	Label string
+}
+
+// projectImages extracts up to VisionOptions.MaxImages images from the PDF
+// files, in file order. A file whose images can't be read is evaluated on
+// its text alone.
+func (e *evaluatorService) projectImages(files []projectFile) []projectImage {
+	var images []projectImage
+	for _, f := range files {
+		remaining := e.vision.MaxImages - len(images)
+		if remaining <= 0 {
+			break
+		}
+		if f.Path == "" || FileTypeForExtension(filepath.Ext(f.Path)) != FileTypePDF {
+			continue
+		}
+
+		extracted, err := ExtractPDFImages(f.Path, remaining)
+		if err != nil {
+			log.Printf("⚠️  Failed to extract images from %s: %v\n", f.Name, err)
+			continue
+		}
+		for _, img := range extracted {
+			images = append(images, projectImage{
+				PromptImage: img.PromptImage,
+				Label:       fmt.Sprintf("page %d of %s", img.Page, f.Name),
+			})
+		}
+	}
+
+	if len(images) > 0 {
+		log.Printf("🖼️  Attaching %d image(s) from the project report\n", len(images))
+	}
+	return images
+}
+
+// loadProjectFiles loads the project report followed by the additional
+// project documents.
+func (e *evaluatorService) loadProjectFiles(ctx context.Context, projectDocID uuid.UUID, additional []uuid.UUID) ([]projectFile, error) {
+	files := make([]projectFile, 0, 1+len(additional))
+	for _, docID := range append([]uuid.UUID{projectDocID}, additional...) {
+		doc, err := e.docRepo.FindByID(docID)
+		if err != nil {
+			return nil, newStageError(models.StageLoad, err, "project report document not found: %v", err)
+		}
+
+		content, err := e.documentContent(ctx, doc)
+		if err != nil {
+			return nil, newStageError(models.StageParse, err, "Failed to parse %s: %v", doc.OriginalName, err)
+		}
+		files = append(files, projectFile{Name: doc.OriginalName, Text: content.Text, Path: doc.FilePath})
+	}
+
+	return files, nil
+}
+
+// joinProjectFiles concatenates the files of a project, headed by their
+// names when there is more than one.
+func joinProjectFiles(files []projectFile) string {
+	if len(files) == 1 {
+		return files[0].Text
+	}
+
+	parts := make([]string, len(files))
+	for i, f := range files {
+		parts[i] = fmt.Sprintf("=== File %d of %d: %s ===\n%s", i+1, len(files), f.Name, f.Text)
+	}
+	return strings.Join(parts, "\n\n")
+}
+
+// documentContent returns the document's stored text, falling back to parsing
+// the file and persisting the result for later runs.
+func (e *evaluatorService) documentContent(ctx context.Context, doc *models.Document) (*PDFContent, error) {
+	if doc.ParsedText != "" {
+		return &PDFContent{
+			Text:      doc.ParsedText,
+			PageCount: doc.PageCount,
+			FilePath:  doc.FilePath,
+		}, nil
+	}
+
+	log.Printf("📄 Parsing %s...\n", doc.OriginalName)
+	content, err := e.parse(ctx, doc.FilePath)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := e.docRepo.UpdateParsedContent(doc.ID, content.Text, content.PageCount); err != nil {
+		log.Printf("⚠️  Failed to store parsed text for document %s: %v\n", doc.ID, err)
+	}
+
+	extractCandidate(e.contactExtraction, doc, content.Text)
+	if doc.Candidate() != nil {
+		if err := e.docRepo.UpdateCandidate(doc); err != nil {
+			log.Printf("⚠️  Failed to store candidate of document %s: %v\n", doc.ID, err)
+		}
+	}
+
+	return content, nil
+}
+
+// recordError adds a failure to the evaluation's error history.
+func (e *evaluatorService) recordError(evalID uuid.UUID, attempt int, stage models.EvaluationStage, message string) {
+	if err := e.evalRepo.RecordError(evalID, attempt, stage, message); err != nil {
+		log.Printf("⚠️  Failed to record error for job %s: %v\n", evalID, err)
+	}
+}
+
+// fail records the error and marks the evaluation failed, or partially
+// completed when some stages finished, unless the failure came from an open
+// circuit breaker: then it goes back to the queue to be picked up again once
+// the dependency recovers. A batch evaluation waiting for its batch job
+// isn't failing and is rescheduled for when the job may be done. A run cut
+// short by the worker shutting down stays processing for it to requeue.
+func (e *evaluatorService) fail(ctx context.Context, evalID uuid.UUID, attempt int, stage models.EvaluationStage, message string, err error, partial bool) {
+	if errors.Is(err, ErrBatchPending) {
+		runAt := time.Now().Add(batchRetryAfter(err))
+		log.Printf("📦 Job %s waits for its batch job until %s\n", evalID, runAt.Format(time.RFC3339))
+		if err := e.evalRepo.Reschedule(evalID, runAt, models.EventBatchPending, message); err != nil {
+			log.Printf("⚠️  Failed to reschedule job %s: %v\n", evalID, err)
+		}
+		return
+	}
+
+	if ctx.Err() != nil {
+		log.Printf("⏹️  Job %s interrupted: %v\n", evalID, err)
+		return
+	}
+
+	e.recordError(evalID, attempt, stage, message)
+
+	if errors.Is(err, ErrCircuitOpen) {
+		log.Printf("🅿️  Parking job %s until the dependency recovers\n", evalID)
+		if err := e.evalRepo.UpdateStatus(evalID, models.StatusQueued); err != nil {
+			log.Printf("⚠️  Failed to park job %s: %v\n", evalID, err)
+		}
+		return
+	}
+
+	markFailed := e.evalRepo.UpdateError
+	if partial {
+		markFailed = e.evalRepo.UpdatePartialError
+	}
+	if err := markFailed(evalID, message); err != nil {
+		log.Printf("⚠️  Failed to mark job %s failed: %v\n", evalID, err)
+	}
+}
+
+// parse runs the PDF parser under the parse retry policy.
+func (e *evaluatorService) parse(ctx context.Context, filePath string) (*PDFContent, error) {
+	var content *PDFContent
+	err := e.retries.Parse.do(ctx, "Parsing", func() error {
+		var err error
+		content, err = e.parseOnce(ctx, filePath)
+		return err
+	})
+	return content, err
+}
 
-	// Find JSON object or array boundaries
-	startObj := strings.Index(text, "{")
-	startArr := strings.Index(text, "[")
-	endObj := strings.LastIndex(text, "}")
-	endArr := strings.LastIndex(text, "]")
+// parseOnce runs the PDF parser under the parse timeout. The parser can't be
+// interrupted, so on timeout it is abandoned and finishes in the background.
+func (e *evaluatorService) parseOnce(ctx context.Context, filePath string) (*PDFContent, error) {
+	ctx, cancel := withStageTimeout(ctx, e.timeouts.Parse)
+	defer cancel()
 
-	// Determine if we have an object or array
-	if startObj != -1 && endObj != -1 && endObj > startObj {
-		// We have a JSON object
-		return text[startObj : endObj+1]
-	} else if startArr != -1 && endArr != -1 && endArr > startArr {
-		// We have a JSON array
-		return text[startArr : endArr+1]
+	type parseResult struct {
+		content *PDFContent
+		err     error
+	}
+	done := make(chan parseResult, 1)
+	go func() {
+		content, err := e.pdfParser.ExtractTextWithMetaData(filePath)
+		done <- parseResult{content: content, err: err}
+	}()
+
+	select {
+	case r := <-done:
+		return r.content, r.err
+	case <-ctx.Done():
+		return nil, fmt.Errorf("parsing %s: %w", filePath, ctx.Err())
+	}
+}
+
+func withStageTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+func (e *evaluatorService) saveLLMKeys(evalID uuid.UUID, keys *llmKeyLog) {
+	if err := e.evalRepo.SaveLLMKeys(evalID, keys.list()); err != nil {
+		log.Printf("⚠️  Failed to link LLM keys to job %s: %v\n", evalID, err)
+	}
+}
+
+func (e *evaluatorService) saveTokenUsage(evalID uuid.UUID, usage *TokenUsage) {
+	promptTokens, completionTokens := usage.Totals()
+	if promptTokens == 0 && completionTokens == 0 {
+		return
+	}
+
+	cost := e.pricing.Cost(promptTokens, completionTokens)
+	log.Printf("🧮 Job %s used %d prompt / %d completion tokens (~$%.4f)\n", evalID, promptTokens, completionTokens, cost)
+
+	if err := e.evalRepo.AddTokenUsage(evalID, promptTokens, completionTokens, cost); err != nil {
+		log.Printf("⚠️  Failed to save token usage for job %s: %v\n", evalID, err)
+	}
+}
+
+// condense map-reduces a document that is longer than allowance tokens:
+// each part is summarized separately (map) and the joined summaries are
+// what gets evaluated (reduce). If summarizing fails the original text is
+// returned and the budget truncates it instead. Summaries of batch
+// evaluations are generated synchronously so the prompt doesn't change
+// between attempts.
+func (e *evaluatorService) condense(ctx context.Context, kind, text string, allowance int) string {
+	if allowance <= 0 || EstimateTokens(text) <= allowance {
+		return text
+	}
+	ctx = withoutBatchMode(ctx)
+
+	parts := SplitByTokens(text, min(allowance, maxSummaryPartTokens))
+	// Leave each summary an equal share of the allowance (~0.75 words/token)
+	maxWords := max(allowance*3/4/len(parts), 50)
+
+	if dryRun := dryRunFrom(ctx); dryRun != nil {
+		dryRun.note(fmt.Sprintf("The %s is ~%d tokens, over its allowance of %d; a real run summarizes it in %d parts with an LLM call each, this prompt has it cut down instead.",
+			kind, EstimateTokens(text), allowance, len(parts)))
+		return text
+	}
+
+	log.Printf("🗜️  %s is ~%d tokens (allowance %d), summarizing %d parts\n", kind, EstimateTokens(text), allowance, len(parts))
+
+	summaries := make([]string, 0, len(parts))
+	for i, part := range parts {
+		prompt := e.promptBuilder.BuildSectionSummaryPrompt(kind, part, i+1, len(parts), maxWords)
+		summary, err := e.generate(ctx, prompt, 0.2)
+		if err != nil {
+			log.Printf("⚠️  Failed to summarize %s part %d: %v\n", kind, i+1, err)
+			return text
+		}
+		summaries = append(summaries, fmt.Sprintf("[Part %d of %d]\n%s", i+1, len(parts), strings.TrimSpace(summary)))
 	}
 
-	return text
+	return strings.Join(summaries, "\n\n")
 }
@@ -0,0 +1,373 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"alfredoptarigan/cv-evaluator/internal/models"
+)
+
+// experienceSections are the CV sections the work history is read from.
+// CVs without any are read whole, except for nonExperienceSections, whose
+// dates are study and courses rather than work.
+var (
+	experienceSections    = []string{"experience", "employment", "work history", "career", "pengalaman"}
+	nonExperienceSections = []string{"education", "certification", "course", "training", "award", "publication", "pendidikan"}
+)
+
+const (
+	minTimelineGapMonths     = 6
+	minTimelineOverlapMonths = 2
+	// yearOnlySlack is how much more two periods must overlap to be flagged
+	// when the dates between them are years only, as "2020 - 2022" and
+	// "2022 - 2024" are a job change during 2022 rather than a year of both.
+	yearOnlySlack = 11
+)
+
+const (
+	monthNamePattern = `jan(?:uary|uari)?|feb(?:ruary|ruari)?|mar(?:ch|et)?|apr(?:il)?|may|mei|june?|juni|july?|juli|aug(?:ust)?|agu(?:stus)?|sept?(?:ember)?|oct(?:ober)?|okt(?:ober)?|nov(?:ember)?|dec(?:ember)?|des(?:ember)?`
+	yearPattern      = `(?:19|20)\d{2}`
+	// dateTokenPattern is "Mar 2021", "03/2021", "2021-03" or "2021".
+	dateTokenPattern = `(?:\b(?:` + monthNamePattern + `)\.?\s+` + yearPattern + `|\b\d{1,2}[/.]` + yearPattern + `|\b` + yearPattern + `[-/.](?:0?[1-9]|1[0-2])\b|\b` + yearPattern + `)\b`
+)
+
+var (
+	dateRange = regexp.MustCompile(`(?i)(` + dateTokenPattern + `)\s*(?:-|–|—|to|until|till|s/d|sampai)\s*(` + dateTokenPattern + `|present|current|now|today|sekarang|saat ini)`)
+
+	monthYearToken = regexp.MustCompile(`(?i)^([a-z]+)\.?\s+(` + yearPattern + `)$`)
+	numericToken   = regexp.MustCompile(`^(\d{1,2})[/.](` + yearPattern + `)$`)
+	isoToken       = regexp.MustCompile(`^(` + yearPattern + `)[-/.](\d{1,2})$`)
+	yearToken      = regexp.MustCompile(`^(` + yearPattern + `)$`)
+
+	// golangMention is always the language. A capitalized "Go" only is
+	// next to other technical terms on its line (goContext), and never as
+	// part of a compound such as "Go-live" or "Go-to-market".
+	golangMention = regexp.MustCompile(`(?i)\bgolang\b`)
+	goWord        = regexp.MustCompile(`\bGo\b`)
+	goContext     = regexp.MustCompile(`(?i)\b(?:golang|python|java|javascript|typescript|rust|ruby|php|kotlin|scala|node(?:\.?js)?|kubernetes|k8s|docker|grpc|graphql|rest(?:ful)?|apis?|microservices?|backend|back-end|postgres(?:ql)?|mysql|mongodb|redis|kafka|rabbitmq|sql|gin|echo|fiber|gorm|goroutines?|concurrency|programming|language|developer|engineer(?:ing)?|tech stack)\b|\bGo\s+1\.\d+`)
+)
+
+// monthNumbers maps the first three letters of English and Indonesian month
+// names to their number.
+var monthNumbers = map[string]int{
+	"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "mei": 5, "jun": 6,
+	"jul": 7, "aug": 8, "agu": 8, "sep": 9, "oct": 10, "okt": 10, "nov": 11,
+	"dec": 12, "des": 12,
+}
+
+// monthSpan is an inclusive range of months counted from year 0.
+// roughStart and roughEnd mark dates given as years only.
+type monthSpan struct {
+	start, end           int
+	roughStart, roughEnd bool
+}
+
+func (s monthSpan) months() int {
+	return s.end - s.start + 1
+}
+
+// ExtractExperienceTimeline reads the dated entries of a CV's work history
+// into a timeline; now is the month "present" ends in. It returns nil when
+// no dates were found. Year-only dates count from January to December.
+func ExtractExperienceTimeline(text string, now time.Time) *models.ExperienceTimeline {
+	current := now.Year()*12 + int(now.Month()) - 1
+
+	var periods []models.ExperiencePeriod
+	var spans, goSpans []monthSpan
+	for _, entry := range experienceEntries(text) {
+		span, isCurrent, ok := parseDateRange(entry.start, entry.end, current)
+		if !ok {
+			continue
+		}
+
+		period := models.ExperiencePeriod{
+			Title:   entry.title,
+			Start:   formatMonth(span.start),
+			End:     formatMonth(span.end),
+			Current: isCurrent,
+			Months:  span.months(),
+			Go:      countGoMentions(entry.text) > 0,
+		}
+		periods = append(periods, period)
+		spans = append(spans, span)
+		if period.Go {
+			goSpans = append(goSpans, span)
+		}
+	}
+	if len(periods) == 0 {
+		return nil
+	}
+
+	merged := mergeSpans(spans)
+	timeline := &models.ExperienceTimeline{
+		Periods:    periods,
+		TotalYears: spanYears(merged),
+		GoYears:    spanYears(mergeSpans(goSpans)),
+	}
+	// A single "Go" in a technical line may still be a slip, so inferring
+	// Go years takes "Golang" or Go named more than once
+	if len(goSpans) == 0 && (golangMention.MatchString(text) || countGoMentions(text) > 1) {
+		timeline.GoYears = timeline.TotalYears
+		timeline.GoInferred = true
+	}
+
+	for i := 1; i < len(merged); i++ {
+		gap := monthSpan{start: merged[i-1].end + 1, end: merged[i].start - 1}
+		if gap.months() >= minTimelineGapMonths {
+			timeline.Gaps = append(timeline.Gaps, timelineInterval(gap))
+		}
+	}
+
+	sorted := append([]monthSpan(nil), spans...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].start < sorted[j].start })
+	for i := range sorted {
+		for j := i + 1; j < len(sorted) && sorted[j].start <= sorted[i].end; j++ {
+			overlap := monthSpan{start: sorted[j].start, end: min(sorted[i].end, sorted[j].end)}
+			threshold := minTimelineOverlapMonths
+			if sorted[i].roughEnd || sorted[j].roughStart {
+				threshold += yearOnlySlack
+			}
+			if overlap.months() >= threshold {
+				timeline.Overlaps = append(timeline.Overlaps, timelineInterval(overlap))
+			}
+		}
+	}
+
+	return timeline
+}
+
+// countGoMentions counts the mentions of the Go language in text: every
+// "Golang", and every capitalized "Go" on a line with other technical terms
+// that isn't the start of a compound.
+func countGoMentions(text string) int {
+	count := 0
+	for _, line := range strings.Split(text, "\n") {
+		count += len(golangMention.FindAllStringIndex(line, -1))
+		if !goContext.MatchString(line) {
+			continue
+		}
+		for _, loc := range goWord.FindAllStringIndex(line, -1) {
+			if rest := line[loc[1]:]; strings.HasPrefix(rest, "-") || strings.HasPrefix(rest, "–") || strings.HasPrefix(rest, "'") {
+				continue
+			}
+			count++
+		}
+	}
+	return count
+}
+
+// experienceEntry is a dated line of the work history and the lines under
+// it, up to the next dated line.
+type experienceEntry struct {
+	title      string
+	start, end string
+	text       string
+}
+
+func experienceEntries(text string) []experienceEntry {
+	sections := splitSections(pageMarker.ReplaceAllString(text, ""))
+	var selected []textSection
+	for _, section := range sections {
+		if section.matches(experienceSections) {
+			selected = append(selected, section)
+		}
+	}
+	if len(selected) == 0 {
+		for _, section := range sections {
+			if !section.matches(nonExperienceSections) {
+				selected = append(selected, section)
+			}
+		}
+	}
+
+	var entries []experienceEntry
+	var body strings.Builder
+	flush := func() {
+		if len(entries) > 0 {
+			entries[len(entries)-1].text = body.String()
+		}
+		body.Reset()
+	}
+	for _, section := range selected {
+		previous := ""
+		for _, line := range strings.Split(section.text, "\n") {
+			line = strings.TrimSpace(line)
+			if loc := dateRange.FindStringSubmatchIndex(line); loc != nil {
+				flush()
+				// A date on a line of its own belongs to the title above it
+				title := strings.Trim(line[:loc[0]]+" "+line[loc[1]:], " ,|-–—()[]")
+				if title == "" {
+					title = previous
+				}
+				entries = append(entries, experienceEntry{
+					title: strings.Join(strings.Fields(title), " "),
+					start: line[loc[2]:loc[3]],
+					end:   line[loc[4]:loc[5]],
+				})
+			} else if line != "" && !isSectionHeader(line) {
+				previous = line
+			}
+			body.WriteString(line)
+			body.WriteString("\n")
+		}
+		flush()
+	}
+
+	return entries
+}
+
+// parseDateRange returns the months a range covers, ending no later than
+// current, and whether it runs to the present. Ranges that end before they
+// start or start in the future aren't ok.
+func parseDateRange(start, end string, current int) (monthSpan, bool, bool) {
+	from, ok := parseMonth(start, false)
+	if !ok || from > current {
+		return monthSpan{}, false, false
+	}
+
+	isCurrent := false
+	to, ok := parseMonth(end, true)
+	if ok {
+		if to < from {
+			return monthSpan{}, false, false
+		}
+	} else {
+		switch strings.ToLower(strings.TrimSpace(end)) {
+		case "present", "current", "now", "today", "sekarang", "saat ini":
+			to, isCurrent = current, true
+		default:
+			return monthSpan{}, false, false
+		}
+	}
+
+	span := monthSpan{
+		start:      from,
+		end:        min(to, current),
+		roughStart: yearToken.MatchString(strings.TrimSpace(start)),
+		roughEnd:   yearToken.MatchString(strings.TrimSpace(end)),
+	}
+	return span, isCurrent, true
+}
+
+// parseMonth reads a date token as a month count; a bare year is its
+// first month at the start of a range and its last one at the end.
+func parseMonth(token string, isEnd bool) (int, bool) {
+	token = strings.TrimSpace(token)
+
+	var year, month int
+	if m := monthYearToken.FindStringSubmatch(token); m != nil {
+		name := strings.ToLower(m[1])
+		if len(name) < 3 || monthNumbers[name[:3]] == 0 {
+			return 0, false
+		}
+		month = monthNumbers[name[:3]]
+		year, _ = strconv.Atoi(m[2])
+	} else if m := numericToken.FindStringSubmatch(token); m != nil {
+		month, _ = strconv.Atoi(m[1])
+		year, _ = strconv.Atoi(m[2])
+	} else if m := isoToken.FindStringSubmatch(token); m != nil {
+		year, _ = strconv.Atoi(m[1])
+		month, _ = strconv.Atoi(m[2])
+	} else if m := yearToken.FindStringSubmatch(token); m != nil {
+		year, _ = strconv.Atoi(m[1])
+		month = 1
+		if isEnd {
+			month = 12
+		}
+	} else {
+		return 0, false
+	}
+
+	if month < 1 || month > 12 {
+		return 0, false
+	}
+	return year*12 + month - 1, true
+}
+
+// mergeSpans returns the union of spans, in order.
+func mergeSpans(spans []monthSpan) []monthSpan {
+	sorted := append([]monthSpan(nil), spans...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].start < sorted[j].start })
+
+	var merged []monthSpan
+	for _, s := range sorted {
+		if n := len(merged); n > 0 && s.start <= merged[n-1].end+1 {
+			merged[n-1].end = max(merged[n-1].end, s.end)
+			continue
+		}
+		merged = append(merged, s)
+	}
+	return merged
+}
+
+// spanYears is the total length of disjoint spans in years, to one decimal.
+func spanYears(spans []monthSpan) float64 {
+	months := 0
+	for _, s := range spans {
+		months += s.months()
+	}
+	return math.Round(float64(months)/12*10) / 10
+}
+
+func timelineInterval(s monthSpan) models.TimelineInterval {
+	return models.TimelineInterval{Start: formatMonth(s.start), End: formatMonth(s.end), Months: s.months()}
+}
+
+func formatMonth(m int) string {
+	return fmt.Sprintf("%04d-%02d", m/12, m%12+1)
+}
+
+// maxExperienceLevelDeviation is how far the LLM's experience_level_score
+// may be from the one the timeline supports, for what dates don't show,
+// like the complexity of the work.
+const maxExperienceLevelDeviation = 1
+
+// experienceLevel is the experience_level_score years of Go experience
+// support.
+func experienceLevel(goYears float64) float64 {
+	switch {
+	case goYears < 1:
+		return 1
+	case goYears < 2:
+		return 2
+	case goYears < 3:
+		return 3
+	case goYears < 5:
+		return 4
+	default:
+		return 5
+	}
+}
+
+// experienceLevelOf is the level the timeline supports, 0 without one.
+func experienceLevelOf(timeline *models.ExperienceTimeline) float64 {
+	if timeline == nil {
+		return 0
+	}
+	return experienceLevel(timeline.GoYears)
+}
+
+// applyTimeline records the CV's timeline and keeps experience_level_score
+// within maxExperienceLevelDeviation of the level it supports, recomputing
+// the averages when the score changes.
+func (r *CVEvaluationResult) applyTimeline(timeline *models.ExperienceTimeline) {
+	r.ExperienceTimeline = timeline
+	if timeline == nil {
+		return
+	}
+
+	level := experienceLevel(timeline.GoYears)
+	bounded := math.Max(r.ExperienceLevelScore, math.Max(level-maxExperienceLevelDeviation, minRubricScore))
+	bounded = math.Min(bounded, math.Min(level+maxExperienceLevelDeviation, maxRubricScore))
+	if bounded != r.ExperienceLevelScore {
+		log.Printf("📅 Adjusting experience_level_score from %g to %g for %.1f years of Go experience\n", r.ExperienceLevelScore, bounded, timeline.GoYears)
+		r.ExperienceLevelScore = bounded
+		r.weigh()
+	}
+}
@@ -0,0 +1,124 @@
+package services
+
+import (
+	"archive/zip"
+	"bytes"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	FileTypePDF  = "pdf"
+	FileTypeDOCX = "docx"
+	FileTypeText = "text"
+	FileTypeHTML = "html"
+)
+
+// sniffLen is how many leading bytes DetectFileType needs to look at.
+const sniffLen = 1024
+
+var (
+	pdfSignature = []byte("%PDF-")
+	zipSignature = []byte("PK\x03\x04")
+)
+
+// fileTypeExtensions maps supported file types to the extensions they may be
+// uploaded with.
+var fileTypeExtensions = map[string][]string{
+	FileTypePDF:  {".pdf"},
+	FileTypeDOCX: {".docx"},
+	FileTypeText: {".txt", ".md"},
+	FileTypeHTML: {".html", ".htm"},
+}
+
+// DetectFileType identifies a document from its leading bytes. It returns an
+// empty string when the content matches none of the supported formats.
+func DetectFileType(header []byte) string {
+	if len(header) > sniffLen {
+		header = header[:sniffLen]
+	}
+
+	// The PDF spec allows junk before the header, within the first 1024 bytes
+	if bytes.Contains(header, pdfSignature) {
+		return FileTypePDF
+	}
+
+	// DOCX is a zip container; isDOCX confirms the layout once the file is on disk
+	if bytes.HasPrefix(header, zipSignature) {
+		return FileTypeDOCX
+	}
+
+	if !isText(header) {
+		return ""
+	}
+	if isHTML(header) {
+		return FileTypeHTML
+	}
+	return FileTypeText
+}
+
+// isHTML reports whether text starts like an HTML document, after any
+// byte order mark, whitespace and comments (e.g. "saved from url").
+func isHTML(header []byte) bool {
+	rest := bytes.TrimPrefix(header, []byte("\xef\xbb\xbf"))
+	for {
+		rest = bytes.TrimLeft(rest, " \t\r\n")
+		if !bytes.HasPrefix(rest, []byte("<!--")) {
+			break
+		}
+		end := bytes.Index(rest, []byte("-->"))
+		if end < 0 {
+			return false
+		}
+		rest = rest[end+3:]
+	}
+
+	lower := bytes.ToLower(rest)
+	return bytes.HasPrefix(lower, []byte("<!doctype html")) || bytes.HasPrefix(lower, []byte("<html"))
+}
+
+// isText reports whether header looks like UTF-8 text, allowing for a
+// character cut off at the end of the sniffed bytes.
+func isText(header []byte) bool {
+	if len(header) == 0 || bytes.IndexByte(header, 0) >= 0 {
+		return false
+	}
+	for len(header) > 0 {
+		r, size := utf8.DecodeRune(header)
+		if r == utf8.RuneError && size == 1 {
+			return len(header) < utf8.UTFMax && !utf8.FullRune(header)
+		}
+		header = header[size:]
+	}
+	return true
+}
+
+// FileTypeForExtension returns the file type an extension claims to be.
+func FileTypeForExtension(ext string) string {
+	ext = strings.ToLower(ext)
+	for fileType, extensions := range fileTypeExtensions {
+		for _, e := range extensions {
+			if e == ext {
+				return fileType
+			}
+		}
+	}
+	return ""
+}
+
+// isDOCX verifies that the zip archive at path is a Word document rather than
+// an arbitrary archive renamed to .docx.
+func isDOCX(path string) bool {
+	r, err := zip.OpenReader(path)
+	if err != nil {
+		return false
+	}
+	defer r.Close()
+
+	for _, f := range r.File {
+		if f.Name == "word/document.xml" {
+			return true
+		}
+	}
+	return false
+}
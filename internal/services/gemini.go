@@ -2,14 +2,25 @@ package services
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"log"
+	"net/http"
 	"strings"
 
 	"google.golang.org/genai"
+
+	"alfredoptarigan/cv-evaluator/internal/apperror"
 )
 
+// GeminiEmbeddingModel is the embedding model used when none is configured.
+const GeminiEmbeddingModel = "text-embedding-004"
+
 type GeminiService interface {
+	ModelName() string
+	EmbeddingModel() string
 	GenerateEmbedding(ctx context.Context, text string) ([]float32, error)
+	EmbedBatch(ctx context.Context, texts []string) ([][]float32, error)
 	GenerateText(ctx context.Context, prompt string, temperature float32) (string, error)
 	GenerateTextWithRetry(ctx context.Context, prompt string, temperature float32, maxRetries int) (string, error)
 }
@@ -18,13 +29,17 @@ type geminiService struct {
 	client     *genai.Client
 	modelName  string
 	embedModel string
+	embedDims  int
+	params     GenerationParams
 }
 
-func NewGeminiService(apiKey string) (GeminiService, error) {
+// NewGeminiService creates the Gemini client. embedDims, when positive, is
+// requested from the embedding model and checked on every embedding so a
+// mismatch with the vector store surfaces immediately. params are the
+// defaults of every text generation call.
+func NewGeminiService(apiKey string, embedModel string, embedDims int, params GenerationParams) (GeminiService, error) {
 	ctx := context.Background()
 
-	fmt.Println("🔑 Gemini API key:", apiKey)
-
 	client, err := genai.NewClient(ctx, &genai.ClientConfig{
 		APIKey:  apiKey,
 		Backend: genai.BackendGeminiAPI,
@@ -33,72 +48,190 @@ func NewGeminiService(apiKey string) (GeminiService, error) {
 		return nil, fmt.Errorf("failed to create gemini client: %w", err)
 	}
 
+	if embedModel == "" {
+		embedModel = GeminiEmbeddingModel
+	}
+
 	return &geminiService{
 		client:     client,
 		modelName:  "gemini-2.5-flash",
-		embedModel: "text-embedding-004",
+		embedModel: embedModel,
+		embedDims:  embedDims,
+		params:     params,
 	}, nil
 }
 
+// LLM providers for LLM_PROVIDER
+const (
+	LLMProviderGemini = "gemini"
+	LLMProviderMock   = "mock"
+)
+
+// NewLLMService creates the named provider: the Gemini client, or the
+// offline mock (see NewMockGeminiService), which ignores the API key and
+// model.
+func NewLLMService(provider string, apiKey string, embedModel string, embedDims int, params GenerationParams) (GeminiService, error) {
+	switch provider {
+	case LLMProviderGemini:
+		return NewGeminiService(apiKey, embedModel, embedDims, params)
+	case LLMProviderMock:
+		return NewMockGeminiService(embedDims), nil
+	default:
+		return nil, fmt.Errorf("unknown LLM provider %q", provider)
+	}
+}
+
+// ModelName implements GeminiService.
+func (g *geminiService) ModelName() string {
+	return g.modelName
+}
+
+// EmbeddingModel implements GeminiService. The output size is part of the
+// name when set, since the same model yields different vectors per size.
+func (g *geminiService) EmbeddingModel() string {
+	if g.embedDims > 0 {
+		return fmt.Sprintf("%s@%d", g.embedModel, g.embedDims)
+	}
+	return g.embedModel
+}
+
+func (g *geminiService) embedConfig() *genai.EmbedContentConfig {
+	if g.embedDims <= 0 {
+		return nil
+	}
+	return &genai.EmbedContentConfig{OutputDimensionality: genai.Ptr(int32(g.embedDims))}
+}
+
+func (g *geminiService) checkDimensions(values []float32) error {
+	if g.embedDims > 0 && len(values) != g.embedDims {
+		return fmt.Errorf("embedding model %s returned %d dimensions, expected %d", g.embedModel, len(values), g.embedDims)
+	}
+	return nil
+}
+
+// maxEmbedBatch is the most texts the API embeds in one request.
+const maxEmbedBatch = 100
+
 // GenerateEmbedding implements GeminiService.
 func (g *geminiService) GenerateEmbedding(ctx context.Context, text string) ([]float32, error) {
-	// Truncate text if too long (max ~10000 tokens for embedding)
-	if len(text) > 40000 {
-		text = text[:40000]
-	}
+	text = truncateForEmbedding(text)
 
-	result, err := g.client.Models.EmbedContent(ctx, g.embedModel, genai.Text(text), nil)
+	result, err := g.client.Models.EmbedContent(ctx, g.embedModel, genai.Text(text), g.embedConfig())
 	if err != nil {
-		return nil, fmt.Errorf("failed to generate embedding: %w", err)
+		return nil, apperror.Wrap(err, http.StatusServiceUnavailable, apperror.CodeLLMUnavailable, "failed to generate embedding")
 	}
 
 	if result == nil || len(result.Embeddings) == 0 {
 		return nil, fmt.Errorf("empty embedding result")
 	}
 
-	return result.Embeddings[0].Values, nil
+	values := result.Embeddings[0].Values
+	if err := g.checkDimensions(values); err != nil {
+		return nil, err
+	}
+
+	return values, nil
+}
+
+// EmbedBatch implements GeminiService. Texts are sent in requests of up to
+// maxEmbedBatch and the embeddings are returned in input order.
+func (g *geminiService) EmbedBatch(ctx context.Context, texts []string) ([][]float32, error) {
+	embeddings := make([][]float32, 0, len(texts))
+
+	for start := 0; start < len(texts); start += maxEmbedBatch {
+		end := min(start+maxEmbedBatch, len(texts))
+
+		contents := make([]*genai.Content, 0, end-start)
+		for _, text := range texts[start:end] {
+			contents = append(contents, genai.NewContentFromText(truncateForEmbedding(text), genai.RoleUser))
+		}
+
+		result, err := g.client.Models.EmbedContent(ctx, g.embedModel, contents, g.embedConfig())
+		if err != nil {
+			return nil, apperror.Wrap(err, http.StatusServiceUnavailable, apperror.CodeLLMUnavailable, "failed to generate embeddings")
+		}
+
+		if result == nil {
+			return nil, fmt.Errorf("empty embedding result")
+		}
+		if len(result.Embeddings) != end-start {
+			return nil, fmt.Errorf("expected %d embeddings, got %d", end-start, len(result.Embeddings))
+		}
+
+		for _, e := range result.Embeddings {
+			if err := g.checkDimensions(e.Values); err != nil {
+				return nil, err
+			}
+			embeddings = append(embeddings, e.Values)
+		}
+	}
+
+	return embeddings, nil
+}
+
+// truncateForEmbedding keeps text within the embedding model's input limit,
+// cutting at a sentence boundary.
+func truncateForEmbedding(text string) string {
+	if EstimateTokens(text) <= embeddingTokenLimit {
+		return text
+	}
+	return cutAtSentence(text, embeddingTokenLimit)
+}
+
+// generationConfig is the config of a text generation request: the
+// service's params with overrides applied.
+func (g *geminiService) generationConfig(temperature float32, overrides GenerationParams) *genai.GenerateContentConfig {
+	config := &genai.GenerateContentConfig{Temperature: &temperature}
+	g.params.merge(overrides).apply(config)
+	return config
 }
 
 // GenerateText implements GeminiService.
 func (g *geminiService) GenerateText(ctx context.Context, prompt string, temperature float32) (string, error) {
-	// Create generation config
-	config := &genai.GenerateContentConfig{
-		Temperature:     &temperature,
-		MaxOutputTokens: 4096,
+	if stream := textStreamFrom(ctx); stream != nil {
+		return g.generateTextStream(ctx, prompt, temperature, stream)
 	}
 
 	// Generate response
-	resp, err := g.client.Models.GenerateContent(ctx, g.modelName, genai.Text(prompt), config)
+	resp, err := g.client.Models.GenerateContent(ctx, modelFor(ctx, g.modelName), promptContents(ctx, prompt), g.generationConfig(temperature, generationParams(ctx)))
 	if err != nil {
-		fmt.Printf("❌ Gemini API error: %v\n", err)
-		return "", fmt.Errorf("failed to generate text: %w", err)
+		log.Printf("❌ Gemini API error: %v\n", err)
+		return "", apperror.Wrap(err, http.StatusServiceUnavailable, apperror.CodeLLMUnavailable, "failed to generate text")
 	}
 
 	if resp == nil {
-		fmt.Println("❌ Gemini API returned nil response")
+		log.Println("❌ Gemini API returned nil response")
 		return "", fmt.Errorf("no response generated (nil response)")
 	}
 
+	// Thinking tokens are billed as output
+	if usage := resp.UsageMetadata; usage != nil {
+		recordTokenUsage(ctx,
+			int64(usage.PromptTokenCount),
+			int64(usage.CandidatesTokenCount)+int64(usage.ThoughtsTokenCount),
+		)
+	}
+
 	// Log response for debugging
-	fmt.Printf("📊 Gemini response received\n")
+	log.Printf("📊 Gemini response received\n")
 
 	// Get text from response
 	text := resp.Text()
 	if text == "" {
-		fmt.Println("❌ No text content in response")
+		log.Println("❌ No text content in response")
 
 		// Try to extract any content from candidates if available
 		if resp.Candidates != nil && len(resp.Candidates) > 0 {
 			var textParts []string
 			for i, candidate := range resp.Candidates {
-				fmt.Printf("📄 Candidate %d: %+v\n", i, candidate)
+				log.Printf("📄 Candidate %d: %+v\n", i, candidate)
 				if candidate.Content != nil {
 					textParts = append(textParts, fmt.Sprintf("%v", candidate.Content))
 				}
 			}
 
 			if len(textParts) > 0 {
-				fmt.Println("⚠️ Using fallback string representation of response parts")
+				log.Println("⚠️ Using fallback string representation of response parts")
 				return strings.Join(textParts, "\n"), nil
 			}
 		}
@@ -109,18 +242,67 @@ func (g *geminiService) GenerateText(ctx context.Context, prompt string, tempera
 	return text, nil
 }
 
+// generateTextStream generates text with the streaming API, passing each
+// piece to stream as it arrives.
+func (g *geminiService) generateTextStream(ctx context.Context, prompt string, temperature float32, stream TextStream) (string, error) {
+	stream.Reset()
+
+	var text strings.Builder
+	var usage *genai.GenerateContentResponseUsageMetadata
+	for resp, err := range g.client.Models.GenerateContentStream(ctx, modelFor(ctx, g.modelName), promptContents(ctx, prompt), g.generationConfig(temperature, generationParams(ctx))) {
+		if err != nil {
+			log.Printf("❌ Gemini API error: %v\n", err)
+			return "", apperror.Wrap(err, http.StatusServiceUnavailable, apperror.CodeLLMUnavailable, "failed to generate text")
+		}
+		if resp.UsageMetadata != nil {
+			usage = resp.UsageMetadata
+		}
+
+		if chunk := resp.Text(); chunk != "" {
+			text.WriteString(chunk)
+			stream.Write(chunk)
+		}
+	}
+
+	// Usage is cumulative, so the last chunk's counts are the totals
+	if usage != nil {
+		recordTokenUsage(ctx,
+			int64(usage.PromptTokenCount),
+			int64(usage.CandidatesTokenCount)+int64(usage.ThoughtsTokenCount),
+		)
+	}
+
+	if text.Len() == 0 {
+		return "", fmt.Errorf("no text content in response")
+	}
+
+	return text.String(), nil
+}
+
 // GenerateTextWithRetry implements GeminiService.
 func (g *geminiService) GenerateTextWithRetry(ctx context.Context, prompt string, temperature float32, maxRetries int) (string, error) {
+	return generateTextWithRetry(ctx, g, prompt, temperature, maxRetries)
+}
+
+// generateTextWithRetry retries svc.GenerateText; GeminiService decorators
+// share it so that every attempt goes through their own GenerateText.
+func generateTextWithRetry(ctx context.Context, svc GeminiService, prompt string, temperature float32, maxRetries int) (string, error) {
 	var lastErr error
 
 	for attempt := 1; attempt <= maxRetries; attempt++ {
-		result, err := g.GenerateText(ctx, prompt, temperature)
+		result, err := svc.GenerateText(ctx, prompt, temperature)
 		if err == nil {
 			return result, nil
 		}
 
 		lastErr = err
 
+		// Retrying against an open breaker only burns attempts, and a batch
+		// request is answered by its job, not by asking again
+		if errors.Is(err, ErrCircuitOpen) || errors.Is(err, ErrBatchPending) || errors.Is(err, ErrBatchFailed) {
+			return "", err
+		}
+
 		// Check if context is cancelled
 		select {
 		case <-ctx.Done():
@@ -128,9 +310,12 @@ func (g *geminiService) GenerateTextWithRetry(ctx context.Context, prompt string
 		default:
 		}
 
-		// Log retry attempt
+		// Log retry attempt and back off if asked to
 		if attempt < maxRetries {
-			fmt.Printf("⚠️ Attempt %d failed: %v. Retrying...\n", attempt, err)
+			log.Printf("⚠️ Attempt %d failed: %v. Retrying...\n", attempt, err)
+			if err := sleepCtx(ctx, RetryPolicy{InitialDelay: retryDelayFrom(ctx)}.delay(attempt)); err != nil {
+				return "", fmt.Errorf("context cancelled: %w", err)
+			}
 		}
 	}
 
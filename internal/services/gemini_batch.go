@@ -0,0 +1,432 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"google.golang.org/genai"
+
+	"alfredoptarigan/cv-evaluator/internal/models"
+	"alfredoptarigan/cv-evaluator/internal/repositories"
+)
+
+// ErrBatchPending is returned by text generation in batch mode while the
+// request waits for its batch job. Asking again later returns the response.
+var ErrBatchPending = errors.New("waiting for llm batch job")
+
+// ErrBatchFailed is returned when the batch job couldn't answer a request.
+// Asking again submits the request anew.
+var ErrBatchFailed = errors.New("llm batch request failed")
+
+// batchRequestRetention is how long answered requests that nobody picked up,
+// e.g. of a deleted evaluation, are kept.
+const batchRequestRetention = 7 * 24 * time.Hour
+
+// batchPendingError is ErrBatchPending with a hint when to ask again.
+type batchPendingError struct {
+	retryAfter time.Duration
+}
+
+func (e *batchPendingError) Error() string {
+	return ErrBatchPending.Error()
+}
+
+func (e *batchPendingError) Is(target error) bool {
+	return target == ErrBatchPending
+}
+
+// batchRetryAfter returns when to ask again for a request that returned
+// ErrBatchPending.
+func batchRetryAfter(err error) time.Duration {
+	var pending *batchPendingError
+	if errors.As(err, &pending) {
+		return pending.retryAfter
+	}
+	return time.Minute
+}
+
+type batchModeKey struct{}
+
+// WithBatchMode makes text generation with ctx go through the batch API.
+func WithBatchMode(ctx context.Context) context.Context {
+	return context.WithValue(ctx, batchModeKey{}, true)
+}
+
+// withoutBatchMode makes text generation with ctx synchronous again, for
+// helper calls that fall back on failure instead of waiting for a job.
+func withoutBatchMode(ctx context.Context) context.Context {
+	return context.WithValue(ctx, batchModeKey{}, false)
+}
+
+func batchMode(ctx context.Context) bool {
+	batch, _ := ctx.Value(batchModeKey{}).(bool)
+	return batch
+}
+
+// GeminiBatchAPI submits text generation requests as asynchronous batch
+// jobs. The base GeminiService implements it.
+type GeminiBatchAPI interface {
+	// SubmitBatch starts a job answering requests, in order, with model and
+	// returns the job's name.
+	SubmitBatch(ctx context.Context, model string, requests []models.LLMBatchRequest) (string, error)
+	// BatchStatus reports whether the job finished and, if so, its results.
+	BatchStatus(ctx context.Context, jobName string) (*BatchJobStatus, error)
+}
+
+// BatchJobStatus is the state of a batch job. Err is set when the job as a
+// whole failed; otherwise Results holds one result per submitted request.
+type BatchJobStatus struct {
+	Done    bool
+	Err     error
+	Results []BatchResult
+}
+
+// BatchResult answers one request of a batch job.
+type BatchResult struct {
+	Text             string
+	PromptTokens     int64
+	CompletionTokens int64
+	Err              error
+}
+
+// SubmitBatch implements GeminiBatchAPI.
+func (g *geminiService) SubmitBatch(ctx context.Context, model string, requests []models.LLMBatchRequest) (string, error) {
+	inlined := make([]*genai.InlinedRequest, len(requests))
+	for i, req := range requests {
+		var params GenerationParams
+		if req.GenerationParams != "" {
+			if err := json.Unmarshal([]byte(req.GenerationParams), &params); err != nil {
+				return "", fmt.Errorf("invalid generation params of batch request %s: %w", req.Key, err)
+			}
+		}
+		inlined[i] = &genai.InlinedRequest{
+			Contents: genai.Text(req.Prompt),
+			Config:   g.generationConfig(req.Temperature, params),
+		}
+	}
+
+	job, err := g.client.Batches.Create(ctx, model, &genai.BatchJobSource{InlinedRequests: inlined}, &genai.CreateBatchJobConfig{
+		DisplayName: fmt.Sprintf("cv-evaluator-%d", time.Now().Unix()),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to create batch job: %w", err)
+	}
+
+	return job.Name, nil
+}
+
+// BatchStatus implements GeminiBatchAPI.
+func (g *geminiService) BatchStatus(ctx context.Context, jobName string) (*BatchJobStatus, error) {
+	job, err := g.client.Batches.Get(ctx, jobName, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get batch job %s: %w", jobName, err)
+	}
+
+	switch job.State {
+	case genai.JobStateSucceeded, genai.JobStatePartiallySucceeded:
+	case genai.JobStateFailed, genai.JobStateCancelled, genai.JobStateExpired:
+		message := string(job.State)
+		if job.Error != nil && job.Error.Message != "" {
+			message = job.Error.Message
+		}
+		return &BatchJobStatus{Done: true, Err: fmt.Errorf("batch job %s failed: %s", jobName, message)}, nil
+	default:
+		return &BatchJobStatus{}, nil
+	}
+
+	if job.Dest == nil {
+		return &BatchJobStatus{Done: true, Err: fmt.Errorf("batch job %s has no inline responses", jobName)}, nil
+	}
+
+	status := &BatchJobStatus{Done: true, Results: make([]BatchResult, len(job.Dest.InlinedResponses))}
+	for i, inlined := range job.Dest.InlinedResponses {
+		result := &status.Results[i]
+		switch {
+		case inlined.Error != nil:
+			result.Err = errors.New(inlined.Error.Message)
+		case inlined.Response == nil || inlined.Response.Text() == "":
+			result.Err = errors.New("no text content in response")
+		default:
+			result.Text = inlined.Response.Text()
+			// Thinking tokens are billed as output
+			if usage := inlined.Response.UsageMetadata; usage != nil {
+				result.PromptTokens = int64(usage.PromptTokenCount)
+				result.CompletionTokens = int64(usage.CandidatesTokenCount) + int64(usage.ThoughtsTokenCount)
+			}
+		}
+	}
+
+	return status, nil
+}
+
+// batchGeminiService sends text generation in batch mode to the batch API:
+// the first call stores the request for the next batch job and returns
+// ErrBatchPending, a later call returns the job's answer. Other calls pass
+// through.
+type batchGeminiService struct {
+	next      GeminiService
+	batchRepo repositories.LLMBatchRepository
+	recheck   time.Duration
+}
+
+// NewBatchGeminiService wraps next. recheck is how long callers are told to
+// wait before asking for a pending response again.
+func NewBatchGeminiService(next GeminiService, batchRepo repositories.LLMBatchRepository, recheck time.Duration) GeminiService {
+	return &batchGeminiService{
+		next:      next,
+		batchRepo: batchRepo,
+		recheck:   recheck,
+	}
+}
+
+// ModelName implements GeminiService.
+func (b *batchGeminiService) ModelName() string {
+	return b.next.ModelName()
+}
+
+// EmbeddingModel implements GeminiService.
+func (b *batchGeminiService) EmbeddingModel() string {
+	return b.next.EmbeddingModel()
+}
+
+// GenerateEmbedding implements GeminiService.
+func (b *batchGeminiService) GenerateEmbedding(ctx context.Context, text string) ([]float32, error) {
+	return b.next.GenerateEmbedding(ctx, text)
+}
+
+// EmbedBatch implements GeminiService.
+func (b *batchGeminiService) EmbedBatch(ctx context.Context, texts []string) ([][]float32, error) {
+	return b.next.EmbedBatch(ctx, texts)
+}
+
+// GenerateText implements GeminiService. An answered request is removed
+// once it is returned. Requests with images are sent directly, as batch
+// requests only keep the prompt text.
+func (b *batchGeminiService) GenerateText(ctx context.Context, prompt string, temperature float32) (string, error) {
+	if !batchMode(ctx) || len(promptImages(ctx)) > 0 {
+		return b.next.GenerateText(ctx, prompt, temperature)
+	}
+
+	model := modelFor(ctx, b.next.ModelName())
+	params := generationParams(ctx)
+	key := llmRequestKey(model, prompt, temperature, params, nil)
+	recordLLMKey(ctx, key)
+
+	req, err := b.batchRepo.Get(key)
+	if err != nil {
+		return "", err
+	}
+
+	if req == nil {
+		err := b.batchRepo.Enqueue(&models.LLMBatchRequest{
+			Key:              key,
+			Model:            model,
+			Temperature:      temperature,
+			GenerationParams: params.fingerprint(),
+			Prompt:           prompt,
+		})
+		if err != nil {
+			return "", err
+		}
+		log.Printf("📦 Queued LLM request for the next %s batch job\n", model)
+		return "", &batchPendingError{retryAfter: b.recheck}
+	}
+
+	switch req.Status {
+	case models.BatchSucceeded:
+		b.forget(key)
+		recordTokenUsage(ctx, req.PromptTokens, req.CompletionTokens)
+		return req.Response, nil
+	case models.BatchFailed:
+		b.forget(key)
+		return "", fmt.Errorf("%w: %s", ErrBatchFailed, req.ErrorMessage)
+	default:
+		return "", &batchPendingError{retryAfter: b.recheck}
+	}
+}
+
+// GenerateTextWithRetry implements GeminiService.
+func (b *batchGeminiService) GenerateTextWithRetry(ctx context.Context, prompt string, temperature float32, maxRetries int) (string, error) {
+	return generateTextWithRetry(ctx, b, prompt, temperature, maxRetries)
+}
+
+func (b *batchGeminiService) forget(key string) {
+	if err := b.batchRepo.Delete(key); err != nil {
+		log.Printf("⚠️  %v\n", err)
+	}
+}
+
+// GeminiBatcher submits the stored batch requests as batch jobs and records
+// the jobs' answers.
+type GeminiBatcher interface {
+	Start(ctx context.Context)
+	Stop()
+	// Flush submits the pending requests, one job per model.
+	Flush(ctx context.Context) error
+	// Poll records the answers of finished jobs.
+	Poll(ctx context.Context) error
+}
+
+// BatchOptions configures a GeminiBatcher.
+type BatchOptions struct {
+	// FlushInterval is how often pending requests are submitted.
+	FlushInterval time.Duration
+	// PollInterval is how often submitted jobs are checked.
+	PollInterval time.Duration
+	// MaxRequests caps the requests per flush.
+	MaxRequests int
+}
+
+type geminiBatcher struct {
+	api       GeminiBatchAPI
+	batchRepo repositories.LLMBatchRepository
+	opts      BatchOptions
+	wg        sync.WaitGroup
+	stopChan  chan struct{}
+}
+
+func NewGeminiBatcher(api GeminiBatchAPI, batchRepo repositories.LLMBatchRepository, opts BatchOptions) GeminiBatcher {
+	return &geminiBatcher{
+		api:       api,
+		batchRepo: batchRepo,
+		opts:      opts,
+		stopChan:  make(chan struct{}),
+	}
+}
+
+// Start implements GeminiBatcher.
+func (b *geminiBatcher) Start(ctx context.Context) {
+	b.wg.Add(1)
+	go func() {
+		defer b.wg.Done()
+		flushTicker := time.NewTicker(b.opts.FlushInterval)
+		defer flushTicker.Stop()
+		pollTicker := time.NewTicker(b.opts.PollInterval)
+		defer pollTicker.Stop()
+
+		log.Printf("📦 Gemini batcher started (flush every %s, poll every %s)\n", b.opts.FlushInterval, b.opts.PollInterval)
+
+		for {
+			select {
+			case <-b.stopChan:
+				log.Println("📦 Gemini batcher stopped")
+				return
+			case <-flushTicker.C:
+				if err := b.Flush(ctx); err != nil {
+					log.Printf("⚠️  Batch flush failed: %v\n", err)
+				}
+			case <-pollTicker.C:
+				if err := b.Poll(ctx); err != nil {
+					log.Printf("⚠️  Batch poll failed: %v\n", err)
+				}
+			}
+		}
+	}()
+}
+
+// Stop implements GeminiBatcher. Running jobs carry on and are polled after
+// the next start.
+func (b *geminiBatcher) Stop() {
+	close(b.stopChan)
+	b.wg.Wait()
+}
+
+// Flush implements GeminiBatcher. Requests of a job that can't be created
+// stay pending for the next flush.
+func (b *geminiBatcher) Flush(ctx context.Context) error {
+	reqs, err := b.batchRepo.FindPending(b.opts.MaxRequests)
+	if err != nil {
+		return err
+	}
+
+	var order []string
+	byModel := map[string][]models.LLMBatchRequest{}
+	for _, req := range reqs {
+		if _, ok := byModel[req.Model]; !ok {
+			order = append(order, req.Model)
+		}
+		byModel[req.Model] = append(byModel[req.Model], req)
+	}
+
+	for _, model := range order {
+		group := byModel[model]
+		jobName, err := b.api.SubmitBatch(ctx, model, group)
+		if err != nil {
+			log.Printf("⚠️  Failed to submit %d requests to %s: %v\n", len(group), model, err)
+			continue
+		}
+
+		keys := make([]string, len(group))
+		for i, req := range group {
+			keys[i] = req.Key
+		}
+		if err := b.batchRepo.MarkSubmitted(keys, jobName); err != nil {
+			return err
+		}
+		log.Printf("📦 Submitted batch job %s with %d requests to %s\n", jobName, len(group), model)
+	}
+
+	return nil
+}
+
+// Poll implements GeminiBatcher.
+func (b *geminiBatcher) Poll(ctx context.Context) error {
+	jobs, err := b.batchRepo.FindSubmittedJobs()
+	if err != nil {
+		return err
+	}
+
+	for _, jobName := range jobs {
+		status, err := b.api.BatchStatus(ctx, jobName)
+		if err != nil {
+			log.Printf("⚠️  %v\n", err)
+			continue
+		}
+		if !status.Done {
+			continue
+		}
+
+		if err := b.record(jobName, status); err != nil {
+			return err
+		}
+		log.Printf("📦 Batch job %s finished\n", jobName)
+	}
+
+	if _, err := b.batchRepo.DeleteFinished(time.Now().Add(-batchRequestRetention)); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// record stores a finished job's answer to each of its requests.
+func (b *geminiBatcher) record(jobName string, status *BatchJobStatus) error {
+	reqs, err := b.batchRepo.FindByJob(jobName)
+	if err != nil {
+		return err
+	}
+
+	for _, req := range reqs {
+		switch {
+		case status.Err != nil:
+			err = b.batchRepo.Fail(req.Key, status.Err.Error())
+		case req.BatchIndex >= len(status.Results):
+			err = b.batchRepo.Fail(req.Key, "no response in batch job")
+		case status.Results[req.BatchIndex].Err != nil:
+			err = b.batchRepo.Fail(req.Key, status.Results[req.BatchIndex].Err.Error())
+		default:
+			result := status.Results[req.BatchIndex]
+			err = b.batchRepo.Complete(req.Key, result.Text, result.PromptTokens, result.CompletionTokens)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
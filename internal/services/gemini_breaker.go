@@ -0,0 +1,60 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"github.com/sony/gobreaker"
+
+	"alfredoptarigan/cv-evaluator/internal/apperror"
+)
+
+// breakerGeminiService fails fast while Gemini is down instead of letting
+// every job wait out its own timeouts and retries.
+type breakerGeminiService struct {
+	next GeminiService
+	cb   *gobreaker.CircuitBreaker
+}
+
+func NewBreakerGeminiService(next GeminiService, maxFailures uint32, openTimeout time.Duration) GeminiService {
+	return &breakerGeminiService{
+		next: next,
+		cb:   newBreaker("gemini", maxFailures, openTimeout),
+	}
+}
+
+// ModelName implements GeminiService.
+func (b *breakerGeminiService) ModelName() string {
+	return b.next.ModelName()
+}
+
+// EmbeddingModel implements GeminiService.
+func (b *breakerGeminiService) EmbeddingModel() string {
+	return b.next.EmbeddingModel()
+}
+
+// GenerateEmbedding implements GeminiService.
+func (b *breakerGeminiService) GenerateEmbedding(ctx context.Context, text string) ([]float32, error) {
+	return callBreaker(b.cb, apperror.CodeLLMUnavailable, func() ([]float32, error) {
+		return b.next.GenerateEmbedding(ctx, text)
+	})
+}
+
+// EmbedBatch implements GeminiService.
+func (b *breakerGeminiService) EmbedBatch(ctx context.Context, texts []string) ([][]float32, error) {
+	return callBreaker(b.cb, apperror.CodeLLMUnavailable, func() ([][]float32, error) {
+		return b.next.EmbedBatch(ctx, texts)
+	})
+}
+
+// GenerateText implements GeminiService.
+func (b *breakerGeminiService) GenerateText(ctx context.Context, prompt string, temperature float32) (string, error) {
+	return callBreaker(b.cb, apperror.CodeLLMUnavailable, func() (string, error) {
+		return b.next.GenerateText(ctx, prompt, temperature)
+	})
+}
+
+// GenerateTextWithRetry implements GeminiService.
+func (b *breakerGeminiService) GenerateTextWithRetry(ctx context.Context, prompt string, temperature float32, maxRetries int) (string, error) {
+	return generateTextWithRetry(ctx, b, prompt, temperature, maxRetries)
+}
@@ -0,0 +1,192 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"alfredoptarigan/cv-evaluator/internal/models"
+	"alfredoptarigan/cv-evaluator/internal/repositories"
+)
+
+const llmCachePurgeInterval = time.Hour
+
+type llmCacheBypassKey struct{}
+
+// WithLLMCacheBypass makes text generation with ctx skip cached responses.
+// Fresh responses are still written back to the cache.
+func WithLLMCacheBypass(ctx context.Context) context.Context {
+	return context.WithValue(ctx, llmCacheBypassKey{}, true)
+}
+
+func llmCacheBypassed(ctx context.Context) bool {
+	bypass, _ := ctx.Value(llmCacheBypassKey{}).(bool)
+	return bypass
+}
+
+type llmKeysKey struct{}
+
+// llmKeyLog collects the cache and batch keys of the LLM calls made with a
+// context from withLLMKeyLog, so the evaluation they belong to can find
+// the stored prompts and responses again when it is erased.
+type llmKeyLog struct {
+	mu   sync.Mutex
+	keys []string
+	seen map[string]bool
+}
+
+func withLLMKeyLog(ctx context.Context) (context.Context, *llmKeyLog) {
+	l := &llmKeyLog{seen: make(map[string]bool)}
+	return context.WithValue(ctx, llmKeysKey{}, l), l
+}
+
+func recordLLMKey(ctx context.Context, key string) {
+	l, ok := ctx.Value(llmKeysKey{}).(*llmKeyLog)
+	if !ok {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if !l.seen[key] {
+		l.seen[key] = true
+		l.keys = append(l.keys, key)
+	}
+}
+
+// list returns the recorded keys in the order first seen.
+func (l *llmKeyLog) list() []string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]string(nil), l.keys...)
+}
+
+// cachedGeminiService reuses earlier responses for identical text
+// generation requests, keyed by hash(model, temperature, prompt).
+type cachedGeminiService struct {
+	next      GeminiService
+	cacheRepo repositories.LLMCacheRepository
+	ttl       time.Duration
+
+	mu         sync.Mutex
+	lastPurged time.Time
+}
+
+func NewCachedGeminiService(next GeminiService, cacheRepo repositories.LLMCacheRepository, ttl time.Duration) GeminiService {
+	if ttl <= 0 {
+		return next
+	}
+
+	return &cachedGeminiService{
+		next:      next,
+		cacheRepo: cacheRepo,
+		ttl:       ttl,
+	}
+}
+
+// ModelName implements GeminiService.
+func (c *cachedGeminiService) ModelName() string {
+	return c.next.ModelName()
+}
+
+// EmbeddingModel implements GeminiService.
+func (c *cachedGeminiService) EmbeddingModel() string {
+	return c.next.EmbeddingModel()
+}
+
+// GenerateEmbedding implements GeminiService.
+func (c *cachedGeminiService) GenerateEmbedding(ctx context.Context, text string) ([]float32, error) {
+	return c.next.GenerateEmbedding(ctx, text)
+}
+
+// EmbedBatch implements GeminiService.
+func (c *cachedGeminiService) EmbedBatch(ctx context.Context, texts []string) ([][]float32, error) {
+	return c.next.EmbedBatch(ctx, texts)
+}
+
+// GenerateText implements GeminiService.
+func (c *cachedGeminiService) GenerateText(ctx context.Context, prompt string, temperature float32) (string, error) {
+	model := modelFor(ctx, c.next.ModelName())
+	key := llmRequestKey(model, prompt, temperature, generationParams(ctx), promptImages(ctx))
+	recordLLMKey(ctx, key)
+
+	if !llmCacheBypassed(ctx) {
+		entry, err := c.cacheRepo.Get(key)
+		if err != nil {
+			log.Printf("⚠️  LLM cache read failed: %v\n", err)
+		} else if entry != nil {
+			log.Println("💾 LLM cache hit")
+			return entry.Response, nil
+		}
+	}
+
+	response, err := c.next.GenerateText(ctx, prompt, temperature)
+	if err != nil {
+		return "", err
+	}
+	// An empty response is never worth repeating
+	if strings.TrimSpace(response) == "" {
+		return response, nil
+	}
+
+	now := time.Now()
+	err = c.cacheRepo.Put(&models.LLMCacheEntry{
+		Key:       key,
+		Model:     model,
+		Response:  response,
+		ExpiresAt: now.Add(c.ttl),
+		CreatedAt: now,
+	})
+	if err != nil {
+		log.Printf("⚠️  LLM cache write failed: %v\n", err)
+	}
+	c.purgeExpired(now)
+
+	return response, nil
+}
+
+// GenerateTextWithRetry implements GeminiService.
+func (c *cachedGeminiService) GenerateTextWithRetry(ctx context.Context, prompt string, temperature float32, maxRetries int) (string, error) {
+	return generateTextWithRetry(ctx, c, prompt, temperature, maxRetries)
+}
+
+// llmRequestKey identifies a text generation request by hash(model,
+// temperature, prompt), plus the generation params and images when there
+// are any.
+func llmRequestKey(model, prompt string, temperature float32, params GenerationParams, images []PromptImage) string {
+	h := sha256.New()
+	h.Write([]byte(model))
+	h.Write([]byte{0})
+	h.Write([]byte(strconv.FormatFloat(float64(temperature), 'f', -1, 32)))
+	h.Write([]byte{0})
+	h.Write([]byte(prompt))
+	if fingerprint := params.fingerprint(); fingerprint != "" {
+		h.Write([]byte{0})
+		h.Write([]byte(fingerprint))
+	}
+	if fingerprint := imagesFingerprint(images); fingerprint != "" {
+		h.Write([]byte{0})
+		h.Write([]byte(fingerprint))
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// purgeExpired drops expired entries at most once per llmCachePurgeInterval.
+func (c *cachedGeminiService) purgeExpired(now time.Time) {
+	c.mu.Lock()
+	if now.Sub(c.lastPurged) < llmCachePurgeInterval {
+		c.mu.Unlock()
+		return
+	}
+	c.lastPurged = now
+	c.mu.Unlock()
+
+	if _, err := c.cacheRepo.DeleteExpired(now); err != nil {
+		log.Printf("⚠️  %v\n", err)
+	}
+}
@@ -0,0 +1,106 @@
+package services
+
+import (
+	"context"
+	"net/http"
+	"sync/atomic"
+
+	"alfredoptarigan/cv-evaluator/internal/apperror"
+)
+
+var ErrLLMQueueFull = apperror.New(http.StatusServiceUnavailable, apperror.CodeLLMUnavailable, "too many pending LLM requests")
+
+// limitedGeminiService caps the number of in-flight Gemini calls across all
+// workers. Callers beyond the cap wait for a slot; when maxQueue is set,
+// callers beyond that fail fast instead of waiting.
+type limitedGeminiService struct {
+	next     GeminiService
+	slots    chan struct{}
+	maxQueue int64
+	waiting  atomic.Int64
+}
+
+// NewLimitedGeminiService wraps next so that at most maxInFlight calls run
+// concurrently. A maxQueue of 0 lets any number of callers wait.
+func NewLimitedGeminiService(next GeminiService, maxInFlight int, maxQueue int) GeminiService {
+	if maxInFlight <= 0 {
+		return next
+	}
+
+	return &limitedGeminiService{
+		next:     next,
+		slots:    make(chan struct{}, maxInFlight),
+		maxQueue: int64(maxQueue),
+	}
+}
+
+func (l *limitedGeminiService) acquire(ctx context.Context) error {
+	select {
+	case l.slots <- struct{}{}:
+		return nil
+	default:
+	}
+
+	if n := l.waiting.Add(1); l.maxQueue > 0 && n > l.maxQueue {
+		l.waiting.Add(-1)
+		return ErrLLMQueueFull
+	}
+	defer l.waiting.Add(-1)
+
+	select {
+	case l.slots <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (l *limitedGeminiService) release() {
+	<-l.slots
+}
+
+// ModelName implements GeminiService.
+func (l *limitedGeminiService) ModelName() string {
+	return l.next.ModelName()
+}
+
+// EmbeddingModel implements GeminiService.
+func (l *limitedGeminiService) EmbeddingModel() string {
+	return l.next.EmbeddingModel()
+}
+
+// GenerateEmbedding implements GeminiService.
+func (l *limitedGeminiService) GenerateEmbedding(ctx context.Context, text string) ([]float32, error) {
+	if err := l.acquire(ctx); err != nil {
+		return nil, err
+	}
+	defer l.release()
+
+	return l.next.GenerateEmbedding(ctx, text)
+}
+
+// EmbedBatch implements GeminiService. A batch takes a single slot.
+func (l *limitedGeminiService) EmbedBatch(ctx context.Context, texts []string) ([][]float32, error) {
+	if err := l.acquire(ctx); err != nil {
+		return nil, err
+	}
+	defer l.release()
+
+	return l.next.EmbedBatch(ctx, texts)
+}
+
+// GenerateText implements GeminiService.
+func (l *limitedGeminiService) GenerateText(ctx context.Context, prompt string, temperature float32) (string, error) {
+	if err := l.acquire(ctx); err != nil {
+		return "", err
+	}
+	defer l.release()
+
+	return l.next.GenerateText(ctx, prompt, temperature)
+}
+
+// GenerateTextWithRetry implements GeminiService. Each attempt takes its own
+// slot so a retrying caller doesn't hold one while it backs off.
+func (l *limitedGeminiService) GenerateTextWithRetry(ctx context.Context, prompt string, temperature float32, maxRetries int) (string, error) {
+	return generateTextWithRetry(ctx, l, prompt, temperature, maxRetries)
+}
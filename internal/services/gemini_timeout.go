@@ -0,0 +1,63 @@
+package services
+
+import (
+	"context"
+	"time"
+)
+
+// timeoutGeminiService bounds every individual Gemini call, so with retries
+// each attempt gets its own deadline.
+type timeoutGeminiService struct {
+	next    GeminiService
+	timeout time.Duration
+}
+
+func NewTimeoutGeminiService(next GeminiService, timeout time.Duration) GeminiService {
+	if timeout <= 0 {
+		return next
+	}
+
+	return &timeoutGeminiService{
+		next:    next,
+		timeout: timeout,
+	}
+}
+
+// ModelName implements GeminiService.
+func (t *timeoutGeminiService) ModelName() string {
+	return t.next.ModelName()
+}
+
+// EmbeddingModel implements GeminiService.
+func (t *timeoutGeminiService) EmbeddingModel() string {
+	return t.next.EmbeddingModel()
+}
+
+// GenerateEmbedding implements GeminiService.
+func (t *timeoutGeminiService) GenerateEmbedding(ctx context.Context, text string) ([]float32, error) {
+	ctx, cancel := context.WithTimeout(ctx, t.timeout)
+	defer cancel()
+
+	return t.next.GenerateEmbedding(ctx, text)
+}
+
+// EmbedBatch implements GeminiService.
+func (t *timeoutGeminiService) EmbedBatch(ctx context.Context, texts []string) ([][]float32, error) {
+	ctx, cancel := context.WithTimeout(ctx, t.timeout)
+	defer cancel()
+
+	return t.next.EmbedBatch(ctx, texts)
+}
+
+// GenerateText implements GeminiService.
+func (t *timeoutGeminiService) GenerateText(ctx context.Context, prompt string, temperature float32) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, t.timeout)
+	defer cancel()
+
+	return t.next.GenerateText(ctx, prompt, temperature)
+}
+
+// GenerateTextWithRetry implements GeminiService.
+func (t *timeoutGeminiService) GenerateTextWithRetry(ctx context.Context, prompt string, temperature float32, maxRetries int) (string, error) {
+	return generateTextWithRetry(ctx, t, prompt, temperature, maxRetries)
+}
@@ -0,0 +1,94 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"maps"
+	"slices"
+
+	"google.golang.org/genai"
+)
+
+// GenerationParams tune text generation. Zero values fall back to the
+// service's defaults, and those to the model's.
+type GenerationParams struct {
+	MaxOutputTokens int32    `json:"max_output_tokens,omitempty"`
+	TopP            *float32 `json:"top_p,omitempty"`
+	TopK            *float32 `json:"top_k,omitempty"`
+	// SafetySettings maps harm categories (e.g. HARM_CATEGORY_HARASSMENT)
+	// to block thresholds (e.g. BLOCK_ONLY_HIGH).
+	SafetySettings map[string]string `json:"safety_settings,omitempty"`
+}
+
+// merge returns p with the fields set in o replaced.
+func (p GenerationParams) merge(o GenerationParams) GenerationParams {
+	if o.MaxOutputTokens > 0 {
+		p.MaxOutputTokens = o.MaxOutputTokens
+	}
+	if o.TopP != nil {
+		p.TopP = o.TopP
+	}
+	if o.TopK != nil {
+		p.TopK = o.TopK
+	}
+	if len(o.SafetySettings) > 0 {
+		settings := maps.Clone(p.SafetySettings)
+		if settings == nil {
+			settings = make(map[string]string, len(o.SafetySettings))
+		}
+		maps.Copy(settings, o.SafetySettings)
+		p.SafetySettings = settings
+	}
+	return p
+}
+
+// isZero reports whether p leaves every setting to the defaults.
+func (p GenerationParams) isZero() bool {
+	return p.MaxOutputTokens == 0 && p.TopP == nil && p.TopK == nil && len(p.SafetySettings) == 0
+}
+
+// fingerprint identifies p in cache and batch keys; zero params have none
+// so keys of calls without them stay the same.
+func (p GenerationParams) fingerprint() string {
+	if p.isZero() {
+		return ""
+	}
+	// Map keys are sorted by encoding/json
+	raw, _ := json.Marshal(p)
+	return string(raw)
+}
+
+// apply sets p on config.
+func (p GenerationParams) apply(config *genai.GenerateContentConfig) {
+	config.MaxOutputTokens = p.MaxOutputTokens
+	config.TopP = p.TopP
+	config.TopK = p.TopK
+	for _, category := range slices.Sorted(maps.Keys(p.SafetySettings)) {
+		config.SafetySettings = append(config.SafetySettings, &genai.SafetySetting{
+			Category:  genai.HarmCategory(category),
+			Threshold: genai.HarmBlockThreshold(p.SafetySettings[category]),
+		})
+	}
+}
+
+// StageGeneration holds the generation params of the pipeline's LLM steps,
+// on top of the service's defaults.
+type StageGeneration struct {
+	// Scoring applies to the CV and project evaluations.
+	Scoring GenerationParams
+	// Summary applies to the overall summary.
+	Summary GenerationParams
+}
+
+type generationParamsKey struct{}
+
+// WithGenerationParams makes text generation with ctx use p over the
+// service's defaults.
+func WithGenerationParams(ctx context.Context, p GenerationParams) context.Context {
+	return context.WithValue(ctx, generationParamsKey{}, p)
+}
+
+func generationParams(ctx context.Context) GenerationParams {
+	p, _ := ctx.Value(generationParamsKey{}).(GenerationParams)
+	return p
+}
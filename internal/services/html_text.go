@@ -0,0 +1,204 @@
+package services
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// skippedHTMLElements hold no resume content: scripts, styling, site
+// navigation, footers and interactive controls.
+var skippedHTMLElements = map[atom.Atom]bool{
+	atom.Head: true, atom.Script: true, atom.Style: true, atom.Noscript: true,
+	atom.Template: true, atom.Svg: true, atom.Iframe: true, atom.Nav: true,
+	atom.Footer: true, atom.Form: true, atom.Button: true, atom.Select: true,
+}
+
+// skippedHTMLRoles are ARIA landmarks around the content rather than in it.
+var skippedHTMLRoles = map[string]bool{
+	"navigation": true, "contentinfo": true, "search": true, "dialog": true,
+	"alertdialog": true,
+}
+
+// blockHTMLElements start a line of their own.
+var blockHTMLElements = map[atom.Atom]bool{
+	atom.Address: true, atom.Article: true, atom.Aside: true, atom.Blockquote: true,
+	atom.Dd: true, atom.Div: true, atom.Dl: true, atom.Dt: true, atom.Figcaption: true,
+	atom.Figure: true, atom.H1: true, atom.H2: true, atom.H3: true, atom.H4: true,
+	atom.H5: true, atom.H6: true, atom.Header: true, atom.Hr: true, atom.Li: true,
+	atom.Main: true, atom.Ol: true, atom.P: true, atom.Pre: true, atom.Section: true,
+	atom.Table: true, atom.Tr: true, atom.Ul: true, atom.Br: true,
+}
+
+// HTMLToText extracts the readable text of an HTML document, such as a
+// resume exported from a web builder or a saved LinkedIn profile. Markup,
+// scripts, navigation and footers are dropped; headings and list items keep
+// a line of their own so sections can still be told apart.
+func HTMLToText(r io.Reader) (string, error) {
+	doc, err := html.Parse(r)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse HTML: %w", err)
+	}
+
+	var w htmlTextWriter
+	w.walk(doc)
+	return w.String(), nil
+}
+
+// readHTMLFile extracts the text of an HTML document, which counts as a
+// single page.
+func readHTMLFile(filePath string) (*PDFContent, error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read HTML file: %w", err)
+	}
+	defer f.Close()
+
+	text, err := HTMLToText(f)
+	if err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(text) == "" {
+		return nil, fmt.Errorf("no text content found in HTML")
+	}
+
+	return &PDFContent{
+		Text:      text,
+		PageCount: 1,
+		FilePath:  filePath,
+	}, nil
+}
+
+type htmlTextWriter struct {
+	lines []string
+	line  strings.Builder
+	pre   int
+}
+
+func (w *htmlTextWriter) walk(n *html.Node) {
+	switch n.Type {
+	case html.TextNode:
+		w.text(n.Data)
+		return
+	case html.ElementNode:
+		if skippedHTMLElements[n.DataAtom] || hiddenHTMLElement(n) {
+			return
+		}
+	}
+
+	block := n.Type == html.ElementNode && blockHTMLElements[n.DataAtom]
+	if block {
+		w.breakLine()
+	}
+	switch n.DataAtom {
+	case atom.Li:
+		w.line.WriteString("- ")
+	case atom.Td, atom.Th:
+		if w.line.Len() > 0 {
+			w.line.WriteString(" | ")
+		}
+	case atom.Pre:
+		w.pre++
+		defer func() { w.pre-- }()
+	}
+
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		w.walk(c)
+	}
+
+	if block {
+		w.breakLine()
+		// Paragraphs and headings are set apart like in the rendered page
+		switch n.DataAtom {
+		case atom.P, atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6, atom.Section, atom.Table, atom.Ul, atom.Ol:
+			w.blankLine()
+		}
+	}
+}
+
+func (w *htmlTextWriter) text(data string) {
+	if w.pre > 0 {
+		for i, part := range strings.Split(data, "\n") {
+			if i > 0 {
+				w.breakLine()
+			}
+			w.line.WriteString(part)
+		}
+		return
+	}
+
+	words := strings.Fields(data)
+	if len(words) == 0 {
+		if w.line.Len() > 0 && data != "" {
+			w.line.WriteString(" ")
+		}
+		return
+	}
+	current := w.line.String()
+	if current != "" && !strings.HasSuffix(current, " ") && startsWithSpace(data) {
+		w.line.WriteString(" ")
+	}
+	w.line.WriteString(strings.Join(words, " "))
+	if endsWithSpace(data) {
+		w.line.WriteString(" ")
+	}
+}
+
+func (w *htmlTextWriter) breakLine() {
+	if line := strings.TrimSpace(w.line.String()); line != "" && line != "-" {
+		w.lines = append(w.lines, line)
+	}
+	w.line.Reset()
+}
+
+func (w *htmlTextWriter) blankLine() {
+	if len(w.lines) > 0 && w.lines[len(w.lines)-1] != "" {
+		w.lines = append(w.lines, "")
+	}
+}
+
+func (w *htmlTextWriter) String() string {
+	w.breakLine()
+	return strings.TrimSpace(strings.Join(w.lines, "\n"))
+}
+
+// hiddenHTMLElement reports whether n isn't shown or is an ARIA landmark
+// around the content.
+func hiddenHTMLElement(n *html.Node) bool {
+	for _, a := range n.Attr {
+		switch strings.ToLower(a.Key) {
+		case "hidden":
+			return true
+		case "aria-hidden":
+			if a.Val == "true" {
+				return true
+			}
+		case "role":
+			if skippedHTMLRoles[strings.ToLower(a.Val)] {
+				return true
+			}
+		case "style":
+			style := strings.ReplaceAll(strings.ToLower(a.Val), " ", "")
+			if strings.Contains(style, "display:none") || strings.Contains(style, "visibility:hidden") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func startsWithSpace(s string) bool {
+	r, _ := utf8.DecodeRuneInString(s)
+	return unicode.IsSpace(r)
+}
+
+func endsWithSpace(s string) bool {
+	r, _ := utf8.DecodeLastRuneInString(s)
+	return unicode.IsSpace(r)
+}
@@ -0,0 +1,135 @@
+package services
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+)
+
+const (
+	// hybridCandidateFactor widens each ranking before fusion so chunks
+	// ranked just below the cut-off by one method can still win overall.
+	hybridCandidateFactor = 4
+	maxHybridKeywords     = 32
+	maxKeywordCandidates  = 100
+	// rrfK dampens the weight of top ranks in reciprocal rank fusion; 60 is
+	// the value from the original RRF paper.
+	rrfK = 60
+)
+
+// extractKeywords returns up to limit of the most frequent terms in text,
+// ignoring stopwords and very short tokens. Ties keep first-seen order.
+func extractKeywords(text string, limit int) []string {
+	counts := make(map[string]int)
+	var order []string
+
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '+' && r != '#'
+	})
+	for _, w := range words {
+		if len([]rune(w)) < 3 || keywordStopwords[w] {
+			continue
+		}
+		if counts[w] == 0 {
+			order = append(order, w)
+		}
+		counts[w]++
+	}
+
+	sort.SliceStable(order, func(i, j int) bool {
+		return counts[order[i]] > counts[order[j]]
+	})
+	if len(order) > limit {
+		order = order[:limit]
+	}
+
+	return order
+}
+
+// rankByKeywords orders results by how many distinct keywords their text
+// contains.
+func rankByKeywords(results []SearchResult, keywords []string) []SearchResult {
+	hits := make([]int, len(results))
+	for i, r := range results {
+		text := strings.ToLower(r.Text)
+		for _, k := range keywords {
+			if strings.Contains(text, k) {
+				hits[i]++
+			}
+		}
+	}
+
+	idx := make([]int, len(results))
+	for i := range idx {
+		idx[i] = i
+	}
+	sort.SliceStable(idx, func(a, b int) bool {
+		return hits[idx[a]] > hits[idx[b]]
+	})
+
+	ranked := make([]SearchResult, len(results))
+	for i, j := range idx {
+		ranked[i] = results[j]
+	}
+
+	return ranked
+}
+
+// fuseRankings merges rankings with reciprocal rank fusion. A chunk found by
+// several rankings keeps the first non-zero score it was returned with.
+func fuseRankings(rankings ...[]SearchResult) []SearchResult {
+	type fused struct {
+		result SearchResult
+		score  float64
+	}
+
+	byKey := make(map[string]*fused)
+	var order []string
+	for _, ranking := range rankings {
+		for rank, r := range ranking {
+			key := r.ID + "\x00" + r.Text
+			f, ok := byKey[key]
+			if !ok {
+				f = &fused{result: r}
+				byKey[key] = f
+				order = append(order, key)
+			} else if f.result.Score == 0 {
+				f.result.Score = r.Score
+			}
+			f.score += 1 / float64(rrfK+rank+1)
+		}
+	}
+
+	sort.SliceStable(order, func(i, j int) bool {
+		return byKey[order[i]].score > byKey[order[j]].score
+	})
+
+	results := make([]SearchResult, len(order))
+	for i, key := range order {
+		results[i] = byKey[key].result
+	}
+
+	return results
+}
+
+func truncateResults(results []SearchResult, limit int) []SearchResult {
+	if limit > 0 && len(results) > limit {
+		return results[:limit]
+	}
+	return results
+}
+
+var keywordStopwords = map[string]bool{
+	"the": true, "and": true, "for": true, "with": true, "that": true,
+	"this": true, "from": true, "are": true, "was": true, "were": true,
+	"have": true, "has": true, "had": true, "you": true, "your": true,
+	"our": true, "their": true, "will": true, "can": true, "not": true,
+	"but": true, "all": true, "any": true, "into": true, "over": true,
+	"using": true, "used": true, "use": true, "such": true, "also": true,
+	"about": true, "more": true, "other": true, "than": true, "been": true,
+	"which": true, "who": true, "what": true, "when": true, "where": true,
+	"how": true, "its": true, "they": true, "them": true, "she": true,
+	"his": true, "her": true, "him": true, "per": true, "via": true,
+	"including": true, "within": true, "across": true, "well": true,
+	"page": true,
+}
@@ -0,0 +1,246 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"alfredoptarigan/cv-evaluator/internal/models"
+	"alfredoptarigan/cv-evaluator/internal/repositories"
+	"alfredoptarigan/cv-evaluator/internal/tenant"
+)
+
+const (
+	referenceChunkSize    = 1000
+	referenceChunkOverlap = 200
+)
+
+// ReferenceDocTypes are the reference document types retrieval looks for.
+var ReferenceDocTypes = []string{"job_description", "case_study", "cv_rubric", "project_rubric"}
+
+// ErrReferenceNotFound is returned for documents that weren't ingested.
+var ErrReferenceNotFound = errors.New("reference document not found")
+
+// ReferenceDocument is a reference PDF (job description, case study or
+// rubric) to embed into the vector store.
+type ReferenceDocument struct {
+	Path    string
+	DocType string
+	// Name is shown as the chunks' source; it defaults to the file name.
+	Name string
+	// Job is the job title the document is retrieved for (see JobKey);
+	// empty for documents used in evaluations for every job.
+	Job string
+}
+
+// ID identifies the document in the vector store. It is derived from the
+// file name, so re-ingesting a file replaces its chunks instead of adding
+// copies.
+func (d ReferenceDocument) ID() string {
+	return strings.ToLower(strings.TrimSuffix(filepath.Base(d.Path), filepath.Ext(d.Path)))
+}
+
+// IngestResult tells what Ingest did with a document.
+type IngestResult struct {
+	Chunks int
+	// Unchanged is set when the document was skipped because the same
+	// content was ingested before with the same type, name, job and
+	// embedding model.
+	Unchanged bool
+}
+
+// ReferenceIngester parses, chunks and embeds reference documents. Documents
+// ingested with a tenant on the context (see tenant.WithID) are visible to
+// that tenant only; without one they are shared with every tenant.
+type ReferenceIngester interface {
+	// Ingest stores the document's chunks, replacing the ones of an
+	// earlier version. Unless force is set, a document whose content hash
+	// matches its last ingestion is skipped.
+	Ingest(ctx context.Context, doc ReferenceDocument, force bool) (IngestResult, error)
+	// List returns the ingested documents of every tenant.
+	List() ([]models.IngestedReference, error)
+	// Delete removes the chunks of an ingested document. It returns
+	// ErrReferenceNotFound when the document wasn't ingested for the
+	// tenant on ctx (or shared, without one).
+	Delete(ctx context.Context, documentID string) error
+	// Reset drops every chunk and forgets past ingestions.
+	Reset() error
+}
+
+type referenceIngester struct {
+	pdfParser     PDFParserService
+	chunker       TextChuncker
+	geminiService GeminiService
+	vectorStore   VectorStore
+	refRepo       repositories.IngestedReferenceRepository
+}
+
+func NewReferenceIngester(
+	pdfParser PDFParserService,
+	chunker TextChuncker,
+	geminiService GeminiService,
+	vectorStore VectorStore,
+	refRepo repositories.IngestedReferenceRepository,
+) ReferenceIngester {
+	return &referenceIngester{
+		pdfParser:     pdfParser,
+		chunker:       chunker,
+		geminiService: geminiService,
+		vectorStore:   vectorStore,
+		refRepo:       refRepo,
+	}
+}
+
+// Ingest implements ReferenceIngester.
+func (r *referenceIngester) Ingest(ctx context.Context, doc ReferenceDocument, force bool) (IngestResult, error) {
+	if doc.Name == "" {
+		doc.Name = filepath.Base(doc.Path)
+	}
+	doc.Job = JobKey(doc.Job)
+	tenantID, _ := tenant.Lookup(ctx)
+
+	hash, err := hashFile(doc.Path)
+	if err != nil {
+		return IngestResult{}, err
+	}
+
+	previous, err := r.refRepo.Find(tenantID, doc.ID())
+	if err != nil {
+		return IngestResult{}, err
+	}
+	if previous != nil && !force &&
+		previous.ContentHash == hash &&
+		previous.DocType == doc.DocType &&
+		previous.Name == doc.Name &&
+		previous.Job == doc.Job &&
+		previous.EmbeddingModel == r.geminiService.EmbeddingModel() {
+		return IngestResult{Chunks: previous.Chunks, Unchanged: true}, nil
+	}
+
+	// Upserting alone would leave the trailing chunks of a longer old
+	// version behind
+	if previous != nil {
+		if err := r.vectorStore.DeleteDocument(ctx, doc.ID()); err != nil {
+			return IngestResult{}, fmt.Errorf("failed to delete old chunks: %w", err)
+		}
+	}
+
+	chunks, err := r.embed(ctx, doc)
+	if err != nil {
+		return IngestResult{}, err
+	}
+
+	err = r.refRepo.Save(&models.IngestedReference{
+		TenantID:       tenantID,
+		DocumentID:     doc.ID(),
+		DocType:        doc.DocType,
+		Name:           doc.Name,
+		Job:            doc.Job,
+		ContentHash:    hash,
+		EmbeddingModel: r.geminiService.EmbeddingModel(),
+		Chunks:         chunks,
+		IngestedAt:     time.Now(),
+	})
+	if err != nil {
+		return IngestResult{}, err
+	}
+
+	return IngestResult{Chunks: chunks}, nil
+}
+
+// List implements ReferenceIngester.
+func (r *referenceIngester) List() ([]models.IngestedReference, error) {
+	return r.refRepo.List()
+}
+
+// Delete implements ReferenceIngester.
+func (r *referenceIngester) Delete(ctx context.Context, documentID string) error {
+	tenantID, _ := tenant.Lookup(ctx)
+
+	previous, err := r.refRepo.Find(tenantID, documentID)
+	if err != nil {
+		return err
+	}
+	if previous == nil {
+		return ErrReferenceNotFound
+	}
+
+	if err := r.vectorStore.DeleteDocument(ctx, documentID); err != nil {
+		return fmt.Errorf("failed to delete chunks: %w", err)
+	}
+	return r.refRepo.Delete(tenantID, documentID)
+}
+
+// Reset implements ReferenceIngester.
+func (r *referenceIngester) Reset() error {
+	if err := r.vectorStore.ResetCollection(); err != nil {
+		return fmt.Errorf("failed to reset collection: %w", err)
+	}
+	return r.refRepo.DeleteAll()
+}
+
+// embed parses, chunks and embeds the document and returns how many chunks
+// it stored.
+func (r *referenceIngester) embed(ctx context.Context, doc ReferenceDocument) (int, error) {
+	content, err := r.pdfParser.ExtractTextWithMetaData(doc.Path)
+	if err != nil {
+		return 0, fmt.Errorf("failed to extract text: %w", err)
+	}
+	log.Printf("   ✅ Extracted %d pages, %d characters", content.PageCount, len(content.Text))
+
+	// Chunk the text along its sections
+	chunks := AssignPages(r.chunker.ChunkCV(content.Text, referenceChunkSize, referenceChunkOverlap))
+	texts := make([]string, len(chunks))
+	for i, chunk := range chunks {
+		texts[i] = chunk.Text
+	}
+
+	embeddings, err := r.geminiService.EmbedBatch(ctx, texts)
+	if err != nil {
+		return 0, fmt.Errorf("failed to generate embeddings: %w", err)
+	}
+
+	stored := 0
+	for i, chunk := range chunks {
+		metadata := map[string]interface{}{
+			"source":          doc.Name,
+			"page":            chunk.Page,
+			"embedding_model": r.geminiService.EmbeddingModel(),
+		}
+		if chunk.Section != "" {
+			metadata["section"] = chunk.Section
+		}
+		if doc.Job != "" {
+			metadata[jobMetadataKey] = doc.Job
+		}
+
+		if err := r.vectorStore.UpsertDocument(ctx, doc.ID(), i, doc.DocType, chunk.Text, embeddings[i], metadata); err != nil {
+			return stored, fmt.Errorf("failed to store chunk %d: %w", i+1, err)
+		}
+		stored++
+	}
+
+	return stored, nil
+}
+
+func hashFile(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", fmt.Errorf("failed to hash %s: %w", path, err)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,235 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"alfredoptarigan/cv-evaluator/internal/models"
+)
+
+// decodeJSON unmarshals the JSON value in an LLM response into target. The
+// value is cut out of any surrounding prose or markdown, and if it still
+// doesn't parse, repaired (see repairJSON) and tried once more. The error is
+// the one from the unrepaired value.
+func decodeJSON(response string, target interface{}) error {
+	candidate := extractJSON(response)
+	err := json.Unmarshal([]byte(candidate), target)
+	if err == nil {
+		return nil
+	}
+
+	if repaired := repairJSON(candidate); repaired != candidate {
+		if json.Unmarshal([]byte(repaired), target) == nil {
+			log.Println("🔧 Repaired malformed JSON in LLM response")
+			return nil
+		}
+	}
+	return err
+}
+
+// extractJSON returns the JSON object or array in text, which might be
+// wrapped in markdown or prose. Brackets are matched, ignoring those inside
+// strings, so braces in the surrounding text don't end up in the value. The
+// first complete, valid object wins, then the first valid array; failing
+// that, the value from the first bracket on is returned, cut short if it was
+// never closed, for repairJSON to fix.
+func extractJSON(text string) string {
+	fallback := ""
+	for _, open := range []byte("{[") {
+		for i := strings.IndexByte(text, open); i >= 0; {
+			span, closed := balancedSpan(text, i)
+			if closed && json.Valid([]byte(span)) {
+				return span
+			}
+			if fallback == "" {
+				fallback = span
+			}
+
+			next := strings.IndexByte(text[i+1:], open)
+			if next < 0 {
+				break
+			}
+			i += next + 1
+		}
+	}
+
+	if fallback != "" {
+		return fallback
+	}
+	return strings.TrimSpace(text)
+}
+
+// balancedSpan returns text from the bracket at start up to its matching
+// bracket, or to the end of text if it isn't closed.
+func balancedSpan(text string, start int) (span string, closed bool) {
+	depth := 0
+	inString, escaped := false, false
+
+	for i := start; i < len(text); i++ {
+		c := text[i]
+		if inString {
+			switch {
+			case escaped:
+				escaped = false
+			case c == '\\':
+				escaped = true
+			case c == '"':
+				inString = false
+			}
+			continue
+		}
+
+		switch c {
+		case '"':
+			inString = true
+		case '{', '[':
+			depth++
+		case '}', ']':
+			depth--
+			if depth == 0 {
+				return text[start : i+1], true
+			}
+		}
+	}
+
+	return text[start:], false
+}
+
+// repairJSON fixes the mistakes LLMs make most in JSON: trailing commas, raw
+// newlines and tabs inside strings, and values cut off before their closing
+// quote or brackets.
+func repairJSON(text string) string {
+	var b strings.Builder
+	var open []byte
+	inString, escaped := false, false
+
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+		if inString {
+			switch {
+			case escaped:
+				escaped = false
+			case c == '\\':
+				escaped = true
+			case c == '"':
+				inString = false
+			case c == '\n':
+				b.WriteString(`\n`)
+				continue
+			case c == '\r':
+				b.WriteString(`\r`)
+				continue
+			case c == '\t':
+				b.WriteString(`\t`)
+				continue
+			}
+			b.WriteByte(c)
+			continue
+		}
+
+		switch c {
+		case '"':
+			inString = true
+		case '{':
+			open = append(open, '}')
+		case '[':
+			open = append(open, ']')
+		case '}', ']':
+			if len(open) > 0 {
+				open = open[:len(open)-1]
+			}
+		case ',':
+			// Drop commas with nothing after them in their object or array
+			rest := strings.TrimSpace(text[i+1:])
+			if rest == "" || rest[0] == '}' || rest[0] == ']' {
+				continue
+			}
+		}
+		b.WriteByte(c)
+	}
+
+	if inString {
+		if escaped {
+			b.WriteByte('\\')
+		}
+		b.WriteByte('"')
+	}
+	repaired := strings.TrimRight(b.String(), " \t\r\n,")
+	for i := len(open) - 1; i >= 0; i-- {
+		repaired += string(open[i])
+	}
+	return repaired
+}
+
+// errEmptyResponse is returned for an LLM response without any text.
+// Evaluations fail on it rather than store made-up results.
+var errEmptyResponse = errors.New("empty response from the LLM")
+
+// correctResponse makes the one extra LLM call a scoring stage gets when its
+// response is unusable: an empty response is asked for again, a result that
+// fails validation is sent back with the problems found, anything else is
+// reformatted as JSON.
+func (e *evaluatorService) correctResponse(ctx context.Context, stage models.ResponseStage, prompt, response string, parseErr error, example interface{}) (string, error) {
+	if errors.Is(parseErr, errEmptyResponse) {
+		log.Printf("🔧 %s response is empty, asking the LLM again\n", stage)
+		return e.regenerate(WithGenerationParams(ctx, e.generation.Scoring), stage, prompt, temperatureFor(ctx, 0.3))
+	}
+
+	var invalid *resultValidationError
+	if errors.As(parseErr, &invalid) {
+		return e.revalidate(ctx, stage, prompt, response, invalid)
+	}
+	return e.reformatJSON(ctx, stage, response, parseErr, example)
+}
+
+// regenerate sends prompt again past the LLM cache, which may hold the
+// unusable response.
+func (e *evaluatorService) regenerate(ctx context.Context, stage models.ResponseStage, prompt string, temperature float32) (string, error) {
+	response, err := e.generate(WithLLMCacheBypass(ctx), prompt, temperature)
+	if err != nil {
+		return "", fmt.Errorf("%w (failed to ask again: %w)", errEmptyResponse, err)
+	}
+	e.saveResponse(ctx, stage, response)
+
+	return response, nil
+}
+
+// revalidate asks the LLM to correct a result that failed validation.
+func (e *evaluatorService) revalidate(ctx context.Context, stage models.ResponseStage, prompt, response string, invalid *resultValidationError) (string, error) {
+	log.Printf("🔧 %s response failed validation, asking the LLM to correct it: %v\n", stage, invalid)
+
+	prompt = e.promptBuilder.BuildCorrectionPrompt(prompt, response, invalid.problems)
+	corrected, err := e.generate(WithGenerationParams(ctx, e.generation.Scoring), prompt, temperatureFor(ctx, 0.3))
+	if err != nil {
+		return "", fmt.Errorf("%w (failed to correct: %w)", invalid, err)
+	}
+	e.saveResponse(ctx, stage, corrected)
+
+	return corrected, nil
+}
+
+// reformatJSON asks the LLM to rewrite a scoring response that couldn't be
+// parsed as valid JSON with example's fields, once, and returns the new
+// response. It is stored like the original one.
+func (e *evaluatorService) reformatJSON(ctx context.Context, stage models.ResponseStage, response string, parseErr error, example interface{}) (string, error) {
+	reason, _, _ := strings.Cut(parseErr.Error(), "\n")
+	log.Printf("🔧 %s response isn't valid JSON, asking the LLM to reformat it: %s\n", stage, reason)
+
+	fields, err := json.MarshalIndent(example, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("%w (failed to reformat: %v)", parseErr, err)
+	}
+	prompt := e.promptBuilder.BuildJSONReformatPrompt(response, reason, string(fields))
+
+	reformatted, err := e.generate(WithGenerationParams(ctx, e.generation.Scoring), prompt, 0)
+	if err != nil {
+		return "", fmt.Errorf("%w (failed to reformat: %w)", parseErr, err)
+	}
+	e.saveResponse(ctx, stage, reformatted)
+
+	return reformatted, nil
+}
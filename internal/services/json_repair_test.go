@@ -0,0 +1,144 @@
+package services
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestBalancedSpan(t *testing.T) {
+	tests := []struct {
+		name   string
+		text   string
+		start  int
+		span   string
+		closed bool
+	}{
+		{"object in prose", `x {"a": 1} y`, 2, `{"a": 1}`, true},
+		{"nested", `{"a": [1, {"b": 2}]} [3]`, 0, `{"a": [1, {"b": 2}]}`, true},
+		{"brace inside a string", `{"a": "}"} tail`, 0, `{"a": "}"}`, true},
+		{"escaped quote before a brace", `{"a": "\"}"} tail`, 0, `{"a": "\"}"}`, true},
+		{"escaped backslash before the quote", `{"a": "\\"} tail`, 0, `{"a": "\\"}`, true},
+		{"array", `[1, [2]] [3]`, 0, `[1, [2]]`, true},
+		{"unclosed", `{"a": [1, 2`, 0, `{"a": [1, 2`, false},
+		{"unclosed string", `{"a": "}`, 0, `{"a": "}`, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			span, closed := balancedSpan(tt.text, tt.start)
+			if span != tt.span || closed != tt.closed {
+				t.Errorf("got %q, %v, want %q, %v", span, closed, tt.span, tt.closed)
+			}
+		})
+	}
+}
+
+func TestExtractJSON(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{"bare object", `{"a": 1}`, `{"a": 1}`},
+		{"markdown fence", "```json\n{\"a\": 1}\n```", `{"a": 1}`},
+		{"prose wrapper", `Here is the result: {"a": 1}. Let me know!`, `{"a": 1}`},
+		{"braces in the prose", `Scores use {curly} notes: {"a": 1} done`, `{"a": 1}`},
+		{"braces inside strings", `Result: {"a": "use {x} or }"} thanks`, `{"a": "use {x} or }"}`},
+		{"nested object", `Result: {"a": {"b": [1, 2]}, "c": "d"} end`, `{"a": {"b": [1, 2]}, "c": "d"}`},
+		{"object over an earlier array", `[1] then {"a": 1}`, `{"a": 1}`},
+		{"array only", `Here: [1, 2] ok`, `[1, 2]`},
+		{"truncated", `Result: {"a": [1, 2`, `{"a": [1, 2`},
+		{"trailing comma", `Result: {"a": 1,} end`, `{"a": 1,}`},
+		{"no JSON", "  no json here \n", "no json here"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := extractJSON(tt.text); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRepairJSON(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{"valid", `{"a": [1, 2], "b": "c"}`, `{"a": [1, 2], "b": "c"}`},
+		{"trailing comma in an object", `{"a": 1,}`, `{"a": 1}`},
+		{"trailing comma in an array", `[1, 2, ]`, `[1, 2 ]`},
+		{"nested trailing commas", `{"a": [1, 2,], "b": {"c": 3,},}`, `{"a": [1, 2], "b": {"c": 3}}`},
+		{"commas and brackets inside strings", `{"a": "}{,]", "b": "1,}",}`, `{"a": "}{,]", "b": "1,}"}`},
+		{"raw newline and tab in a string", "{\"a\": \"one\ntwo\tthree\r\"}", `{"a": "one\ntwo\tthree\r"}`},
+		{"truncated string", `{"a": "unfinished`, `{"a": "unfinished"}`},
+		{"truncated inside an escape", `{"a": "x\`, `{"a": "x\\"}`},
+		{"truncated nested value", `{"a": {"b": [1, 2`, `{"a": {"b": [1, 2]}}`},
+		{"truncated after a comma", `{"a": 1, `, `{"a": 1}`},
+		{"truncated in an array of objects", `{"a": [{"b": "c"}, {"d": "e"`, `{"a": [{"b": "c"}, {"d": "e"}]}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := repairJSON(tt.text)
+			if got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+			if !json.Valid([]byte(got)) {
+				t.Errorf("repaired %q isn't valid JSON", got)
+			}
+		})
+	}
+}
+
+func TestDecodeJSON(t *testing.T) {
+	type result struct {
+		Score    float64  `json:"score"`
+		Feedback string   `json:"feedback"`
+		Tags     []string `json:"tags"`
+	}
+
+	tests := []struct {
+		name     string
+		response string
+		want     result
+		wantErr  bool
+	}{
+		{
+			name:     "prose and trailing commas",
+			response: "Sure! Here it is:\n```json\n{\"score\": 4.5, \"feedback\": \"Solid {Go} skills\", \"tags\": [\"go\",],}\n```",
+			want:     result{Score: 4.5, Feedback: "Solid {Go} skills", Tags: []string{"go"}},
+		},
+		{
+			name:     "truncated",
+			response: `{"score": 3, "feedback": "Good but cut off`,
+			want:     result{Score: 3, Feedback: "Good but cut off"},
+		},
+		{
+			name:     "not JSON",
+			response: "I can't evaluate this document.",
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got result
+			err := decodeJSON(tt.response, &got)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("got %+v, want an error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("got error %v, want none", err)
+			}
+			if got.Score != tt.want.Score || got.Feedback != tt.want.Feedback || len(got.Tags) != len(tt.want.Tags) {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
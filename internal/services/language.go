@@ -0,0 +1,63 @@
+package services
+
+import (
+	"strings"
+	"unicode"
+)
+
+// languageStopwords are frequent short words that tell the languages CVs
+// usually come in apart.
+var languageStopwords = map[string][]string{
+	"en": {"the", "and", "of", "to", "in", "for", "with", "on", "is", "as", "at", "by", "from", "my", "i"},
+	"id": {"dan", "yang", "di", "untuk", "dengan", "dalam", "ke", "dari", "pada", "sebagai", "saya", "ini", "itu", "tahun"},
+	"es": {"el", "la", "de", "y", "en", "los", "las", "del", "con", "para", "por", "una", "un", "como"},
+	"pt": {"o", "a", "de", "e", "em", "os", "as", "do", "da", "com", "para", "uma", "um", "como", "no", "na"},
+	"fr": {"le", "la", "les", "de", "et", "des", "en", "du", "pour", "avec", "une", "un", "dans", "sur"},
+	"de": {"der", "die", "das", "und", "in", "mit", "für", "von", "den", "im", "ein", "eine", "auf", "zu"},
+	"nl": {"de", "het", "een", "en", "van", "in", "met", "voor", "op", "is", "als", "bij", "naar", "aan"},
+	"it": {"il", "la", "di", "e", "in", "per", "con", "del", "della", "che", "un", "una", "nel", "come"},
+}
+
+// languageSampleWords bounds how much text DetectLanguage looks at.
+const languageSampleWords = 2000
+
+// DetectLanguage guesses the ISO 639-1 code of text's language from its
+// most common words, or returns "" when there is too little to tell.
+func DetectLanguage(text string) string {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r)
+	})
+	if len(words) > languageSampleWords {
+		words = words[:languageSampleWords]
+	}
+
+	scores := make(map[string]int, len(languageStopwords))
+	for lang, stopwords := range languageStopwords {
+		set := make(map[string]bool, len(stopwords))
+		for _, w := range stopwords {
+			set[w] = true
+		}
+		for _, w := range words {
+			if set[w] {
+				scores[lang]++
+			}
+		}
+	}
+
+	best, bestScore, runnerUp := "", 0, 0
+	for lang, score := range scores {
+		switch {
+		case score > bestScore || score == bestScore && lang < best:
+			runnerUp = max(runnerUp, bestScore)
+			best, bestScore = lang, score
+		case score > runnerUp:
+			runnerUp = score
+		}
+	}
+
+	// Related languages share words, so a close call is no answer
+	if bestScore < 5 || bestScore*4 < runnerUp*5 {
+		return ""
+	}
+	return best
+}
@@ -0,0 +1,42 @@
+package services
+
+import "context"
+
+// LLMOverrides replaces the LLM settings for one evaluation. Zero values keep
+// the defaults.
+type LLMOverrides struct {
+	// Model replaces the configured generation model.
+	Model string
+	// Temperature replaces the pipeline's per-step temperatures for scoring
+	// and the summary.
+	Temperature *float32
+}
+
+type llmOverridesKey struct{}
+
+// WithLLMOverrides makes text generation with ctx use o.
+func WithLLMOverrides(ctx context.Context, o LLMOverrides) context.Context {
+	return context.WithValue(ctx, llmOverridesKey{}, o)
+}
+
+func llmOverrides(ctx context.Context) LLMOverrides {
+	o, _ := ctx.Value(llmOverridesKey{}).(LLMOverrides)
+	return o
+}
+
+// modelFor returns the model text generation with ctx uses.
+func modelFor(ctx context.Context, defaultModel string) string {
+	if model := llmOverrides(ctx).Model; model != "" {
+		return model
+	}
+	return defaultModel
+}
+
+// temperatureFor returns the temperature for a pipeline step that defaults
+// to def.
+func temperatureFor(ctx context.Context, def float32) float32 {
+	if t := llmOverrides(ctx).Temperature; t != nil {
+		return *t
+	}
+	return def
+}
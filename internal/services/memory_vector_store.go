@@ -0,0 +1,191 @@
+package services
+
+import (
+	"context"
+	"log"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+
+	"alfredoptarigan/cv-evaluator/internal/tenant"
+)
+
+type memoryChunk struct {
+	tenantID  string
+	docID     string
+	index     int
+	docType   string
+	text      string
+	metadata  map[string]interface{}
+	embedding []float32
+}
+
+type memoryVectorStore struct {
+	mu     sync.RWMutex
+	chunks []memoryChunk
+}
+
+// NewMemoryVectorStore keeps chunks in process and searches them by brute
+// force. Nothing is persisted, so it is meant for development only.
+func NewMemoryVectorStore() VectorStore {
+	return &memoryVectorStore{}
+}
+
+// InitCollection implements VectorStore.
+func (m *memoryVectorStore) InitCollection() error {
+	log.Println("⚠️  Using in-memory vector store; chunks are lost on restart")
+	return nil
+}
+
+// ResetCollection implements VectorStore.
+func (m *memoryVectorStore) ResetCollection() error {
+	m.mu.Lock()
+	m.chunks = nil
+	m.mu.Unlock()
+
+	return nil
+}
+
+// UpsertDocument implements VectorStore.
+func (m *memoryVectorStore) UpsertDocument(ctx context.Context, docID string, chunkIndex int, docType string, text string, embedding []float32, metadata map[string]interface{}) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	chunk := memoryChunk{
+		tenantID:  tenantScope(ctx),
+		docID:     docID,
+		index:     chunkIndex,
+		docType:   docType,
+		text:      text,
+		metadata:  metadata,
+		embedding: embedding,
+	}
+	for i, c := range m.chunks {
+		if c.tenantID == chunk.tenantID && c.docID == docID && c.index == chunkIndex {
+			m.chunks[i] = chunk
+			return nil
+		}
+	}
+
+	m.chunks = append(m.chunks, chunk)
+	return nil
+}
+
+// SearchSimilar implements VectorStore.
+func (m *memoryVectorStore) SearchSimilar(ctx context.Context, queryEmbedding []float32, docType string, limit int) ([]SearchResult, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	tenantID, job := tenant.FromContext(ctx), jobScope(ctx)
+	var results []SearchResult
+	for _, c := range m.chunks {
+		if !c.visibleTo(tenantID, job) || docType != "" && c.docType != docType {
+			continue
+		}
+		results = append(results, c.result(cosineSimilarity(queryEmbedding, c.embedding)))
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Score > results[j].Score
+	})
+
+	return truncateResults(results, limit), nil
+}
+
+// HybridSearch implements VectorStore.
+func (m *memoryVectorStore) HybridSearch(ctx context.Context, queryEmbedding []float32, queryText string, docType string, limit int) ([]SearchResult, error) {
+	candidates := limit * hybridCandidateFactor
+
+	dense, err := m.SearchSimilar(ctx, queryEmbedding, docType, candidates)
+	if err != nil {
+		return nil, err
+	}
+
+	keywords := extractKeywords(queryText, maxHybridKeywords)
+	if len(keywords) == 0 {
+		return truncateResults(dense, limit), nil
+	}
+
+	tenantID, job := tenant.FromContext(ctx), jobScope(ctx)
+	m.mu.RLock()
+	var matches []SearchResult
+	for _, c := range m.chunks {
+		if !c.visibleTo(tenantID, job) || docType != "" && c.docType != docType {
+			continue
+		}
+		text := strings.ToLower(c.text)
+		for _, k := range keywords {
+			if strings.Contains(text, k) {
+				matches = append(matches, c.result(0))
+				break
+			}
+		}
+	}
+	m.mu.RUnlock()
+
+	sparse := truncateResults(rankByKeywords(matches, keywords), candidates)
+	return truncateResults(fuseRankings(dense, sparse), limit), nil
+}
+
+// DeleteDocument implements VectorStore.
+func (m *memoryVectorStore) DeleteDocument(ctx context.Context, docID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	scope := tenantScope(ctx)
+	kept := m.chunks[:0]
+	for _, c := range m.chunks {
+		if c.docID != docID || c.tenantID != scope {
+			kept = append(kept, c)
+		}
+	}
+	m.chunks = kept
+
+	return nil
+}
+
+func (c memoryChunk) visibleTo(tenantID, job string) bool {
+	if job != "" && chunkJob(c.metadata) != "" && chunkJob(c.metadata) != job {
+		return false
+	}
+	return c.tenantID == "" || c.tenantID == tenantID
+}
+
+func (c memoryChunk) result(score float32) SearchResult {
+	result := SearchResult{
+		ID:       c.docID,
+		Score:    score,
+		Text:     c.text,
+		DocType:  c.docType,
+		Metadata: make(map[string]interface{}, len(c.metadata)),
+	}
+	for k, v := range c.metadata {
+		result.Metadata[k] = v
+	}
+	result.Metadata["chunk_index"] = c.index
+
+	result.Source, _ = c.metadata["source"].(string)
+	result.Section, _ = c.metadata["section"].(string)
+	result.Page, _ = c.metadata["page"].(int)
+
+	return result
+}
+
+func cosineSimilarity(a, b []float32) float32 {
+	if len(a) != len(b) || len(a) == 0 {
+		return 0
+	}
+
+	var dot, normA, normB float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		normA += float64(a[i]) * float64(a[i])
+		normB += float64(b[i]) * float64(b[i])
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+
+	return float32(dot / (math.Sqrt(normA) * math.Sqrt(normB)))
+}
@@ -0,0 +1,224 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+
+	"alfredoptarigan/cv-evaluator/internal/models"
+)
+
+// Canned responses of the mock provider. The scores are consistent with
+// the prompts' weights: a 3.75 CV average is a 0.75 match rate.
+const (
+	mockCVEvaluation = `{
+  "technical_skills_score": 4,
+  "experience_level_score": 3,
+  "achievements_score": 4,
+  "cultural_fit_score": 4,
+  "weighted_average": 3.75,
+  "match_rate": 0.75,
+  "feedback": "Mock evaluation: the CV covers most of the backend requirements. Experience is somewhat below the requested level."
+}`
+	mockProjectEvaluation = `{
+  "correctness_score": 4,
+  "code_quality_score": 4,
+  "resilience_score": 3,
+  "documentation_score": 4,
+  "creativity_score": 3,
+  "weighted_average": 3.7,
+  "project_score": 3.7,
+  "feedback": "Mock evaluation: the project implements the requested pipeline. Error handling could be more thorough."
+}`
+	mockSummary = "Mock summary: a solid backend candidate with a working take-home project. Gaps in experience and resilience are worth probing in the interview. Recommendation: Hire."
+	mockText    = "Mock response."
+)
+
+var (
+	mockScoreCount = regexp.MustCompile(`exactly (\d+) scores`)
+	mockWordLimit  = regexp.MustCompile(`at most (\d+) words`)
+)
+
+type mockGeminiService struct {
+	dims int
+
+	mu   sync.Mutex
+	jobs map[string][]BatchResult
+}
+
+// NewMockGeminiService answers offline and deterministically, for tests and
+// demos: canned evaluation scores and summaries, and embeddings hashed from
+// the words of the text, so texts sharing words are close. Token usage is
+// estimated from the text length. It implements GeminiBatchAPI with batch
+// jobs that finish immediately.
+func NewMockGeminiService(embedDims int) GeminiService {
+	if embedDims <= 0 {
+		embedDims = 768
+	}
+	return &mockGeminiService{dims: embedDims, jobs: make(map[string][]BatchResult)}
+}
+
+// ModelName implements GeminiService.
+func (m *mockGeminiService) ModelName() string {
+	return "mock"
+}
+
+// EmbeddingModel implements GeminiService.
+func (m *mockGeminiService) EmbeddingModel() string {
+	return fmt.Sprintf("mock-embedding@%d", m.dims)
+}
+
+// GenerateEmbedding implements GeminiService.
+func (m *mockGeminiService) GenerateEmbedding(ctx context.Context, text string) ([]float32, error) {
+	return m.embed(text), nil
+}
+
+// EmbedBatch implements GeminiService.
+func (m *mockGeminiService) EmbedBatch(ctx context.Context, texts []string) ([][]float32, error) {
+	embeddings := make([][]float32, len(texts))
+	for i, text := range texts {
+		embeddings[i] = m.embed(text)
+	}
+	return embeddings, nil
+}
+
+// embed hashes each word into a dimension and normalizes the counts.
+func (m *mockGeminiService) embed(text string) []float32 {
+	vector := make([]float32, m.dims)
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for _, word := range words {
+		h := fnv.New32a()
+		h.Write([]byte(word))
+		vector[h.Sum32()%uint32(m.dims)]++
+	}
+
+	var norm float64
+	for _, v := range vector {
+		norm += float64(v * v)
+	}
+	if norm == 0 {
+		vector[0] = 1
+		return vector
+	}
+	for i := range vector {
+		vector[i] /= float32(math.Sqrt(norm))
+	}
+	return vector
+}
+
+// GenerateText implements GeminiService. The response is picked by the
+// kind of prompt.
+func (m *mockGeminiService) GenerateText(ctx context.Context, prompt string, temperature float32) (string, error) {
+	text := mockResponse(prompt)
+	recordTokenUsage(ctx, int64(EstimateTokens(prompt)), int64(EstimateTokens(text)))
+
+	if stream := textStreamFrom(ctx); stream != nil {
+		stream.Reset()
+		for _, word := range strings.SplitAfter(text, " ") {
+			stream.Write(word)
+		}
+	}
+	return text, nil
+}
+
+// GenerateTextWithRetry implements GeminiService. The mock never fails.
+func (m *mockGeminiService) GenerateTextWithRetry(ctx context.Context, prompt string, temperature float32, maxRetries int) (string, error) {
+	return m.GenerateText(ctx, prompt, temperature)
+}
+
+// SubmitBatch implements GeminiBatchAPI.
+func (m *mockGeminiService) SubmitBatch(ctx context.Context, model string, requests []models.LLMBatchRequest) (string, error) {
+	results := make([]BatchResult, len(requests))
+	for i, req := range requests {
+		text := mockResponse(req.Prompt)
+		results[i] = BatchResult{
+			Text:             text,
+			PromptTokens:     int64(EstimateTokens(req.Prompt)),
+			CompletionTokens: int64(EstimateTokens(text)),
+		}
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	name := "mock-batches/" + strconv.Itoa(len(m.jobs)+1)
+	m.jobs[name] = results
+	return name, nil
+}
+
+// BatchStatus implements GeminiBatchAPI.
+func (m *mockGeminiService) BatchStatus(ctx context.Context, jobName string) (*BatchJobStatus, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	results, ok := m.jobs[jobName]
+	if !ok {
+		return nil, fmt.Errorf("unknown batch job %s", jobName)
+	}
+	return &BatchJobStatus{Done: true, Results: results}, nil
+}
+
+// mockResponse answers the prompts of PromptBuilder in the format they ask
+// for. Prompts are told apart by their opening sentence, since the
+// documents they embed may contain anything.
+func mockResponse(prompt string) string {
+	switch {
+	case strings.HasPrefix(prompt, "You are an expert HR recruiter evaluating a candidate's CV"):
+		return mockCVEvaluation
+	case strings.HasPrefix(prompt, "You are an expert technical evaluator assessing a candidate's project report"):
+		return mockProjectEvaluation
+	case strings.HasPrefix(prompt, "You are selecting reference material"):
+		// The instructions come after the passages
+		count := 0
+		if matches := mockScoreCount.FindAllStringSubmatch(prompt, -1); matches != nil {
+			count, _ = strconv.Atoi(matches[len(matches)-1][1])
+		}
+		scores := make([]string, count)
+		for i := range scores {
+			// Keep the search order
+			scores[i] = strconv.Itoa(max(10-i, 1))
+		}
+		return `{"scores": [` + strings.Join(scores, ", ") + `]}`
+	case strings.HasPrefix(prompt, "You are fixing a response"):
+		// Answer with the evaluation whose fields are expected
+		fields, _, _ := strings.Cut(prompt, "RESPONSE:")
+		if strings.Contains(fields, `"technical_skills_score"`) {
+			return mockCVEvaluation
+		}
+		return mockProjectEvaluation
+	case strings.HasPrefix(prompt, "You are condensing part"):
+		return mockCondense(prompt)
+	case strings.HasPrefix(prompt, "You are an expert technical hiring manager making a final assessment"):
+		return mockSummary
+	default:
+		return mockText
+	}
+}
+
+// mockCondense "summarizes" a document part by keeping its first words.
+func mockCondense(prompt string) string {
+	part, instructions := prompt, ""
+	if start := strings.Index(part, "DOCUMENT PART:\n"); start >= 0 {
+		part = part[start+len("DOCUMENT PART:\n"):]
+	}
+	if end := strings.LastIndex(part, "\n\nSummarize this part"); end >= 0 {
+		part, instructions = part[:end], part[end:]
+	}
+
+	limit := 200
+	if match := mockWordLimit.FindStringSubmatch(instructions); match != nil {
+		limit, _ = strconv.Atoi(match[1])
+	}
+	words := strings.Fields(part)
+	if len(words) > limit {
+		words = words[:limit]
+	}
+	return strings.Join(words, " ")
+}
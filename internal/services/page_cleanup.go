@@ -0,0 +1,122 @@
+package services
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// pageEdgeLines is how many non-empty lines at the top and bottom of a page
+// are checked for running headers and footers.
+const pageEdgeLines = 3
+
+var (
+	// pageNumberLine matches page numbering such as "Page 2 of 3" (as in
+	// LinkedIn's "Save to PDF"), "2 / 3" or "- 2 -".
+	pageNumberLine = regexp.MustCompile(`(?i)^(page\s*)?-?\s*\d{1,3}\s*-?\s*((of|/)\s*\d{1,3})?$`)
+	digits         = regexp.MustCompile(`\d+`)
+)
+
+// CleanPages removes what a PDF repeats on every page rather than says once:
+// page numbers and running headers and footers near the page edges, keeping
+// the first occurrence of a repeated line (often the candidate's name). It
+// also drops the private-use glyphs some exporters emit for icons and
+// normalizes non-breaking spaces.
+func CleanPages(pages []string) []string {
+	lines := make([][]string, len(pages))
+	for i, page := range pages {
+		lines[i] = strings.Split(normalizePageText(page), "\n")
+	}
+
+	// A line counts as running if it sits at the edge of at least half the
+	// pages, and of two at least
+	seenOn := make(map[string]int)
+	for _, page := range lines {
+		for _, key := range uniqueKeys(edgeLines(page)) {
+			seenOn[key]++
+		}
+	}
+	minPages := max(2, (len(pages)+1)/2)
+
+	kept := make(map[string]bool)
+	cleaned := make([]string, len(pages))
+	for i, page := range lines {
+		edges := edgeLines(page)
+		var b strings.Builder
+		for j, line := range page {
+			if edges[j] != "" {
+				key := edges[j]
+				if pageNumberLine.MatchString(strings.TrimSpace(line)) {
+					continue
+				}
+				if seenOn[key] >= minPages {
+					if kept[key] {
+						continue
+					}
+					kept[key] = true
+				}
+			}
+			b.WriteString(line)
+			b.WriteString("\n")
+		}
+		cleaned[i] = b.String()
+	}
+
+	return cleaned
+}
+
+// edgeLines returns, by line index, the comparison key of the first and
+// last few non-empty lines of a page; other lines map to "".
+func edgeLines(page []string) map[int]string {
+	var nonEmpty []int
+	for i, line := range page {
+		if strings.TrimSpace(line) != "" {
+			nonEmpty = append(nonEmpty, i)
+		}
+	}
+
+	edges := make(map[int]string)
+	for k, i := range nonEmpty {
+		if k < pageEdgeLines || k >= len(nonEmpty)-pageEdgeLines {
+			edges[i] = lineKey(page[i])
+		}
+	}
+	return edges
+}
+
+func uniqueKeys(edges map[int]string) []string {
+	seen := make(map[string]bool, len(edges))
+	keys := make([]string, 0, len(edges))
+	for _, key := range edges {
+		if !seen[key] {
+			seen[key] = true
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// lineKey compares lines ignoring case, spacing and numbers, so "Page 1"
+// and "Page 2" match.
+func lineKey(line string) string {
+	line = digits.ReplaceAllString(strings.ToLower(line), "#")
+	return strings.Join(strings.Fields(line), " ")
+}
+
+func normalizePageText(text string) string {
+	text = strings.Map(func(r rune) rune {
+		switch {
+		case r == '\u00a0' || r == '\u2007' || r == '\u202f':
+			return ' '
+		case r == '\u200b' || r == '\ufeff' || unicode.Is(unicode.Co, r):
+			return -1
+		}
+		return r
+	}, text)
+
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRightFunc(line, unicode.IsSpace)
+	}
+	return strings.Join(lines, "\n")
+}
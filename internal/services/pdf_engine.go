@@ -0,0 +1,226 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ledongthuc/pdf"
+)
+
+const (
+	// PDFEngineBuiltin is the pure Go parser (ledongthuc/pdf).
+	PDFEngineBuiltin = "builtin"
+	// PDFEnginePdftotext runs poppler's pdftotext, which copes better with
+	// unusual fonts and malformed files.
+	PDFEnginePdftotext = "pdftotext"
+)
+
+// pdftotextTimeout stops a pdftotext run stuck on a pathological file. The
+// evaluator has its own, usually shorter, parse timeout on top.
+const pdftotextTimeout = 2 * time.Minute
+
+var errNoPDFText = errors.New("no text content found in PDF")
+
+// ErrPDFEncrypted is returned by engines for a password-protected PDF opened
+// without its password, or with the wrong one.
+var ErrPDFEncrypted = errors.New("PDF is password-protected")
+
+// ErrDocumentTooLong is returned for documents past the ParseLimits.
+var ErrDocumentTooLong = errors.New("document is too long")
+
+// ParseLimits cap the documents a parser accepts, so a huge upload is
+// rejected instead of blowing the prompt budget. Zero means no limit.
+type ParseLimits struct {
+	MaxPages int
+	// MaxTextLength caps the extracted text, in characters.
+	MaxTextLength int
+}
+
+// PageOptions tune a PDFEngine run.
+type PageOptions struct {
+	// Password opens encrypted files and is ignored otherwise. Only the
+	// built-in engine takes one.
+	Password string
+	// MaxPages, if positive, makes the engine give up on longer documents
+	// with ErrDocumentTooLong before extracting them.
+	MaxPages int
+}
+
+// PDFPage is the raw text of one page.
+type PDFPage struct {
+	Number int
+	Text   string
+}
+
+// PDFEngine extracts the text of each page of a PDF.
+type PDFEngine interface {
+	Name() string
+	// Pages returns the pages that have text and the document's page count.
+	Pages(filePath string, opts PageOptions) ([]PDFPage, int, error)
+}
+
+// NewPDFEngine returns the engine called name. pdftotextPath is the
+// pdftotext binary, looked up in PATH unless it contains a slash.
+func NewPDFEngine(name, pdftotextPath string) (PDFEngine, error) {
+	switch name {
+	case PDFEngineBuiltin:
+		return NewBuiltinPDFEngine(), nil
+	case PDFEnginePdftotext:
+		path, err := exec.LookPath(pdftotextPath)
+		if err != nil {
+			return nil, fmt.Errorf("pdftotext not found (install poppler-utils or set PDFTOTEXT_PATH): %w", err)
+		}
+		return &pdftotextEngine{path: path}, nil
+	default:
+		return nil, fmt.Errorf("unknown PDF engine %q", name)
+	}
+}
+
+// NewPDFEngines returns the engines to try in order: primary, then fallback
+// if set.
+func NewPDFEngines(primary, fallback, pdftotextPath string) ([]PDFEngine, error) {
+	names := []string{primary}
+	if fallback != "" {
+		names = append(names, fallback)
+	}
+
+	engines := make([]PDFEngine, 0, len(names))
+	for _, name := range names {
+		engine, err := NewPDFEngine(name, pdftotextPath)
+		if err != nil {
+			return nil, err
+		}
+		engines = append(engines, engine)
+	}
+	return engines, nil
+}
+
+type builtinPDFEngine struct{}
+
+func NewBuiltinPDFEngine() PDFEngine {
+	return builtinPDFEngine{}
+}
+
+func (builtinPDFEngine) Name() string { return PDFEngineBuiltin }
+
+// Pages implements PDFEngine.
+func (builtinPDFEngine) Pages(filePath string, opts PageOptions) ([]PDFPage, int, error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to open PDF: %w", err)
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to open PDF: %w", err)
+	}
+
+	// The reader asks for passwords until it gets an empty one
+	tried := false
+	r, err := pdf.NewReaderEncrypted(f, info.Size(), func() string {
+		if tried {
+			return ""
+		}
+		tried = true
+		return opts.Password
+	})
+	if errors.Is(err, pdf.ErrInvalidPassword) {
+		return nil, 0, encryptedError(opts.Password)
+	}
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to open PDF: %w", err)
+	}
+
+	var pages []PDFPage
+	totalPage := r.NumPage()
+	if opts.MaxPages > 0 && totalPage > opts.MaxPages {
+		return nil, 0, fmt.Errorf("%w: %d pages, at most %d allowed", ErrDocumentTooLong, totalPage, opts.MaxPages)
+	}
+
+	for pageIndex := 1; pageIndex <= totalPage; pageIndex++ {
+		page := r.Page(pageIndex)
+		if page.V.IsNull() {
+			continue
+		}
+
+		text, err := page.GetPlainText(nil)
+		if err != nil {
+			// Log error but continue with other pages
+			continue
+		}
+		pages = append(pages, PDFPage{Number: pageIndex, Text: text})
+	}
+
+	return pages, totalPage, nil
+}
+
+type pdftotextEngine struct {
+	path string
+}
+
+func (e *pdftotextEngine) Name() string { return PDFEnginePdftotext }
+
+// Pages implements PDFEngine. pdftotext ends every page with a form feed.
+// With MaxPages it stops one page past the limit, as it can't tell the page
+// count up front. It refuses passwords: pdftotext only takes them on its
+// command line, where any user of the host can read them.
+func (e *pdftotextEngine) Pages(filePath string, opts PageOptions) ([]PDFPage, int, error) {
+	if opts.Password != "" {
+		return nil, 0, errors.New("pdftotext can't open password-protected PDFs")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), pdftotextTimeout)
+	defer cancel()
+
+	args := []string{"-enc", "UTF-8", "-eol", "unix"}
+	if opts.MaxPages > 0 {
+		args = append(args, "-l", strconv.Itoa(opts.MaxPages+1))
+	}
+	args = append(args, filePath, "-")
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, e.path, args...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if strings.Contains(stderr.String(), "Incorrect password") {
+			return nil, 0, encryptedError(opts.Password)
+		}
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, 0, fmt.Errorf("pdftotext failed: %w: %s", err, msg)
+		}
+		return nil, 0, fmt.Errorf("pdftotext failed: %w", err)
+	}
+
+	texts := strings.Split(stdout.String(), "\f")
+	// The text after the last form feed is empty
+	if len(texts) > 1 {
+		texts = texts[:len(texts)-1]
+	}
+	if opts.MaxPages > 0 && len(texts) > opts.MaxPages {
+		return nil, 0, fmt.Errorf("%w: more than %d pages", ErrDocumentTooLong, opts.MaxPages)
+	}
+
+	var pages []PDFPage
+	for i, text := range texts {
+		if strings.TrimSpace(text) != "" {
+			pages = append(pages, PDFPage{Number: i + 1, Text: text})
+		}
+	}
+
+	return pages, len(texts), nil
+}
+
+func encryptedError(password string) error {
+	if password != "" {
+		return fmt.Errorf("%w: the password is incorrect", ErrPDFEncrypted)
+	}
+	return ErrPDFEncrypted
+}
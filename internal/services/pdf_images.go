@@ -0,0 +1,243 @@
+package services
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"os"
+	"sort"
+
+	"github.com/ledongthuc/pdf"
+)
+
+// PDFImage is an image embedded in a PDF.
+type PDFImage struct {
+	PromptImage
+	Page int
+}
+
+const (
+	// minDiagramSide skips icons, bullets and logos.
+	minDiagramSide = 150
+	// maxInlineImageBytes keeps requests well under Gemini's inline data
+	// limit.
+	maxInlineImageBytes = 4 << 20
+)
+
+// pdfImageRef is an image XObject found while walking the pages.
+type pdfImageRef struct {
+	page          int
+	value         pdf.Value
+	width, height int
+}
+
+// ExtractPDFImages returns up to limit of the largest images embedded in the
+// PDF at filePath, in page order. JPEGs are passed through as they are and
+// raw images are converted to PNG; other encodings, and JPEGs in encrypted
+// files, are skipped.
+func ExtractPDFImages(filePath string, limit int) ([]PDFImage, error) {
+	if limit <= 0 {
+		return nil, nil
+	}
+
+	f, r, err := pdf.Open(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open PDF: %w", err)
+	}
+	defer f.Close()
+
+	refs := findPDFImages(r)
+	sort.SliceStable(refs, func(i, j int) bool {
+		return refs[i].width*refs[i].height > refs[j].width*refs[j].height
+	})
+
+	var jpegs []jpegStream
+	var images []PDFImage
+	for _, ref := range refs {
+		if len(images) == limit {
+			break
+		}
+
+		var img *PromptImage
+		switch filter := ref.value.Key("Filter"); {
+		case filter.Name() == "DCTDecode":
+			if jpegs == nil {
+				if jpegs, err = scanJPEGStreams(filePath); err != nil {
+					return nil, err
+				}
+			}
+			img = takeJPEG(jpegs, ref)
+		case filter.IsNull() || filter.Name() == "FlateDecode":
+			img = decodeRawImage(ref)
+		}
+		if img != nil && len(img.Data) <= maxInlineImageBytes {
+			images = append(images, PDFImage{PromptImage: *img, Page: ref.page})
+		}
+	}
+
+	sort.SliceStable(images, func(i, j int) bool { return images[i].Page < images[j].Page })
+	return images, nil
+}
+
+// findPDFImages lists the image XObjects big enough to be diagrams. An image
+// reused on several pages, like a letterhead, is listed once.
+func findPDFImages(r *pdf.Reader) []pdfImageRef {
+	var refs []pdfImageRef
+	seen := make(map[string]bool)
+	for pageIndex := 1; pageIndex <= r.NumPage(); pageIndex++ {
+		page := r.Page(pageIndex)
+		if page.V.IsNull() {
+			continue
+		}
+
+		xobjects := page.Resources().Key("XObject")
+		for _, name := range xobjects.Keys() {
+			x := xobjects.Key(name)
+			if x.Key("Subtype").Name() != "Image" {
+				continue
+			}
+			width, height := int(x.Key("Width").Int64()), int(x.Key("Height").Int64())
+			if width < minDiagramSide || height < minDiagramSide {
+				continue
+			}
+
+			id := fmt.Sprintf("%dx%d/%d", width, height, x.Key("Length").Int64())
+			if seen[id] {
+				continue
+			}
+			seen[id] = true
+			refs = append(refs, pdfImageRef{page: pageIndex, value: x, width: width, height: height})
+		}
+	}
+	return refs
+}
+
+// jpegStream is a JPEG stored verbatim in the file.
+type jpegStream struct {
+	data          []byte
+	width, height int
+	used          bool
+}
+
+// scanJPEGStreams finds the JPEG streams in the raw file. The PDF reader
+// can't return them undecoded, so they are matched to their XObjects by
+// size instead.
+func scanJPEGStreams(filePath string) ([]jpegStream, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read PDF: %w", err)
+	}
+
+	streams := []jpegStream{}
+	for rest := data; ; {
+		i := bytes.Index(rest, []byte("stream"))
+		if i < 0 {
+			break
+		}
+		rest = rest[i+len("stream"):]
+		body := bytes.TrimPrefix(bytes.TrimPrefix(rest, []byte("\r")), []byte("\n"))
+		if !bytes.HasPrefix(body, []byte{0xFF, 0xD8, 0xFF}) {
+			continue
+		}
+		end := bytes.Index(body, []byte("endstream"))
+		if end < 0 {
+			break
+		}
+
+		jpg := bytes.TrimRight(body[:end], "\r\n")
+		if cfg, err := jpeg.DecodeConfig(bytes.NewReader(jpg)); err == nil {
+			streams = append(streams, jpegStream{data: jpg, width: cfg.Width, height: cfg.Height})
+		}
+		rest = body[end:]
+	}
+	return streams, nil
+}
+
+// takeJPEG returns the first unused JPEG stream of ref's size.
+func takeJPEG(streams []jpegStream, ref pdfImageRef) *PromptImage {
+	for i := range streams {
+		s := &streams[i]
+		if !s.used && s.width == ref.width && s.height == ref.height {
+			s.used = true
+			return &PromptImage{MIMEType: "image/jpeg", Data: s.data}
+		}
+	}
+	return nil
+}
+
+// decodeRawImage converts an 8-bit gray, RGB or CMYK image to PNG. The PDF
+// reader panics on filter parameters it doesn't support, which just skips
+// the image.
+func decodeRawImage(ref pdfImageRef) (img *PromptImage) {
+	defer func() {
+		if recover() != nil {
+			img = nil
+		}
+	}()
+
+	if ref.value.Key("BitsPerComponent").Int64() != 8 {
+		return nil
+	}
+	components := colorComponents(ref.value.Key("ColorSpace"))
+	if components == 0 {
+		return nil
+	}
+
+	size := ref.width * ref.height * components
+	samples, err := io.ReadAll(io.LimitReader(ref.value.Reader(), int64(size)))
+	if err != nil || len(samples) < size {
+		return nil
+	}
+
+	rect := image.Rect(0, 0, ref.width, ref.height)
+	var decoded image.Image
+	switch components {
+	case 1:
+		decoded = &image.Gray{Pix: samples, Stride: ref.width, Rect: rect}
+	case 3:
+		rgba := image.NewNRGBA(rect)
+		for i, j := 0, 0; i < len(samples); i, j = i+3, j+4 {
+			copy(rgba.Pix[j:j+3], samples[i:i+3])
+			rgba.Pix[j+3] = 0xFF
+		}
+		decoded = rgba
+	case 4:
+		decoded = &image.CMYK{Pix: samples, Stride: ref.width * 4, Rect: rect}
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, decoded); err != nil {
+		return nil
+	}
+	return &PromptImage{MIMEType: "image/png", Data: buf.Bytes()}
+}
+
+// colorComponents returns the number of components of a color space, or 0
+// for ones decodeRawImage doesn't handle, like indexed colors.
+func colorComponents(cs pdf.Value) int {
+	name := cs.Name()
+	if cs.Kind() == pdf.Array && cs.Len() > 0 {
+		name = cs.Index(0).Name()
+		if name == "ICCBased" && cs.Len() > 1 {
+			switch n := int(cs.Index(1).Key("N").Int64()); n {
+			case 1, 3, 4:
+				return n
+			}
+			return 0
+		}
+	}
+
+	switch name {
+	case "DeviceGray", "CalGray":
+		return 1
+	case "DeviceRGB", "CalRGB":
+		return 3
+	case "DeviceCMYK":
+		return 4
+	default:
+		return 0
+	}
+}
@@ -1,16 +1,23 @@
 package services
 
 import (
+	"errors"
 	"fmt"
+	"log"
 	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
 	"strings"
-
-	"github.com/ledongthuc/pdf"
+	"unicode/utf8"
 )
 
 type PDFParserService interface {
 	ExtractText(filepath string) (string, error)
 	ExtractTextWithMetaData(filepath string) (*PDFContent, error)
+	// ExtractProtectedText is ExtractTextWithMetaData for a PDF that may be
+	// encrypted with password; a missing or wrong one gives ErrPDFEncrypted.
+	ExtractProtectedText(filepath, password string) (*PDFContent, error)
 }
 
 type PDFContent struct {
@@ -19,90 +26,224 @@ type PDFContent struct {
 	FilePath  string
 }
 
-type pdfParserService struct{}
-
-func NewPDFParserService() PDFParserService {
-	return &pdfParserService{}
+type pdfParserService struct {
+	limits ParseLimits
+	// engines are tried in order until one finds text.
+	engines []PDFEngine
 }
 
-func (p *pdfParserService) ExtractText(filePath string) (string, error) {
-	f, r, err := pdf.Open(filePath)
-	if err != nil {
-		return "", fmt.Errorf("failed to open PDF: %w", err)
+// NewPDFParserService returns a parser that tries engines in order on each
+// PDF until one extracts text; without engines it uses the built-in one.
+// Documents past limits fail with ErrDocumentTooLong.
+func NewPDFParserService(limits ParseLimits, engines ...PDFEngine) PDFParserService {
+	if len(engines) == 0 {
+		engines = []PDFEngine{NewBuiltinPDFEngine()}
 	}
-	defer f.Close()
-
-	var textBuilder strings.Builder
-	totalPage := r.NumPage()
-
-	for pageIndex := 1; pageIndex <= totalPage; pageIndex++ {
-		page := r.Page(pageIndex)
-		if page.V.IsNull() {
-			continue
-		}
+	return &pdfParserService{limits: limits, engines: engines}
+}
 
-		text, err := page.GetPlainText(nil)
+func (p *pdfParserService) ExtractText(filePath string) (string, error) {
+	if FileTypeForExtension(filepath.Ext(filePath)) != FileTypePDF {
+		content, err := p.ExtractTextWithMetaData(filePath)
 		if err != nil {
-			// Log error but continue with other pages
-			continue
+			return "", err
 		}
+		return content.Text, nil
+	}
 
-		textBuilder.WriteString(text)
-		textBuilder.WriteString("\n\n")
+	pages, _, err := p.readPages(filePath, "")
+	if err != nil {
+		return "", err
 	}
 
-	text := textBuilder.String()
-	if strings.TrimSpace(text) == "" {
-		return "", fmt.Errorf("no text content found in PDF")
+	var textBuilder strings.Builder
+	for _, page := range pages {
+		textBuilder.WriteString(page.Text)
+		textBuilder.WriteString("\n\n")
 	}
 
-	return text, nil
+	return textBuilder.String(), nil
 }
 
 func (p *pdfParserService) ExtractTextWithMetaData(filePath string) (*PDFContent, error) {
+	return p.ExtractProtectedText(filePath, "")
+}
+
+// ExtractProtectedText implements PDFParserService.
+func (p *pdfParserService) ExtractProtectedText(filePath, password string) (*PDFContent, error) {
 	// Check if file exists
 	if _, err := os.Stat(filePath); os.IsNotExist(err) {
 		return nil, fmt.Errorf("file does not exist: %s", filePath)
 	}
+	var readFile func(string) (*PDFContent, error)
+	switch FileTypeForExtension(filepath.Ext(filePath)) {
+	case FileTypeText:
+		readFile = readTextFile
+	case FileTypeHTML:
+		readFile = readHTMLFile
+	case FileTypeDOCX:
+		readFile = readDOCXFile
+	}
+	if readFile != nil {
+		content, err := readFile(filePath)
+		if err != nil {
+			return nil, err
+		}
+		if err := p.checkTextLength(utf8.RuneCountInString(content.Text)); err != nil {
+			return nil, err
+		}
+		return content, nil
+	}
 
-	f, r, err := pdf.Open(filePath)
+	pages, totalPage, err := p.readPages(filePath, password)
 	if err != nil {
-		return nil, fmt.Errorf("failed to open PDF: %w", err)
+		return nil, err
 	}
-	defer f.Close()
 
 	var textBuilder strings.Builder
-	totalPage := r.NumPage()
+	for _, page := range pages {
+		textBuilder.WriteString(fmt.Sprintf(pageMarkerFormat, page.Number))
+		textBuilder.WriteString(page.Text)
+		textBuilder.WriteString("\n\n")
+	}
+
+	return &PDFContent{
+		Text:      textBuilder.String(),
+		PageCount: totalPage,
+		FilePath:  filePath,
+	}, nil
+}
 
-	for pageIndex := 1; pageIndex <= totalPage; pageIndex++ {
-		page := r.Page(pageIndex)
-		if page.V.IsNull() {
-			continue
+// readPages extracts the pages with the first engine that finds any text,
+// cleaned of running headers and footers, and returns the page count.
+// A wrong or missing password or a document past the limits isn't the
+// engine's fault, so it is returned without trying the others.
+func (p *pdfParserService) readPages(filePath, password string) ([]PDFPage, int, error) {
+	// Protected PDFs are only opened in process, so the password never
+	// shows up in another process's arguments
+	engines := p.engines
+	if password != "" {
+		engines = []PDFEngine{NewBuiltinPDFEngine()}
+	}
+
+	var err error
+	for i, engine := range engines {
+		var pages []PDFPage
+		var totalPage int
+		pages, totalPage, err = extractPages(engine, filePath, PageOptions{Password: password, MaxPages: p.limits.MaxPages})
+		if errors.Is(err, ErrPDFEncrypted) || errors.Is(err, ErrDocumentTooLong) {
+			return nil, 0, err
+		}
+		if err == nil && !pagesHaveText(pages) {
+			err = errNoPDFText
+		}
+		if err == nil {
+			texts := make([]string, len(pages))
+			for i, page := range pages {
+				texts[i] = page.Text
+			}
+			length := 0
+			for i, text := range CleanPages(texts) {
+				pages[i].Text = text
+				length += utf8.RuneCountInString(text)
+			}
+			if err := p.checkTextLength(length); err != nil {
+				return nil, 0, err
+			}
+			return pages, totalPage, nil
 		}
 
-		text, err := page.GetPlainText(nil)
-		if err != nil {
-			// Log error but continue with other pages
-			continue
+		if i+1 < len(engines) {
+			log.Printf("⚠️  %s PDF engine failed on %s, trying %s: %v\n", engine.Name(), filepath.Base(filePath), engines[i+1].Name(), err)
 		}
+	}
+	return nil, 0, err
+}
 
-		textBuilder.WriteString(fmt.Sprintf("--- Page %d ---\n", pageIndex))
-		textBuilder.WriteString(text)
-		textBuilder.WriteString("\n\n")
+// extractPages runs engine, turning a panic on a malformed file into an
+// error so the next engine gets a chance.
+func extractPages(engine PDFEngine, filePath string, opts PageOptions) (pages []PDFPage, totalPage int, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%s PDF engine panicked: %v", engine.Name(), r)
+		}
+	}()
+	return engine.Pages(filePath, opts)
+}
+
+func (p *pdfParserService) checkTextLength(length int) error {
+	if p.limits.MaxTextLength > 0 && length > p.limits.MaxTextLength {
+		return fmt.Errorf("%w: %d characters of text, at most %d allowed", ErrDocumentTooLong, length, p.limits.MaxTextLength)
+	}
+	return nil
+}
+
+func pagesHaveText(pages []PDFPage) bool {
+	for _, page := range pages {
+		if strings.TrimSpace(page.Text) != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// readTextFile reads a plain text or Markdown document, which counts as a
+// single page.
+func readTextFile(filePath string) (*PDFContent, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read text file: %w", err)
 	}
 
-	text := textBuilder.String()
+	text := string(data)
 	if strings.TrimSpace(text) == "" {
-		return nil, fmt.Errorf("no text content found in PDF")
+		return nil, fmt.Errorf("no text content found in file")
 	}
 
 	return &PDFContent{
 		Text:      text,
-		PageCount: totalPage,
+		PageCount: 1,
 		FilePath:  filePath,
 	}, nil
 }
 
+// pageMarkerFormat separates pages in ExtractTextWithMetaData output.
+const pageMarkerFormat = "--- Page %d ---\n"
+
+var pageMarker = regexp.MustCompile(`(?m)^--- Page (\d+) ---\n?`)
+
+// AssignPages sets Page on chunks of ExtractTextWithMetaData output from the
+// page markers they contain, then strips the markers from their text. Chunks
+// must be in document order, since a chunk without a marker continues the
+// page of the one before it.
+func AssignPages(chunks []TextChunk) []TextChunk {
+	page := 1
+	result := make([]TextChunk, 0, len(chunks))
+
+	for _, chunk := range chunks {
+		markers := pageMarker.FindAllStringSubmatchIndex(chunk.Text, -1)
+		chunk.Page = page
+		for i, m := range markers {
+			n, err := strconv.Atoi(chunk.Text[m[2]:m[3]])
+			if err != nil {
+				continue
+			}
+			// A marker at the very start means the chunk opens the page
+			if i == 0 && strings.TrimSpace(chunk.Text[:m[0]]) == "" {
+				chunk.Page = n
+			}
+			page = n
+		}
+
+		chunk.Text = strings.TrimSpace(pageMarker.ReplaceAllString(chunk.Text, ""))
+		if chunk.Text != "" {
+			result = append(result, chunk)
+		}
+	}
+
+	return result
+}
+
 // Helper function to clean and normalize text
 func CleanText(text string) string {
 	// Remove excessive whitespace
@@ -0,0 +1,225 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	"gorm.io/gorm"
+
+	"alfredoptarigan/cv-evaluator/internal/tenant"
+)
+
+type pgvectorStore struct {
+	db         *gorm.DB
+	dimensions int
+}
+
+// NewPgvectorStore keeps chunks in the vector_chunks table of the main
+// database, for deployments that don't run Qdrant. The pgvector extension
+// must be available on the server.
+func NewPgvectorStore(db *gorm.DB, dimensions int) VectorStore {
+	return &pgvectorStore{db: db, dimensions: dimensions}
+}
+
+type pgvectorRow struct {
+	DocID    string
+	DocType  string
+	Text     string
+	Metadata string
+	Score    float64
+}
+
+// InitCollection implements VectorStore. The table is created here rather
+// than by a migration so the extension is only required when this backend
+// is selected.
+func (p *pgvectorStore) InitCollection() error {
+	statements := []string{
+		`CREATE EXTENSION IF NOT EXISTS vector`,
+		fmt.Sprintf(`CREATE TABLE IF NOT EXISTS vector_chunks (
+			id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+			doc_id TEXT NOT NULL,
+			doc_type TEXT NOT NULL,
+			text TEXT NOT NULL,
+			metadata JSONB NOT NULL DEFAULT '{}',
+			embedding vector(%d) NOT NULL,
+			created_at TIMESTAMP NOT NULL DEFAULT now()
+		)`, p.dimensions),
+		`ALTER TABLE vector_chunks ADD COLUMN IF NOT EXISTS tenant_id TEXT NOT NULL DEFAULT ''`,
+		`CREATE INDEX IF NOT EXISTS idx_vector_chunks_tenant_id ON vector_chunks (tenant_id)`,
+		`CREATE INDEX IF NOT EXISTS idx_vector_chunks_doc_id ON vector_chunks (doc_id)`,
+		`CREATE INDEX IF NOT EXISTS idx_vector_chunks_doc_type ON vector_chunks (doc_type)`,
+		`CREATE INDEX IF NOT EXISTS idx_vector_chunks_embedding ON vector_chunks USING hnsw (embedding vector_cosine_ops)`,
+		`CREATE INDEX IF NOT EXISTS idx_vector_chunks_text ON vector_chunks USING gin (to_tsvector('simple', text))`,
+	}
+
+	for _, stmt := range statements {
+		if err := p.db.Exec(stmt).Error; err != nil {
+			return fmt.Errorf("failed to initialize pgvector store: %w", err)
+		}
+	}
+
+	// For vector columns the type modifier is the dimension
+	var dimensions int
+	err := p.db.Raw(`SELECT atttypmod FROM pg_attribute
+		WHERE attrelid = 'vector_chunks'::regclass AND attname = 'embedding'`).Scan(&dimensions).Error
+	if err != nil {
+		return fmt.Errorf("failed to check vector dimension: %w", err)
+	}
+	if dimensions != p.dimensions {
+		return fmt.Errorf("vector_chunks has %d dimensions, embeddings have %d: %w", dimensions, p.dimensions, ErrDimensionMismatch)
+	}
+
+	log.Println("✅ pgvector store initialized")
+	return nil
+}
+
+// ResetCollection implements VectorStore.
+func (p *pgvectorStore) ResetCollection() error {
+	if err := p.db.Exec(`DROP TABLE IF EXISTS vector_chunks`).Error; err != nil {
+		return fmt.Errorf("failed to drop vector_chunks: %w", err)
+	}
+
+	return p.InitCollection()
+}
+
+// UpsertDocument implements VectorStore. Shared chunks have an empty
+// tenant_id.
+func (p *pgvectorStore) UpsertDocument(ctx context.Context, docID string, chunkIndex int, docType string, text string, embedding []float32, metadata map[string]interface{}) error {
+	stored := make(map[string]interface{}, len(metadata)+1)
+	for key, value := range metadata {
+		stored[key] = value
+	}
+	stored["chunk_index"] = chunkIndex
+
+	encoded, err := json.Marshal(stored)
+	if err != nil {
+		return fmt.Errorf("failed to encode metadata: %w", err)
+	}
+
+	scope := tenantScope(ctx)
+	err = p.db.WithContext(ctx).Exec(
+		`INSERT INTO vector_chunks (id, tenant_id, doc_id, doc_type, text, metadata, embedding)
+		VALUES (?, ?, ?, ?, ?, ?::jsonb, ?::vector)
+		ON CONFLICT (id) DO UPDATE SET
+			doc_type = EXCLUDED.doc_type,
+			text = EXCLUDED.text,
+			metadata = EXCLUDED.metadata,
+			embedding = EXCLUDED.embedding`,
+		ChunkID(scope, docID, chunkIndex), scope, docID, docType, text, string(encoded), vectorLiteral(embedding),
+	).Error
+	if err != nil {
+		return fmt.Errorf("failed to upsert chunk: %w", err)
+	}
+
+	return nil
+}
+
+// SearchSimilar implements VectorStore. Scores are cosine similarities, as
+// with Qdrant.
+func (p *pgvectorStore) SearchSimilar(ctx context.Context, queryEmbedding []float32, docType string, limit int) ([]SearchResult, error) {
+	vector := vectorLiteral(queryEmbedding)
+
+	var rows []pgvectorRow
+	err := p.db.WithContext(ctx).Raw(
+		`SELECT doc_id, doc_type, text, metadata::text AS metadata, 1 - (embedding <=> ?::vector) AS score
+		FROM vector_chunks
+		WHERE (tenant_id = ? OR tenant_id = '') AND (? = '' OR doc_type = ?)
+			AND (? = '' OR COALESCE(metadata->>'job', '') IN ('', ?))
+		ORDER BY embedding <=> ?::vector
+		LIMIT ?`,
+		vector, tenant.FromContext(ctx), docType, docType, jobScope(ctx), jobScope(ctx), vector, limit,
+	).Scan(&rows).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to search: %w", err)
+	}
+
+	return pgvectorResults(rows), nil
+}
+
+// HybridSearch implements VectorStore. The keyword pass uses Postgres
+// full-text search over the same chunks.
+func (p *pgvectorStore) HybridSearch(ctx context.Context, queryEmbedding []float32, queryText string, docType string, limit int) ([]SearchResult, error) {
+	candidates := limit * hybridCandidateFactor
+
+	dense, err := p.SearchSimilar(ctx, queryEmbedding, docType, candidates)
+	if err != nil {
+		return nil, err
+	}
+
+	keywords := extractKeywords(queryText, maxHybridKeywords)
+	if len(keywords) == 0 {
+		return truncateResults(dense, limit), nil
+	}
+
+	var rows []pgvectorRow
+	err = p.db.WithContext(ctx).Raw(
+		`SELECT doc_id, doc_type, text, metadata::text AS metadata, 0 AS score
+		FROM vector_chunks, websearch_to_tsquery('simple', ?) query
+		WHERE (tenant_id = ? OR tenant_id = '') AND (? = '' OR doc_type = ?)
+			AND (? = '' OR COALESCE(metadata->>'job', '') IN ('', ?))
+			AND to_tsvector('simple', text) @@ query
+		ORDER BY ts_rank(to_tsvector('simple', text), query) DESC
+		LIMIT ?`,
+		strings.Join(keywords, " or "), tenant.FromContext(ctx), docType, docType, jobScope(ctx), jobScope(ctx), candidates,
+	).Scan(&rows).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to run keyword search: %w", err)
+	}
+
+	return truncateResults(fuseRankings(dense, pgvectorResults(rows)), limit), nil
+}
+
+// DeleteDocument implements VectorStore.
+func (p *pgvectorStore) DeleteDocument(ctx context.Context, docID string) error {
+	err := p.db.WithContext(ctx).Exec(`DELETE FROM vector_chunks WHERE doc_id = ? AND tenant_id = ?`, docID, tenantScope(ctx)).Error
+	if err != nil {
+		return fmt.Errorf("failed to delete document: %w", err)
+	}
+
+	return nil
+}
+
+func pgvectorResults(rows []pgvectorRow) []SearchResult {
+	results := make([]SearchResult, 0, len(rows))
+	for _, row := range rows {
+		result := SearchResult{
+			ID:       row.DocID,
+			Score:    float32(row.Score),
+			Text:     row.Text,
+			DocType:  row.DocType,
+			Metadata: make(map[string]interface{}),
+		}
+
+		if err := json.Unmarshal([]byte(row.Metadata), &result.Metadata); err != nil {
+			log.Printf("⚠️  Ignoring invalid metadata on chunk %s: %v\n", row.DocID, err)
+		}
+		result.Source, _ = result.Metadata["source"].(string)
+		result.Section, _ = result.Metadata["section"].(string)
+		if page, ok := result.Metadata["page"].(float64); ok {
+			result.Page = int(page)
+		}
+
+		results = append(results, result)
+	}
+
+	return results
+}
+
+// vectorLiteral formats an embedding in pgvector's text representation.
+func vectorLiteral(v []float32) string {
+	var b strings.Builder
+	b.WriteByte('[')
+	for i, f := range v {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(strconv.FormatFloat(float64(f), 'f', -1, 32))
+	}
+	b.WriteByte(']')
+
+	return b.String()
+}
@@ -0,0 +1,328 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"alfredoptarigan/cv-evaluator/internal/apperror"
+	"alfredoptarigan/cv-evaluator/internal/models"
+	"alfredoptarigan/cv-evaluator/internal/pii"
+	"alfredoptarigan/cv-evaluator/internal/repositories"
+	"alfredoptarigan/cv-evaluator/internal/tenant"
+)
+
+var ErrErasureReportNotFound = apperror.New(http.StatusNotFound, apperror.CodeNotFound, "erasure report not found")
+
+// ErasureInput identifies a data subject; at least one field must be set.
+type ErasureInput struct {
+	Email       string
+	DocumentIDs []uuid.UUID
+}
+
+// PrivacyService erases a data subject's documents and evaluations on
+// request, e.g. under the GDPR right to erasure.
+type PrivacyService interface {
+	// Erase deletes the caller tenant's documents that belong to the
+	// subject (stored file, parsed text and vectors), their upload sessions
+	// and every evaluation that used them, with the LLM cache entries and
+	// batch requests of its calls, and saves a report of it.
+	Erase(ctx context.Context, input ErasureInput) (*models.ErasureReport, error)
+	Report(ctx context.Context, id uuid.UUID) (*models.ErasureReport, error)
+}
+
+type privacyService struct {
+	docRepo           repositories.DocumentRepository
+	evalRepo          repositories.EvaluationRepository
+	uploadSessionRepo repositories.UploadSessionRepository
+	reportRepo        repositories.ErasureReportRepository
+	llmCacheRepo      repositories.LLMCacheRepository
+	llmBatchRepo      repositories.LLMBatchRepository
+	pdfParser         PDFParserService
+	storageService    StorageService
+	vectorStore       VectorStore
+}
+
+func NewPrivacyService(
+	docRepo repositories.DocumentRepository,
+	evalRepo repositories.EvaluationRepository,
+	uploadSessionRepo repositories.UploadSessionRepository,
+	reportRepo repositories.ErasureReportRepository,
+	llmCacheRepo repositories.LLMCacheRepository,
+	llmBatchRepo repositories.LLMBatchRepository,
+	pdfParser PDFParserService,
+	storageService StorageService,
+	vectorStore VectorStore,
+) PrivacyService {
+	return &privacyService{
+		docRepo:           docRepo,
+		evalRepo:          evalRepo,
+		uploadSessionRepo: uploadSessionRepo,
+		reportRepo:        reportRepo,
+		llmCacheRepo:      llmCacheRepo,
+		llmBatchRepo:      llmBatchRepo,
+		pdfParser:         pdfParser,
+		storageService:    storageService,
+		vectorStore:       vectorStore,
+	}
+}
+
+// Erase implements PrivacyService.
+func (s *privacyService) Erase(ctx context.Context, input ErasureInput) (*models.ErasureReport, error) {
+	tenantID := tenant.FromContext(ctx)
+
+	report := &models.ErasureReport{
+		TenantID:      tenantID,
+		DocumentIDs:   []uuid.UUID{},
+		EvaluationIDs: []uuid.UUID{},
+	}
+	if input.Email != "" {
+		sum := sha256.Sum256([]byte(strings.ToLower(strings.TrimSpace(input.Email))))
+		report.SubjectHash = hex.EncodeToString(sum[:])
+	}
+	fail := func(format string, args ...any) {
+		report.Failures = append(report.Failures, fmt.Sprintf(format, args...))
+	}
+
+	docs, err := s.subjectDocuments(tenantID, input, fail)
+	if err != nil {
+		return nil, err
+	}
+
+	docIDs := make([]uuid.UUID, len(docs))
+	for i, doc := range docs {
+		docIDs[i] = doc.ID
+	}
+
+	// Evaluations go first: their feedback is about the subject, and the
+	// documents can't be deleted while referenced on databases without
+	// ON DELETE SET NULL.
+	if len(docIDs) > 0 {
+		evalIDs, err := s.evalRepo.FindIDsByDocuments(docIDs)
+		if err != nil {
+			return nil, err
+		}
+
+		// The cached responses and batched prompts of their LLM calls hold
+		// the documents' text and the feedback about them; the links to
+		// them go with the evaluations
+		if len(evalIDs) > 0 {
+			s.eraseLLMData(report, evalIDs, fail)
+		}
+
+		deleted, err := s.evalRepo.Delete(evalIDs)
+		if err != nil {
+			return nil, err
+		}
+		report.EvaluationIDs = append(report.EvaluationIDs, deleted...)
+
+		// A worker running one of the evaluations would write about the
+		// subject again after the erasure, so those are left alone with
+		// the documents they use, to be erased once they finish
+		if running := withoutIDs(evalIDs, deleted); len(running) > 0 {
+			inUse, err := s.documentsInUse(tenantID, running)
+			if err != nil {
+				return nil, err
+			}
+			for _, id := range running {
+				fail("evaluation %s is being processed; erase again once it has finished", id)
+			}
+
+			kept := docs[:0]
+			for _, doc := range docs {
+				if inUse[doc.ID] {
+					fail("document %s is used by an evaluation being processed", doc.ID)
+					continue
+				}
+				kept = append(kept, doc)
+			}
+			docs = kept
+			docIDs = docIDs[:0]
+			for _, doc := range docs {
+				docIDs = append(docIDs, doc.ID)
+			}
+		}
+
+		sessions, err := s.uploadSessionRepo.DeleteByDocuments(docIDs)
+		if err != nil {
+			fail("upload sessions: %v", err)
+		}
+		report.UploadSessionsDeleted = sessions
+	}
+
+	for _, doc := range docs {
+		if err := s.storageService.DeleteFile(doc.Filename); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			fail("file of document %s: %v", doc.ID, err)
+		} else {
+			report.FilesDeleted++
+		}
+
+		if err := s.vectorStore.DeleteDocument(tenant.WithID(ctx, doc.TenantID), doc.ID.String()); err != nil {
+			fail("vectors of document %s: %v", doc.ID, err)
+		} else {
+			report.VectorsDeleted++
+		}
+
+		if err := s.docRepo.Delete(doc.ID); err != nil {
+			fail("document %s: %v", doc.ID, err)
+			continue
+		}
+		report.DocumentIDs = append(report.DocumentIDs, doc.ID)
+	}
+
+	if err := s.reportRepo.Create(report); err != nil {
+		return nil, err
+	}
+
+	log.Printf("🗑️  Erasure %s: %d documents, %d evaluations, %d failures\n",
+		report.ID, len(report.DocumentIDs), len(report.EvaluationIDs), len(report.Failures))
+
+	return report, nil
+}
+
+// documentsInUse returns the IDs of the documents the evaluations use.
+func (s *privacyService) documentsInUse(tenantID string, evalIDs []uuid.UUID) (map[uuid.UUID]bool, error) {
+	evaluations, err := s.evalRepo.FindByIDs(tenantID, evalIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	inUse := make(map[uuid.UUID]bool)
+	for _, evaluation := range evaluations {
+		inUse[evaluation.CVDocumentID] = true
+		inUse[evaluation.ProjectDocumentID] = true
+
+		additional, err := s.evalRepo.FindAdditionalProjectDocuments(evaluation.ID)
+		if err != nil {
+			return nil, err
+		}
+		for _, id := range additional {
+			inUse[id] = true
+		}
+	}
+	return inUse, nil
+}
+
+// withoutIDs returns ids except those in exclude, in order.
+func withoutIDs(ids, exclude []uuid.UUID) []uuid.UUID {
+	skip := make(map[uuid.UUID]bool, len(exclude))
+	for _, id := range exclude {
+		skip[id] = true
+	}
+
+	var rest []uuid.UUID
+	for _, id := range ids {
+		if !skip[id] {
+			rest = append(rest, id)
+		}
+	}
+	return rest
+}
+
+// eraseLLMData deletes the LLM cache entries and batch requests made by the
+// evaluations' calls.
+func (s *privacyService) eraseLLMData(report *models.ErasureReport, evalIDs []uuid.UUID, fail func(string, ...any)) {
+	keys, err := s.evalRepo.FindLLMKeys(evalIDs)
+	if err != nil {
+		fail("llm keys: %v", err)
+		return
+	}
+
+	cached, err := s.llmCacheRepo.DeleteKeys(keys)
+	if err != nil {
+		fail("llm cache: %v", err)
+	}
+	report.LLMCacheEntriesDeleted = cached
+
+	batched, err := s.llmBatchRepo.DeleteKeys(keys)
+	if err != nil {
+		fail("llm batch requests: %v", err)
+	}
+	report.LLMBatchRequestsDeleted = batched
+}
+
+// subjectDocuments returns the tenant's documents named by ID, mentioning
+// the email or recorded with it as the candidate's. IDs of other tenants'
+// documents are ignored.
+func (s *privacyService) subjectDocuments(tenantID string, input ErasureInput, fail func(string, ...any)) ([]models.Document, error) {
+	var docs []models.Document
+	seen := make(map[uuid.UUID]bool)
+	add := func(found []models.Document) {
+		for _, doc := range found {
+			if doc.TenantID == tenantID && !seen[doc.ID] {
+				seen[doc.ID] = true
+				docs = append(docs, doc)
+			}
+		}
+	}
+
+	if len(input.DocumentIDs) > 0 {
+		found, err := s.docRepo.FindByIDs(input.DocumentIDs)
+		if err != nil {
+			return nil, err
+		}
+		add(found)
+	}
+
+	if email := strings.TrimSpace(input.Email); email != "" {
+		if err := s.parseUnparsed(tenantID, fail); err != nil {
+			return nil, err
+		}
+
+		found, err := s.docRepo.FindContaining(tenantID, email)
+		if err != nil {
+			return nil, err
+		}
+		add(found)
+
+		// Hashed contact extraction stores only the hash of the email
+		found, err = s.docRepo.FindByCandidateEmail(tenantID, []string{email, pii.HashEmail(email)})
+		if err != nil {
+			return nil, err
+		}
+		add(found)
+	}
+
+	return docs, nil
+}
+
+// parseUnparsed parses the tenant's documents that have no parsed text yet,
+// e.g. because parsing failed at upload, so the email can be searched for in
+// them. Those that still can't be parsed are recorded as failures, as the
+// subject may be named in them.
+func (s *privacyService) parseUnparsed(tenantID string, fail func(string, ...any)) error {
+	unparsed, err := s.docRepo.FindUnparsed(tenantID)
+	if err != nil {
+		return err
+	}
+
+	for _, doc := range unparsed {
+		content, err := s.pdfParser.ExtractTextWithMetaData(doc.FilePath)
+		if err != nil {
+			fail("document %s could not be parsed to search for the email; erase it by ID: %v", doc.ID, err)
+			continue
+		}
+		if err := s.docRepo.UpdateParsedContent(doc.ID, content.Text, content.PageCount); err != nil {
+			fail("document %s: %v", doc.ID, err)
+		}
+	}
+	return nil
+}
+
+// Report implements PrivacyService.
+func (s *privacyService) Report(ctx context.Context, id uuid.UUID) (*models.ErasureReport, error) {
+	report, err := s.reportRepo.FindByID(id)
+	if err != nil || report.TenantID != tenant.FromContext(ctx) {
+		return nil, ErrErasureReportNotFound
+	}
+
+	return report, nil
+}
@@ -3,6 +3,8 @@ package services
 import (
 	"fmt"
 	"strings"
+
+	"alfredoptarigan/cv-evaluator/internal/models"
 )
 
 type PromptBuilder struct{}
@@ -85,6 +87,68 @@ Be thorough and specific. Reference actual implementation details from the repor
 		caseStudyBrief, scoringRubric, projectText)
 }
 
+// BuildDiagramsNote introduces the images attached to the project prompt,
+// labelled by where they come from; empty without any.
+func (pb *PromptBuilder) BuildDiagramsNote(labels []string) string {
+	if len(labels) == 0 {
+		return ""
+	}
+
+	var list strings.Builder
+	for i, label := range labels {
+		fmt.Fprintf(&list, "%d. %s\n", i+1, label)
+	}
+	return fmt.Sprintf(`
+
+ATTACHED DIAGRAMS:
+The following images from the project report are attached after this prompt, in this order:
+%s
+Treat architecture, flow and data model diagrams as part of the report: use them as evidence for Correctness and for Documentation & Explanation. Ignore images without technical content, such as photos or logos.`, list.String())
+}
+
+// BuildExperienceTimelineNote gives the CV prompt the work history read
+// from the CV's dates and the experience level it supports; empty without
+// a timeline.
+func (pb *PromptBuilder) BuildExperienceTimelineNote(timeline *models.ExperienceTimeline, level float64) string {
+	if timeline == nil {
+		return ""
+	}
+
+	var b strings.Builder
+	for _, p := range timeline.Periods {
+		end := p.End
+		if p.Current {
+			end = "present"
+		}
+		title := p.Title
+		if title == "" {
+			title = "(untitled)"
+		}
+		fmt.Fprintf(&b, "- %s: %s to %s (%d months", title, p.Start, end, p.Months)
+		if p.Go {
+			b.WriteString(", mentions Go")
+		}
+		b.WriteString(")\n")
+	}
+	fmt.Fprintf(&b, "Total experience: %.1f years. Experience with Go: %.1f years", timeline.TotalYears, timeline.GoYears)
+	if timeline.GoInferred {
+		b.WriteString(" (Go is only mentioned outside the periods, so all of them are counted)")
+	}
+	b.WriteString(".\n")
+	for _, gap := range timeline.Gaps {
+		fmt.Fprintf(&b, "Gap: %s to %s (%d months)\n", gap.Start, gap.End, gap.Months)
+	}
+	for _, overlap := range timeline.Overlaps {
+		fmt.Fprintf(&b, "Overlap: %s to %s (%d months)\n", overlap.Start, overlap.End, overlap.Months)
+	}
+
+	return fmt.Sprintf(`
+
+EXPERIENCE TIMELINE (computed from the dates in the CV):
+%s
+Use these figures for Experience Level instead of computing durations yourself; overlapping periods are counted once. They support an experience_level_score of %g; stay within 1 point of it, adjusting for the complexity and relevance of the work. Mention significant gaps or overlaps in the feedback only if they matter for the role.`, b.String(), level)
+}
+
 // BuildFinalSummaryPrompt creates prompt for overall summary
 func (pb *PromptBuilder) BuildFinalSummaryPrompt(cvFeedback, projectFeedback string, cvMatchRate, projectScore float64, jobTitle string) string {
 	return fmt.Sprintf(`You are an expert technical hiring manager making a final assessment of a candidate for a %s position.
@@ -106,6 +170,83 @@ Return ONLY the summary text, no JSON format needed. Be direct and actionable.`,
 		jobTitle, cvMatchRate, cvFeedback, projectScore, projectFeedback)
 }
 
+// BuildSectionSummaryPrompt creates prompt for condensing one part of a long
+// document before it is evaluated
+func (pb *PromptBuilder) BuildSectionSummaryPrompt(documentKind, part string, index, total, maxWords int) string {
+	return fmt.Sprintf(`You are condensing part %d of %d of a candidate's %s so it can be evaluated as a whole.
+
+DOCUMENT PART:
+%s
+
+Summarize this part in at most %d words. Keep every concrete fact an evaluator would need: section names, roles, employers, dates, technologies, skills, responsibilities, measurable results and links. Drop filler and repetition. Do not add opinions or information that is not in the text.
+
+Return ONLY the summary text.`,
+		index, total, documentKind, part, maxWords)
+}
+
+// BuildRerankPrompt creates prompt for scoring retrieved passages against
+// the document they were retrieved for
+func (pb *PromptBuilder) BuildRerankPrompt(query string, passages []string) string {
+	var b strings.Builder
+	for i, p := range passages {
+		fmt.Fprintf(&b, "[%d]\n%s\n\n", i+1, strings.TrimSpace(p))
+	}
+
+	return fmt.Sprintf(`You are selecting reference material for evaluating a candidate's document.
+
+CANDIDATE DOCUMENT:
+%s
+
+REFERENCE PASSAGES:
+%s
+Rate how useful each passage is for evaluating this document against the role's requirements and the scoring criteria, from 0 (irrelevant) to 10 (essential).
+
+Return your response in the following JSON format, with exactly %d scores in passage order:
+{
+  "scores": [<0-10>, ...]
+}`,
+		query, b.String(), len(passages))
+}
+
+// BuildJSONReformatPrompt creates prompt for rewriting a response that
+// should have been JSON but couldn't be parsed
+func (pb *PromptBuilder) BuildJSONReformatPrompt(response, parseError, fields string) string {
+	return fmt.Sprintf(`You are fixing a response that was supposed to be a single JSON object but could not be parsed.
+
+PARSE ERROR:
+%s
+
+EXPECTED FIELDS (placeholder values):
+%s
+
+RESPONSE:
+%s
+
+Rewrite the response as one valid JSON object with exactly the expected fields. Keep every value and the wording of the feedback as it is; only fix the syntax (quotes, escaping, commas, brackets).
+
+Return ONLY the JSON object, without markdown.`,
+		parseError, fields, response)
+}
+
+// BuildCorrectionPrompt creates prompt for correcting a scoring response
+// that failed validation, repeating the original prompt
+func (pb *PromptBuilder) BuildCorrectionPrompt(prompt, response string, problems []string) string {
+	var b strings.Builder
+	for _, p := range problems {
+		fmt.Fprintf(&b, "- %s\n", p)
+	}
+
+	return fmt.Sprintf(`%s
+
+YOUR PREVIOUS RESPONSE:
+%s
+
+Your previous response has these problems:
+%s
+Return the corrected evaluation in the JSON format above, fixing every problem listed. Return ONLY the JSON object.`,
+		prompt, response, b.String())
+}
+
 // BuildRetrievalQuery creates query for RAG retrieval
 func (pb *PromptBuilder) BuildRetrievalQuery(queryType, context string) string {
 	switch queryType {
@@ -130,8 +271,18 @@ func FormatRAGContext(results []SearchResult) string {
 
 	var parts []string
 	for i, result := range results {
-		parts = append(parts, fmt.Sprintf("--- Context %d (Score: %.2f) ---\n%s",
-			i+1, result.Score, strings.TrimSpace(result.Text)))
+		label := fmt.Sprintf("Score: %.2f", result.Score)
+		if result.Source != "" {
+			label += ", Source: " + result.Source
+		}
+		if result.Page > 0 {
+			label += fmt.Sprintf(", Page: %d", result.Page)
+		}
+		if result.Section != "" {
+			label += ", Section: " + result.Section
+		}
+		parts = append(parts, fmt.Sprintf("--- Context %d (%s) ---\n%s",
+			i+1, label, strings.TrimSpace(result.Text)))
 	}
 
 	return strings.Join(parts, "\n\n")
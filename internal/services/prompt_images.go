@@ -0,0 +1,65 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+
+	"google.golang.org/genai"
+)
+
+// imageTokens is what Gemini bills for an image, whatever its size.
+const imageTokens = 258
+
+// PromptImage is an image sent along with a text generation prompt.
+type PromptImage struct {
+	MIMEType string
+	Data     []byte
+}
+
+type promptImagesKey struct{}
+
+// WithPromptImages makes text generation with ctx send images after the
+// prompt, for models that take image input.
+func WithPromptImages(ctx context.Context, images []PromptImage) context.Context {
+	if len(images) == 0 {
+		return ctx
+	}
+	return context.WithValue(ctx, promptImagesKey{}, images)
+}
+
+func promptImages(ctx context.Context) []PromptImage {
+	images, _ := ctx.Value(promptImagesKey{}).([]PromptImage)
+	return images
+}
+
+// promptContents is the request content for prompt and the images attached
+// to ctx.
+func promptContents(ctx context.Context, prompt string) []*genai.Content {
+	images := promptImages(ctx)
+	if len(images) == 0 {
+		return genai.Text(prompt)
+	}
+
+	parts := []*genai.Part{genai.NewPartFromText(prompt)}
+	for _, img := range images {
+		parts = append(parts, genai.NewPartFromBytes(img.Data, img.MIMEType))
+	}
+	return []*genai.Content{genai.NewContentFromParts(parts, genai.RoleUser)}
+}
+
+// imagesFingerprint identifies images for cache keys; empty without any.
+func imagesFingerprint(images []PromptImage) string {
+	if len(images) == 0 {
+		return ""
+	}
+
+	h := sha256.New()
+	for _, img := range images {
+		h.Write([]byte(img.MIMEType))
+		h.Write([]byte{0})
+		sum := sha256.Sum256(img.Data)
+		h.Write(sum[:])
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
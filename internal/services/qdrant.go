@@ -5,34 +5,26 @@ import (
 	"fmt"
 	"log"
 	"net/url"
+	"sort"
 	"strconv"
 
-	"github.com/google/uuid"
 	"github.com/qdrant/go-client/qdrant"
-)
-
-type QdrantService interface {
-	InitCollection() error
-	UpsertDocument(ctx context.Context, docID string, docType string, text string, embedding []float32) error
-	SearchSimilar(ctx context.Context, queryEmbedding []float32, docType string, limit int) ([]SearchResult, error)
-	DeleteDocument(ctx context.Context, docID string) error
-}
 
-type SearchResult struct {
-	ID       string
-	Score    float32
-	Text     string
-	DocType  string
-	Metadata map[string]interface{}
-}
+	"alfredoptarigan/cv-evaluator/internal/tenant"
+)
 
 type qdrantService struct {
 	client         *qdrant.Client
+	apiKey         string
 	collectionName string
 	vectorSize     uint64
+	snapshots      QdrantSnapshotOptions
+	// tenantCollections lists tenants whose chunks live in a collection of
+	// their own instead of the shared one.
+	tenantCollections map[string]bool
 }
 
-func NewQdrantService(urlStr, apiKey, collectionName string) (QdrantService, error) {
+func NewQdrantService(urlStr, apiKey, collectionName string, vectorSize int, snapshots QdrantSnapshotOptions, tenantCollections []string) (VectorStore, error) {
 	// Parse URL to extract host, port, and TLS usage
 	parsed, err := url.Parse(urlStr)
 	if err != nil {
@@ -60,31 +52,106 @@ func NewQdrantService(urlStr, apiKey, collectionName string) (QdrantService, err
 		return nil, fmt.Errorf("failed to create qdrant client: %w", err)
 	}
 
+	dedicated := make(map[string]bool, len(tenantCollections))
+	for _, t := range tenantCollections {
+		dedicated[t] = true
+	}
+
 	return &qdrantService{
-		client:         client,
-		collectionName: collectionName,
-		vectorSize:     768, // OpenAI embedding size
+		client:            client,
+		apiKey:            apiKey,
+		collectionName:    collectionName,
+		vectorSize:        uint64(vectorSize),
+		snapshots:         snapshots,
+		tenantCollections: dedicated,
 	}, nil
 }
 
-// InitCollection implements QdrantService.
+// collections returns the shared collection followed by every per-tenant one.
+func (q *qdrantService) collections() []string {
+	names := []string{q.collectionName}
+	for t := range q.tenantCollections {
+		names = append(names, q.tenantCollection(t))
+	}
+	return names
+}
+
+func (q *qdrantService) tenantCollection(tenantID string) string {
+	return q.collectionName + "_" + tenantID
+}
+
+// writeTarget returns the collection and tenant_id for chunks written with
+// ctx. Shared chunks have no tenant_id.
+func (q *qdrantService) writeTarget(ctx context.Context) (string, string) {
+	scope := tenantScope(ctx)
+	if scope != "" && q.tenantCollections[scope] {
+		return q.tenantCollection(scope), scope
+	}
+	return q.collectionName, scope
+}
+
+type scopedCollection struct {
+	name   string
+	tenant *qdrant.Condition
+}
+
+// readTargets returns the collections a search with ctx has to cover, each
+// with the condition restricting it to the tenant's and shared chunks.
+func (q *qdrantService) readTargets(ctx context.Context) []scopedCollection {
+	tenantID := tenant.FromContext(ctx)
+	shared := qdrant.NewIsEmpty("tenant_id")
+
+	if q.tenantCollections[tenantID] {
+		return []scopedCollection{
+			{name: q.tenantCollection(tenantID)},
+			{name: q.collectionName, tenant: shared},
+		}
+	}
+
+	return []scopedCollection{{
+		name: q.collectionName,
+		tenant: qdrant.NewFilterAsCondition(&qdrant.Filter{
+			Should: []*qdrant.Condition{qdrant.NewMatch("tenant_id", tenantID), shared},
+		}),
+	}}
+}
+
+// InitCollection implements VectorStore.
 func (q *qdrantService) InitCollection() error {
+	for _, name := range q.collections() {
+		if err := q.initCollection(name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (q *qdrantService) initCollection(name string) error {
 	ctx := context.Background()
 
 	// Check if collection exists
-	exists, err := q.client.CollectionExists(ctx, q.collectionName)
+	exists, err := q.client.CollectionExists(ctx, name)
 	if err != nil {
 		return fmt.Errorf("failed to check collection: %w", err)
 	}
 
 	if exists {
-		log.Println("✅ Collection already exists")
-		return nil
+		info, err := q.client.GetCollectionInfo(ctx, name)
+		if err != nil {
+			return fmt.Errorf("failed to get collection info: %w", err)
+		}
+		size := info.GetConfig().GetParams().GetVectorsConfig().GetParams().GetSize()
+		if size != q.vectorSize {
+			return fmt.Errorf("collection %s has %d dimensions, embeddings have %d: %w", name, size, q.vectorSize, ErrDimensionMismatch)
+		}
+
+		log.Printf("✅ Collection '%s' already exists\n", name)
+		return q.EnsureIndexes(ctx, name)
 	}
 
 	// Create collection
 	err = q.client.CreateCollection(ctx, &qdrant.CreateCollection{
-		CollectionName: q.collectionName,
+		CollectionName: name,
 		VectorsConfig: qdrant.NewVectorsConfig(&qdrant.VectorParams{
 			Size:     q.vectorSize,
 			Distance: qdrant.Distance_Cosine,
@@ -95,27 +162,91 @@ func (q *qdrantService) InitCollection() error {
 		return fmt.Errorf("failed to create collection: %w", err)
 	}
 
-	log.Printf("✅ Qdrant collection '%s' created successfully\n", q.collectionName)
+	log.Printf("✅ Qdrant collection '%s' created successfully\n", name)
+	return q.EnsureIndexes(ctx, name)
+}
+
+// ResetCollection implements VectorStore. Per-tenant collections are reset
+// as well.
+func (q *qdrantService) ResetCollection() error {
+	ctx := context.Background()
+
+	for _, name := range q.collections() {
+		exists, err := q.client.CollectionExists(ctx, name)
+		if err != nil {
+			return fmt.Errorf("failed to check collection: %w", err)
+		}
+		if exists {
+			if err := q.client.DeleteCollection(ctx, name); err != nil {
+				return fmt.Errorf("failed to delete collection: %w", err)
+			}
+			log.Printf("🗑️  Qdrant collection '%s' deleted\n", name)
+		}
+	}
+
+	return q.InitCollection()
+}
+
+// EnsureIndexes creates the payload indexes used by filtered searches,
+// deletes and HybridSearch. Creating an index that already exists is a
+// no-op in Qdrant, so this is safe to run on every start.
+func (q *qdrantService) EnsureIndexes(ctx context.Context, collection string) error {
+	for _, field := range []string{"doc_type", "doc_id", "tenant_id", jobMetadataKey} {
+		if err := q.createIndex(ctx, collection, field, qdrant.FieldType_FieldTypeKeyword, nil); err != nil {
+			return err
+		}
+	}
+
+	return q.createIndex(ctx, collection, "text", qdrant.FieldType_FieldTypeText, qdrant.NewPayloadIndexParamsText(&qdrant.TextIndexParams{
+		Tokenizer: qdrant.TokenizerType_Word,
+		Lowercase: qdrant.PtrOf(true),
+	}))
+}
+
+func (q *qdrantService) createIndex(ctx context.Context, collection, field string, fieldType qdrant.FieldType, params *qdrant.PayloadIndexParams) error {
+	_, err := q.client.CreateFieldIndex(ctx, &qdrant.CreateFieldIndexCollection{
+		CollectionName:   collection,
+		FieldName:        field,
+		FieldType:        fieldType.Enum(),
+		FieldIndexParams: params,
+		Wait:             qdrant.PtrOf(true),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create %s index: %w", field, err)
+	}
+
 	return nil
 }
 
-// UpsertDocument implements QdrantService.
-func (q *qdrantService) UpsertDocument(ctx context.Context, docID string, docType string, text string, embedding []float32) error {
-	pointID := uuid.New()
+// UpsertDocument implements VectorStore. Metadata is stored alongside the
+// text in the payload; it can't override doc_id, chunk_index, doc_type,
+// tenant_id or text. Chunks belong to the tenant on ctx, or are shared with
+// every tenant when ctx carries none.
+func (q *qdrantService) UpsertDocument(ctx context.Context, docID string, chunkIndex int, docType string, text string, embedding []float32, metadata map[string]interface{}) error {
+	collection, scope := q.writeTarget(ctx)
+
+	payload := make(map[string]interface{}, len(metadata)+5)
+	for key, value := range metadata {
+		payload[key] = value
+	}
+	delete(payload, "tenant_id")
+	payload["doc_id"] = docID
+	payload["chunk_index"] = chunkIndex
+	payload["doc_type"] = docType
+	payload["text"] = text
+	if scope != "" {
+		payload["tenant_id"] = scope
+	}
 
 	point := &qdrant.PointStruct{
-		Id:      qdrant.NewIDNum(uint64(pointID.ID())),
+		Id:      qdrant.NewID(ChunkID(scope, docID, chunkIndex).String()),
 		Vectors: qdrant.NewVectors(embedding...),
-		Payload: qdrant.NewValueMap(map[string]interface{}{
-			"doc_id":   docID,
-			"doc_type": docType,
-			"text":     text,
-		}),
+		Payload: qdrant.NewValueMap(payload),
 	}
 
 	// Upsert point
 	_, err := q.client.Upsert(ctx, &qdrant.UpsertPoints{
-		CollectionName: q.collectionName,
+		CollectionName: collection,
 		Points:         []*qdrant.PointStruct{point},
 	})
 	if err != nil {
@@ -125,80 +256,173 @@ func (q *qdrantService) UpsertDocument(ctx context.Context, docID string, docTyp
 	return nil
 }
 
-// SearchSimilar implements QdrantService.
+// SearchSimilar implements VectorStore. Only the tenant's own and shared
+// chunks are searched.
 func (q *qdrantService) SearchSimilar(ctx context.Context, queryEmbedding []float32, docType string, limit int) ([]SearchResult, error) {
-	var filter *qdrant.Filter
-	if docType != "" {
-		filter = &qdrant.Filter{
-			Must: []*qdrant.Condition{
-				qdrant.NewMatch("doc_type", docType),
-			},
+	var results []SearchResult
+	for _, target := range q.readTargets(ctx) {
+		points, err := q.client.Query(ctx, &qdrant.QueryPoints{
+			CollectionName: target.name,
+			Query:          qdrant.NewQuery(queryEmbedding...),
+			Filter:         searchFilter(docType, target.tenant, jobScope(ctx)),
+			Limit:          qdrant.PtrOf(uint64(limit)),
+			WithPayload:    qdrant.NewWithPayload(true),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to search: %w", err)
+		}
+
+		// Convert results
+		for _, point := range points {
+			results = append(results, toSearchResult(point.Score, point.Payload))
 		}
 	}
 
-	searchResult, err := q.client.Query(ctx, &qdrant.QueryPoints{
-		CollectionName: q.collectionName,
-		Query:          qdrant.NewQuery(queryEmbedding...),
-		Filter:         filter,
-		Limit:          qdrant.PtrOf(uint64(limit)),
-		WithPayload:    qdrant.NewWithPayload(true),
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Score > results[j].Score
 	})
 
+	return truncateResults(results, limit), nil
+}
+
+// HybridSearch implements VectorStore. It runs the vector search and a
+// keyword search over the text index for the most frequent terms of
+// queryText, then merges both rankings with reciprocal rank fusion. Scores
+// of the returned results are the vector similarity, or 0 for chunks found
+// by keywords only.
+func (q *qdrantService) HybridSearch(ctx context.Context, queryEmbedding []float32, queryText string, docType string, limit int) ([]SearchResult, error) {
+	candidates := limit * hybridCandidateFactor
+
+	dense, err := q.SearchSimilar(ctx, queryEmbedding, docType, candidates)
 	if err != nil {
-		return nil, fmt.Errorf("failed to search: %w", err)
+		return nil, err
 	}
 
-	// Convert results
-	var results []SearchResult
-	for _, point := range searchResult {
-		payload := point.Payload
+	keywords := extractKeywords(queryText, maxHybridKeywords)
+	if len(keywords) == 0 {
+		return truncateResults(dense, limit), nil
+	}
 
-		result := SearchResult{
-			Score:    point.Score,
-			Metadata: make(map[string]interface{}),
+	var matches []SearchResult
+	for _, target := range q.readTargets(ctx) {
+		filter := searchFilter(docType, target.tenant, jobScope(ctx))
+		if filter == nil {
+			filter = &qdrant.Filter{}
+		}
+		for _, k := range keywords {
+			filter.Should = append(filter.Should, qdrant.NewMatchText("text", k))
 		}
 
-		// Extract payload
-		if docID, ok := payload["doc_id"]; ok {
-			if val, ok := docID.GetKind().(*qdrant.Value_StringValue); ok {
-				result.ID = val.StringValue
-			}
+		points, err := q.client.Scroll(ctx, &qdrant.ScrollPoints{
+			CollectionName: target.name,
+			Filter:         filter,
+			Limit:          qdrant.PtrOf(uint32(maxKeywordCandidates)),
+			WithPayload:    qdrant.NewWithPayload(true),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to run keyword search: %w", err)
 		}
 
-		if text, ok := payload["text"]; ok {
-			if val, ok := text.GetKind().(*qdrant.Value_StringValue); ok {
-				result.Text = val.StringValue
-			}
+		for _, point := range points {
+			matches = append(matches, toSearchResult(0, point.Payload))
 		}
+	}
+	sparse := truncateResults(rankByKeywords(matches, keywords), candidates)
 
-		if dtype, ok := payload["doc_type"]; ok {
-			if val, ok := dtype.GetKind().(*qdrant.Value_StringValue); ok {
-				result.DocType = val.StringValue
-			}
+	return truncateResults(fuseRankings(dense, sparse), limit), nil
+}
+
+func searchFilter(docType string, tenantCondition *qdrant.Condition, job string) *qdrant.Filter {
+	var must []*qdrant.Condition
+	if docType != "" {
+		must = append(must, qdrant.NewMatch("doc_type", docType))
+	}
+	if tenantCondition != nil {
+		must = append(must, tenantCondition)
+	}
+	if job != "" {
+		must = append(must, qdrant.NewFilterAsCondition(&qdrant.Filter{
+			Should: []*qdrant.Condition{qdrant.NewMatch(jobMetadataKey, job), qdrant.NewIsEmpty(jobMetadataKey)},
+		}))
+	}
+
+	if len(must) == 0 {
+		return nil
+	}
+	return &qdrant.Filter{Must: must}
+}
+
+func toSearchResult(score float32, payload map[string]*qdrant.Value) SearchResult {
+	result := SearchResult{
+		Score:    score,
+		Metadata: make(map[string]interface{}),
+	}
+
+	// Extract payload
+	if docID, ok := payload["doc_id"]; ok {
+		if val, ok := docID.GetKind().(*qdrant.Value_StringValue); ok {
+			result.ID = val.StringValue
 		}
+	}
 
-		// Store all metadata
-		for key, value := range payload {
-			result.Metadata[key] = value
+	if text, ok := payload["text"]; ok {
+		if val, ok := text.GetKind().(*qdrant.Value_StringValue); ok {
+			result.Text = val.StringValue
 		}
+	}
 
-		results = append(results, result)
+	if dtype, ok := payload["doc_type"]; ok {
+		if val, ok := dtype.GetKind().(*qdrant.Value_StringValue); ok {
+			result.DocType = val.StringValue
+		}
 	}
 
-	return results, nil
+	if source, ok := payload["source"]; ok {
+		if val, ok := source.GetKind().(*qdrant.Value_StringValue); ok {
+			result.Source = val.StringValue
+		}
+	}
+
+	if section, ok := payload["section"]; ok {
+		if val, ok := section.GetKind().(*qdrant.Value_StringValue); ok {
+			result.Section = val.StringValue
+		}
+	}
+
+	if page, ok := payload["page"]; ok {
+		if val, ok := page.GetKind().(*qdrant.Value_IntegerValue); ok {
+			result.Page = int(val.IntegerValue)
+		}
+	}
+
+	// Store all metadata
+	for key, value := range payload {
+		result.Metadata[key] = value
+	}
+
+	return result
 }
 
-// DeleteDocument implements QdrantService.
+// DeleteDocument implements VectorStore. It only deletes chunks of the
+// tenant on ctx, or shared chunks when ctx carries no tenant.
 func (q *qdrantService) DeleteDocument(ctx context.Context, docID string) error {
+	collection, scope := q.writeTarget(ctx)
+
+	tenantCondition := qdrant.NewIsEmpty("tenant_id")
+	if scope != "" {
+		tenantCondition = qdrant.NewMatch("tenant_id", scope)
+	}
+
 	// Delete by filter
 	filter := &qdrant.Filter{
 		Must: []*qdrant.Condition{
 			qdrant.NewMatch("doc_id", docID),
+			tenantCondition,
 		},
 	}
 
 	_, err := q.client.Delete(ctx, &qdrant.DeletePoints{
-		CollectionName: q.collectionName,
+		CollectionName: collection,
 		Points: &qdrant.PointsSelector{
 			PointsSelectorOneOf: &qdrant.PointsSelector_Filter{
 				Filter: filter,
@@ -0,0 +1,211 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/qdrant/go-client/qdrant"
+
+	"alfredoptarigan/cv-evaluator/internal/models"
+)
+
+// exportPageSize is how many points are read per scroll request when
+// exporting.
+const exportPageSize = 256
+
+// VectorSnapshotter is implemented by vector stores that can back up and
+// restore their collection.
+type VectorSnapshotter interface {
+	CreateSnapshot(ctx context.Context) (*models.VectorSnapshot, error)
+	ListSnapshots(ctx context.Context) ([]models.VectorSnapshot, error)
+	RestoreSnapshot(ctx context.Context, name string) error
+	// ExportPoints writes every point as one JSON object per line and
+	// returns the number of points written.
+	ExportPoints(ctx context.Context, w io.Writer) (int, error)
+}
+
+// QdrantSnapshotOptions locates snapshots for restores, which are only
+// available through Qdrant's REST API.
+type QdrantSnapshotOptions struct {
+	// HTTPURL is the REST endpoint, e.g. http://qdrant:6333.
+	HTTPURL string
+	// Path is the snapshots directory on the Qdrant node.
+	Path string
+}
+
+// CreateSnapshot implements VectorSnapshotter.
+func (q *qdrantService) CreateSnapshot(ctx context.Context) (*models.VectorSnapshot, error) {
+	desc, err := q.client.CreateSnapshot(ctx, q.collectionName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create snapshot: %w", err)
+	}
+
+	snapshot := toVectorSnapshot(desc)
+	return &snapshot, nil
+}
+
+// ListSnapshots implements VectorSnapshotter.
+func (q *qdrantService) ListSnapshots(ctx context.Context) ([]models.VectorSnapshot, error) {
+	descs, err := q.client.ListSnapshots(ctx, q.collectionName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list snapshots: %w", err)
+	}
+
+	snapshots := make([]models.VectorSnapshot, 0, len(descs))
+	for _, desc := range descs {
+		snapshots = append(snapshots, toVectorSnapshot(desc))
+	}
+
+	return snapshots, nil
+}
+
+// RestoreSnapshot implements VectorSnapshotter. The collection is replaced
+// by the snapshot's content.
+func (q *qdrantService) RestoreSnapshot(ctx context.Context, name string) error {
+	if name == "" || strings.ContainsAny(name, `/\`) || strings.Contains(name, "..") {
+		return fmt.Errorf("invalid snapshot name: %q", name)
+	}
+
+	body, err := json.Marshal(map[string]string{
+		"location": "file://" + path.Join(q.snapshots.Path, q.collectionName, name),
+		"priority": "snapshot",
+	})
+	if err != nil {
+		return fmt.Errorf("failed to encode restore request: %w", err)
+	}
+
+	endpoint := fmt.Sprintf("%s/collections/%s/snapshots/recover",
+		strings.TrimRight(q.snapshots.HTTPURL, "/"), url.PathEscape(q.collectionName))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create restore request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if q.apiKey != "" {
+		req.Header.Set("api-key", q.apiKey)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to restore snapshot: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("failed to restore snapshot: qdrant returned %d: %s", resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+
+	return nil
+}
+
+type exportedPoint struct {
+	ID      string                 `json:"id"`
+	Vector  []float32              `json:"vector"`
+	Payload map[string]interface{} `json:"payload"`
+}
+
+// ExportPoints implements VectorSnapshotter.
+func (q *qdrantService) ExportPoints(ctx context.Context, w io.Writer) (int, error) {
+	enc := json.NewEncoder(w)
+	exported := 0
+
+	var offset *qdrant.PointId
+	for {
+		points, next, err := q.client.ScrollAndOffset(ctx, &qdrant.ScrollPoints{
+			CollectionName: q.collectionName,
+			Offset:         offset,
+			Limit:          qdrant.PtrOf(uint32(exportPageSize)),
+			WithPayload:    qdrant.NewWithPayload(true),
+			WithVectors:    qdrant.NewWithVectors(true),
+		})
+		if err != nil {
+			return exported, fmt.Errorf("failed to scroll points: %w", err)
+		}
+
+		for _, point := range points {
+			line := exportedPoint{
+				ID:      pointIDString(point.GetId()),
+				Payload: make(map[string]interface{}, len(point.GetPayload())),
+			}
+			if vector := point.GetVectors().GetVector(); vector != nil {
+				if dense := vector.GetDense(); dense != nil {
+					line.Vector = dense.GetData()
+				} else {
+					line.Vector = vector.GetData()
+				}
+			}
+			for key, value := range point.GetPayload() {
+				line.Payload[key] = qdrantValue(value)
+			}
+
+			if err := enc.Encode(line); err != nil {
+				return exported, fmt.Errorf("failed to write point: %w", err)
+			}
+			exported++
+		}
+
+		if next == nil {
+			return exported, nil
+		}
+		offset = next
+	}
+}
+
+func toVectorSnapshot(desc *qdrant.SnapshotDescription) models.VectorSnapshot {
+	snapshot := models.VectorSnapshot{
+		Name:      desc.GetName(),
+		SizeBytes: desc.GetSize(),
+	}
+	if ts := desc.GetCreationTime(); ts != nil {
+		created := ts.AsTime().In(time.UTC)
+		snapshot.CreatedAt = &created
+	}
+
+	return snapshot
+}
+
+func pointIDString(id *qdrant.PointId) string {
+	if uuid := id.GetUuid(); uuid != "" {
+		return uuid
+	}
+	return fmt.Sprintf("%d", id.GetNum())
+}
+
+// qdrantValue converts a payload value to plain Go values for JSON.
+func qdrantValue(v *qdrant.Value) interface{} {
+	switch kind := v.GetKind().(type) {
+	case *qdrant.Value_StringValue:
+		return kind.StringValue
+	case *qdrant.Value_IntegerValue:
+		return kind.IntegerValue
+	case *qdrant.Value_DoubleValue:
+		return kind.DoubleValue
+	case *qdrant.Value_BoolValue:
+		return kind.BoolValue
+	case *qdrant.Value_ListValue:
+		values := kind.ListValue.GetValues()
+		list := make([]interface{}, len(values))
+		for i, item := range values {
+			list[i] = qdrantValue(item)
+		}
+		return list
+	case *qdrant.Value_StructValue:
+		fields := kind.StructValue.GetFields()
+		obj := make(map[string]interface{}, len(fields))
+		for key, item := range fields {
+			obj[key] = qdrantValue(item)
+		}
+		return obj
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,153 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"alfredoptarigan/cv-evaluator/internal/apperror"
+	"alfredoptarigan/cv-evaluator/internal/models"
+	"alfredoptarigan/cv-evaluator/internal/repositories"
+	"alfredoptarigan/cv-evaluator/internal/tenant"
+)
+
+// QuotaService enforces monthly per-tenant limits on uploads and evaluations
+// and keeps the usage accounting behind GET /usage.
+type QuotaService interface {
+	// Check reports whether the quota is already used up, to fail early
+	// before expensive work. It reserves nothing.
+	Check(ctx context.Context, kind models.UsageKind) error
+	// Reserve counts one use against the quota, failing with QUOTA_EXCEEDED
+	// when none is left, and returns the period it was counted in. Call
+	// Release with that period if the operation then fails.
+	Reserve(ctx context.Context, kind models.UsageKind) (string, error)
+	// Release hands back a use reserved in period, which may have ended
+	// since.
+	Release(ctx context.Context, kind models.UsageKind, period string) error
+	Usage(ctx context.Context) (*models.UsageResponse, error)
+}
+
+type quotaService struct {
+	usageRepo          repositories.UsageRepository
+	monthlyUploads     int64
+	monthlyEvaluations int64
+}
+
+func NewQuotaService(usageRepo repositories.UsageRepository, monthlyUploads, monthlyEvaluations int64) QuotaService {
+	return &quotaService{
+		usageRepo:          usageRepo,
+		monthlyUploads:     monthlyUploads,
+		monthlyEvaluations: monthlyEvaluations,
+	}
+}
+
+// Check implements QuotaService.
+func (q *quotaService) Check(ctx context.Context, kind models.UsageKind) error {
+	limit := q.limitFor(kind)
+	if limit <= 0 {
+		return nil
+	}
+
+	now := time.Now().UTC()
+	counter, err := q.usageRepo.Get(tenant.FromContext(ctx), usagePeriod(now))
+	if err != nil {
+		return err
+	}
+
+	if used := usedOf(counter, kind); used >= limit {
+		return quotaExceeded(kind, limit, used, now)
+	}
+
+	return nil
+}
+
+// Reserve implements QuotaService.
+func (q *quotaService) Reserve(ctx context.Context, kind models.UsageKind) (string, error) {
+	limit := q.limitFor(kind)
+	now := time.Now().UTC()
+	period := usagePeriod(now)
+	tenantID := tenant.FromContext(ctx)
+
+	ok, err := q.usageRepo.Increment(tenantID, period, kind, 1, limit)
+	if err != nil {
+		return "", err
+	}
+	if ok {
+		return period, nil
+	}
+
+	used := limit
+	if counter, err := q.usageRepo.Get(tenantID, period); err == nil {
+		used = usedOf(counter, kind)
+	}
+	return "", quotaExceeded(kind, limit, used, now)
+}
+
+// Release implements QuotaService.
+func (q *quotaService) Release(ctx context.Context, kind models.UsageKind, period string) error {
+	_, err := q.usageRepo.Increment(tenant.FromContext(ctx), period, kind, -1, 0)
+	return err
+}
+
+// Usage implements QuotaService.
+func (q *quotaService) Usage(ctx context.Context) (*models.UsageResponse, error) {
+	now := time.Now().UTC()
+	tenantID := tenant.FromContext(ctx)
+
+	counter, err := q.usageRepo.Get(tenantID, usagePeriod(now))
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.UsageResponse{
+		TenantID: tenantID,
+		Period:   counter.Period,
+		ResetsAt: nextUsagePeriod(now),
+		Uploads: models.UsageQuota{
+			Used:  counter.Uploads,
+			Limit: q.monthlyUploads,
+		},
+		Evaluations: models.UsageQuota{
+			Used:  counter.Evaluations,
+			Limit: q.monthlyEvaluations,
+		},
+	}, nil
+}
+
+func quotaExceeded(kind models.UsageKind, limit, used int64, now time.Time) error {
+	appErr := apperror.New(
+		http.StatusTooManyRequests,
+		apperror.CodeQuotaExceeded,
+		fmt.Sprintf("Monthly %s quota of %d exceeded", kind, limit),
+	)
+	appErr.Details = map[string]interface{}{
+		"quota":     kind,
+		"limit":     limit,
+		"used":      used,
+		"resets_at": nextUsagePeriod(now),
+	}
+	return appErr
+}
+
+func usedOf(counter models.UsageCounter, kind models.UsageKind) int64 {
+	if kind == models.UsageEvaluations {
+		return counter.Evaluations
+	}
+	return counter.Uploads
+}
+
+func (q *quotaService) limitFor(kind models.UsageKind) int64 {
+	if kind == models.UsageEvaluations {
+		return q.monthlyEvaluations
+	}
+	return q.monthlyUploads
+}
+
+func usagePeriod(t time.Time) string {
+	return t.Format("2006-01")
+}
+
+func nextUsagePeriod(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,173 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"log"
+	"sync"
+	"time"
+
+	"alfredoptarigan/cv-evaluator/internal/models"
+	"alfredoptarigan/cv-evaluator/internal/repositories"
+	"alfredoptarigan/cv-evaluator/internal/tenant"
+)
+
+// reconcileGracePeriod skips files and rows that are still being written by
+// an in-flight upload.
+const reconcileGracePeriod = time.Hour
+
+// StorageReconciler finds files without a document row and document rows
+// whose file is gone, optionally deleting both.
+type StorageReconciler interface {
+	Start(ctx context.Context)
+	Stop()
+	Reconcile(ctx context.Context, cleanup bool) (*models.ReconcileReport, error)
+}
+
+type storageReconciler struct {
+	docRepo        repositories.DocumentRepository
+	storageService StorageService
+	vectorStore    VectorStore
+	interval       time.Duration
+	cleanup        bool
+	mu             sync.Mutex
+	wg             sync.WaitGroup
+	stopChan       chan struct{}
+}
+
+func NewStorageReconciler(
+	docRepo repositories.DocumentRepository,
+	storageService StorageService,
+	vectorStore VectorStore,
+	interval time.Duration,
+	cleanup bool,
+) StorageReconciler {
+	return &storageReconciler{
+		docRepo:        docRepo,
+		storageService: storageService,
+		vectorStore:    vectorStore,
+		interval:       interval,
+		cleanup:        cleanup,
+		stopChan:       make(chan struct{}),
+	}
+}
+
+// Start implements StorageReconciler.
+func (r *storageReconciler) Start(ctx context.Context) {
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+		ticker := time.NewTicker(r.interval)
+		defer ticker.Stop()
+
+		log.Printf("🔎 Storage reconciler started (every %s, cleanup=%t)\n", r.interval, r.cleanup)
+
+		for {
+			select {
+			case <-r.stopChan:
+				log.Println("🔎 Storage reconciler stopped")
+				return
+			case <-ticker.C:
+				if _, err := r.Reconcile(ctx, r.cleanup); err != nil {
+					log.Printf("⚠️  Storage reconciliation failed: %v\n", err)
+				}
+			}
+		}
+	}()
+}
+
+// Stop implements StorageReconciler.
+func (r *storageReconciler) Stop() {
+	close(r.stopChan)
+	r.wg.Wait()
+}
+
+// Reconcile implements StorageReconciler.
+func (r *storageReconciler) Reconcile(ctx context.Context, cleanup bool) (*models.ReconcileReport, error) {
+	// Scheduled and admin-triggered runs must not race each other
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	report := &models.ReconcileReport{
+		StartedAt:     time.Now(),
+		Cleanup:       cleanup,
+		OrphanedFiles: []string{},
+		MissingFiles:  []models.MissingFileInfo{},
+	}
+	cutoff := report.StartedAt.Add(-reconcileGracePeriod)
+
+	files, err := r.storageService.ListFiles()
+	if err != nil {
+		return nil, err
+	}
+
+	docs, err := r.docRepo.ListFiles()
+	if err != nil {
+		return nil, err
+	}
+
+	report.FilesChecked = len(files)
+	report.DocumentsChecked = len(docs)
+
+	onDisk := make(map[string]bool, len(files))
+	for _, f := range files {
+		onDisk[f.Name] = true
+	}
+
+	known := make(map[string]bool, len(docs))
+	for _, doc := range docs {
+		known[doc.Filename] = true
+	}
+
+	for _, f := range files {
+		if known[f.Name] || f.ModTime.After(cutoff) {
+			continue
+		}
+
+		report.OrphanedFiles = append(report.OrphanedFiles, f.Name)
+		if !cleanup {
+			continue
+		}
+
+		if err := r.storageService.DeleteFile(f.Name); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			log.Printf("⚠️  Failed to delete orphaned file %s: %v\n", f.Name, err)
+			continue
+		}
+		report.DeletedFiles++
+	}
+
+	for _, doc := range docs {
+		if onDisk[doc.Filename] || doc.CreatedAt.After(cutoff) {
+			continue
+		}
+
+		report.MissingFiles = append(report.MissingFiles, models.MissingFileInfo{
+			DocumentID: doc.ID,
+			TenantID:   doc.TenantID,
+			Filename:   doc.Filename,
+		})
+		if !cleanup {
+			continue
+		}
+
+		if err := r.vectorStore.DeleteDocument(tenant.WithID(ctx, doc.TenantID), doc.ID.String()); err != nil {
+			log.Printf("⚠️  Failed to delete vectors for document %s: %v\n", doc.ID, err)
+		}
+
+		if err := r.docRepo.Delete(doc.ID); err != nil {
+			log.Printf("⚠️  Failed to delete document %s: %v\n", doc.ID, err)
+			continue
+		}
+		report.DeletedDocuments++
+	}
+
+	report.FinishedAt = time.Now()
+
+	if len(report.OrphanedFiles) > 0 || len(report.MissingFiles) > 0 {
+		log.Printf("🔎 Storage reconciliation: %d orphaned files, %d missing files (deleted %d files, %d documents)\n",
+			len(report.OrphanedFiles), len(report.MissingFiles), report.DeletedFiles, report.DeletedDocuments)
+	}
+
+	return report, nil
+}
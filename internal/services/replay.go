@@ -0,0 +1,163 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"alfredoptarigan/cv-evaluator/internal/models"
+	"alfredoptarigan/cv-evaluator/internal/repositories"
+)
+
+type responseLogKey struct{}
+
+// responseLog identifies the evaluation attempt whose raw LLM responses are
+// stored.
+type responseLog struct {
+	evalID  uuid.UUID
+	attempt int
+}
+
+// withResponseLog makes the scoring and summary calls made with ctx store
+// their raw responses for the evaluation. Shadow runs and dry runs don't
+// carry it.
+func withResponseLog(ctx context.Context, evalID uuid.UUID, attempt int) context.Context {
+	return context.WithValue(ctx, responseLogKey{}, responseLog{evalID: evalID, attempt: attempt})
+}
+
+// saveResponse stores response if ctx asks for it. Failing to store it only
+// means the evaluation can't be replayed.
+func (e *evaluatorService) saveResponse(ctx context.Context, stage models.ResponseStage, response string) {
+	l, ok := ctx.Value(responseLogKey{}).(responseLog)
+	if !ok {
+		return
+	}
+
+	err := e.evalRepo.SaveResponse(&models.EvaluationResponse{
+		EvaluationID: l.evalID,
+		Attempt:      l.attempt,
+		Stage:        stage,
+		Response:     response,
+	})
+	if err != nil {
+		log.Printf("⚠️  Failed to store %s response of job %s: %v\n", stage, l.evalID, err)
+	}
+}
+
+// Replay implements EvaluatorService. Stages checkpointed before are kept;
+// for the others the latest stored response is parsed.
+func (e *evaluatorService) Replay(ctx context.Context, evalID uuid.UUID) (*models.EvaluationReplay, error) {
+	evaluation, err := e.evalRepo.FindByID(evalID)
+	if err != nil {
+		return nil, err
+	}
+	if evaluation.Status != models.StatusFailed && evaluation.Status != models.StatusPartiallyCompleted {
+		return nil, fmt.Errorf("%w: %s evaluations can't be replayed", repositories.ErrInvalidTransition, evaluation.Status)
+	}
+
+	stored, err := e.evalRepo.FindResponses(evalID)
+	if err != nil {
+		return nil, err
+	}
+	latest := make(map[models.ResponseStage]string)
+	for _, r := range stored {
+		latest[r.Stage] = r.Response
+	}
+
+	run := &evaluationRun{evaluation: evaluation}
+	done := e.restoreCheckpoints(run, e.stages())
+
+	replay := &models.EvaluationReplay{ID: evalID.String(), Status: string(evaluation.Status)}
+	var checkpoints []models.EvaluationCheckpoint
+	data := &repositories.EvaluationUpdateData{}
+
+	// replayStage parses the stage's stored response with parse unless it
+	// was checkpointed, and reports whether the stage's result is available
+	replayStage := func(stage models.ResponseStage, checkpoint models.CheckpointStage, parse func(string) (interface{}, error)) bool {
+		if done[checkpoint] {
+			replay.Stages = append(replay.Stages, models.ReplayedStage{Stage: stage, Outcome: models.ReplayCheckpointed})
+			return true
+		}
+		response, ok := latest[stage]
+		if !ok {
+			replay.Stages = append(replay.Stages, models.ReplayedStage{Stage: stage, Outcome: models.ReplayMissing})
+			return false
+		}
+
+		result, err := parse(response)
+		if err == nil {
+			var output []byte
+			if output, err = json.Marshal(result); err == nil {
+				checkpoints = append(checkpoints, models.EvaluationCheckpoint{Stage: checkpoint, Output: string(output)})
+			}
+		}
+		if err != nil {
+			replay.Stages = append(replay.Stages, models.ReplayedStage{Stage: stage, Outcome: models.ReplayUnparseable, Error: err.Error()})
+			return false
+		}
+		replay.Stages = append(replay.Stages, models.ReplayedStage{Stage: stage, Outcome: models.ReplayRecovered})
+		return true
+	}
+
+	cvDone := replayStage(models.ResponseCVResult, models.CheckpointCVResult, func(response string) (interface{}, error) {
+		result, err := e.parseCVResult(response, ExtractExperienceTimeline(run.cvText, time.Now()))
+		if err == nil {
+			run.cvResult = result
+			data.CVMatchRate, data.CVFeedback = &result.MatchRate, &result.Feedback
+		}
+		return result, err
+	})
+	projectDone := replayStage(models.ResponseProjectResult, models.CheckpointProjectResult, func(response string) (interface{}, error) {
+		result, err := e.parseProjectResult(response)
+		if err == nil {
+			run.projectResult = result
+			data.ProjectScore, data.ProjectFeedback = &result.ProjectScore, &result.Feedback
+		}
+		return result, err
+	})
+
+	complete := false
+	if cvDone && projectDone {
+		summary, ok := latest[models.ResponseSummary]
+		summary = strings.TrimSpace(summary)
+		switch {
+		case !ok:
+			replay.Stages = append(replay.Stages, models.ReplayedStage{Stage: models.ResponseSummary, Outcome: models.ReplayMissing})
+		case summary == "":
+			replay.Stages = append(replay.Stages, models.ReplayedStage{Stage: models.ResponseSummary, Outcome: models.ReplayUnparseable, Error: "empty response"})
+		default:
+			replay.Stages = append(replay.Stages, models.ReplayedStage{Stage: models.ResponseSummary, Outcome: models.ReplayRecovered})
+			data.CVMatchRate, data.CVFeedback = &run.cvResult.MatchRate, &run.cvResult.Feedback
+			data.ProjectScore, data.ProjectFeedback = &run.projectResult.ProjectScore, &run.projectResult.Feedback
+			data.OverallSummary = &summary
+			complete = true
+		}
+	}
+
+	if len(checkpoints) == 0 && !complete {
+		replay.Message = "Nothing was recovered; retry the evaluation to run the remaining stages"
+		return replay, nil
+	}
+
+	ragContextMissing := evaluation.RAGContextMissing || run.ragContextMissing()
+	data.RAGContextMissing = &ragContextMissing
+
+	if err := e.evalRepo.Replay(evalID, checkpoints, data, complete); err != nil {
+		return nil, err
+	}
+
+	if complete {
+		log.Printf("♻️  Evaluation %s completed from its stored LLM responses\n", evalID)
+		replay.Status = string(models.StatusCompleted)
+		replay.Message = "Evaluation completed from its stored LLM responses"
+	} else {
+		log.Printf("♻️  Recovered %d stage(s) of evaluation %s from its stored LLM responses\n", len(checkpoints), evalID)
+		replay.Message = "Recovered stages were checkpointed; retry the evaluation to run the remaining stages"
+	}
+	return replay, nil
+}
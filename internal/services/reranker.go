@@ -0,0 +1,79 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sort"
+)
+
+// rerankQueryTokens bounds how much of the query is sent to the reranker.
+const rerankQueryTokens = 1500
+
+// Reranker reorders retrieved chunks by relevance to a query and drops the
+// ones that don't help answer it.
+type Reranker interface {
+	Rerank(ctx context.Context, query string, results []SearchResult, limit int) ([]SearchResult, error)
+}
+
+type llmReranker struct {
+	gemini        GeminiService
+	promptBuilder *PromptBuilder
+	minScore      int
+}
+
+// NewLLMReranker scores chunks with the LLM on a 0-10 scale. Chunks scored
+// below minScore are dropped.
+func NewLLMReranker(gemini GeminiService, minScore int) Reranker {
+	return &llmReranker{
+		gemini:        gemini,
+		promptBuilder: NewPromptBuilder(),
+		minScore:      minScore,
+	}
+}
+
+// Rerank implements Reranker.
+func (r *llmReranker) Rerank(ctx context.Context, query string, results []SearchResult, limit int) ([]SearchResult, error) {
+	if len(results) == 0 {
+		return results, nil
+	}
+
+	passages := make([]string, len(results))
+	for i, result := range results {
+		passages[i] = result.Text
+	}
+
+	query = TruncateToTokens(query, rerankQueryTokens, cvPrioritySections)
+	prompt := r.promptBuilder.BuildRerankPrompt(query, passages)
+
+	response, err := r.gemini.GenerateText(ctx, prompt, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to rerank: %w", err)
+	}
+
+	var parsed struct {
+		Scores []int `json:"scores"`
+	}
+	if err := decodeJSON(response, &parsed); err != nil {
+		return nil, fmt.Errorf("failed to parse rerank scores: %w", err)
+	}
+	if len(parsed.Scores) != len(results) {
+		return nil, fmt.Errorf("reranker returned %d scores for %d passages", len(parsed.Scores), len(results))
+	}
+
+	idx := make([]int, 0, len(results))
+	for i, score := range parsed.Scores {
+		if score >= r.minScore {
+			idx = append(idx, i)
+		}
+	}
+	sort.SliceStable(idx, func(a, b int) bool {
+		return parsed.Scores[idx[a]] > parsed.Scores[idx[b]]
+	})
+
+	reranked := make([]SearchResult, 0, len(idx))
+	for _, i := range idx {
+		reranked = append(reranked, results[i])
+	}
+
+	return truncateResults(reranked, limit), nil
+}
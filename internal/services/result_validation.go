@@ -0,0 +1,134 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"strings"
+)
+
+// Scores are on a 1-5 scale.
+const (
+	minRubricScore = 1
+	maxRubricScore = 5
+)
+
+// Parameter weights of the scoring prompts, which the weighted averages are
+// recomputed with.
+const (
+	cvTechnicalSkillsWeight = 0.40
+	cvExperienceLevelWeight = 0.25
+	cvAchievementsWeight    = 0.20
+	cvCulturalFitWeight     = 0.15
+
+	projectCorrectnessWeight   = 0.30
+	projectCodeQualityWeight   = 0.25
+	projectResilienceWeight    = 0.20
+	projectDocumentationWeight = 0.15
+	projectCreativityWeight    = 0.10
+)
+
+// weightedAverageTolerance is how far the LLM's own weighted average may be
+// off before the difference is logged.
+const weightedAverageTolerance = 0.05
+
+// resultValidationError lists what is wrong with a parsed scoring result.
+type resultValidationError struct {
+	kind     string
+	problems []string
+}
+
+func (e *resultValidationError) Error() string {
+	return fmt.Sprintf("invalid %s evaluation: %s", e.kind, strings.Join(e.problems, "; "))
+}
+
+// scoreChecker collects problems with a result's fields.
+type scoreChecker struct {
+	problems []string
+}
+
+func (c *scoreChecker) score(field string, value float64) {
+	if value < minRubricScore || value > maxRubricScore || math.IsNaN(value) {
+		c.problems = append(c.problems, fmt.Sprintf("%s is %g, must be between %d and %d", field, value, minRubricScore, maxRubricScore))
+	}
+}
+
+func (c *scoreChecker) feedback(value string) {
+	if strings.TrimSpace(value) == "" {
+		c.problems = append(c.problems, "feedback is empty")
+	}
+}
+
+func (c *scoreChecker) err(kind string) error {
+	if len(c.problems) == 0 {
+		return nil
+	}
+	return &resultValidationError{kind: kind, problems: c.problems}
+}
+
+// validate checks the scores and feedback and replaces the weighted average
+// and match rate with ones computed from the scores.
+func (r *CVEvaluationResult) validate() error {
+	var check scoreChecker
+	check.score("technical_skills_score", r.TechnicalSkillsScore)
+	check.score("experience_level_score", r.ExperienceLevelScore)
+	check.score("achievements_score", r.AchievementsScore)
+	check.score("cultural_fit_score", r.CulturalFitScore)
+	check.feedback(r.Feedback)
+	if err := check.err("CV"); err != nil {
+		return err
+	}
+
+	logAverageMismatch("CV", r.WeightedAverage, r.weightedAverage())
+	r.weigh()
+	return nil
+}
+
+func (r *CVEvaluationResult) weightedAverage() float64 {
+	return roundScore(r.TechnicalSkillsScore*cvTechnicalSkillsWeight +
+		r.ExperienceLevelScore*cvExperienceLevelWeight +
+		r.AchievementsScore*cvAchievementsWeight +
+		r.CulturalFitScore*cvCulturalFitWeight)
+}
+
+// weigh sets the weighted average and match rate from the scores.
+func (r *CVEvaluationResult) weigh() {
+	r.WeightedAverage = r.weightedAverage()
+	r.MatchRate = roundScore(r.WeightedAverage / maxRubricScore)
+}
+
+// validate checks the scores and feedback and replaces the weighted average
+// and project score with ones computed from the scores.
+func (r *ProjectEvaluationResult) validate() error {
+	var check scoreChecker
+	check.score("correctness_score", r.CorrectnessScore)
+	check.score("code_quality_score", r.CodeQualityScore)
+	check.score("resilience_score", r.ResilienceScore)
+	check.score("documentation_score", r.DocumentationScore)
+	check.score("creativity_score", r.CreativityScore)
+	check.feedback(r.Feedback)
+	if err := check.err("project"); err != nil {
+		return err
+	}
+
+	average := roundScore(r.CorrectnessScore*projectCorrectnessWeight +
+		r.CodeQualityScore*projectCodeQualityWeight +
+		r.ResilienceScore*projectResilienceWeight +
+		r.DocumentationScore*projectDocumentationWeight +
+		r.CreativityScore*projectCreativityWeight)
+	logAverageMismatch("project", r.WeightedAverage, average)
+	r.WeightedAverage = average
+	r.ProjectScore = average
+	return nil
+}
+
+func logAverageMismatch(kind string, reported, computed float64) {
+	if math.Abs(reported-computed) > weightedAverageTolerance {
+		log.Printf("⚠️  LLM reported a %s weighted average of %g, using %g computed from the scores\n", kind, reported, computed)
+	}
+}
+
+// roundScore rounds to the two decimals scores are stored with.
+func roundScore(v float64) float64 {
+	return math.Round(v*100) / 100
+}
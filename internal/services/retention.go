@@ -0,0 +1,161 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"log"
+	"sync"
+	"time"
+
+	"alfredoptarigan/cv-evaluator/internal/repositories"
+	"alfredoptarigan/cv-evaluator/internal/tenant"
+)
+
+const retentionBatchSize = 100
+
+// RetentionService periodically deletes uploaded documents (file, database
+// row and vector points) once they are older than the retention period.
+type RetentionService interface {
+	Start(ctx context.Context)
+	Stop()
+	RunOnce(ctx context.Context) (int, error)
+}
+
+type retentionService struct {
+	docRepo         repositories.DocumentRepository
+	storageService  StorageService
+	vectorStore     VectorStore
+	period          time.Duration
+	tenantOverrides map[string]time.Duration
+	interval        time.Duration
+	wg              sync.WaitGroup
+	stopChan        chan struct{}
+}
+
+func NewRetentionService(
+	docRepo repositories.DocumentRepository,
+	storageService StorageService,
+	vectorStore VectorStore,
+	period time.Duration,
+	tenantOverrides map[string]time.Duration,
+	interval time.Duration,
+) RetentionService {
+	return &retentionService{
+		docRepo:         docRepo,
+		storageService:  storageService,
+		vectorStore:     vectorStore,
+		period:          period,
+		tenantOverrides: tenantOverrides,
+		interval:        interval,
+		stopChan:        make(chan struct{}),
+	}
+}
+
+// Start implements RetentionService.
+func (r *retentionService) Start(ctx context.Context) {
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+		ticker := time.NewTicker(r.interval)
+		defer ticker.Stop()
+
+		log.Printf("🧹 Retention job started (period %s, every %s)\n", r.period, r.interval)
+
+		for {
+			select {
+			case <-r.stopChan:
+				log.Println("🧹 Retention job stopped")
+				return
+			case <-ticker.C:
+				deleted, err := r.RunOnce(ctx)
+				if err != nil {
+					log.Printf("⚠️  Retention run failed: %v\n", err)
+				}
+				if deleted > 0 {
+					log.Printf("🧹 Retention deleted %d documents\n", deleted)
+				}
+			}
+		}
+	}()
+}
+
+// Stop implements RetentionService.
+func (r *retentionService) Stop() {
+	close(r.stopChan)
+	r.wg.Wait()
+}
+
+// RunOnce implements RetentionService.
+func (r *retentionService) RunOnce(ctx context.Context) (int, error) {
+	now := time.Now()
+	deleted := 0
+
+	overridden := make([]string, 0, len(r.tenantOverrides))
+	for tenantID, period := range r.tenantOverrides {
+		overridden = append(overridden, tenantID)
+
+		n, err := r.purge(ctx, repositories.DocumentExpiryFilter{
+			CreatedBefore: now.Add(-period),
+			TenantID:      tenantID,
+		})
+		deleted += n
+		if err != nil {
+			return deleted, err
+		}
+	}
+
+	if r.period > 0 {
+		n, err := r.purge(ctx, repositories.DocumentExpiryFilter{
+			CreatedBefore:  now.Add(-r.period),
+			ExcludeTenants: overridden,
+		})
+		deleted += n
+		if err != nil {
+			return deleted, err
+		}
+	}
+
+	return deleted, nil
+}
+
+func (r *retentionService) purge(ctx context.Context, filter repositories.DocumentExpiryFilter) (int, error) {
+	filter.Limit = retentionBatchSize
+	deleted := 0
+
+	for {
+		docs, err := r.docRepo.FindExpired(filter)
+		if err != nil {
+			return deleted, err
+		}
+
+		passDeleted := 0
+		for _, doc := range docs {
+			if err := r.storageService.DeleteFile(doc.Filename); err != nil && !errors.Is(err, fs.ErrNotExist) {
+				log.Printf("⚠️  Failed to delete file for document %s: %v\n", doc.ID, err)
+				filter.SkipIDs = append(filter.SkipIDs, doc.ID)
+				continue
+			}
+
+			if err := r.vectorStore.DeleteDocument(tenant.WithID(ctx, doc.TenantID), doc.ID.String()); err != nil {
+				log.Printf("⚠️  Failed to delete vectors for document %s: %v\n", doc.ID, err)
+			}
+
+			if err := r.docRepo.Delete(doc.ID); err != nil {
+				log.Printf("⚠️  Failed to delete document %s: %v\n", doc.ID, err)
+				filter.SkipIDs = append(filter.SkipIDs, doc.ID)
+				continue
+			}
+
+			passDeleted++
+		}
+		deleted += passDeleted
+
+		// Failed documents are skipped on the next pass, but if nothing could
+		// be deleted at all (a read-only upload dir, a DB permission error)
+		// leave the rest for the next run
+		if len(docs) < filter.Limit || passDeleted == 0 {
+			return deleted, nil
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package services
+
+import (
+	"strings"
+	"unicode"
+)
+
+// filterByScore drops results whose vector similarity is below minScore.
+// Results with a zero score were found by keyword search only and are kept.
+func filterByScore(results []SearchResult, minScore float32) []SearchResult {
+	if minScore <= 0 {
+		return results
+	}
+
+	kept := results[:0:0]
+	for _, r := range results {
+		if r.Score == 0 || r.Score >= minScore {
+			kept = append(kept, r)
+		}
+	}
+
+	return kept
+}
+
+// diversify picks k results by maximal marginal relevance: each pick trades
+// off its rank in results against its word overlap with the picks so far.
+// lambda 1 keeps the original order; lower values favour diversity.
+func diversify(results []SearchResult, k int, lambda float64) []SearchResult {
+	if lambda >= 1 || len(results) <= k {
+		return truncateResults(results, k)
+	}
+
+	words := make([]map[string]bool, len(results))
+	for i, r := range results {
+		words[i] = wordSet(r.Text)
+	}
+
+	picked := make([]int, 0, k)
+	used := make([]bool, len(results))
+	for len(picked) < k {
+		best, bestScore := -1, 0.0
+		for i := range results {
+			if used[i] {
+				continue
+			}
+
+			// Rank-based relevance, so fused and reranked orders work too
+			relevance := 1 - float64(i)/float64(len(results))
+			redundancy := 0.0
+			for _, j := range picked {
+				redundancy = max(redundancy, jaccard(words[i], words[j]))
+			}
+
+			score := lambda*relevance - (1-lambda)*redundancy
+			if best < 0 || score > bestScore {
+				best, bestScore = i, score
+			}
+		}
+
+		used[best] = true
+		picked = append(picked, best)
+	}
+
+	selected := make([]SearchResult, len(picked))
+	for i, j := range picked {
+		selected[i] = results[j]
+	}
+
+	return selected
+}
+
+func wordSet(text string) map[string]bool {
+	set := make(map[string]bool)
+	for _, w := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		set[w] = true
+	}
+	return set
+}
+
+func jaccard(a, b map[string]bool) float64 {
+	if len(a) == 0 && len(b) == 0 {
+		return 0
+	}
+
+	shared := 0
+	for w := range a {
+		if b[w] {
+			shared++
+		}
+	}
+
+	return float64(shared) / float64(len(a)+len(b)-shared)
+}
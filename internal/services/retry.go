@@ -0,0 +1,102 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+)
+
+// RetryPolicy says how often a pipeline step is tried. The delay before
+// each retry starts at InitialDelay and doubles.
+type RetryPolicy struct {
+	MaxAttempts  int
+	InitialDelay time.Duration
+}
+
+// StageRetries holds the retry policy of each pipeline step that calls out
+// of the process.
+type StageRetries struct {
+	Parse       RetryPolicy
+	LLM         RetryPolicy
+	VectorQuery RetryPolicy
+}
+
+// delay returns how long to wait after the given failed attempt (1-based).
+func (p RetryPolicy) delay(attempt int) time.Duration {
+	if p.InitialDelay <= 0 {
+		return 0
+	}
+	return p.InitialDelay << (attempt - 1)
+}
+
+// do runs fn until it succeeds or the attempts run out. Errors that another
+// attempt can't fix (an open breaker, a pending batch, a done ctx) are
+// returned at once.
+func (p RetryPolicy) do(ctx context.Context, label string, fn func() error) error {
+	attempts := max(p.MaxAttempts, 1)
+
+	var err error
+	for attempt := 1; attempt <= attempts; attempt++ {
+		if err = fn(); err == nil || !retryable(err) {
+			return err
+		}
+		if attempt == attempts {
+			break
+		}
+
+		log.Printf("⚠️ %s attempt %d failed: %v. Retrying...\n", label, attempt, err)
+		if err := sleepCtx(ctx, p.delay(attempt)); err != nil {
+			return fmt.Errorf("context cancelled: %w", err)
+		}
+	}
+
+	if attempts == 1 {
+		return err
+	}
+	return fmt.Errorf("failed after %d attempts: %w", attempts, err)
+}
+
+// retryable reports whether trying again might help.
+func retryable(err error) bool {
+	return !errors.Is(err, ErrCircuitOpen) &&
+		!errors.Is(err, ErrBatchPending) &&
+		!errors.Is(err, ErrBatchFailed) &&
+		!errors.Is(err, ErrPDFEncrypted) &&
+		!errors.Is(err, ErrDocumentTooLong) &&
+		!errors.Is(err, context.Canceled)
+}
+
+func sleepCtx(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+type retryDelayKey struct{}
+
+// withRetryDelay makes GenerateTextWithRetry wait delay before its first
+// retry, doubling it for each further one. Without it retries are immediate.
+func withRetryDelay(ctx context.Context, delay time.Duration) context.Context {
+	return context.WithValue(ctx, retryDelayKey{}, delay)
+}
+
+func retryDelayFrom(ctx context.Context) time.Duration {
+	d, _ := ctx.Value(retryDelayKey{}).(time.Duration)
+	return d
+}
+
+// generate sends prompt under the LLM retry policy.
+func (e *evaluatorService) generate(ctx context.Context, prompt string, temperature float32) (string, error) {
+	return e.geminiService.GenerateTextWithRetry(withRetryDelay(ctx, e.retries.LLM.InitialDelay), prompt, temperature, e.retries.LLM.MaxAttempts)
+}
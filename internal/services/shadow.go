@@ -0,0 +1,110 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math/rand"
+
+	"alfredoptarigan/cv-evaluator/internal/models"
+)
+
+// ShadowOptions runs a second pipeline configuration on Percent (0-100) of
+// the evaluations, next to the canonical one. Shadow results are stored for
+// offline comparison and never affect the evaluation. An empty Model
+// disables shadowing.
+type ShadowOptions struct {
+	Model       string
+	Temperature *float32
+	Percent     float64
+}
+
+// startShadow runs the shadow pipeline in the background when evaluation is
+// sampled. The returned channel is closed when it is done, or nil when no
+// shadow runs. Only the first attempt is shadowed so retries don't add
+// duplicate rows, and batch evaluations aren't, since their calls only
+// return on later attempts.
+func (e *evaluatorService) startShadow(ctx context.Context, evaluation models.Evaluation) <-chan struct{} {
+	if e.shadow.Model == "" || evaluation.Attempts > 1 || evaluation.ExecutionMode == models.ExecutionBatch ||
+		rand.Float64()*100 >= e.shadow.Percent {
+		return nil
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		e.runShadow(ctx, evaluation)
+	}()
+	return done
+}
+
+func (e *evaluatorService) runShadow(ctx context.Context, evaluation models.Evaluation) {
+	log.Printf("👥 Running shadow evaluation with %s for job %s\n", e.shadow.Model, evaluation.ID)
+
+	// The shadow's tokens are counted apart from the evaluation's
+	ctx, usage := WithTokenUsage(ctx)
+	ctx = WithLLMOverrides(ctx, LLMOverrides{Model: e.shadow.Model, Temperature: e.shadow.Temperature})
+
+	shadow := &models.EvaluationShadow{
+		EvaluationID: evaluation.ID,
+		Model:        e.shadow.Model,
+		Temperature:  e.shadow.Temperature,
+	}
+	if err := e.scoreShadow(ctx, evaluation, shadow); err != nil {
+		log.Printf("⚠️  Shadow evaluation failed for job %s: %v\n", evaluation.ID, err)
+		shadow.ErrorMessage = err.Error()
+	}
+
+	shadow.PromptTokens, shadow.CompletionTokens = usage.Totals()
+	shadow.EstimatedCostUSD = e.pricing.Cost(shadow.PromptTokens, shadow.CompletionTokens)
+
+	if err := e.evalRepo.SaveShadow(shadow); err != nil {
+		log.Printf("⚠️  Failed to save shadow result for job %s: %v\n", evaluation.ID, err)
+	}
+}
+
+// scoreShadow runs the scoring stages and the summary into shadow, keeping
+// whatever finished before an error.
+func (e *evaluatorService) scoreShadow(ctx context.Context, evaluation models.Evaluation, shadow *models.EvaluationShadow) error {
+	cvText, err := e.loadText(ctx, evaluation.CVDocumentID, "CV")
+	if err != nil {
+		return err
+	}
+	cvContext, err := e.retrieveContext(ctx, cvText, cvContextTypes)
+	if err != nil {
+		cvContext = ""
+	}
+	cvResult, err := e.evaluateCV(ctx, cvText, cvContext, evaluation.JobTitle)
+	if err != nil {
+		return fmt.Errorf("failed to evaluate CV: %w", err)
+	}
+	shadow.CVMatchRate = &cvResult.MatchRate
+	shadow.CVFeedback = cvResult.Feedback
+
+	additional, err := e.evalRepo.FindAdditionalProjectDocuments(evaluation.ID)
+	if err != nil {
+		return fmt.Errorf("failed to load project documents: %w", err)
+	}
+	projectFiles, err := e.loadProjectFiles(ctx, evaluation.ProjectDocumentID, additional)
+	if err != nil {
+		return err
+	}
+	projectContext, err := e.retrieveContext(ctx, joinProjectFiles(projectFiles), projectContextTypes)
+	if err != nil {
+		projectContext = ""
+	}
+	projectResult, err := e.evaluateProject(ctx, projectFiles, projectContext)
+	if err != nil {
+		return fmt.Errorf("failed to evaluate project: %w", err)
+	}
+	shadow.ProjectScore = &projectResult.ProjectScore
+	shadow.ProjectFeedback = projectResult.Feedback
+
+	summary, err := e.generateSummary(ctx, cvResult, projectResult, evaluation.JobTitle)
+	if err != nil {
+		return fmt.Errorf("failed to generate summary: %w", err)
+	}
+	shadow.OverallSummary = summary
+
+	return nil
+}
@@ -1,30 +1,57 @@
 package services
 
 import (
+	"bufio"
 	"fmt"
 	"io"
 	"mime/multipart"
+	"net/http"
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/google/uuid"
+
+	"alfredoptarigan/cv-evaluator/internal/apperror"
 )
 
+var ErrFileTooLarge = apperror.New(http.StatusBadRequest, apperror.CodeFileTooLarge, "file too large")
+
 type StorageService interface {
 	SaveFile(file *multipart.FileHeader, fileType string) (string, string, error)
+	SaveReader(src io.Reader, originalName string, fileType string, maxSize int64) (string, string, error)
 	GetFilePath(filename string) string
 	DeleteFile(filename string) error
+	OpenFile(filename string) (io.ReadCloser, int64, error)
+	ListFiles() ([]StoredFile, error)
+	AppendPartial(id string, src io.Reader, maxBytes int64) (int64, error)
+	OpenPartial(id string) (io.ReadCloser, error)
+	DeletePartial(id string) error
 	EnsureUploadDir() error
 }
 
+// StoredFile is a file found in the upload directory.
+type StoredFile struct {
+	Name    string
+	Size    int64
+	ModTime time.Time
+}
+
 type storageService struct {
-	uploadPath string
+	uploadPath   string
+	allowedTypes map[string]bool
 }
 
-func NewStorageService(uploadPath string) StorageService {
+func NewStorageService(uploadPath string, allowedTypes []string) StorageService {
+	allowed := make(map[string]bool, len(allowedTypes))
+	for _, t := range allowedTypes {
+		allowed[strings.ToLower(strings.TrimSpace(t))] = true
+	}
+
 	return &storageService{
-		uploadPath: uploadPath,
+		uploadPath:   uploadPath,
+		allowedTypes: allowed,
 	}
 }
 
@@ -37,16 +64,6 @@ func (s *storageService) EnsureUploadDir() error {
 }
 
 func (s *storageService) SaveFile(file *multipart.FileHeader, fileType string) (string, string, error) {
-	// Validate file extensions
-	ext := strings.ToLower(filepath.Ext(file.Filename))
-	if ext != ".pdf" {
-		return "", "", fmt.Errorf("invalid file extension: %s", ext)
-	}
-
-	// Generate the unique filename
-	uniqueFilename := fmt.Sprintf("%s_%s%s", fileType, uuid.New().String(), ext)
-	filePath := filepath.Join(s.uploadPath, uniqueFilename)
-
 	// Open source file
 	src, err := file.Open()
 	if err != nil {
@@ -54,6 +71,35 @@ func (s *storageService) SaveFile(file *multipart.FileHeader, fileType string) (
 	}
 	defer src.Close()
 
+	return s.SaveReader(src, file.Filename, fileType, 0)
+}
+
+// SaveReader stores the content of src under a unique filename. A positive
+// maxSize caps the number of bytes written; exceeding it removes the partial
+// file and returns ErrFileTooLarge.
+func (s *storageService) SaveReader(src io.Reader, originalName string, fileType string, maxSize int64) (string, string, error) {
+	// Validate file extensions against the allowlist
+	ext := strings.ToLower(filepath.Ext(originalName))
+	claimedType := FileTypeForExtension(ext)
+	if claimedType == "" || !s.allowedTypes[claimedType] {
+		return "", "", apperror.New(http.StatusBadRequest, apperror.CodeUnsupportedFileType, fmt.Sprintf("invalid file extension: %s", ext))
+	}
+
+	// Sniff the actual content so renamed files are rejected
+	buffered := bufio.NewReaderSize(src, sniffLen)
+	header, err := buffered.Peek(sniffLen)
+	if err != nil && err != io.EOF && err != bufio.ErrBufferFull {
+		return "", "", fmt.Errorf("failed to read uploaded file: %w", err)
+	}
+	if detected := DetectFileType(header); detected != claimedType {
+		return "", "", contentMismatchError(ext)
+	}
+	src = buffered
+
+	// Generate the unique filename
+	uniqueFilename := fmt.Sprintf("%s_%s%s", fileType, uuid.New().String(), ext)
+	filePath := filepath.Join(s.uploadPath, uniqueFilename)
+
 	// Create destination file
 	dst, err := os.Create(filePath)
 	if err != nil {
@@ -61,14 +107,34 @@ func (s *storageService) SaveFile(file *multipart.FileHeader, fileType string) (
 	}
 	defer dst.Close()
 
+	if maxSize > 0 {
+		src = io.LimitReader(src, maxSize+1)
+	}
+
 	// Copy file
-	if _, err := io.Copy(dst, src); err != nil {
+	written, err := io.Copy(dst, src)
+	if err != nil {
+		os.Remove(filePath)
 		return "", "", fmt.Errorf("failed to save file: %w", err)
 	}
 
+	if maxSize > 0 && written > maxSize {
+		os.Remove(filePath)
+		return "", "", ErrFileTooLarge
+	}
+
+	if claimedType == FileTypeDOCX && !isDOCX(filePath) {
+		os.Remove(filePath)
+		return "", "", contentMismatchError(ext)
+	}
+
 	return uniqueFilename, filePath, nil
 }
 
+func contentMismatchError(ext string) error {
+	return apperror.New(http.StatusBadRequest, apperror.CodeUnsupportedFileType, fmt.Sprintf("file content does not match its %s extension", ext))
+}
+
 func (s *storageService) GetFilePath(filename string) string {
 	return filepath.Join(s.uploadPath, filename)
 }
@@ -80,3 +146,102 @@ func (s *storageService) DeleteFile(filename string) error {
 	}
 	return nil
 }
+
+// OpenFile returns the stored file and its size.
+func (s *storageService) OpenFile(filename string) (io.ReadCloser, int64, error) {
+	f, err := os.Open(s.GetFilePath(filename))
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to open file: %w", err)
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, 0, fmt.Errorf("failed to stat file: %w", err)
+	}
+
+	return f, info.Size(), nil
+}
+
+func (s *storageService) ListFiles() ([]StoredFile, error) {
+	entries, err := os.ReadDir(s.uploadPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read upload directory: %w", err)
+	}
+
+	files := make([]StoredFile, 0, len(entries))
+	for _, entry := range entries {
+		if !entry.Type().IsRegular() {
+			continue
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			// Removed between ReadDir and Info
+			continue
+		}
+
+		files = append(files, StoredFile{
+			Name:    entry.Name(),
+			Size:    info.Size(),
+			ModTime: info.ModTime(),
+		})
+	}
+
+	return files, nil
+}
+
+// partialPath keeps in-progress uploads out of the upload directory listing.
+func (s *storageService) partialPath(id string) string {
+	return filepath.Join(s.uploadPath, "partial", id)
+}
+
+// AppendPartial appends at most maxBytes from src to the partial upload and
+// returns the number of bytes written. On any error, including src holding
+// more than maxBytes, the file is truncated back to its previous size.
+func (s *storageService) AppendPartial(id string, src io.Reader, maxBytes int64) (int64, error) {
+	path := s.partialPath(id)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return 0, fmt.Errorf("failed to create partial upload directory: %w", err)
+	}
+
+	dst, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return 0, fmt.Errorf("failed to open partial upload: %w", err)
+	}
+	defer dst.Close()
+
+	info, err := dst.Stat()
+	if err != nil {
+		return 0, fmt.Errorf("failed to stat partial upload: %w", err)
+	}
+
+	written, err := io.Copy(dst, io.LimitReader(src, maxBytes+1))
+	if err == nil && written > maxBytes {
+		err = ErrFileTooLarge
+	}
+	if err != nil {
+		dst.Truncate(info.Size())
+		if err == ErrFileTooLarge {
+			return 0, err
+		}
+		return 0, fmt.Errorf("failed to write partial upload: %w", err)
+	}
+
+	return written, nil
+}
+
+func (s *storageService) OpenPartial(id string) (io.ReadCloser, error) {
+	f, err := os.Open(s.partialPath(id))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open partial upload: %w", err)
+	}
+	return f, nil
+}
+
+func (s *storageService) DeletePartial(id string) error {
+	if err := os.Remove(s.partialPath(id)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to delete partial upload: %w", err)
+	}
+	return nil
+}
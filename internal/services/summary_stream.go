@@ -0,0 +1,161 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+// TextStream receives text while it is generated.
+type TextStream interface {
+	// Reset discards what was written so far, as a new attempt starts.
+	Reset()
+	Write(chunk string)
+}
+
+type textStreamKey struct{}
+
+// WithTextStream makes text generation with ctx stream its output to
+// stream. Cached and batch responses aren't streamed.
+func WithTextStream(ctx context.Context, stream TextStream) context.Context {
+	return context.WithValue(ctx, textStreamKey{}, stream)
+}
+
+func textStreamFrom(ctx context.Context) TextStream {
+	stream, _ := ctx.Value(textStreamKey{}).(TextStream)
+	return stream
+}
+
+// SummaryChunk is a piece of an overall summary being generated. Reset
+// discards the text received so far.
+type SummaryChunk struct {
+	Reset bool
+	Text  string
+}
+
+// summaryListenerBuffer is how many chunks a listener may fall behind
+// before it is dropped.
+const summaryListenerBuffer = 64
+
+// SummaryStreams relays overall summaries to listeners while they are
+// generated. It only sees evaluations run by this process's workers.
+type SummaryStreams interface {
+	// Subscribe returns the summary text generated so far and a channel
+	// with the following chunks. The channel is closed when the summary is
+	// finished or the listener fell behind; cancel releases it.
+	Subscribe(id uuid.UUID) (string, <-chan SummaryChunk, func())
+	// Stream returns the TextStream that feeds id's listeners.
+	Stream(id uuid.UUID) TextStream
+	// Finish closes id's listeners.
+	Finish(id uuid.UUID)
+}
+
+type summaryStream struct {
+	text      strings.Builder
+	listeners map[chan SummaryChunk]struct{}
+}
+
+type summaryStreams struct {
+	mu      sync.Mutex
+	streams map[uuid.UUID]*summaryStream
+}
+
+func NewSummaryStreams() SummaryStreams {
+	return &summaryStreams{streams: make(map[uuid.UUID]*summaryStream)}
+}
+
+// stream returns id's stream, creating it if needed. s.mu must be held.
+func (s *summaryStreams) stream(id uuid.UUID) *summaryStream {
+	stream, ok := s.streams[id]
+	if !ok {
+		stream = &summaryStream{listeners: make(map[chan SummaryChunk]struct{})}
+		s.streams[id] = stream
+	}
+	return stream
+}
+
+// Subscribe implements SummaryStreams.
+func (s *summaryStreams) Subscribe(id uuid.UUID) (string, <-chan SummaryChunk, func()) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stream := s.stream(id)
+	ch := make(chan SummaryChunk, summaryListenerBuffer)
+	stream.listeners[ch] = struct{}{}
+
+	cancel := func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		if _, ok := stream.listeners[ch]; ok {
+			delete(stream.listeners, ch)
+			close(ch)
+		}
+		if len(stream.listeners) == 0 && stream.text.Len() == 0 && s.streams[id] == stream {
+			delete(s.streams, id)
+		}
+	}
+
+	return stream.text.String(), ch, cancel
+}
+
+// Stream implements SummaryStreams.
+func (s *summaryStreams) Stream(id uuid.UUID) TextStream {
+	return &summaryWriter{streams: s, id: id}
+}
+
+// Finish implements SummaryStreams.
+func (s *summaryStreams) Finish(id uuid.UUID) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stream, ok := s.streams[id]
+	if !ok {
+		return
+	}
+	for ch := range stream.listeners {
+		delete(stream.listeners, ch)
+		close(ch)
+	}
+	delete(s.streams, id)
+}
+
+// broadcast sends chunk to id's listeners, dropping the ones that fell
+// behind; they catch up by subscribing again.
+func (s *summaryStreams) broadcast(id uuid.UUID, chunk SummaryChunk) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stream := s.stream(id)
+	if chunk.Reset {
+		stream.text.Reset()
+	}
+	stream.text.WriteString(chunk.Text)
+
+	for ch := range stream.listeners {
+		select {
+		case ch <- chunk:
+		default:
+			delete(stream.listeners, ch)
+			close(ch)
+		}
+	}
+}
+
+// summaryWriter is the TextStream of one evaluation.
+type summaryWriter struct {
+	streams *summaryStreams
+	id      uuid.UUID
+}
+
+// Reset implements TextStream.
+func (w *summaryWriter) Reset() {
+	w.streams.broadcast(w.id, SummaryChunk{Reset: true})
+}
+
+// Write implements TextStream.
+func (w *summaryWriter) Write(chunk string) {
+	w.streams.broadcast(w.id, SummaryChunk{Text: chunk})
+}
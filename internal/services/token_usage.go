@@ -0,0 +1,54 @@
+package services
+
+import (
+	"context"
+	"sync"
+)
+
+// TokenUsage accumulates the tokens reported by the LLM provider for the
+// calls made with a context from WithTokenUsage.
+type TokenUsage struct {
+	mu               sync.Mutex
+	PromptTokens     int64
+	CompletionTokens int64
+}
+
+func (u *TokenUsage) add(prompt, completion int64) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.PromptTokens += prompt
+	u.CompletionTokens += completion
+}
+
+// Totals returns the accumulated prompt and completion tokens.
+func (u *TokenUsage) Totals() (int64, int64) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	return u.PromptTokens, u.CompletionTokens
+}
+
+type tokenUsageKey struct{}
+
+// WithTokenUsage returns a context whose LLM calls are counted in the
+// returned TokenUsage.
+func WithTokenUsage(ctx context.Context) (context.Context, *TokenUsage) {
+	usage := &TokenUsage{}
+	return context.WithValue(ctx, tokenUsageKey{}, usage), usage
+}
+
+func recordTokenUsage(ctx context.Context, prompt, completion int64) {
+	if usage, ok := ctx.Value(tokenUsageKey{}).(*TokenUsage); ok {
+		usage.add(prompt, completion)
+	}
+}
+
+// TokenPricing is the provider price in USD per million tokens.
+type TokenPricing struct {
+	InputPerMTok  float64
+	OutputPerMTok float64
+}
+
+// Cost estimates the USD cost of the given token counts.
+func (p TokenPricing) Cost(promptTokens, completionTokens int64) float64 {
+	return (float64(promptTokens)*p.InputPerMTok + float64(completionTokens)*p.OutputPerMTok) / 1_000_000
+}
@@ -0,0 +1,248 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/google/uuid"
+
+	"alfredoptarigan/cv-evaluator/internal/models"
+	"alfredoptarigan/cv-evaluator/internal/pii"
+	"alfredoptarigan/cv-evaluator/internal/repositories"
+)
+
+// TrainingExporter writes completed evaluations as prompt/completion pairs
+// for supervised fine-tuning or prompt evaluation tools.
+type TrainingExporter interface {
+	// Export writes one JSON line per task (CV, project, summary) of each
+	// matching evaluation and returns how many lines it wrote.
+	Export(ctx context.Context, w io.Writer, opts TrainingExportOptions) (int, error)
+}
+
+// TrainingExportOptions selects what Export writes.
+type TrainingExportOptions struct {
+	// Since skips evaluations completed before it.
+	Since time.Time
+	// Limit caps the number of evaluations (0 = all).
+	Limit int
+	// ExcerptChars caps the document text in each record.
+	ExcerptChars int
+	// CorrectedOnly exports only evaluations a reviewer corrected.
+	CorrectedOnly bool
+}
+
+// Task names of exported records.
+const (
+	TrainingTaskCV      = "cv_evaluation"
+	TrainingTaskProject = "project_evaluation"
+	TrainingTaskSummary = "summary"
+)
+
+// TrainingRecord is one exported line. Completion is the reviewer's
+// correction when there is one; OriginalCompletion then holds the model's
+// output.
+type TrainingRecord struct {
+	EvaluationID       string `json:"evaluation_id"`
+	Task               string `json:"task"`
+	Model              string `json:"model"`
+	JobTitle           string `json:"job_title"`
+	InputExcerpt       string `json:"input_excerpt,omitempty"`
+	Prompt             string `json:"prompt"`
+	Completion         string `json:"completion"`
+	Corrected          bool   `json:"corrected"`
+	OriginalCompletion string `json:"original_completion,omitempty"`
+	CorrectionNote     string `json:"correction_note,omitempty"`
+}
+
+// trainingExportPageSize is how many evaluations are loaded at a time.
+const trainingExportPageSize = 100
+
+type trainingExporter struct {
+	evalRepo      repositories.EvaluationRepository
+	promptBuilder *PromptBuilder
+	defaultModel  string
+}
+
+// NewTrainingExporter creates a TrainingExporter. Evaluations without a
+// model override are attributed to defaultModel.
+func NewTrainingExporter(evalRepo repositories.EvaluationRepository, defaultModel string) TrainingExporter {
+	return &trainingExporter{
+		evalRepo:      evalRepo,
+		promptBuilder: NewPromptBuilder(),
+		defaultModel:  defaultModel,
+	}
+}
+
+// Export implements TrainingExporter. Retrieved reference context isn't
+// stored, so prompts are rebuilt without it. Document text, prompts and
+// outputs pass through pii.Redact.
+func (t *trainingExporter) Export(ctx context.Context, w io.Writer, opts TrainingExportOptions) (int, error) {
+	enc := json.NewEncoder(w)
+	written, exported := 0, 0
+	afterTime, afterID := opts.Since, uuid.Nil
+
+	for opts.Limit <= 0 || exported < opts.Limit {
+		if err := ctx.Err(); err != nil {
+			return written, err
+		}
+
+		evals, err := t.evalRepo.FindCompleted(afterTime, afterID, trainingExportPageSize)
+		if err != nil {
+			return written, err
+		}
+		if len(evals) == 0 {
+			break
+		}
+
+		for _, eval := range evals {
+			afterTime, afterID = *eval.CompletedAt, eval.ID
+			if opts.Limit > 0 && exported >= opts.Limit {
+				break
+			}
+
+			records, err := t.records(eval, opts)
+			if err != nil {
+				return written, err
+			}
+			if len(records) == 0 {
+				continue
+			}
+
+			for _, record := range records {
+				if err := enc.Encode(record); err != nil {
+					return written, fmt.Errorf("failed to write training record: %w", err)
+				}
+				written++
+			}
+			exported++
+		}
+	}
+
+	return written, nil
+}
+
+// records builds the records of one evaluation, or none when it is skipped.
+func (t *trainingExporter) records(eval models.Evaluation, opts TrainingExportOptions) ([]TrainingRecord, error) {
+	correction, err := t.evalRepo.FindCorrection(eval.ID)
+	if err != nil {
+		return nil, err
+	}
+	if opts.CorrectedOnly && correction == nil {
+		return nil, nil
+	}
+
+	checkpoints, err := t.evalRepo.FindCheckpoints(eval.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Full stage outputs come from the checkpoints; older evaluations only
+	// have the scores and feedback
+	cvResult := &CVEvaluationResult{MatchRate: eval.CVMatchRate, Feedback: eval.CVFeedback}
+	projectResult := &ProjectEvaluationResult{ProjectScore: eval.ProjectScore, Feedback: eval.ProjectFeedback}
+	for _, c := range checkpoints {
+		switch c.Stage {
+		case models.CheckpointCVResult:
+			_ = json.Unmarshal([]byte(c.Output), cvResult)
+		case models.CheckpointProjectResult:
+			_ = json.Unmarshal([]byte(c.Output), projectResult)
+		}
+	}
+	// Citations come from retrieval, not from the model's answer
+	cvResult.Citations, projectResult.Citations = nil, nil
+
+	model := eval.Model
+	if model == "" {
+		model = t.defaultModel
+	}
+	base := TrainingRecord{EvaluationID: eval.ID.String(), Model: model, JobTitle: eval.JobTitle}
+
+	cvExcerpt := excerpt(pii.Redact(eval.CVDocument.ParsedText), opts.ExcerptChars)
+	cv := base
+	cv.Task = TrainingTaskCV
+	cv.InputExcerpt = cvExcerpt
+	cv.Prompt = t.promptBuilder.BuildCVEvaluationPrompt(cvExcerpt, "", "", eval.JobTitle)
+
+	projectExcerpt := excerpt(pii.Redact(eval.ProjectDocument.ParsedText), opts.ExcerptChars)
+	project := base
+	project.Task = TrainingTaskProject
+	project.InputExcerpt = projectExcerpt
+	project.Prompt = t.promptBuilder.BuildProjectEvaluationPrompt(projectExcerpt, "", "")
+
+	summary := base
+	summary.Task = TrainingTaskSummary
+	summary.Prompt = pii.Redact(t.promptBuilder.BuildFinalSummaryPrompt(
+		cvResult.Feedback, projectResult.Feedback, cvResult.MatchRate, projectResult.ProjectScore, eval.JobTitle,
+	))
+
+	cvOriginal, err := completionJSON(cvResult)
+	if err != nil {
+		return nil, err
+	}
+	projectOriginal, err := completionJSON(projectResult)
+	if err != nil {
+		return nil, err
+	}
+	summaryOriginal := pii.Redact(eval.OverallSummary)
+	cv.Completion, project.Completion, summary.Completion = cvOriginal, projectOriginal, summaryOriginal
+
+	if correction != nil {
+		corrected := *cvResult
+		if correction.CVMatchRate != nil {
+			corrected.MatchRate = *correction.CVMatchRate
+		}
+		if correction.CVFeedback != "" {
+			corrected.Feedback = correction.CVFeedback
+		}
+		if cv.Completion, err = completionJSON(&corrected); err != nil {
+			return nil, err
+		}
+
+		correctedProject := *projectResult
+		if correction.ProjectScore != nil {
+			correctedProject.ProjectScore = *correction.ProjectScore
+		}
+		if correction.ProjectFeedback != "" {
+			correctedProject.Feedback = correction.ProjectFeedback
+		}
+		if project.Completion, err = completionJSON(&correctedProject); err != nil {
+			return nil, err
+		}
+
+		if correction.OverallSummary != "" {
+			summary.Completion = pii.Redact(correction.OverallSummary)
+		}
+
+		for _, r := range []*TrainingRecord{&cv, &project, &summary} {
+			r.Corrected = true
+			r.CorrectionNote = pii.Redact(correction.Note)
+		}
+		cv.OriginalCompletion = cvOriginal
+		project.OriginalCompletion = projectOriginal
+		summary.OriginalCompletion = summaryOriginal
+	}
+
+	return []TrainingRecord{cv, project, summary}, nil
+}
+
+// completionJSON encodes a stage result the way the model is asked to
+// answer, with PII removed from the feedback.
+func completionJSON(result interface{}) (string, error) {
+	raw, err := json.Marshal(result)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode completion: %w", err)
+	}
+	return pii.Redact(string(raw)), nil
+}
+
+// excerpt shortens text to at most limit runes (0 = no limit).
+func excerpt(text string, limit int) string {
+	runes := []rune(text)
+	if limit <= 0 || len(runes) <= limit {
+		return text
+	}
+	return string(runes[:limit])
+}
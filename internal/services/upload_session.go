@@ -0,0 +1,188 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"alfredoptarigan/cv-evaluator/internal/apperror"
+	"alfredoptarigan/cv-evaluator/internal/models"
+	"alfredoptarigan/cv-evaluator/internal/repositories"
+	"alfredoptarigan/cv-evaluator/internal/tenant"
+)
+
+var (
+	ErrUploadSessionNotFound = apperror.New(http.StatusNotFound, apperror.CodeUploadNotFound, "upload session not found")
+	ErrUploadSessionExpired  = apperror.New(http.StatusGone, apperror.CodeUploadExpired, "upload session expired")
+)
+
+// UploadSessionService implements resumable uploads: a session is created
+// with the expected size, chunks are appended at the current offset, and the
+// last chunk finalizes it into a Document through DocumentService.
+type UploadSessionService interface {
+	Create(ctx context.Context, originalName string, fileType string, size int64) (*models.UploadSession, error)
+	Get(ctx context.Context, id uuid.UUID) (*models.UploadSession, error)
+	Append(ctx context.Context, id uuid.UUID, offset int64, chunk io.Reader) (*models.UploadSession, *models.Document, error)
+}
+
+type uploadSessionService struct {
+	sessionRepo    repositories.UploadSessionRepository
+	storageService StorageService
+	docService     DocumentService
+	maxFileSize    int64
+	ttl            time.Duration
+	locks          sync.Map // session ID -> *sync.Mutex
+}
+
+func NewUploadSessionService(
+	sessionRepo repositories.UploadSessionRepository,
+	storageService StorageService,
+	docService DocumentService,
+	maxFileSize int64,
+	ttl time.Duration,
+) UploadSessionService {
+	return &uploadSessionService{
+		sessionRepo:    sessionRepo,
+		storageService: storageService,
+		docService:     docService,
+		maxFileSize:    maxFileSize,
+		ttl:            ttl,
+	}
+}
+
+// Create implements UploadSessionService.
+func (s *uploadSessionService) Create(ctx context.Context, originalName string, fileType string, size int64) (*models.UploadSession, error) {
+	if size > s.maxFileSize {
+		return nil, apperror.New(http.StatusBadRequest, apperror.CodeFileTooLarge, fmt.Sprintf("file too large. Max size: %d bytes", s.maxFileSize))
+	}
+
+	// Expired sessions are cleaned up lazily whenever a new one starts
+	s.purgeExpired()
+
+	now := time.Now()
+	session := &models.UploadSession{
+		ID:           models.NewID(),
+		TenantID:     tenant.FromContext(ctx),
+		OriginalName: originalName,
+		FileType:     fileType,
+		Size:         size,
+		ExpiresAt:    now.Add(s.ttl),
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	}
+
+	if err := s.sessionRepo.Create(session); err != nil {
+		return nil, err
+	}
+
+	return session, nil
+}
+
+// Get implements UploadSessionService.
+func (s *uploadSessionService) Get(ctx context.Context, id uuid.UUID) (*models.UploadSession, error) {
+	session, err := s.sessionRepo.FindByID(id)
+	if err != nil || session.TenantID != tenant.FromContext(ctx) {
+		return nil, ErrUploadSessionNotFound
+	}
+
+	if session.DocumentID == nil && time.Now().After(session.ExpiresAt) {
+		return nil, ErrUploadSessionExpired
+	}
+
+	return session, nil
+}
+
+// Append implements UploadSessionService. The returned document is non-nil
+// once the upload is complete.
+func (s *uploadSessionService) Append(ctx context.Context, id uuid.UUID, offset int64, chunk io.Reader) (*models.UploadSession, *models.Document, error) {
+	lock, _ := s.locks.LoadOrStore(id, &sync.Mutex{})
+	lock.(*sync.Mutex).Lock()
+	defer lock.(*sync.Mutex).Unlock()
+
+	session, err := s.Get(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if session.DocumentID != nil || offset != session.ReceivedBytes {
+		return nil, nil, &apperror.Error{
+			Status:  http.StatusConflict,
+			Code:    apperror.CodeUploadOffset,
+			Message: "upload offset does not match the bytes received",
+			Details: map[string]interface{}{"offset": session.ReceivedBytes},
+		}
+	}
+
+	written, err := s.storageService.AppendPartial(id.String(), chunk, session.Size-session.ReceivedBytes)
+	if err != nil {
+		if err == ErrFileTooLarge {
+			return nil, nil, apperror.New(http.StatusBadRequest, apperror.CodeFileTooLarge, "chunk exceeds the declared upload size")
+		}
+		return nil, nil, err
+	}
+
+	session.ReceivedBytes += written
+	if err := s.sessionRepo.UpdateReceived(id, session.ReceivedBytes); err != nil {
+		return nil, nil, err
+	}
+
+	if session.ReceivedBytes < session.Size {
+		return session, nil, nil
+	}
+
+	doc, err := s.finalize(ctx, session)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return session, doc, nil
+}
+
+func (s *uploadSessionService) finalize(ctx context.Context, session *models.UploadSession) (*models.Document, error) {
+	src, err := s.storageService.OpenPartial(session.ID.String())
+	if err != nil {
+		return nil, err
+	}
+
+	doc, err := s.docService.Upload(ctx, src, session.OriginalName, session.FileType, "")
+	src.Close()
+	if err != nil {
+		// The bytes can't become a document; drop them so the client starts over
+		s.storageService.DeletePartial(session.ID.String())
+		s.sessionRepo.UpdateReceived(session.ID, 0)
+		return nil, err
+	}
+
+	if err := s.sessionRepo.SetDocument(session.ID, doc.ID); err != nil {
+		return nil, err
+	}
+	session.DocumentID = &doc.ID
+
+	if err := s.storageService.DeletePartial(session.ID.String()); err != nil {
+		log.Printf("⚠️  Failed to delete partial upload %s: %v\n", session.ID, err)
+	}
+	s.locks.Delete(session.ID)
+
+	return doc, nil
+}
+
+func (s *uploadSessionService) purgeExpired() {
+	ids, err := s.sessionRepo.DeleteExpired(time.Now())
+	if err != nil {
+		log.Printf("⚠️  Failed to purge expired upload sessions: %v\n", err)
+		return
+	}
+
+	for _, id := range ids {
+		if err := s.storageService.DeletePartial(id.String()); err != nil {
+			log.Printf("⚠️  Failed to delete partial upload %s: %v\n", id, err)
+		}
+		s.locks.Delete(id)
+	}
+}
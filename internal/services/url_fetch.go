@@ -0,0 +1,267 @@
+package services
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"path"
+	"strings"
+	"syscall"
+	"time"
+
+	"alfredoptarigan/cv-evaluator/internal/apperror"
+)
+
+// maxFetchRedirects bounds the redirects followed for one download, e.g. a
+// Google Drive export link redirecting to its content host.
+const maxFetchRedirects = 5
+
+// contentFileTypes maps the content types a download may declare to the
+// file type its content has to sniff as. Generic binary types leave it to
+// sniffing alone.
+var contentFileTypes = map[string]string{
+	"application/pdf": FileTypePDF,
+	"application/vnd.openxmlformats-officedocument.wordprocessingml.document": FileTypeDOCX,
+	"text/plain":               FileTypeText,
+	"text/markdown":            FileTypeText,
+	"text/x-markdown":          FileTypeText,
+	"text/html":                FileTypeHTML,
+	"application/xhtml+xml":    FileTypeHTML,
+	"application/octet-stream": "",
+	"binary/octet-stream":      "",
+}
+
+var errPrivateAddress = errors.New("address is not publicly routable")
+
+// URLFetcher downloads documents linked by URL.
+type URLFetcher interface {
+	// Fetch starts downloading rawURL. The body of the returned document is
+	// positioned at the start of the content and must be closed.
+	Fetch(ctx context.Context, rawURL string, maxSize int64) (*FetchedDocument, error)
+}
+
+// FetchedDocument is a download in progress.
+type FetchedDocument struct {
+	Body io.ReadCloser
+	// Name is the file name to store the document under, with the extension
+	// of its sniffed content.
+	Name string
+	Host string
+}
+
+type urlFetcher struct {
+	client *http.Client
+}
+
+// NewURLFetcher returns a fetcher whose downloads, body included, must
+// finish within timeout. Unless allowPrivate is set, hosts resolving to
+// addresses that aren't globally routable (loopback, private, link-local,
+// carrier-grade NAT and the other special-use ranges) are refused so URLs
+// can't reach internal services or cloud metadata endpoints.
+func NewURLFetcher(timeout time.Duration, allowPrivate bool) URLFetcher {
+	allowed := isPublicIP
+	if allowPrivate {
+		allowed = nil
+	}
+	return newURLFetcher(timeout, allowed)
+}
+
+// newURLFetcher returns a fetcher that only connects, and follows
+// redirects, to addresses allowed accepts; a nil allowed accepts any.
+func newURLFetcher(timeout time.Duration, allowed func(net.IP) bool) *urlFetcher {
+	dialer := &net.Dialer{Timeout: 10 * time.Second}
+	if allowed != nil {
+		// Checked on the resolved address, so a public name pointing at
+		// an internal address is refused as well
+		dialer.Control = func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); ip == nil || !allowed(ip) {
+				return errPrivateAddress
+			}
+			return nil
+		}
+	}
+
+	return &urlFetcher{
+		client: &http.Client{
+			Timeout: timeout,
+			Transport: &http.Transport{
+				DialContext:           dialer.DialContext,
+				TLSHandshakeTimeout:   10 * time.Second,
+				ResponseHeaderTimeout: timeout,
+				MaxIdleConns:          10,
+				IdleConnTimeout:       90 * time.Second,
+			},
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				if len(via) >= maxFetchRedirects {
+					return fmt.Errorf("stopped after %d redirects", maxFetchRedirects)
+				}
+				if req.URL.Scheme != "http" && req.URL.Scheme != "https" {
+					return fmt.Errorf("redirect to unsupported scheme %q", req.URL.Scheme)
+				}
+				if ip := net.ParseIP(req.URL.Hostname()); ip != nil && allowed != nil && !allowed(ip) {
+					return errPrivateAddress
+				}
+				return nil
+			},
+		},
+	}
+}
+
+// nonPublicPrefixes are the IANA special-purpose ranges that aren't globally
+// reachable, along with the transition ranges (NAT64, 6to4, Teredo) that can
+// carry one of them.
+var nonPublicPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),       // "this" network
+	netip.MustParsePrefix("10.0.0.0/8"),      // private
+	netip.MustParsePrefix("100.64.0.0/10"),   // carrier-grade NAT, e.g. Alibaba Cloud metadata
+	netip.MustParsePrefix("127.0.0.0/8"),     // loopback
+	netip.MustParsePrefix("169.254.0.0/16"),  // link-local, e.g. AWS and GCP metadata
+	netip.MustParsePrefix("172.16.0.0/12"),   // private
+	netip.MustParsePrefix("192.0.0.0/24"),    // IETF protocol assignments
+	netip.MustParsePrefix("192.0.2.0/24"),    // documentation
+	netip.MustParsePrefix("192.88.99.0/24"),  // 6to4 relay anycast
+	netip.MustParsePrefix("192.168.0.0/16"),  // private
+	netip.MustParsePrefix("198.18.0.0/15"),   // benchmarking
+	netip.MustParsePrefix("198.51.100.0/24"), // documentation
+	netip.MustParsePrefix("203.0.113.0/24"),  // documentation
+	netip.MustParsePrefix("224.0.0.0/4"),     // multicast
+	netip.MustParsePrefix("240.0.0.0/4"),     // reserved and broadcast
+	netip.MustParsePrefix("::/96"),           // unspecified, loopback and IPv4-compatible
+	netip.MustParsePrefix("64:ff9b::/96"),    // NAT64
+	netip.MustParsePrefix("64:ff9b:1::/48"),  // local NAT64
+	netip.MustParsePrefix("100::/64"),        // discard
+	netip.MustParsePrefix("2001::/23"),       // IETF protocol assignments, Teredo included
+	netip.MustParsePrefix("2001:db8::/32"),   // documentation
+	netip.MustParsePrefix("2002::/16"),       // 6to4
+	netip.MustParsePrefix("fc00::/7"),        // unique local
+	netip.MustParsePrefix("fe80::/10"),       // link-local
+	netip.MustParsePrefix("fec0::/10"),       // site-local
+	netip.MustParsePrefix("ff00::/8"),        // multicast
+}
+
+// isPublicIP reports whether ip is outside every non-public range. IPv4
+// addresses mapped into IPv6 are checked as IPv4.
+func isPublicIP(ip net.IP) bool {
+	addr, ok := netip.AddrFromSlice(ip)
+	if !ok {
+		return false
+	}
+	addr = addr.Unmap()
+	for _, prefix := range nonPublicPrefixes {
+		if prefix.Contains(addr) {
+			return false
+		}
+	}
+	return true
+}
+
+// Fetch implements URLFetcher.
+func (f *urlFetcher) Fetch(ctx context.Context, rawURL string, maxSize int64) (*FetchedDocument, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, apperror.New(http.StatusBadRequest, apperror.CodeValidationFailed, "document URL must be an absolute http or https URL")
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, apperror.New(http.StatusBadRequest, apperror.CodeValidationFailed, "invalid document URL")
+	}
+	req.Header.Set("User-Agent", "cv-evaluator")
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return nil, fetchError(u.Host, err)
+	}
+
+	fetched, err := inspectDownload(resp, maxSize)
+	if err != nil {
+		resp.Body.Close()
+		return nil, err
+	}
+	return fetched, nil
+}
+
+// inspectDownload checks the response and names the document after the
+// file type its content sniffs as.
+func inspectDownload(resp *http.Response, maxSize int64) (*FetchedDocument, error) {
+	host := resp.Request.URL.Host
+	if resp.StatusCode != http.StatusOK {
+		return nil, apperror.New(http.StatusBadGateway, apperror.CodeFetchFailed,
+			fmt.Sprintf("%s returned %s", host, resp.Status))
+	}
+	if maxSize > 0 && resp.ContentLength > maxSize {
+		return nil, ErrFileTooLarge
+	}
+
+	contentType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	declared, known := contentFileTypes[contentType]
+	if contentType != "" && !known {
+		return nil, apperror.New(http.StatusBadRequest, apperror.CodeUnsupportedFileType,
+			fmt.Sprintf("%s returned %s, not a document", host, contentType))
+	}
+
+	body := bufio.NewReaderSize(resp.Body, sniffLen)
+	header, err := body.Peek(sniffLen)
+	if err != nil && err != io.EOF && err != bufio.ErrBufferFull {
+		return nil, fetchError(host, err)
+	}
+	detected := DetectFileType(header)
+	if detected == "" || (declared != "" && declared != detected) {
+		return nil, apperror.New(http.StatusBadRequest, apperror.CodeUnsupportedFileType,
+			fmt.Sprintf("content downloaded from %s is not a supported document", host))
+	}
+
+	name := downloadName(resp)
+	if FileTypeForExtension(path.Ext(name)) != detected {
+		name += fileTypeExtensions[detected][0]
+	}
+
+	return &FetchedDocument{
+		Body: struct {
+			io.Reader
+			io.Closer
+		}{body, resp.Body},
+		Name: name,
+		Host: host,
+	}, nil
+}
+
+// downloadName takes the file name from Content-Disposition, falling back to
+// the last segment of the final URL's path.
+func downloadName(resp *http.Response) string {
+	if _, params, err := mime.ParseMediaType(resp.Header.Get("Content-Disposition")); err == nil {
+		if name := path.Base(strings.ReplaceAll(params["filename"], "\\", "/")); name != "." && name != "/" {
+			return name
+		}
+	}
+	if name := path.Base(resp.Request.URL.Path); name != "." && name != "/" {
+		return name
+	}
+	return "download"
+}
+
+func fetchError(host string, err error) error {
+	if errors.Is(err, errPrivateAddress) {
+		return apperror.New(http.StatusBadRequest, apperror.CodeValidationFailed,
+			fmt.Sprintf("%s is not a public address", host))
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return &apperror.Error{Status: http.StatusGatewayTimeout, Code: apperror.CodeFetchFailed,
+			Message: fmt.Sprintf("download from %s timed out", host), Err: err}
+	}
+	return &apperror.Error{Status: http.StatusBadGateway, Code: apperror.CodeFetchFailed,
+		Message: fmt.Sprintf("failed to download from %s", host), Err: err}
+}
@@ -0,0 +1,195 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"alfredoptarigan/cv-evaluator/internal/apperror"
+)
+
+func TestIsPublicIP(t *testing.T) {
+	tests := []struct {
+		ip   string
+		want bool
+	}{
+		{"8.8.8.8", true},
+		{"1.1.1.1", true},
+		{"100.63.255.255", true},
+		{"100.128.0.1", true},
+		{"2606:4700:4700::1111", true},
+		{"::ffff:8.8.8.8", true},
+
+		{"0.0.0.0", false},
+		{"127.0.0.1", false},
+		{"10.1.2.3", false},
+		{"172.16.0.1", false},
+		{"192.168.1.1", false},
+		{"169.254.169.254", false}, // AWS and GCP metadata
+		{"100.100.100.200", false}, // Alibaba Cloud metadata
+		{"100.64.0.1", false},
+		{"192.0.0.1", false},
+		{"192.0.2.1", false},
+		{"198.18.0.1", false},
+		{"224.0.0.1", false},
+		{"255.255.255.255", false},
+		{"::", false},
+		{"::1", false},
+		{"::ffff:127.0.0.1", false},
+		{"::ffff:169.254.169.254", false},
+		{"64:ff9b::a9fe:a9fe", false},
+		{"2002:a9fe:a9fe::1", false},
+		{"2001:db8::1", false},
+		{"fc00::1", false},
+		{"fd00:ec2::254", false}, // AWS IPv6 metadata
+		{"fe80::1", false},
+		{"ff02::1", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.ip, func(t *testing.T) {
+			ip := net.ParseIP(tt.ip)
+			if ip == nil {
+				t.Fatalf("invalid test address %q", tt.ip)
+			}
+			if got := isPublicIP(ip); got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFetchRefusesPrivateAddress(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "internal")
+	}))
+	defer srv.Close()
+
+	_, err := NewURLFetcher(5*time.Second, false).Fetch(context.Background(), srv.URL+"/cv.txt", 0)
+	if status := errorStatus(err); status != http.StatusBadRequest {
+		t.Fatalf("got status %d (%v), want %d", status, err, http.StatusBadRequest)
+	}
+}
+
+func TestFetchRedirects(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/cv.txt", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		io.WriteString(w, "Jane Doe, Backend Engineer")
+	})
+	mux.HandleFunc("/redirect", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, r.URL.Query().Get("to"), http.StatusFound)
+	})
+	mux.HandleFunc("/loop", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/loop", http.StatusFound)
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	// The test server is on loopback, so allow that but nothing else private
+	fetcher := newURLFetcher(5*time.Second, func(ip net.IP) bool {
+		return ip.IsLoopback() || isPublicIP(ip)
+	})
+
+	tests := []struct {
+		name   string
+		path   string
+		status int
+	}{
+		{"to the same host", "/redirect?to=/cv.txt", http.StatusOK},
+		{"to link-local metadata", "/redirect?to=http://169.254.169.254/latest/meta-data/", http.StatusBadRequest},
+		{"to carrier-grade NAT metadata", "/redirect?to=http://100.100.100.200/latest/meta-data/", http.StatusBadRequest},
+		{"to a private address", "/redirect?to=http://10.0.0.1/", http.StatusBadRequest},
+		{"to a mapped private address", "/redirect?to=http://[::ffff:10.0.0.1]/", http.StatusBadRequest},
+		{"to an unsupported scheme", "/redirect?to=file:///etc/passwd", http.StatusBadGateway},
+		{"in a loop", "/loop", http.StatusBadGateway},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fetched, err := fetcher.Fetch(context.Background(), srv.URL+tt.path, 0)
+			if tt.status == http.StatusOK {
+				if err != nil {
+					t.Fatalf("got error %v, want none", err)
+				}
+				defer fetched.Body.Close()
+				if fetched.Name != "cv.txt" {
+					t.Errorf("got name %q, want %q", fetched.Name, "cv.txt")
+				}
+				return
+			}
+			if status := errorStatus(err); status != tt.status {
+				t.Fatalf("got status %d (%v), want %d", status, err, tt.status)
+			}
+		})
+	}
+}
+
+func TestInspectDownload(t *testing.T) {
+	tests := []struct {
+		name        string
+		status      int
+		contentType string
+		body        string
+		url         string
+		wantStatus  int
+		wantName    string
+	}{
+		{"text", http.StatusOK, "text/plain", "Jane Doe", "http://example.com/files/cv.txt", http.StatusOK, "cv.txt"},
+		{"pdf with a generic type", http.StatusOK, "application/octet-stream", "%PDF-1.7\n", "http://example.com/download", http.StatusOK, "download.pdf"},
+		{"extension of another type", http.StatusOK, "", "%PDF-1.7\n", "http://example.com/cv.txt", http.StatusOK, "cv.txt.pdf"},
+		{"error status", http.StatusNotFound, "text/plain", "not found", "http://example.com/cv.txt", http.StatusBadGateway, ""},
+		{"unfollowed redirect", http.StatusFound, "", "", "http://example.com/cv.txt", http.StatusBadGateway, ""},
+		{"not a document type", http.StatusOK, "image/png", "\x89PNG\r\n", "http://example.com/cv.png", http.StatusBadRequest, ""},
+		{"content not matching its type", http.StatusOK, "application/pdf", "Jane Doe", "http://example.com/cv.pdf", http.StatusBadRequest, ""},
+		{"binary content", http.StatusOK, "", "\x00\x01\x02\x03", "http://example.com/cv.bin", http.StatusBadRequest, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := &http.Response{
+				StatusCode: tt.status,
+				Status:     http.StatusText(tt.status),
+				Header:     http.Header{},
+				Body:       io.NopCloser(strings.NewReader(tt.body)),
+				Request:    httptest.NewRequest(http.MethodGet, tt.url, nil),
+			}
+			if tt.contentType != "" {
+				resp.Header.Set("Content-Type", tt.contentType)
+			}
+
+			fetched, err := inspectDownload(resp, 0)
+			if tt.wantStatus != http.StatusOK {
+				if status := errorStatus(err); status != tt.wantStatus {
+					t.Fatalf("got status %d (%v), want %d", status, err, tt.wantStatus)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("got error %v, want none", err)
+			}
+			if fetched.Name != tt.wantName {
+				t.Errorf("got name %q, want %q", fetched.Name, tt.wantName)
+			}
+			if body, _ := io.ReadAll(fetched.Body); string(body) != tt.body {
+				t.Errorf("got body %q, want %q", body, tt.body)
+			}
+		})
+	}
+}
+
+// errorStatus returns the HTTP status of err, 0 if it is nil or not an
+// apperror.
+func errorStatus(err error) int {
+	var appErr *apperror.Error
+	if errors.As(err, &appErr) {
+		return appErr.Status
+	}
+	return 0
+}
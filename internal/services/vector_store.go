@@ -0,0 +1,154 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"alfredoptarigan/cv-evaluator/internal/tenant"
+)
+
+// ErrDimensionMismatch is returned by InitCollection when the collection was
+// built for another embedding size.
+var ErrDimensionMismatch = errors.New("vector dimension mismatch; rebuild the collection with cvctl vectors rebuild")
+
+// VectorStore keeps embedded reference chunks and finds the ones closest to
+// a query. A chunk is identified by its tenant, document ID and chunk index,
+// so upserting the same chunk again replaces it.
+//
+// Chunks written with a tenant on the context (see tenant.WithID) are only
+// visible to that tenant; chunks written without one are shared reference
+// material. Searches return the tenant's own and the shared chunks, and
+// DeleteDocument only touches chunks of the context's scope. Searches with
+// a job on the context (see WithJob) also leave out other jobs' chunks.
+type VectorStore interface {
+	// InitCollection creates the collection if needed and fails when an
+	// existing one was built for a different vector size.
+	InitCollection() error
+	// ResetCollection drops all chunks and recreates the collection, e.g.
+	// after the embedding model changed.
+	ResetCollection() error
+	UpsertDocument(ctx context.Context, docID string, chunkIndex int, docType string, text string, embedding []float32, metadata map[string]interface{}) error
+	SearchSimilar(ctx context.Context, queryEmbedding []float32, docType string, limit int) ([]SearchResult, error)
+	HybridSearch(ctx context.Context, queryEmbedding []float32, queryText string, docType string, limit int) ([]SearchResult, error)
+	DeleteDocument(ctx context.Context, docID string) error
+}
+
+type SearchResult struct {
+	ID       string
+	Score    float32
+	Text     string
+	DocType  string
+	Source   string
+	Section  string
+	Page     int
+	Metadata map[string]interface{}
+}
+
+const (
+	VectorStoreQdrant   = "qdrant"
+	VectorStorePgvector = "pgvector"
+	VectorStoreWeaviate = "weaviate"
+	VectorStoreMemory   = "memory"
+)
+
+// VectorStoreOptions selects and configures a VectorStore backend.
+type VectorStoreOptions struct {
+	Backend string
+	// Dimensions is the embedding vector size.
+	Dimensions int
+	// DB is used by the pgvector backend.
+	DB *gorm.DB
+
+	QdrantURL       string
+	QdrantAPIKey    string
+	Collection      string
+	QdrantSnapshots QdrantSnapshotOptions
+	// QdrantTenantCollections lists tenants that get a Qdrant collection of
+	// their own.
+	QdrantTenantCollections []string
+
+	// Weaviate backend connection; WeaviateClass holds the chunks.
+	WeaviateURL    string
+	WeaviateAPIKey string
+	WeaviateClass  string
+}
+
+// chunkIDNamespace scopes the name-based UUIDs of chunks.
+var chunkIDNamespace = uuid.MustParse("6f1c1a52-3c1e-4c55-9d0e-5b8f2a7c4e10")
+
+// ChunkID derives a stable UUID (v5) for a chunk from its tenant scope,
+// document ID and index. Shared chunks (empty scope) keep the IDs they had
+// before chunks were scoped.
+func ChunkID(scope, docID string, chunkIndex int) uuid.UUID {
+	name := fmt.Sprintf("%s#%d", docID, chunkIndex)
+	if scope != "" {
+		name = scope + "/" + name
+	}
+	return uuid.NewSHA1(chunkIDNamespace, []byte(name))
+}
+
+// tenantScope returns the tenant chunks written with ctx belong to, or ""
+// for shared chunks.
+func tenantScope(ctx context.Context) string {
+	id, _ := tenant.Lookup(ctx)
+	return id
+}
+
+// jobMetadataKey is the chunk metadata holding the job a reference document
+// was ingested for (see JobKey).
+const jobMetadataKey = "job"
+
+type jobKey struct{}
+
+// WithJob scopes searches with ctx to the chunks of reference documents
+// ingested for job, a JobKey, and those ingested for no job in particular.
+// Without a job, chunks of every job are searched.
+func WithJob(ctx context.Context, job string) context.Context {
+	if job == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, jobKey{}, job)
+}
+
+func jobScope(ctx context.Context) string {
+	job, _ := ctx.Value(jobKey{}).(string)
+	return job
+}
+
+// chunkJob returns the job a chunk's metadata ties it to, "" for none.
+func chunkJob(metadata map[string]interface{}) string {
+	job, _ := metadata[jobMetadataKey].(string)
+	return job
+}
+
+// JobKey turns a job title into the identifier reference documents are
+// ingested for: lowercase words joined by dashes, so "Backend Engineer"
+// and "backend-engineer" are the same job.
+func JobKey(title string) string {
+	words := strings.FieldsFunc(strings.ToLower(title), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	return strings.Join(words, "-")
+}
+
+// NewVectorStore creates the backend named by opts.Backend.
+func NewVectorStore(opts VectorStoreOptions) (VectorStore, error) {
+	switch opts.Backend {
+	case VectorStoreQdrant, "":
+		return NewQdrantService(opts.QdrantURL, opts.QdrantAPIKey, opts.Collection, opts.Dimensions, opts.QdrantSnapshots, opts.QdrantTenantCollections)
+	case VectorStorePgvector:
+		return NewPgvectorStore(opts.DB, opts.Dimensions), nil
+	case VectorStoreMemory:
+		return NewMemoryVectorStore(), nil
+	case VectorStoreWeaviate:
+		return NewWeaviateStore(opts.WeaviateURL, opts.WeaviateAPIKey, opts.WeaviateClass), nil
+	default:
+		return nil, fmt.Errorf("unknown vector store backend: %s", opts.Backend)
+	}
+}
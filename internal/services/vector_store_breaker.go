@@ -0,0 +1,64 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"github.com/sony/gobreaker"
+
+	"alfredoptarigan/cv-evaluator/internal/apperror"
+)
+
+type breakerVectorStore struct {
+	next VectorStore
+	cb   *gobreaker.CircuitBreaker
+}
+
+// NewBreakerVectorStore wraps next in a circuit breaker reported under name.
+func NewBreakerVectorStore(next VectorStore, name string, maxFailures uint32, openTimeout time.Duration) VectorStore {
+	return &breakerVectorStore{
+		next: next,
+		cb:   newBreaker(name, maxFailures, openTimeout),
+	}
+}
+
+// InitCollection implements VectorStore. It runs once at startup and isn't
+// worth tripping the breaker for.
+func (b *breakerVectorStore) InitCollection() error {
+	return b.next.InitCollection()
+}
+
+// ResetCollection implements VectorStore.
+func (b *breakerVectorStore) ResetCollection() error {
+	return b.next.ResetCollection()
+}
+
+// UpsertDocument implements VectorStore.
+func (b *breakerVectorStore) UpsertDocument(ctx context.Context, docID string, chunkIndex int, docType string, text string, embedding []float32, metadata map[string]interface{}) error {
+	_, err := callBreaker(b.cb, apperror.CodeServiceUnavailable, func() (struct{}, error) {
+		return struct{}{}, b.next.UpsertDocument(ctx, docID, chunkIndex, docType, text, embedding, metadata)
+	})
+	return err
+}
+
+// SearchSimilar implements VectorStore.
+func (b *breakerVectorStore) SearchSimilar(ctx context.Context, queryEmbedding []float32, docType string, limit int) ([]SearchResult, error) {
+	return callBreaker(b.cb, apperror.CodeServiceUnavailable, func() ([]SearchResult, error) {
+		return b.next.SearchSimilar(ctx, queryEmbedding, docType, limit)
+	})
+}
+
+// HybridSearch implements VectorStore.
+func (b *breakerVectorStore) HybridSearch(ctx context.Context, queryEmbedding []float32, queryText string, docType string, limit int) ([]SearchResult, error) {
+	return callBreaker(b.cb, apperror.CodeServiceUnavailable, func() ([]SearchResult, error) {
+		return b.next.HybridSearch(ctx, queryEmbedding, queryText, docType, limit)
+	})
+}
+
+// DeleteDocument implements VectorStore.
+func (b *breakerVectorStore) DeleteDocument(ctx context.Context, docID string) error {
+	_, err := callBreaker(b.cb, apperror.CodeServiceUnavailable, func() (struct{}, error) {
+		return struct{}{}, b.next.DeleteDocument(ctx, docID)
+	})
+	return err
+}
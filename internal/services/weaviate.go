@@ -0,0 +1,378 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"alfredoptarigan/cv-evaluator/internal/tenant"
+)
+
+type weaviateStore struct {
+	baseURL   string
+	apiKey    string
+	className string
+	client    *http.Client
+}
+
+// NewWeaviateStore talks to Weaviate's REST and GraphQL APIs. Vectors are
+// supplied by us, so the class is created without a vectorizer.
+func NewWeaviateStore(baseURL, apiKey, className string) VectorStore {
+	return &weaviateStore{
+		baseURL:   strings.TrimRight(baseURL, "/"),
+		apiKey:    apiKey,
+		className: className,
+		client:    &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// weaviateShared is the tenantId of shared chunks, and weaviateAnyJob the
+// job of chunks not ingested for one; Weaviate can't filter on empty text
+// values.
+const (
+	weaviateShared = "_shared"
+	weaviateAnyJob = "_any"
+)
+
+type weaviateObject struct {
+	DocID    string `json:"docId"`
+	DocType  string `json:"docType"`
+	Text     string `json:"text"`
+	Metadata string `json:"metadata"`
+	Extra    struct {
+		Distance *float64 `json:"distance"`
+	} `json:"_additional"`
+}
+
+// InitCollection implements VectorStore. Weaviate rejects vectors of the
+// wrong size on insert, so dimensions aren't checked here.
+func (w *weaviateStore) InitCollection() error {
+	ctx := context.Background()
+
+	status, err := w.do(ctx, http.MethodGet, "/v1/schema/"+w.className, nil, nil)
+	if err != nil {
+		return fmt.Errorf("failed to check class: %w", err)
+	}
+	if status == http.StatusOK {
+		log.Println("✅ Weaviate class already exists")
+		return w.ensureProperties(ctx)
+	}
+
+	// docId, docType, tenantId and job are matched exactly, text is searched with BM25 and
+	// metadata is only carried along as JSON
+	class := map[string]interface{}{
+		"class":      w.className,
+		"vectorizer": "none",
+		"vectorIndexConfig": map[string]interface{}{
+			"distance": "cosine",
+		},
+		"properties": []map[string]interface{}{
+			{"name": "docId", "dataType": []string{"text"}, "tokenization": "field"},
+			{"name": "docType", "dataType": []string{"text"}, "tokenization": "field"},
+			{"name": "tenantId", "dataType": []string{"text"}, "tokenization": "field"},
+			{"name": "job", "dataType": []string{"text"}, "tokenization": "field"},
+			{"name": "text", "dataType": []string{"text"}, "tokenization": "word"},
+			{"name": "metadata", "dataType": []string{"text"}, "indexFilterable": false, "indexSearchable": false},
+		},
+	}
+	if _, err := w.do(ctx, http.MethodPost, "/v1/schema", class, nil); err != nil {
+		return fmt.Errorf("failed to create class: %w", err)
+	}
+
+	log.Printf("✅ Weaviate class '%s' created successfully\n", w.className)
+	return nil
+}
+
+// ensureProperties adds tenantId and job to classes created before chunks
+// were scoped by tenant and job. Objects stored without them are not found
+// by searches (scoped to a job, for job) until the class is reindexed.
+func (w *weaviateStore) ensureProperties(ctx context.Context) error {
+	var class struct {
+		Properties []struct {
+			Name string `json:"name"`
+		} `json:"properties"`
+	}
+	if _, err := w.do(ctx, http.MethodGet, "/v1/schema/"+w.className, nil, &class); err != nil {
+		return fmt.Errorf("failed to read class: %w", err)
+	}
+	existing := make(map[string]bool)
+	for _, p := range class.Properties {
+		existing[p.Name] = true
+	}
+
+	for _, name := range []string{"tenantId", "job"} {
+		if existing[name] {
+			continue
+		}
+		property := map[string]interface{}{"name": name, "dataType": []string{"text"}, "tokenization": "field"}
+		if _, err := w.do(ctx, http.MethodPost, "/v1/schema/"+w.className+"/properties", property, nil); err != nil {
+			return fmt.Errorf("failed to add %s property: %w", name, err)
+		}
+		log.Printf("⚠️  Added %s to Weaviate class '%s'; reindex to make existing chunks searchable\n", name, w.className)
+	}
+	return nil
+}
+
+// ResetCollection implements VectorStore. Weaviate takes the vector size
+// from the first object, so a dropped class accepts the new size.
+func (w *weaviateStore) ResetCollection() error {
+	if _, err := w.do(context.Background(), http.MethodDelete, "/v1/schema/"+w.className, nil, nil); err != nil {
+		return fmt.Errorf("failed to delete class: %w", err)
+	}
+
+	return w.InitCollection()
+}
+
+// UpsertDocument implements VectorStore.
+func (w *weaviateStore) UpsertDocument(ctx context.Context, docID string, chunkIndex int, docType string, text string, embedding []float32, metadata map[string]interface{}) error {
+	stored := make(map[string]interface{}, len(metadata)+1)
+	for key, value := range metadata {
+		stored[key] = value
+	}
+	stored["chunk_index"] = chunkIndex
+
+	encoded, err := json.Marshal(stored)
+	if err != nil {
+		return fmt.Errorf("failed to encode metadata: %w", err)
+	}
+
+	// The batch endpoint replaces an object that already has this ID
+	scope := tenantScope(ctx)
+	object := map[string]interface{}{
+		"class": w.className,
+		"id":    ChunkID(scope, docID, chunkIndex).String(),
+		"properties": map[string]interface{}{
+			"docId":    docID,
+			"docType":  docType,
+			"tenantId": weaviateTenant(scope),
+			"job":      weaviateJob(chunkJob(metadata)),
+			"text":     text,
+			"metadata": string(encoded),
+		},
+		"vector": embedding,
+	}
+	var results []struct {
+		Result struct {
+			Errors *struct {
+				Error []struct {
+					Message string `json:"message"`
+				} `json:"error"`
+			} `json:"errors"`
+		} `json:"result"`
+	}
+	batch := map[string]interface{}{"objects": []interface{}{object}}
+	if _, err := w.do(ctx, http.MethodPost, "/v1/batch/objects", batch, &results); err != nil {
+		return fmt.Errorf("failed to upsert object: %w", err)
+	}
+	for _, r := range results {
+		if r.Result.Errors != nil && len(r.Result.Errors.Error) > 0 {
+			return fmt.Errorf("failed to upsert object: %s", r.Result.Errors.Error[0].Message)
+		}
+	}
+
+	return nil
+}
+
+// SearchSimilar implements VectorStore. Scores are cosine similarities, as
+// with Qdrant.
+func (w *weaviateStore) SearchSimilar(ctx context.Context, queryEmbedding []float32, docType string, limit int) ([]SearchResult, error) {
+	vector, err := json.Marshal(queryEmbedding)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode query vector: %w", err)
+	}
+
+	args := fmt.Sprintf("nearVector: {vector: %s}, limit: %d%s", vector, limit, w.searchWhere(ctx, docType))
+	objects, err := w.get(ctx, args)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search: %w", err)
+	}
+
+	return weaviateResults(objects), nil
+}
+
+// HybridSearch implements VectorStore. The keyword pass uses Weaviate's BM25
+// search; both rankings are fused here like the other backends do.
+func (w *weaviateStore) HybridSearch(ctx context.Context, queryEmbedding []float32, queryText string, docType string, limit int) ([]SearchResult, error) {
+	candidates := limit * hybridCandidateFactor
+
+	dense, err := w.SearchSimilar(ctx, queryEmbedding, docType, candidates)
+	if err != nil {
+		return nil, err
+	}
+
+	keywords := extractKeywords(queryText, maxHybridKeywords)
+	if len(keywords) == 0 {
+		return truncateResults(dense, limit), nil
+	}
+
+	query, _ := json.Marshal(strings.Join(keywords, " "))
+	args := fmt.Sprintf(`bm25: {query: %s, properties: ["text"]}, limit: %d%s`, query, candidates, w.searchWhere(ctx, docType))
+	objects, err := w.get(ctx, args)
+	if err != nil {
+		return nil, fmt.Errorf("failed to run keyword search: %w", err)
+	}
+
+	sparse := weaviateResults(objects)
+	for i := range sparse {
+		sparse[i].Score = 0
+	}
+
+	return truncateResults(fuseRankings(dense, sparse), limit), nil
+}
+
+// DeleteDocument implements VectorStore.
+func (w *weaviateStore) DeleteDocument(ctx context.Context, docID string) error {
+	body := map[string]interface{}{
+		"match": map[string]interface{}{
+			"class": w.className,
+			"where": map[string]interface{}{
+				"operator": "And",
+				"operands": []map[string]interface{}{
+					{"path": []string{"docId"}, "operator": "Equal", "valueText": docID},
+					{"path": []string{"tenantId"}, "operator": "Equal", "valueText": weaviateTenant(tenantScope(ctx))},
+				},
+			},
+		},
+	}
+	if _, err := w.do(ctx, http.MethodDelete, "/v1/batch/objects", body, nil); err != nil {
+		return fmt.Errorf("failed to delete document: %w", err)
+	}
+
+	return nil
+}
+
+// searchWhere restricts a query to the tenant's own and shared chunks of
+// docType, and to those of the job on ctx and of no job in particular.
+func (w *weaviateStore) searchWhere(ctx context.Context, docType string) string {
+	tenantID, _ := json.Marshal(tenant.FromContext(ctx))
+	operands := []string{fmt.Sprintf(`{operator: Or, operands: [{path: ["tenantId"], operator: Equal, valueText: %s}, {path: ["tenantId"], operator: Equal, valueText: "%s"}]}`, tenantID, weaviateShared)}
+	if docType != "" {
+		value, _ := json.Marshal(docType)
+		operands = append(operands, fmt.Sprintf(`{path: ["docType"], operator: Equal, valueText: %s}`, value))
+	}
+	if job := jobScope(ctx); job != "" {
+		value, _ := json.Marshal(job)
+		operands = append(operands, fmt.Sprintf(`{operator: Or, operands: [{path: ["job"], operator: Equal, valueText: %s}, {path: ["job"], operator: Equal, valueText: "%s"}]}`, value, weaviateAnyJob))
+	}
+
+	if len(operands) == 1 {
+		return ", where: " + operands[0]
+	}
+	return fmt.Sprintf(`, where: {operator: And, operands: [%s]}`, strings.Join(operands, ", "))
+}
+
+func weaviateJob(job string) string {
+	if job == "" {
+		return weaviateAnyJob
+	}
+	return job
+}
+
+func weaviateTenant(scope string) string {
+	if scope == "" {
+		return weaviateShared
+	}
+	return scope
+}
+
+// get runs a GraphQL Get query on the class with the given arguments.
+func (w *weaviateStore) get(ctx context.Context, args string) ([]weaviateObject, error) {
+	query := fmt.Sprintf(`{ Get { %s(%s) { docId docType text metadata _additional { distance } } } }`, w.className, args)
+
+	var response struct {
+		Data struct {
+			Get map[string][]weaviateObject `json:"Get"`
+		} `json:"data"`
+		Errors []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	if _, err := w.do(ctx, http.MethodPost, "/v1/graphql", map[string]string{"query": query}, &response); err != nil {
+		return nil, err
+	}
+	if len(response.Errors) > 0 {
+		return nil, fmt.Errorf("graphql: %s", response.Errors[0].Message)
+	}
+
+	return response.Data.Get[w.className], nil
+}
+
+// do sends a JSON request and decodes the response into out when set. A 404
+// is returned as a status, not an error, so callers can probe for objects.
+func (w *weaviateStore) do(ctx context.Context, method, path string, body interface{}, out interface{}) (int, error) {
+	var reader io.Reader
+	if body != nil {
+		encoded, err := json.Marshal(body)
+		if err != nil {
+			return 0, fmt.Errorf("failed to encode request: %w", err)
+		}
+		reader = bytes.NewReader(encoded)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, w.baseURL+path, reader)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if w.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+w.apiKey)
+	}
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return resp.StatusCode, nil
+	}
+	if resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return resp.StatusCode, fmt.Errorf("weaviate returned %d: %s", resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+
+	if out != nil {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			return resp.StatusCode, fmt.Errorf("failed to decode response: %w", err)
+		}
+	}
+
+	return resp.StatusCode, nil
+}
+
+func weaviateResults(objects []weaviateObject) []SearchResult {
+	results := make([]SearchResult, 0, len(objects))
+	for _, obj := range objects {
+		result := SearchResult{
+			ID:       obj.DocID,
+			Text:     obj.Text,
+			DocType:  obj.DocType,
+			Metadata: make(map[string]interface{}),
+		}
+		if obj.Extra.Distance != nil {
+			result.Score = float32(1 - *obj.Extra.Distance)
+		}
+
+		if obj.Metadata != "" {
+			if err := json.Unmarshal([]byte(obj.Metadata), &result.Metadata); err != nil {
+				log.Printf("⚠️  Ignoring invalid metadata on chunk %s: %v\n", obj.DocID, err)
+			}
+		}
+		result.Source, _ = result.Metadata["source"].(string)
+		result.Section, _ = result.Metadata["section"].(string)
+		if page, ok := result.Metadata["page"].(float64); ok {
+			result.Page = int(page)
+		}
+
+		results = append(results, result)
+	}
+
+	return results
+}
@@ -2,41 +2,112 @@ package services
 
 import (
 	"context"
+	"fmt"
 	"log"
 	"sync"
 	"time"
 
 	"github.com/google/uuid"
 
+	"alfredoptarigan/cv-evaluator/internal/models"
 	"alfredoptarigan/cv-evaluator/internal/repositories"
 )
 
 type Worker interface {
 	Start(ctx context.Context)
+	// Stop drains the pool: no new jobs are taken, running ones get the
+	// shutdown grace period to finish and are requeued if they don't.
 	Stop()
-	EnqueueJob(evalID uuid.UUID)
+	// EnqueueJob hands a job to the workers without blocking. It returns
+	// false when the queue is full; the job then stays queued in the
+	// database for the pending jobs poller.
+	EnqueueJob(evalID uuid.UUID) bool
+	// QueueDepth returns how many jobs wait in memory and how many fit.
+	QueueDepth() (int, int)
+	// Notify wakes the outbox relay after an evaluation was committed.
+	Notify()
+	// Pause stops workers from taking new jobs; running ones finish.
+	Pause()
+	Resume()
+	Status() models.WorkerPoolStatus
+	// SetConcurrency resizes the pool and turns autoscaling off.
+	SetConcurrency(n int)
+	EnableAutoscale() error
+}
+
+const (
+	outboxPollInterval = time.Second
+	outboxBatchSize    = 50
+	// staleJobAge is how long a queued job may go untouched before the
+	// poller assumes its dispatch was lost, e.g. in a crash.
+	staleJobAge = time.Minute
+	// expireBatchSize bounds how many jobs one poll expires.
+	expireBatchSize = 100
+	// cancelWait bounds how long Stop waits for cancelled jobs to return.
+	cancelWait = 5 * time.Second
+)
+
+// WorkerOptions configures the worker pool.
+type WorkerOptions struct {
+	Concurrency int
+	// MaxConcurrency above Concurrency enables autoscaling between the two
+	// based on the queue depth, checked every AutoscaleInterval.
+	MaxConcurrency    int
+	AutoscaleInterval time.Duration
+	QueueSize         int
+	ShutdownGrace     time.Duration
+	// QueueTTL expires jobs still queued this long after they were created
+	// (0 = never).
+	QueueTTL time.Duration
 }
 
 type worker struct {
 	evalRepo         repositories.EvaluationRepository
 	evaluatorService EvaluatorService
 	jobQueue         chan uuid.UUID
-	concurrency      int
+	wake             chan struct{}
+	opts             WorkerOptions
 	wg               sync.WaitGroup
 	stopChan         chan struct{}
+	jobCtx           context.Context
+	cancelJobs       context.CancelFunc
+
+	mu sync.Mutex
+	// resumed is closed while the pool runs and replaced by an open channel
+	// on Pause.
+	resumed     chan struct{}
+	paused      bool
+	concurrency int
+	autoscale   bool
+	slots       []*workerSlot
+	nextID      int
+}
+
+// workerSlot is one job-processing goroutine. Closing quit retires it after
+// its current job.
+type workerSlot struct {
+	status models.WorkerStatus
+	quit   chan struct{}
 }
 
 func NewWorker(
 	evalRepo repositories.EvaluationRepository,
 	evaluatorService EvaluatorService,
-	concurrency int,
+	opts WorkerOptions,
 ) Worker {
+	resumed := make(chan struct{})
+	close(resumed)
+
 	return &worker{
 		evalRepo:         evalRepo,
 		evaluatorService: evaluatorService,
-		jobQueue:         make(chan uuid.UUID, 100),
-		concurrency:      concurrency,
+		jobQueue:         make(chan uuid.UUID, opts.QueueSize),
+		wake:             make(chan struct{}, 1),
+		opts:             opts,
 		stopChan:         make(chan struct{}),
+		resumed:          resumed,
+		concurrency:      opts.Concurrency,
+		autoscale:        opts.MaxConcurrency > opts.Concurrency,
 	}
 }
 
@@ -44,58 +115,455 @@ func NewWorker(
 func (w *worker) Start(ctx context.Context) {
 	log.Printf("🚀 Starting worker with %d concurrent workers\n", w.concurrency)
 
+	// Jobs run under their own context so Stop can cancel them once the
+	// grace period is over
+	w.jobCtx, w.cancelJobs = context.WithCancel(ctx)
+
 	// Start worker goroutines
-	for i := 0; i < w.concurrency; i++ {
-		w.wg.Add(1)
-		go w.processJobs(ctx, i+1)
-	}
+	w.mu.Lock()
+	w.scaleLocked()
+	w.mu.Unlock()
+
+	// Dispatch committed jobs from the outbox
+	w.wg.Add(1)
+	go w.relayOutbox()
 
 	// Start polling for pending jobs
 	w.wg.Add(1)
-	go w.pollPendingJobs(ctx)
+	go w.pollPendingJobs(w.jobCtx)
+
+	if w.opts.MaxConcurrency > w.opts.Concurrency {
+		w.wg.Add(1)
+		go w.autoscaleLoop()
+	}
 
 	log.Println("✅ Worker started successfully")
 }
 
+// SetConcurrency implements Worker.
+func (w *worker) SetConcurrency(n int) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.autoscale {
+		log.Println("📏 Autoscaling disabled by a manual concurrency change")
+	}
+	w.autoscale = false
+	w.concurrency = n
+	if w.jobCtx != nil {
+		w.scaleLocked()
+	}
+}
+
+// EnableAutoscale implements Worker.
+func (w *worker) EnableAutoscale() error {
+	if w.opts.MaxConcurrency <= w.opts.Concurrency {
+		return fmt.Errorf("autoscaling needs WORKER_MAX_CONCURRENCY above WORKER_CONCURRENCY")
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.autoscale {
+		return nil
+	}
+	w.autoscale = true
+	w.concurrency = max(w.opts.Concurrency, min(w.concurrency, w.opts.MaxConcurrency))
+	if w.jobCtx != nil {
+		w.scaleLocked()
+	}
+
+	return nil
+}
+
+// scaleLocked starts or retires goroutines until the running ones match
+// w.concurrency. Retired goroutines finish their current job first.
+func (w *worker) scaleLocked() {
+	running := 0
+	for _, slot := range w.slots {
+		if slot.status.State != models.WorkerStopping {
+			running++
+		}
+	}
+
+	for ; running < w.concurrency; running++ {
+		w.nextID++
+		slot := &workerSlot{
+			status: models.WorkerStatus{ID: w.nextID, State: models.WorkerIdle},
+			quit:   make(chan struct{}),
+		}
+		w.slots = append(w.slots, slot)
+
+		w.wg.Add(1)
+		go w.processJobs(w.jobCtx, slot)
+	}
+
+	// Retire idle goroutines before busy ones, newest first
+	for _, state := range []models.WorkerState{models.WorkerIdle, models.WorkerActive} {
+		for i := len(w.slots) - 1; i >= 0 && running > w.concurrency; i-- {
+			slot := w.slots[i]
+			if slot.status.State == state {
+				slot.status.State = models.WorkerStopping
+				close(slot.quit)
+				running--
+			}
+		}
+	}
+}
+
+// autoscaleLoop adds a goroutine while jobs wait in the queue and removes
+// one while the queue is empty and some are idle. It runs whenever
+// autoscaling is configured and does nothing while it is switched off.
+func (w *worker) autoscaleLoop() {
+	defer w.wg.Done()
+	ticker := time.NewTicker(w.opts.AutoscaleInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-w.stopChan:
+			return
+		case <-ticker.C:
+		}
+
+		w.mu.Lock()
+		// Jobs pile up while paused; that's not load
+		if !w.autoscale || w.paused {
+			w.mu.Unlock()
+			continue
+		}
+
+		idle := 0
+		for _, slot := range w.slots {
+			if slot.status.State == models.WorkerIdle {
+				idle++
+			}
+		}
+
+		target := w.concurrency
+		switch buffered := len(w.jobQueue); {
+		case buffered > 0 && target < w.opts.MaxConcurrency:
+			target++
+		case buffered == 0 && idle > 0 && target > w.opts.Concurrency:
+			target--
+		}
+		if target != w.concurrency {
+			log.Printf("📏 Scaling workers from %d to %d\n", w.concurrency, target)
+			w.concurrency = target
+			w.scaleLocked()
+		}
+		w.mu.Unlock()
+	}
+}
+
 // Stop implements Worker.
 func (w *worker) Stop() {
-	log.Println("🛑 Stopping worker...")
+	log.Printf("🛑 Draining worker (grace period %s)...\n", w.opts.ShutdownGrace)
 	close(w.stopChan)
-	w.wg.Wait()
+
+	if waitTimeout(&w.wg, w.opts.ShutdownGrace) {
+		log.Println("✅ Worker stopped")
+		return
+	}
+
+	inFlight := w.inFlight()
+	log.Printf("⏱️  Grace period over, cancelling %d running jobs\n", len(inFlight))
+	if w.cancelJobs != nil {
+		w.cancelJobs()
+	}
+	if !waitTimeout(&w.wg, cancelWait) {
+		log.Println("⚠️  Some jobs did not return after cancellation")
+	}
+
+	// Cancelled jobs are left processing; put them back so the next
+	// instance runs them
+	for _, id := range inFlight {
+		if err := w.evalRepo.Requeue(id); err != nil {
+			log.Printf("⚠️  Failed to requeue job %s: %v\n", id, err)
+			continue
+		}
+		log.Printf("↩️  Job %s requeued\n", id)
+	}
 	log.Println("✅ Worker stopped")
 }
 
+func (w *worker) inFlight() []uuid.UUID {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	var ids []uuid.UUID
+	for _, slot := range w.slots {
+		if slot.status.EvaluationID != nil {
+			ids = append(ids, *slot.status.EvaluationID)
+		}
+	}
+	return ids
+}
+
+// waitTimeout waits for wg and reports whether it finished within timeout.
+func waitTimeout(wg *sync.WaitGroup, timeout time.Duration) bool {
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case <-done:
+		return true
+	case <-timer.C:
+		return false
+	}
+}
+
 // EnqueueJob implements Worker.
-func (w *worker) EnqueueJob(evalID uuid.UUID) {
+func (w *worker) EnqueueJob(evalID uuid.UUID) bool {
+	select {
+	case <-w.stopChan:
+		log.Printf("⚠️  Worker stopped, cannot enqueue job %s\n", evalID)
+		return false
+	default:
+	}
+
 	select {
 	case w.jobQueue <- evalID:
 		log.Printf("📥 Job %s enqueued\n", evalID)
+		return true
+	default:
+		log.Printf("⏳ Job queue full, job %s left for the poller\n", evalID)
+		return false
+	}
+}
+
+// QueueDepth implements Worker.
+func (w *worker) QueueDepth() (int, int) {
+	return len(w.jobQueue), cap(w.jobQueue)
+}
+
+// Notify implements Worker.
+func (w *worker) Notify() {
+	select {
+	case w.wake <- struct{}{}:
+	default:
+	}
+}
+
+// relayOutbox moves outbox entries onto the job queue. An entry is removed
+// when claimed; if the process dies before the job runs, the pending jobs
+// poller picks it up once it is stale.
+func (w *worker) relayOutbox() {
+	defer w.wg.Done()
+	ticker := time.NewTicker(outboxPollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-w.stopChan:
+			log.Println("📤 Outbox relay stopped")
+			return
+		case <-ticker.C:
+		case <-w.wake:
+		}
+
+		// Only claim what fits; the rest stays in the outbox until workers
+		// catch up
+		free := cap(w.jobQueue) - len(w.jobQueue)
+		if free <= 0 {
+			continue
+		}
+		limit := min(free, outboxBatchSize)
+
+		ids, err := w.evalRepo.ClaimOutbox(limit)
+		if err != nil {
+			log.Printf("⚠️  Failed to read outbox: %v\n", err)
+			continue
+		}
+		for _, id := range ids {
+			w.EnqueueJob(id)
+		}
+		if len(ids) == limit {
+			w.Notify()
+		}
+	}
+}
+
+// Pause implements Worker.
+func (w *worker) Pause() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.paused {
+		return
+	}
+	w.paused = true
+	w.resumed = make(chan struct{})
+	log.Println("⏸️  Worker paused")
+}
+
+// Resume implements Worker.
+func (w *worker) Resume() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if !w.paused {
+		return
+	}
+	w.paused = false
+	close(w.resumed)
+	log.Println("▶️  Worker resumed")
+}
+
+// Status implements Worker.
+func (w *worker) Status() models.WorkerPoolStatus {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	buffered, capacity := w.QueueDepth()
+	status := models.WorkerPoolStatus{
+		Paused:         w.paused,
+		Concurrency:    w.concurrency,
+		Autoscale:      w.autoscale,
+		MinConcurrency: w.opts.Concurrency,
+		MaxConcurrency: max(w.opts.MaxConcurrency, w.opts.Concurrency),
+		QueueBuffered:  buffered,
+		QueueCapacity:  capacity,
+		Workers:        make([]models.WorkerStatus, 0, len(w.slots)),
+	}
+	for _, slot := range w.slots {
+		status.Workers = append(status.Workers, slot.status)
+	}
+
+	return status
+}
+
+// waitUntilResumed blocks while the pool is paused. It returns false when
+// the worker is stopped or the slot retired.
+func (w *worker) waitUntilResumed(slot *workerSlot) bool {
+	select {
+	case <-slot.quit:
+		return false
+	default:
+	}
+
+	w.mu.Lock()
+	resumed := w.resumed
+	w.mu.Unlock()
+
+	select {
+	case <-resumed:
+		return true
+	case <-slot.quit:
+		return false
 	case <-w.stopChan:
-		log.Printf("⚠️  Worker stopped, cannot enqueue job %s\n", evalID)
+		return false
 	}
 }
 
-func (w *worker) processJobs(ctx context.Context, workerID int) {
+func (w *worker) isPaused() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.paused
+}
+
+// requeue puts a job taken while paused back on the queue. If the queue has
+// filled up since, the pending jobs poller dispatches it again once stale.
+func (w *worker) requeue(evalID uuid.UUID) {
+	select {
+	case w.jobQueue <- evalID:
+	default:
+		log.Printf("⏳ Job queue full, job %s left for the poller\n", evalID)
+	}
+}
+
+func (w *worker) setSlot(slot *workerSlot, evalID *uuid.UUID) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	state := models.WorkerIdle
+	var startedAt *time.Time
+	if evalID != nil {
+		now := time.Now()
+		state, startedAt = models.WorkerActive, &now
+	}
+	if slot.status.State == models.WorkerStopping {
+		state = models.WorkerStopping
+	}
+
+	slot.status = models.WorkerStatus{ID: slot.status.ID, State: state, EvaluationID: evalID, StartedAt: startedAt}
+}
+
+func (w *worker) removeSlot(slot *workerSlot) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	for i, s := range w.slots {
+		if s == slot {
+			w.slots = append(w.slots[:i], w.slots[i+1:]...)
+			return
+		}
+	}
+}
+
+func (w *worker) processJobs(ctx context.Context, slot *workerSlot) {
+	workerID := slot.status.ID
 	defer w.wg.Done()
+	defer w.removeSlot(slot)
 	log.Printf("🚀 Worker %d started processing jobs\n", workerID)
 
 	for {
+		if !w.waitUntilResumed(slot) {
+			log.Printf("👷 Worker #%d stopped\n", workerID)
+			return
+		}
+
 		select {
 		case <-w.stopChan:
 			log.Printf("👷 Worker #%d stopped\n", workerID)
 			return
+		case <-slot.quit:
+			log.Printf("👷 Worker #%d retired\n", workerID)
+			return
 		case evalID := <-w.jobQueue:
+			// A slot already waiting on the queue when Pause was called
+			// must not start the job it receives
+			if w.isPaused() {
+				w.requeue(evalID)
+				continue
+			}
+
 			log.Printf("👷 Worker #%d processing job %s\n", workerID, evalID)
+			w.setSlot(slot, &evalID)
 			// Process the evaluation
 			if err := w.evaluatorService.EvaluateCandidate(ctx, evalID); err != nil {
 				log.Printf("❌ Worker #%d failed to process job %s: %v\n", workerID, evalID, err)
 			} else {
 				log.Printf("✅ Worker #%d completed job %s\n", workerID, evalID)
 			}
+			w.setSlot(slot, nil)
 		}
 	}
 }
 
+// expireQueuedJobs gives up on jobs queued longer than QueueTTL past when
+// they were due, e.g. ones parked for days behind an open circuit breaker.
+func (w *worker) expireQueuedJobs() {
+	if w.opts.QueueTTL <= 0 {
+		return
+	}
+
+	message := fmt.Sprintf("expired after waiting in the queue for more than %s", w.opts.QueueTTL)
+	expired, err := w.evalRepo.ExpireQueued(time.Now().Add(-w.opts.QueueTTL), message, expireBatchSize)
+	if len(expired) > 0 {
+		log.Printf("⌛ Expired %d queued jobs older than %s\n", len(expired), w.opts.QueueTTL)
+	}
+	if err != nil {
+		log.Printf("⚠️  Failed to expire queued jobs: %v\n", err)
+	}
+}
+
 func (w *worker) pollPendingJobs(ctx context.Context) {
 	defer w.wg.Done()
 	ticker := time.NewTicker(10 * time.Second)
@@ -109,8 +577,10 @@ func (w *worker) pollPendingJobs(ctx context.Context) {
 			log.Println("🔄 Pending jobs poller stopped")
 			return
 		case <-ticker.C:
-			// Find pending jobs
-			pendingJobs, err := w.evalRepo.FindPendingJobs(10)
+			w.expireQueuedJobs()
+
+			// Find queued jobs whose dispatch was lost
+			pendingJobs, err := w.evalRepo.FindPendingJobs(10, time.Now().Add(-staleJobAge))
 			if err != nil {
 				log.Printf("⚠️  Failed to fetch pending jobs: %v\n", err)
 				continue
@@ -120,9 +590,11 @@ func (w *worker) pollPendingJobs(ctx context.Context) {
 				log.Printf("📋 Found %d pending jobs\n", len(pendingJobs))
 			}
 
-			// Enqueue pending jobs
+			// Enqueue pending jobs until the queue is full
 			for _, job := range pendingJobs {
-				w.EnqueueJob(job.ID)
+				if !w.EnqueueJob(job.ID) {
+					break
+				}
 			}
 		}
 	}
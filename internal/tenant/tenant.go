@@ -0,0 +1,45 @@
+package tenant
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// APIKeyHeader carries the caller's API key on HTTP requests and as gRPC
+// metadata (lower-cased).
+const APIKeyHeader = "X-API-Key"
+
+// Anonymous is the tenant used for callers without an API key.
+const Anonymous = "anonymous"
+
+type contextKey struct{}
+
+// FromAPIKey derives a stable tenant ID from an API key so the raw key is
+// never stored.
+func FromAPIKey(apiKey string) string {
+	if apiKey == "" {
+		return Anonymous
+	}
+
+	sum := sha256.Sum256([]byte(apiKey))
+	return hex.EncodeToString(sum[:8])
+}
+
+func WithID(ctx context.Context, tenantID string) context.Context {
+	return context.WithValue(ctx, contextKey{}, tenantID)
+}
+
+// FromContext returns the tenant attached to ctx, or Anonymous.
+func FromContext(ctx context.Context) string {
+	if id, ok := ctx.Value(contextKey{}).(string); ok && id != "" {
+		return id
+	}
+	return Anonymous
+}
+
+// Lookup returns the tenant explicitly attached to ctx, if any.
+func Lookup(ctx context.Context) (string, bool) {
+	id, ok := ctx.Value(contextKey{}).(string)
+	return id, ok && id != ""
+}
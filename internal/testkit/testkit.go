@@ -0,0 +1,294 @@
+// Package testkit runs the API in-process for end-to-end tests that need no
+// network or API key: a SQLite database and upload directory in a
+// temporary directory, the in-memory vector store and the mock LLM, with
+// reference documents from fixtures.
+//
+//	h := testkit.New(t)
+//	cvID, projectID := h.Upload(testkit.FixtureCV, testkit.FixtureProjectReport)
+//	job := h.Evaluate(models.EvaluateRequest{JobTitle: "Backend Engineer", CVDocumentID: cvID, ProjectDocumentID: projectID})
+//	result := h.WaitForResult(job.ID)
+package testkit
+
+import (
+	"bytes"
+	"context"
+	"embed"
+	"encoding/json"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+
+	"alfredoptarigan/cv-evaluator/internal/config"
+	"alfredoptarigan/cv-evaluator/internal/models"
+	"alfredoptarigan/cv-evaluator/internal/repositories"
+	"alfredoptarigan/cv-evaluator/internal/server"
+	"alfredoptarigan/cv-evaluator/internal/services"
+)
+
+// Fixture PDFs. The candidate and the company are made up.
+const (
+	FixtureCV             = "cv.pdf"
+	FixtureProjectReport  = "project_report.pdf"
+	FixtureJobDescription = "job_description.pdf"
+	FixtureCaseStudyBrief = "case_study_brief.pdf"
+	FixtureCVRubric       = "cv_rubric.pdf"
+	FixtureProjectRubric  = "project_rubric.pdf"
+)
+
+// AdminToken authenticates the admin endpoints (X-Admin-Token).
+const AdminToken = "testkit-admin-token"
+
+const (
+	resultTimeout      = 30 * time.Second
+	resultPollInterval = 50 * time.Millisecond
+	ingestTimeout      = time.Minute
+)
+
+//go:embed fixtures/*.pdf
+var fixtures embed.FS
+
+// References are the reference fixtures New ingests, by document type.
+var References = map[string]string{
+	FixtureJobDescription: "job_description",
+	FixtureCaseStudyBrief: "case_study",
+	FixtureCVRubric:       "cv_rubric",
+	FixtureProjectRubric:  "project_rubric",
+}
+
+// Harness is a running API. Its fields give tests direct access to what
+// the HTTP API doesn't show.
+type Harness struct {
+	Config *config.Config
+	DB     *gorm.DB
+	Server *server.Server
+
+	t   testing.TB
+	dir string
+}
+
+// New starts the API and ingests the reference fixtures. configure, if
+// given, adjusts the configuration first, e.g. to set quotas. Everything
+// is stopped and removed when the test ends.
+func New(t testing.TB, configure ...func(*config.Config)) *Harness {
+	t.Helper()
+	dir := t.TempDir()
+
+	cfg := config.Load()
+	cfg.Server.Env = "test"
+	cfg.GRPC.Enabled = false
+	cfg.LLMProvider = services.LLMProviderMock
+	cfg.VectorStore = "memory"
+	cfg.Database = config.DatabaseConfig{Driver: config.DriverSQLite, Path: filepath.Join(dir, "cv_evaluator.db")}
+	cfg.Storage.UploadPath = filepath.Join(dir, "uploads")
+	cfg.Storage.ReconcileInterval = 0
+	cfg.Retention.Period = 0
+	cfg.Retention.TenantOverrides = nil
+	cfg.Gemini.CanaryPercent = 0
+	cfg.Gemini.ShadowPercent = 0
+	cfg.Admin.Token = AdminToken
+	cfg.Startup.ConnectRetries = 0
+	for _, fn := range configure {
+		fn(cfg)
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("testkit: %v", err)
+	}
+
+	db, err := config.InitDatabase(cfg)
+	if err != nil {
+		t.Fatalf("testkit: %v", err)
+	}
+	srv, err := server.New(cfg, db)
+	if err != nil {
+		t.Fatalf("testkit: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	srv.Start(ctx)
+	t.Cleanup(func() {
+		srv.Stop()
+		cancel()
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+
+	h := &Harness{Config: cfg, DB: db, Server: srv, t: t, dir: dir}
+	h.ingestReferences()
+	return h
+}
+
+// FixturePath returns the path of a fixture PDF on disk.
+func (h *Harness) FixturePath(name string) string {
+	h.t.Helper()
+
+	path := filepath.Join(h.dir, "fixtures", name)
+	if _, err := os.Stat(path); err == nil {
+		return path
+	}
+
+	data, err := fixtures.ReadFile("fixtures/" + name)
+	if err != nil {
+		h.t.Fatalf("testkit: unknown fixture %s", name)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		h.t.Fatalf("testkit: %v", err)
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		h.t.Fatalf("testkit: %v", err)
+	}
+	return path
+}
+
+func (h *Harness) ingestReferences() {
+	h.t.Helper()
+
+	ingester := services.NewReferenceIngester(
+		services.NewPDFParserService(services.ParseLimits{}),
+		services.NewTextChunker(),
+		h.Server.LLM,
+		h.Server.VectorStore,
+		repositories.NewIngestedReferenceRepository(h.DB),
+	)
+
+	ctx, cancel := context.WithTimeout(context.Background(), ingestTimeout)
+	defer cancel()
+	for name, docType := range References {
+		doc := services.ReferenceDocument{Path: h.FixturePath(name), DocType: docType}
+		if _, err := ingester.Ingest(ctx, doc, false); err != nil {
+			h.t.Fatalf("testkit: failed to ingest %s: %v", name, err)
+		}
+	}
+}
+
+// Do sends a request to the API and returns the response.
+func (h *Harness) Do(req *http.Request) *http.Response {
+	h.t.Helper()
+
+	resp, err := h.Server.App.Test(req, -1)
+	if err != nil {
+		h.t.Fatalf("testkit: %s %s: %v", req.Method, req.URL.Path, err)
+	}
+	return resp
+}
+
+// Request sends body, if not nil, as JSON and returns the response.
+func (h *Harness) Request(method, path string, body interface{}) *http.Response {
+	h.t.Helper()
+
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			h.t.Fatalf("testkit: %v", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequest(method, path, reader)
+	if err != nil {
+		h.t.Fatalf("testkit: %v", err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	return h.Do(req)
+}
+
+// Decode reads a JSON response into target, failing the test unless the
+// response has the wanted status.
+func (h *Harness) Decode(resp *http.Response, wantStatus int, target interface{}) {
+	h.t.Helper()
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		h.t.Fatalf("testkit: %v", err)
+	}
+	if resp.StatusCode != wantStatus {
+		h.t.Fatalf("testkit: got status %d, want %d: %s", resp.StatusCode, wantStatus, data)
+	}
+	if target != nil {
+		if err := json.Unmarshal(data, target); err != nil {
+			h.t.Fatalf("testkit: failed to decode %s: %v", data, err)
+		}
+	}
+}
+
+// Upload uploads a CV and a project report, by fixture name, and returns
+// their document IDs.
+func (h *Harness) Upload(cv, projectReport string) (cvID, projectID string) {
+	h.t.Helper()
+
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	for field, name := range map[string]string{"cv": cv, "project_report": projectReport} {
+		data, err := os.ReadFile(h.FixturePath(name))
+		if err != nil {
+			h.t.Fatalf("testkit: %v", err)
+		}
+		part, err := form.CreateFormFile(field, name)
+		if err != nil {
+			h.t.Fatalf("testkit: %v", err)
+		}
+		part.Write(data)
+	}
+	form.Close()
+
+	req, err := http.NewRequest(http.MethodPost, "/api/v1/upload", &body)
+	if err != nil {
+		h.t.Fatalf("testkit: %v", err)
+	}
+	req.Header.Set("Content-Type", form.FormDataContentType())
+
+	var uploaded struct {
+		Documents []models.UploadResponse `json:"documents"`
+	}
+	h.Decode(h.Do(req), http.StatusCreated, &uploaded)
+
+	for _, doc := range uploaded.Documents {
+		switch doc.FileType {
+		case "cv":
+			cvID = doc.ID
+		case "project_report":
+			projectID = doc.ID
+		}
+	}
+	return cvID, projectID
+}
+
+// Evaluate submits an evaluation.
+func (h *Harness) Evaluate(req models.EvaluateRequest) models.EvaluateResponse {
+	h.t.Helper()
+
+	var resp models.EvaluateResponse
+	h.Decode(h.Request(http.MethodPost, "/api/v1/evaluate", req), http.StatusAccepted, &resp)
+	return resp
+}
+
+// WaitForResult polls the evaluation until it is no longer queued or
+// processing.
+func (h *Harness) WaitForResult(id string) models.ResultResponse {
+	h.t.Helper()
+
+	deadline := time.Now().Add(resultTimeout)
+	for {
+		var result models.ResultResponse
+		h.Decode(h.Request(http.MethodGet, "/api/v1/result/"+id, nil), http.StatusOK, &result)
+
+		status := models.EvaluationStatus(result.Status)
+		if status != models.StatusQueued && status != models.StatusProcessing {
+			return result
+		}
+		if time.Now().After(deadline) {
+			h.t.Fatalf("testkit: evaluation %s still %s after %s", id, status, resultTimeout)
+		}
+		time.Sleep(resultPollInterval)
+	}
+}
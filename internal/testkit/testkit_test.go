@@ -0,0 +1,42 @@
+package testkit
+
+import (
+	"testing"
+
+	"alfredoptarigan/cv-evaluator/internal/models"
+)
+
+func TestEvaluateEndToEnd(t *testing.T) {
+	h := New(t)
+
+	cvID, projectID := h.Upload(FixtureCV, FixtureProjectReport)
+	if cvID == "" || projectID == "" {
+		t.Fatalf("upload returned cv %q and project report %q", cvID, projectID)
+	}
+
+	job := h.Evaluate(models.EvaluateRequest{
+		JobTitle:          "Backend Engineer",
+		CVDocumentID:      cvID,
+		ProjectDocumentID: projectID,
+	})
+	if job.ID == "" {
+		t.Fatal("evaluate returned no job ID")
+	}
+
+	result := h.WaitForResult(job.ID)
+	if result.Status != string(models.StatusCompleted) {
+		t.Fatalf("got status %s, want %s: %+v", result.Status, models.StatusCompleted, result)
+	}
+	if result.Result == nil {
+		t.Fatal("completed evaluation has no result")
+	}
+	if result.Result.CVMatchRate <= 0 {
+		t.Errorf("got cv_match_rate %v, want > 0", result.Result.CVMatchRate)
+	}
+	if result.Result.ProjectScore <= 0 {
+		t.Errorf("got project_score %v, want > 0", result.Result.ProjectScore)
+	}
+	if result.Result.OverallSummary == "" {
+		t.Error("got empty overall_summary")
+	}
+}
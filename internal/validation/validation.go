@@ -0,0 +1,80 @@
+package validation
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+
+	"alfredoptarigan/cv-evaluator/internal/apperror"
+)
+
+var validate = newValidator()
+
+func newValidator() *validator.Validate {
+	v := validator.New(validator.WithRequiredStructEnabled())
+
+	// Report fields by their JSON names so errors match the request payload
+	v.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name := strings.SplitN(field.Tag.Get("json"), ",", 2)[0]
+		if name == "-" {
+			return ""
+		}
+		if name == "" {
+			return field.Name
+		}
+		return name
+	})
+
+	return v
+}
+
+// Struct runs the `validate` tags of v and returns a VALIDATION_FAILED
+// apperror listing every invalid field.
+func Struct(v interface{}) error {
+	err := validate.Struct(v)
+	if err == nil {
+		return nil
+	}
+
+	var validationErrors validator.ValidationErrors
+	if !errors.As(err, &validationErrors) {
+		return apperror.Wrap(err, http.StatusBadRequest, apperror.CodeValidationFailed, "Invalid request payload")
+	}
+
+	fields := make([]apperror.FieldError, 0, len(validationErrors))
+	messages := make([]string, 0, len(validationErrors))
+	for _, fe := range validationErrors {
+		message := fieldMessage(fe)
+		fields = append(fields, apperror.FieldError{
+			Field:   fe.Field(),
+			Rule:    fe.Tag(),
+			Message: message,
+		})
+		messages = append(messages, message)
+	}
+
+	appErr := apperror.New(http.StatusBadRequest, apperror.CodeValidationFailed, strings.Join(messages, "; "))
+	appErr.Fields = fields
+	return appErr
+}
+
+func fieldMessage(fe validator.FieldError) string {
+	switch fe.Tag() {
+	case "required", "required_without":
+		return fmt.Sprintf("%s is required", fe.Field())
+	case "uuid", "uuid4":
+		return fmt.Sprintf("Invalid %s format", fe.Field())
+	case "min":
+		return fmt.Sprintf("%s must be at least %s", fe.Field(), fe.Param())
+	case "max":
+		return fmt.Sprintf("%s must be at most %s", fe.Field(), fe.Param())
+	case "oneof":
+		return fmt.Sprintf("%s must be one of: %s", fe.Field(), fe.Param())
+	default:
+		return fmt.Sprintf("%s is invalid", fe.Field())
+	}
+}
@@ -0,0 +1,269 @@
+// Admin UI: a thin client of the HTTP API. Pages are picked by the URL hash;
+// the API key and admin token are kept in localStorage.
+'use strict';
+
+const API = '/api/v2';
+const ADMIN_API = '/api/v1/admin';
+const PENDING = ['queued', 'processing'];
+const PAGE_SIZE = 20;
+
+const settings = {
+  get apiKey() { return localStorage.getItem('apiKey') || ''; },
+  get adminToken() { return localStorage.getItem('adminToken') || ''; },
+};
+
+let resultsCursor = null;
+let pollTimer = null;
+// flash is shown on the next page, e.g. after submitting an evaluation.
+let flash = '';
+
+// el builds an element; text children are escaped.
+function el(tag, attrs, ...children) {
+  const node = document.createElement(tag);
+  Object.entries(attrs || {}).forEach(([key, value]) => {
+    if (key.startsWith('on')) node.addEventListener(key.slice(2), value);
+    else node.setAttribute(key, value);
+  });
+  children.flat().forEach((child) => {
+    if (child !== null && child !== undefined) node.append(child instanceof Node ? child : String(child));
+  });
+  return node;
+}
+
+function showMessage(text, isError) {
+  const message = document.getElementById('message');
+  message.textContent = text;
+  message.className = isError ? 'error' : '';
+  message.hidden = !text;
+}
+
+// api calls the API and returns the decoded JSON, throwing the message of
+// the error envelope on failure.
+async function api(method, path, body, admin) {
+  const headers = {};
+  if (settings.apiKey) headers['X-API-Key'] = settings.apiKey;
+  if (admin) headers['X-Admin-Token'] = settings.adminToken;
+  if (body && !(body instanceof FormData)) {
+    headers['Content-Type'] = 'application/json';
+    body = JSON.stringify(body);
+  }
+
+  const response = await fetch(path, { method, headers, body });
+  if (response.status === 204) return null;
+  const data = await response.json().catch(() => ({}));
+  if (!response.ok) {
+    throw new Error(data.error || `${method} ${path} failed with ${response.status}`);
+  }
+  return data;
+}
+
+async function graphql(query, variables) {
+  const data = await api('POST', '/graphql', { query, variables });
+  if (data.errors && data.errors.length) throw new Error(data.errors[0].message);
+  return data.data;
+}
+
+function formatTime(value) {
+  return value ? new Date(value).toLocaleString() : '';
+}
+
+function formatScore(value, digits) {
+  return value === null || value === undefined ? '' : Number(value).toFixed(digits);
+}
+
+// Evaluate
+
+async function submitEvaluation(event) {
+  event.preventDefault();
+  const form = event.target;
+  const upload = new FormData();
+  upload.append('cv', form.cv.files[0]);
+  upload.append('project_report', form.project_report.files[0]);
+
+  try {
+    showMessage('Uploading…');
+    const uploaded = await api('POST', `${API}/upload`, upload);
+    const evaluation = await api('POST', `${API}/evaluate`, {
+      job_title: form.job_title.value,
+      pair_id: uploaded.pair_id,
+    });
+    form.reset();
+    flash = evaluation.duplicate ? 'These files were already evaluated for this job.' : 'Evaluation queued.';
+    location.hash = `#result/${evaluation.id}`;
+  } catch (err) {
+    showMessage(err.message, true);
+  }
+}
+
+// Results
+
+const EVALUATIONS_QUERY = `query($filter: EvaluationFilter, $first: Int, $after: String) {
+  evaluations(filter: $filter, first: $first, after: $after) {
+    nodes { id jobTitle status createdAt result { cvMatchRate projectScore } }
+    nextCursor
+  }
+}`;
+
+async function loadResults(append) {
+  const form = document.getElementById('results-form');
+  const rows = document.getElementById('results-rows');
+  const filter = {};
+  if (form.status.value) filter.statuses = [form.status.value];
+  if (form.job_title.value) filter.jobTitle = form.job_title.value;
+
+  try {
+    const data = await graphql(EVALUATIONS_QUERY, {
+      filter,
+      first: PAGE_SIZE,
+      after: append ? resultsCursor : null,
+    });
+    if (!append) rows.replaceChildren();
+    data.evaluations.nodes.forEach((evaluation) => {
+      rows.append(el('tr', { class: 'link', onclick: () => { location.hash = `#result/${evaluation.id}`; } },
+        el('td', {}, formatTime(evaluation.createdAt)),
+        el('td', {}, evaluation.jobTitle),
+        el('td', {}, evaluation.status),
+        el('td', {}, evaluation.result ? formatScore(evaluation.result.cvMatchRate, 2) : ''),
+        el('td', {}, evaluation.result ? formatScore(evaluation.result.projectScore, 1) : '')));
+    });
+    resultsCursor = data.evaluations.nextCursor;
+    document.getElementById('results-more').hidden = !resultsCursor;
+  } catch (err) {
+    showMessage(err.message, true);
+  }
+}
+
+function renderBreakdown(title, breakdown, digits) {
+  if (!breakdown) return null;
+  const subScores = Object.entries(breakdown.sub_scores || {});
+  const citations = breakdown.citations || [];
+  return el('div', {},
+    el('h3', {}, `${title}: ${formatScore(breakdown.score, digits)}`),
+    el('p', {}, breakdown.feedback),
+    subScores.length ? el('table', {}, el('tbody', {}, subScores.map(([name, score]) =>
+      el('tr', {}, el('td', {}, name.replace(/_/g, ' ')), el('td', {}, score))))) : null,
+    citations.length ? el('details', {},
+      el('summary', {}, `${citations.length} reference passages`),
+      el('ul', {}, citations.map((c) =>
+        el('li', {}, `${c.source || c.doc_type}${c.section ? `, ${c.section}` : ''}${c.page ? `, page ${c.page}` : ''}`)))) : null);
+}
+
+async function loadResult(id) {
+  clearTimeout(pollTimer);
+  const body = document.getElementById('result-body');
+  try {
+    const result = await api('GET', `${API}/result/${encodeURIComponent(id)}`);
+    const data = result.result || {};
+    body.replaceChildren(
+      el('h2', {}, `Evaluation ${result.id}`),
+      el('p', {}, `Status: ${result.status}`, result.queue_position ? ` (position ${result.queue_position} in queue)` : ''),
+      result.error_message ? el('p', { class: 'error' }, result.error_message) : null,
+      data.overall_summary ? el('div', {}, el('h3', {}, 'Summary'), el('p', {}, data.overall_summary)) : null,
+      data.rag_context_missing ? el('p', { class: 'hint' }, 'Scored without all of its reference documents.') : null,
+      renderBreakdown('CV match rate', data.cv, 2),
+      renderBreakdown('Project score', data.project, 1),
+      (result.stages || []).length ? el('details', {},
+        el('summary', {}, 'Stages'),
+        el('ul', {}, result.stages.map((s) => el('li', {}, `${s.stage} — ${formatTime(s.completed_at)}`)))) : null);
+
+    if (PENDING.includes(result.status) && location.hash === `#result/${id}`) {
+      pollTimer = setTimeout(() => loadResult(id), 3000);
+    }
+  } catch (err) {
+    body.replaceChildren();
+    showMessage(err.message, true);
+  }
+}
+
+// Reference documents
+
+async function loadReferences() {
+  const rows = document.getElementById('reference-rows');
+  try {
+    const data = await api('GET', `${ADMIN_API}/references`, null, true);
+    rows.replaceChildren(...data.references.map((ref) =>
+      el('tr', {},
+        el('td', {}, ref.name),
+        el('td', {}, ref.doc_type),
+        el('td', {}, ref.tenant_id || 'shared'),
+        el('td', {}, ref.chunks),
+        el('td', {}, formatTime(ref.ingested_at)),
+        el('td', {}, el('button', { onclick: () => deleteReference(ref) }, 'Delete')))));
+  } catch (err) {
+    rows.replaceChildren();
+    showMessage(err.message, true);
+  }
+}
+
+async function submitReference(event) {
+  event.preventDefault();
+  const form = event.target;
+  try {
+    showMessage('Ingesting…');
+    const result = await api('POST', `${ADMIN_API}/references`, new FormData(form), true);
+    form.reset();
+    showMessage(result.unchanged ? 'Unchanged since it was last ingested.' : `Ingested ${result.chunks} chunks.`);
+    loadReferences();
+  } catch (err) {
+    showMessage(err.message, true);
+  }
+}
+
+async function deleteReference(ref) {
+  if (!confirm(`Delete ${ref.name}? Evaluations will no longer be grounded in it.`)) return;
+  const query = ref.tenant_id ? `?tenant_id=${encodeURIComponent(ref.tenant_id)}` : '';
+  try {
+    await api('DELETE', `${ADMIN_API}/references/${encodeURIComponent(ref.document_id)}${query}`, null, true);
+    showMessage(`Deleted ${ref.name}.`);
+    loadReferences();
+  } catch (err) {
+    showMessage(err.message, true);
+  }
+}
+
+// Settings
+
+function saveSettings(event) {
+  event.preventDefault();
+  const form = event.target;
+  localStorage.setItem('apiKey', form.api_key.value);
+  localStorage.setItem('adminToken', form.admin_token.value);
+  showMessage('Saved.');
+}
+
+// Routing
+
+function route() {
+  const [page, id] = (location.hash.slice(1) || 'evaluate').split('/');
+  clearTimeout(pollTimer);
+  showMessage(flash);
+  flash = '';
+  document.querySelectorAll('main > section').forEach((section) => {
+    section.hidden = section.id !== page;
+  });
+  document.querySelectorAll('nav a').forEach((link) => {
+    link.classList.toggle('active', link.getAttribute('href') === `#${page}` ||
+      (page === 'result' && link.getAttribute('href') === '#results'));
+  });
+
+  switch (page) {
+    case 'results': loadResults(false); break;
+    case 'result': loadResult(id); break;
+    case 'references': loadReferences(); break;
+    case 'settings': {
+      const form = document.getElementById('settings-form');
+      form.api_key.value = settings.apiKey;
+      form.admin_token.value = settings.adminToken;
+      break;
+    }
+    default: break;
+  }
+}
+
+document.getElementById('evaluate-form').addEventListener('submit', submitEvaluation);
+document.getElementById('results-form').addEventListener('submit', (event) => { event.preventDefault(); loadResults(false); });
+document.getElementById('results-more').addEventListener('click', () => loadResults(true));
+document.getElementById('reference-form').addEventListener('submit', submitReference);
+document.getElementById('settings-form').addEventListener('submit', saveSettings);
+window.addEventListener('hashchange', route);
+route();
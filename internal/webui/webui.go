@@ -0,0 +1,30 @@
+// Package webui is the admin web UI served at /admin: a static page that
+// drives the HTTP API from the browser, for users who won't use curl.
+package webui
+
+import (
+	"embed"
+	"io/fs"
+	"net/http"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/filesystem"
+)
+
+//go:embed static
+var static embed.FS
+
+// Handler serves the UI. The page calls the API with the API key and admin
+// token the user enters, so it needs no authentication of its own.
+func Handler() fiber.Handler {
+	root, err := fs.Sub(static, "static")
+	if err != nil {
+		panic(err)
+	}
+
+	return filesystem.New(filesystem.Config{
+		Root:   http.FS(root),
+		Index:  "index.html",
+		MaxAge: 300,
+	})
+}